@@ -0,0 +1,159 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const defaultAudioLevelWindow = 300 * time.Millisecond
+
+// AudioLevel is the level of an audio track measured over one window of the meter
+type AudioLevel struct {
+	// RMS and Peak are linear amplitudes from 0 (silence) to 1 (full scale).
+	// With header extension levels, Peak is the loudest packet in the window.
+	RMS  float64
+	Peak float64
+	// Voice is set when the sender flagged voice activity in the window, header extension levels only
+	Voice     bool
+	UpdatedAt time.Time
+}
+
+// DBFS returns the RMS level in dBFS, -127 for silence
+func (l AudioLevel) DBFS() float64 {
+	if l.RMS <= 0 {
+		return -127
+	}
+	return max(20*math.Log10(l.RMS), -127)
+}
+
+type audioLevelThreshold struct {
+	level    float64
+	above    bool
+	callback func(above bool, level AudioLevel)
+}
+
+// AudioLevelMeter computes RMS and peak levels of an audio track, either from the RFC 6464
+// audio levels of received packets or from decoded PCM samples.
+// Levels are updated once per window, when samples arrive.
+type AudioLevelMeter struct {
+	lock        sync.Mutex
+	window      time.Duration
+	windowStart time.Time
+	sumSquares  float64
+	count       int
+	peak        float64
+	voice       bool
+	level       AudioLevel
+	thresholds  []*audioLevelThreshold
+}
+
+// NewAudioLevelMeter creates a meter measuring over the given window, 300ms when zero
+func NewAudioLevelMeter(window time.Duration) *AudioLevelMeter {
+	if window <= 0 {
+		window = defaultAudioLevelWindow
+	}
+	return &AudioLevelMeter{
+		window: window,
+	}
+}
+
+// Level returns the level of the last complete window, zero when no samples were received for a while,
+// e.g. because the track is muted.
+func (m *AudioLevelMeter) Level() AudioLevel {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if time.Since(m.level.UpdatedAt) > 3*m.window {
+		return AudioLevel{}
+	}
+	return m.level
+}
+
+// OnThreshold calls callback whenever the RMS level crosses level, in either direction.
+func (m *AudioLevelMeter) OnThreshold(level float64, callback func(above bool, level AudioLevel)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.thresholds = append(m.thresholds, &audioLevelThreshold{
+		level:    level,
+		above:    m.level.RMS > level,
+		callback: callback,
+	})
+}
+
+// ObserveLevel adds the audio level of a packet, in -dBov as carried by the RFC 6464 header extension:
+// 0 is the loudest and 127 silence.
+func (m *AudioLevelMeter) ObserveLevel(level uint8, voice bool) {
+	amplitude := 0.0
+	if level < 127 {
+		amplitude = math.Pow(10, -float64(level)/20)
+	}
+	m.observe(time.Now(), amplitude*amplitude, 1, amplitude, voice)
+}
+
+// ObservePCM adds decoded 16-bit PCM samples.
+func (m *AudioLevelMeter) ObservePCM(samples []int16) {
+	if len(samples) == 0 {
+		return
+	}
+	var sumSquares, peak float64
+	for _, s := range samples {
+		v := float64(s) / 32768
+		sumSquares += v * v
+		peak = max(peak, math.Abs(v))
+	}
+	m.observe(time.Now(), sumSquares, len(samples), peak, false)
+}
+
+func (m *AudioLevelMeter) observe(now time.Time, sumSquares float64, count int, peak float64, voice bool) {
+	m.lock.Lock()
+	if m.windowStart.IsZero() {
+		m.windowStart = now
+	}
+	m.sumSquares += sumSquares
+	m.count += count
+	m.peak = max(m.peak, peak)
+	m.voice = m.voice || voice
+	if now.Sub(m.windowStart) < m.window {
+		m.lock.Unlock()
+		return
+	}
+
+	level := AudioLevel{
+		RMS:       math.Sqrt(m.sumSquares / float64(m.count)),
+		Peak:      m.peak,
+		Voice:     m.voice,
+		UpdatedAt: now,
+	}
+	m.level = level
+	m.windowStart = now
+	m.sumSquares, m.count, m.peak, m.voice = 0, 0, 0, false
+
+	var crossed []*audioLevelThreshold
+	for _, t := range m.thresholds {
+		if above := level.RMS > t.level; above != t.above {
+			t.above = above
+			crossed = append(crossed, t)
+		}
+	}
+	m.lock.Unlock()
+
+	for _, t := range crossed {
+		go t.callback(level.RMS > t.level, level)
+	}
+}
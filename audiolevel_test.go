@@ -0,0 +1,73 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudioLevelMeter(t *testing.T) {
+	m := NewAudioLevelMeter(100 * time.Millisecond)
+	crossings := make(chan bool, 10)
+	m.OnThreshold(0.1, func(above bool, level AudioLevel) {
+		crossings <- above
+	})
+
+	start := time.Now()
+	// -6 dBov and -20 dBov packets
+	m.observe(start, 0.25, 1, 0.5, true)
+	m.observe(start.Add(50*time.Millisecond), 0.01, 1, 0.1, false)
+	require.Zero(t, m.Level().RMS, "window not complete")
+	m.observe(start.Add(100*time.Millisecond), 0.25, 1, 0.5, false)
+
+	level := m.level
+	require.InDelta(t, 0.41, level.RMS, 0.01)
+	require.Equal(t, 0.5, level.Peak)
+	require.True(t, level.Voice)
+	require.InDelta(t, -7.7, level.DBFS(), 0.1)
+	select {
+	case above := <-crossings:
+		require.True(t, above)
+	case <-time.After(time.Second):
+		t.Fatal("threshold not crossed")
+	}
+
+	m.observe(start.Add(200*time.Millisecond), 0, 1, 0, false)
+	require.Zero(t, m.level.RMS)
+	select {
+	case above := <-crossings:
+		require.False(t, above)
+	case <-time.After(time.Second):
+		t.Fatal("threshold not crossed")
+	}
+	require.Equal(t, -127.0, m.level.DBFS())
+}
+
+func TestAudioLevelMeterSources(t *testing.T) {
+	m := NewAudioLevelMeter(0)
+	m.ObserveLevel(0, false)
+	require.Equal(t, 1.0, m.peak)
+	m.ObserveLevel(127, false)
+	require.Equal(t, 1.0, m.sumSquares)
+
+	m = NewAudioLevelMeter(0)
+	m.ObservePCM([]int16{16384, -16384, 16384, -16384})
+	require.Equal(t, 0.5, m.peak)
+	require.InDelta(t, 1.0, m.sumSquares, 1e-9)
+	require.Equal(t, 4, m.count)
+}
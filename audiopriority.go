@@ -0,0 +1,250 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const defaultAudioPriorityInterval = time.Second
+
+// AudioPriorityStatus is reported with OnAudioPriorityChanged
+type AudioPriorityStatus struct {
+	// Congested is set while more is sent than is estimated to be available, see BandwidthEstimates
+	Congested bool
+	// PausedLayers are the video layers paused to protect audio, in the order they were paused
+	PausedLayers []PausedVideoLayer
+	// bitrates in bits per second, see BandwidthEstimates
+	AvailableOutgoingBitrate uint64
+	OutgoingBitrate          uint64
+}
+
+// PausedVideoLayer is a layer of a local video track paused by WithAudioPriority
+type PausedVideoLayer struct {
+	TrackSID string
+	Quality  livekit.VideoQuality
+}
+
+// videoLayerCandidate is a layer that may be paused, with its current send bitrate
+type videoLayerCandidate struct {
+	PausedVideoLayer
+	track   *LocalTrack
+	bitrate uint64
+}
+
+// audioPriorityController pauses video layers while the uplink is congested and resumes them
+// once the estimate leaves room for the bitrate they had
+type audioPriorityController struct {
+	lock      sync.Mutex
+	stop      chan struct{}
+	estimator func() BandwidthEstimates
+	congested bool
+	paused    []videoLayerCandidate
+}
+
+func newAudioPriorityController() *audioPriorityController {
+	return &audioPriorityController{}
+}
+
+// start returns the channel closed on close, estimator provides the estimates for check
+func (c *audioPriorityController) start(estimator func() BandwidthEstimates) (chan struct{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stop != nil {
+		return nil, false
+	}
+	c.stop = make(chan struct{})
+	c.estimator = estimator
+	return c.stop, true
+}
+
+// check updates the controller with the current estimates, see update
+func (c *audioPriorityController) check(candidates []videoLayerCandidate) (AudioPriorityStatus, bool) {
+	c.lock.Lock()
+	estimator := c.estimator
+	c.lock.Unlock()
+	if estimator == nil {
+		return AudioPriorityStatus{}, false
+	}
+	return c.update(estimator(), candidates)
+}
+
+// close stops the controller and resumes the paused layers
+func (c *audioPriorityController) close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	for _, l := range c.paused {
+		l.track.congestionPaused.Store(false)
+	}
+	c.paused = nil
+	c.congested = false
+}
+
+// update pauses the first of candidates while congested, or resumes the layer paused last when there is room
+// for it again. candidates are the layers that may be paused, in the order to pause them.
+// Returns the status and whether it changed.
+func (c *audioPriorityController) update(estimates BandwidthEstimates, candidates []videoLayerCandidate) (AudioPriorityStatus, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var changed bool
+	// layers of unpublished tracks are forgotten, the track may be published again
+	present := make(map[*LocalTrack]struct{}, len(candidates))
+	for _, l := range candidates {
+		present[l.track] = struct{}{}
+	}
+	c.paused = slices.DeleteFunc(c.paused, func(l videoLayerCandidate) bool {
+		if _, ok := present[l.track]; ok {
+			return false
+		}
+		l.track.congestionPaused.Store(false)
+		changed = true
+		return true
+	})
+
+	// without an estimate, i.e. before the first TWCC feedback of the server, nothing is changed
+	if available := estimates.AvailableOutgoingBitrate; available != 0 {
+		congested := estimates.OutgoingBitrate > available
+		changed = changed || congested != c.congested
+		c.congested = congested
+
+		if congested {
+			for _, l := range candidates {
+				if l.track.congestionPaused.Load() {
+					continue
+				}
+				l.track.congestionPaused.Store(true)
+				c.paused = append(c.paused, l)
+				changed = true
+				break
+			}
+		} else if n := len(c.paused); n != 0 {
+			last := c.paused[n-1]
+			if available >= estimates.OutgoingBitrate+last.bitrate*5/4 {
+				last.track.congestionPaused.Store(false)
+				c.paused = c.paused[:n-1]
+				changed = true
+			}
+		}
+	}
+
+	status := AudioPriorityStatus{
+		Congested:                c.congested,
+		AvailableOutgoingBitrate: estimates.AvailableOutgoingBitrate,
+		OutgoingBitrate:          estimates.OutgoingBitrate,
+	}
+	for _, l := range c.paused {
+		status.PausedLayers = append(status.PausedLayers, l.PausedVideoLayer)
+	}
+	return status, changed
+}
+
+// audioPriorityCandidates returns the layers of local video tracks that may be paused, higher qualities first.
+// The lowest simulcast layer and tracks without simulcast are only included when allowPause is set.
+func (r *Room) audioPriorityCandidates(allowPause bool) []videoLayerCandidate {
+	var candidates []videoLayerCandidate
+	for _, pub := range r.LocalParticipant.TrackPublications() {
+		lp, ok := pub.(*LocalTrackPublication)
+		if !ok || lp.Kind() != TrackKindVideo {
+			continue
+		}
+		lp.lock.RLock()
+		track, _ := asLocalTrack(lp.track)
+		layers := make([]videoLayerCandidate, 0, len(lp.simulcastTracks))
+		for quality, st := range lp.simulcastTracks {
+			layers = append(layers, videoLayerCandidate{
+				PausedVideoLayer: PausedVideoLayer{TrackSID: lp.SID(), Quality: quality},
+				track:            st,
+			})
+		}
+		lp.lock.RUnlock()
+
+		if len(layers) == 0 && track != nil {
+			// paused with the lowest layers of other tracks
+			layers = append(layers, videoLayerCandidate{
+				PausedVideoLayer: PausedVideoLayer{TrackSID: lp.SID(), Quality: livekit.VideoQuality_LOW},
+				track:            track,
+			})
+		} else {
+			slices.SortFunc(layers, func(a, b videoLayerCandidate) int {
+				return int(a.Quality) - int(b.Quality)
+			})
+		}
+		if !allowPause {
+			layers = layers[min(1, len(layers)):]
+		}
+		candidates = append(candidates, layers...)
+	}
+
+	for i := range candidates {
+		candidates[i].bitrate, _ = candidates[i].track.sendStats.rates()
+	}
+	slices.SortStableFunc(candidates, func(a, b videoLayerCandidate) int {
+		return int(b.Quality) - int(a.Quality)
+	})
+	return candidates
+}
+
+func (r *Room) startAudioPriority() {
+	config := r.engine.connParams.AudioPriority
+	if config == nil {
+		return
+	}
+	stop, ok := r.audioPriority.start(r.engine.BandwidthEstimates)
+	if !ok {
+		return
+	}
+	interval := config.Interval
+	if interval <= 0 {
+		interval = defaultAudioPriorityInterval
+	}
+
+	r.engine.goroutines.Go("audio-priority", func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if r.engine.reconnecting.Load() {
+				continue
+			}
+
+			status, changed := r.audioPriority.check(r.audioPriorityCandidates(config.AllowVideoPause))
+			if changed {
+				r.log.Infow("audio priority changed",
+					"congested", status.Congested,
+					"pausedLayers", len(status.PausedLayers),
+					"available", status.AvailableOutgoingBitrate,
+					"outgoing", status.OutgoingBitrate,
+				)
+				go r.callback.OnAudioPriorityChanged(status)
+			}
+		}
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestAudioPriorityController(t *testing.T) {
+	high, mid := &LocalTrack{}, &LocalTrack{}
+	candidates := []videoLayerCandidate{
+		{PausedVideoLayer: PausedVideoLayer{TrackSID: "TR_video", Quality: livekit.VideoQuality_HIGH}, track: high, bitrate: 1_000_000},
+		{PausedVideoLayer: PausedVideoLayer{TrackSID: "TR_video", Quality: livekit.VideoQuality_MEDIUM}, track: mid, bitrate: 400_000},
+	}
+	c := newAudioPriorityController()
+
+	t.Run("no estimate", func(t *testing.T) {
+		status, changed := c.update(BandwidthEstimates{OutgoingBitrate: 2_000_000}, candidates)
+		require.False(t, changed)
+		require.False(t, status.Congested)
+		require.False(t, high.IsPaused())
+	})
+
+	t.Run("pauses one layer per update", func(t *testing.T) {
+		congested := BandwidthEstimates{AvailableOutgoingBitrate: 1_000_000, OutgoingBitrate: 1_500_000}
+		status, changed := c.update(congested, candidates)
+		require.True(t, changed)
+		require.True(t, status.Congested)
+		require.Equal(t, []PausedVideoLayer{candidates[0].PausedVideoLayer}, status.PausedLayers)
+		require.True(t, high.IsPaused())
+		require.False(t, mid.IsPaused())
+
+		status, changed = c.update(congested, candidates)
+		require.True(t, changed)
+		require.Len(t, status.PausedLayers, 2)
+		require.True(t, mid.IsPaused())
+
+		// nothing left to pause
+		_, changed = c.update(congested, candidates)
+		require.False(t, changed)
+	})
+
+	t.Run("resumes with headroom, last paused first", func(t *testing.T) {
+		// not enough room for the medium layer yet
+		status, changed := c.update(BandwidthEstimates{AvailableOutgoingBitrate: 800_000, OutgoingBitrate: 500_000}, candidates)
+		require.True(t, changed)
+		require.False(t, status.Congested)
+		require.Len(t, status.PausedLayers, 2)
+
+		status, changed = c.update(BandwidthEstimates{AvailableOutgoingBitrate: 1_000_000, OutgoingBitrate: 500_000}, candidates)
+		require.True(t, changed)
+		require.Equal(t, []PausedVideoLayer{candidates[0].PausedVideoLayer}, status.PausedLayers)
+		require.False(t, mid.IsPaused())
+		require.True(t, high.IsPaused())
+	})
+
+	t.Run("forgets unpublished tracks and resumes on close", func(t *testing.T) {
+		status, changed := c.update(BandwidthEstimates{}, candidates[1:])
+		require.True(t, changed)
+		require.Empty(t, status.PausedLayers)
+
+		c.update(BandwidthEstimates{AvailableOutgoingBitrate: 100, OutgoingBitrate: 200}, candidates)
+		require.True(t, high.IsPaused())
+		c.close()
+		require.False(t, high.IsPaused())
+	})
+}
+
+func TestAudioPriorityThresholds(t *testing.T) {
+	track := &LocalTrack{}
+	candidates := []videoLayerCandidate{
+		{PausedVideoLayer: PausedVideoLayer{TrackSID: "TR_video", Quality: livekit.VideoQuality_HIGH}, track: track, bitrate: 400_000},
+	}
+	var estimates BandwidthEstimates
+	c := newAudioPriorityController()
+	_, changed := c.check(candidates)
+	require.False(t, changed, "not started")
+
+	_, ok := c.start(func() BandwidthEstimates { return estimates })
+	require.True(t, ok)
+	defer c.close()
+
+	// sending what is available is not congested
+	estimates = BandwidthEstimates{AvailableOutgoingBitrate: 1_000_000, OutgoingBitrate: 1_000_000}
+	status, _ := c.check(candidates)
+	require.False(t, status.Congested)
+	require.False(t, track.IsPaused())
+
+	estimates.OutgoingBitrate++
+	status, changed = c.check(candidates)
+	require.True(t, changed)
+	require.True(t, status.Congested)
+	require.True(t, track.IsPaused())
+
+	// resumed once the estimate leaves room for the layer's bitrate and a quarter more
+	estimates = BandwidthEstimates{AvailableOutgoingBitrate: 600_000 + 500_000 - 1, OutgoingBitrate: 600_000}
+	status, _ = c.check(candidates)
+	require.False(t, status.Congested)
+	require.True(t, track.IsPaused())
+
+	estimates.AvailableOutgoingBitrate++
+	status, changed = c.check(candidates)
+	require.True(t, changed)
+	require.Empty(t, status.PausedLayers)
+	require.False(t, track.IsPaused())
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"slices"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+// clientAutoSubscribes returns whether the SDK has to subscribe to a new publication itself
+func (e *RTCEngine) clientAutoSubscribes(identity string, pub TrackPublication) bool {
+	if e.connParams == nil || !e.connParams.AutoSubscribe || e.connParams.ServerAutoSubscribe() {
+		return false
+	}
+	if e.connParams.AudioOnly && pub.Kind() != TrackKindAudio {
+		return false
+	}
+	return autoSubscribeMatches(e.connParams.AutoSubscribeFilter, identity, pub)
+}
+
+func autoSubscribeMatches(filter *signalling.AutoSubscribeFilter, identity string, pub TrackPublication) bool {
+	if filter == nil {
+		return true
+	}
+	if len(filter.Kinds) > 0 && !slices.Contains(filter.Kinds, pub.Kind().ProtoType()) {
+		return false
+	}
+	if len(filter.Sources) > 0 && !slices.Contains(filter.Sources, pub.Source()) {
+		return false
+	}
+	if slices.Contains(filter.ExcludeSources, pub.Source()) {
+		return false
+	}
+	if len(filter.Identities) > 0 && !slices.Contains(filter.Identities, identity) {
+		return false
+	}
+	return true
+}
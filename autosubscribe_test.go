@@ -0,0 +1,65 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestClientAutoSubscribes(t *testing.T) {
+	newPub := func(kind livekit.TrackType, source livekit.TrackSource) TrackPublication {
+		pub := &RemoteTrackPublication{}
+		pub.updateInfo(&livekit.TrackInfo{Sid: "TR_1", Type: kind, Source: source})
+		return pub
+	}
+	mic := newPub(livekit.TrackType_AUDIO, livekit.TrackSource_MICROPHONE)
+	camera := newPub(livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA)
+	screen := newPub(livekit.TrackType_VIDEO, livekit.TrackSource_SCREEN_SHARE)
+
+	// the server subscribes without a filter
+	e := &RTCEngine{connParams: &signalling.ConnectParams{AutoSubscribe: true}}
+	require.True(t, e.connParams.ServerAutoSubscribe())
+	require.False(t, e.clientAutoSubscribes("a", mic))
+
+	e.connParams = &signalling.ConnectParams{AutoSubscribe: true, AudioOnly: true}
+	require.False(t, e.connParams.ServerAutoSubscribe())
+	require.True(t, e.clientAutoSubscribes("a", mic))
+	require.False(t, e.clientAutoSubscribes("a", camera))
+
+	params := &signalling.ConnectParams{}
+	WithAutoSubscribeFilter(AutoSubscribeFilter{
+		ExcludeSources: []livekit.TrackSource{livekit.TrackSource_SCREEN_SHARE},
+		Identities:     []string{"a"},
+	})(params)
+	e.connParams = params
+	require.False(t, params.ServerAutoSubscribe())
+	require.True(t, e.clientAutoSubscribes("a", mic))
+	require.True(t, e.clientAutoSubscribes("a", camera))
+	require.False(t, e.clientAutoSubscribes("a", screen))
+	require.False(t, e.clientAutoSubscribes("b", mic))
+
+	params.AutoSubscribeFilter = &AutoSubscribeFilter{Kinds: []livekit.TrackType{livekit.TrackType_AUDIO}}
+	require.True(t, e.clientAutoSubscribes("b", mic))
+	require.False(t, e.clientAutoSubscribes("b", camera))
+
+	// WithAutoSubscribe(false) disables auto subscribe
+	WithAutoSubscribe(false)(params)
+	require.False(t, e.clientAutoSubscribes("b", mic))
+}
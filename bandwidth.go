@@ -0,0 +1,71 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"time"
+)
+
+// BandwidthEstimates contains bitrates of the connection, in bits per second
+type BandwidthEstimates struct {
+	// available outgoing bitrate, estimated from the TWCC feedback of the server, or as estimated by the server (REMB)
+	// when it sends no TWCC feedback, e.g. with custom interceptors. 0 if there is no estimate yet
+	AvailableOutgoingBitrate uint64
+	// measured bitrate of media sent
+	OutgoingBitrate uint64
+	// measured bitrate of media received
+	IncomingBitrate uint64
+}
+
+// BandwidthEstimates returns the current bandwidth estimates of the publisher and subscriber transports
+func (e *RTCEngine) BandwidthEstimates() BandwidthEstimates {
+	var estimates BandwidthEstimates
+	publisher, hasPublisher := e.Publisher()
+	if hasPublisher {
+		monitor := publisher.BandwidthMonitor()
+		estimates.AvailableOutgoingBitrate = monitor.SendSideEstimate()
+		if estimates.AvailableOutgoingBitrate == 0 {
+			estimates.AvailableOutgoingBitrate = monitor.RemoteEstimate()
+		}
+		estimates.OutgoingBitrate, estimates.IncomingBitrate = monitor.Bitrates()
+	}
+	if subscriber, ok := e.Subscriber(); ok && (!hasPublisher || subscriber != publisher) {
+		_, incoming := subscriber.BandwidthMonitor().Bitrates()
+		estimates.IncomingBitrate += incoming
+	}
+	return estimates
+}
+
+func (e *RTCEngine) startBandwidthEstimatesWorker() {
+	interval := e.connParams.BandwidthEstimatesInterval
+	if interval <= 0 || !e.bandwidthWorkerStarted.CompareAndSwap(false, true) {
+		return
+	}
+
+	e.goroutines.Go("bandwidth-estimates", func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if e.closed.Load() {
+				return
+			}
+			if e.reconnecting.Load() {
+				continue
+			}
+			e.engineHandler.OnBandwidthEstimates(e.BandwidthEstimates())
+		}
+	})
+}
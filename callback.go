@@ -25,10 +25,18 @@ import (
 // Deleted attributes will have empty string value in the changed map.
 type ParticipantAttributesChangedFunc func(changed map[string]string, p Participant)
 
+// ParticipantCallback holds the callbacks of a participant, they are called like the ones of RoomCallback.
+// Events of a remote participant, including its RoomCallback events, are not called from the signal goroutine
+// but from an event queue of the participant.
 type ParticipantCallback struct {
 	// for local participant
 	OnLocalTrackPublished   func(publication *LocalTrackPublication, lp *LocalParticipant)
 	OnLocalTrackUnpublished func(publication *LocalTrackPublication, lp *LocalParticipant)
+	// called after a full reconnect for every track that was published before, see WithAutoRepublish
+	OnLocalTrackRepublished     func(oldPublication, publication *LocalTrackPublication, lp *LocalParticipant)
+	OnLocalTrackRepublishFailed func(oldPublication *LocalTrackPublication, err error, lp *LocalParticipant)
+	// called when the server changes which qualities of a track subscribers need, disabled layers are paused
+	OnLocalTrackSubscribedQualityChanged func(publication *LocalTrackPublication, qualities []*livekit.SubscribedQuality, lp *LocalParticipant)
 
 	// for all participants
 	OnTrackMuted               func(pub TrackPublication, p Participant)
@@ -38,22 +46,42 @@ type ParticipantCallback struct {
 	OnIsSpeakingChanged        func(p Participant)
 	OnConnectionQualityChanged func(update *livekit.ConnectionQualityInfo, p Participant)
 
-	// for remote participants
+	// for remote participants, track and data events of a participant are delivered one at a time
+	// and in order, after OnParticipantConnected and before OnParticipantDisconnected.
+	// OnTrackSubscribed is started in order but runs on a goroutine of the track, so that it can read the track
+	// until it ends, OnTrackUnsubscribed and OnTrackUnpublished of the track are called after it returned.
 	OnTrackSubscribed         func(track *webrtc.TrackRemote, publication *RemoteTrackPublication, rp *RemoteParticipant)
 	OnTrackUnsubscribed       func(track *webrtc.TrackRemote, publication *RemoteTrackPublication, rp *RemoteParticipant)
-	OnTrackSubscriptionFailed func(sid string, rp *RemoteParticipant)
+	OnTrackSubscriptionFailed func(sid string, rp *RemoteParticipant) // Deprecated: Use OnTrackSubscriptionFailedWithError instead
 	OnTrackPublished          func(publication *RemoteTrackPublication, rp *RemoteParticipant)
 	OnTrackUnpublished        func(publication *RemoteTrackPublication, rp *RemoteParticipant)
 	OnDataReceived            func(data []byte, params DataReceiveParams) // Deprecated: Use OnDataPacket instead
 	OnDataPacket              func(data DataPacket, params DataReceiveParams)
 	OnTranscriptionReceived   func(transcriptionSegments []*TranscriptionSegment, p Participant, publication TrackPublication)
+	// called when sip.* attributes of a SIP participant change, e.g. call status
+	OnSIPInfoChanged func(info SIPInfo, rp *RemoteParticipant)
+	// called when attributes, tracks or stream health of an ingress participant change
+	OnIngressInfoChanged func(info IngressInfo, rp *RemoteParticipant)
+	// called once retries are exhausted or the server reports a permanent error, see SubscriptionError
+	OnTrackSubscriptionFailedWithError func(sid string, err error, rp *RemoteParticipant)
+	// called when the layers the server reports for a remote video track change, see AvailableLayers
+	OnTrackLayersChanged func(publication *RemoteTrackPublication, layers []AvailableLayer, rp *RemoteParticipant)
+	// called when the encryption type or key index of a local or remote publication changes, see EncryptionStatus
+	OnEncryptionStatusChanged func(pub TrackPublication, status EncryptionStatus, p Participant)
+	// called when the server switches the codec of a subscribed track, see RemoteTrackPublication.OnCodecChanged
+	// to rebind readers before the first packet of the new codec
+	OnTrackCodecChanged func(track *webrtc.TrackRemote, publication *RemoteTrackPublication, codec webrtc.RTPCodecParameters, rp *RemoteParticipant)
 }
 
 // NewParticipantCallback creates a new ParticipantCallback with default no-op handlers.
 func NewParticipantCallback() *ParticipantCallback {
 	return &ParticipantCallback{
-		OnLocalTrackPublished:   func(publication *LocalTrackPublication, lp *LocalParticipant) {},
-		OnLocalTrackUnpublished: func(publication *LocalTrackPublication, lp *LocalParticipant) {},
+		OnLocalTrackPublished:       func(publication *LocalTrackPublication, lp *LocalParticipant) {},
+		OnLocalTrackUnpublished:     func(publication *LocalTrackPublication, lp *LocalParticipant) {},
+		OnLocalTrackRepublished:     func(oldPublication, publication *LocalTrackPublication, lp *LocalParticipant) {},
+		OnLocalTrackRepublishFailed: func(oldPublication *LocalTrackPublication, err error, lp *LocalParticipant) {},
+
+		OnLocalTrackSubscribedQualityChanged: func(pub *LocalTrackPublication, qualities []*livekit.SubscribedQuality, lp *LocalParticipant) {},
 
 		OnTrackMuted:               func(pub TrackPublication, p Participant) {},
 		OnTrackUnmuted:             func(pub TrackPublication, p Participant) {},
@@ -69,6 +97,15 @@ func NewParticipantCallback() *ParticipantCallback {
 		OnDataReceived:             func(data []byte, params DataReceiveParams) {},
 		OnDataPacket:               func(data DataPacket, params DataReceiveParams) {},
 		OnTranscriptionReceived:    func(transcriptionSegments []*TranscriptionSegment, p Participant, publication TrackPublication) {},
+
+		OnSIPInfoChanged:     func(info SIPInfo, rp *RemoteParticipant) {},
+		OnIngressInfoChanged: func(info IngressInfo, rp *RemoteParticipant) {},
+
+		OnTrackSubscriptionFailedWithError: func(sid string, err error, rp *RemoteParticipant) {},
+		OnTrackLayersChanged:               func(publication *RemoteTrackPublication, layers []AvailableLayer, rp *RemoteParticipant) {},
+		OnEncryptionStatusChanged:          func(pub TrackPublication, status EncryptionStatus, p Participant) {},
+		OnTrackCodecChanged: func(track *webrtc.TrackRemote, publication *RemoteTrackPublication, codec webrtc.RTPCodecParameters, rp *RemoteParticipant) {
+		},
 	}
 }
 
@@ -80,6 +117,15 @@ func (cb *ParticipantCallback) Merge(other *ParticipantCallback) {
 	if other.OnLocalTrackUnpublished != nil {
 		cb.OnLocalTrackUnpublished = other.OnLocalTrackUnpublished
 	}
+	if other.OnLocalTrackRepublished != nil {
+		cb.OnLocalTrackRepublished = other.OnLocalTrackRepublished
+	}
+	if other.OnLocalTrackRepublishFailed != nil {
+		cb.OnLocalTrackRepublishFailed = other.OnLocalTrackRepublishFailed
+	}
+	if other.OnLocalTrackSubscribedQualityChanged != nil {
+		cb.OnLocalTrackSubscribedQualityChanged = other.OnLocalTrackSubscribedQualityChanged
+	}
 	if other.OnTrackMuted != nil {
 		cb.OnTrackMuted = other.OnTrackMuted
 	}
@@ -122,6 +168,24 @@ func (cb *ParticipantCallback) Merge(other *ParticipantCallback) {
 	if other.OnTranscriptionReceived != nil {
 		cb.OnTranscriptionReceived = other.OnTranscriptionReceived
 	}
+	if other.OnSIPInfoChanged != nil {
+		cb.OnSIPInfoChanged = other.OnSIPInfoChanged
+	}
+	if other.OnIngressInfoChanged != nil {
+		cb.OnIngressInfoChanged = other.OnIngressInfoChanged
+	}
+	if other.OnTrackSubscriptionFailedWithError != nil {
+		cb.OnTrackSubscriptionFailedWithError = other.OnTrackSubscriptionFailedWithError
+	}
+	if other.OnTrackLayersChanged != nil {
+		cb.OnTrackLayersChanged = other.OnTrackLayersChanged
+	}
+	if other.OnEncryptionStatusChanged != nil {
+		cb.OnEncryptionStatusChanged = other.OnEncryptionStatusChanged
+	}
+	if other.OnTrackCodecChanged != nil {
+		cb.OnTrackCodecChanged = other.OnTrackCodecChanged
+	}
 }
 
 type DisconnectionReason string
@@ -135,11 +199,21 @@ const (
 	ParticipantRemoved DisconnectionReason = "removed by server"
 	DuplicateIdentity  DisconnectionReason = "duplicate identity"
 	OtherReason        DisconnectionReason = "other reasons"
+
+	// TokenExpired is used when the connection could not be re-established because the token expired
+	TokenExpired    DisconnectionReason = "token expired"
+	RoomDeleted     DisconnectionReason = "room deleted"
+	ServerShutdown  DisconnectionReason = "server shutdown"
+	SIPTrunkFailure DisconnectionReason = "sip trunk failure"
+	// JoinFailure is used when joining failed, Failed when an established connection was lost
+	JoinFailure       DisconnectionReason = "join failed"
+	ConnectionTimeout DisconnectionReason = "connection timeout"
+	MediaFailure      DisconnectionReason = "media failure"
 )
 
 // GetDisconnectionReason converts a protocol disconnect reason to a DisconnectionReason.
+// DisconnectionError keeps the original reason.
 func GetDisconnectionReason(reason livekit.DisconnectReason) DisconnectionReason {
-	// TODO: SDK should forward the original reason and provide helpers like IsRequestedLeave.
 	r := OtherReason
 	switch reason {
 	case livekit.DisconnectReason_CLIENT_INITIATED:
@@ -154,17 +228,35 @@ func GetDisconnectionReason(reason livekit.DisconnectReason) DisconnectionReason
 		r = ParticipantRemoved
 	case livekit.DisconnectReason_DUPLICATE_IDENTITY:
 		r = DuplicateIdentity
-	case livekit.DisconnectReason_JOIN_FAILURE, livekit.DisconnectReason_SIGNAL_CLOSE, livekit.DisconnectReason_STATE_MISMATCH:
+	case livekit.DisconnectReason_ROOM_DELETED:
+		r = RoomDeleted
+	case livekit.DisconnectReason_SERVER_SHUTDOWN:
+		r = ServerShutdown
+	case livekit.DisconnectReason_SIP_TRUNK_FAILURE:
+		r = SIPTrunkFailure
+	case livekit.DisconnectReason_JOIN_FAILURE:
+		r = JoinFailure
+	case livekit.DisconnectReason_CONNECTION_TIMEOUT:
+		r = ConnectionTimeout
+	case livekit.DisconnectReason_MEDIA_FAILURE:
+		r = MediaFailure
+	case livekit.DisconnectReason_SIGNAL_CLOSE, livekit.DisconnectReason_STATE_MISMATCH:
 		r = Failed
 	}
 	return r
 }
 
+// RoomCallback holds the callbacks of a room, unset ones do nothing.
+// Events of a remote participant are called from a goroutine of the participant, one at a time and in order, see
+// ParticipantCallback, the others from the goroutine that handles the signal message or from a worker of the room.
+// Callbacks must not block those goroutines for long, and may be called concurrently with each other.
 type RoomCallback struct {
 	OnDisconnected            func()
 	OnDisconnectedWithReason  func(reason DisconnectionReason)
 	OnParticipantConnected    func(*RemoteParticipant)
 	OnParticipantDisconnected func(*RemoteParticipant)
+	OnAgentConnected          func(*RemoteParticipant) // in addition to OnParticipantConnected
+	OnAgentDisconnected       func(*RemoteParticipant) // in addition to OnParticipantDisconnected
 	OnActiveSpeakersChanged   func([]Participant)
 	OnRoomMetadataChanged     func(metadata string)
 	OnRecordingStatusChanged  func(isRecording bool)
@@ -172,6 +264,44 @@ type RoomCallback struct {
 	OnReconnecting            func()
 	OnReconnected             func()
 	OnLocalTrackSubscribed    func(publication *LocalTrackPublication, lp *LocalParticipant)
+	// called whenever Room.IsRecording changes, and once after joining a room that is already being recorded
+	OnIsRecordingChanged func(isRecording bool)
+	// called when the selected ICE candidate pair of a transport changes, e.g. when media moves from UDP to TURN/TCP
+	OnActiveCandidatePairChanged func(target livekit.SignalTarget, local, remote ICECandidateInfo)
+	// called periodically when enabled with WithBandwidthEstimatesInterval, one call at a time,
+	// estimates that were updated while the callback ran are skipped in favor of the latest
+	OnBandwidthEstimatesUpdated func(estimates BandwidthEstimates)
+	// called when an offer is not answered in time or an answer could not be sent, see WithNegotiationTimeout
+	OnNegotiationStalled func(target livekit.SignalTarget, recovery NegotiationRecovery, err error)
+	// called when a participant stops or resumes sending presence heartbeats, see WithPresenceHeartbeat
+	OnParticipantLivenessChanged func(rp *RemoteParticipant, alive bool)
+	// called when packets of a participant are dropped by WithInboundRateLimits, at most once per second and kind
+	OnRateLimited func(identity string, kind RateLimitKind)
+	// called when an incoming data stream is rejected by WithStreamGuard
+	OnDataStreamRejected func(err *StreamRejectedError, participantIdentity string)
+	// called with the digits a participant entered once a DTMF sequence is complete, see WithDTMFSequence
+	OnDTMFSequence func(identity string, digits string)
+	// called once after joining with the participants already in the room, empty when alone
+	OnParticipantSnapshot func(participants []*RemoteParticipant)
+	// called with OnDisconnectedWithReason, err holds the server reason and the errors that caused the disconnect
+	OnDisconnectedWithError func(err *DisconnectionError)
+	// called when the state of the budget set with WithSubscriptionBudget changes
+	OnSubscriptionBudgetChanged func(status SubscriptionBudgetStatus)
+	// called when WithAudioPriority pauses or resumes video layers, or congestion starts or ends
+	OnAudioPriorityChanged func(status AudioPriorityStatus)
+	// called periodically when enabled with WithStatsReportInterval, one call at a time,
+	// reports that were collected while the callback ran are skipped in favor of the latest
+	OnStatsReport func(report StatsReport)
+	// called on every change of Room.ConnectionState, before OnReconnecting, OnReconnected and OnDisconnected
+	OnConnectionStateChanged func(state, previous ConnectionState)
+	// called with non-fatal internal errors, at most 10 times per second and category.
+	// The first error after others were dropped is a *SuppressedErrorsError
+	OnError func(err error, category ErrorCategory)
+	// called for reliable packets once they left the local data channel or were confirmed by the server on resume,
+	// err is ErrDataNotSent when they were dropped with a full reconnect or close, see WithDataPublishSequence.
+	// A packet that left the data channel may still be lost if the connection drops before it reached the server,
+	// the server acknowledges packets only when the connection is resumed.
+	OnDataSent func(sequence uint32, err error)
 
 	// participant events are sent to the room as well
 	ParticipantCallback
@@ -187,13 +317,32 @@ func NewRoomCallback() *RoomCallback {
 		OnDisconnectedWithReason:  func(reason DisconnectionReason) {},
 		OnParticipantConnected:    func(participant *RemoteParticipant) {},
 		OnParticipantDisconnected: func(participant *RemoteParticipant) {},
+		OnAgentConnected:          func(participant *RemoteParticipant) {},
+		OnAgentDisconnected:       func(participant *RemoteParticipant) {},
 		OnActiveSpeakersChanged:   func(participants []Participant) {},
 		OnRoomMetadataChanged:     func(metadata string) {},
 		OnRecordingStatusChanged:  func(isRecording bool) {},
+		OnIsRecordingChanged:      func(isRecording bool) {},
 		OnRoomMoved:               func(roomName string, token string) {},
 		OnReconnecting:            func() {},
 		OnReconnected:             func() {},
 		OnLocalTrackSubscribed:    func(publication *LocalTrackPublication, lp *LocalParticipant) {},
+
+		OnActiveCandidatePairChanged: func(target livekit.SignalTarget, local, remote ICECandidateInfo) {},
+		OnBandwidthEstimatesUpdated:  func(estimates BandwidthEstimates) {},
+		OnNegotiationStalled:         func(target livekit.SignalTarget, recovery NegotiationRecovery, err error) {},
+		OnParticipantLivenessChanged: func(rp *RemoteParticipant, alive bool) {},
+		OnRateLimited:                func(identity string, kind RateLimitKind) {},
+		OnDataStreamRejected:         func(err *StreamRejectedError, participantIdentity string) {},
+		OnDTMFSequence:               func(identity string, digits string) {},
+		OnParticipantSnapshot:        func(participants []*RemoteParticipant) {},
+		OnDisconnectedWithError:      func(err *DisconnectionError) {},
+		OnSubscriptionBudgetChanged:  func(status SubscriptionBudgetStatus) {},
+		OnAudioPriorityChanged:       func(status AudioPriorityStatus) {},
+		OnStatsReport:                func(report StatsReport) {},
+		OnConnectionStateChanged:     func(state, previous ConnectionState) {},
+		OnError:                      func(err error, category ErrorCategory) {},
+		OnDataSent:                   func(sequence uint32, err error) {},
 	}
 }
 
@@ -215,6 +364,12 @@ func (cb *RoomCallback) Merge(other *RoomCallback) {
 	if other.OnParticipantDisconnected != nil {
 		cb.OnParticipantDisconnected = other.OnParticipantDisconnected
 	}
+	if other.OnAgentConnected != nil {
+		cb.OnAgentConnected = other.OnAgentConnected
+	}
+	if other.OnAgentDisconnected != nil {
+		cb.OnAgentDisconnected = other.OnAgentDisconnected
+	}
 	if other.OnActiveSpeakersChanged != nil {
 		cb.OnActiveSpeakersChanged = other.OnActiveSpeakersChanged
 	}
@@ -224,6 +379,9 @@ func (cb *RoomCallback) Merge(other *RoomCallback) {
 	if other.OnRecordingStatusChanged != nil {
 		cb.OnRecordingStatusChanged = other.OnRecordingStatusChanged
 	}
+	if other.OnIsRecordingChanged != nil {
+		cb.OnIsRecordingChanged = other.OnIsRecordingChanged
+	}
 	if other.OnReconnecting != nil {
 		cb.OnReconnecting = other.OnReconnecting
 	}
@@ -233,6 +391,51 @@ func (cb *RoomCallback) Merge(other *RoomCallback) {
 	if other.OnLocalTrackSubscribed != nil {
 		cb.OnLocalTrackSubscribed = other.OnLocalTrackSubscribed
 	}
+	if other.OnActiveCandidatePairChanged != nil {
+		cb.OnActiveCandidatePairChanged = other.OnActiveCandidatePairChanged
+	}
+	if other.OnBandwidthEstimatesUpdated != nil {
+		cb.OnBandwidthEstimatesUpdated = other.OnBandwidthEstimatesUpdated
+	}
+	if other.OnNegotiationStalled != nil {
+		cb.OnNegotiationStalled = other.OnNegotiationStalled
+	}
+	if other.OnParticipantLivenessChanged != nil {
+		cb.OnParticipantLivenessChanged = other.OnParticipantLivenessChanged
+	}
+	if other.OnRateLimited != nil {
+		cb.OnRateLimited = other.OnRateLimited
+	}
+	if other.OnDataStreamRejected != nil {
+		cb.OnDataStreamRejected = other.OnDataStreamRejected
+	}
+	if other.OnDTMFSequence != nil {
+		cb.OnDTMFSequence = other.OnDTMFSequence
+	}
+	if other.OnParticipantSnapshot != nil {
+		cb.OnParticipantSnapshot = other.OnParticipantSnapshot
+	}
+	if other.OnDisconnectedWithError != nil {
+		cb.OnDisconnectedWithError = other.OnDisconnectedWithError
+	}
+	if other.OnSubscriptionBudgetChanged != nil {
+		cb.OnSubscriptionBudgetChanged = other.OnSubscriptionBudgetChanged
+	}
+	if other.OnAudioPriorityChanged != nil {
+		cb.OnAudioPriorityChanged = other.OnAudioPriorityChanged
+	}
+	if other.OnStatsReport != nil {
+		cb.OnStatsReport = other.OnStatsReport
+	}
+	if other.OnConnectionStateChanged != nil {
+		cb.OnConnectionStateChanged = other.OnConnectionStateChanged
+	}
+	if other.OnError != nil {
+		cb.OnError = other.OnError
+	}
+	if other.OnDataSent != nil {
+		cb.OnDataSent = other.OnDataSent
+	}
 
 	cb.ParticipantCallback.Merge(&other.ParticipantCallback)
 }
@@ -0,0 +1,117 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"strings"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// OnCodecChanged sets a handler called when the server switches the codec of this subscription, e.g. when it
+// falls back to a backup codec. It runs on the goroutine reading the track, before the first packet of the new
+// codec is returned by TrackRemote.ReadRTP, so readers and writers can be rebound to the new codec in place,
+// e.g. by starting a new recording segment. It must not block.
+func (p *RemoteTrackPublication) OnCodecChanged(handler func(codec webrtc.RTPCodecParameters)) {
+	p.lock.Lock()
+	p.codecChangeHandler = handler
+	p.lock.Unlock()
+}
+
+// Codec returns the codec currently received, empty when not subscribed
+func (p *RemoteTrackPublication) Codec() webrtc.RTPCodecParameters {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.codec
+}
+
+func (p *RemoteTrackPublication) startCodecMonitor(r *webrtc.RTPReceiver, t *webrtc.TrackRemote) {
+	monitor := p.engine.payloadTypeMonitor()
+	if monitor == nil {
+		return
+	}
+	ssrc := uint32(t.SSRC())
+	p.lock.Lock()
+	p.codec = t.Codec()
+	p.codecSSRC = ssrc
+	p.lock.Unlock()
+	monitor.Register(ssrc, func(payloadType uint8) {
+		if codec, ok := receiverCodec(r, payloadType); ok {
+			p.setCodec(codec)
+		}
+	})
+}
+
+func (p *RemoteTrackPublication) stopCodecMonitor() {
+	p.lock.Lock()
+	ssrc := p.codecSSRC
+	p.codecSSRC = 0
+	p.lock.Unlock()
+	if monitor := p.engine.payloadTypeMonitor(); monitor != nil && ssrc != 0 {
+		monitor.Unregister(ssrc)
+	}
+}
+
+// setCodec notifies handlers when the mime type changed, payload type changes within a codec are ignored
+func (p *RemoteTrackPublication) setCodec(codec webrtc.RTPCodecParameters) {
+	p.lock.Lock()
+	if strings.EqualFold(p.codec.MimeType, codec.MimeType) {
+		p.codec = codec
+		p.lock.Unlock()
+		return
+	}
+	old := p.codec
+	p.codec = codec
+	handler := p.codecChangeHandler
+	onTrackCodecChanged := p.onTrackCodecChanged
+	p.lock.Unlock()
+
+	p.engine.log.Infow(
+		"subscribed track codec changed",
+		"trackID", p.SID(),
+		"oldCodec", old.MimeType,
+		"codec", codec.MimeType,
+	)
+	if handler != nil {
+		handler(codec)
+	}
+	if onTrackCodecChanged != nil {
+		onTrackCodecChanged(p, codec)
+	}
+}
+
+// receiverCodec returns the negotiated codec of a payload type
+func receiverCodec(r *webrtc.RTPReceiver, payloadType uint8) (webrtc.RTPCodecParameters, bool) {
+	if r == nil {
+		return webrtc.RTPCodecParameters{}, false
+	}
+	for _, codec := range r.GetParameters().Codecs {
+		if uint8(codec.PayloadType) == payloadType {
+			return codec, true
+		}
+	}
+	return webrtc.RTPCodecParameters{}, false
+}
+
+func (p *RemoteParticipant) onTrackCodecChanged(pub *RemoteTrackPublication, codec webrtc.RTPCodecParameters) {
+	track := pub.TrackRemote()
+	if track == nil {
+		return
+	}
+	p.events.enqueue(func() {
+		p.Callback.OnTrackCodecChanged(track, pub, codec, p)
+		p.roomCallback.OnTrackCodecChanged(track, pub, codec, p)
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteTrackPublicationCodecChange(t *testing.T) {
+	vp8 := webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, PayloadType: 96}
+	h264 := webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264}, PayloadType: 102}
+
+	var handled, notified []webrtc.RTPCodecParameters
+	pub := &RemoteTrackPublication{codec: vp8}
+	pub.engine = &RTCEngine{log: logger}
+	pub.onTrackCodecChanged = func(_ *RemoteTrackPublication, codec webrtc.RTPCodecParameters) {
+		notified = append(notified, codec)
+	}
+	pub.OnCodecChanged(func(codec webrtc.RTPCodecParameters) {
+		// handler runs before the participant is notified
+		require.Len(t, notified, len(handled))
+		handled = append(handled, codec)
+	})
+
+	// another payload type of the same codec
+	vp8Alt := vp8
+	vp8Alt.PayloadType = 98
+	pub.setCodec(vp8Alt)
+	require.Empty(t, handled)
+	require.Equal(t, vp8Alt, pub.Codec())
+
+	pub.setCodec(h264)
+	require.Equal(t, []webrtc.RTPCodecParameters{h264}, handled)
+	require.Equal(t, []webrtc.RTPCodecParameters{h264}, notified)
+	require.Equal(t, h264, pub.Codec())
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4"
+)
+
+const codecNegotiationPollInterval = 50 * time.Millisecond
+
+func (p *LocalParticipant) publishTrackWithFallback(track webrtc.TrackLocal, opts *TrackPublicationOptions, pubOptions *LocalTrackPublishOptions) (*LocalTrackPublication, error) {
+	primary, ok := track.(TrackLocalWithCodec)
+	if !ok {
+		return nil, ErrMissingPrimaryCodec
+	}
+	if opts == nil {
+		opts = &TrackPublicationOptions{}
+	}
+
+	candidates := append([]TrackLocalWithCodec{primary}, pubOptions.codecFallbacks...)
+	var lastErr error
+	for i, candidate := range candidates {
+		pub, err := p.publishTrack(candidate, opts, pubOptions)
+		if err == nil {
+			if err = p.waitForCodecNegotiated(candidate, pubOptions.getPublishTimeout()); err == nil {
+				return pub, nil
+			}
+			p.removeFallbackPublication(pub)
+		}
+		if !errors.Is(err, ErrCodecNotNegotiated) && !errors.Is(err, ErrTrackPublishTimeout) {
+			return nil, err
+		}
+		lastErr = err
+		if i < len(candidates)-1 {
+			p.log.Infow("codec not negotiated, publishing fallback",
+				"name", opts.Name, "codec", candidate.Codec().MimeType, "fallback", candidates[i+1].Codec().MimeType)
+		}
+	}
+	return nil, lastErr
+}
+
+// waitForCodecNegotiated waits for the answer covering the track and checks that it accepted the track's codec
+func (p *LocalParticipant) waitForCodecNegotiated(track TrackLocalWithCodec, timeout time.Duration) error {
+	transport := p.getPublishTransport()
+	if transport == nil {
+		return ErrNoPeerConnection
+	}
+	pc := transport.PeerConnection()
+	mimeType := track.Codec().MimeType
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if pc.SignalingState() == webrtc.SignalingStateStable {
+			var mid string
+			for _, tr := range pc.GetTransceivers() {
+				if tr.Sender() != nil && tr.Sender().Track() == track {
+					mid = tr.Mid()
+					break
+				}
+			}
+			if rd := pc.CurrentRemoteDescription(); mid != "" && rd != nil {
+				found, negotiated, err := codecNegotiated(rd.SDP, mid, mimeType)
+				if err != nil {
+					return err
+				}
+				if found && negotiated {
+					return nil
+				}
+				if found {
+					return fmt.Errorf("%w: %s", ErrCodecNotNegotiated, mimeType)
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: no answer for %s", ErrCodecNotNegotiated, mimeType)
+		}
+		time.Sleep(codecNegotiationPollInterval)
+	}
+}
+
+// codecNegotiated looks up the media section of mid in an answer, found is false when the answer does not cover it yet
+func codecNegotiated(answer string, mid string, mimeType string) (found bool, negotiated bool, err error) {
+	parsed := &sdp.SessionDescription{}
+	if err := parsed.UnmarshalString(answer); err != nil {
+		return false, false, err
+	}
+	_, codecName, _ := strings.Cut(mimeType, "/")
+	for _, m := range parsed.MediaDescriptions {
+		if v, _ := m.Attribute(sdp.AttrKeyMID); v != mid {
+			continue
+		}
+		if m.MediaName.Port.Value == 0 {
+			// rejected media section
+			return true, false, nil
+		}
+		for _, attr := range m.Attributes {
+			if attr.Key != "rtpmap" {
+				continue
+			}
+			// e.g. 96 H264/90000
+			payloadType, encoding, _ := strings.Cut(attr.Value, " ")
+			name, _, _ := strings.Cut(encoding, "/")
+			if strings.EqualFold(name, codecName) && slices.Contains(m.MediaName.Formats, payloadType) {
+				return true, true, nil
+			}
+		}
+		return true, false, nil
+	}
+	return false, false, nil
+}
+
+// removeFallbackPublication unpublishes a track whose codec was not negotiated without closing it
+func (p *LocalParticipant) removeFallbackPublication(pub *LocalTrackPublication) {
+	p.tracks.Delete(pub.SID())
+	p.audioTracks.Delete(pub.SID())
+	p.videoTracks.Delete(pub.SID())
+
+	if transport := p.getPublishTransport(); transport != nil {
+		p.abortPublish(pub, transport)
+	}
+
+	p.Callback.OnLocalTrackUnpublished(pub, p)
+	p.roomCallback.OnLocalTrackUnpublished(pub, p)
+}
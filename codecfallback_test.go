@@ -0,0 +1,58 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecNegotiated(t *testing.T) {
+	answer := "v=0\r\n" +
+		"o=- 1 2 IN IP4 127.0.0.1\r\n" +
+		"s=-\r\n" +
+		"t=0 0\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96 97\r\n" +
+		"c=IN IP4 0.0.0.0\r\n" +
+		"a=mid:0\r\n" +
+		"a=rtpmap:96 VP8/90000\r\n" +
+		"a=rtpmap:97 rtx/90000\r\n" +
+		"m=video 0 UDP/TLS/RTP/SAVPF 102\r\n" +
+		"c=IN IP4 0.0.0.0\r\n" +
+		"a=mid:1\r\n" +
+		"a=rtpmap:102 H264/90000\r\n"
+
+	found, negotiated, err := codecNegotiated(answer, "0", webrtc.MimeTypeVP8)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, negotiated)
+
+	found, negotiated, err = codecNegotiated(answer, "0", webrtc.MimeTypeH264)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.False(t, negotiated)
+
+	// rejected media section
+	found, negotiated, err = codecNegotiated(answer, "1", webrtc.MimeTypeH264)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.False(t, negotiated)
+
+	found, _, err = codecNegotiated(answer, "2", webrtc.MimeTypeVP8)
+	require.NoError(t, err)
+	require.False(t, found)
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"github.com/pion/webrtc/v4"
+)
+
+// ICECandidateInfo describes one end of an ICE candidate pair
+type ICECandidateInfo struct {
+	Address  string
+	Port     uint16
+	Protocol webrtc.ICEProtocol
+	Type     webrtc.ICECandidateType
+	// protocol used to reach the TURN server (udp, tcp or tls), set for local relay candidates only
+	RelayProtocol string
+
+	// base address of server reflexive and relay candidates
+	RelatedAddress string
+}
+
+// IsRelay returns true when media goes through a TURN server
+func (c ICECandidateInfo) IsRelay() bool {
+	return c.Type == webrtc.ICECandidateTypeRelay
+}
+
+// ICECandidatePairInfo is the candidate pair selected for a transport
+type ICECandidatePairInfo struct {
+	Local  ICECandidateInfo
+	Remote ICECandidateInfo
+}
+
+// ConnectionDetails contains the currently selected candidate pair of each transport,
+// nil for transports that are not connected.
+type ConnectionDetails struct {
+	Publisher  *ICECandidatePairInfo
+	Subscriber *ICECandidatePairInfo
+}
+
+func newICECandidatePairInfo(pair *webrtc.ICECandidatePair, pc *webrtc.PeerConnection) *ICECandidatePairInfo {
+	if pair == nil || pair.Local == nil || pair.Remote == nil {
+		return nil
+	}
+
+	info := &ICECandidatePairInfo{
+		Local:  newICECandidateInfo(pair.Local),
+		Remote: newICECandidateInfo(pair.Remote),
+	}
+	if info.Local.IsRelay() && pc != nil {
+		// relay protocol is only available in stats
+		for _, s := range pc.GetStats() {
+			cs, ok := s.(webrtc.ICECandidateStats)
+			if !ok || cs.Type != webrtc.StatsTypeLocalCandidate {
+				continue
+			}
+			if cs.IP == pair.Local.Address && uint16(cs.Port) == pair.Local.Port && cs.CandidateType == pair.Local.Typ {
+				info.Local.RelayProtocol = cs.RelayProtocol
+				break
+			}
+		}
+	}
+	return info
+}
+
+func newICECandidateInfo(c *webrtc.ICECandidate) ICECandidateInfo {
+	return ICECandidateInfo{
+		Address:  c.Address,
+		Port:     c.Port,
+		Protocol: c.Protocol,
+		Type:     c.Typ,
+
+		RelatedAddress: c.RelatedAddress,
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestICECandidatePairInfo(t *testing.T) {
+	require.Nil(t, newICECandidatePairInfo(nil, nil))
+	require.Nil(t, newICECandidatePairInfo(&webrtc.ICECandidatePair{Local: &webrtc.ICECandidate{}}, nil))
+
+	info := newICECandidatePairInfo(&webrtc.ICECandidatePair{
+		Local:  &webrtc.ICECandidate{Address: "10.0.0.1", Port: 50000, Protocol: webrtc.ICEProtocolUDP, Typ: webrtc.ICECandidateTypeRelay},
+		Remote: &webrtc.ICECandidate{Address: "192.0.2.1", Port: 7882, Protocol: webrtc.ICEProtocolTCP, Typ: webrtc.ICECandidateTypeHost},
+	}, nil)
+	require.Equal(t, ICECandidateInfo{Address: "10.0.0.1", Port: 50000, Protocol: webrtc.ICEProtocolUDP, Type: webrtc.ICECandidateTypeRelay}, info.Local)
+	require.True(t, info.Local.IsRelay())
+	require.Equal(t, webrtc.ICEProtocolTCP, info.Remote.Protocol)
+	require.False(t, info.Remote.IsRelay())
+}
+
+func TestConnectionDetails(t *testing.T) {
+	type change struct {
+		target        livekit.SignalTarget
+		local, remote ICECandidateInfo
+	}
+	changes := make(chan change, 4)
+	cb := NewRoomCallback()
+	cb.OnActiveCandidatePairChanged = func(target livekit.SignalTarget, local, remote ICECandidateInfo) {
+		changes <- change{target, local, remote}
+	}
+	room := NewRoom(cb)
+	room.engine.connParams = &signalling.ConnectParams{}
+	require.NoError(t, room.engine.configure(nil, nil, nil))
+	t.Cleanup(func() { _ = room.engine.Close(context.Background()) })
+	require.Equal(t, ConnectionDetails{}, room.ConnectionDetails())
+
+	// connect the publisher to a local peer
+	remote, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = remote.Close() })
+	publisher := room.engine.publisher
+	publisher.OnOffer = func(offer webrtc.SessionDescription) {
+		go func() {
+			require.NoError(t, remote.SetRemoteDescription(offer))
+			answer, err := remote.CreateAnswer(nil)
+			require.NoError(t, err)
+			gathered := webrtc.GatheringCompletePromise(remote)
+			require.NoError(t, remote.SetLocalDescription(answer))
+			<-gathered
+			require.NoError(t, publisher.SetRemoteDescription(*remote.LocalDescription()))
+		}()
+	}
+	_, err = publisher.PeerConnection().AddTransceiverFromKind(webrtc.RTPCodecTypeAudio)
+	require.NoError(t, err)
+	require.NoError(t, publisher.createAndSendOffer(nil))
+
+	select {
+	case c := <-changes:
+		require.Equal(t, livekit.SignalTarget_PUBLISHER, c.target)
+		require.Equal(t, webrtc.ICECandidateTypeHost, c.remote.Type)
+		require.NotZero(t, c.local.Port)
+	case <-time.After(5 * time.Second):
+		t.Fatal("candidate pair not selected")
+	}
+
+	details := room.ConnectionDetails()
+	require.NotNil(t, details.Publisher)
+	require.False(t, details.Publisher.Local.IsRelay())
+	require.Nil(t, details.Subscriber)
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"sync"
+)
+
+// IsReconnecting returns true while a lost connection is resumed or restarted
+func (s ConnectionState) IsReconnecting() bool {
+	switch s {
+	case ConnectionStateResuming, ConnectionStateRestarting, ConnectionStateReconnecting:
+		return true
+	default:
+		return false
+	}
+}
+
+// connectionStateMachine holds the connection state and wakes up waiters on every change
+type connectionStateMachine struct {
+	lock  sync.Mutex
+	state ConnectionState
+	// closed and replaced when the state changes
+	changed chan struct{}
+}
+
+func newConnectionStateMachine() *connectionStateMachine {
+	return &connectionStateMachine{
+		state:   ConnectionStateDisconnected,
+		changed: make(chan struct{}),
+	}
+}
+
+func (m *connectionStateMachine) get() ConnectionState {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.state
+}
+
+// set returns the previous state and whether the state changed
+func (m *connectionStateMachine) set(state ConnectionState) (ConnectionState, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	prev := m.state
+	if prev == state {
+		return prev, false
+	}
+	m.state = state
+	close(m.changed)
+	m.changed = make(chan struct{})
+	return prev, true
+}
+
+func (m *connectionStateMachine) wait(ctx context.Context, state ConnectionState) error {
+	for {
+		m.lock.Lock()
+		current, changed := m.state, m.changed
+		m.lock.Unlock()
+
+		if current == state {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// ConnectionState returns the state of the connection, disconnected before joining.
+func (e *RTCEngine) ConnectionState() ConnectionState {
+	if e == nil || e.connState == nil {
+		return ConnectionStateDisconnected
+	}
+	return e.connState.get()
+}
+
+// WaitForState blocks until the connection is in state, or ctx is done.
+// A state that was left again before the waiter woke up may be missed.
+func (e *RTCEngine) WaitForState(ctx context.Context, state ConnectionState) error {
+	return e.connState.wait(ctx, state)
+}
+
+func (r *Room) setConnectionState(state ConnectionState) {
+	prev, changed := r.engine.connState.set(state)
+	if !changed {
+		return
+	}
+	r.log.Debugw("connection state changed", "state", state, "previous", prev)
+	r.callback.OnConnectionStateChanged(state, prev)
+}
+
+// WaitForState blocks until the room is in state, e.g. ConnectionStateConnected after a reconnect, or ctx is done.
+func (r *Room) WaitForState(ctx context.Context, state ConnectionState) error {
+	return r.engine.WaitForState(ctx, state)
+}
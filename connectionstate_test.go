@@ -0,0 +1,82 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionStateMachine(t *testing.T) {
+	m := newConnectionStateMachine()
+	require.Equal(t, ConnectionStateDisconnected, m.get())
+	require.NoError(t, m.wait(context.Background(), ConnectionStateDisconnected))
+
+	prev, changed := m.set(ConnectionStateConnecting)
+	require.True(t, changed)
+	require.Equal(t, ConnectionStateDisconnected, prev)
+	_, changed = m.set(ConnectionStateConnecting)
+	require.False(t, changed)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.wait(context.Background(), ConnectionStateConnected)
+	}()
+	m.set(ConnectionStateResuming)
+	select {
+	case <-done:
+		t.Fatal("wait returned before the state was reached")
+	case <-time.After(20 * time.Millisecond):
+	}
+	m.set(ConnectionStateConnected)
+	require.NoError(t, <-done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, m.wait(ctx, ConnectionStateRestarting), context.DeadlineExceeded)
+}
+
+func TestRoomConnectionStateChanges(t *testing.T) {
+	type change struct{ state, previous ConnectionState }
+	var changes []change
+	room := NewRoom(&RoomCallback{
+		OnConnectionStateChanged: func(state, previous ConnectionState) {
+			changes = append(changes, change{state, previous})
+		},
+		OnReconnecting: func() {
+			require.NotEmpty(t, changes)
+		},
+	})
+
+	room.OnResuming()
+	require.Equal(t, ConnectionStateResuming, room.ConnectionState())
+	require.True(t, room.ConnectionState().IsReconnecting())
+	room.OnRestarting()
+	require.Equal(t, ConnectionStateRestarting, room.ConnectionState())
+	require.True(t, room.ConnectionState().IsReconnecting())
+	require.NoError(t, room.WaitForState(context.Background(), ConnectionStateRestarting))
+
+	room.OnDisconnectedWithError(&DisconnectionError{Reason: Failed})
+	require.Equal(t, ConnectionStateDisconnected, room.ConnectionState())
+	require.False(t, room.ConnectionState().IsReconnecting())
+	require.Equal(t, []change{
+		{ConnectionStateResuming, ConnectionStateDisconnected},
+		{ConnectionStateRestarting, ConnectionStateResuming},
+		{ConnectionStateDisconnected, ConnectionStateRestarting},
+	}, changes)
+}
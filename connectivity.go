@@ -0,0 +1,273 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// ConnectivityCheckName identifies a test run by CheckConnectivity
+type ConnectivityCheckName string
+
+const (
+	// joins with default settings, shows whether the signal connection (WebSocket) can be established at all
+	ConnectivityCheckWebSocket ConnectivityCheckName = "websocket"
+	// joins using UDP candidates only
+	ConnectivityCheckUDP ConnectivityCheckName = "udp"
+	// joins using TCP candidates only
+	ConnectivityCheckTCP ConnectivityCheckName = "tcp"
+	// joins through the TURN servers sent by the server, over TLS only
+	ConnectivityCheckTURNTLS ConnectivityCheckName = "turn_tls"
+	// sends a burst of reliable data and measures how fast it drains
+	ConnectivityCheckBandwidth ConnectivityCheckName = "bandwidth"
+)
+
+var allConnectivityChecks = []ConnectivityCheckName{
+	ConnectivityCheckWebSocket,
+	ConnectivityCheckUDP,
+	ConnectivityCheckTCP,
+	ConnectivityCheckTURNTLS,
+	ConnectivityCheckBandwidth,
+}
+
+const (
+	connectivityCheckTopic = "lk.connectivity-check"
+	// nobody has this identity, the server accepts and drops the bandwidth probe
+	connectivityCheckDestination = "lk.connectivity-check"
+	bandwidthProbeChunkSize      = 15000
+	bandwidthProbeBytes          = 32 * bandwidthProbeChunkSize
+)
+
+// ConnectivityCheckResult is the outcome of a single check
+type ConnectivityCheckResult struct {
+	Name     ConnectivityCheckName
+	Passed   bool
+	Duration time.Duration
+	// selected local and remote candidate types and protocol, e.g. "srflx/udp -> host/udp"
+	CandidatePair string
+	Error         error
+}
+
+// ConnectivityReport is the result of CheckConnectivity
+type ConnectivityReport struct {
+	Checks []ConnectivityCheckResult
+	// measured outgoing bitrate in bits per second, 0 if the bandwidth check did not pass
+	EstimatedBandwidth uint64
+}
+
+// OK returns true when every check passed
+func (r *ConnectivityReport) OK() bool {
+	for _, c := range r.Checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns the result of the named check, false if it was not run
+func (r *ConnectivityReport) Check(name ConnectivityCheckName) (ConnectivityCheckResult, bool) {
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ConnectivityCheckResult{}, false
+}
+
+type connectivityOptions struct {
+	checks       []ConnectivityCheckName
+	checkTimeout time.Duration
+}
+
+type ConnectivityOption func(*connectivityOptions)
+
+// WithConnectivityChecks runs only the given checks, all checks are run by default
+func WithConnectivityChecks(checks ...ConnectivityCheckName) ConnectivityOption {
+	return func(o *connectivityOptions) {
+		o.checks = checks
+	}
+}
+
+// WithConnectivityCheckTimeout limits how long each check may take, 10 seconds by default
+func WithConnectivityCheckTimeout(timeout time.Duration) ConnectivityOption {
+	return func(o *connectivityOptions) {
+		o.checkTimeout = timeout
+	}
+}
+
+// CheckConnectivity runs a series of connectivity tests against a LiveKit server, to diagnose firewalls and
+// proxies before joining real rooms. Every check joins the room of token as a separate connection, so the
+// token should be for a dedicated room and identity and allow publishing data for the bandwidth check.
+//
+// An error is returned only when the checks could not be run, failed checks are part of the report.
+func CheckConnectivity(ctx context.Context, url, token string, opts ...ConnectivityOption) (*ConnectivityReport, error) {
+	o := connectivityOptions{
+		checks:       allConnectivityChecks,
+		checkTimeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if url == "" || token == "" {
+		return nil, ErrInvalidParameter
+	}
+
+	report := &ConnectivityReport{}
+	for _, name := range o.checks {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, o.checkTimeout)
+		result, bandwidth := runConnectivityCheck(checkCtx, name, url, token)
+		cancel()
+		if name == ConnectivityCheckBandwidth && result.Passed {
+			report.EstimatedBandwidth = bandwidth
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report, nil
+}
+
+func connectivityCheckOptions(name ConnectivityCheckName) ([]ConnectOption, error) {
+	opts := []ConnectOption{WithAutoSubscribe(false), WithDisableRegionDiscovery()}
+	switch name {
+	case ConnectivityCheckWebSocket, ConnectivityCheckBandwidth:
+	case ConnectivityCheckUDP:
+		opts = append(opts, WithICENetworkTypes(webrtc.NetworkTypeUDP4, webrtc.NetworkTypeUDP6))
+	case ConnectivityCheckTCP:
+		opts = append(opts, WithICENetworkTypes(webrtc.NetworkTypeTCP4, webrtc.NetworkTypeTCP6))
+	case ConnectivityCheckTURNTLS:
+		opts = append(opts,
+			WithICETransportPolicy(webrtc.ICETransportPolicyRelay),
+			withICEServerFilter(isTURNTLSURL),
+		)
+	default:
+		return nil, fmt.Errorf("%w: unknown connectivity check %q", ErrInvalidParameter, name)
+	}
+	return opts, nil
+}
+
+func isTURNTLSURL(url string) bool {
+	return strings.HasPrefix(url, "turns:")
+}
+
+func runConnectivityCheck(ctx context.Context, name ConnectivityCheckName, url, token string) (ConnectivityCheckResult, uint64) {
+	result := ConnectivityCheckResult{Name: name}
+	opts, err := connectivityCheckOptions(name)
+	if err != nil {
+		result.Error = err
+		return result, 0
+	}
+
+	start := time.Now()
+	room := NewRoom(nil)
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = room.Close(closeCtx)
+	}()
+
+	joined := make(chan error, 1)
+	go func() {
+		joined <- room.joinWithToken(ctx, url, token, opts...)
+	}()
+	select {
+	case err = <-joined:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Error = err
+		return result, 0
+	}
+
+	var bandwidth uint64
+	if name == ConnectivityCheckBandwidth {
+		bandwidth, err = probeBandwidth(ctx, room)
+	}
+	result.Duration = time.Since(start)
+	result.CandidatePair = selectedCandidatePair(room.GetSessionStats())
+	result.Error = err
+	result.Passed = err == nil
+	return result, bandwidth
+}
+
+// probeBandwidth sends a burst of reliable data and measures how fast the SCTP send buffer drains,
+// a rough estimate of the outgoing bandwidth to the server
+func probeBandwidth(ctx context.Context, room *Room) (uint64, error) {
+	chunk := make([]byte, bandwidthProbeChunkSize)
+	start := time.Now()
+	for sent := 0; sent < bandwidthProbeBytes; sent += len(chunk) {
+		if err := room.LocalParticipant.PublishDataPacket(
+			UserData(chunk),
+			WithDataPublishTopic(connectivityCheckTopic),
+			WithDataPublishReliable(true),
+			WithDataPublishDestination([]string{connectivityCheckDestination}),
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	dc := room.engine.GetDataChannel(livekit.DataPacket_RELIABLE)
+	if dc == nil {
+		return 0, errors.New("datachannel not found")
+	}
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for dc.BufferedAmount() > 0 {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	elapsed := time.Since(start)
+	if elapsed <= 0 {
+		return 0, nil
+	}
+	return uint64(float64(bandwidthProbeBytes*8) / elapsed.Seconds()), nil
+}
+
+// selectedCandidatePair describes the nominated candidate pair of the publisher, or the subscriber if
+// there is no publisher
+func selectedCandidatePair(stats SessionStats) string {
+	for _, report := range []webrtc.StatsReport{stats.Publisher, stats.Subscriber} {
+		for _, s := range report {
+			pair, ok := s.(webrtc.ICECandidatePairStats)
+			if !ok || !pair.Nominated || pair.State != webrtc.StatsICECandidatePairStateSucceeded {
+				continue
+			}
+			return describeCandidate(report, pair.LocalCandidateID) + " -> " + describeCandidate(report, pair.RemoteCandidateID)
+		}
+	}
+	return ""
+}
+
+func describeCandidate(report webrtc.StatsReport, id string) string {
+	if c, ok := report[id].(webrtc.ICECandidateStats); ok {
+		return c.CandidateType.String() + "/" + c.Protocol
+	}
+	return "unknown"
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterICEServers(t *testing.T) {
+	servers := []webrtc.ICEServer{
+		{URLs: []string{"stun:stun.example.com:3478"}},
+		{URLs: []string{"turn:turn.example.com:3478?transport=udp", "turns:turn.example.com:443?transport=tcp"}, Username: "user"},
+	}
+	filtered := filterICEServers(servers, isTURNTLSURL)
+	require.Equal(t, []webrtc.ICEServer{
+		{URLs: []string{"turns:turn.example.com:443?transport=tcp"}, Username: "user"},
+	}, filtered)
+	// the input is not modified
+	require.Len(t, servers[1].URLs, 2)
+
+	require.Empty(t, filterICEServers(servers, func(string) bool { return false }))
+}
+
+func TestConnectivityReport(t *testing.T) {
+	report := &ConnectivityReport{Checks: []ConnectivityCheckResult{
+		{Name: ConnectivityCheckUDP, Passed: true},
+		{Name: ConnectivityCheckTCP},
+	}}
+	require.False(t, report.OK())
+	tcp, ok := report.Check(ConnectivityCheckTCP)
+	require.True(t, ok)
+	require.False(t, tcp.Passed)
+	_, ok = report.Check(ConnectivityCheckTURNTLS)
+	require.False(t, ok)
+
+	report.Checks[1].Passed = true
+	require.True(t, report.OK())
+
+	_, err := connectivityCheckOptions("unknown")
+	require.ErrorIs(t, err, ErrInvalidParameter)
+	_, err = CheckConnectivity(t.Context(), "", "")
+	require.ErrorIs(t, err, ErrInvalidParameter)
+}
+
+func TestSelectedCandidatePair(t *testing.T) {
+	report := webrtc.StatsReport{
+		"pair-1": webrtc.ICECandidatePairStats{
+			LocalCandidateID:  "local-1",
+			RemoteCandidateID: "remote-1",
+			State:             webrtc.StatsICECandidatePairStateFailed,
+		},
+		"pair-2": webrtc.ICECandidatePairStats{
+			LocalCandidateID:  "local-2",
+			RemoteCandidateID: "remote-2",
+			State:             webrtc.StatsICECandidatePairStateSucceeded,
+			Nominated:         true,
+		},
+		"local-2":  webrtc.ICECandidateStats{CandidateType: webrtc.ICECandidateTypeRelay, Protocol: "udp"},
+		"remote-2": webrtc.ICECandidateStats{CandidateType: webrtc.ICECandidateTypeHost, Protocol: "udp"},
+	}
+	require.Equal(t, "relay/udp -> host/udp", selectedCandidatePair(SessionStats{Subscriber: report}))
+	require.Empty(t, selectedCandidatePair(SessionStats{}))
+}
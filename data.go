@@ -74,6 +74,7 @@ type dataPublishOptions struct {
 	Reliable              *bool
 	DestinationIdentities []string
 	Topic                 string
+	Sequence              *uint32
 }
 
 type DataPublishOption func(*dataPublishOptions)
@@ -90,6 +91,14 @@ func WithDataPublishReliable(reliable bool) DataPublishOption {
 	}
 }
 
+// WithDataPublishSequence stores the sequence number assigned to a reliable packet in sequence,
+// to match it with OnDataSent. It is 0 for lossy packets.
+func WithDataPublishSequence(sequence *uint32) DataPublishOption {
+	return func(o *dataPublishOptions) {
+		o.Sequence = sequence
+	}
+}
+
 // WithDataPublishDestination sets specific participant identities to send data to.
 // If not set, data will be sent to all participants.
 func WithDataPublishDestination(identities []string) DataPublishOption {
@@ -0,0 +1,140 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// publishers wait for the buffered amount of a data channel to drop to this before sending more
+	dataChannelBufferedAmountLowThreshold = 64 * 1024
+	// buffer status is checked again after this in case an event was missed, e.g. when the data channel was replaced
+	bufferStatusRecheckInterval = time.Second
+)
+
+// bufferLowNotifier wakes up all goroutines waiting for a data channel buffer to drain
+type bufferLowNotifier struct {
+	lock sync.Mutex
+	// closed on notify, created by the first waiter
+	ch chan struct{}
+}
+
+func (n *bufferLowNotifier) wait() <-chan struct{} {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+func (n *bufferLowNotifier) notify() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
+
+func (e *RTCEngine) bufferLowNotifier(kind livekit.DataPacket_Kind) *bufferLowNotifier {
+	if kind == livekit.DataPacket_RELIABLE {
+		return &e.reliableBufferLow
+	}
+	return &e.lossyBufferLow
+}
+
+// watchBufferedAmount notifies waiters of kind when the buffered amount of dc drops below the threshold
+func (e *RTCEngine) watchBufferedAmount(dc *webrtc.DataChannel, kind livekit.DataPacket_Kind) {
+	notifier := e.bufferLowNotifier(kind)
+	dc.SetBufferedAmountLowThreshold(dataChannelBufferedAmountLowThreshold)
+	dc.OnBufferedAmountLow(func() {
+		if kind == livekit.DataPacket_RELIABLE {
+			e.alignReliableSendBuffer(dc)
+		}
+		notifier.notify()
+	})
+	// waiters on a replaced data channel check the new one
+	notifier.notify()
+}
+
+// waitForBufferStatusLowContext blocks until the buffered amount of the data channel of kind is at or below
+// the threshold, the engine is closed or ctx is done
+func (e *RTCEngine) waitForBufferStatusLowContext(ctx context.Context, kind livekit.DataPacket_Kind) error {
+	notifier := e.bufferLowNotifier(kind)
+	var recheck *time.Timer
+	defer func() {
+		if recheck != nil {
+			recheck.Stop()
+		}
+	}()
+
+	for {
+		// wait before checking, so that a notification after the check is not missed
+		low := notifier.wait()
+		dc := e.GetDataChannel(kind)
+		if dc == nil {
+			return ErrDataChannelNotFound
+		}
+		if dc.BufferedAmount() <= dc.BufferedAmountLowThreshold() {
+			return nil
+		}
+
+		if recheck == nil {
+			recheck = time.NewTimer(bufferStatusRecheckInterval)
+		} else {
+			recheck.Reset(bufferStatusRecheckInterval)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-e.closing:
+			return ErrAborted
+		case <-low:
+		case <-recheck.C:
+		}
+	}
+}
+
+// PublishDataContext sends payload as user data like PublishDataPacketContext.
+func (p *LocalParticipant) PublishDataContext(ctx context.Context, payload []byte, opts ...DataPublishOption) error {
+	return p.PublishDataPacketContext(ctx, UserData(payload), opts...)
+}
+
+// PublishDataPacketContext is PublishDataPacket with backpressure: it blocks while more than 64KiB are buffered
+// on the data channel, e.g. when the receiver or the network is slower than the application, and returns
+// ctx.Err() when ctx is done before the packet could be sent.
+func (p *LocalParticipant) PublishDataPacketContext(ctx context.Context, pck DataPacket, opts ...DataPublishOption) error {
+	options := &dataPublishOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if err := p.engine.ensurePublisherConnected(true); err != nil {
+		return err
+	}
+	if err := p.engine.waitForBufferStatusLowContext(ctx, p.dataPacketKind(options)); err != nil {
+		return err
+	}
+	return p.PublishDataPacket(pck, opts...)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestBufferLowNotifier(t *testing.T) {
+	var n bufferLowNotifier
+	// notify without waiters is a no-op
+	n.notify()
+
+	first, second := n.wait(), n.wait()
+	require.Equal(t, first, second)
+	n.notify()
+	for _, ch := range []<-chan struct{}{first, second} {
+		select {
+		case <-ch:
+		default:
+			t.Fatal("waiter was not notified")
+		}
+	}
+
+	next := n.wait()
+	select {
+	case <-next:
+		t.Fatal("waiter of the next drop was notified")
+	default:
+	}
+}
+
+func TestWaitForBufferStatusLow(t *testing.T) {
+	e := &RTCEngine{log: logger}
+	require.ErrorIs(t, e.waitForBufferStatusLowContext(context.Background(), livekit.DataPacket_RELIABLE), ErrDataChannelNotFound)
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close()
+	dc, err := pc.CreateDataChannel(reliableDataChannelName, nil)
+	require.NoError(t, err)
+
+	waiting := e.reliableBufferLow.wait()
+	e.reliableDC = dc
+	e.watchBufferedAmount(dc, livekit.DataPacket_RELIABLE)
+	require.EqualValues(t, dataChannelBufferedAmountLowThreshold, dc.BufferedAmountLowThreshold())
+	select {
+	case <-waiting:
+	case <-time.After(time.Second):
+		t.Fatal("waiters were not notified of the new data channel")
+	}
+
+	// nothing buffered
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, e.waitForBufferStatusLowContext(ctx, livekit.DataPacket_RELIABLE))
+	require.ErrorIs(t, e.waitForBufferStatusLowContext(ctx, livekit.DataPacket_LOSSY), ErrDataChannelNotFound)
+}
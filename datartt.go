@@ -0,0 +1,191 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataPingTopic carries data channel pings and their echoes, packets on it are not passed to data callbacks
+const DataPingTopic = "lk.ping"
+
+// DataRTT is the round-trip time to a participant over each data channel, see Room.MeasureDataRTT
+type DataRTT struct {
+	Reliable time.Duration
+	Lossy    time.Duration
+}
+
+// lossy pings are resent at this interval until an echo arrives
+const lossyPingInterval = 250 * time.Millisecond
+
+type dataPingMessage struct {
+	ID       string `json:"id"`
+	Reliable bool   `json:"reliable"`
+	Pong     bool   `json:"pong,omitempty"`
+}
+
+// dataPingTracker matches echoes to outstanding pings
+type dataPingTracker struct {
+	lock    sync.Mutex
+	pending map[string]chan struct{}
+}
+
+func newDataPingTracker() *dataPingTracker {
+	return &dataPingTracker{
+		pending: make(map[string]chan struct{}),
+	}
+}
+
+func (t *dataPingTracker) add(id string) chan struct{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ch := make(chan struct{})
+	t.pending[id] = ch
+	return ch
+}
+
+func (t *dataPingTracker) remove(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.pending, id)
+}
+
+// resolve signals the ping with the given id, returns false if it is not outstanding
+func (t *dataPingTracker) resolve(id string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ch, ok := t.pending[id]
+	if !ok {
+		return false
+	}
+	delete(t.pending, id)
+	close(ch)
+	return true
+}
+
+// MeasureDataRTT measures the round-trip time to a participant over both the reliable and the
+// lossy data channel. The remote side echoes pings on DataPingTopic automatically.
+// Lossy pings are resent until echoed. When ctx ends first, the measurements taken so far
+// are returned together with the context error.
+func (r *Room) MeasureDataRTT(ctx context.Context, identity string) (DataRTT, error) {
+	if r.GetParticipantByIdentity(identity) == nil {
+		return DataRTT{}, ErrParticipantNotFound
+	}
+
+	var (
+		rtt  DataRTT
+		lock sync.Mutex
+		wg   sync.WaitGroup
+		errs = make(chan error, 2)
+	)
+	for _, reliable := range []bool{true, false} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d, err := r.pingData(ctx, identity, reliable)
+			if err != nil {
+				errs <- err
+				return
+			}
+			lock.Lock()
+			if reliable {
+				rtt.Reliable = d
+			} else {
+				rtt.Lossy = d
+			}
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	return rtt, <-errs
+}
+
+func (r *Room) pingData(ctx context.Context, identity string, reliable bool) (time.Duration, error) {
+	var retry <-chan time.Time
+	if !reliable {
+		ticker := time.NewTicker(lossyPingInterval)
+		defer ticker.Stop()
+		retry = ticker.C
+	}
+
+	for {
+		msg := dataPingMessage{ID: uuid.New().String(), Reliable: reliable}
+		echo := r.dataPings.add(msg.ID)
+		start := time.Now()
+		err := r.sendDataPing(identity, msg)
+		if err != nil {
+			r.dataPings.remove(msg.ID)
+			return 0, err
+		}
+
+		select {
+		case <-echo:
+			return time.Since(start), nil
+		case <-retry:
+			r.dataPings.remove(msg.ID)
+		case <-ctx.Done():
+			r.dataPings.remove(msg.ID)
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func (r *Room) sendDataPing(identity string, msg dataPingMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return r.LocalParticipant.PublishDataPacket(
+		UserData(payload),
+		WithDataPublishTopic(DataPingTopic),
+		WithDataPublishReliable(msg.Reliable),
+		WithDataPublishDestination([]string{identity}),
+	)
+}
+
+// handleDataPing echoes pings and resolves echoes, returns true if the packet was on DataPingTopic
+func (r *Room) handleDataPing(identity string, dataPacket DataPacket) bool {
+	user, ok := dataPacket.(*UserDataPacket)
+	if !ok || user.Topic != DataPingTopic {
+		return false
+	}
+	var msg dataPingMessage
+	if err := json.Unmarshal(user.Payload, &msg); err != nil || msg.ID == "" {
+		r.log.Debugw("could not parse data ping", "participant", identity, "error", err)
+		return true
+	}
+	if msg.Pong {
+		r.dataPings.resolve(msg.ID)
+		return true
+	}
+	if identity == "" {
+		return true
+	}
+
+	msg.Pong = true
+	if err := r.sendDataPing(identity, msg); err != nil {
+		r.log.Debugw("could not echo data ping", "participant", identity, "error", err)
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataPingEcho(t *testing.T) {
+	room := NewRoom(nil)
+
+	var delivered int
+	room.callback.OnDataPacket = func(data DataPacket, params DataReceiveParams) {
+		delivered++
+	}
+
+	echo := room.dataPings.add("ping-1")
+	payload, err := json.Marshal(dataPingMessage{ID: "ping-1", Reliable: true, Pong: true})
+	require.NoError(t, err)
+	room.OnDataPacket("remote", &UserDataPacket{Topic: DataPingTopic, Payload: payload})
+
+	select {
+	case <-echo:
+	default:
+		t.Fatal("echo was not resolved")
+	}
+	// an unknown or repeated echo is ignored
+	require.False(t, room.dataPings.resolve("ping-1"))
+
+	// malformed pings are swallowed as well
+	room.OnDataPacket("remote", &UserDataPacket{Topic: DataPingTopic, Payload: []byte("{")})
+	require.Zero(t, delivered)
+}
+
+func TestMeasureDataRTTUnknownParticipant(t *testing.T) {
+	room := NewRoom(nil)
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	_, err := room.MeasureDataRTT(ctx, "nobody")
+	require.ErrorIs(t, err, ErrParticipantNotFound)
+}
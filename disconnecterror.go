@@ -0,0 +1,117 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// DisconnectionError describes why a room was disconnected, including the errors that led to it
+type DisconnectionError struct {
+	Reason DisconnectionReason
+	// ServerReason is the reason sent by the server or given to DisconnectWithReason,
+	// UNKNOWN_REASON when the connection failed
+	ServerReason livekit.DisconnectReason
+	// WasConnected is false when joining the room failed, true when an established session ended
+	WasConnected bool
+	// Err is the error chain that caused the disconnect, nil when the server or the application ended the session
+	Err error
+}
+
+func (e *DisconnectionError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+	}
+	return string(e.Reason)
+}
+
+func (e *DisconnectionError) Unwrap() error {
+	return e.Err
+}
+
+// newLeaveDisconnectionError describes a session ended by a leave request
+func newLeaveDisconnectionError(reason livekit.DisconnectReason) *DisconnectionError {
+	return &DisconnectionError{
+		Reason:       GetDisconnectionReason(reason),
+		ServerReason: reason,
+		WasConnected: true,
+	}
+}
+
+// newFailureDisconnectionError describes a failed join or a session that could not be re-established
+func newFailureDisconnectionError(err error, token string, wasConnected bool) *DisconnectionError {
+	reason := Failed
+	switch {
+	case errors.Is(err, ErrDuplicateIdentity):
+		reason = DuplicateIdentity
+	case !wasConnected:
+		reason = JoinFailure
+	}
+	// the server refuses expired tokens, which is only reported as unauthorized
+	if tokenExpired(token, time.Now()) {
+		reason = TokenExpired
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrTokenExpired, err)
+		} else {
+			err = ErrTokenExpired
+		}
+	}
+	return &DisconnectionError{
+		Reason:       reason,
+		WasConnected: wasConnected,
+		Err:          err,
+	}
+}
+
+// tokenExpired checks the expiry of a token without verifying it
+func tokenExpired(token string, now time.Time) bool {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return false
+	}
+	claims := jwt.Claims{}
+	if err = tok.UnsafeClaimsWithoutVerification(&claims); err != nil || claims.Expiry == nil {
+		return false
+	}
+	return now.After(claims.Expiry.Time())
+}
+
+// DisconnectionError returns why the room was last disconnected, nil while connected or before joining
+func (r *Room) DisconnectionError() *DisconnectionError {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.disconnectErr
+}
+
+// setDisconnectionError keeps the first cause of a disconnect, e.g. a leave request over the Disconnect that follows
+func (r *Room) setDisconnectionError(err *DisconnectionError) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.disconnectErr == nil {
+		r.disconnectErr = err
+	}
+}
+
+func (r *Room) clearDisconnectionError() {
+	r.lock.Lock()
+	r.disconnectErr = nil
+	r.lock.Unlock()
+}
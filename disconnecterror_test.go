@@ -0,0 +1,87 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestGetDisconnectionReason(t *testing.T) {
+	for reason, expected := range map[livekit.DisconnectReason]DisconnectionReason{
+		livekit.DisconnectReason_CLIENT_INITIATED:   LeaveRequested,
+		livekit.DisconnectReason_DUPLICATE_IDENTITY: DuplicateIdentity,
+		livekit.DisconnectReason_ROOM_DELETED:       RoomDeleted,
+		livekit.DisconnectReason_SERVER_SHUTDOWN:    ServerShutdown,
+		livekit.DisconnectReason_JOIN_FAILURE:       JoinFailure,
+		livekit.DisconnectReason_SIGNAL_CLOSE:       Failed,
+		livekit.DisconnectReason_MEDIA_FAILURE:      MediaFailure,
+		livekit.DisconnectReason_MIGRATION:          OtherReason,
+	} {
+		require.Equal(t, expected, GetDisconnectionReason(reason), reason.String())
+	}
+}
+
+func TestFailureDisconnectionError(t *testing.T) {
+	cause := errors.New("unauthorized")
+	valid, err := auth.NewAccessToken("key", "secret").SetValidFor(time.Hour).ToJWT()
+	require.NoError(t, err)
+
+	derr := newFailureDisconnectionError(cause, valid, false)
+	require.Equal(t, JoinFailure, derr.Reason)
+	require.ErrorIs(t, derr, cause)
+	require.Equal(t, "join failed: unauthorized", derr.Error())
+
+	derr = newFailureDisconnectionError(cause, valid, true)
+	require.Equal(t, Failed, derr.Reason)
+	require.True(t, derr.WasConnected)
+
+	require.True(t, tokenExpired(valid, time.Now().Add(2*time.Hour)))
+	require.False(t, tokenExpired("not a token", time.Now()))
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("secret")}, nil)
+	require.NoError(t, err)
+	expired, err := jwt.Signed(signer).Claims(jwt.Claims{Expiry: jwt.NewNumericDate(time.Now().Add(-time.Minute))}).CompactSerialize()
+	require.NoError(t, err)
+	derr = newFailureDisconnectionError(cause, expired, true)
+	require.Equal(t, TokenExpired, derr.Reason)
+	require.ErrorIs(t, derr, ErrTokenExpired)
+	require.ErrorIs(t, derr, cause)
+}
+
+func TestRoomDisconnectionError(t *testing.T) {
+	var received *DisconnectionError
+	cb := NewRoomCallback()
+	cb.OnDisconnectedWithError = func(err *DisconnectionError) {
+		received = err
+	}
+	room := NewRoom(cb)
+	require.Nil(t, room.DisconnectionError())
+
+	room.OnDisconnectedWithError(newLeaveDisconnectionError(livekit.DisconnectReason_DUPLICATE_IDENTITY))
+	require.Equal(t, DuplicateIdentity, received.Reason)
+	require.Equal(t, livekit.DisconnectReason_DUPLICATE_IDENTITY, received.ServerReason)
+
+	// the disconnect following a leave request keeps its cause
+	room.Disconnect()
+	require.Same(t, received, room.DisconnectionError())
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	defaultDriftThreshold       = 20 * time.Millisecond
+	defaultDriftMaxCorrection   = 0.005
+	defaultDriftResyncThreshold = 500 * time.Millisecond
+	// weight of a new measurement in the smoothed drift, keeps write jitter out of the estimate
+	driftSmoothing = 0.01
+)
+
+// DriftCompensationConfig tunes how a track keeps RTP timestamps in line with the wall clock, zero values
+// use the defaults. See WithDriftCompensation.
+type DriftCompensationConfig struct {
+	// drift tolerated before timestamps are corrected, 20ms by default
+	Threshold time.Duration
+	// largest correction applied to a sample, as a fraction of its duration, 0.005 by default
+	MaxCorrection float64
+	// drift treated as a discontinuity, e.g. after samples were not written for a while, instead of
+	// being corrected, 500ms by default
+	ResyncThreshold time.Duration
+}
+
+// WithDriftCompensation detects drift between the clock of a live sample source, e.g. a capture device,
+// and the wall clock, and adjusts RTP timestamps of samples timed by their Duration gradually, so that
+// receivers do not drift or overrun their buffers on long running publishes.
+// Samples with a PacketTimestamp are not adjusted.
+func WithDriftCompensation(config DriftCompensationConfig) LocalTrackOptions {
+	return func(s *LocalTrack) {
+		s.drift = newDriftCompensator(config)
+	}
+}
+
+// driftCompensator compares the RTP time advanced by samples with the wall clock
+type driftCompensator struct {
+	threshold       float64
+	maxCorrection   float64
+	resyncThreshold float64
+	clockRate       float64
+
+	started bool
+	start   time.Time
+	// samples advanced since start
+	advanced int64
+	// smoothed drift in samples, positive when RTP time is ahead of the wall clock
+	drift      float64
+	correcting bool
+}
+
+func newDriftCompensator(config DriftCompensationConfig) *driftCompensator {
+	if config.Threshold <= 0 {
+		config.Threshold = defaultDriftThreshold
+	}
+	if config.MaxCorrection <= 0 || config.MaxCorrection >= 1 {
+		config.MaxCorrection = defaultDriftMaxCorrection
+	}
+	if config.ResyncThreshold <= config.Threshold {
+		config.ResyncThreshold = max(defaultDriftResyncThreshold, 2*config.Threshold)
+	}
+	return &driftCompensator{
+		threshold:       config.Threshold.Seconds(),
+		maxCorrection:   config.MaxCorrection,
+		resyncThreshold: config.ResyncThreshold.Seconds(),
+	}
+}
+
+func (d *driftCompensator) setClockRate(clockRate float64) {
+	if d.clockRate != clockRate {
+		d.clockRate = clockRate
+		d.started = false
+	}
+}
+
+// update records a sample advancing RTP time by samples at now, returns the number of samples to add to it
+func (d *driftCompensator) update(samples uint32, now time.Time) int64 {
+	if d.clockRate <= 0 {
+		return 0
+	}
+	if !d.started {
+		d.rebase(now)
+		return 0
+	}
+
+	d.advanced += int64(samples)
+	measured := float64(d.advanced) - now.Sub(d.start).Seconds()*d.clockRate
+	if math.Abs(measured) > d.resyncThreshold*d.clockRate {
+		// a gap in writing, not drift
+		d.rebase(now)
+		return 0
+	}
+	d.drift += driftSmoothing * (measured - d.drift)
+
+	switch {
+	case math.Abs(d.drift) > d.threshold*d.clockRate:
+		d.correcting = true
+	case math.Abs(d.drift) < d.threshold*d.clockRate/2:
+		d.correcting = false
+	}
+	if !d.correcting || samples == 0 {
+		return 0
+	}
+
+	limit := max(1, int64(d.maxCorrection*float64(samples)))
+	correction := min(limit, int64(math.Abs(d.drift)))
+	if d.drift > 0 {
+		correction = -correction
+	}
+	d.advanced += correction
+	d.drift += float64(correction)
+	return correction
+}
+
+func (d *driftCompensator) rebase(now time.Time) {
+	d.started = true
+	d.start = now
+	d.advanced = 0
+	d.drift = 0
+	d.correcting = false
+}
+
+// current returns the smoothed drift, positive when RTP time is ahead of the wall clock
+func (d *driftCompensator) current() time.Duration {
+	if d.clockRate <= 0 {
+		return 0
+	}
+	return time.Duration(d.drift / d.clockRate * float64(time.Second))
+}
+
+// ClockDrift returns how far RTP time of the track is ahead (positive) or behind (negative) the wall clock,
+// only measured when WithDriftCompensation is set
+func (s *LocalTrack) ClockDrift() time.Duration {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.drift == nil {
+		return 0
+	}
+	return s.drift.current()
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriftCompensator(t *testing.T) {
+	d := newDriftCompensator(DriftCompensationConfig{})
+	d.setClockRate(48000)
+
+	// device clock runs 0.2% fast: 20ms of samples every 19.96ms
+	now := time.Unix(1000, 0)
+	var corrected int64
+	for range 30000 {
+		corrected += d.update(960, now)
+		now = now.Add(19960 * time.Microsecond)
+	}
+	require.Negative(t, corrected)
+	// without correction the drift after 10 minutes would be 1.2s, it is held near the threshold
+	require.Less(t, math.Abs(d.current().Seconds()), defaultDriftThreshold.Seconds()*1.5)
+
+	// a pause in writing is a discontinuity, not drift
+	now = now.Add(2 * time.Second)
+	require.Zero(t, d.update(960, now))
+	require.Zero(t, d.current())
+
+	// a steady clock is not corrected
+	d = newDriftCompensator(DriftCompensationConfig{})
+	d.setClockRate(48000)
+	corrected = 0
+	for range 3000 {
+		corrected += d.update(960, now)
+		now = now.Add(20 * time.Millisecond)
+	}
+	require.Zero(t, corrected)
+}
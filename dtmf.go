@@ -0,0 +1,143 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const defaultDTMFInterDigitTimeout = 3 * time.Second
+
+// dtmfDigits maps SIP DTMF event codes to digits, for packets that do not carry the digit
+const dtmfDigits = "0123456789*#ABCD"
+
+// dtmfDigit returns the digit of a DTMF packet
+func dtmfDigit(msg *livekit.SipDTMF) string {
+	if msg.Digit != "" {
+		return msg.Digit
+	}
+	if int(msg.Code) < len(dtmfDigits) {
+		return dtmfDigits[msg.Code : msg.Code+1]
+	}
+	return ""
+}
+
+type dtmfSequence struct {
+	digits   strings.Builder
+	count    int
+	deadline time.Time
+	timer    *time.Timer
+}
+
+// dtmfAggregator assembles DTMF digits of each participant into sequences,
+// completed by an inter-digit timeout, a terminator or the max number of digits
+type dtmfAggregator struct {
+	lock       sync.Mutex
+	pending    map[string]*dtmfSequence
+	config     func() DTMFSequenceConfig
+	onSequence func(identity string, digits string)
+}
+
+func newDTMFAggregator(config func() DTMFSequenceConfig, onSequence func(identity string, digits string)) *dtmfAggregator {
+	return &dtmfAggregator{
+		pending:    make(map[string]*dtmfSequence),
+		config:     config,
+		onSequence: onSequence,
+	}
+}
+
+func (a *dtmfAggregator) add(identity string, msg *livekit.SipDTMF) {
+	digit := dtmfDigit(msg)
+	if digit == "" {
+		return
+	}
+	config := a.config()
+	timeout := config.InterDigitTimeout
+	if timeout < 0 {
+		return
+	}
+	if timeout == 0 {
+		timeout = defaultDTMFInterDigitTimeout
+	}
+
+	a.lock.Lock()
+	seq := a.pending[identity]
+	if strings.Contains(config.Terminators, digit) {
+		// a terminator completes the sequence even when no digit was entered before it
+		if seq != nil {
+			seq.timer.Stop()
+			delete(a.pending, identity)
+		}
+		a.lock.Unlock()
+		a.complete(identity, seq)
+		return
+	}
+
+	if seq == nil {
+		seq = &dtmfSequence{}
+		a.pending[identity] = seq
+		seq.timer = time.AfterFunc(timeout, func() {
+			a.expire(identity, seq)
+		})
+	} else {
+		seq.timer.Reset(timeout)
+	}
+	seq.deadline = time.Now().Add(timeout)
+	seq.digits.WriteString(digit)
+	seq.count++
+	if config.MaxDigits <= 0 || seq.count < config.MaxDigits {
+		a.lock.Unlock()
+		return
+	}
+	seq.timer.Stop()
+	delete(a.pending, identity)
+	a.lock.Unlock()
+	a.complete(identity, seq)
+}
+
+func (a *dtmfAggregator) expire(identity string, seq *dtmfSequence) {
+	a.lock.Lock()
+	if a.pending[identity] != seq || time.Now().Before(seq.deadline) {
+		// already completed, or a digit was entered while the timer fired
+		a.lock.Unlock()
+		return
+	}
+	delete(a.pending, identity)
+	a.lock.Unlock()
+	a.complete(identity, seq)
+}
+
+func (a *dtmfAggregator) complete(identity string, seq *dtmfSequence) {
+	var digits string
+	if seq != nil {
+		digits = seq.digits.String()
+	}
+	go a.onSequence(identity, digits)
+}
+
+// close drops incomplete sequences
+func (a *dtmfAggregator) close() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for identity, seq := range a.pending {
+		seq.timer.Stop()
+		delete(a.pending, identity)
+	}
+}
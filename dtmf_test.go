@@ -0,0 +1,91 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+)
+
+type dtmfResult struct {
+	identity string
+	digits   string
+}
+
+func newTestDTMFAggregator(config DTMFSequenceConfig) (*dtmfAggregator, chan dtmfResult) {
+	results := make(chan dtmfResult, 10)
+	a := newDTMFAggregator(func() DTMFSequenceConfig { return config }, func(identity, digits string) {
+		results <- dtmfResult{identity, digits}
+	})
+	return a, results
+}
+
+func requireDTMFSequence(t *testing.T, results chan dtmfResult, identity, digits string) {
+	t.Helper()
+	select {
+	case res := <-results:
+		require.Equal(t, dtmfResult{identity, digits}, res)
+	case <-time.After(time.Second):
+		t.Fatal("sequence not completed")
+	}
+}
+
+func TestDTMFAggregator(t *testing.T) {
+	t.Run("inter-digit timeout", func(t *testing.T) {
+		a, results := newTestDTMFAggregator(DTMFSequenceConfig{InterDigitTimeout: 50 * time.Millisecond})
+		for _, d := range []string{"1", "2", "3"} {
+			a.add("caller", &livekit.SipDTMF{Digit: d})
+		}
+		requireDTMFSequence(t, results, "caller", "123")
+
+		a.add("other", &livekit.SipDTMF{Code: 10})
+		requireDTMFSequence(t, results, "other", "*")
+	})
+
+	t.Run("terminator", func(t *testing.T) {
+		a, results := newTestDTMFAggregator(DTMFSequenceConfig{InterDigitTimeout: time.Minute, Terminators: "#"})
+		a.add("caller", &livekit.SipDTMF{Digit: "4"})
+		a.add("caller", &livekit.SipDTMF{Digit: "2"})
+		a.add("caller", &livekit.SipDTMF{Code: 11})
+		requireDTMFSequence(t, results, "caller", "42")
+
+		a.add("caller", &livekit.SipDTMF{Digit: "#"})
+		requireDTMFSequence(t, results, "caller", "")
+	})
+
+	t.Run("max digits", func(t *testing.T) {
+		a, results := newTestDTMFAggregator(DTMFSequenceConfig{InterDigitTimeout: time.Minute, MaxDigits: 4})
+		for _, d := range "123456" {
+			a.add("caller", &livekit.SipDTMF{Digit: string(d)})
+		}
+		requireDTMFSequence(t, results, "caller", "1234")
+
+		a.close()
+		require.Empty(t, a.pending)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		a, results := newTestDTMFAggregator(DTMFSequenceConfig{InterDigitTimeout: -1, Terminators: "#"})
+		a.add("caller", &livekit.SipDTMF{Digit: "#"})
+		select {
+		case <-results:
+			t.Fatal("unexpected sequence")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
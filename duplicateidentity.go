@@ -0,0 +1,87 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils/xtwirp"
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+// tokenParticipant returns the room and identity of a join token without verifying it
+func tokenParticipant(token string) (room string, identity string, err error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return "", "", err
+	}
+	var claims struct {
+		jwt.Claims
+		Video *auth.VideoGrant `json:"video,omitempty"`
+	}
+	if err = tok.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return "", "", err
+	}
+	identity = claims.Subject
+	if identity == "" {
+		identity = claims.ID
+	}
+	if claims.Video != nil {
+		room = claims.Video.Room
+	}
+	return room, identity, nil
+}
+
+// checkIdentityAvailable fails with ErrDuplicateIdentity when the identity of the token is connected to its room.
+// This is best effort, a participant joining at the same time is still replaced by the server.
+func checkIdentityAvailable(ctx context.Context, roomService livekit.RoomService, token string) error {
+	room, identity, err := tokenParticipant(token)
+	if err != nil {
+		return err
+	}
+	if room == "" || identity == "" {
+		return fmt.Errorf("%w: token has no room or identity", ErrInvalidParameter)
+	}
+
+	ctx, err = twirp.WithHTTPRequestHeaders(ctx, signalling.NewHTTPHeaderWithToken(token))
+	if err != nil {
+		return err
+	}
+	_, err = roomService.GetParticipant(ctx, &livekit.RoomParticipantIdentity{
+		Room:     room,
+		Identity: identity,
+	})
+	var terr twirp.Error
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: %s", ErrDuplicateIdentity, identity)
+	case errors.As(err, &terr) && terr.Code() == twirp.NotFound:
+		return nil
+	default:
+		return fmt.Errorf("could not look up identity, the token needs the roomAdmin grant: %w", err)
+	}
+}
+
+func newTokenRoomService(url string) livekit.RoomService {
+	return livekit.NewRoomServiceProtobufClient(signalling.ToHttpURL(url), &http.Client{}, xtwirp.DefaultClientOptions()...)
+}
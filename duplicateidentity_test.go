@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+)
+
+type fakeParticipantLookup struct {
+	livekit.RoomService
+	req           *livekit.RoomParticipantIdentity
+	authorization string
+	err           error
+}
+
+func (f *fakeParticipantLookup) GetParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	f.req = req
+	if h, ok := twirp.HTTPRequestHeaders(ctx); ok {
+		f.authorization = h.Get("Authorization")
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &livekit.ParticipantInfo{Identity: req.Identity}, nil
+}
+
+func TestCheckIdentityAvailable(t *testing.T) {
+	token, err := auth.NewAccessToken("key", "secret").
+		SetIdentity("agent").
+		SetVideoGrant(&auth.VideoGrant{RoomJoin: true, Room: "room"}).
+		ToJWT()
+	require.NoError(t, err)
+
+	lookup := &fakeParticipantLookup{}
+	err = checkIdentityAvailable(t.Context(), lookup, token)
+	require.ErrorIs(t, err, ErrDuplicateIdentity)
+	require.Equal(t, "room", lookup.req.Room)
+	require.Equal(t, "agent", lookup.req.Identity)
+	require.Equal(t, "Bearer "+token, lookup.authorization)
+	require.Equal(t, DuplicateIdentity, newFailureDisconnectionError(err, token, false).Reason)
+
+	lookup.err = twirp.NotFoundError("participant not found")
+	require.NoError(t, checkIdentityAvailable(t.Context(), lookup, token))
+
+	lookup.err = twirp.NewError(twirp.PermissionDenied, "permissions denied")
+	err = checkIdentityAvailable(t.Context(), lookup, token)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrDuplicateIdentity)
+
+	noRoom, err := auth.NewAccessToken("key", "secret").SetIdentity("agent").ToJWT()
+	require.NoError(t, err)
+	require.ErrorIs(t, checkIdentityAvailable(t.Context(), lookup, noRoom), ErrInvalidParameter)
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// EgressLayout is a built-in layout of room composite egress
+type EgressLayout string
+
+const (
+	EgressLayoutGrid          EgressLayout = "grid"
+	EgressLayoutSpeaker       EgressLayout = "speaker"
+	EgressLayoutSingleSpeaker EgressLayout = "single-speaker"
+)
+
+// placeholder in custom template query params, replaced with the name of the room
+const egressTemplateRoomName = "{room_name}"
+
+const (
+	maxEgressDimension = 3840
+	maxEgressFramerate = 60
+	// bits per pixel and frame outside of this range give poor quality or waste bandwidth
+	minEgressBitsPerPixel = 0.02
+	maxEgressBitsPerPixel = 1.0
+)
+
+// RoomCompositeEgressBuilder builds a RoomCompositeEgressRequest, see NewRoomCompositeEgress
+type RoomCompositeEgressBuilder struct {
+	req    *livekit.RoomCompositeEgressRequest
+	errors []FieldError
+}
+
+// NewRoomCompositeEgress starts building a room composite egress request with the default grid layout.
+// Call Build to validate the request before passing it to EgressClient.StartRoomCompositeEgress.
+func NewRoomCompositeEgress(roomName string) *RoomCompositeEgressBuilder {
+	return &RoomCompositeEgressBuilder{
+		req: &livekit.RoomCompositeEgressRequest{
+			RoomName: roomName,
+			Layout:   string(EgressLayoutGrid),
+		},
+	}
+}
+
+// Layout selects a built-in layout, light selects its light theme.
+func (b *RoomCompositeEgressBuilder) Layout(layout EgressLayout, light bool) *RoomCompositeEgressBuilder {
+	b.req.Layout = string(layout)
+	if light {
+		b.req.Layout += "-light"
+	}
+	return b
+}
+
+// CustomTemplate renders the room with a custom template at baseURL. The params are added to its query,
+// "{room_name}" in values is replaced with the name of the room. layout is passed to the template and may be empty.
+func (b *RoomCompositeEgressBuilder) CustomTemplate(baseURL string, layout string, params map[string]string) *RoomCompositeEgressBuilder {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		b.errors = append(b.errors, FieldError{Field: "custom_base_url", Message: "must be an http or https url"})
+		return b
+	}
+	query := u.Query()
+	for k, v := range params {
+		query.Set(k, strings.ReplaceAll(v, egressTemplateRoomName, b.req.RoomName))
+	}
+	u.RawQuery = query.Encode()
+
+	b.req.CustomBaseUrl = u.String()
+	b.req.Layout = layout
+	return b
+}
+
+// AudioOnly records audio only, the layout is not rendered.
+func (b *RoomCompositeEgressBuilder) AudioOnly() *RoomCompositeEgressBuilder {
+	b.req.AudioOnly = true
+	return b
+}
+
+// VideoOnly records video only.
+func (b *RoomCompositeEgressBuilder) VideoOnly() *RoomCompositeEgressBuilder {
+	b.req.VideoOnly = true
+	return b
+}
+
+// Preset encodes with one of the encoding presets, H264_720P_30 by default.
+func (b *RoomCompositeEgressBuilder) Preset(preset livekit.EncodingOptionsPreset) *RoomCompositeEgressBuilder {
+	b.req.Options = &livekit.RoomCompositeEgressRequest_Preset{Preset: preset}
+	return b
+}
+
+// Encoding encodes with custom options, bitrates are in kbps.
+func (b *RoomCompositeEgressBuilder) Encoding(opts *livekit.EncodingOptions) *RoomCompositeEgressBuilder {
+	b.req.Options = &livekit.RoomCompositeEgressRequest_Advanced{Advanced: opts}
+	return b
+}
+
+// FileOutput adds a file output.
+func (b *RoomCompositeEgressBuilder) FileOutput(output *livekit.EncodedFileOutput) *RoomCompositeEgressBuilder {
+	b.req.FileOutputs = append(b.req.FileOutputs, output)
+	return b
+}
+
+// StreamOutput adds a stream output to the given urls.
+func (b *RoomCompositeEgressBuilder) StreamOutput(protocol livekit.StreamProtocol, urls ...string) *RoomCompositeEgressBuilder {
+	b.req.StreamOutputs = append(b.req.StreamOutputs, &livekit.StreamOutput{Protocol: protocol, Urls: urls})
+	return b
+}
+
+// SegmentOutput adds an HLS output.
+func (b *RoomCompositeEgressBuilder) SegmentOutput(output *livekit.SegmentedFileOutput) *RoomCompositeEgressBuilder {
+	b.req.SegmentOutputs = append(b.req.SegmentOutputs, output)
+	return b
+}
+
+// ImageOutput adds a thumbnail output.
+func (b *RoomCompositeEgressBuilder) ImageOutput(output *livekit.ImageOutput) *RoomCompositeEgressBuilder {
+	b.req.ImageOutputs = append(b.req.ImageOutputs, output)
+	return b
+}
+
+// Build validates the request and returns it, the error is a *ValidationError.
+func (b *RoomCompositeEgressBuilder) Build() (*livekit.RoomCompositeEgressRequest, error) {
+	fields := append([]FieldError{}, b.errors...)
+
+	var verr *ValidationError
+	if err := ValidateRequest(b.req); err != nil {
+		if !errors.As(err, &verr) {
+			return nil, err
+		}
+		fields = append(fields, verr.Fields...)
+	}
+	if b.req.AudioOnly && b.req.CustomBaseUrl == "" && b.req.Layout != string(EgressLayoutGrid) {
+		fields = append(fields, FieldError{Field: "layout", Message: "is not rendered for audio only egress"})
+	}
+	fields = append(fields, encodingCombinationErrors(b.req.GetAdvanced(), b.req.AudioOnly)...)
+
+	if len(fields) > 0 {
+		return nil, &ValidationError{Fields: fields}
+	}
+	return b.req, nil
+}
+
+// encodingCombinationErrors checks that resolution, frame rate and bitrate fit together
+func encodingCombinationErrors(opts *livekit.EncodingOptions, audioOnly bool) []FieldError {
+	if opts == nil || audioOnly {
+		return nil
+	}
+	var fields []FieldError
+	if opts.Width > maxEgressDimension || opts.Height > maxEgressDimension {
+		fields = append(fields, FieldError{Field: "advanced.width", Message: fmt.Sprintf("resolution must not exceed %d pixels per side", maxEgressDimension)})
+	}
+	if opts.Width%2 != 0 || opts.Height%2 != 0 {
+		fields = append(fields, FieldError{Field: "advanced.width", Message: "width and height must be even"})
+	}
+	if opts.Framerate > maxEgressFramerate {
+		fields = append(fields, FieldError{Field: "advanced.framerate", Message: fmt.Sprintf("must not exceed %d", maxEgressFramerate)})
+	}
+	if opts.Width > 0 && opts.Height > 0 && opts.Framerate > 0 && opts.VideoBitrate > 0 {
+		bpp := float64(opts.VideoBitrate) * 1000 / (float64(opts.Width) * float64(opts.Height) * float64(opts.Framerate))
+		switch {
+		case bpp < minEgressBitsPerPixel:
+			fields = append(fields, FieldError{Field: "advanced.video_bitrate", Message: fmt.Sprintf("%d kbps is too low for %dx%d at %d fps", opts.VideoBitrate, opts.Width, opts.Height, opts.Framerate)})
+		case bpp > maxEgressBitsPerPixel:
+			fields = append(fields, FieldError{Field: "advanced.video_bitrate", Message: fmt.Sprintf("%d kbps is too high for %dx%d at %d fps", opts.VideoBitrate, opts.Width, opts.Height, opts.Framerate)})
+		}
+	}
+	return fields
+}
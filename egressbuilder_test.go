@@ -0,0 +1,51 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomCompositeEgressBuilder(t *testing.T) {
+	req, err := NewRoomCompositeEgress("room").
+		Layout(EgressLayoutSpeaker, true).
+		Preset(livekit.EncodingOptionsPreset_H264_1080P_30).
+		StreamOutput(livekit.StreamProtocol_RTMP, "rtmp://stream.example.com/live/key").
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, "speaker-light", req.Layout)
+	require.Equal(t, livekit.EncodingOptionsPreset_H264_1080P_30, req.GetPreset())
+
+	req, err = NewRoomCompositeEgress("room").
+		CustomTemplate("https://example.com/layout?theme=dark", "grid", map[string]string{"room": "{room_name}"}).
+		FileOutput(&livekit.EncodedFileOutput{Filepath: "out.mp4"}).
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/layout?room=room&theme=dark", req.CustomBaseUrl)
+
+	_, err = NewRoomCompositeEgress("room").
+		Encoding(&livekit.EncodingOptions{Width: 1921, Height: 1080, Framerate: 30, VideoBitrate: 100}).
+		FileOutput(&livekit.EncodedFileOutput{Filepath: "out.mp4"}).
+		Build()
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []FieldError{
+		{Field: "advanced.width", Message: "width and height must be even"},
+		{Field: "advanced.video_bitrate", Message: "100 kbps is too low for 1921x1080 at 30 fps"},
+	}, verr.Fields)
+}
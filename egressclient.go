@@ -44,43 +44,68 @@ func NewEgressClient(url string, apiKey string, secretKey string, opts ...twirp.
 }
 
 func (c *EgressClient) StartRoomCompositeEgress(ctx context.Context, req *livekit.RoomCompositeEgressRequest) (*livekit.EgressInfo, error) {
+	if err := ValidateRequest(req); err != nil {
+		return nil, err
+	}
 	ctx, err := c.withAuth(ctx, withVideoGrant{RoomRecord: true})
 	if err != nil {
 		return nil, err
 	}
-	return c.egressClient.StartRoomCompositeEgress(ctx, req)
+	return idempotent(ctx, c.authBase, "StartRoomCompositeEgress", req, func(ctx context.Context) (*livekit.EgressInfo, error) {
+		return c.egressClient.StartRoomCompositeEgress(ctx, req)
+	})
 }
 
 func (c *EgressClient) StartParticipantEgress(ctx context.Context, req *livekit.ParticipantEgressRequest) (*livekit.EgressInfo, error) {
+	if err := ValidateRequest(req); err != nil {
+		return nil, err
+	}
 	ctx, err := c.withAuth(ctx, withVideoGrant{RoomRecord: true})
 	if err != nil {
 		return nil, err
 	}
-	return c.egressClient.StartParticipantEgress(ctx, req)
+	return idempotent(ctx, c.authBase, "StartParticipantEgress", req, func(ctx context.Context) (*livekit.EgressInfo, error) {
+		return c.egressClient.StartParticipantEgress(ctx, req)
+	})
 }
 
 func (c *EgressClient) StartTrackCompositeEgress(ctx context.Context, req *livekit.TrackCompositeEgressRequest) (*livekit.EgressInfo, error) {
+	if err := ValidateRequest(req); err != nil {
+		return nil, err
+	}
 	ctx, err := c.withAuth(ctx, withVideoGrant{RoomRecord: true})
 	if err != nil {
 		return nil, err
 	}
-	return c.egressClient.StartTrackCompositeEgress(ctx, req)
+	return idempotent(ctx, c.authBase, "StartTrackCompositeEgress", req, func(ctx context.Context) (*livekit.EgressInfo, error) {
+		return c.egressClient.StartTrackCompositeEgress(ctx, req)
+	})
 }
 
 func (c *EgressClient) StartTrackEgress(ctx context.Context, req *livekit.TrackEgressRequest) (*livekit.EgressInfo, error) {
+	if err := ValidateRequest(req); err != nil {
+		return nil, err
+	}
 	ctx, err := c.withAuth(ctx, withVideoGrant{RoomRecord: true})
 	if err != nil {
 		return nil, err
 	}
-	return c.egressClient.StartTrackEgress(ctx, req)
+	return idempotent(ctx, c.authBase, "StartTrackEgress", req, func(ctx context.Context) (*livekit.EgressInfo, error) {
+		return c.egressClient.StartTrackEgress(ctx, req)
+	})
 }
 
 func (c *EgressClient) StartWebEgress(ctx context.Context, req *livekit.WebEgressRequest) (*livekit.EgressInfo, error) {
+	if err := ValidateRequest(req); err != nil {
+		return nil, err
+	}
 	ctx, err := c.withAuth(ctx, withVideoGrant{RoomRecord: true})
 	if err != nil {
 		return nil, err
 	}
-	return c.egressClient.StartWebEgress(ctx, req)
+	return idempotent(ctx, c.authBase, "StartWebEgress", req, func(ctx context.Context) (*livekit.EgressInfo, error) {
+		return c.egressClient.StartWebEgress(ctx, req)
+	})
 }
 
 func (c *EgressClient) UpdateLayout(ctx context.Context, req *livekit.UpdateLayoutRequest) (*livekit.EgressInfo, error) {
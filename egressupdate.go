@@ -0,0 +1,125 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// EgressStateError is returned when an egress cannot be updated in its current state, it matches ErrEgressNotActive
+type EgressStateError struct {
+	EgressID string
+	Status   livekit.EgressStatus
+}
+
+func (e *EgressStateError) Error() string {
+	return fmt.Sprintf("egress %s is not active: %s", e.EgressID, e.Status)
+}
+
+func (e *EgressStateError) Unwrap() error {
+	return ErrEgressNotActive
+}
+
+func isEgressActive(status livekit.EgressStatus) bool {
+	return status == livekit.EgressStatus_EGRESS_STARTING || status == livekit.EgressStatus_EGRESS_ACTIVE
+}
+
+// GetEgress returns the egress with the given ID or ErrEgressNotFound.
+func (c *EgressClient) GetEgress(ctx context.Context, egressID string) (*livekit.EgressInfo, error) {
+	res, err := c.ListEgress(ctx, &livekit.ListEgressRequest{EgressId: egressID})
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range res.Items {
+		if info.EgressId == egressID {
+			return info, nil
+		}
+	}
+	return nil, ErrEgressNotFound
+}
+
+// getActiveEgress returns the egress if it can be updated
+func (c *EgressClient) getActiveEgress(ctx context.Context, egressID string) (*livekit.EgressInfo, error) {
+	info, err := c.GetEgress(ctx, egressID)
+	if err != nil {
+		return nil, err
+	}
+	if !isEgressActive(info.Status) {
+		return nil, &EgressStateError{EgressID: egressID, Status: info.Status}
+	}
+	return info, nil
+}
+
+// UpdateActiveLayout changes the layout of a room composite or web egress after checking that it is active.
+// It returns an *EgressStateError matching ErrEgressNotActive for egresses that ended.
+func (c *EgressClient) UpdateActiveLayout(ctx context.Context, egressID string, layout string) (*livekit.EgressInfo, error) {
+	info, err := c.getActiveEgress(ctx, egressID)
+	if err != nil {
+		return nil, err
+	}
+	switch info.Request.(type) {
+	case *livekit.EgressInfo_RoomComposite, *livekit.EgressInfo_Web:
+	default:
+		return nil, fmt.Errorf("%w: egress %s has no layout", ErrInvalidParameter, egressID)
+	}
+	return c.UpdateLayout(ctx, &livekit.UpdateLayoutRequest{EgressId: egressID, Layout: layout})
+}
+
+// EgressStreamUpdate adds and removes stream outputs of an active egress, see EgressClient.UpdateActiveStream
+type EgressStreamUpdate struct {
+	client *EgressClient
+	req    *livekit.UpdateStreamRequest
+}
+
+// UpdateActiveStream starts an update of the stream outputs of an egress, e.g.
+//
+//	client.UpdateActiveStream(egressID).AddOutput("rtmp://a/live/key").RemoveOutput("rtmp://b/live/key").Apply(ctx)
+func (c *EgressClient) UpdateActiveStream(egressID string) *EgressStreamUpdate {
+	return &EgressStreamUpdate{
+		client: c,
+		req:    &livekit.UpdateStreamRequest{EgressId: egressID},
+	}
+}
+
+// AddOutput starts streaming to the urls.
+func (u *EgressStreamUpdate) AddOutput(urls ...string) *EgressStreamUpdate {
+	u.req.AddOutputUrls = append(u.req.AddOutputUrls, urls...)
+	return u
+}
+
+// RemoveOutput stops streaming to the urls.
+func (u *EgressStreamUpdate) RemoveOutput(urls ...string) *EgressStreamUpdate {
+	u.req.RemoveOutputUrls = append(u.req.RemoveOutputUrls, urls...)
+	return u
+}
+
+// Apply sends the update after checking that the egress is active and has a stream output.
+// It returns an *EgressStateError matching ErrEgressNotActive for egresses that ended.
+func (u *EgressStreamUpdate) Apply(ctx context.Context) (*livekit.EgressInfo, error) {
+	if len(u.req.AddOutputUrls) == 0 && len(u.req.RemoveOutputUrls) == 0 {
+		return nil, ErrInvalidParameter
+	}
+	info, err := u.client.getActiveEgress(ctx, u.req.EgressId)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.StreamResults) == 0 && info.GetStream() == nil {
+		return nil, fmt.Errorf("%w: egress %s has no stream output", ErrInvalidParameter, u.req.EgressId)
+	}
+	return u.client.UpdateStream(ctx, u.req)
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestEgressStateError(t *testing.T) {
+	var err error = &EgressStateError{EgressID: "EG_1", Status: livekit.EgressStatus_EGRESS_COMPLETE}
+	require.ErrorIs(t, err, ErrEgressNotActive)
+
+	var stateErr *EgressStateError
+	require.True(t, errors.As(err, &stateErr))
+	require.Equal(t, livekit.EgressStatus_EGRESS_COMPLETE, stateErr.Status)
+
+	require.True(t, isEgressActive(livekit.EgressStatus_EGRESS_STARTING))
+	require.True(t, isEgressActive(livekit.EgressStatus_EGRESS_ACTIVE))
+	require.False(t, isEgressActive(livekit.EgressStatus_EGRESS_ENDING))
+	require.False(t, isEgressActive(livekit.EgressStatus_EGRESS_FAILED))
+}
+
+func TestEgressStreamUpdate(t *testing.T) {
+	c := NewEgressClient("http://localhost:7880", "key", "secret")
+
+	u := c.UpdateActiveStream("EG_1").
+		AddOutput("rtmp://a/live/1", "rtmp://b/live/1").
+		RemoveOutput("rtmp://c/live/1")
+	require.Equal(t, "EG_1", u.req.EgressId)
+	require.Equal(t, []string{"rtmp://a/live/1", "rtmp://b/live/1"}, u.req.AddOutputUrls)
+	require.Equal(t, []string{"rtmp://c/live/1"}, u.req.RemoveOutputUrls)
+
+	_, err := c.UpdateActiveStream("EG_1").Apply(context.Background())
+	require.ErrorIs(t, err, ErrInvalidParameter)
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"github.com/livekit/protocol/livekit"
+)
+
+// EncryptionStatus describes how the media of a publication is end-to-end encrypted
+type EncryptionStatus struct {
+	// encryption type as reported by the server in TrackInfo
+	Type livekit.Encryption_Type
+	// key index (KID) of the most recent encrypted frame, valid when KeyIndexKnown is set
+	KeyIndex      uint8
+	KeyIndexKnown bool
+}
+
+// Encrypted returns true when the publication is end-to-end encrypted
+func (s EncryptionStatus) Encrypted() bool {
+	return s.Type != livekit.Encryption_NONE
+}
+
+// FrameKeyIndex returns the key index (KID) carried in the trailer of a frame encrypted
+// with EncryptGCMAudioSample or a compatible client, false if the frame is too short to carry one
+func FrameKeyIndex(frame []byte) (uint8, bool) {
+	if len(frame) < 2 {
+		return 0, false
+	}
+	ivLength := int(frame[len(frame)-2])
+	if ivLength == 0 || len(frame) < 2+ivLength+unencrypted_audio_bytes {
+		return 0, false
+	}
+	return frame[len(frame)-1], true
+}
+
+func (p *trackPublicationBase) EncryptionType() livekit.Encryption_Type {
+	if info, ok := p.info.Load().(*livekit.TrackInfo); ok {
+		return info.Encryption
+	}
+	return livekit.Encryption_NONE
+}
+
+// IsEncrypted returns true when the server reports the publication as end-to-end encrypted
+func (p *trackPublicationBase) IsEncrypted() bool {
+	return p.EncryptionType() != livekit.Encryption_NONE
+}
+
+// EncryptionStatus returns the encryption type and the last seen key index of the publication
+func (p *trackPublicationBase) EncryptionStatus() EncryptionStatus {
+	status := EncryptionStatus{Type: p.EncryptionType()}
+	// stored as index+1, zero means no key index was seen yet
+	if v := p.keyIndex.Load(); v != 0 {
+		status.KeyIndex = uint8(v - 1)
+		status.KeyIndexKnown = true
+	}
+	return status
+}
+
+// setKeyIndex records the key index, returns true if it changed
+func (p *trackPublicationBase) setKeyIndex(keyIndex uint8) bool {
+	return p.keyIndex.Swap(uint32(keyIndex)+1) != uint32(keyIndex)+1
+}
+
+// SetKeyIndex records the key index the application currently encrypts this track with.
+// OnEncryptionStatusChanged is fired when it changes, e.g. after a key rotation.
+func (p *LocalTrackPublication) SetKeyIndex(keyIndex uint8) {
+	if p.setKeyIndex(keyIndex) && p.onEncryptionChanged != nil {
+		p.onEncryptionChanged(p, p.EncryptionStatus())
+	}
+}
+
+// ObserveEncryptedFrame records the key index of a received frame before it is decrypted,
+// so that key epoch changes of the remote sender are surfaced via OnEncryptionStatusChanged.
+// Frames without a valid trailer are ignored.
+func (p *RemoteTrackPublication) ObserveEncryptedFrame(frame []byte) {
+	keyIndex, ok := FrameKeyIndex(frame)
+	if !ok {
+		return
+	}
+	if p.setKeyIndex(keyIndex) && p.onEncryptionChanged != nil {
+		p.onEncryptionChanged(p, p.EncryptionStatus())
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestFrameKeyIndex(t *testing.T) {
+	key := make([]byte, 16)
+	frame, err := EncryptGCMAudioSample([]byte{0xfc, 1, 2, 3}, key, 3)
+	require.NoError(t, err)
+	keyIndex, ok := FrameKeyIndex(frame)
+	require.True(t, ok)
+	require.Equal(t, uint8(3), keyIndex)
+
+	_, ok = FrameKeyIndex([]byte{1})
+	require.False(t, ok)
+	_, ok = FrameKeyIndex([]byte{1, 12, 0})
+	require.False(t, ok)
+}
+
+func TestRemoteEncryptionStatus(t *testing.T) {
+	changes := make(chan EncryptionStatus, 4)
+	cb := NewRoomCallback()
+	cb.OnEncryptionStatusChanged = func(pub TrackPublication, status EncryptionStatus, p Participant) {
+		changes <- status
+	}
+
+	pi := &livekit.ParticipantInfo{Identity: "publisher", Tracks: []*livekit.TrackInfo{{
+		Sid:  "TR_audio",
+		Type: livekit.TrackType_AUDIO,
+	}}}
+	rp := newRemoteParticipant(pi, cb, nil, nil, nil, logger)
+	rp.events.release(nil)
+	pub := rp.getPublication("TR_audio")
+	require.NotNil(t, pub)
+	require.False(t, pub.IsEncrypted())
+	require.Equal(t, EncryptionStatus{}, pub.EncryptionStatus())
+
+	pi = &livekit.ParticipantInfo{Identity: "publisher", Version: 1, Tracks: []*livekit.TrackInfo{{
+		Sid:        "TR_audio",
+		Type:       livekit.TrackType_AUDIO,
+		Encryption: livekit.Encryption_GCM,
+	}}}
+	rp.updateInfo(pi)
+	require.Equal(t, EncryptionStatus{Type: livekit.Encryption_GCM}, receiveStatus(t, changes))
+
+	frame, err := EncryptGCMAudioSample([]byte{0xfc, 1, 2, 3}, make([]byte, 16), 1)
+	require.NoError(t, err)
+	pub.ObserveEncryptedFrame(frame)
+	require.Equal(t, EncryptionStatus{Type: livekit.Encryption_GCM, KeyIndex: 1, KeyIndexKnown: true}, receiveStatus(t, changes))
+
+	// same key index does not notify
+	pub.ObserveEncryptedFrame(frame)
+	select {
+	case status := <-changes:
+		t.Fatalf("unexpected status change %+v", status)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func receiveStatus(t *testing.T, changes chan EncryptionStatus) EncryptionStatus {
+	select {
+	case status := <-changes:
+		return status
+	case <-time.After(time.Second):
+		t.Fatal("no encryption status change")
+		return EncryptionStatus{}
+	}
+}
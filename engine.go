@@ -17,8 +17,10 @@ package lksdk
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
@@ -31,6 +33,7 @@ import (
 	protoLogger "github.com/livekit/protocol/logger"
 	protosignalling "github.com/livekit/protocol/signalling"
 
+	sdkinterceptor "github.com/livekit/server-sdk-go/v2/pkg/interceptor"
 	"github.com/livekit/server-sdk-go/v2/signalling"
 )
 
@@ -39,7 +42,7 @@ import (
 type engineHandler interface {
 	OnLocalTrackUnpublished(response *livekit.TrackUnpublishedResponse)
 	OnTrackRemoteMuted(request *livekit.MuteTrackRequest)
-	OnDisconnected(reason DisconnectionReason)
+	OnDisconnectedWithError(err *DisconnectionError)
 	OnMediaTrack(track *webrtc.TrackRemote, receiver *webrtc.RTPReceiver)
 	OnParticipantUpdate([]*livekit.ParticipantInfo)
 	OnSpeakersChanged([]*livekit.SpeakerInfo)
@@ -73,6 +76,15 @@ type engineHandler interface {
 	OnSubscribedQualityUpdate(subscribedQualityUpdate *livekit.SubscribedQualityUpdate)
 	OnSubscribedAudioCodecUpdate(subscribedAudioCodecUpdate *livekit.SubscribedAudioCodecUpdate)
 	OnMediaSectionsRequirement(mediaSectionsRequirement *livekit.MediaSectionsRequirement)
+	OnActiveCandidatePairChanged(target livekit.SignalTarget, pair *ICECandidatePairInfo)
+	OnBandwidthEstimates(estimates BandwidthEstimates)
+	OnStatsReport(report StatsReport)
+	OnSubscriptionResponse(response *livekit.SubscriptionResponse)
+	OnNegotiationStalled(target livekit.SignalTarget, recovery NegotiationRecovery, err error)
+	OnRateLimited(identity string, kind RateLimitKind)
+	OnTokenRefreshed(token string)
+	OnError(err error, category ErrorCategory)
+	OnDataSent(sequence uint32, err error)
 }
 
 // -------------------------------------------
@@ -116,6 +128,20 @@ type RTCEngine struct {
 	lossyDCSub      *webrtc.DataChannel
 	reliableMsgLock sync.Mutex
 	reliableMsgSeq  uint32
+	// reliable packets that may not have reached the server, see resendReliableMessages
+	reliableSendBuffer reliableSendBuffer
+	// last reliable sequence the server received, from the ReconnectResponse
+	resumeLastMessageSeq atomic.Uint32
+	// set while resuming a stored session, see resumeSession
+	resumingSession atomic.Bool
+	sessionResumed  atomic.Bool
+
+	// signaled when the buffered amount of the data channels drops, see waitForBufferStatusLowContext
+	reliableBufferLow bufferLowNotifier
+	lossyBufferLow    bufferLowNotifier
+
+	// rate limits OnError
+	errorReports errorReporter
 
 	trackPublishedListenersLock sync.Mutex
 	trackPublishedListeners     map[string]chan *livekit.TrackPublishedResponse
@@ -124,8 +150,11 @@ type RTCEngine struct {
 	hasConnected          atomic.Bool
 	hasPublish            atomic.Bool
 	closed                atomic.Bool
+	closeDone             chan struct{}
+	closing               chan struct{} // closed when closing starts, interrupts reconnect delays
 	reconnecting          atomic.Bool
 	requiresFullReconnect atomic.Bool
+	connState             *connectionStateMachine
 
 	url        string
 	token      atomic.String
@@ -133,6 +162,40 @@ type RTCEngine struct {
 
 	joinTimeout time.Duration
 
+	pingLock           sync.Mutex
+	serverPingInterval time.Duration
+	serverPingTimeout  time.Duration
+	pingStop           chan struct{}
+	lastPongAt         atomic.Time
+	pongRespReceived   atomic.Bool
+	signalRTT          atomic.Duration
+
+	bandwidthWorkerStarted atomic.Bool
+	statsWorkerStarted     atomic.Bool
+
+	// audio levels of received packets, shared by both transports
+	audioLevels *sdkinterceptor.AudioLevelMonitor
+	// delays of paced packets, used by pipeline tracing
+	pacerDelays *sdkinterceptor.PacerDelayMonitor
+	// payload types of received packets, used to detect codec changes
+	payloadTypes *sdkinterceptor.PayloadTypeMonitor
+	// receive buffers of subscribed tracks, see SubscriptionBufferConfig
+	receiveBuffers *receiveBufferRegistry
+
+	inboundLimiter *inboundRateLimiter
+	goroutines     *goroutineRegistry
+
+	publisherWatchdog  negotiationWatchdog
+	subscriberWatchdog negotiationWatchdog
+	// last offer of the server, applied again when a retry finds it was not
+	subscriberOffer atomic.Pointer[webrtc.SessionDescription]
+
+	// set with UpdateICEServers, replaces the servers of join and reconnect responses
+	iceServersLock sync.Mutex
+	iceServers     []webrtc.ICEServer
+	// returned by WithICECredentialRefresh, replaces the servers of WithICEServers
+	refreshedICEServers []webrtc.ICEServer
+
 	onClose     []func()
 	onCloseLock sync.Mutex
 }
@@ -150,6 +213,15 @@ func NewRTCEngine(
 		trackPublishedListeners:  make(map[string]chan *livekit.TrackPublishedResponse),
 		joinTimeout:              15 * time.Second,
 		reliableMsgSeq:           1,
+		inboundLimiter:           newInboundRateLimiter(),
+		goroutines:               newGoroutineRegistry(),
+		closeDone:                make(chan struct{}),
+		closing:                  make(chan struct{}),
+		audioLevels:              sdkinterceptor.NewAudioLevelMonitor(),
+		pacerDelays:              sdkinterceptor.NewPacerDelayMonitor(),
+		receiveBuffers:           newReceiveBufferRegistry(),
+		payloadTypes:             sdkinterceptor.NewPayloadTypeMonitor(),
+		connState:                newConnectionStateMachine(),
 	}
 	if !useSinglePeerConnection {
 		e.signalling = signalling.NewSignalling(signalling.SignallingParams{
@@ -200,6 +272,7 @@ func (e *RTCEngine) JoinContext(
 	e.url = url
 	e.token.Store(token)
 	e.connParams = connectParams
+	e.refreshICEServers(ctx)
 
 	var (
 		publisherOffer webrtc.SessionDescription
@@ -215,6 +288,7 @@ func (e *RTCEngine) JoinContext(
 			return false, err
 		}
 		e.pendingPublisherOffer = publisherOffer
+		publisherOffer = e.publisher.transformSDP(signalling.SDPDirectionOutgoing, publisherOffer)
 		e.pclock.Unlock()
 	}
 
@@ -230,6 +304,8 @@ func (e *RTCEngine) JoinContext(
 	}
 
 	e.hasConnected.Store(true)
+	e.startBandwidthEstimatesWorker()
+	e.startStatsReportWorker()
 	return true, nil
 }
 
@@ -239,12 +315,27 @@ func (e *RTCEngine) OnClose(onClose func()) {
 	e.onCloseLock.Unlock()
 }
 
-func (e *RTCEngine) Close() {
+// Close closes the engine and blocks until the transports, the signal connection and the registered
+// close handlers are torn down, or ctx is done. Reconnect attempts in progress are waited for.
+func (e *RTCEngine) Close(ctx context.Context) error {
+	e.CloseAsync()
+	select {
+	case <-e.closeDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CloseAsync starts closing the engine and returns immediately.
+func (e *RTCEngine) CloseAsync() {
 	if !e.closed.CompareAndSwap(false, true) {
 		return
 	}
+	close(e.closing)
 
-	go func() {
+	e.goroutines.Go("engine-close", func() {
+		defer close(e.closeDone)
 		for e.reconnecting.Load() {
 			time.Sleep(50 * time.Millisecond)
 		}
@@ -265,8 +356,11 @@ func (e *RTCEngine) Close() {
 			_ = subscriber.Close()
 		}
 
+		e.stopPingWorker()
+		e.stopNegotiationWatchdogs()
 		e.signalTransport.Close()
-	}()
+		e.failReliableMessages()
+	})
 }
 
 func (e *RTCEngine) IsConnected() bool {
@@ -294,6 +388,18 @@ func (e *RTCEngine) Subscriber() (*PCTransport, bool) {
 	return e.subscriber, e.subscriber != nil
 }
 
+// ConnectionDetails returns the selected candidate pair of each transport
+func (e *RTCEngine) ConnectionDetails() ConnectionDetails {
+	var details ConnectionDetails
+	if publisher, ok := e.Publisher(); ok {
+		details.Publisher = publisher.SelectedCandidatePair()
+	}
+	if subscriber, ok := e.Subscriber(); ok {
+		details.Subscriber = subscriber.SelectedCandidatePair()
+	}
+	return details
+}
+
 func (e *RTCEngine) setRTT(rtt uint32) {
 	if subscriber, ok := e.Subscriber(); ok {
 		subscriber.SetRTT(rtt)
@@ -308,7 +414,8 @@ func (e *RTCEngine) configure(
 	e.log.Debugw("Using ICE servers", "servers", iceServers)
 	configuration := e.makeRTCConfiguration(iceServers, clientConfig)
 
-	// reset reliable message sequence
+	// reset reliable message sequence, packets of a previous session are not resent
+	e.failReliableMessages()
 	e.reliableMsgLock.Lock()
 	e.reliableMsgSeq = 1
 	e.reliableMsgLock.Unlock()
@@ -345,10 +452,21 @@ func (e *RTCEngine) createPublisherPCLocked(configuration webrtc.Configuration)
 		Configuration:        configuration,
 		Codecs:               e.connParams.Codecs,
 		RetransmitBufferSize: e.connParams.RetransmitBufferSize,
+		RTCP:                 e.connParams.RTCP,
+		AudioOnly:            e.connParams.AudioOnly,
 		Pacer:                e.connParams.Pacer,
+		PacerDelays:          e.pacerDelays,
+		PacerBypassAudio:     e.connParams.AudioPriority != nil,
 		Interceptors:         e.connParams.Interceptors,
 		OnRTTUpdate:          e.setRTT,
 		IsSender:             true,
+		SDPTransformer:       e.connParams.SDPTransformer,
+		AudioLevels:          e.audioLevels,
+		PayloadTypes:         e.payloadTypes,
+		ReceiveBuffers:       e.receiveBuffers,
+		ICEKeepalive:         e.connParams.ICEKeepalive,
+		NetworkTypes:         e.connParams.ICENetworkTypes,
+		ICEUDPMux:            e.connParams.ICEUDPMux,
 	}); err != nil {
 		return err
 	}
@@ -370,8 +488,8 @@ func (e *RTCEngine) createPublisherPCLocked(configuration webrtc.Configuration)
 				protosignalling.ToProtoTrickle(init, livekit.SignalTarget_PUBLISHER, false),
 			),
 		); err != nil {
-			e.log.Errorw(
-				"could not send ICE candidate", err,
+			e.reportError(
+				ErrorCategoryICE, "could not send ICE candidate", err,
 				"transport", livekit.SignalTarget_PUBLISHER,
 			)
 		}
@@ -384,6 +502,10 @@ func (e *RTCEngine) createPublisherPCLocked(configuration webrtc.Configuration)
 		e.handleICEConnectionStateChange(publisher, livekit.SignalTarget_PUBLISHER, state)
 	})
 
+	e.publisher.OnSelectedCandidatePairChange(func(pair *ICECandidatePairInfo) {
+		e.engineHandler.OnActiveCandidatePairChanged(livekit.SignalTarget_PUBLISHER, pair)
+	})
+
 	e.publisher.OnOffer = func(offer webrtc.SessionDescription) {
 		e.hasPublish.Store(true)
 		if err := e.signalTransport.SendMessage(
@@ -391,8 +513,9 @@ func (e *RTCEngine) createPublisherPCLocked(configuration webrtc.Configuration)
 				protosignalling.ToProtoSessionDescription(offer, 0, nil),
 			),
 		); err != nil {
-			e.log.Errorw("could not send offer for publisher pc", err)
+			e.reportError(ErrorCategoryNegotiation, "could not send offer for publisher pc", err)
 		}
+		e.armNegotiationWatchdog(livekit.SignalTarget_PUBLISHER)
 	}
 
 	trueVal := true
@@ -408,6 +531,7 @@ func (e *RTCEngine) createPublisherPCLocked(configuration webrtc.Configuration)
 		return err
 	}
 	e.lossyDC.OnMessage(e.handleDataPacket)
+	e.watchBufferedAmount(e.lossyDC, livekit.DataPacket_LOSSY)
 
 	e.reliableDC, err = e.publisher.pc.CreateDataChannel(reliableDataChannelName, &webrtc.DataChannelInit{
 		Ordered: &trueVal,
@@ -417,6 +541,7 @@ func (e *RTCEngine) createPublisherPCLocked(configuration webrtc.Configuration)
 		return err
 	}
 	e.reliableDC.OnMessage(e.handleDataPacket)
+	e.watchBufferedAmount(e.reliableDC, livekit.DataPacket_RELIABLE)
 	e.dclock.Unlock()
 
 	return nil
@@ -432,6 +557,15 @@ func (e *RTCEngine) createSubscriberPCLocked(configuration webrtc.Configuration)
 		Configuration:        configuration,
 		Codecs:               e.connParams.Codecs,
 		RetransmitBufferSize: e.connParams.RetransmitBufferSize,
+		RTCP:                 e.connParams.RTCP,
+		AudioOnly:            e.connParams.AudioOnly,
+		SDPTransformer:       e.connParams.SDPTransformer,
+		AudioLevels:          e.audioLevels,
+		PayloadTypes:         e.payloadTypes,
+		ReceiveBuffers:       e.receiveBuffers,
+		ICEKeepalive:         e.connParams.ICEKeepalive,
+		NetworkTypes:         e.connParams.ICENetworkTypes,
+		ICEUDPMux:            e.connParams.ICEUDPMux,
 	}); err != nil {
 		return err
 	}
@@ -455,8 +589,8 @@ func (e *RTCEngine) createSubscriberPCLocked(configuration webrtc.Configuration)
 				protosignalling.ToProtoTrickle(init, livekit.SignalTarget_SUBSCRIBER, false),
 			),
 		); err != nil {
-			e.log.Errorw(
-				"could not send ICE candidate", err,
+			e.reportError(
+				ErrorCategoryICE, "could not send ICE candidate", err,
 				"transport", livekit.SignalTarget_SUBSCRIBER,
 			)
 		}
@@ -469,6 +603,10 @@ func (e *RTCEngine) createSubscriberPCLocked(configuration webrtc.Configuration)
 		e.handleICEConnectionStateChange(subscriber, livekit.SignalTarget_SUBSCRIBER, state)
 	})
 
+	e.subscriber.OnSelectedCandidatePairChange(func(pair *ICECandidatePairInfo) {
+		e.engineHandler.OnActiveCandidatePairChanged(livekit.SignalTarget_SUBSCRIBER, pair)
+	})
+
 	e.subscriber.pc.OnTrack(func(remote *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
 		e.engineHandler.OnMediaTrack(remote, receiver)
 	})
@@ -621,9 +759,13 @@ func (e *RTCEngine) UnregisterTrackPublishedListener(cid string) {
 func (e *RTCEngine) handleDataPacket(msg webrtc.DataChannelMessage) {
 	packet, err := e.readDataPacket(msg)
 	if err != nil {
+		e.reportError(ErrorCategoryData, "could not parse data packet", err, "size", len(msg.Data))
 		return
 	}
 	identity := packet.ParticipantIdentity
+	if !e.allowInboundPacket(packet, len(msg.Data)) {
+		return
+	}
 	switch msg := packet.Value.(type) {
 	case *livekit.DataPacket_User:
 		m := msg.User
@@ -677,6 +819,23 @@ func (e *RTCEngine) handleDataPacket(msg webrtc.DataChannelMessage) {
 	}
 }
 
+// marshalDataPacket encodes pck as protojson text with WithJSONDataPackets, as protobuf otherwise
+func (e *RTCEngine) marshalDataPacket(pck *livekit.DataPacket) (webrtc.DataChannelMessage, error) {
+	if e.connParams != nil && e.connParams.JSONDataPackets {
+		data, err := protojson.Marshal(pck)
+		return webrtc.DataChannelMessage{IsString: true, Data: data}, err
+	}
+	data, err := proto.Marshal(pck)
+	return webrtc.DataChannelMessage{Data: data}, err
+}
+
+func sendDataChannelMessage(dc *webrtc.DataChannel, msg webrtc.DataChannelMessage) error {
+	if msg.IsString {
+		return dc.SendText(string(msg.Data))
+	}
+	return dc.Send(msg.Data)
+}
+
 func (e *RTCEngine) readDataPacket(msg webrtc.DataChannelMessage) (*livekit.DataPacket, error) {
 	dataPacket := &livekit.DataPacket{}
 	if msg.IsString {
@@ -700,19 +859,41 @@ func (e *RTCEngine) handleDisconnect(fullReconnect bool) {
 		return
 	}
 
-	go func() {
+	e.goroutines.Go("reconnect", func() {
 		defer e.reconnecting.Store(false)
-		for reconnectCount := 0; reconnectCount < maxReconnectCount && !e.closed.Load(); reconnectCount++ {
+		policy := e.reconnectPolicy()
+		start := time.Now()
+		var (
+			lastErr   error
+			restarted bool
+		)
+		for reconnectCount := 0; !e.closed.Load(); reconnectCount++ {
+			delay, retry := policy.NextRetryDelay(reconnectCount, time.Since(start))
+			if !retry {
+				break
+			}
+			if delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-e.closing:
+					// the loop ends as closed is set
+					continue
+				}
+			}
+
 			if e.requiresFullReconnect.Load() {
 				fullReconnect = true
 			}
 			if fullReconnect {
-				if reconnectCount == 0 {
+				// also when resuming failed before
+				if !restarted {
+					restarted = true
 					e.engineHandler.OnRestarting()
 				}
 				e.log.Infow("restarting connection...", "reconnectCount", reconnectCount)
 				if err := e.restartConnection(); err != nil {
 					e.log.Errorw("restart connection failed", err)
+					lastErr = err
 				} else {
 					return
 				}
@@ -723,25 +904,22 @@ func (e *RTCEngine) handleDisconnect(fullReconnect bool) {
 				e.log.Infow("resuming connection...", "reconnectCount", reconnectCount)
 				if err := e.resumeConnection(); err != nil {
 					e.log.Errorw("resume connection failed", err)
+					lastErr = err
 				} else {
 					return
 				}
 			}
-
-			delay := time.Duration(reconnectCount*reconnectCount) * initialReconnectInterval
-			if delay > maxReconnectInterval {
-				break
-			}
-			if reconnectCount < maxReconnectCount-1 {
-				time.Sleep(delay)
-			}
 		}
 
-		e.engineHandler.OnDisconnected(Failed)
-	}()
+		if lastErr != nil {
+			lastErr = fmt.Errorf("could not reconnect: %w", lastErr)
+		}
+		e.engineHandler.OnDisconnectedWithError(newFailureDisconnectionError(lastErr, e.token.Load(), true))
+	})
 }
 
 func (e *RTCEngine) resumeConnection() error {
+	e.refreshICEServersOnResume()
 	err := e.signalTransport.Reconnect(
 		e.url,
 		e.token.Load(),
@@ -776,15 +954,19 @@ func (e *RTCEngine) resumeConnection() error {
 		}
 	}
 
+	e.startPingWorker(e.pingSettings())
+
 	if err = e.waitUntilConnected(); err != nil {
 		return err
 	}
+	e.resendReliableMessages(e.resumeLastMessageSeq.Swap(0))
 
 	e.engineHandler.OnResumed()
 	return nil
 }
 
 func (e *RTCEngine) restartConnection() error {
+	e.stopPingWorker()
 	if e.signalTransport.IsStarted() {
 		// TODO: special reason for reconnect?
 		e.SendLeaveWithReason(livekit.DisconnectReason_UNKNOWN_REASON)
@@ -800,26 +982,40 @@ func (e *RTCEngine) restartConnection() error {
 func (e *RTCEngine) createSubscriberPCAnswerAndSend() error {
 	answer, err := e.subscriber.pc.CreateAnswer(nil)
 	if err != nil {
-		e.log.Errorw("could not create answer", err)
+		e.reportError(ErrorCategoryNegotiation, "could not create answer", err)
 		return err
 	}
 	if err := e.subscriber.pc.SetLocalDescription(answer); err != nil {
-		e.log.Errorw("could not set subscriber local description", err)
+		e.reportError(ErrorCategoryNegotiation, "could not set subscriber local description", err)
 		return err
 	}
+	answer = e.subscriber.transformSDP(signalling.SDPDirectionOutgoing, answer)
 	e.log.Debugw("sending answer for subscriber", "answer", answer)
 	if err := e.signalTransport.SendMessage(
 		e.signalling.SignalSdpAnswer(
 			protosignalling.ToProtoSessionDescription(answer, 0, nil),
 		),
 	); err != nil {
-		e.log.Errorw("could not send answer for subscriber pc", err)
+		e.reportError(ErrorCategoryNegotiation, "could not send answer for subscriber pc", err)
+		return err
 	}
+	e.subscriberWatchdog.settled()
 	return nil
 }
 
 func (e *RTCEngine) makeRTCConfiguration(iceServers []*livekit.ICEServer, clientConfig *livekit.ClientConfiguration) webrtc.Configuration {
 	rtcICEServers := protosignalling.FromProtoIceServers(iceServers)
+	if e.connParams.ICEServerFilter != nil {
+		rtcICEServers = filterICEServers(rtcICEServers, e.connParams.ICEServerFilter)
+	}
+	// servers of WithICEServers come first
+	if e.connParams.ICEServersPolicy == ICEServersReplace {
+		rtcICEServers = nil
+	}
+	rtcICEServers = append(slices.Clone(e.connectionICEServers()), rtcICEServers...)
+	if override := e.iceServersOverride(); override != nil {
+		rtcICEServers = override
+	}
 	configuration := webrtc.Configuration{
 		ICEServers:         rtcICEServers,
 		ICETransportPolicy: e.connParams.ICETransportPolicy,
@@ -841,24 +1037,32 @@ func (e *RTCEngine) publishDataPacket(pck *livekit.DataPacket, kind livekit.Data
 	dc := e.GetDataChannel(kind)
 	if dc == nil {
 		e.log.Errorw("could not get data channel", nil, "kind", kind)
-		return errors.New("datachannel not found")
+		return ErrDataChannelNotFound
 	}
 
-	if kind == livekit.DataPacket_RELIABLE {
-		e.reliableMsgLock.Lock()
-		defer e.reliableMsgLock.Unlock()
-
-		pck.Sequence = e.reliableMsgSeq
-		e.reliableMsgSeq++
+	if kind != livekit.DataPacket_RELIABLE {
+		msg, err := e.marshalDataPacket(pck)
+		if err != nil {
+			e.reportError(ErrorCategoryData, "could not marshal data packet", err)
+			return err
+		}
+		sendDataChannelMessage(dc, msg)
+		return nil
 	}
 
-	data, err := proto.Marshal(pck)
+	e.reliableMsgLock.Lock()
+	pck.Sequence = e.reliableMsgSeq
+	msg, err := e.marshalDataPacket(pck)
 	if err != nil {
-		e.log.Errorw("could not marshal data packet", err)
+		e.reliableMsgLock.Unlock()
+		e.reportError(ErrorCategoryData, "could not marshal data packet", err)
 		return err
 	}
+	e.reliableMsgSeq++
+	sent := e.sendReliableLocked(dc, pck.Sequence, msg)
+	e.reliableMsgLock.Unlock()
 
-	dc.Send(data)
+	e.reportDataSent(sent, nil)
 	return nil
 }
 
@@ -972,13 +1176,11 @@ func (e *RTCEngine) publishStreamChunk(chunk *livekit.DataStream_Chunk, destinat
 	return publishErr
 }
 
-func (e *RTCEngine) publishStreamTrailer(streamId string, destinationIdentities []string) error {
+func (e *RTCEngine) publishStreamTrailer(trailer *livekit.DataStream_Trailer, destinationIdentities []string) error {
 	packet := &livekit.DataPacket{
 		DestinationIdentities: destinationIdentities,
 		Value: &livekit.DataPacket_StreamTrailer{
-			StreamTrailer: &livekit.DataStream_Trailer{
-				StreamId: streamId,
-			},
+			StreamTrailer: trailer,
 		},
 	}
 
@@ -989,17 +1191,9 @@ func (e *RTCEngine) publishStreamTrailer(streamId string, destinationIdentities
 	return publishErr
 }
 
-func (e *RTCEngine) isBufferStatusLow(kind livekit.DataPacket_Kind) bool {
-	dc := e.GetDataChannel(kind)
-	if dc != nil {
-		return dc.BufferedAmount() <= dc.BufferedAmountLowThreshold()
-	}
-	return false
-}
-
 func (e *RTCEngine) waitForBufferStatusLow(kind livekit.DataPacket_Kind) {
-	for !e.isBufferStatusLow(kind) {
-		time.Sleep(10 * time.Millisecond)
+	if err := e.waitForBufferStatusLowContext(context.Background(), kind); err != nil {
+		e.log.Debugw("stopped waiting for data channel buffer", "kind", kind, "error", err)
 	}
 }
 
@@ -1198,6 +1392,8 @@ func (e *RTCEngine) OnJoinResponse(res *livekit.JoinResponse) error {
 	)
 
 	e.signalTransport.Start()
+	e.setServerPingSettings(res.PingInterval, res.PingTimeout)
+	e.startPingWorker(e.pingSettings())
 
 	if e.signalling.PublishInJoin() {
 		if publisher, ok := e.Publisher(); ok {
@@ -1228,6 +1424,12 @@ func (e *RTCEngine) OnJoinResponse(res *livekit.JoinResponse) error {
 }
 
 func (e *RTCEngine) OnReconnectResponse(res *livekit.ReconnectResponse) error {
+	if e.resumingSession.Load() {
+		// there are no transports yet when resuming a stored session
+		e.sessionResumed.Store(true)
+		return e.configure(res.IceServers, res.ClientConfiguration, nil)
+	}
+	e.resumeLastMessageSeq.Store(res.LastMessageSeq)
 	configuration := e.makeRTCConfiguration(res.IceServers, res.ClientConfiguration)
 
 	e.pclock.Lock()
@@ -1235,14 +1437,14 @@ func (e *RTCEngine) OnReconnectResponse(res *livekit.ReconnectResponse) error {
 
 	if e.publisher != nil {
 		if err := e.publisher.SetConfiguration(configuration); err != nil {
-			e.log.Errorw("could not set rtc configuration for publisher", err)
+			e.reportError(ErrorCategoryNegotiation, "could not set rtc configuration for publisher", err)
 			return err
 		}
 	}
 
 	if e.subscriber != nil {
 		if err := e.subscriber.SetConfiguration(configuration); err != nil {
-			e.log.Errorw("could not set rtc configuration for subscriber", err)
+			e.reportError(ErrorCategoryNegotiation, "could not set rtc configuration for subscriber", err)
 			return err
 		}
 	}
@@ -1257,9 +1459,11 @@ func (e *RTCEngine) OnAnswer(sd webrtc.SessionDescription, answerId uint32, _mid
 	}
 
 	if err := e.publisher.SetRemoteDescription(sd); err != nil {
-		e.log.Errorw("could not set remote description", err)
+		e.reportError(ErrorCategoryNegotiation, "could not set remote description", err)
+		e.handleNegotiationFailure(livekit.SignalTarget_PUBLISHER, err)
 	} else {
 		e.log.Debugw("successfully set publisher answer")
+		e.publisherWatchdog.settled()
 	}
 }
 
@@ -1270,8 +1474,10 @@ func (e *RTCEngine) OnOffer(sd webrtc.SessionDescription, offerId uint32, _midTo
 	}
 
 	e.log.Debugw("received offer for subscriber", "offer", sd, "offerId", offerId)
+	e.subscriberOffer.Store(&sd)
 	if err := e.subscriber.SetRemoteDescription(sd); err != nil {
-		e.log.Errorw("could not set remote description", err)
+		e.reportError(ErrorCategoryNegotiation, "could not set remote description", err)
+		e.handleNegotiationFailure(livekit.SignalTarget_SUBSCRIBER, err)
 		return
 	}
 }
@@ -1293,7 +1499,7 @@ func (e *RTCEngine) OnTrickle(init webrtc.ICECandidateInit, target livekit.Signa
 		err = e.subscriber.AddICECandidate(init)
 	}
 	if err != nil {
-		e.log.Errorw("could not add ICE candidate", err)
+		e.reportError(ErrorCategoryICE, "could not add ICE candidate", err)
 	}
 }
 
@@ -1337,16 +1543,22 @@ func (e *RTCEngine) OnTrackRemoteMuted(request *livekit.MuteTrackRequest) {
 
 func (e *RTCEngine) OnTokenRefresh(refreshToken string) {
 	e.token.Store(refreshToken)
+	e.engineHandler.OnTokenRefreshed(refreshToken)
 }
 
 func (e *RTCEngine) OnLeave(leave *livekit.LeaveRequest) {
 	e.log.Debugw("received leave request", "action", leave.GetAction())
-	switch leave.GetAction() {
+	action := leave.GetAction()
+	if leave.GetReason() == livekit.DisconnectReason_DUPLICATE_IDENTITY {
+		// the identity connected elsewhere, reconnecting would evict the new connection
+		action = livekit.LeaveRequest_DISCONNECT
+	}
+	switch action {
 	case livekit.LeaveRequest_DISCONNECT:
-		e.Close()
+		e.CloseAsync()
 		reason := leave.GetReason()
 		e.log.Infow("server initiated leave", "reason", reason)
-		e.engineHandler.OnDisconnected(GetDisconnectionReason(reason))
+		e.engineHandler.OnDisconnectedWithError(newLeaveDisconnectionError(reason))
 
 	case livekit.LeaveRequest_RECONNECT:
 		e.handleDisconnect(true)
@@ -1370,6 +1582,29 @@ func (e *RTCEngine) OnSubscribedAudioCodecUpdate(subscribedAudioCodecUpdate *liv
 	e.engineHandler.OnSubscribedAudioCodecUpdate(subscribedAudioCodecUpdate)
 }
 
+func (e *RTCEngine) OnSubscriptionResponse(response *livekit.SubscriptionResponse) {
+	e.engineHandler.OnSubscriptionResponse(response)
+}
+
+func (e *RTCEngine) OnPong(pong *livekit.Pong) {
+	e.pongRespReceived.Store(true)
+	e.onPong(pong)
+}
+
+// OnLegacyPong handles the answer of older servers, which echo back only the timestamp
+func (e *RTCEngine) OnLegacyPong(lastPingTimestamp int64) {
+	e.onPong(&livekit.Pong{LastPingTimestamp: lastPingTimestamp})
+}
+
+func (e *RTCEngine) onPong(pong *livekit.Pong) {
+	e.lastPongAt.Store(time.Now())
+	if pong.LastPingTimestamp > 0 {
+		if rtt := time.Since(time.UnixMilli(pong.LastPingTimestamp)); rtt >= 0 {
+			e.signalRTT.Store(rtt)
+		}
+	}
+}
+
 func (e *RTCEngine) OnMediaSectionsRequirement(mediaSectionsRequirement *livekit.MediaSectionsRequirement) {
 	e.engineHandler.OnMediaSectionsRequirement(mediaSectionsRequirement)
 }
@@ -1381,3 +1616,171 @@ func setConfiguration(pcTransport *PCTransport, configuration webrtc.Configurati
 		pcTransport.SetConfiguration(configuration)
 	}
 }
+
+// -------------------------------------------
+
+// SignalRTT returns the round trip time measured with signal pings, 0 until measured
+func (e *RTCEngine) SignalRTT() time.Duration {
+	return e.signalRTT.Load()
+}
+
+func (e *RTCEngine) setServerPingSettings(interval, timeout int32) {
+	e.pingLock.Lock()
+	e.serverPingInterval = time.Duration(interval) * time.Second
+	e.serverPingTimeout = time.Duration(timeout) * time.Second
+	e.pingLock.Unlock()
+}
+
+// goroutineRegistry returns nil for a nil engine, which runs goroutines untracked
+func (e *RTCEngine) goroutineRegistry() *goroutineRegistry {
+	if e == nil {
+		return nil
+	}
+	return e.goroutines
+}
+
+func (e *RTCEngine) audioLevelMonitor() *sdkinterceptor.AudioLevelMonitor {
+	if e == nil {
+		return nil
+	}
+	return e.audioLevels
+}
+
+func (e *RTCEngine) payloadTypeMonitor() *sdkinterceptor.PayloadTypeMonitor {
+	if e == nil {
+		return nil
+	}
+	return e.payloadTypes
+}
+
+// pacerDelayMonitor returns nil when packets are not paced
+func (e *RTCEngine) pacerDelayMonitor() *sdkinterceptor.PacerDelayMonitor {
+	if e == nil || e.connParams == nil || e.connParams.Pacer == nil {
+		return nil
+	}
+	return e.pacerDelays
+}
+
+func (e *RTCEngine) publishDefaults() signalling.PublishDefaults {
+	if e.connParams == nil {
+		return signalling.PublishDefaults{}
+	}
+	return e.connParams.PublishDefaults
+}
+
+func (e *RTCEngine) reliableDataByDefault() bool {
+	return e.connParams != nil && e.connParams.ReliableDataByDefault
+}
+
+func (e *RTCEngine) dtmfSequenceConfig() DTMFSequenceConfig {
+	if e.connParams == nil || e.connParams.DTMFSequence == nil {
+		return DTMFSequenceConfig{Terminators: "#"}
+	}
+	return *e.connParams.DTMFSequence
+}
+
+func (e *RTCEngine) interfaceMTULimit() bool {
+	return e.connParams != nil && e.connParams.InterfaceMTULimit
+}
+
+func (e *RTCEngine) isAudioOnly() bool {
+	return e.connParams != nil && e.connParams.AudioOnly
+}
+
+// pingSettings returns ping interval and timeout, settings from connect options take precedence over the server's
+func (e *RTCEngine) pingSettings() (time.Duration, time.Duration) {
+	e.pingLock.Lock()
+	interval, timeout := e.serverPingInterval, e.serverPingTimeout
+	e.pingLock.Unlock()
+
+	if e.connParams != nil {
+		if e.connParams.PingInterval > 0 {
+			interval = e.connParams.PingInterval
+		}
+		if e.connParams.PingTimeout > 0 {
+			timeout = e.connParams.PingTimeout
+		}
+	}
+	return interval, timeout
+}
+
+func (e *RTCEngine) startPingWorker(interval, timeout time.Duration) {
+	e.stopPingWorker()
+	if interval <= 0 {
+		return
+	}
+
+	pinger, ok := e.signalling.(signalling.SignalPinger)
+	if !ok {
+		return
+	}
+
+	stop := make(chan struct{})
+	e.pingLock.Lock()
+	e.pingStop = stop
+	e.pingLock.Unlock()
+
+	// the server may have changed, it is asked with both pings again
+	e.pongRespReceived.Store(false)
+	e.lastPongAt.Store(time.Now())
+	e.goroutines.Go("signal-ping", func() { e.pingWorker(pinger, interval, timeout, stop) })
+}
+
+func (e *RTCEngine) stopPingWorker() {
+	e.pingLock.Lock()
+	if e.pingStop != nil {
+		close(e.pingStop)
+		e.pingStop = nil
+	}
+	e.pingLock.Unlock()
+}
+
+// pingWorker sends a PingReq every interval, and the legacy Ping as well until the server answered a PingReq,
+// older servers answer only the legacy one. The connection is resumed when no answer arrives within timeout.
+func (e *RTCEngine) pingWorker(pinger signalling.SignalPinger, interval, timeout time.Duration, stop <-chan struct{}) {
+	// check for pongs more often than pinging, to detect a dead connection soon after the timeout
+	checkInterval := interval
+	if timeout > 0 && timeout/4 < checkInterval {
+		checkInterval = timeout / 4
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	lastPingAt := time.Time{}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if e.closed.Load() {
+			return
+		}
+
+		if timeout > 0 {
+			if sinceLastPong := time.Since(e.lastPongAt.Load()); sinceLastPong > timeout {
+				e.log.Warnw("signal connection timed out", nil, "sinceLastPong", sinceLastPong, "timeout", timeout)
+				e.stopPingWorker()
+				e.handleDisconnect(false)
+				return
+			}
+		}
+
+		if time.Since(lastPingAt) < interval {
+			continue
+		}
+		lastPingAt = time.Now()
+		ping := &livekit.Ping{
+			Timestamp: lastPingAt.UnixMilli(),
+			Rtt:       e.signalRTT.Load().Milliseconds(),
+		}
+		if err := e.signalTransport.SendMessage(pinger.SignalPingReq(ping)); err != nil {
+			e.log.Debugw("could not send ping", "error", err)
+		}
+		if !e.pongRespReceived.Load() {
+			if err := e.signalTransport.SendMessage(pinger.SignalPing(ping.Timestamp)); err != nil {
+				e.log.Debugw("could not send legacy ping", "error", err)
+			}
+		}
+	}
+}
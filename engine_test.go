@@ -0,0 +1,144 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestMarshalDataPacket(t *testing.T) {
+	pck := &livekit.DataPacket{
+		Kind:     livekit.DataPacket_RELIABLE,
+		Sequence: 7,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{Payload: []byte("hello"), Topic: proto.String("chat")},
+		},
+	}
+
+	for _, jsonPackets := range []bool{false, true} {
+		e := &RTCEngine{log: logger, connParams: &signalling.ConnectParams{JSONDataPackets: jsonPackets}}
+		msg, err := e.marshalDataPacket(pck)
+		require.NoError(t, err)
+		require.Equal(t, jsonPackets, msg.IsString)
+
+		read, err := e.readDataPacket(webrtc.DataChannelMessage{IsString: msg.IsString, Data: msg.Data})
+		require.NoError(t, err)
+		require.True(t, proto.Equal(pck, read))
+	}
+}
+
+func TestWithJSONDataPacketsAdvertises(t *testing.T) {
+	params := &signalling.ConnectParams{}
+	WithExtraAttributes(map[string]string{"role": "sensor"})(params)
+	WithJSONDataPackets()(params)
+	require.True(t, params.JSONDataPackets)
+	require.Equal(t, map[string]string{"role": "sensor", AttributeDataPacketEncoding: "json"}, params.Attributes)
+}
+
+// pingSignalTransport records the pings sent and fails to reconnect
+type pingSignalTransport struct {
+	signalling.SignalTransport
+
+	lock        sync.Mutex
+	pings       int
+	legacyPings int
+	reconnects  int
+}
+
+func (s *pingSignalTransport) Close() {}
+
+func (s *pingSignalTransport) SendMessage(msg proto.Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch msg.(*livekit.SignalRequest).Message.(type) {
+	case *livekit.SignalRequest_PingReq:
+		s.pings++
+	case *livekit.SignalRequest_Ping:
+		s.legacyPings++
+	}
+	return nil
+}
+
+func (s *pingSignalTransport) Reconnect(string, string, signalling.ConnectParams, string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reconnects++
+	return errors.New("unavailable")
+}
+
+func (s *pingSignalTransport) counts() (int, int, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.pings, s.legacyPings, s.reconnects
+}
+
+func TestPingWorker(t *testing.T) {
+	room := NewRoom(nil)
+	e := room.engine
+	transport := &pingSignalTransport{}
+	e.signalTransport = transport
+	e.connParams = &signalling.ConnectParams{PingInterval: 5 * time.Millisecond, PingTimeout: time.Hour}
+	defer e.Close(context.Background())
+
+	// both pings until the server answers a PingReq
+	e.startPingWorker(e.pingSettings())
+	require.Eventually(t, func() bool {
+		pings, legacyPings, _ := transport.counts()
+		return pings > 0 && legacyPings > 0
+	}, time.Second, time.Millisecond)
+
+	e.OnLegacyPong(time.Now().UnixMilli())
+	e.OnPong(&livekit.Pong{LastPingTimestamp: time.Now().Add(-20 * time.Millisecond).UnixMilli(), Timestamp: 1})
+	require.GreaterOrEqual(t, e.SignalRTT(), 20*time.Millisecond)
+	_, legacyPings, _ := transport.counts()
+	require.Eventually(t, func() bool {
+		pings, _, _ := transport.counts()
+		return pings > 5
+	}, time.Second, time.Millisecond)
+	_, after, _ := transport.counts()
+	// at most one legacy ping raced the pong
+	require.LessOrEqual(t, after, legacyPings+1)
+	e.stopPingWorker()
+}
+
+func TestPingTimeoutResumes(t *testing.T) {
+	room := NewRoom(nil)
+	e := room.engine
+	transport := &pingSignalTransport{}
+	e.signalTransport = transport
+	e.connParams = &signalling.ConnectParams{PingInterval: 5 * time.Millisecond, PingTimeout: 20 * time.Millisecond}
+	e.hasConnected.Store(true)
+	defer e.Close(context.Background())
+
+	// no pongs, the connection is resumed after the timeout
+	e.startPingWorker(e.pingSettings())
+	require.Eventually(t, func() bool {
+		_, _, reconnects := transport.counts()
+		return reconnects > 0
+	}, 2*time.Second, time.Millisecond)
+	require.True(t, room.ConnectionState().IsReconnecting())
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrorCategory is the area of a non-fatal internal error reported with OnError
+type ErrorCategory string
+
+const (
+	// ICE candidates could not be sent or added
+	ErrorCategoryICE ErrorCategory = "ice"
+	// offers, answers or peer connection configurations could not be created or applied
+	ErrorCategoryNegotiation ErrorCategory = "negotiation"
+	// data packets could not be marshaled or parsed, unparsable packets are dropped
+	ErrorCategoryData ErrorCategory = "data"
+	// track settings or subscriptions could not be sent
+	ErrorCategoryMedia ErrorCategory = "media"
+)
+
+const (
+	// OnError is called at most this often per second and category, with a burst of the same size
+	errorReportsPerSecond = 10
+)
+
+// SuppressedErrorsError wraps the first error reported after errors of the same category
+// were dropped by the rate limit of OnError
+type SuppressedErrorsError struct {
+	Err        error
+	Suppressed int
+}
+
+func (e *SuppressedErrorsError) Error() string {
+	return fmt.Sprintf("%v (%d earlier errors suppressed)", e.Err, e.Suppressed)
+}
+
+func (e *SuppressedErrorsError) Unwrap() error {
+	return e.Err
+}
+
+// errorReporter rate limits errors per category, the zero value is ready to use
+type errorReporter struct {
+	lock       sync.Mutex
+	limiters   map[ErrorCategory]*rate.Limiter
+	suppressed map[ErrorCategory]int
+}
+
+// allow returns the error to report, false when it is suppressed
+func (r *errorReporter) allow(category ErrorCategory, err error) (error, bool) {
+	return r.allowAt(time.Now(), category, err)
+}
+
+func (r *errorReporter) allowAt(now time.Time, category ErrorCategory, err error) (error, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.limiters == nil {
+		r.limiters = make(map[ErrorCategory]*rate.Limiter)
+		r.suppressed = make(map[ErrorCategory]int)
+	}
+	limiter := r.limiters[category]
+	if limiter == nil {
+		limiter = rate.NewLimiter(errorReportsPerSecond, errorReportsPerSecond)
+		r.limiters[category] = limiter
+	}
+	if !limiter.AllowN(now, 1) {
+		r.suppressed[category]++
+		return nil, false
+	}
+	if suppressed := r.suppressed[category]; suppressed != 0 {
+		delete(r.suppressed, category)
+		return &SuppressedErrorsError{Err: err, Suppressed: suppressed}, true
+	}
+	return err, true
+}
+
+// reportError logs a non-fatal error and passes it on to OnError
+func (e *RTCEngine) reportError(category ErrorCategory, msg string, err error, keysAndValues ...any) {
+	e.log.Errorw(msg, err, keysAndValues...)
+	if e.engineHandler == nil {
+		return
+	}
+	if err == nil {
+		err = fmt.Errorf("%s", msg)
+	} else {
+		err = fmt.Errorf("%s: %w", msg, err)
+	}
+	if err, ok := e.errorReports.allow(category, err); ok {
+		e.engineHandler.OnError(err, category)
+	}
+}
+
+func (r *Room) OnError(err error, category ErrorCategory) {
+	go r.callback.OnError(err, category)
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorReporterRateLimit(t *testing.T) {
+	var r errorReporter
+	now := time.Now()
+	errFailed := errors.New("failed")
+
+	for i := 0; i < errorReportsPerSecond; i++ {
+		err, ok := r.allowAt(now, ErrorCategoryICE, errFailed)
+		require.True(t, ok)
+		require.Same(t, errFailed, err)
+	}
+	for i := 0; i < 3; i++ {
+		_, ok := r.allowAt(now, ErrorCategoryICE, errFailed)
+		require.False(t, ok)
+	}
+
+	// categories are limited independently
+	err, ok := r.allowAt(now, ErrorCategoryData, errFailed)
+	require.True(t, ok)
+	require.Same(t, errFailed, err)
+
+	// once tokens are available again, the dropped errors are counted on the next one
+	now = now.Add(time.Second / errorReportsPerSecond)
+	err, ok = r.allowAt(now, ErrorCategoryICE, errFailed)
+	require.True(t, ok)
+	var suppressed *SuppressedErrorsError
+	require.ErrorAs(t, err, &suppressed)
+	require.Equal(t, 3, suppressed.Suppressed)
+	require.ErrorIs(t, err, errFailed)
+}
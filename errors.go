@@ -34,4 +34,29 @@ var (
 	ErrNoPeerConnection         = errors.New("peer connection not established")
 	ErrAborted                  = errors.New("operation was aborted")
 	ErrMissingPrimaryCodec      = errors.New("primary track must be TrackLocalWithCodec when backup codec is present")
+	ErrNegotiationTimeout       = errors.New("no answer received for offer")
+	ErrAudioOnly                = errors.New("video is not supported on audio only connections")
+	ErrStreamQuotaExceeded      = errors.New("data stream quota exceeded")
+	ErrStreamAborted            = errors.New("data stream aborted")
+	ErrEgressNotFound           = errors.New("egress not found")
+	ErrEgressNotActive          = errors.New("egress is not active")
+	ErrIngressNotFound          = errors.New("ingress not found")
+	ErrPublishNotPermitted      = errors.New("participant is not permitted to publish")
+	ErrCodecNotNegotiated       = errors.New("codec was not negotiated")
+	ErrRoomNotFound             = errors.New("room not found")
+	ErrParticipantNotFound      = errors.New("participant not found")
+	ErrTokenExpired             = errors.New("token expired")
+	ErrDuplicateIdentity        = errors.New("identity is already connected")
+	ErrUnknownRID               = errors.New("unknown simulcast rid")
+	ErrNoSession                = errors.New("no stored session")
+	ErrSessionNotResumed        = errors.New("server did not resume the session")
+	ErrDataChannelNotFound      = errors.New("datachannel not found")
+	ErrDataNotSent              = errors.New("data packet was not sent before the connection was lost")
+	ErrFileChecksumMismatch     = errors.New("checksum of received file does not match")
+	ErrFileIncomplete           = errors.New("file transfer ended before the file was complete")
+	ErrFileOffsetMismatch       = errors.New("file transfer offset does not match the partially received file")
+	ErrInvalidFileName          = errors.New("invalid file name")
+	ErrMailboxTimeout           = errors.New("no mailbox response received in time")
+	ErrRpcQueueFull             = errors.New("too many rpc calls queued for the destination")
+	ErrVirtualStreamRejected    = errors.New("no handler registered for the virtual stream")
 )
@@ -0,0 +1,236 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"time"
+)
+
+// data packets of senders that are not known yet are held this long, waiting for the participant update
+const pendingEventsTimeout = time.Second
+
+// eventQueue runs the callbacks of a remote participant one at a time, in the order they were queued,
+// so that a participant is connected before its tracks are published, and tracks are published before
+// they are subscribed. A new queue is held until released, when the participant is announced.
+// Callbacks that may run as long as a track, see enqueueDetached, run on a queue of their own for the track,
+// so they don't hold back the events of other tracks. The queue runs user callbacks, so its goroutine is not
+// tracked by the goroutineRegistry.
+type eventQueue struct {
+	lock    sync.Mutex
+	events  []func()
+	held    bool
+	running bool
+	// queues of enqueueDetached by key, removed once idle
+	detached map[string]*eventQueue
+	onIdle   func()
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{
+		held: true,
+	}
+}
+
+func (q *eventQueue) enqueue(event func()) {
+	q.lock.Lock()
+	q.events = append(q.events, event)
+	q.startLocked()
+	q.lock.Unlock()
+}
+
+// enqueueDetached runs event on the queue of key once the events queued before it ran,
+// e.g. OnTrackSubscribed, which commonly reads the track until it ends
+func (q *eventQueue) enqueueDetached(key string, event func()) {
+	q.enqueue(func() { q.enqueueOnDetached(key, true, event) })
+}
+
+// enqueueAfterDetached runs event once the events queued before it ran, and after the detached events of key,
+// e.g. OnTrackUnsubscribed, which must not be called before OnTrackSubscribed of the track returned
+func (q *eventQueue) enqueueAfterDetached(key string, event func()) {
+	q.enqueue(func() {
+		if !q.enqueueOnDetached(key, false, event) {
+			event()
+		}
+	})
+}
+
+func (q *eventQueue) enqueueOnDetached(key string, create bool, event func()) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	d := q.detached[key]
+	if d == nil {
+		if !create {
+			return false
+		}
+		d = &eventQueue{}
+		d.onIdle = func() {
+			q.lock.Lock()
+			defer q.lock.Unlock()
+			d.lock.Lock()
+			defer d.lock.Unlock()
+			if q.detached[key] == d && !d.running && len(d.events) == 0 {
+				delete(q.detached, key)
+			}
+		}
+		if q.detached == nil {
+			q.detached = make(map[string]*eventQueue)
+		}
+		q.detached[key] = d
+	}
+	d.enqueue(event)
+	return true
+}
+
+// release runs first, if set, ahead of the events queued while held
+func (q *eventQueue) release(first func()) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !q.held {
+		return
+	}
+	q.held = false
+	if first != nil {
+		q.events = append([]func(){first}, q.events...)
+	}
+	q.startLocked()
+}
+
+func (q *eventQueue) startLocked() {
+	if q.held || q.running || len(q.events) == 0 {
+		return
+	}
+	q.running = true
+	go q.run()
+}
+
+func (q *eventQueue) run() {
+	for {
+		q.lock.Lock()
+		if len(q.events) == 0 {
+			q.running = false
+			q.lock.Unlock()
+			if q.onIdle != nil {
+				q.onIdle()
+			}
+			return
+		}
+		event := q.events[0]
+		q.events[0] = nil
+		q.events = q.events[1:]
+		q.lock.Unlock()
+
+		event()
+	}
+}
+
+// latestEvent runs callback with the values posted to it one at a time, from a goroutine of its own.
+// Values posted while the callback runs replace each other, only the latest one is passed next,
+// so a slow callback of periodic reports sees current values instead of falling behind.
+type latestEvent[T any] struct {
+	callback func(T)
+
+	lock    sync.Mutex
+	pending T
+	posted  bool
+	running bool
+}
+
+func newLatestEvent[T any](callback func(T)) *latestEvent[T] {
+	return &latestEvent[T]{callback: callback}
+}
+
+func (l *latestEvent[T]) post(value T) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.pending, l.posted = value, true
+	if !l.running {
+		l.running = true
+		go l.run()
+	}
+}
+
+func (l *latestEvent[T]) run() {
+	for {
+		l.lock.Lock()
+		if !l.posted {
+			l.running = false
+			l.lock.Unlock()
+			return
+		}
+		value := l.pending
+		var zero T
+		l.pending, l.posted = zero, false
+		l.lock.Unlock()
+
+		l.callback(value)
+	}
+}
+
+type pendingEvents struct {
+	events []func(rp *RemoteParticipant)
+	timer  *time.Timer
+}
+
+// holdEvent delays an event of a participant that is not known yet, until it connects or the hold times out
+func (r *Room) holdEvent(identity string, event func(rp *RemoteParticipant)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	pending := r.pendingEvents[identity]
+	if pending == nil {
+		pending = &pendingEvents{}
+		pending.timer = time.AfterFunc(pendingEventsTimeout, func() {
+			r.releaseEvents(identity, nil)
+		})
+		r.pendingEvents[identity] = pending
+	}
+	pending.events = append(pending.events, event)
+}
+
+// releaseEvents queues held events of identity to rp, or runs them without a participant when rp is nil
+func (r *Room) releaseEvents(identity string, rp *RemoteParticipant) {
+	r.lock.Lock()
+	pending := r.pendingEvents[identity]
+	delete(r.pendingEvents, identity)
+	r.lock.Unlock()
+	if pending == nil {
+		return
+	}
+	pending.timer.Stop()
+
+	if rp != nil {
+		for _, event := range pending.events {
+			rp.events.enqueue(func() { event(rp) })
+		}
+		return
+	}
+	for _, event := range pending.events {
+		r.releasedEvents.enqueue(func() { event(nil) })
+	}
+}
+
+func (r *Room) clearPendingEvents() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for identity, pending := range r.pendingEvents {
+		pending.timer.Stop()
+		delete(r.pendingEvents, identity)
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestParticipantEventOrder(t *testing.T) {
+	var (
+		lock   sync.Mutex
+		events []string
+	)
+	record := func(event string) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event)
+	}
+	cb := NewRoomCallback()
+	cb.OnParticipantConnected = func(rp *RemoteParticipant) {
+		// a slow handler must not let later events overtake it
+		time.Sleep(20 * time.Millisecond)
+		record("connected:" + rp.Identity())
+	}
+	cb.OnTrackPublished = func(pub *RemoteTrackPublication, rp *RemoteParticipant) {
+		record("published:" + pub.SID())
+	}
+	cb.OnDataPacket = func(data DataPacket, params DataReceiveParams) {
+		require.NotNil(t, params.Sender)
+		record("data:" + string(data.(*UserDataPacket).Payload))
+	}
+	cb.OnParticipantDisconnected = func(rp *RemoteParticipant) {
+		record("disconnected:" + rp.Identity())
+	}
+	room := NewRoom(cb)
+
+	// data from a sender that is not known yet is held until it connects
+	room.OnDataPacket("alice", &UserDataPacket{Payload: []byte("early")})
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{{
+		Sid:      "PA_alice",
+		Identity: "alice",
+		State:    livekit.ParticipantInfo_ACTIVE,
+		Tracks:   []*livekit.TrackInfo{{Sid: "TR_audio", Type: livekit.TrackType_AUDIO}},
+	}})
+	room.OnDataPacket("alice", &UserDataPacket{Payload: []byte("late")})
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{{
+		Sid:      "PA_alice",
+		Identity: "alice",
+		State:    livekit.ParticipantInfo_DISCONNECTED,
+		Version:  1,
+	}})
+
+	expected := []string{
+		"connected:alice",
+		"published:TR_audio",
+		"data:early",
+		"data:late",
+		"disconnected:alice",
+	}
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(events) == len(expected)
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, expected, events)
+}
+
+func TestPendingEventsTimeout(t *testing.T) {
+	received := make(chan DataReceiveParams, 1)
+	cb := NewRoomCallback()
+	cb.OnDataPacket = func(data DataPacket, params DataReceiveParams) {
+		received <- params
+	}
+	room := NewRoom(cb)
+
+	room.OnDataPacket("ghost", &UserDataPacket{Payload: []byte("hello")})
+	select {
+	case params := <-received:
+		require.Equal(t, "ghost", params.SenderIdentity)
+		require.Nil(t, params.Sender)
+	case <-time.After(2 * pendingEventsTimeout):
+		t.Fatal("held data not delivered")
+	}
+}
+
+func TestParticipantSnapshot(t *testing.T) {
+	for _, backfill := range []bool{false, true} {
+		snapshots := make(chan []*RemoteParticipant, 1)
+		connected := make(chan string, 2)
+		cb := NewRoomCallback()
+		cb.OnParticipantSnapshot = func(participants []*RemoteParticipant) {
+			snapshots <- participants
+		}
+		cb.OnParticipantConnected = func(rp *RemoteParticipant) {
+			connected <- rp.Identity()
+		}
+		room := NewRoom(cb)
+		room.engine.connParams = &signalling.ConnectParams{ParticipantConnectedOnJoin: backfill}
+
+		room.OnRoomJoined(
+			&livekit.Room{Name: "room"},
+			&livekit.ParticipantInfo{Sid: "PA_me", Identity: "me"},
+			[]*livekit.ParticipantInfo{{Sid: "PA_alice", Identity: "alice"}, {Sid: "PA_bob", Identity: "bob"}},
+			nil, nil,
+		)
+		select {
+		case participants := <-snapshots:
+			require.Len(t, participants, 2)
+			require.Equal(t, "alice", participants[0].Identity())
+		case <-time.After(time.Second):
+			t.Fatal("no snapshot")
+		}
+
+		if !backfill {
+			select {
+			case identity := <-connected:
+				t.Fatalf("unexpected connected event for %s", identity)
+			case <-time.After(50 * time.Millisecond):
+			}
+			continue
+		}
+		var identities []string
+		for range 2 {
+			select {
+			case identity := <-connected:
+				identities = append(identities, identity)
+			case <-time.After(time.Second):
+				t.Fatal("no connected event")
+			}
+		}
+		require.ElementsMatch(t, []string{"alice", "bob"}, identities)
+	}
+}
+
+func TestParticipantEventsAfterBlockingTrackSubscribed(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	subscribed := make(chan struct{})
+	muted := make(chan struct{})
+	disconnected := make(chan struct{})
+	cb := NewRoomCallback()
+	cb.OnTrackSubscribed = func(track *webrtc.TrackRemote, pub *RemoteTrackPublication, rp *RemoteParticipant) {
+		close(subscribed)
+		// like a handler reading the track until it ends
+		<-release
+	}
+	cb.OnTrackMuted = func(pub TrackPublication, p Participant) {
+		close(muted)
+	}
+	cb.OnParticipantDisconnected = func(rp *RemoteParticipant) {
+		close(disconnected)
+	}
+	room := NewRoom(cb)
+
+	info := &livekit.ParticipantInfo{
+		Sid:      "PA_alice",
+		Identity: "alice",
+		State:    livekit.ParticipantInfo_ACTIVE,
+		Tracks:   []*livekit.TrackInfo{{Sid: "TR_video", Type: livekit.TrackType_VIDEO}},
+	}
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{info})
+	rp := room.GetParticipantByIdentity("alice")
+	require.NotNil(t, rp)
+	rp.addSubscribedMediaTrack(nil, "TR_video", nil)
+
+	waitFor := func(ch chan struct{}, event string) {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatalf("%s not delivered", event)
+		}
+	}
+	waitFor(subscribed, "subscribed")
+
+	info = proto.Clone(info).(*livekit.ParticipantInfo)
+	info.Version = 1
+	info.Tracks[0].Muted = true
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{info})
+	waitFor(muted, "muted")
+
+	info = proto.Clone(info).(*livekit.ParticipantInfo)
+	info.Version = 2
+	info.State = livekit.ParticipantInfo_DISCONNECTED
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{info})
+	waitFor(disconnected, "disconnected")
+}
+
+func TestLatestEvent(t *testing.T) {
+	var (
+		lock       sync.Mutex
+		values     []int
+		concurrent bool
+		running    bool
+	)
+	block := make(chan struct{})
+	started := make(chan struct{}, 1)
+	l := newLatestEvent(func(v int) {
+		lock.Lock()
+		concurrent = concurrent || running
+		running = true
+		values = append(values, v)
+		lock.Unlock()
+		if v == 1 {
+			started <- struct{}{}
+			<-block
+		}
+		lock.Lock()
+		running = false
+		lock.Unlock()
+	})
+
+	l.post(1)
+	<-started
+	// posted while the callback runs, only the latest is passed
+	l.post(2)
+	l.post(3)
+	close(block)
+
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(values) == 2 && !running
+	}, time.Second, time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, []int{1, 3}, values)
+	require.False(t, concurrent)
+}
+
+func TestTrackUnsubscribedAfterSubscribed(t *testing.T) {
+	var (
+		lock   sync.Mutex
+		events []string
+	)
+	record := func(event string) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event)
+	}
+	release := make(chan struct{})
+	cb := NewRoomCallback()
+	cb.OnTrackSubscribed = func(track *webrtc.TrackRemote, pub *RemoteTrackPublication, rp *RemoteParticipant) {
+		<-release
+		record("subscribed")
+	}
+	cb.OnTrackUnsubscribed = func(track *webrtc.TrackRemote, pub *RemoteTrackPublication, rp *RemoteParticipant) {
+		record("unsubscribed")
+	}
+	cb.OnTrackUnpublished = func(pub *RemoteTrackPublication, rp *RemoteParticipant) {
+		record("unpublished")
+	}
+	cb.OnTrackMuted = func(pub TrackPublication, p Participant) {
+		record("muted")
+	}
+	room := NewRoom(cb)
+
+	info := &livekit.ParticipantInfo{
+		Sid:      "PA_alice",
+		Identity: "alice",
+		State:    livekit.ParticipantInfo_ACTIVE,
+		Tracks:   []*livekit.TrackInfo{{Sid: "TR_video", Type: livekit.TrackType_VIDEO}},
+	}
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{info})
+	rp := room.GetParticipantByIdentity("alice")
+	require.NotNil(t, rp)
+	rp.addSubscribedMediaTrack(&webrtc.TrackRemote{}, "TR_video", nil)
+
+	// the events of other tracks are not held back by the running subscribed callback
+	info = proto.Clone(info).(*livekit.ParticipantInfo)
+	info.Version = 1
+	info.Tracks[0].Muted = true
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{info})
+	rp.unpublishTrack("TR_video", true)
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(events) == 1
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(events) == 4
+	}, time.Second, time.Millisecond)
+	require.Equal(t, []string{"muted", "subscribed", "unsubscribed", "unpublished"}, events)
+
+	// the queue of the track is removed once idle
+	require.Eventually(t, func() bool {
+		rp.events.lock.Lock()
+		defer rp.events.lock.Unlock()
+		return len(rp.events.detached) == 0
+	}, time.Second, time.Millisecond)
+}
@@ -84,7 +84,9 @@ func main() {
 func onTrackSubscribed(track *webrtc.TrackRemote, publication *lksdk.RemoteTrackPublication, rp *lksdk.RemoteParticipant) {
 	fileName := fmt.Sprintf("%s-%s", rp.Identity(), track.ID())
 	fmt.Println("write track to file ", fileName)
-	NewTrackWriter(track, rp.WritePLI, fileName)
+	if _, err := NewTrackWriter(track, publication, rp.WritePLI, fileName); err != nil {
+		logger.Errorw("failed to write track", err)
+	}
 }
 
 const (
@@ -93,62 +95,106 @@ const (
 )
 
 type TrackWriter struct {
-	sb     *samplebuilder.SampleBuilder
-	writer media.Writer
-	track  *webrtc.TrackRemote
+	sb        *samplebuilder.SampleBuilder
+	writer    media.Writer
+	track     *webrtc.TrackRemote
+	pliWriter lksdk.PLIWriter
+	fileName  string
+
+	// a new file is started when the server switches the codec
+	segment      int
+	codecChanged bool
 }
 
-func NewTrackWriter(track *webrtc.TrackRemote, pliWriter lksdk.PLIWriter, fileName string) (*TrackWriter, error) {
+func NewTrackWriter(track *webrtc.TrackRemote, publication *lksdk.RemoteTrackPublication, pliWriter lksdk.PLIWriter, fileName string) (*TrackWriter, error) {
+	t := &TrackWriter{
+		track:     track,
+		pliWriter: pliWriter,
+		fileName:  fileName,
+	}
+	if err := t.startSegment(track.Codec()); err != nil {
+		return nil, err
+	}
+	// called on the goroutine reading the track, before the first packet of the new codec
+	publication.OnCodecChanged(func(codec webrtc.RTPCodecParameters) {
+		t.codecChanged = true
+	})
+
+	writeWG.Add(1)
+	go t.start()
+	return t, nil
+}
+
+func (t *TrackWriter) startSegment(codec webrtc.RTPCodecParameters) error {
 	var (
 		sb     *samplebuilder.SampleBuilder
 		writer media.Writer
 		err    error
 	)
+	fileName := t.fileName
+	if t.segment > 0 {
+		fileName = fmt.Sprintf("%s-%d", t.fileName, t.segment)
+	}
 	switch {
-	case strings.EqualFold(track.Codec().MimeType, "video/vp8"):
-		sb = samplebuilder.New(maxVideoLate, &codecs.VP8Packet{}, track.Codec().ClockRate, samplebuilder.WithPacketDroppedHandler(func() {
-			pliWriter(track.SSRC())
+	case strings.EqualFold(codec.MimeType, "video/vp8"):
+		sb = samplebuilder.New(maxVideoLate, &codecs.VP8Packet{}, codec.ClockRate, samplebuilder.WithPacketDroppedHandler(func() {
+			t.pliWriter(t.track.SSRC())
 		}))
 		// ivfwriter use frame count as PTS, that might cause video played in a incorrect framerate(fast or slow)
 		writer, err = ivfwriter.New(fileName + ".ivf")
 
-	case strings.EqualFold(track.Codec().MimeType, "video/h264"):
-		sb = samplebuilder.New(maxVideoLate, &codecs.H264Packet{}, track.Codec().ClockRate, samplebuilder.WithPacketDroppedHandler(func() {
-			pliWriter(track.SSRC())
+	case strings.EqualFold(codec.MimeType, "video/h264"):
+		sb = samplebuilder.New(maxVideoLate, &codecs.H264Packet{}, codec.ClockRate, samplebuilder.WithPacketDroppedHandler(func() {
+			t.pliWriter(t.track.SSRC())
 		}))
 		writer, err = h264writer.New(fileName + ".h264")
 
-	case strings.EqualFold(track.Codec().MimeType, "video/h265"):
-		sb = samplebuilder.New(maxVideoLate, &codecs.H265Packet{}, track.Codec().ClockRate, samplebuilder.WithPacketDroppedHandler(func() {
-			pliWriter(track.SSRC())
+	case strings.EqualFold(codec.MimeType, "video/h265"):
+		sb = samplebuilder.New(maxVideoLate, &codecs.H265Packet{}, codec.ClockRate, samplebuilder.WithPacketDroppedHandler(func() {
+			t.pliWriter(t.track.SSRC())
 		}))
 		writer, err = h265writer.New(fileName + ".h265")
 
-	case strings.EqualFold(track.Codec().MimeType, "audio/opus"):
-		sb = samplebuilder.New(maxAudioLate, &codecs.OpusPacket{}, track.Codec().ClockRate)
-		writer, err = oggwriter.New(fileName+".ogg", 48000, track.Codec().Channels)
+	case strings.EqualFold(codec.MimeType, "audio/opus"):
+		sb = samplebuilder.New(maxAudioLate, &codecs.OpusPacket{}, codec.ClockRate)
+		writer, err = oggwriter.New(fileName+".ogg", 48000, codec.Channels)
 
 	default:
-		return nil, errors.New("unsupported codec type")
+		return errors.New("unsupported codec type")
 	}
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	t := &TrackWriter{
-		sb:     sb,
-		writer: writer,
-		track:  track,
+	t.sb = sb
+	t.writer = writer
+	t.segment++
+	return nil
+}
+
+// nextSegment finishes the current file and starts a new one for the codec of the track
+func (t *TrackWriter) nextSegment() error {
+	for _, p := range t.sb.ForcePopPackets() {
+		if err := t.writer.WriteRTP(p); err != nil {
+			logger.Errorw("failed to write rtp packet", err)
+		}
 	}
-	writeWG.Add(1)
-	go t.start()
-	return t, nil
+	t.writer.Close()
+	t.writer = nil
+	if err := t.startSegment(t.track.Codec()); err != nil {
+		return err
+	}
+	// the new decoder needs a key frame
+	t.pliWriter(t.track.SSRC())
+	return nil
 }
 
 func (t *TrackWriter) start() {
 	defer func() {
-		t.writer.Close()
+		if t.writer != nil {
+			t.writer.Close()
+		}
 		writeWG.Done()
 	}()
 
@@ -158,6 +204,13 @@ func (t *TrackWriter) start() {
 			logger.Errorw("failed to read rtp packet", err)
 			break
 		}
+		if t.codecChanged {
+			t.codecChanged = false
+			if err := t.nextSegment(); err != nil {
+				logger.Errorw("failed to start new segment", err)
+				break
+			}
+		}
 		t.sb.Push(pkt)
 
 		for _, p := range t.sb.PopPackets() {
@@ -0,0 +1,284 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// TopicFileTransfer is the default topic of TransferFile and RegisterFileReceiver
+	TopicFileTransfer = "lk.file-transfer"
+
+	// stream attributes of file transfers
+	AttributeFileOffset = "lk.file.offset"
+	AttributeFileSize   = "lk.file.size"
+	// hex encoded SHA-256 of the complete file, sent with the stream trailer
+	AttributeFileSHA256 = "lk.file.sha256"
+
+	// partially received files are kept with this suffix until they are complete
+	partialFileSuffix = ".part"
+
+	// files are read in blocks of up to this many stream chunks
+	maxFileTransferBlockChunks = 16
+)
+
+// FileTransferOptions are the options of TransferFile
+//   - Topic is the topic of the stream, TopicFileTransfer if not provided
+//   - DestinationIdentities is the list of identities that will receive the file, empty for all participants
+//   - MimeType is detected from the file extension or content if not provided
+//   - Offset is where to resume an interrupted transfer, the size of the partial file at the receiver
+//   - OnProgress is called with the bytes of the file sent so far, including Offset
+type FileTransferOptions struct {
+	Topic                 string
+	DestinationIdentities []string
+	MimeType              string
+	Offset                uint64
+	OnProgress            func(sent, total uint64)
+}
+
+// TransferFile streams a file over the reliable data channel and blocks until it was sent.
+// Unlike SendFile, the file is not loaded into memory and read in larger blocks while the data channel drains
+// quickly. A SHA-256 checksum of the whole file is sent for receivers to verify, see RegisterFileReceiver.
+// When ctx is done, the stream is closed without checksum and the transfer can be resumed with Offset.
+func (p *LocalParticipant) TransferFile(ctx context.Context, path string, opts FileTransferOptions) (*ByteStreamInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(stat.Size())
+	if opts.Offset > size {
+		return nil, ErrFileOffsetMismatch
+	}
+	if opts.Topic == "" {
+		opts.Topic = TopicFileTransfer
+	}
+	if opts.MimeType == "" {
+		opts.MimeType = detectMimeType(f, path)
+	}
+
+	// the checksum covers the part the receiver already has
+	sum := sha256.New()
+	if _, err = io.CopyN(sum, f, int64(opts.Offset)); err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(path)
+	writer := p.StreamBytes(StreamBytesOptions{
+		Topic:                 opts.Topic,
+		MimeType:              opts.MimeType,
+		DestinationIdentities: opts.DestinationIdentities,
+		TotalSize:             size - opts.Offset,
+		FileName:              &name,
+		Attributes: map[string]string{
+			AttributeFileOffset: strconv.FormatUint(opts.Offset, 10),
+			AttributeFileSize:   strconv.FormatUint(size, 10),
+		},
+	})
+
+	if err = p.sendFileBlocks(ctx, writer, f, sum, opts, size); err != nil {
+		writer.closeWithTrailer(err.Error(), nil)
+		return nil, err
+	}
+	writer.closeWithTrailer("", map[string]string{
+		AttributeFileSHA256: hex.EncodeToString(sum.Sum(nil)),
+	})
+	return &writer.Info, nil
+}
+
+func (p *LocalParticipant) sendFileBlocks(ctx context.Context, writer *ByteStreamWriter, f io.Reader, sum hash.Hash, opts FileTransferOptions, size uint64) error {
+	buf := make([]byte, maxFileTransferBlockChunks*STREAM_CHUNK_SIZE)
+	blockChunks := 1
+	sent := opts.Offset
+	for {
+		if err := p.engine.waitForBufferStatusLowContext(ctx, livekit.DataPacket_RELIABLE); err != nil {
+			return err
+		}
+
+		n, err := io.ReadFull(f, buf[:blockChunks*STREAM_CHUNK_SIZE])
+		if n > 0 {
+			sum.Write(buf[:n])
+			if werr := writer.writeSync(chunkBytes(buf[:n])); werr != nil {
+				return werr
+			}
+			sent += uint64(n)
+			if opts.OnProgress != nil {
+				opts.OnProgress(sent, size)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		blockChunks = p.engine.tuneFileBlockChunks(blockChunks)
+	}
+}
+
+// tuneFileBlockChunks grows the blocks read from a file while the reliable data channel drains
+// faster than it is filled, and shrinks them when data piles up
+func (e *RTCEngine) tuneFileBlockChunks(blockChunks int) int {
+	dc := e.GetDataChannel(livekit.DataPacket_RELIABLE)
+	if dc == nil {
+		return blockChunks
+	}
+	switch buffered := dc.BufferedAmount(); {
+	case buffered <= dataChannelBufferedAmountLowThreshold/2:
+		return min(blockChunks*2, maxFileTransferBlockChunks)
+	case buffered > dataChannelBufferedAmountLowThreshold:
+		return max(blockChunks/2, 1)
+	default:
+		return blockChunks
+	}
+}
+
+// detectMimeType uses the file extension, or the content when the extension is unknown
+func detectMimeType(f io.ReaderAt, path string) string {
+	if mimeType := mime.TypeByExtension(filepath.Ext(path)); mimeType != "" {
+		return mimeType
+	}
+	head := make([]byte, 512)
+	n, _ := f.ReadAt(head, 0)
+	return http.DetectContentType(head[:n])
+}
+
+// ReceivedFile describes a file received with RegisterFileReceiver
+//   - Path is where the file was written, with partialFileSuffix when the transfer failed
+//   - Received is the size of the file on disk, the Offset to resume an incomplete transfer with
+type ReceivedFile struct {
+	Info           ByteStreamInfo
+	SenderIdentity string
+	Path           string
+	Size           uint64
+	Received       uint64
+	SHA256         string
+}
+
+// FileReceivedHandler is called when a file was received completely, or with an error when it was not.
+// Partial files are kept for transfers that can be resumed.
+type FileReceivedHandler func(file *ReceivedFile, err error)
+
+// RegisterFileReceiver writes files sent with TransferFile on topic to dir and verifies their checksum.
+// Files are written with partialFileSuffix until they are complete, existing files are replaced.
+// An empty topic registers TopicFileTransfer.
+func (r *Room) RegisterFileReceiver(topic string, dir string, onFileReceived FileReceivedHandler) error {
+	if topic == "" {
+		topic = TopicFileTransfer
+	}
+	return r.RegisterByteStreamHandler(topic, func(reader *ByteStreamReader, participantIdentity string) {
+		file, err := receiveFile(reader, dir)
+		if file != nil {
+			file.SenderIdentity = participantIdentity
+		}
+		if err != nil {
+			r.log.Infow("could not receive file", "error", err, "participant", participantIdentity, "streamID", reader.Info.Id)
+			reader.Discard()
+		}
+		onFileReceived(file, err)
+	})
+}
+
+func receiveFile(reader *ByteStreamReader, dir string) (*ReceivedFile, error) {
+	if reader.Info.Name == nil {
+		return nil, ErrInvalidFileName
+	}
+	name := *reader.Info.Name
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFileName, name)
+	}
+	offset, err := strconv.ParseUint(reader.Info.Attributes[AttributeFileOffset], 10, 64)
+	if err != nil {
+		offset = 0
+	}
+	size, _ := strconv.ParseUint(reader.Info.Attributes[AttributeFileSize], 10, 64)
+
+	file := &ReceivedFile{
+		Info: reader.Info,
+		Path: filepath.Join(dir, name+partialFileSuffix),
+		Size: size,
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset != 0 {
+		flags = os.O_RDWR
+	}
+	f, err := os.OpenFile(file.Path, flags, 0o644)
+	if os.IsNotExist(err) {
+		return file, ErrFileOffsetMismatch
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if offset != 0 {
+		n, err := io.Copy(sum, f)
+		if err != nil {
+			return nil, err
+		}
+		file.Received = uint64(n)
+		if file.Received != offset {
+			return file, ErrFileOffsetMismatch
+		}
+	}
+
+	n, err := io.Copy(io.MultiWriter(f, sum), reader)
+	file.Received += uint64(n)
+	if err != nil {
+		return file, err
+	}
+
+	expected := reader.Info.Attributes[AttributeFileSHA256]
+	if expected == "" || size != 0 && file.Received != size {
+		return file, ErrFileIncomplete
+	}
+	file.SHA256 = hex.EncodeToString(sum.Sum(nil))
+	if file.SHA256 != expected {
+		// resuming would keep the corrupted part
+		_ = os.Remove(file.Path)
+		file.Received = 0
+		return file, ErrFileChecksumMismatch
+	}
+
+	if err = f.Close(); err != nil {
+		return file, err
+	}
+	path := filepath.Join(dir, name)
+	if err = os.Rename(file.Path, path); err != nil {
+		return file, err
+	}
+	file.Path = path
+	return file, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func newFileReader(name string, content []byte, offset int, checksum string) *ByteStreamReader {
+	reader := NewByteStreamReader(ByteStreamInfo{
+		baseStreamInfo: &baseStreamInfo{
+			Id: "stream",
+			Attributes: map[string]string{
+				AttributeFileOffset: strconv.Itoa(offset),
+				AttributeFileSize:   strconv.Itoa(len(content)),
+			},
+		},
+		Name: &name,
+	}, nil)
+	reader.enqueue(&livekit.DataStream_Chunk{Content: content[offset:]})
+	if checksum != "" {
+		reader.Info.Attributes[AttributeFileSHA256] = checksum
+	}
+	reader.close()
+	return reader
+}
+
+func TestReceiveFile(t *testing.T) {
+	content := []byte("the quick brown fox jumps over the lazy dog")
+	digest := sha256.Sum256(content)
+	checksum := hex.EncodeToString(digest[:])
+
+	t.Run("complete", func(t *testing.T) {
+		dir := t.TempDir()
+		file, err := receiveFile(newFileReader("fox.txt", content, 0, checksum), dir)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, "fox.txt"), file.Path)
+		require.Equal(t, checksum, file.SHA256)
+		require.Equal(t, uint64(len(content)), file.Received)
+
+		data, err := os.ReadFile(file.Path)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	})
+
+	t.Run("resumed", func(t *testing.T) {
+		dir := t.TempDir()
+		// interrupted transfer, no checksum in the trailer
+		file, err := receiveFile(newFileReader("fox.txt", content[:10], 0, ""), dir)
+		require.ErrorIs(t, err, ErrFileIncomplete)
+		require.Equal(t, uint64(10), file.Received)
+
+		file, err = receiveFile(newFileReader("fox.txt", content, int(file.Received), checksum), dir)
+		require.NoError(t, err)
+		data, err := os.ReadFile(file.Path)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	})
+
+	t.Run("offset mismatch", func(t *testing.T) {
+		_, err := receiveFile(newFileReader("fox.txt", content, 5, checksum), t.TempDir())
+		require.ErrorIs(t, err, ErrFileOffsetMismatch)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		file, err := receiveFile(newFileReader("fox.txt", content, 0, hex.EncodeToString(make([]byte, 32))), dir)
+		require.ErrorIs(t, err, ErrFileChecksumMismatch)
+		require.NoFileExists(t, file.Path)
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := receiveFile(newFileReader("../fox.txt", content, 0, checksum), t.TempDir())
+		require.ErrorIs(t, err, ErrInvalidFileName)
+	})
+}
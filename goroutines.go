@@ -0,0 +1,131 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGoroutineLeakTimeout = 5 * time.Second
+	goroutineWaitInterval       = 10 * time.Millisecond
+)
+
+// goroutineRegistry tracks the goroutines spawned by the SDK for a room, by name,
+// so that they can be verified to exit after disconnecting. Goroutines running callbacks are not tracked.
+type goroutineRegistry struct {
+	lock    sync.Mutex
+	running map[string]int
+	total   int
+}
+
+func newGoroutineRegistry() *goroutineRegistry {
+	return &goroutineRegistry{
+		running: make(map[string]int),
+	}
+}
+
+// Go runs f in a tracked goroutine, a nil registry runs it untracked
+func (g *goroutineRegistry) Go(name string, f func()) {
+	if g == nil {
+		go f()
+		return
+	}
+
+	g.lock.Lock()
+	g.running[name]++
+	g.total++
+	g.lock.Unlock()
+
+	go func() {
+		defer g.done(name)
+		f()
+	}()
+}
+
+func (g *goroutineRegistry) done(name string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.total--
+	if g.running[name]--; g.running[name] <= 0 {
+		delete(g.running, name)
+	}
+}
+
+func (g *goroutineRegistry) count() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.total
+}
+
+func (g *goroutineRegistry) snapshot() map[string]int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return maps.Clone(g.running)
+}
+
+// wait returns nil once all goroutines exited, or the ones still running when ctx is done
+func (g *goroutineRegistry) wait(ctx context.Context) map[string]int {
+	ticker := time.NewTicker(goroutineWaitInterval)
+	defer ticker.Stop()
+
+	for {
+		if g.count() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if g.count() == 0 {
+				return nil
+			}
+			return g.snapshot()
+		case <-ticker.C:
+		}
+	}
+}
+
+// GoroutineCount returns the number of goroutines the SDK is running for the room, excluding callbacks.
+// It drops to zero shortly after disconnecting.
+func (r *Room) GoroutineCount() int {
+	return r.engine.goroutines.count()
+}
+
+// Goroutines returns the number of running SDK goroutines by name, e.g. for diagnosing shutdown issues.
+func (r *Room) Goroutines() map[string]int {
+	return r.engine.goroutines.snapshot()
+}
+
+// checkGoroutineLeaks logs the goroutines that did not exit within the leak timeout after disconnecting
+func (r *Room) checkGoroutineLeaks() {
+	timeout := defaultGoroutineLeakTimeout
+	if params := r.engine.connParams; params != nil && params.GoroutineLeakTimeout != 0 {
+		timeout = params.GoroutineLeakTimeout
+	}
+	if timeout < 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if leaked := r.engine.goroutines.wait(ctx); leaked != nil {
+			r.log.Warnw("goroutines did not exit after disconnecting", nil, "timeout", timeout, "goroutines", leaked)
+		}
+	}()
+}
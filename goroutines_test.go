@@ -0,0 +1,67 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoroutineRegistry(t *testing.T) {
+	g := newGoroutineRegistry()
+	stop := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		g.Go("worker", func() { <-stop })
+	}
+	g.Go("other", func() { <-stop })
+
+	require.Equal(t, 3, g.count())
+	require.Equal(t, map[string]int{"worker": 2, "other": 1}, g.snapshot())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.Equal(t, map[string]int{"worker": 2, "other": 1}, g.wait(ctx))
+
+	close(stop)
+	require.Nil(t, g.wait(context.Background()))
+	require.Equal(t, 0, g.count())
+	require.Empty(t, g.snapshot())
+
+	// nil registry runs untracked
+	var nilRegistry *goroutineRegistry
+	done := make(chan struct{})
+	nilRegistry.Go("untracked", func() { close(done) })
+	<-done
+}
+
+func TestRoomClose(t *testing.T) {
+	room := NewRoom(nil)
+	require.NoError(t, room.Close(context.Background()))
+	require.Equal(t, 0, room.GoroutineCount())
+	require.Equal(t, ConnectionStateDisconnected, room.ConnectionState())
+
+	// closing again returns immediately
+	require.NoError(t, room.Close(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	engine := NewRTCEngine(false, nil, func() string { return "" })
+	engine.reconnecting.Store(true)
+	require.ErrorIs(t, engine.Close(ctx), context.Canceled)
+	engine.reconnecting.Store(false)
+	require.NoError(t, engine.Close(context.Background()))
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// bounds a WithICECredentialRefresh call before resuming, the last servers are used when it times out
+const iceCredentialRefreshTimeout = 10 * time.Second
+
+// UpdateICEServers replaces the ICE servers of both transports, e.g. to rotate time-limited TURN credentials
+// during long sessions. The servers take effect on the next ICE restart and are kept across reconnects,
+// instead of the servers sent by the server. Passing nil goes back to the servers sent by the server on the
+// next reconnect.
+func (r *Room) UpdateICEServers(servers []webrtc.ICEServer) error {
+	return r.engine.UpdateICEServers(servers)
+}
+
+// UpdateICEServers applies servers to the transports and to configurations created on reconnect
+func (e *RTCEngine) UpdateICEServers(servers []webrtc.ICEServer) error {
+	servers = slices.Clone(servers)
+	e.iceServersLock.Lock()
+	e.iceServers = servers
+	e.iceServersLock.Unlock()
+	if servers == nil {
+		return nil
+	}
+
+	e.pclock.Lock()
+	defer e.pclock.Unlock()
+
+	for _, t := range []*PCTransport{e.publisher, e.subscriber} {
+		if t == nil {
+			continue
+		}
+		configuration := t.pc.GetConfiguration()
+		configuration.ICEServers = servers
+		if err := t.SetConfiguration(configuration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterICEServers keeps the URLs accepted by keep, servers without URLs left are dropped
+func filterICEServers(servers []webrtc.ICEServer, keep func(url string) bool) []webrtc.ICEServer {
+	var filtered []webrtc.ICEServer
+	for _, server := range servers {
+		var urls []string
+		for _, url := range server.URLs {
+			if keep(url) {
+				urls = append(urls, url)
+			}
+		}
+		if len(urls) != 0 {
+			server.URLs = urls
+			filtered = append(filtered, server)
+		}
+	}
+	return filtered
+}
+
+// refreshICEServers renews the servers of the connection with WithICECredentialRefresh
+func (e *RTCEngine) refreshICEServers(ctx context.Context) {
+	if e.connParams == nil || e.connParams.ICECredentialRefresh == nil {
+		return
+	}
+	servers := e.connectionICEServers()
+	refreshed, err := e.connParams.ICECredentialRefresh(ctx, slices.Clone(servers))
+	if err != nil {
+		e.log.Warnw("could not refresh ICE credentials", err)
+		return
+	}
+
+	e.iceServersLock.Lock()
+	e.refreshedICEServers = refreshed
+	e.iceServersLock.Unlock()
+}
+
+// refreshICEServersOnResume refreshes the servers before resuming, until the timeout or the engine is closed
+func (e *RTCEngine) refreshICEServersOnResume() {
+	if e.connParams == nil || e.connParams.ICECredentialRefresh == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), iceCredentialRefreshTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-e.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	e.refreshICEServers(ctx)
+}
+
+// connectionICEServers returns the servers of WithICEServers, or their last refresh
+func (e *RTCEngine) connectionICEServers() []webrtc.ICEServer {
+	e.iceServersLock.Lock()
+	defer e.iceServersLock.Unlock()
+
+	if e.refreshedICEServers != nil {
+		return e.refreshedICEServers
+	}
+	return e.connParams.ICEServers
+}
+
+// iceServersOverride returns the servers set with UpdateICEServers, nil when not set
+func (e *RTCEngine) iceServersOverride() []webrtc.ICEServer {
+	e.iceServersLock.Lock()
+	defer e.iceServersLock.Unlock()
+
+	return e.iceServers
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestUpdateICEServers(t *testing.T) {
+	room := NewRoom(nil)
+	e := room.engine
+	e.connParams = &signalling.ConnectParams{}
+	serverICE := []*livekit.ICEServer{{Urls: []string{"turn:server.example.com:3478"}, Username: "u", Credential: "c"}}
+	require.NoError(t, e.configure(serverICE, nil, nil))
+	defer e.Close(t.Context())
+
+	publisher, ok := e.Publisher()
+	require.True(t, ok)
+	require.Equal(t, []string{"turn:server.example.com:3478"}, publisher.pc.GetConfiguration().ICEServers[0].URLs)
+
+	servers := []webrtc.ICEServer{{URLs: []string{"turn:own.example.com:443?transport=tcp"}, Username: "rotated", Credential: "secret"}}
+	require.NoError(t, room.UpdateICEServers(servers))
+	for _, transport := range []*PCTransport{e.publisher, e.subscriber} {
+		require.Equal(t, servers, transport.pc.GetConfiguration().ICEServers)
+	}
+
+	// kept when the server sends its servers again on reconnect
+	require.Equal(t, servers, e.makeRTCConfiguration(serverICE, nil).ICEServers)
+
+	require.NoError(t, room.UpdateICEServers(nil))
+	require.Equal(t, "u", e.makeRTCConfiguration(serverICE, nil).ICEServers[0].Username)
+}
+
+func TestConnectionICEServers(t *testing.T) {
+	e := NewRoom(nil).engine
+	serverICE := []*livekit.ICEServer{{Urls: []string{"turn:server.example.com:3478"}, Username: "u", Credential: "c"}}
+	own := []webrtc.ICEServer{{URLs: []string{"turn:own.example.com:443?transport=tcp"}, Username: "own", Credential: "expiring"}}
+
+	e.connParams = &signalling.ConnectParams{ICEServers: own}
+	servers := e.makeRTCConfiguration(serverICE, nil).ICEServers
+	require.Len(t, servers, 2)
+	require.Equal(t, own[0], servers[0])
+	require.Equal(t, "u", servers[1].Username)
+
+	// credentials passed to the refresher
+	var refreshed []string
+	e.connParams = &signalling.ConnectParams{
+		ICEServers:       own,
+		ICEServersPolicy: ICEServersReplace,
+		ICECredentialRefresh: func(ctx context.Context, servers []webrtc.ICEServer) ([]webrtc.ICEServer, error) {
+			refreshed = append(refreshed, servers[0].Credential.(string))
+			if len(refreshed) == 3 {
+				return nil, errors.New("unavailable")
+			}
+			servers[0].Credential = fmt.Sprintf("renewed-%d", len(refreshed))
+			return servers, nil
+		},
+	}
+	require.Equal(t, own, e.makeRTCConfiguration(serverICE, nil).ICEServers)
+
+	e.refreshICEServers(t.Context())
+	require.Equal(t, "renewed-1", e.makeRTCConfiguration(serverICE, nil).ICEServers[0].Credential)
+	// the given servers are not modified
+	require.Equal(t, "expiring", own[0].Credential)
+
+	e.refreshICEServers(t.Context())
+	require.Equal(t, []string{"expiring", "renewed-1"}, refreshed)
+	// failed refreshes keep the previous servers
+	e.refreshICEServers(t.Context())
+	servers = e.makeRTCConfiguration(serverICE, nil).ICEServers
+	require.Len(t, servers, 1)
+	require.Equal(t, "renewed-2", servers[0].Credential)
+
+	// servers set with UpdateICEServers take precedence
+	require.NoError(t, e.UpdateICEServers([]webrtc.ICEServer{{URLs: []string{"stun:stun.example.com"}}}))
+	require.Equal(t, "stun:stun.example.com", e.makeRTCConfiguration(serverICE, nil).ICEServers[0].URLs[0])
+}
+
+func TestRefreshICEServersOnResumeStopsOnClose(t *testing.T) {
+	e := NewRoom(nil).engine
+	refreshErr := make(chan error, 1)
+	e.connParams = &signalling.ConnectParams{
+		ICECredentialRefresh: func(ctx context.Context, servers []webrtc.ICEServer) ([]webrtc.ICEServer, error) {
+			_, ok := ctx.Deadline()
+			require.True(t, ok)
+			<-ctx.Done()
+			refreshErr <- ctx.Err()
+			return nil, ctx.Err()
+		},
+	}
+
+	// a refresh that hangs is cancelled once the engine closes
+	close(e.closing)
+	e.refreshICEServersOnResume()
+	require.ErrorIs(t, <-refreshErr, context.Canceled)
+}
@@ -0,0 +1,145 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// how long the result of a call is returned for repeated calls with the same key
+	idempotencyKeyTTL = 10 * time.Minute
+	// calls with an idempotency key outlive the context of the caller until this timeout, or its deadline when later
+	idempotentCallTimeout = time.Minute
+)
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context that makes CreateRoom, CreateIngress, the StartEgress calls
+// and CreateSIPParticipant idempotent for the given key.
+//
+// Calls with the same key and request share one request: a call that times out on the client keeps running in the
+// background, and a retry with the same key waits for and returns its result instead of creating a duplicate.
+// A call with the same key but a different request is not deduplicated. Successful results are returned for
+// repeated calls for 10 minutes. Calls that fail are sent again on retry.
+//
+// Deduplication is client-side and in-process only: keys are kept in memory and are not sent to the server,
+// they do not protect against retries from other processes or after a restart.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func idempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key, ok && key != ""
+}
+
+type idempotentCall struct {
+	done    chan struct{}
+	res     any
+	err     error
+	expires time.Time
+}
+
+type idempotencyCache struct {
+	lock  sync.Mutex
+	calls map[string]*idempotentCall
+}
+
+var idempotentCalls = &idempotencyCache{
+	calls: make(map[string]*idempotentCall),
+}
+
+// join returns the call for key and true if the caller must start it
+func (c *idempotencyCache) join(key string) (*idempotentCall, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	for k, call := range c.calls {
+		if !call.expires.IsZero() && now.After(call.expires) {
+			delete(c.calls, k)
+		}
+	}
+
+	if call, ok := c.calls[key]; ok {
+		return call, false
+	}
+	call := &idempotentCall{done: make(chan struct{})}
+	c.calls[key] = call
+	return call, true
+}
+
+func (c *idempotencyCache) finish(key string, call *idempotentCall, res any, err error) {
+	c.lock.Lock()
+	call.res, call.err = res, err
+	if err != nil {
+		// nothing was created or the outcome is unknown, a retry sends the request again
+		delete(c.calls, key)
+	} else {
+		call.expires = time.Now().Add(idempotencyKeyTTL)
+	}
+	c.lock.Unlock()
+	close(call.done)
+}
+
+// idempotent runs call once per idempotency key of ctx, see WithIdempotencyKey
+func idempotent[T any](ctx context.Context, b authBase, method string, req proto.Message, call func(ctx context.Context) (T, error)) (T, error) {
+	key, ok := idempotencyKeyFromContext(ctx)
+	if !ok {
+		return call(ctx)
+	}
+	// a key reused for a different request must not return the result of the other one
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		var res T
+		return res, err
+	}
+	hash := sha256.Sum256(data)
+	key = b.apiKey + "/" + method + "/" + key + "/" + hex.EncodeToString(hash[:])
+
+	c, start := idempotentCalls.join(key)
+	if start {
+		deadline := time.Now().Add(idempotentCallTimeout)
+		if d, ok := ctx.Deadline(); ok && d.After(deadline) {
+			deadline = d
+		}
+		go func() {
+			callCtx, cancel := context.WithDeadline(context.WithoutCancel(ctx), deadline)
+			defer cancel()
+			res, err := call(callCtx)
+			idempotentCalls.finish(key, c, res, err)
+		}()
+	}
+
+	select {
+	case <-c.done:
+		res, _ := c.res.(T)
+		// every caller gets its own copy of the shared result
+		if m, ok := any(res).(proto.Message); ok && c.err == nil {
+			res, _ = proto.Clone(m).(T)
+		}
+		return res, c.err
+	case <-ctx.Done():
+		var res T
+		return res, ctx.Err()
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	call := func(ctx context.Context) (string, error) {
+		calls.Inc()
+		<-release
+		return "created", nil
+	}
+
+	ctx := WithIdempotencyKey(context.Background(), "key")
+	req := &livekit.CreateRoomRequest{Name: "room"}
+
+	// the caller times out but the call keeps running
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err := idempotent(timeoutCtx, authBase{apiKey: "api"}, "Create", req, call)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the retry joins the running call
+	close(release)
+	res, err := idempotent(ctx, authBase{apiKey: "api"}, "Create", req, call)
+	require.NoError(t, err)
+	require.Equal(t, "created", res)
+
+	// and later calls return the result
+	res, err = idempotent(ctx, authBase{apiKey: "api"}, "Create", req, call)
+	require.NoError(t, err)
+	require.Equal(t, "created", res)
+	require.EqualValues(t, 1, calls.Load())
+
+	// the same key with a different request is sent
+	res, err = idempotent(ctx, authBase{apiKey: "api"}, "Create", &livekit.CreateRoomRequest{Name: "other"}, call)
+	require.NoError(t, err)
+	require.Equal(t, "created", res)
+	require.EqualValues(t, 2, calls.Load())
+
+	// failed calls are sent again
+	failing := func(ctx context.Context) (string, error) {
+		calls.Inc()
+		return "", errors.New("failed")
+	}
+	_, err = idempotent(ctx, authBase{apiKey: "api"}, "Other", req, failing)
+	require.Error(t, err)
+	_, err = idempotent(ctx, authBase{apiKey: "api"}, "Other", req, failing)
+	require.Error(t, err)
+	require.EqualValues(t, 4, calls.Load())
+}
+
+func TestIdempotentResultAndDeadline(t *testing.T) {
+	var deadline time.Time
+	call := func(ctx context.Context) (*livekit.Room, error) {
+		deadline, _ = ctx.Deadline()
+		return &livekit.Room{Name: "room"}, nil
+	}
+
+	// a caller deadline later than the timeout is kept
+	ctx, cancel := context.WithTimeout(WithIdempotencyKey(context.Background(), "deadline"), 2*idempotentCallTimeout)
+	defer cancel()
+	req := &livekit.CreateRoomRequest{Name: "room"}
+	first, err := idempotent(ctx, authBase{apiKey: "api"}, "Create", req, call)
+	require.NoError(t, err)
+	expected, _ := ctx.Deadline()
+	require.Equal(t, expected, deadline)
+
+	// callers sharing a key get their own copy
+	second, err := idempotent(ctx, authBase{apiKey: "api"}, "Create", req, call)
+	require.NoError(t, err)
+	require.NotSame(t, first, second)
+	first.Name = "changed"
+	require.Equal(t, "room", second.Name)
+}
@@ -47,12 +47,17 @@ func (c *IngressClient) CreateIngress(ctx context.Context, in *livekit.CreateIng
 	if in == nil {
 		return nil, ErrInvalidParameter
 	}
+	if err := ValidateRequest(in); err != nil {
+		return nil, err
+	}
 
 	ctx, err := c.withAuth(ctx, withVideoGrant{IngressAdmin: true})
 	if err != nil {
 		return nil, err
 	}
-	return c.ingressClient.CreateIngress(ctx, in)
+	return idempotent(ctx, c.authBase, "CreateIngress", in, func(ctx context.Context) (*livekit.IngressInfo, error) {
+		return c.ingressClient.CreateIngress(ctx, in)
+	})
 }
 
 func (c *IngressClient) UpdateIngress(ctx context.Context, in *livekit.UpdateIngressRequest) (*livekit.IngressInfo, error) {
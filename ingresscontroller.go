@@ -0,0 +1,356 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	defaultIngressPollInterval = 10 * time.Second
+)
+
+// IngressRecoveryPolicy decides when a controlled ingress is reset or recreated.
+// Only ingresses whose input was connected at least once are recovered.
+type IngressRecoveryPolicy struct {
+	// DownTimeout recovers the ingress when its input stayed disconnected this long, 0 disables
+	DownTimeout time.Duration
+	// MaxDisconnects recovers the ingress when its input disconnected this many times within DisconnectWindow, 0 disables
+	MaxDisconnects   int
+	DisconnectWindow time.Duration
+	// StableAfter is how long the input needs to publish before it is considered connected again,
+	// short reconnects do not reset the down timer
+	StableAfter time.Duration
+	// Cooldown is the minimum time between two recoveries
+	Cooldown time.Duration
+	// MaxAttempts limits the recoveries without the input connecting in between, 0 means unlimited
+	MaxAttempts int
+	// Recreate creates a new ingress with the same settings and deletes the old one instead of disabling and
+	// re-enabling it. The stream key and URL of push ingresses change when recreated.
+	Recreate bool
+}
+
+// DefaultIngressRecoveryPolicy recovers an ingress after 2 minutes of downtime or 5 disconnects within 10 minutes.
+var DefaultIngressRecoveryPolicy = IngressRecoveryPolicy{
+	DownTimeout:      2 * time.Minute,
+	MaxDisconnects:   5,
+	DisconnectWindow: 10 * time.Minute,
+	StableAfter:      10 * time.Second,
+	Cooldown:         time.Minute,
+	MaxAttempts:      3,
+}
+
+type IngressControllerEventType string
+
+const (
+	IngressInputConnected    IngressControllerEventType = "input_connected"
+	IngressInputDisconnected IngressControllerEventType = "input_disconnected"
+	IngressRecovering        IngressControllerEventType = "recovering"
+	IngressRecovered         IngressControllerEventType = "recovered"
+	IngressRecoveryFailed    IngressControllerEventType = "recovery_failed"
+	// IngressRecoveryExhausted is sent once MaxAttempts recoveries did not bring the input back
+	IngressRecoveryExhausted IngressControllerEventType = "recovery_exhausted"
+)
+
+type IngressControllerEvent struct {
+	Type IngressControllerEventType
+	// Ingress is the latest known state, the new ingress after it was recreated
+	Ingress *livekit.IngressInfo
+	Err     error
+}
+
+type IngressControllerOption func(*IngressController)
+
+// WithIngressRecoveryPolicy replaces DefaultIngressRecoveryPolicy.
+func WithIngressRecoveryPolicy(policy IngressRecoveryPolicy) IngressControllerOption {
+	return func(c *IngressController) {
+		c.policy = policy
+	}
+}
+
+// WithIngressPollInterval sets how often the ingress state is polled, 10s by default.
+func WithIngressPollInterval(interval time.Duration) IngressControllerOption {
+	return func(c *IngressController) {
+		c.interval = interval
+	}
+}
+
+// WithIngressWebhookEvents applies ingress webhook events as soon as they are received,
+// e.g. from webhook.ReceiveWebhookEvent. Polling continues to catch missed events.
+func WithIngressWebhookEvents(events <-chan *livekit.WebhookEvent) IngressControllerOption {
+	return func(c *IngressController) {
+		c.events = events
+	}
+}
+
+// WithIngressEventHandler is called from the controller goroutine for every input change and recovery.
+func WithIngressEventHandler(onEvent func(IngressControllerEvent)) IngressControllerOption {
+	return func(c *IngressController) {
+		c.onEvent = onEvent
+	}
+}
+
+// IngressController watches an ingress and resets or recreates it when its input
+// disconnects more than the IngressRecoveryPolicy allows.
+type IngressController struct {
+	client   *IngressClient
+	policy   IngressRecoveryPolicy
+	interval time.Duration
+	events   <-chan *livekit.WebhookEvent
+	onEvent  func(IngressControllerEvent)
+
+	lock      sync.Mutex
+	ingressID string
+	info      *livekit.IngressInfo
+
+	// only accessed from Run
+	connected    bool
+	upSince      time.Time
+	downSince    time.Time
+	disconnects  []time.Time
+	lastRecovery time.Time
+	attempts     int
+}
+
+func NewIngressController(client *IngressClient, ingressID string, opts ...IngressControllerOption) *IngressController {
+	c := &IngressController{
+		client:    client,
+		policy:    DefaultIngressRecoveryPolicy,
+		interval:  defaultIngressPollInterval,
+		ingressID: ingressID,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// IngressID returns the ID of the controlled ingress, which changes when it is recreated.
+func (c *IngressController) IngressID() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.ingressID
+}
+
+// Ingress returns the latest known state of the ingress, nil before the first poll.
+func (c *IngressController) Ingress() *livekit.IngressInfo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.info
+}
+
+// Run watches the ingress until ctx is done. It returns ErrIngressNotFound when the ingress was deleted.
+func (c *IngressController) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		info, err := c.fetch(ctx)
+		if errors.Is(err, ErrIngressNotFound) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			logger.Warnw("could not fetch ingress", err, "ingressID", c.IngressID())
+		} else if c.observe(info, time.Now()) {
+			c.recover(ctx, info)
+			ticker.Reset(c.interval)
+		}
+
+		if err := c.wait(ctx, ticker); err != nil {
+			return err
+		}
+	}
+}
+
+// wait returns once the ticker fired or, when webhook events are given, an event for the ingress was applied
+func (c *IngressController) wait(ctx context.Context, ticker *time.Ticker) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			return nil
+		case event := <-c.events:
+			if event.GetIngressInfo().GetIngressId() != c.IngressID() {
+				continue
+			}
+			if c.observe(event.IngressInfo, time.Now()) {
+				c.recover(ctx, event.IngressInfo)
+				ticker.Reset(c.interval)
+			}
+		}
+	}
+}
+
+func (c *IngressController) fetch(ctx context.Context) (*livekit.IngressInfo, error) {
+	ingressID := c.IngressID()
+	res, err := c.client.ListIngress(ctx, &livekit.ListIngressRequest{IngressId: ingressID})
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range res.Items {
+		if info.IngressId == ingressID {
+			return info, nil
+		}
+	}
+	return nil, ErrIngressNotFound
+}
+
+// observe applies the ingress state and returns true when the ingress should be recovered
+func (c *IngressController) observe(info *livekit.IngressInfo, now time.Time) bool {
+	c.lock.Lock()
+	c.info = info
+	c.lock.Unlock()
+
+	switch info.State.GetStatus() {
+	case livekit.IngressState_ENDPOINT_BUFFERING, livekit.IngressState_ENDPOINT_PUBLISHING:
+		if c.upSince.IsZero() {
+			c.upSince = now
+		}
+		if !c.connected && now.Sub(c.upSince) >= c.policy.StableAfter {
+			c.connected = true
+			c.downSince = time.Time{}
+			c.attempts = 0
+			c.emit(IngressInputConnected, info, nil)
+		}
+		return false
+	}
+
+	c.upSince = time.Time{}
+	if c.connected {
+		c.connected = false
+		c.downSince = now
+		c.disconnects = append(c.disconnects, now)
+		c.emit(IngressInputDisconnected, info, nil)
+	}
+	if c.downSince.IsZero() {
+		// never connected
+		return false
+	}
+	if !c.lastRecovery.IsZero() && now.Sub(c.lastRecovery) < c.policy.Cooldown {
+		return false
+	}
+	if c.policy.MaxAttempts > 0 && c.attempts >= c.policy.MaxAttempts {
+		return false
+	}
+
+	for len(c.disconnects) > 0 && now.Sub(c.disconnects[0]) > c.policy.DisconnectWindow {
+		c.disconnects = c.disconnects[1:]
+	}
+	if c.policy.DownTimeout > 0 && now.Sub(c.downSince) >= c.policy.DownTimeout {
+		return true
+	}
+	return c.policy.MaxDisconnects > 0 && len(c.disconnects) >= c.policy.MaxDisconnects
+}
+
+func (c *IngressController) recover(ctx context.Context, info *livekit.IngressInfo) {
+	c.emit(IngressRecovering, info, nil)
+
+	var err error
+	if c.policy.Recreate {
+		info, err = c.recreate(ctx, info)
+	} else {
+		info, err = c.reset(ctx, info)
+	}
+
+	now := time.Now()
+	c.lastRecovery = now
+	c.attempts++
+	// the recovered ingress gets DownTimeout to connect again
+	c.downSince = now
+	c.disconnects = nil
+
+	if err != nil {
+		logger.Warnw("could not recover ingress", err, "ingressID", info.IngressId)
+		c.emit(IngressRecoveryFailed, info, err)
+	} else {
+		logger.Infow("recovered ingress", "ingressID", info.IngressId, "recreated", c.policy.Recreate)
+		c.lock.Lock()
+		c.ingressID = info.IngressId
+		c.info = info
+		c.lock.Unlock()
+		c.emit(IngressRecovered, info, nil)
+	}
+	if c.policy.MaxAttempts > 0 && c.attempts >= c.policy.MaxAttempts {
+		c.emit(IngressRecoveryExhausted, info, err)
+	}
+}
+
+// reset disables and re-enables the ingress, which drops the current input session
+func (c *IngressController) reset(ctx context.Context, info *livekit.IngressInfo) (*livekit.IngressInfo, error) {
+	if _, err := c.client.UpdateIngress(ctx, &livekit.UpdateIngressRequest{
+		IngressId: info.IngressId,
+		Enabled:   proto.Bool(false),
+	}); err != nil {
+		return info, err
+	}
+	updated, err := c.client.UpdateIngress(ctx, &livekit.UpdateIngressRequest{
+		IngressId: info.IngressId,
+		Enabled:   proto.Bool(true),
+	})
+	if err != nil {
+		return info, err
+	}
+	return updated, nil
+}
+
+// recreate creates the new ingress before deleting the old one, so a failure leaves the old ingress in place
+func (c *IngressController) recreate(ctx context.Context, info *livekit.IngressInfo) (*livekit.IngressInfo, error) {
+	created, err := c.client.CreateIngress(ctx, createIngressRequestFromInfo(info))
+	if err != nil {
+		return info, err
+	}
+	if _, err := c.client.DeleteIngress(ctx, &livekit.DeleteIngressRequest{IngressId: info.IngressId}); err != nil {
+		logger.Warnw("could not delete replaced ingress", err, "ingressID", info.IngressId, "newIngressID", created.IngressId)
+	}
+	return created, nil
+}
+
+func createIngressRequestFromInfo(info *livekit.IngressInfo) *livekit.CreateIngressRequest {
+	req := &livekit.CreateIngressRequest{
+		InputType:           info.InputType,
+		Name:                info.Name,
+		RoomName:            info.RoomName,
+		ParticipantIdentity: info.ParticipantIdentity,
+		ParticipantName:     info.ParticipantName,
+		ParticipantMetadata: info.ParticipantMetadata,
+		BypassTranscoding:   info.BypassTranscoding,
+		EnableTranscoding:   info.EnableTranscoding,
+		Audio:               info.Audio,
+		Video:               info.Video,
+		Enabled:             info.Enabled,
+	}
+	if info.InputType == livekit.IngressInput_URL_INPUT {
+		// for pull ingresses the URL is the source
+		req.Url = info.Url
+	}
+	return req
+}
+
+func (c *IngressController) emit(eventType IngressControllerEventType, info *livekit.IngressInfo, err error) {
+	if c.onEvent != nil {
+		c.onEvent(IngressControllerEvent{Type: eventType, Ingress: info, Err: err})
+	}
+}
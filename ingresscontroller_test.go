@@ -0,0 +1,107 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestIngressControllerObserve(t *testing.T) {
+	var events []IngressControllerEventType
+	c := NewIngressController(nil, "IN_1",
+		WithIngressRecoveryPolicy(IngressRecoveryPolicy{
+			DownTimeout:      time.Minute,
+			MaxDisconnects:   3,
+			DisconnectWindow: 10 * time.Minute,
+			StableAfter:      10 * time.Second,
+			Cooldown:         30 * time.Second,
+		}),
+		WithIngressEventHandler(func(e IngressControllerEvent) {
+			events = append(events, e.Type)
+		}),
+	)
+	info := func(status livekit.IngressState_Status) *livekit.IngressInfo {
+		return &livekit.IngressInfo{IngressId: "IN_1", State: &livekit.IngressState{Status: status}}
+	}
+	up, down := info(livekit.IngressState_ENDPOINT_PUBLISHING), info(livekit.IngressState_ENDPOINT_INACTIVE)
+	now := time.Now()
+
+	t.Run("never connected is not recovered", func(t *testing.T) {
+		require.False(t, c.observe(down, now))
+		require.False(t, c.observe(down, now.Add(time.Hour)))
+		require.Empty(t, events)
+	})
+
+	t.Run("connects after stable period", func(t *testing.T) {
+		require.False(t, c.observe(up, now))
+		require.Empty(t, events)
+		require.False(t, c.observe(up, now.Add(10*time.Second)))
+		require.Equal(t, []IngressControllerEventType{IngressInputConnected}, events)
+	})
+
+	t.Run("short reconnect does not reset downtime", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		require.False(t, c.observe(down, now))
+		require.Equal(t, IngressInputDisconnected, events[len(events)-1])
+		require.False(t, c.observe(up, now.Add(30*time.Second)))
+		require.False(t, c.observe(down, now.Add(35*time.Second)))
+		require.True(t, c.observe(down, now.Add(time.Minute)))
+	})
+
+	t.Run("cooldown after recovery", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		c.lastRecovery = now
+		c.downSince = now
+		require.False(t, c.observe(down, now.Add(20*time.Second)))
+		require.True(t, c.observe(down, now.Add(time.Minute)))
+	})
+
+	t.Run("flapping input", func(t *testing.T) {
+		c.lastRecovery = time.Time{}
+		c.disconnects = nil
+		for i := 0; i < 3; i++ {
+			now = now.Add(time.Minute)
+			require.False(t, c.observe(up, now))
+			require.False(t, c.observe(up, now.Add(10*time.Second)))
+			shouldRecover := c.observe(down, now.Add(20*time.Second))
+			require.Equal(t, i == 2, shouldRecover)
+		}
+	})
+}
+
+func TestCreateIngressRequestFromInfo(t *testing.T) {
+	req := createIngressRequestFromInfo(&livekit.IngressInfo{
+		IngressId:           "IN_1",
+		InputType:           livekit.IngressInput_URL_INPUT,
+		Url:                 "https://example.com/stream.m3u8",
+		RoomName:            "room",
+		ParticipantIdentity: "camera",
+	})
+	require.Equal(t, livekit.IngressInput_URL_INPUT, req.InputType)
+	require.Equal(t, "https://example.com/stream.m3u8", req.Url)
+	require.Equal(t, "room", req.RoomName)
+	require.Equal(t, "camera", req.ParticipantIdentity)
+
+	req = createIngressRequestFromInfo(&livekit.IngressInfo{
+		InputType: livekit.IngressInput_RTMP_INPUT,
+		Url:       "rtmp://example.com/live",
+	})
+	require.Empty(t, req.Url)
+}
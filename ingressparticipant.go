@@ -0,0 +1,89 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"github.com/livekit/protocol/livekit"
+)
+
+type IngressStreamHealth string
+
+const (
+	// participant joined, no tracks published yet
+	IngressStreamStarting IngressStreamHealth = "starting"
+	IngressStreamHealthy  IngressStreamHealth = "healthy"
+	// poor connection quality reported by the server
+	IngressStreamDegraded IngressStreamHealth = "degraded"
+	// server lost media from the ingress
+	IngressStreamLost IngressStreamHealth = "lost"
+)
+
+// IngressInfo describes an ingress participant. It is derived from ingress.* attributes,
+// published tracks and connection quality. Input type is not sent to participants, use
+// IngressClient.ListIngress with IngressID to get it.
+type IngressInfo struct {
+	IngressID  string
+	ResourceID string
+	// set while an out of network splice (e.g. ads break) is active
+	OutOfNetworkEventID string
+	Health              IngressStreamHealth
+}
+
+func (i IngressInfo) IsOutOfNetwork() bool {
+	return i.OutOfNetworkEventID != ""
+}
+
+// IngressInfo returns the ingress state of the participant, ok is false if it is not an ingress participant
+func (p *RemoteParticipant) IngressInfo() (info IngressInfo, ok bool) {
+	if p.Kind() != ParticipantIngress {
+		return IngressInfo{}, false
+	}
+
+	hasTracks := len(p.TrackPublications()) > 0
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	info = IngressInfo{
+		IngressID:           p.attributes[livekit.AttrIngressID],
+		ResourceID:          p.attributes[livekit.AttrIngressResourceID],
+		OutOfNetworkEventID: p.attributes[livekit.AttrIngressOutOfNetworkEventID],
+		Health:              IngressStreamHealthy,
+	}
+	switch {
+	case !hasTracks:
+		info.Health = IngressStreamStarting
+	case p.connectionQuality == nil:
+		// no quality update received yet
+	case p.connectionQuality.Quality == livekit.ConnectionQuality_LOST:
+		info.Health = IngressStreamLost
+	case p.connectionQuality.Quality == livekit.ConnectionQuality_POOR:
+		info.Health = IngressStreamDegraded
+	}
+	return info, true
+}
+
+func (p *RemoteParticipant) setConnectionQualityInfo(info *livekit.ConnectionQualityInfo) {
+	oldIngressInfo, _ := p.IngressInfo()
+	p.baseParticipant.setConnectionQualityInfo(info)
+	p.notifyIngressInfoChanged(oldIngressInfo)
+}
+
+func (p *RemoteParticipant) notifyIngressInfoChanged(old IngressInfo) {
+	if info, ok := p.IngressInfo(); ok && info != old {
+		p.Callback.OnIngressInfoChanged(info, p)
+		p.roomCallback.OnIngressInfoChanged(info, p)
+	}
+}
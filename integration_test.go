@@ -273,7 +273,7 @@ func TestResume(t *testing.T) {
 	require.NoError(t, err)
 
 	subCB.OnReconnecting = func() {
-		require.Equal(t, ConnectionStateReconnecting, sub.ConnectionState())
+		require.True(t, sub.ConnectionState().IsReconnecting())
 	}
 	subCB.OnReconnected = func() {
 		require.Equal(t, ConnectionStateConnected, sub.ConnectionState())
@@ -0,0 +1,138 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// send rates are measured over at least this long
+const sendStatsWindow = time.Second
+
+// PublishedLayer describes a layer that is being sent for a publication
+type PublishedLayer struct {
+	Quality livekit.VideoQuality
+	RID     string
+	SSRC    webrtc.SSRC
+	// Width and Height are the configured dimensions of the layer, zero when not set
+	Width  uint32
+	Height uint32
+	// Framerate in frames per second and Bitrate in bits per second are measured from the packets written
+	Framerate float64
+	Bitrate   uint64
+	// Paused is set when dynacast paused the layer as no subscriber receives it, or WithAudioPriority paused it
+	Paused bool
+}
+
+// sendStats measures the packets written to a LocalTrack
+type sendStats struct {
+	bytes         atomic.Uint64
+	frames        atomic.Uint64
+	lastTimestamp atomic.Uint32
+
+	lock       sync.Mutex
+	sampledAt  time.Time
+	lastBytes  uint64
+	lastFrames uint64
+	bitrate    uint64
+	framerate  float64
+}
+
+func (s *sendStats) add(p *rtp.Packet) {
+	size := uint64(p.MarshalSize())
+	first := s.bytes.Add(size) == size
+	// packets of a frame share the timestamp
+	if s.lastTimestamp.Swap(p.Timestamp) != p.Timestamp || first {
+		s.frames.Inc()
+	}
+}
+
+// rates returns bitrate and framerate since the previous sample
+func (s *sendStats) rates() (uint64, float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	if s.sampledAt.IsZero() {
+		s.sampledAt = now
+		s.lastBytes, s.lastFrames = s.bytes.Load(), s.frames.Load()
+		return 0, 0
+	}
+	elapsed := now.Sub(s.sampledAt)
+	if elapsed < sendStatsWindow {
+		return s.bitrate, s.framerate
+	}
+
+	bytes, frames := s.bytes.Load(), s.frames.Load()
+	s.bitrate = uint64(float64(bytes-s.lastBytes) * 8 / elapsed.Seconds())
+	s.framerate = float64(frames-s.lastFrames) / elapsed.Seconds()
+	s.lastBytes, s.lastFrames = bytes, frames
+	s.sampledAt = now
+	return s.bitrate, s.framerate
+}
+
+func (s *LocalTrack) publishedLayer(width, height uint32) PublishedLayer {
+	layer := PublishedLayer{
+		Quality: livekit.VideoQuality_HIGH,
+		RID:     s.RID(),
+		SSRC:    s.SSRC(),
+		Width:   width,
+		Height:  height,
+		Paused:  s.IsPaused(),
+	}
+	if s.videoLayer != nil {
+		layer.Quality = s.videoLayer.Quality
+		layer.Width, layer.Height = s.videoLayer.Width, s.videoLayer.Height
+	}
+	layer.Bitrate, layer.Framerate = s.sendStats.rates()
+	return layer
+}
+
+// Layers returns the layers being sent for a video publication of LocalTracks, from low to high quality.
+// Rates need a second of measurements, they are zero on the first call.
+// Returns nil for other tracks.
+func (p *LocalTrackPublication) Layers() []PublishedLayer {
+	if p.Kind() != TrackKindVideo {
+		return nil
+	}
+	p.lock.RLock()
+	track, _ := asLocalTrack(p.track)
+	simulcastTracks := make([]*LocalTrack, 0, len(p.simulcastTracks))
+	for _, st := range p.simulcastTracks {
+		simulcastTracks = append(simulcastTracks, st)
+	}
+	p.lock.RUnlock()
+
+	var layers []PublishedLayer
+	if len(simulcastTracks) > 0 {
+		for _, st := range simulcastTracks {
+			layers = append(layers, st.publishedLayer(0, 0))
+		}
+	} else if track != nil {
+		layers = append(layers, track.publishedLayer(uint32(p.opts.VideoWidth), uint32(p.opts.VideoHeight)))
+	}
+	slices.SortFunc(layers, func(a, b PublishedLayer) int {
+		return int(a.Quality) - int(b.Quality)
+	})
+	return layers
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestPublishedLayers(t *testing.T) {
+	codec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}
+	pub := NewLocalTrackPublication(TrackKindVideo, nil, TrackPublicationOptions{}, nil, logger)
+	for _, layer := range []*livekit.VideoLayer{
+		{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720},
+		{Quality: livekit.VideoQuality_LOW, Width: 320, Height: 180},
+	} {
+		track, err := NewLocalTrack(codec, WithSimulcast("video", layer))
+		require.NoError(t, err)
+		pub.addSimulcastTrack(track)
+	}
+
+	low := pub.GetSimulcastTrack(livekit.VideoQuality_LOW)
+	high := pub.GetSimulcastTrack(livekit.VideoQuality_HIGH)
+	high.setPaused(true)
+
+	layers := pub.Layers()
+	require.Len(t, layers, 2)
+	require.Equal(t, livekit.VideoQuality_LOW, layers[0].Quality)
+	require.Equal(t, uint32(320), layers[0].Width)
+	require.Equal(t, "q", layers[0].RID)
+	require.False(t, layers[0].Paused)
+	require.Equal(t, livekit.VideoQuality_HIGH, layers[1].Quality)
+	require.True(t, layers[1].Paused)
+
+	// 10 frames of 2 packets
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 2; j++ {
+			require.NoError(t, low.WriteRTP(&rtp.Packet{
+				Header:  rtp.Header{Version: 2, Timestamp: uint32(i * 3000)},
+				Payload: make([]byte, 88),
+			}, nil))
+		}
+	}
+	low.sendStats.lock.Lock()
+	low.sendStats.sampledAt = time.Now().Add(-2 * time.Second)
+	low.sendStats.lock.Unlock()
+
+	layers = pub.Layers()
+	require.InDelta(t, 5, layers[0].Framerate, 0.1)
+	require.InDelta(t, 20*100*8/2, layers[0].Bitrate, 10)
+	require.Zero(t, layers[1].Bitrate)
+
+	require.Nil(t, NewLocalTrackPublication(TrackKindAudio, nil, TrackPublicationOptions{}, nil, logger).Layers())
+}
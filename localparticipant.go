@@ -15,10 +15,12 @@
 package lksdk
 
 import (
+	"context"
 	"fmt"
 	"mime"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -26,6 +28,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pion/webrtc/v4"
+	"go.uber.org/atomic"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/protocol/livekit"
@@ -44,6 +47,12 @@ type LocalParticipant struct {
 
 	rpcPendingAcks      *sync.Map
 	rpcPendingResponses *sync.Map
+	// see RegisterRpcMethod
+	rpc rpcServer
+
+	pending pendingPublications
+	// see WithInterfaceMTULimit
+	interfacePayloadLimit atomic.Int32
 }
 
 func newLocalParticipant(engine *RTCEngine, roomcallback *RoomCallback, serverInfo *livekit.ServerInfo, log protoLogger.Logger) *LocalParticipant {
@@ -63,16 +72,37 @@ func (p *LocalParticipant) PublishTrack(track webrtc.TrackLocal, opts *TrackPubl
 	for _, opt := range pubOpts {
 		opt(pubOptions)
 	}
+	if len(pubOptions.codecFallbacks) > 0 {
+		return p.publishTrackWithFallback(track, opts, pubOptions)
+	}
+	return p.publishTrack(track, opts, pubOptions)
+}
+
+func (p *LocalParticipant) publishTrack(track webrtc.TrackLocal, opts *TrackPublicationOptions, pubOptions *LocalTrackPublishOptions) (*LocalTrackPublication, error) {
 	if pubOptions.backupCodecTrack != nil {
 		if _, ok := track.(TrackLocalWithCodec); !ok {
 			return nil, ErrMissingPrimaryCodec
 		}
 	}
+	if track.Kind() == webrtc.RTPCodecTypeVideo && p.engine.isAudioOnly() {
+		return nil, ErrAudioOnly
+	}
 
 	if opts == nil {
 		opts = &TrackPublicationOptions{}
 	}
 	kind := KindFromRTPType(track.Kind())
+	defaults := p.engine.publishDefaults()
+	if kind == TrackKindAudio {
+		opts.DisableDTX = opts.DisableDTX || defaults.DisableAudioDTX
+		opts.Stereo = opts.Stereo || defaults.StereoAudio
+	}
+	if opts.BackupCodecPolicy == livekit.BackupCodecPolicy_PREFER_REGRESSION {
+		opts.BackupCodecPolicy = defaults.BackupCodecPolicy
+	}
+	if pubOptions.publishTimeout == 0 {
+		pubOptions.publishTimeout = defaults.PublishTimeout
+	}
 	// default sources, since clients generally look for camera/mic
 	if opts.Source == livekit.TrackSource_UNKNOWN {
 		if kind == TrackKindVideo {
@@ -81,6 +111,9 @@ func (p *LocalParticipant) PublishTrack(track webrtc.TrackLocal, opts *TrackPubl
 			opts.Source = livekit.TrackSource_MICROPHONE
 		}
 	}
+	if err := checkPublishPermission(p.Permissions(), opts.Source); err != nil {
+		return nil, err
+	}
 
 	transport := p.getPublishTransport()
 	if transport == nil {
@@ -91,43 +124,58 @@ func (p *LocalParticipant) PublishTrack(track webrtc.TrackLocal, opts *TrackPubl
 	p.engine.RegisterTrackPublishedListener(track.ID(), pubChan)
 	defer p.engine.UnregisterTrackPublishedListener(track.ID())
 
+	p.pending.add(track.ID(), opts.Name, kind, opts.Source)
+	defer p.pending.remove(track.ID())
+
 	pub := NewLocalTrackPublication(kind, track, *opts, p.engine, p.log)
+	pub.cid = track.ID()
 	pub.onMuteChanged = p.onTrackMuted
+	pub.onEncryptionChanged = p.onEncryptionStatusChanged
+	p.configurePayloadSize(pubOptions, track, pubOptions.backupCodecTrack)
+	p.configurePipelineTracing(track, pubOptions.backupCodecTrack)
+
+	var primaryCodec webrtc.RTPCodecCapability
+	if lt, ok := asLocalTrack(track); ok {
+		primaryCodec = lt.Codec()
+	} else if tc, ok := track.(TrackLocalWithCodec); ok {
+		primaryCodec = tc.Codec()
+	}
+	pub.publishedCodec.Store(primaryCodec.MimeType)
 
 	// add transceivers - re-use if possible, AddTrack will try to re-use.
 	// NOTE: `AddTrack` technically cannot re-use transceiver if it was ever
 	// used to send media, i. e. if it was ever in a `sendrecv` or `sendonly`
 	// direction. But, pion does not enforce that based on browser behaviour
 	// observed in practice.
-	sender, err := transport.PeerConnection().AddTrack(track)
-	if err != nil {
-		return nil, err
-	}
+	transport.waitForRecyclableTransceiver(track.Kind())
+	err := transport.setupTransceivers(func() error {
+		sender, err := transport.PeerConnection().AddTrack(track)
+		if err != nil {
+			return err
+		}
 
-	// LocalTrack will consume rtcp packets so we don't need to consume again
-	lt, isSampleTrack := track.(*LocalTrack)
-	var primaryCodec webrtc.RTPCodecCapability
-	if isSampleTrack {
-		primaryCodec = lt.Codec()
-	} else {
-		if _, ok := track.(TrackLocalWithCodec); ok {
-			primaryCodec = track.(TrackLocalWithCodec).Codec()
+		// LocalTrack will consume rtcp packets so we don't need to consume again
+		if _, isSampleTrack := asLocalTrack(track); !isSampleTrack {
+			pub.readRTCP(sender)
 		}
-		pub.readRTCP(sender)
-	}
-	if primaryCodec.MimeType != "" {
-		for _, tr := range transport.PeerConnection().GetTransceivers() {
-			if tr.Sender() == sender {
-				codecs := append([]webrtc.RTPCodecParameters{}, sender.GetParameters().Codecs...)
-				for i, c := range codecs {
-					if strings.EqualFold(c.RTPCodecCapability.MimeType, primaryCodec.MimeType) {
-						codecs[0], codecs[i] = codecs[i], codecs[0]
-						break
+		if primaryCodec.MimeType != "" {
+			for _, tr := range transport.PeerConnection().GetTransceivers() {
+				if tr.Sender() == sender {
+					codecs := append([]webrtc.RTPCodecParameters{}, sender.GetParameters().Codecs...)
+					for i, c := range codecs {
+						if strings.EqualFold(c.RTPCodecCapability.MimeType, primaryCodec.MimeType) {
+							codecs[0], codecs[i] = codecs[i], codecs[0]
+							break
+						}
 					}
+					tr.SetCodecPreferences(codecs)
 				}
-				tr.SetCodecPreferences(codecs)
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	req := &livekit.AddTrackRequest{
@@ -173,8 +221,10 @@ func (p *LocalParticipant) PublishTrack(track webrtc.TrackLocal, opts *TrackPubl
 		}
 	}
 	if err := p.engine.SendAddTrack(req); err != nil {
+		p.abortPublish(pub, transport)
 		return nil, err
 	}
+	p.pending.setState(track.ID(), PublicationStateAwaitingAck)
 
 	transport.Negotiate()
 
@@ -182,11 +232,13 @@ func (p *LocalParticipant) PublishTrack(track webrtc.TrackLocal, opts *TrackPubl
 	select {
 	case pubRes = <-pubChan:
 		break
-	case <-time.After(trackPublishTimeout):
+	case <-time.After(pubOptions.getPublishTimeout()):
+		p.abortPublish(pub, transport)
 		return nil, ErrTrackPublishTimeout
 	}
 
 	pub.updateInfo(pubRes.Track)
+	p.checkPublishedEncryption(pub)
 	p.addPublication(pub)
 
 	p.Callback.OnLocalTrackPublished(pub, p)
@@ -207,6 +259,9 @@ func (p *LocalParticipant) PublishSimulcastTrack(tracks []*LocalTrack, opts *Tra
 		if track.Kind() != webrtc.RTPCodecTypeVideo {
 			return nil, ErrUnsupportedSimulcastKind
 		}
+		if p.engine.isAudioOnly() {
+			return nil, ErrAudioOnly
+		}
 		if track.videoLayer == nil || track.RID() == "" {
 			return nil, ErrInvalidSimulcastTrack
 		}
@@ -216,6 +271,9 @@ func (p *LocalParticipant) PublishSimulcastTrack(tracks []*LocalTrack, opts *Tra
 	for _, opt := range pubOpts {
 		opt(pubOptions)
 	}
+	if pubOptions.publishTimeout == 0 {
+		pubOptions.publishTimeout = p.engine.publishDefaults().PublishTimeout
+	}
 
 	tracksCopy := make([]*LocalTrack, len(tracks))
 	copy(tracksCopy, tracks)
@@ -232,6 +290,9 @@ func (p *LocalParticipant) PublishSimulcastTrack(tracks []*LocalTrack, opts *Tra
 	if opts.Source == livekit.TrackSource_UNKNOWN {
 		opts.Source = livekit.TrackSource_CAMERA
 	}
+	if err := checkPublishPermission(p.Permissions(), opts.Source); err != nil {
+		return nil, err
+	}
 
 	mainTrack := tracksCopy[len(tracksCopy)-1]
 
@@ -239,8 +300,17 @@ func (p *LocalParticipant) PublishSimulcastTrack(tracks []*LocalTrack, opts *Tra
 	p.engine.RegisterTrackPublishedListener(mainTrack.ID(), pubChan)
 	defer p.engine.UnregisterTrackPublishedListener(mainTrack.ID())
 
+	p.pending.add(mainTrack.ID(), opts.Name, KindFromRTPType(mainTrack.Kind()), opts.Source)
+	defer p.pending.remove(mainTrack.ID())
+
 	pub := NewLocalTrackPublication(KindFromRTPType(mainTrack.Kind()), nil, *opts, p.engine, p.log)
+	pub.cid = mainTrack.ID()
 	pub.onMuteChanged = p.onTrackMuted
+	pub.onEncryptionChanged = p.onEncryptionStatusChanged
+	for _, st := range slices.Concat(tracksCopy, pubOptions.backupCodecTracks) {
+		p.configurePayloadSize(pubOptions, st)
+		p.configurePipelineTracing(st)
+	}
 
 	transport := p.getPublishTransport()
 	if transport == nil {
@@ -248,38 +318,46 @@ func (p *LocalParticipant) PublishSimulcastTrack(tracks []*LocalTrack, opts *Tra
 	}
 
 	// add transceivers
-	var (
-		transceiver *webrtc.RTPTransceiver
-		sender      *webrtc.RTPSender
-		err         error
-	)
 	pc := transport.PeerConnection()
-	for idx, st := range tracksCopy {
-		if idx == 0 {
-			// add transceivers - re-use if possible, AddTrack will try to re-use.
-			// NOTE: `AddTrack` technically cannot re-use transceiver if it was ever
-			// used to send media, i. e. if it was ever in a `sendrecv` or `sendonly`
-			// direction. But, pion does not enforce that based on browser behaviour
-			// observed in practice.
-			sender, err = pc.AddTrack(st)
-			if err != nil {
-				return nil, err
-			}
+	transport.waitForRecyclableTransceiver(mainTrack.Kind())
+	err := transport.setupTransceivers(func() error {
+		var (
+			transceiver *webrtc.RTPTransceiver
+			sender      *webrtc.RTPSender
+			err         error
+		)
+		for idx, st := range tracksCopy {
+			if idx == 0 {
+				// add transceivers - re-use if possible, AddTrack will try to re-use.
+				// NOTE: `AddTrack` technically cannot re-use transceiver if it was ever
+				// used to send media, i. e. if it was ever in a `sendrecv` or `sendonly`
+				// direction. But, pion does not enforce that based on browser behaviour
+				// observed in practice.
+				sender, err = pc.AddTrack(st)
+				if err != nil {
+					return err
+				}
 
-			// as there is no way to get transceiver from sender, search
-			for _, tr := range pc.GetTransceivers() {
-				if tr.Sender() == sender {
-					transceiver = tr
-					break
+				// as there is no way to get transceiver from sender, search
+				for _, tr := range pc.GetTransceivers() {
+					if tr.Sender() == sender {
+						transceiver = tr
+						break
+					}
+				}
+			} else {
+				if err = sender.AddEncoding(st); err != nil {
+					return err
 				}
 			}
-		} else {
-			if err = sender.AddEncoding(st); err != nil {
-				return nil, err
-			}
+			pub.addSimulcastTrack(st)
+			st.SetTransceiver(transceiver)
 		}
-		pub.addSimulcastTrack(st)
-		st.SetTransceiver(transceiver)
+		return nil
+	})
+	if err != nil {
+		p.abortPublish(pub, transport)
+		return nil, err
 	}
 
 	var layers []*livekit.VideoLayer
@@ -329,18 +407,22 @@ func (p *LocalParticipant) PublishSimulcastTrack(tracks []*LocalTrack, opts *Tra
 	}
 	err = p.engine.SendAddTrack(req)
 	if err != nil {
+		p.abortPublish(pub, transport)
 		return nil, err
 	}
+	p.pending.setState(mainTrack.ID(), PublicationStateAwaitingAck)
 
 	var pubRes *livekit.TrackPublishedResponse
 	select {
 	case pubRes = <-pubChan:
 		break
-	case <-time.After(trackPublishTimeout):
+	case <-time.After(pubOptions.getPublishTimeout()):
+		p.abortPublish(pub, transport)
 		return nil, ErrTrackPublishTimeout
 	}
 
 	pub.updateInfo(pubRes.Track)
+	p.checkPublishedEncryption(pub)
 	p.addPublication(pub)
 
 	transport.Negotiate()
@@ -366,16 +448,21 @@ func (p *LocalParticipant) handleSubscribedQualityUpdate(subscribedQualityUpdate
 		"mime", trackPublication.MimeType(),
 		"subscribedQualityUpdate", protoLogger.Proto(subscribedQualityUpdate),
 	)
-	newCodecs := trackPublication.setPublishingCodecsQuality(subscribedQualityUpdate.SubscribedCodecs)
+	newCodecs, primaryUpdated := trackPublication.setPublishingCodecsQuality(subscribedQualityUpdate.SubscribedCodecs)
+	if primaryUpdated {
+		qualities := trackPublication.SubscribedQualities()
+		p.Callback.OnLocalTrackSubscribedQualityChanged(trackPublication, qualities, p)
+		p.roomCallback.OnLocalTrackSubscribedQualityChanged(trackPublication, qualities, p)
+	}
 	if len(newCodecs) > 0 {
-		go func() {
+		p.engine.goroutineRegistry().Go("backup-codec-publish", func() {
 			for _, codec := range newCodecs {
 				p.log.Infow("publishing backup codec from subscribed quality update", "trackID", trackPublication.SID(), "codec", codec)
 				if err := p.publishAdditionalCodecForTrack(trackPublication, codec); err != nil {
 					p.log.Warnw("failed to publish backup codec", err, "trackID", trackPublication.SID(), "codec", codec)
 				}
 			}
-		}()
+		})
 	}
 }
 
@@ -394,14 +481,14 @@ func (p *LocalParticipant) handleSubscribedAudioCodecUpdate(subscribedAudioCodec
 	)
 	newCodecs := trackPublication.setAudioCodecSubscribed(subscribedAudioCodecUpdate.SubscribedAudioCodecs)
 	if len(newCodecs) > 0 {
-		go func() {
+		p.engine.goroutineRegistry().Go("backup-codec-publish", func() {
 			for _, codec := range newCodecs {
 				p.log.Infow("publishing backup codec from subscribed audio codec update", "trackID", trackPublication.SID(), "codec", codec)
 				if err := p.publishAdditionalCodecForTrack(trackPublication, codec); err != nil {
 					p.log.Warnw("failed to publish backup codec", err, "trackID", trackPublication.SID(), "codec", codec)
 				}
 			}
-		}()
+		})
 	}
 }
 
@@ -434,51 +521,59 @@ func (p *LocalParticipant) publishAdditionalCodecForTrack(trackPublication *Loca
 	p.engine.RegisterTrackPublishedListener(mainTrack.ID(), pubChan)
 	defer p.engine.UnregisterTrackPublishedListener(mainTrack.ID())
 
+	p.pending.add(mainTrack.ID(), trackPublication.Name(), TrackKindVideo, trackPublication.Source())
+	defer p.pending.remove(mainTrack.ID())
+
 	pc := transport.PeerConnection()
-	if track != nil {
-		sender, err := pc.AddTrack(track)
-		if err != nil {
-			return err
-		}
+	if err := transport.setupTransceivers(func() error {
+		if track != nil {
+			sender, err := pc.AddTrack(track)
+			if err != nil {
+				return err
+			}
 
-		for _, tr := range pc.GetTransceivers() {
-			if tr.Sender() == sender {
-				codecs := append([]webrtc.RTPCodecParameters{}, sender.GetParameters().Codecs...)
-				for i, c := range codecs {
-					if strings.EqualFold(c.RTPCodecCapability.MimeType, mainTrack.Codec().MimeType) {
-						codecs[0], codecs[i] = codecs[i], codecs[0]
-						break
+			for _, tr := range pc.GetTransceivers() {
+				if tr.Sender() == sender {
+					codecs := append([]webrtc.RTPCodecParameters{}, sender.GetParameters().Codecs...)
+					for i, c := range codecs {
+						if strings.EqualFold(c.RTPCodecCapability.MimeType, mainTrack.Codec().MimeType) {
+							codecs[0], codecs[i] = codecs[i], codecs[0]
+							break
+						}
 					}
+					tr.SetCodecPreferences(codecs)
 				}
-				tr.SetCodecPreferences(codecs)
 			}
-		}
-	} else { // simulcast backup tracks
-		var (
-			transceiver *webrtc.RTPTransceiver
-			sender      *webrtc.RTPSender
-			err         error
-		)
-		for idx, st := range tracks {
-			if idx == 0 {
-				sender, err = pc.AddTrack(st)
-				if err != nil {
-					return err
-				}
+		} else { // simulcast backup tracks
+			var (
+				transceiver *webrtc.RTPTransceiver
+				sender      *webrtc.RTPSender
+				err         error
+			)
+			for idx, st := range tracks {
+				if idx == 0 {
+					sender, err = pc.AddTrack(st)
+					if err != nil {
+						return err
+					}
 
-				for _, tr := range pc.GetTransceivers() {
-					if tr.Sender() == sender {
-						transceiver = tr
-						break
+					for _, tr := range pc.GetTransceivers() {
+						if tr.Sender() == sender {
+							transceiver = tr
+							break
+						}
+					}
+				} else {
+					if err = sender.AddEncoding(st); err != nil {
+						return err
 					}
 				}
-			} else {
-				if err = sender.AddEncoding(st); err != nil {
-					return err
-				}
+				st.SetTransceiver(transceiver)
 			}
-			st.SetTransceiver(transceiver)
 		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	req := &livekit.AddTrackRequest{
@@ -494,6 +589,7 @@ func (p *LocalParticipant) publishAdditionalCodecForTrack(trackPublication *Loca
 	if err := p.engine.SendAddTrack(req); err != nil {
 		return err
 	}
+	p.pending.setState(mainTrack.ID(), PublicationStateAwaitingAck)
 	trackPublication.setBackupCodecPublished()
 
 	transport.Negotiate()
@@ -509,7 +605,17 @@ func (p *LocalParticipant) publishAdditionalCodecForTrack(trackPublication *Loca
 	return nil
 }
 
-func (p *LocalParticipant) republishTracks() {
+// abortPublish removes transceivers added for a publication that did not complete
+func (p *LocalParticipant) abortPublish(pub *LocalTrackPublication, transport *PCTransport) {
+	if err := pub.unpublish(transport); err != nil {
+		p.log.Warnw("could not remove transceiver of failed publication", err, "name", pub.Name())
+	}
+	transport.Negotiate()
+}
+
+// removes all publications without closing the underlying tracks,
+// returning the ones that still have media attached
+func (p *LocalParticipant) unpublishAllTracks() []*LocalTrackPublication {
 	var localPubs []*LocalTrackPublication
 	p.tracks.Range(func(key, value interface{}) bool {
 		track := value.(*LocalTrackPublication)
@@ -525,23 +631,35 @@ func (p *LocalParticipant) republishTracks() {
 		p.roomCallback.OnLocalTrackUnpublished(track, p)
 		return true
 	})
+	return localPubs
+}
 
-	for _, pub := range localPubs {
-		opt := pub.PublicationOptions()
-		backupCodecTrack, backupCodecTracksForSimulcast := pub.getBackupCodecTrack()
-		if tracks := pub.TrackLocalForSimulcast(); len(tracks) > 0 {
-			p.PublishSimulcastTrack(tracks, &opt, WithBackupCodecForSimulcastTrack(backupCodecTracksForSimulcast))
-		} else if track := pub.TrackLocal(); track != nil {
-			_, err := p.PublishTrack(track, &opt, WithBackupCodec(backupCodecTrack))
-			if err != nil {
-				p.engine.log.Warnw("could not republish track", err, "track", pub.SID())
-			}
-		} else {
-			p.engine.log.Warnw("could not republish track as no track local found", nil, "track", pub.SID())
+func (p *LocalParticipant) republishTracks() {
+	for _, pub := range p.unpublishAllTracks() {
+		newPub, err := p.republishTrack(pub)
+		if err != nil {
+			p.engine.log.Warnw("could not republish track", err, "track", pub.SID())
+			p.Callback.OnLocalTrackRepublishFailed(pub, err, p)
+			p.roomCallback.OnLocalTrackRepublishFailed(pub, err, p)
+			continue
 		}
+		p.Callback.OnLocalTrackRepublished(pub, newPub, p)
+		p.roomCallback.OnLocalTrackRepublished(pub, newPub, p)
 	}
 }
 
+func (p *LocalParticipant) republishTrack(pub *LocalTrackPublication) (*LocalTrackPublication, error) {
+	opt := pub.PublicationOptions()
+	backupCodecTrack, backupCodecTracksForSimulcast := pub.getBackupCodecTrack()
+	if tracks := pub.TrackLocalForSimulcast(); len(tracks) > 0 {
+		return p.PublishSimulcastTrack(tracks, &opt, WithBackupCodecForSimulcastTrack(backupCodecTracksForSimulcast))
+	}
+	if track := pub.TrackLocal(); track != nil {
+		return p.PublishTrack(track, &opt, WithBackupCodec(backupCodecTrack))
+	}
+	return nil, ErrCannotFindTrack
+}
+
 func (p *LocalParticipant) closeTracks() {
 	var localPubs []*LocalTrackPublication
 	p.tracks.Range(func(key, value interface{}) bool {
@@ -581,7 +699,8 @@ func (p *LocalParticipant) PublishData(payload []byte, opts ...DataPublishOption
 // By default, the message can be received by all participants in a room,
 // see WithDataPublishDestination for choosing specific participants.
 //
-// Messages are sent via UDP and offer no delivery guarantees, see WithDataPublishReliable for sending data reliably (with retries).
+// Messages are sent via UDP and offer no delivery guarantees, see WithDataPublishReliable for sending data reliably (with retries)
+// and WithReliableDataByDefault for changing the default.
 func (p *LocalParticipant) PublishDataPacket(pck DataPacket, opts ...DataPublishOption) error {
 	options := &dataPublishOptions{}
 	for _, opt := range opts {
@@ -594,11 +713,7 @@ func (p *LocalParticipant) PublishDataPacket(pck DataPacket, opts ...DataPublish
 		}
 	}
 
-	// This matches the default value of Kind on protobuf level.
-	kind := livekit.DataPacket_LOSSY
-	if options.Reliable != nil && *options.Reliable {
-		kind = livekit.DataPacket_RELIABLE
-	}
+	kind := p.dataPacketKind(options)
 
 	dataPacket.DestinationIdentities = options.DestinationIdentities
 	if u, ok := dataPacket.Value.(*livekit.DataPacket_User); ok && u.User != nil {
@@ -606,7 +721,19 @@ func (p *LocalParticipant) PublishDataPacket(pck DataPacket, opts ...DataPublish
 		u.User.DestinationIdentities = options.DestinationIdentities
 	}
 
-	return p.engine.publishDataPacket(dataPacket, kind)
+	err := p.engine.publishDataPacket(dataPacket, kind)
+	if options.Sequence != nil {
+		*options.Sequence = dataPacket.Sequence
+	}
+	return err
+}
+
+func (p *LocalParticipant) dataPacketKind(options *dataPublishOptions) livekit.DataPacket_Kind {
+	if options.Reliable != nil && *options.Reliable || options.Reliable == nil && p.engine.reliableDataByDefault() {
+		return livekit.DataPacket_RELIABLE
+	}
+	// This matches the default value of Kind on protobuf level.
+	return livekit.DataPacket_LOSSY
 }
 
 // UnpublishTrack stops publishing a track and removes it from the room.
@@ -715,6 +842,25 @@ func (p *LocalParticipant) onTrackMuted(pub *LocalTrackPublication, muted bool)
 	}
 }
 
+func (p *LocalParticipant) onEncryptionStatusChanged(pub *LocalTrackPublication, status EncryptionStatus) {
+	p.Callback.OnEncryptionStatusChanged(pub, status, p)
+	p.roomCallback.OnEncryptionStatusChanged(pub, status, p)
+}
+
+// checkPublishedEncryption reports a publication the server did not accept with the requested encryption
+func (p *LocalParticipant) checkPublishedEncryption(pub *LocalTrackPublication) {
+	status := pub.EncryptionStatus()
+	if status.Type == pub.opts.Encryption {
+		return
+	}
+	p.log.Warnw("track published with unexpected encryption", nil,
+		"trackID", pub.SID(),
+		"requested", pub.opts.Encryption.String(),
+		"published", status.Type.String(),
+	)
+	p.onEncryptionStatusChanged(pub, status)
+}
+
 // SetSubscriptionPermission controls who can subscribe to LocalParticipant's published tracks.
 //
 // By default, all participants can subscribe. This allows fine-grained control over
@@ -792,102 +938,124 @@ func (p *LocalParticipant) HandleIncomingRpcResponse(requestId string, payload *
 }
 
 // PerformRpc initiates an RPC call to a remote participant.
-// Returns the response payload or an error if the call fails or times out.
+// Returns the response payload or an error if the call fails or times out, failures of the call are *RpcError.
 func (p *LocalParticipant) PerformRpc(params PerformRpcParams) (*string, error) {
-	responseTimeout := 15000 * time.Millisecond
+	return p.PerformRpcContext(context.Background(), params)
+}
+
+// PerformRpcContext is PerformRpc that stops waiting for the response when ctx is done and returns ctx.Err().
+// A response arriving later is ignored.
+func (p *LocalParticipant) PerformRpcContext(ctx context.Context, params PerformRpcParams) (*string, error) {
+	responseTimeout := defaultRpcResponseTimeout
 	if params.ResponseTimeout != nil {
 		responseTimeout = *params.ResponseTimeout
 	}
 
-	resultChan := make(chan *string, 1)
-	errorChan := make(chan error, 1)
-
 	maxRoundTripLatency := 7000 * time.Millisecond
 	minEffectiveResponseTimeout := 1 * time.Second
 
-	go func() {
-		if byteLength(params.Payload) > MaxPayloadBytes {
-			errorChan <- rpcErrorFromBuiltInCodes(RpcRequestPayloadTooLarge, nil)
-			return
-		}
+	if byteLength(params.Payload) > MaxPayloadBytes {
+		return nil, rpcErrorFromBuiltInCodes(RpcRequestPayloadTooLarge, nil)
+	}
+	if p.serverInfo != nil && compareVersions(p.serverInfo.Version, "1.8.0") < 0 {
+		return nil, rpcErrorFromBuiltInCodes(RpcUnsupportedServer, nil)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-		if p.serverInfo != nil && compareVersions(p.serverInfo.Version, "1.8.0") < 0 {
-			errorChan <- rpcErrorFromBuiltInCodes(RpcUnsupportedServer, nil)
-			return
-		}
+	effectiveResponseTimeout := responseTimeout - maxRoundTripLatency
+	if effectiveResponseTimeout < minEffectiveResponseTimeout {
+		effectiveResponseTimeout = minEffectiveResponseTimeout
+	}
+	id := uuid.New().String()
 
-		effectiveResponseTimeout := responseTimeout - maxRoundTripLatency
-		if effectiveResponseTimeout < minEffectiveResponseTimeout {
-			effectiveResponseTimeout = minEffectiveResponseTimeout
+	resultChan := make(chan *string, 1)
+	errorChan := make(chan error, 1)
+	fail := func(err error) {
+		select {
+		case errorChan <- err:
+		default:
 		}
-		id := uuid.New().String()
-		p.engine.publishRpcRequest(params.DestinationIdentity, id, params.Method, params.Payload, effectiveResponseTimeout)
-
-		// Client-side timers:
-		// - responseTimer: total time client is willing to wait for a response.
-		// - ackTimer: time allowed for initial ACK/round-trip.
-		responseTimer := time.AfterFunc(responseTimeout, func() {
-			p.rpcPendingResponses.Delete(id)
-
-			select {
-			case errorChan <- rpcErrorFromBuiltInCodes(RpcResponseTimeout, nil):
-			default:
-			}
-		})
+	}
 
-		ackTimer := time.AfterFunc(maxRoundTripLatency, func() {
-			p.rpcPendingAcks.Delete(id)
-			p.rpcPendingResponses.Delete(id)
-			responseTimer.Stop()
+	// Client-side timers:
+	// - responseTimer: total time client is willing to wait for a response.
+	// - ackTimer: time allowed for initial ACK/round-trip.
+	responseTimer := time.AfterFunc(responseTimeout, func() {
+		p.rpcPendingResponses.Delete(id)
+		fail(rpcErrorFromBuiltInCodes(RpcResponseTimeout, nil))
+	})
 
-			select {
-			case errorChan <- rpcErrorFromBuiltInCodes(RpcConnectionTimeout, nil):
-			default:
-			}
-		})
+	ackTimer := time.AfterFunc(maxRoundTripLatency, func() {
+		p.rpcPendingAcks.Delete(id)
+		p.rpcPendingResponses.Delete(id)
+		responseTimer.Stop()
+		fail(rpcErrorFromBuiltInCodes(RpcConnectionTimeout, nil))
+	})
+
+	forget := func() {
+		ackTimer.Stop()
+		responseTimer.Stop()
+		p.rpcPendingAcks.Delete(id)
+		p.rpcPendingResponses.Delete(id)
+	}
+
+	// registered before sending, the ack may arrive before publishing returns
+	p.rpcPendingAcks.Store(id, rpcPendingAckHandler{
+		resolve: func() {
+			ackTimer.Stop()
+		},
+		participantIdentity: params.DestinationIdentity,
+	})
 
-		p.rpcPendingAcks.Store(id, rpcPendingAckHandler{
-			resolve: func() {
+	p.rpcPendingResponses.Store(id, rpcPendingResponseHandler{
+		resolve: func(payload *string, error *RpcError) {
+			responseTimer.Stop()
+			if _, ok := p.rpcPendingAcks.Load(id); ok {
+				p.engine.log.Warnw("RPC response received before ack", nil, "requestId", id)
+				p.rpcPendingAcks.Delete(id)
 				ackTimer.Stop()
-			},
-			participantIdentity: params.DestinationIdentity,
-		})
+			}
 
-		p.rpcPendingResponses.Store(id, rpcPendingResponseHandler{
-			resolve: func(payload *string, error *RpcError) {
-				responseTimer.Stop()
-				if _, ok := p.rpcPendingAcks.Load(id); ok {
-					p.engine.log.Warnw("RPC response received before ack", nil, "requestId", id)
-					p.rpcPendingAcks.Delete(id)
-					ackTimer.Stop()
+			if error != nil {
+				fail(error)
+			} else {
+				if payload == nil {
+					emptyStr := ""
+					payload = &emptyStr
 				}
-
-				if error != nil {
-					errorChan <- error
-				} else {
-					if payload != nil {
-						resultChan <- payload
-					} else {
-						emptyStr := ""
-						resultChan <- &emptyStr
-					}
+				select {
+				case resultChan <- payload:
+				default:
 				}
-			},
-			participantIdentity: params.DestinationIdentity,
-		})
-	}()
+			}
+		},
+		participantIdentity: params.DestinationIdentity,
+	})
+
+	p.engine.goroutineRegistry().Go("rpc-request", func() {
+		if err := p.engine.publishRpcRequest(params.DestinationIdentity, id, params.Method, params.Payload, effectiveResponseTimeout); err != nil {
+			forget()
+			fail(rpcErrorFromBuiltInCodes(RpcSendFailed, nil))
+		}
+	})
 
 	select {
 	case result := <-resultChan:
 		return result, nil
 	case err := <-errorChan:
 		return nil, err
+	case <-ctx.Done():
+		forget()
+		return nil, ctx.Err()
 	}
 }
 
 func (p *LocalParticipant) cleanup() {
 	p.rpcPendingAcks.Clear()
 	p.rpcPendingResponses.Clear()
+	p.rpc.clear()
 }
 
 // StreamText creates a new text stream writer with the provided options.
@@ -928,6 +1096,7 @@ func (p *LocalParticipant) StreamText(options StreamTextOptions) *TextStreamWrit
 			TextHeader: &livekit.DataStream_TextHeader{
 				OperationType:     livekit.DataStream_CREATE,
 				AttachedStreamIds: options.AttachedStreamIds,
+				Generated:         options.Generated,
 			},
 		},
 	}
@@ -0,0 +1,252 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func newTestLocalParticipant(t *testing.T, cb *RoomCallback) *LocalParticipant {
+	room := NewRoom(cb)
+	room.engine.connParams = &signalling.ConnectParams{}
+	require.NoError(t, room.engine.configure(nil, nil, nil))
+	t.Cleanup(func() { _ = room.engine.Close(context.Background()) })
+	return room.LocalParticipant
+}
+
+func addTestLocalPublication(p *LocalParticipant, sid string, track Track) *LocalTrackPublication {
+	pub := NewLocalTrackPublication(TrackKindAudio, track, TrackPublicationOptions{Name: sid}, p.engine, p.engine.log)
+	pub.updateInfo(&livekit.TrackInfo{Sid: sid, Type: livekit.TrackType_AUDIO})
+	p.addPublication(pub)
+	return pub
+}
+
+func TestRepublishTracks(t *testing.T) {
+	var unpublished []string
+	var failed []*LocalTrackPublication
+	var failures []error
+	cb := NewRoomCallback()
+	cb.OnLocalTrackUnpublished = func(pub *LocalTrackPublication, lp *LocalParticipant) {
+		unpublished = append(unpublished, pub.SID())
+	}
+	cb.OnLocalTrackRepublished = func(oldPub, pub *LocalTrackPublication, lp *LocalParticipant) {
+		t.Fatal("republished without permission")
+	}
+	cb.OnLocalTrackRepublishFailed = func(oldPub *LocalTrackPublication, err error, lp *LocalParticipant) {
+		failed = append(failed, oldPub)
+		failures = append(failures, err)
+	}
+	p := newTestLocalParticipant(t, cb)
+	p.updateInfo(&livekit.ParticipantInfo{Sid: "PA_local", Identity: "local", Permission: &livekit.ParticipantPermission{}})
+
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "audio", "local")
+	require.NoError(t, err)
+	withMedia := addTestLocalPublication(p, "TR_media", track)
+	addTestLocalPublication(p, "TR_none", nil)
+
+	// every publication is removed, only the ones with media are published again
+	p.republishTracks()
+	require.ElementsMatch(t, []string{"TR_media", "TR_none"}, unpublished)
+	require.Empty(t, p.TrackPublications())
+	require.Equal(t, []*LocalTrackPublication{withMedia}, failed)
+	require.ErrorIs(t, failures[0], ErrPublishNotPermitted)
+}
+
+func TestUnpublishAllTracks(t *testing.T) {
+	p := newTestLocalParticipant(t, NewRoomCallback())
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "audio", "local")
+	require.NoError(t, err)
+	withMedia := addTestLocalPublication(p, "TR_media", track)
+	addTestLocalPublication(p, "TR_none", nil)
+
+	// with WithAutoRepublish(false), the tracks are only removed and left to the application
+	require.Equal(t, []*LocalTrackPublication{withMedia}, p.unpublishAllTracks())
+	require.Empty(t, p.TrackPublications())
+
+	params := &signalling.ConnectParams{}
+	WithAutoRepublish(false)(params)
+	require.True(t, params.DisableAutoRepublish)
+}
+
+// publishSignalTransport acknowledges track publications once respond is set
+type publishSignalTransport struct {
+	signalling.SignalTransport
+	engine *RTCEngine
+
+	lock      sync.Mutex
+	respond   bool
+	addTracks int
+}
+
+func (s *publishSignalTransport) Close() {}
+
+func (s *publishSignalTransport) SendMessage(msg proto.Message) error {
+	req := msg.(*livekit.SignalRequest).GetAddTrack()
+	if req == nil {
+		return nil
+	}
+	s.lock.Lock()
+	s.addTracks++
+	respond := s.respond
+	s.lock.Unlock()
+	if respond {
+		go s.engine.OnLocalTrackPublished(&livekit.TrackPublishedResponse{
+			Cid:   req.Cid,
+			Track: &livekit.TrackInfo{Sid: "TR_" + req.Cid, Name: req.Name, Type: req.Type},
+		})
+	}
+	return nil
+}
+
+func (s *publishSignalTransport) setRespond(respond bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.respond = respond
+}
+
+func TestPublishTimeout(t *testing.T) {
+	p := newTestLocalParticipant(t, NewRoomCallback())
+	transport := &publishSignalTransport{engine: p.engine}
+	p.engine.signalTransport = transport
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "audio", "local")
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = p.PublishTrack(track, &TrackPublicationOptions{Name: "mic"}, WithPublishTimeout(50*time.Millisecond))
+	require.ErrorIs(t, err, ErrTrackPublishTimeout)
+	require.Less(t, time.Since(start), trackPublishTimeout)
+	require.Empty(t, p.TrackPublications())
+
+	// the transceiver of the failed publication no longer sends the track
+	publisher, ok := p.engine.Publisher()
+	require.True(t, ok)
+	require.NotEmpty(t, publisher.PeerConnection().GetTransceivers())
+	for _, tr := range publisher.PeerConnection().GetTransceivers() {
+		if sender := tr.Sender(); sender != nil {
+			require.Nil(t, sender.Track())
+		}
+	}
+
+	// so it can be published again
+	transport.setRespond(true)
+	pub, err := p.PublishTrack(track, &TrackPublicationOptions{Name: "mic"}, WithPublishTimeout(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, "TR_audio", pub.SID())
+	require.Equal(t, 2, transport.addTracks)
+}
+
+func TestAudioOnlyPublish(t *testing.T) {
+	p := newTestLocalParticipant(t, NewRoomCallback())
+	p.engine.connParams.AudioOnly = true
+
+	video, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, "video", "local")
+	require.NoError(t, err)
+	_, err = p.PublishTrack(video, &TrackPublicationOptions{Name: "camera"})
+	require.ErrorIs(t, err, ErrAudioOnly)
+
+	layer, err := NewLocalTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+		WithSimulcast("video", &livekit.VideoLayer{Quality: livekit.VideoQuality_HIGH}))
+	require.NoError(t, err)
+	_, err = p.PublishSimulcastTrack([]*LocalTrack{layer}, &TrackPublicationOptions{Name: "camera"})
+	require.ErrorIs(t, err, ErrAudioOnly)
+	require.Empty(t, p.TrackPublications())
+}
+
+func TestPublishTimeoutDefault(t *testing.T) {
+	opts := &LocalTrackPublishOptions{}
+	require.Equal(t, trackPublishTimeout, opts.getPublishTimeout())
+	WithPublishTimeout(time.Second)(opts)
+	require.Equal(t, time.Second, opts.getPublishTimeout())
+}
+
+func TestSubscribedQualityPausesTrack(t *testing.T) {
+	var qualities [][]*livekit.SubscribedQuality
+	cb := NewRoomCallback()
+	cb.OnLocalTrackSubscribedQualityChanged = func(pub *LocalTrackPublication, q []*livekit.SubscribedQuality, lp *LocalParticipant) {
+		qualities = append(qualities, q)
+	}
+	p := newTestLocalParticipant(t, cb)
+
+	track, err := NewLocalTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000})
+	require.NoError(t, err)
+	pub := NewLocalTrackPublication(TrackKindVideo, track, TrackPublicationOptions{}, p.engine, p.log)
+	pub.updateInfo(&livekit.TrackInfo{Sid: "TR_video", Type: livekit.TrackType_VIDEO, MimeType: webrtc.MimeTypeVP8})
+	p.addPublication(pub)
+	update := func(enabled bool) {
+		p.handleSubscribedQualityUpdate(&livekit.SubscribedQualityUpdate{
+			TrackSid: "TR_video",
+			SubscribedCodecs: []*livekit.SubscribedCodec{{
+				Codec:     "vp8",
+				Qualities: []*livekit.SubscribedQuality{{Quality: livekit.VideoQuality_HIGH, Enabled: enabled}},
+			}},
+		})
+	}
+
+	// a single layer track is paused when no quality is needed, without changing its mute state
+	update(false)
+	require.True(t, track.IsPaused())
+	require.False(t, track.muted.Load())
+	require.Len(t, qualities, 1)
+	require.False(t, pub.SubscribedQualities()[0].Enabled)
+
+	track.setMuted(true)
+	update(true)
+	require.False(t, track.IsPaused())
+	require.True(t, track.muted.Load())
+	require.Len(t, qualities, 2)
+	require.True(t, pub.SubscribedQualities()[0].Enabled)
+}
+
+func TestSubscribedQualityPausesLayers(t *testing.T) {
+	codec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}
+	pub := NewLocalTrackPublication(TrackKindVideo, nil, TrackPublicationOptions{}, nil, logger)
+	pub.updateInfo(&livekit.TrackInfo{Sid: "TR_video", Type: livekit.TrackType_VIDEO, MimeType: webrtc.MimeTypeVP8})
+	layers := make(map[livekit.VideoQuality]*LocalTrack)
+	for _, quality := range []livekit.VideoQuality{livekit.VideoQuality_LOW, livekit.VideoQuality_HIGH} {
+		layer, err := NewLocalTrack(codec, WithSimulcast("video", &livekit.VideoLayer{Quality: quality}))
+		require.NoError(t, err)
+		pub.addSimulcastTrack(layer)
+		layers[quality] = layer
+	}
+
+	_, primaryUpdated := pub.setPublishingCodecsQuality([]*livekit.SubscribedCodec{{
+		Codec: "vp8",
+		Qualities: []*livekit.SubscribedQuality{
+			{Quality: livekit.VideoQuality_LOW, Enabled: true},
+			{Quality: livekit.VideoQuality_HIGH, Enabled: false},
+		},
+	}})
+	require.True(t, primaryUpdated)
+	require.False(t, layers[livekit.VideoQuality_LOW].IsPaused())
+	require.True(t, layers[livekit.VideoQuality_HIGH].IsPaused())
+	require.False(t, layers[livekit.VideoQuality_HIGH].muted.Load())
+
+	// updates for other codecs leave the primary one alone
+	_, primaryUpdated = pub.setPublishingCodecsQuality([]*livekit.SubscribedCodec{{Codec: "av1"}})
+	require.False(t, primaryUpdated)
+	require.True(t, layers[livekit.VideoQuality_HIGH].IsPaused())
+}
@@ -35,6 +35,8 @@ import (
 	"github.com/livekit/protocol/livekit"
 	protoLogger "github.com/livekit/protocol/logger"
 	"github.com/livekit/protocol/utils/guid"
+
+	sdkinterceptor "github.com/livekit/server-sdk-go/v2/pkg/interceptor"
 )
 
 const (
@@ -49,6 +51,10 @@ var (
 
 type SampleWriteOptions struct {
 	AudioLevel *uint8
+	// PartialFrame is set when the sample is a part of a frame that continues with the next sample, e.g. an H.264
+	// slice written as soon as the encoder produced it. Parts of a frame share the timestamp of the first part,
+	// which is timed like a whole frame, and only the packets of the last part have the marker bit set.
+	PartialFrame bool
 }
 
 // LocalTrack is a local track that simplifies writing samples.
@@ -75,8 +81,10 @@ type LocalTrack struct {
 	simulcastID      string
 	videoLayer       *livekit.VideoLayer
 	onRTCP           func(rtcp.Packet)
+	sendStats        sendStats
 
 	muted        atomic.Bool
+	paused       atomic.Bool
 	disconnected atomic.Bool
 	cancelWrite  func()
 	writeClosed  chan struct{}
@@ -85,9 +93,44 @@ type LocalTrack struct {
 	onUnbind     func()
 	// notify when sample provider responds with EOF
 	onWriteComplete func()
+
+	payloader rtp.Payloader
+	// configured max payload size and the limit from the interface MTU, 0 when not set
+	maxPayloadSize        int
+	interfacePayloadLimit int
+	// timestamp of the next packet, kept when the packetizer is recreated
+	nextPacketTimestamp    uint32
+	hasNextPacketTimestamp bool
+
+	// see WithDriftCompensation, nil when disabled
+	drift *driftCompensator
+
+	// see WithPipelineTracer, pacerDelays is set when packets are paced
+	tracer      *PipelineTracer
+	pacerDelays *sdkinterceptor.PacerDelayMonitor
+
+	// set by WithAudioPriority, separate from paused which dynacast controls
+	congestionPaused atomic.Bool
+
+	// set while the parts of a frame are written, see SampleWriteOptions.PartialFrame
+	inFrame      bool
+	frameSamples uint32
 }
 type LocalSampleTrack = LocalTrack
 
+// asLocalTrack returns the LocalTrack of tracks built on it, e.g. LocalRTPTrack
+func asLocalTrack(t Track) (*LocalTrack, bool) {
+	switch lt := t.(type) {
+	case *LocalTrack:
+		return lt, lt != nil
+	case *LocalRTPTrack:
+		if lt != nil {
+			return lt.LocalTrack, true
+		}
+	}
+	return nil, false
+}
+
 type LocalTrackOptions func(s *LocalTrack)
 type LocalSampleTrackOptions = LocalTrackOptions
 
@@ -209,15 +252,15 @@ func (s *LocalTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters
 		}
 	}
 	s.sequencer = rtp.NewRandomSequencer()
-	s.packetizer = rtp.NewPacketizer(
-		rtpOutboundMTU,
-		0, // Value is handled when writing
-		0, // Value is handled when writing
-		payloader,
-		s.sequencer,
-		codec.ClockRate,
-	)
+	s.payloader = payloader
 	s.clockRate = float64(codec.RTPCodecCapability.ClockRate)
+	if s.drift != nil {
+		s.drift.setClockRate(s.clockRate)
+	}
+	s.hasNextPacketTimestamp = false
+	s.inFrame = false
+	s.resetPacketizerLocked()
+	s.registerPacerDelaysLocked()
 	onBind := s.onBind
 	provider := s.provider
 	onWriteComplete := s.onWriteComplete
@@ -245,6 +288,7 @@ func (s *LocalTrack) Unbind(t webrtc.TrackLocalContext) error {
 	onUnbind := s.onUnbind
 	s.bound.Store(false)
 	cancel := s.cancelWrite
+	s.unregisterPacerDelaysLocked()
 	s.lock.Unlock()
 
 	var err error
@@ -313,6 +357,8 @@ func (s *LocalTrack) WriteRTP(p *rtp.Packet, opts *SampleWriteOptions) error {
 	transceiver := s.transceiver
 	ssrcAcked := s.ssrcAcked
 	audioLevelID := s.audioLevelID
+	tracer := s.tracer
+	paced := s.pacerDelays != nil
 	s.lock.RUnlock()
 
 	if audioLevelID != 0 && opts != nil && opts.AudioLevel != nil {
@@ -349,18 +395,38 @@ func (s *LocalTrack) WriteRTP(p *rtp.Packet, opts *SampleWriteOptions) error {
 		}
 	}
 
-	return s.rtpTrack.WriteRTP(p)
+	// paced packets are written to the socket later, the pacer reports their delays
+	if tracer != nil && !paced {
+		defer tracer.Start(PipelineStageSocket)()
+	}
+	if err := s.rtpTrack.WriteRTP(p); err != nil {
+		return err
+	}
+	s.sendStats.add(p)
+	return nil
 }
 
 // WriteSample writes a media sample to the track with optional write options.
 // This handles timing, RTP packetization, and sample ordering automatically.
 func (s *LocalTrack) WriteSample(sample media.Sample, opts *SampleWriteOptions) error {
+	packetizeStart := time.Now()
 	s.lock.Lock()
 	if s.packetizer == nil {
 		s.lock.Unlock()
 		return nil
 	}
 
+	partial := opts != nil && opts.PartialFrame
+	if s.inFrame {
+		// a following part of a frame, timed with the first part
+		packets := s.packetizeLocked(sample.Data, 0, partial)
+		tracer := s.tracer
+		s.lock.Unlock()
+		tracer.Observe(PipelineStagePacketizer, time.Since(packetizeStart))
+
+		return s.writePackets(packets, opts)
+	}
+
 	//
 	// A few different cases for time stamp
 	//   1. Publishers sending too fast, like from a file or some other source which has faster than real time data.
@@ -463,6 +529,12 @@ func (s *LocalTrack) WriteSample(sample media.Sample, opts *SampleWriteOptions)
 		}
 		samples = elapsedDurationSamples
 	}
+	if s.drift != nil && sample.PacketTimestamp == 0 {
+		if correction := s.drift.update(skippedSamples+samples, time.Now()); correction != 0 {
+			samples = uint32(int64(samples) + correction)
+			currentRTPTimestamp = uint32(int64(currentRTPTimestamp) + correction)
+		}
+	}
 
 	// skip packets by the number of previously dropped packets
 	for i := uint16(0); i < sample.PrevDroppedPackets; i++ {
@@ -477,12 +549,42 @@ func (s *LocalTrack) WriteSample(sample media.Sample, opts *SampleWriteOptions)
 		s.packetizer.SkipSamples(skippedSamples)
 	}
 
-	packets := s.packetizer.Packetize(sample.Data, samplesPerPacket)
+	packets := s.packetizeLocked(sample.Data, samplesPerPacket, partial)
 
 	s.lastTS = sample.Timestamp
 	s.lastRTPTimestamp = currentRTPTimestamp
+	tracer := s.tracer
 	s.lock.Unlock()
+	tracer.Observe(PipelineStagePacketizer, time.Since(packetizeStart))
+
+	return s.writePackets(packets, opts)
+}
+
+// packetizeLocked packetizes data that lasts samples. For parts of a frame, the timestamp advances by the samples
+// of the first part once the last part is packetized.
+func (s *LocalTrack) packetizeLocked(data []byte, samples uint32, partial bool) []*rtp.Packet {
+	if s.inFrame {
+		samples = s.frameSamples
+	} else if partial {
+		s.frameSamples = samples
+	}
+	s.inFrame = partial
+	if partial {
+		samples = 0
+	}
+
+	packets := s.packetizer.Packetize(data, samples)
+	if len(packets) > 0 {
+		s.nextPacketTimestamp = packets[0].Timestamp + samples
+		s.hasNextPacketTimestamp = true
+		if partial {
+			packets[len(packets)-1].Marker = false
+		}
+	}
+	return packets
+}
 
+func (s *LocalTrack) writePackets(packets []*rtp.Packet, opts *SampleWriteOptions) error {
 	if s.disconnected.Load() {
 		return nil
 	}
@@ -528,6 +630,17 @@ func (s *LocalTrack) setMuted(muted bool) {
 	s.muted.Store(muted)
 }
 
+// IsPaused returns true when the server paused the track (or simulcast layer) as nobody is receiving it,
+// or when it is paused to protect audio under congestion, see WithAudioPriority.
+// Samples from a SampleProvider are dropped while paused, tracks written to directly should stop writing.
+func (s *LocalTrack) IsPaused() bool {
+	return s.paused.Load() || s.congestionPaused.Load()
+}
+
+func (s *LocalTrack) setPaused(paused bool) bool {
+	return s.paused.Swap(paused) != paused
+}
+
 func (s *LocalTrack) setDisconnected(disconnected bool) {
 	s.disconnected.Store(disconnected)
 }
@@ -604,8 +717,13 @@ func (s *LocalTrack) writeWorker(provider SampleProvider, onComplete func()) {
 	ticker := time.NewTicker(time.Hour)
 	defer ticker.Stop()
 
+	s.lock.RLock()
+	tracer := s.tracer
+	s.lock.RUnlock()
+
 	for {
 		// Be mindful that NextSample is not thread-safe
+		providerStart := time.Now()
 		sample, err := provider.NextSample(ctx)
 		if err == io.EOF {
 			return
@@ -614,8 +732,9 @@ func (s *LocalTrack) writeWorker(provider SampleProvider, onComplete func()) {
 			s.log.Errorw("could not get sample from provider", err)
 			return
 		}
+		tracer.Observe(PipelineStageProvider, time.Since(providerStart))
 
-		if !s.muted.Load() {
+		if !s.muted.Load() && !s.IsPaused() {
 			var opts *SampleWriteOptions
 			if isAudioProvider {
 				level := audioProvider.CurrentAudioLevel()
@@ -0,0 +1,84 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalTrackPartialFrame(t *testing.T) {
+	codec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000}
+	track, err := NewLocalTrack(codec)
+	require.NoError(t, err)
+
+	// simulate binding
+	payloader, err := payloaderForCodec(codec)
+	require.NoError(t, err)
+	track.lock.Lock()
+	track.payloader = payloader
+	track.sequencer = rtp.NewRandomSequencer()
+	track.clockRate = float64(codec.ClockRate)
+	track.resetPacketizerLocked()
+	track.lock.Unlock()
+
+	slice := func(size int) []byte {
+		// non-IDR slice NAL unit
+		return append([]byte{0, 0, 0, 1, 0x41}, make([]byte, size)...)
+	}
+	packetize := func(data []byte, samples uint32, partial bool) []*rtp.Packet {
+		track.lock.Lock()
+		defer track.lock.Unlock()
+		return track.packetizeLocked(data, samples, partial)
+	}
+	markers := func(packets []*rtp.Packet) []bool {
+		var m []bool
+		for _, p := range packets {
+			m = append(m, p.Marker)
+		}
+		return m
+	}
+
+	first := packetize(slice(100), 3000, true)
+	require.Len(t, first, 1)
+	require.False(t, first[0].Marker)
+	ts := first[0].Timestamp
+
+	// following parts are timed with the first part
+	second := packetize(slice(2000), 0, true)
+	require.Equal(t, []bool{false, false}, markers(second))
+	last := packetize(slice(100), 0, false)
+	require.Equal(t, []bool{true}, markers(last))
+	for _, p := range append(second, last...) {
+		require.Equal(t, ts, p.Timestamp)
+	}
+	require.False(t, track.inFrame)
+
+	next := packetize(slice(100), 3000, false)
+	require.Equal(t, []bool{true}, markers(next))
+	require.Equal(t, ts+3000, next[0].Timestamp)
+
+	// a recreated packetizer continues within the frame
+	packetize(slice(100), 3000, true)
+	track.lock.Lock()
+	track.resetPacketizerLocked()
+	track.lock.Unlock()
+	last = packetize(slice(100), 0, false)
+	require.Equal(t, ts+6000, last[0].Timestamp)
+	require.Equal(t, ts+9000, packetize(slice(100), 3000, false)[0].Timestamp)
+}
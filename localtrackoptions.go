@@ -1,6 +1,10 @@
 package lksdk
 
-import "github.com/pion/webrtc/v4"
+import (
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
 
 type TrackLocalWithCodec interface {
 	webrtc.TrackLocal
@@ -11,6 +15,16 @@ type LocalTrackPublishOptions struct {
 	backupCodecTrack TrackLocalWithCodec
 	// backup codec tracks for simulcast track
 	backupCodecTracks []*LocalTrack
+	publishTimeout    time.Duration
+	codecFallbacks    []TrackLocalWithCodec
+	maxPayloadSize    int
+}
+
+func (o *LocalTrackPublishOptions) getPublishTimeout() time.Duration {
+	if o.publishTimeout > 0 {
+		return o.publishTimeout
+	}
+	return trackPublishTimeout
 }
 
 type LocalTrackPublishOption func(*LocalTrackPublishOptions)
@@ -26,3 +40,30 @@ func WithBackupCodecForSimulcastTrack(backupCodecTracks []*LocalTrack) LocalTrac
 		opts.backupCodecTracks = backupCodecTracks
 	}
 }
+
+// WithPublishTimeout sets how long to wait for the server to acknowledge the publication.
+// On timeout, the transceiver is removed and ErrTrackPublishTimeout is returned, the track can be published again.
+func WithPublishTimeout(timeout time.Duration) LocalTrackPublishOption {
+	return func(opts *LocalTrackPublishOptions) {
+		opts.publishTimeout = timeout
+	}
+}
+
+// WithCodecFallback publishes the next fallback track when the codec of the published track is not
+// negotiated, e.g. H.264 is not enabled on the server. Fallback tracks carry the same media in other codecs
+// and are tried in order. The track passed to PublishTrack must be a TrackLocalWithCodec.
+// Tracks that were not published are not closed, see LocalTrackPublication.PublishedCodec for the codec used.
+func WithCodecFallback(fallbacks ...TrackLocalWithCodec) LocalTrackPublishOption {
+	return func(opts *LocalTrackPublishOptions) {
+		opts.codecFallbacks = fallbacks
+	}
+}
+
+// WithMaxPayloadSize sets the max size of RTP packets created by the packetizer of the published LocalTrack
+// (and its simulcast layers), defaults to 1200 bytes. Use a lower value on networks where the default is fragmented.
+// The size cannot be raised above the default; tracks that are packetized by the application are not affected.
+func WithMaxPayloadSize(size int) LocalTrackPublishOption {
+	return func(opts *LocalTrackPublishOptions) {
+		opts.maxPayloadSize = size
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	protoLogger "github.com/livekit/protocol/logger"
+)
+
+// LogCategory groups log lines of the SDK for sampling and redaction, see NewFilteredLogger
+type LogCategory string
+
+const (
+	// ICE candidates, connection states and restarts
+	LogCategoryICE LogCategory = "ice"
+	// offers, answers and other signal messages
+	LogCategorySignal LogCategory = "signal"
+	// data packets, streams and RPC
+	LogCategoryData LogCategory = "data"
+	// tracks, RTP and codecs
+	LogCategoryMedia LogCategory = "media"
+	// everything else, and values added with WithValues
+	LogCategoryGeneral LogCategory = "general"
+)
+
+// categories are matched in order, keywords are prefixes of the lower cased words of a message
+var logCategoryKeywords = []struct {
+	category LogCategory
+	keywords []string
+}{
+	{LogCategoryICE, []string{"ice", "candidate", "turn"}},
+	{LogCategorySignal, []string{"signal", "offer", "answer", "negotiat", "sdp", "join", "leave"}},
+	{LogCategoryData, []string{"data", "stream", "rpc", "packet"}},
+	{LogCategoryMedia, []string{"track", "rtp", "rtcp", "codec", "pli", "simulcast"}},
+}
+
+func logCategoryOf(msg string) LogCategory {
+	words := strings.FieldsFunc(strings.ToLower(msg), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, c := range logCategoryKeywords {
+		for _, keyword := range c.keywords {
+			for _, word := range words {
+				if strings.HasPrefix(word, keyword) {
+					return c.category
+				}
+			}
+		}
+	}
+	return LogCategoryGeneral
+}
+
+// LogFilterConfig configures NewFilteredLogger
+type LogFilterConfig struct {
+	// SampleRates logs 1 in N debug and info lines per message of a category, warnings and errors are always logged
+	SampleRates map[LogCategory]int
+	// Redact returns the value to log for a field of a line in category, see RedactTokensAndIdentities
+	Redact func(category LogCategory, key string, value any) any
+}
+
+const redactedValue = "[redacted]"
+
+// redacted by RedactTokensAndIdentities
+var sensitiveLogKeys = map[string]bool{
+	"token":                 true,
+	"refreshtoken":          true,
+	"accesstoken":           true,
+	"identity":              true,
+	"participant":           true,
+	"participantidentity":   true,
+	"calleridentity":        true,
+	"destinationidentity":   true,
+	"destinationidentities": true,
+}
+
+// RedactTokensAndIdentities replaces tokens and participant identities with a placeholder,
+// including JWTs logged under other keys.
+func RedactTokensAndIdentities(_ LogCategory, key string, value any) any {
+	if sensitiveLogKeys[strings.ToLower(key)] {
+		return redactedValue
+	}
+	if s, ok := value.(string); ok && looksLikeJWT(s) {
+		return redactedValue
+	}
+	return value
+}
+
+func looksLikeJWT(s string) bool {
+	return strings.HasPrefix(s, "eyJ") && strings.Count(s, ".") == 2
+}
+
+// logSampler counts lines per message, shared by loggers derived from a filtered logger
+type logSampler struct {
+	lock   sync.Mutex
+	counts map[string]int
+}
+
+func (s *logSampler) sample(msg string, rate int) bool {
+	if rate <= 1 {
+		return true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := s.counts[msg]
+	s.counts[msg] = n + 1
+	return n%rate == 0
+}
+
+type filteredLogger struct {
+	logger  protoLogger.Logger
+	config  LogFilterConfig
+	sampler *logSampler
+}
+
+// NewFilteredLogger wraps l to sample verbose categories and redact sensitive fields, so that debug logging
+// can be enabled in production, e.g.
+//
+//	lksdk.SetLogger(lksdk.NewFilteredLogger(l, lksdk.LogFilterConfig{
+//		SampleRates: map[lksdk.LogCategory]int{lksdk.LogCategoryICE: 10},
+//		Redact:      lksdk.RedactTokensAndIdentities,
+//	}))
+func NewFilteredLogger(l protoLogger.Logger, config LogFilterConfig) protoLogger.Logger {
+	return &filteredLogger{
+		// skip the frame of the filter
+		logger:  l.WithCallDepth(1),
+		config:  config,
+		sampler: &logSampler{counts: make(map[string]int)},
+	}
+}
+
+func (l *filteredLogger) derive(logger protoLogger.Logger) *filteredLogger {
+	return &filteredLogger{logger: logger, config: l.config, sampler: l.sampler}
+}
+
+func (l *filteredLogger) redact(category LogCategory, keysAndValues []any) []any {
+	if l.config.Redact == nil || len(keysAndValues) == 0 {
+		return keysAndValues
+	}
+	redacted := make([]any, len(keysAndValues))
+	copy(redacted, keysAndValues)
+	for i := 0; i+1 < len(redacted); i += 2 {
+		if key, ok := redacted[i].(string); ok {
+			redacted[i+1] = l.config.Redact(category, key, redacted[i+1])
+		}
+	}
+	return redacted
+}
+
+func (l *filteredLogger) sampled(msg string) (LogCategory, bool) {
+	category := logCategoryOf(msg)
+	return category, l.sampler.sample(msg, l.config.SampleRates[category])
+}
+
+func (l *filteredLogger) Debugw(msg string, keysAndValues ...any) {
+	if category, ok := l.sampled(msg); ok {
+		l.logger.Debugw(msg, l.redact(category, keysAndValues)...)
+	}
+}
+
+func (l *filteredLogger) Infow(msg string, keysAndValues ...any) {
+	if category, ok := l.sampled(msg); ok {
+		l.logger.Infow(msg, l.redact(category, keysAndValues)...)
+	}
+}
+
+func (l *filteredLogger) Warnw(msg string, err error, keysAndValues ...any) {
+	l.logger.Warnw(msg, err, l.redact(logCategoryOf(msg), keysAndValues)...)
+}
+
+func (l *filteredLogger) Errorw(msg string, err error, keysAndValues ...any) {
+	l.logger.Errorw(msg, err, l.redact(logCategoryOf(msg), keysAndValues)...)
+}
+
+func (l *filteredLogger) WithValues(keysAndValues ...any) protoLogger.Logger {
+	return l.derive(l.logger.WithValues(l.redact(LogCategoryGeneral, keysAndValues)...))
+}
+
+func (l *filteredLogger) WithUnlikelyValues(keysAndValues ...any) protoLogger.UnlikelyLogger {
+	return protoLogger.NewUnlikelyLogger(l, l.redact(LogCategoryGeneral, keysAndValues)...)
+}
+
+func (l *filteredLogger) WithName(name string) protoLogger.Logger {
+	return l.derive(l.logger.WithName(name))
+}
+
+func (l *filteredLogger) WithComponent(component string) protoLogger.Logger {
+	return l.derive(l.logger.WithComponent(component))
+}
+
+func (l *filteredLogger) WithCallDepth(depth int) protoLogger.Logger {
+	return l.derive(l.logger.WithCallDepth(depth))
+}
+
+func (l *filteredLogger) WithItemSampler() protoLogger.Logger {
+	return l.derive(l.logger.WithItemSampler())
+}
+
+func (l *filteredLogger) WithoutSampler() protoLogger.Logger {
+	return l.derive(l.logger.WithoutSampler())
+}
+
+func (l *filteredLogger) WithDeferredValues() (protoLogger.Logger, protoLogger.DeferredFieldResolver) {
+	logger, resolver := l.logger.WithDeferredValues()
+	return l.derive(logger), &redactingResolver{DeferredFieldResolver: resolver, logger: l}
+}
+
+// redactingResolver redacts deferred values when they are resolved
+type redactingResolver struct {
+	protoLogger.DeferredFieldResolver
+	logger *filteredLogger
+}
+
+func (r *redactingResolver) Resolve(args ...any) {
+	r.DeferredFieldResolver.Resolve(r.logger.redact(LogCategoryGeneral, args)...)
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	protoLogger "github.com/livekit/protocol/logger"
+)
+
+type logLine struct {
+	msg           string
+	keysAndValues []any
+}
+
+type recordingLogger struct {
+	protoLogger.Logger
+	values []any
+	lines  *[]logLine
+}
+
+func (l *recordingLogger) record(msg string, keysAndValues []any) {
+	*l.lines = append(*l.lines, logLine{msg, append(append([]any{}, l.values...), keysAndValues...)})
+}
+
+func (l *recordingLogger) Debugw(msg string, keysAndValues ...any) { l.record(msg, keysAndValues) }
+func (l *recordingLogger) Infow(msg string, keysAndValues ...any)  { l.record(msg, keysAndValues) }
+func (l *recordingLogger) Warnw(msg string, _ error, keysAndValues ...any) {
+	l.record(msg, keysAndValues)
+}
+func (l *recordingLogger) WithCallDepth(int) protoLogger.Logger { return l }
+func (l *recordingLogger) WithValues(keysAndValues ...any) protoLogger.Logger {
+	return &recordingLogger{Logger: l.Logger, values: append(append([]any{}, l.values...), keysAndValues...), lines: l.lines}
+}
+
+func TestLogCategoryOf(t *testing.T) {
+	require.Equal(t, LogCategoryICE, logCategoryOf("remote ICE candidate"))
+	require.Equal(t, LogCategorySignal, logCategoryOf("could not send offer for publisher pc"))
+	require.Equal(t, LogCategoryData, logCategoryOf("could not publish stream trailer"))
+	require.Equal(t, LogCategoryMedia, logCategoryOf("could not send track settings"))
+	require.Equal(t, LogCategoryGeneral, logCategoryOf("subscription budget changed"))
+	// keywords match the start of words
+	require.Equal(t, LogCategoryGeneral, logCategoryOf("could not reach service"))
+}
+
+func TestFilteredLoggerSampling(t *testing.T) {
+	var lines []logLine
+	l := NewFilteredLogger(&recordingLogger{Logger: logger, lines: &lines}, LogFilterConfig{
+		SampleRates: map[LogCategory]int{LogCategoryICE: 3},
+	})
+
+	for i := 0; i < 7; i++ {
+		l.Debugw("remote ICE candidate", "i", i)
+	}
+	l.Debugw("ICE connected")
+	l.Warnw("could not add ICE candidate", nil)
+	l.Infow("connection state changed")
+
+	require.Equal(t, []logLine{
+		{"remote ICE candidate", []any{"i", 0}},
+		{"remote ICE candidate", []any{"i", 3}},
+		{"remote ICE candidate", []any{"i", 6}},
+		// sampled per message
+		{"ICE connected", []any{}},
+		{"could not add ICE candidate", []any{}},
+		{"connection state changed", []any{}},
+	}, lines)
+}
+
+func TestFilteredLoggerRedaction(t *testing.T) {
+	var lines []logLine
+	l := NewFilteredLogger(&recordingLogger{Logger: logger, lines: &lines}, LogFilterConfig{
+		Redact: RedactTokensAndIdentities,
+	})
+
+	l.WithValues("participant", "alice").Infow("joined",
+		"room", "support",
+		"url", "wss://example.livekit.cloud?access_token=x",
+		"refresh", "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJhbGljZSJ9.sig",
+		"Token", "secret",
+	)
+	require.Equal(t, []logLine{{"joined", []any{
+		"participant", redactedValue,
+		"room", "support",
+		"url", "wss://example.livekit.cloud?access_token=x",
+		"refresh", redactedValue,
+		"Token", redactedValue,
+	}}}, lines)
+
+	// the hook gets the category to only redact some lines
+	lines = nil
+	l = NewFilteredLogger(&recordingLogger{Logger: logger, lines: &lines}, LogFilterConfig{
+		Redact: func(category LogCategory, key string, value any) any {
+			if category == LogCategoryData && key == "payload" {
+				return len(value.(string))
+			}
+			return value
+		},
+	})
+	l.Debugw("received data packet", "payload", "hello")
+	l.Debugw("received track", "payload", "hello")
+	require.Equal(t, []any{"payload", 5}, lines[0].keysAndValues)
+	require.Equal(t, []any{"payload", "hello"}, lines[1].keysAndValues)
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MailboxTopic carries mailbox requests and responses, packets on it are not passed to data callbacks.
+// Messages are JSON objects with "id", "method" and "payload", responses echo the "id" and set "response"
+// and "error" if the request failed, so that clients without RPC support can implement them with user packets.
+const MailboxTopic = "lk.mailbox"
+
+// requests fail with ErrMailboxTimeout after this when ctx has no deadline
+const defaultMailboxTimeout = 10 * time.Second
+
+type mailboxMessage struct {
+	ID       string `json:"id"`
+	Method   string `json:"method,omitempty"`
+	Payload  string `json:"payload"`
+	Response bool   `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// MailboxRequest is a request received on MailboxTopic
+type MailboxRequest struct {
+	ID             string
+	SenderIdentity string
+	Method         string
+	Payload        string
+}
+
+// MailboxHandler handles requests for a method, the error message is returned to the sender as MailboxError
+type MailboxHandler func(req MailboxRequest) (string, error)
+
+// MailboxError is returned by SendMailboxRequest when the receiver could not handle the request
+type MailboxError struct {
+	Method  string
+	Message string
+}
+
+func (e *MailboxError) Error() string {
+	return fmt.Sprintf("mailbox request %s failed: %s", e.Method, e.Message)
+}
+
+// mailbox matches responses to outstanding requests and holds the handlers
+type mailbox struct {
+	lock     sync.Mutex
+	pending  map[string]chan mailboxMessage
+	handlers map[string]MailboxHandler
+}
+
+func newMailbox() *mailbox {
+	return &mailbox{
+		pending:  make(map[string]chan mailboxMessage),
+		handlers: make(map[string]MailboxHandler),
+	}
+}
+
+func (m *mailbox) add(id string) chan mailboxMessage {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ch := make(chan mailboxMessage, 1)
+	m.pending[id] = ch
+	return ch
+}
+
+func (m *mailbox) remove(id string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.pending, id)
+}
+
+// resolve passes a response to its request, returns false if it is not outstanding
+func (m *mailbox) resolve(msg mailboxMessage) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ch, ok := m.pending[msg.ID]
+	if !ok {
+		return false
+	}
+	delete(m.pending, msg.ID)
+	ch <- msg
+	return true
+}
+
+func (m *mailbox) handler(method string) MailboxHandler {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.handlers[method]
+}
+
+// RegisterMailboxHandler registers a handler for mailbox requests of method.
+// It returns an error if a handler is already registered for this method.
+func (r *Room) RegisterMailboxHandler(method string, handler MailboxHandler) error {
+	r.mailbox.lock.Lock()
+	defer r.mailbox.lock.Unlock()
+
+	if _, ok := r.mailbox.handlers[method]; ok {
+		return fmt.Errorf("mailbox handler already registered for method: %s", method)
+	}
+	r.mailbox.handlers[method] = handler
+	return nil
+}
+
+// UnregisterMailboxHandler removes a previously registered mailbox handler.
+func (r *Room) UnregisterMailboxHandler(method string) {
+	r.mailbox.lock.Lock()
+	defer r.mailbox.lock.Unlock()
+
+	delete(r.mailbox.handlers, method)
+}
+
+// SendMailboxRequest sends a request to a participant over reliable user packets and waits for the response.
+// Unlike PerformRpc it works with participants that handle MailboxTopic in application code, e.g. older clients
+// without RPC support. It returns ErrMailboxTimeout when no response arrives within 10s, unless ctx has a deadline.
+func (r *Room) SendMailboxRequest(ctx context.Context, identity string, method string, payload string) (string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, defaultMailboxTimeout, ErrMailboxTimeout)
+		defer cancel()
+	}
+
+	msg := mailboxMessage{ID: uuid.New().String(), Method: method, Payload: payload}
+	response := r.mailbox.add(msg.ID)
+	if err := r.sendMailboxMessage(identity, msg); err != nil {
+		r.mailbox.remove(msg.ID)
+		return "", err
+	}
+
+	select {
+	case res := <-response:
+		if res.Error != "" {
+			return "", &MailboxError{Method: method, Message: res.Error}
+		}
+		return res.Payload, nil
+	case <-ctx.Done():
+		r.mailbox.remove(msg.ID)
+		return "", context.Cause(ctx)
+	}
+}
+
+func (r *Room) sendMailboxMessage(identity string, msg mailboxMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return r.LocalParticipant.PublishDataPacket(
+		UserData(payload),
+		WithDataPublishTopic(MailboxTopic),
+		WithDataPublishReliable(true),
+		WithDataPublishDestination([]string{identity}),
+	)
+}
+
+// handleMailbox answers requests and resolves responses, returns true if the packet was on MailboxTopic
+func (r *Room) handleMailbox(identity string, dataPacket DataPacket) bool {
+	user, ok := dataPacket.(*UserDataPacket)
+	if !ok || user.Topic != MailboxTopic {
+		return false
+	}
+	var msg mailboxMessage
+	if err := json.Unmarshal(user.Payload, &msg); err != nil || msg.ID == "" {
+		r.log.Debugw("could not parse mailbox message", "participant", identity, "error", err)
+		return true
+	}
+	if msg.Response {
+		r.mailbox.resolve(msg)
+		return true
+	}
+	if identity == "" {
+		return true
+	}
+
+	go func() {
+		res := mailboxMessage{ID: msg.ID, Response: true}
+		if handler := r.mailbox.handler(msg.Method); handler == nil {
+			res.Error = "unsupported method"
+		} else if payload, err := handler(MailboxRequest{
+			ID:             msg.ID,
+			SenderIdentity: identity,
+			Method:         msg.Method,
+			Payload:        msg.Payload,
+		}); err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Payload = payload
+		}
+		if err := r.sendMailboxMessage(identity, res); err != nil {
+			r.log.Debugw("could not send mailbox response", "participant", identity, "method", msg.Method, "error", err)
+		}
+	}()
+	return true
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailboxResponse(t *testing.T) {
+	room := NewRoom(nil)
+
+	var delivered int
+	room.callback.OnDataPacket = func(data DataPacket, params DataReceiveParams) {
+		delivered++
+	}
+
+	response := room.mailbox.add("req-1")
+	payload, err := json.Marshal(mailboxMessage{ID: "req-1", Payload: "pong", Response: true})
+	require.NoError(t, err)
+	room.OnDataPacket("remote", &UserDataPacket{Topic: MailboxTopic, Payload: payload})
+
+	select {
+	case res := <-response:
+		require.Equal(t, "pong", res.Payload)
+	default:
+		t.Fatal("response was not resolved")
+	}
+	// an unknown or repeated response is ignored
+	require.False(t, room.mailbox.resolve(mailboxMessage{ID: "req-1", Response: true}))
+
+	// malformed messages are swallowed as well
+	room.OnDataPacket("remote", &UserDataPacket{Topic: MailboxTopic, Payload: []byte("{")})
+	require.Zero(t, delivered)
+}
+
+func TestRegisterMailboxHandler(t *testing.T) {
+	room := NewRoom(nil)
+	handler := func(req MailboxRequest) (string, error) { return req.Payload, nil }
+
+	require.NoError(t, room.RegisterMailboxHandler("echo", handler))
+	require.Error(t, room.RegisterMailboxHandler("echo", handler))
+	require.NotNil(t, room.mailbox.handler("echo"))
+
+	room.UnregisterMailboxHandler("echo")
+	require.Nil(t, room.mailbox.handler("echo"))
+}
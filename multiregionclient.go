@@ -0,0 +1,331 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/twitchtv/twirp"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils/xtwirp"
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+const (
+	regionSettingsRefreshInterval = 5 * time.Minute
+)
+
+// NewMultiRegionRoomServiceClient creates a RoomServiceClient for a LiveKit Cloud project that sends API calls
+// to the nearest region and fails over to the next region when one is unavailable.
+// Calls for a room stick to the region that served it last. Regions are discovered from the project URL
+// and refreshed periodically, the project URL itself is used as the last resort.
+// Calls that may have reached a region are only sent to the next one when they are safe to repeat, e.g. listing
+// or updating, others like CreateRoom or SendData fail over only when the region could not be connected to.
+// For URLs that are not LiveKit Cloud it behaves like NewRoomServiceClient.
+func NewMultiRegionRoomServiceClient(url string, apiKey string, secretKey string, opts ...twirp.ClientOption) *RoomServiceClient {
+	hostname, err := parseCloudURL(url)
+	if err != nil {
+		return NewRoomServiceClient(url, apiKey, secretKey, opts...)
+	}
+
+	opts = append(opts, xtwirp.DefaultClientOptions()...)
+	base := authBase{
+		apiKey:    apiKey,
+		apiSecret: secretKey,
+	}
+	return &RoomServiceClient{
+		roomService: newMultiRegionRoomService(hostname, signalling.ToHttpURL(url), base, opts),
+		authBase:    base,
+	}
+}
+
+type regionEndpoint struct {
+	url    string
+	client livekit.RoomService
+}
+
+// multiRegionRoomService routes RoomService calls to regional endpoints
+type multiRegionRoomService struct {
+	authBase
+	hostname   string
+	projectURL string
+	opts       []twirp.ClientOption
+	httpClient *http.Client
+	refresh    singleflight.Group
+
+	lock        sync.Mutex
+	regions     []string // nearest first
+	refreshedAt time.Time
+	clients     map[string]livekit.RoomService // url -> client
+	sticky      map[string]string              // room name -> url
+}
+
+func newMultiRegionRoomService(hostname, projectURL string, base authBase, opts []twirp.ClientOption) *multiRegionRoomService {
+	return &multiRegionRoomService{
+		authBase:   base,
+		hostname:   hostname,
+		projectURL: projectURL,
+		opts:       opts,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		clients: make(map[string]livekit.RoomService),
+		sticky:  make(map[string]string),
+	}
+}
+
+// refreshRegions discovers the regions when they are stale, concurrent calls share one discovery.
+// It returns when ctx is done even if discovery still runs, the call then uses the regions known so far.
+func (s *multiRegionRoomService) refreshRegions(ctx context.Context) {
+	s.lock.Lock()
+	stale := time.Since(s.refreshedAt) > regionSettingsRefreshInterval
+	s.lock.Unlock()
+	if !stale {
+		return
+	}
+
+	done := s.refresh.DoChan("regions", func() (any, error) {
+		s.discoverRegions(context.WithoutCancel(ctx))
+		return nil, nil
+	})
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+func (s *multiRegionRoomService) discoverRegions(ctx context.Context) {
+	at := auth.NewAccessToken(s.apiKey, s.apiSecret)
+	withVideoGrant{RoomList: true}.Apply(at)
+	token, err := at.ToJWT()
+	if err != nil {
+		logger.Warnw("could not create token for region discovery", err)
+		return
+	}
+
+	settings, err := fetchRegionSettings(ctx, s.httpClient, s.hostname, token)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// retry failed discovery with the next call after the refresh interval as well, the project URL still works
+	s.refreshedAt = time.Now()
+	if err != nil {
+		logger.Warnw("could not discover regions", err, "hostname", s.hostname)
+		return
+	}
+
+	regions := make([]string, 0, len(settings.Regions))
+	for _, region := range settings.Regions {
+		if region.Url != "" {
+			regions = append(regions, signalling.ToHttpURL(region.Url))
+		}
+	}
+	s.regions = regions
+}
+
+func (s *multiRegionRoomService) clientLocked(url string) livekit.RoomService {
+	client, ok := s.clients[url]
+	if !ok {
+		client = livekit.NewRoomServiceProtobufClient(url, &http.Client{}, s.opts...)
+		s.clients[url] = client
+	}
+	return client
+}
+
+// endpoints returns the endpoints to try in order, the sticky region of the room first
+func (s *multiRegionRoomService) endpoints(ctx context.Context, room string) []regionEndpoint {
+	s.refreshRegions(ctx)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	urls := make([]string, 0, len(s.regions)+2)
+	if sticky, ok := s.sticky[room]; ok && room != "" {
+		urls = append(urls, sticky)
+	}
+	urls = append(urls, s.regions...)
+	urls = append(urls, s.projectURL)
+
+	endpoints := make([]regionEndpoint, 0, len(urls))
+	seen := make(map[string]struct{}, len(urls))
+	for _, u := range urls {
+		if _, ok := seen[u]; ok {
+			continue
+		}
+		seen[u] = struct{}{}
+		endpoints = append(endpoints, regionEndpoint{url: u, client: s.clientLocked(u)})
+	}
+	return endpoints
+}
+
+func (s *multiRegionRoomService) stick(room, url string) {
+	if room == "" {
+		return
+	}
+	s.lock.Lock()
+	s.sticky[room] = url
+	s.lock.Unlock()
+}
+
+func (s *multiRegionRoomService) unstick(room string) {
+	s.lock.Lock()
+	delete(s.sticky, room)
+	s.lock.Unlock()
+}
+
+// isRegionUnreachable returns true for errors of an endpoint that could not be connected to,
+// the request was not sent
+func isRegionUnreachable(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isRegionUnavailable returns true for errors of an endpoint that was not reached or is overloaded,
+// the request may have been received
+func isRegionUnavailable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var twErr twirp.Error
+	if errors.As(err, &twErr) {
+		return twErr.Code() == twirp.Unavailable
+	}
+	return false
+}
+
+// multiRegionCall tries the endpoints of room in order until one is available, calls that are not idempotent
+// are only tried in the next region when the request could not be sent
+func multiRegionCall[T any](ctx context.Context, s *multiRegionRoomService, room string, idempotent bool, call func(client livekit.RoomService) (T, error)) (T, error) {
+	var (
+		res T
+		err error
+	)
+	for _, endpoint := range s.endpoints(ctx, room) {
+		res, err = call(endpoint.client)
+		if err == nil {
+			s.stick(room, endpoint.url)
+			return res, nil
+		}
+		failover := isRegionUnreachable(err) || (idempotent && isRegionUnavailable(err))
+		if !failover || ctx.Err() != nil {
+			return res, err
+		}
+		logger.Infow("region unavailable, trying next", "url", endpoint.url, "room", room, "error", err)
+	}
+	return res, err
+}
+
+func (s *multiRegionRoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomRequest) (*livekit.Room, error) {
+	return multiRegionCall(ctx, s, req.Name, false, func(c livekit.RoomService) (*livekit.Room, error) {
+		return c.CreateRoom(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) ListRooms(ctx context.Context, req *livekit.ListRoomsRequest) (*livekit.ListRoomsResponse, error) {
+	return multiRegionCall(ctx, s, "", true, func(c livekit.RoomService) (*livekit.ListRoomsResponse, error) {
+		return c.ListRooms(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) DeleteRoom(ctx context.Context, req *livekit.DeleteRoomRequest) (*livekit.DeleteRoomResponse, error) {
+	res, err := multiRegionCall(ctx, s, req.Room, false, func(c livekit.RoomService) (*livekit.DeleteRoomResponse, error) {
+		return c.DeleteRoom(ctx, req)
+	})
+	if err == nil {
+		s.unstick(req.Room)
+	}
+	return res, err
+}
+
+func (s *multiRegionRoomService) ListParticipants(ctx context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
+	return multiRegionCall(ctx, s, req.Room, true, func(c livekit.RoomService) (*livekit.ListParticipantsResponse, error) {
+		return c.ListParticipants(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) GetParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	return multiRegionCall(ctx, s, req.Room, true, func(c livekit.RoomService) (*livekit.ParticipantInfo, error) {
+		return c.GetParticipant(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) RemoveParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.RemoveParticipantResponse, error) {
+	return multiRegionCall(ctx, s, req.Room, false, func(c livekit.RoomService) (*livekit.RemoveParticipantResponse, error) {
+		return c.RemoveParticipant(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) MutePublishedTrack(ctx context.Context, req *livekit.MuteRoomTrackRequest) (*livekit.MuteRoomTrackResponse, error) {
+	return multiRegionCall(ctx, s, req.Room, true, func(c livekit.RoomService) (*livekit.MuteRoomTrackResponse, error) {
+		return c.MutePublishedTrack(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) UpdateParticipant(ctx context.Context, req *livekit.UpdateParticipantRequest) (*livekit.ParticipantInfo, error) {
+	return multiRegionCall(ctx, s, req.Room, true, func(c livekit.RoomService) (*livekit.ParticipantInfo, error) {
+		return c.UpdateParticipant(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) UpdateSubscriptions(ctx context.Context, req *livekit.UpdateSubscriptionsRequest) (*livekit.UpdateSubscriptionsResponse, error) {
+	return multiRegionCall(ctx, s, req.Room, true, func(c livekit.RoomService) (*livekit.UpdateSubscriptionsResponse, error) {
+		return c.UpdateSubscriptions(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
+	return multiRegionCall(ctx, s, req.Room, false, func(c livekit.RoomService) (*livekit.SendDataResponse, error) {
+		return c.SendData(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.UpdateRoomMetadataRequest) (*livekit.Room, error) {
+	return multiRegionCall(ctx, s, req.Room, true, func(c livekit.RoomService) (*livekit.Room, error) {
+		return c.UpdateRoomMetadata(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) ForwardParticipant(ctx context.Context, req *livekit.ForwardParticipantRequest) (*livekit.ForwardParticipantResponse, error) {
+	return multiRegionCall(ctx, s, req.Room, false, func(c livekit.RoomService) (*livekit.ForwardParticipantResponse, error) {
+		return c.ForwardParticipant(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) MoveParticipant(ctx context.Context, req *livekit.MoveParticipantRequest) (*livekit.MoveParticipantResponse, error) {
+	return multiRegionCall(ctx, s, req.Room, false, func(c livekit.RoomService) (*livekit.MoveParticipantResponse, error) {
+		return c.MoveParticipant(ctx, req)
+	})
+}
+
+func (s *multiRegionRoomService) PerformRpc(ctx context.Context, req *livekit.PerformRpcRequest) (*livekit.PerformRpcResponse, error) {
+	return multiRegionCall(ctx, s, req.Room, false, func(c livekit.RoomService) (*livekit.PerformRpcResponse, error) {
+		return c.PerformRpc(ctx, req)
+	})
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+	"go.uber.org/atomic"
+)
+
+type fakeRegionService struct {
+	livekit.RoomService
+	err   error
+	calls int
+}
+
+func (f *fakeRegionService) ListParticipants(ctx context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &livekit.ListParticipantsResponse{}, nil
+}
+
+func (f *fakeRegionService) SendData(ctx context.Context, req *livekit.SendDataRequest) (*livekit.SendDataResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &livekit.SendDataResponse{}, nil
+}
+
+func TestMultiRegionRoomService(t *testing.T) {
+	near := &fakeRegionService{err: twirp.NewError(twirp.Unavailable, "down")}
+	far := &fakeRegionService{}
+	project := &fakeRegionService{}
+
+	s := newMultiRegionRoomService("project.livekit.cloud", "https://project.livekit.cloud", authBase{}, nil)
+	s.regions = []string{"https://near", "https://far"}
+	s.refreshedAt = time.Now()
+	s.clients["https://near"] = near
+	s.clients["https://far"] = far
+	s.clients["https://project.livekit.cloud"] = project
+
+	ctx := context.Background()
+	_, err := s.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: "room"})
+	require.NoError(t, err)
+	require.Equal(t, 1, near.calls)
+	require.Equal(t, 1, far.calls)
+	require.Equal(t, "https://far", s.sticky["room"])
+
+	// the room sticks to the region that served it
+	near.err = nil
+	_, err = s.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: "room"})
+	require.NoError(t, err)
+	require.Equal(t, 1, near.calls)
+	require.Equal(t, 2, far.calls)
+
+	// other errors are returned without failover
+	far.err = twirp.NotFoundError("room")
+	_, err = s.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: "room"})
+	require.Error(t, err)
+	require.Equal(t, 1, near.calls)
+	require.Zero(t, project.calls)
+}
+
+func TestMultiRegionFailoverOfNonIdempotentCalls(t *testing.T) {
+	near := &fakeRegionService{err: twirp.NewError(twirp.Unavailable, "down")}
+	far := &fakeRegionService{}
+
+	s := newMultiRegionRoomService("project.livekit.cloud", "https://project.livekit.cloud", authBase{}, nil)
+	s.regions = []string{"https://near", "https://far"}
+	s.refreshedAt = time.Now()
+	s.clients["https://near"] = near
+	s.clients["https://far"] = far
+	s.clients["https://project.livekit.cloud"] = &fakeRegionService{}
+
+	// the region may have received the request, it is not sent again
+	ctx := context.Background()
+	_, err := s.SendData(ctx, &livekit.SendDataRequest{Room: "room"})
+	require.Error(t, err)
+	require.Equal(t, 1, near.calls)
+	require.Zero(t, far.calls)
+
+	// the region could not be connected to, the request was not sent
+	near.err = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	_, err = s.SendData(ctx, &livekit.SendDataRequest{Room: "room"})
+	require.NoError(t, err)
+	require.Equal(t, 2, near.calls)
+	require.Equal(t, 1, far.calls)
+}
+
+func TestMultiRegionRefreshRegions(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		<-release
+		_, _ = w.Write([]byte(`{"regions":[{"region":"near","url":"wss://near.livekit.cloud"}]}`))
+	}))
+	defer srv.Close()
+
+	s := newMultiRegionRoomService(strings.TrimPrefix(srv.URL, "https://"), "https://project.livekit.cloud", authBase{apiKey: "key", apiSecret: "secret"}, nil)
+	s.httpClient = srv.Client()
+
+	// a caller stops waiting when its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s.refreshRegions(ctx)
+	require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+
+	// concurrent callers share the discovery that is still running
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.refreshRegions(context.Background())
+		}()
+	}
+	close(release)
+	wg.Wait()
+
+	require.EqualValues(t, 1, requests.Load())
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	require.Equal(t, []string{"https://near.livekit.cloud"}, s.regions)
+}
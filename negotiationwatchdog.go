@@ -0,0 +1,183 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const defaultNegotiationTimeout = 10 * time.Second
+
+// NegotiationRecovery is the action taken when a negotiation is stuck
+type NegotiationRecovery string
+
+const (
+	// offer is sent again, or answer is re-created for the subscriber
+	NegotiationRecoveryRetry         NegotiationRecovery = "retry"
+	NegotiationRecoveryResume        NegotiationRecovery = "resume"
+	NegotiationRecoveryFullReconnect NegotiationRecovery = "full_reconnect"
+)
+
+// negotiationWatchdog tracks an outstanding negotiation of a transport, escalating
+// recovery on consecutive failures until a negotiation completes
+type negotiationWatchdog struct {
+	lock     sync.Mutex
+	timer    *time.Timer
+	failures int
+}
+
+func (w *negotiationWatchdog) arm(timeout time.Duration, onTimeout func()) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(timeout, onTimeout)
+}
+
+// settled is called when a negotiation completed
+func (w *negotiationWatchdog) settled() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.failures = 0
+}
+
+func (w *negotiationWatchdog) stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+func (w *negotiationWatchdog) fail() NegotiationRecovery {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	// failures reported before the timeout fire it no more
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.failures++
+	switch w.failures {
+	case 1:
+		return NegotiationRecoveryRetry
+	case 2:
+		return NegotiationRecoveryResume
+	default:
+		return NegotiationRecoveryFullReconnect
+	}
+}
+
+func (e *RTCEngine) negotiationWatchdog(target livekit.SignalTarget) *negotiationWatchdog {
+	if target == livekit.SignalTarget_SUBSCRIBER {
+		return &e.subscriberWatchdog
+	}
+	return &e.publisherWatchdog
+}
+
+func (e *RTCEngine) negotiationTimeout() time.Duration {
+	if e.connParams != nil && e.connParams.NegotiationTimeout != 0 {
+		return e.connParams.NegotiationTimeout
+	}
+	return defaultNegotiationTimeout
+}
+
+// armNegotiationWatchdog starts waiting for the answer to an offer sent on the target transport
+func (e *RTCEngine) armNegotiationWatchdog(target livekit.SignalTarget) {
+	timeout := e.negotiationTimeout()
+	if timeout < 0 {
+		return
+	}
+	e.negotiationWatchdog(target).arm(timeout, func() {
+		e.handleNegotiationFailure(target, ErrNegotiationTimeout)
+	})
+}
+
+func (e *RTCEngine) stopNegotiationWatchdogs() {
+	e.publisherWatchdog.stop()
+	e.subscriberWatchdog.stop()
+}
+
+func (e *RTCEngine) handleNegotiationFailure(target livekit.SignalTarget, err error) {
+	if e.closed.Load() || !e.hasConnected.Load() || e.negotiationTimeout() < 0 {
+		return
+	}
+	if e.reconnecting.Load() {
+		// reconnect negotiates again
+		return
+	}
+
+	recovery := e.negotiationWatchdog(target).fail()
+	e.log.Warnw("negotiation stalled", err, "transport", target, "recovery", recovery)
+	e.engineHandler.OnNegotiationStalled(target, recovery, err)
+
+	switch recovery {
+	case NegotiationRecoveryRetry:
+		e.goroutines.Go("negotiation-retry", func() { e.retryNegotiation(target, err) })
+	case NegotiationRecoveryResume:
+		e.handleDisconnect(false)
+	default:
+		e.handleDisconnect(true)
+	}
+}
+
+func (e *RTCEngine) retryNegotiation(target livekit.SignalTarget, cause error) {
+	if target == livekit.SignalTarget_PUBLISHER {
+		publisher, ok := e.Publisher()
+		if !ok {
+			return
+		}
+		// ICE restart rolls back the outstanding offer before creating a new one
+		if err := publisher.createAndSendOffer(&webrtc.OfferOptions{ICERestart: true}); err != nil {
+			e.handleNegotiationFailure(target, err)
+		}
+		return
+	}
+
+	subscriber, ok := e.Subscriber()
+	if !ok {
+		return
+	}
+	if subscriber.pc.SignalingState() != webrtc.SignalingStateHaveRemoteOffer {
+		// offer could not be applied, it is applied again and answered once settled
+		offer := e.subscriberOffer.Load()
+		if offer == nil {
+			e.handleNegotiationFailure(target, cause)
+			return
+		}
+		if err := subscriber.SetRemoteDescription(*offer); err != nil {
+			e.handleNegotiationFailure(target, err)
+		}
+		return
+	}
+	if err := e.createSubscriberPCAnswerAndSend(); err != nil {
+		e.handleNegotiationFailure(target, err)
+	}
+}
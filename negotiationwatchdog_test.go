@@ -0,0 +1,149 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestNegotiationWatchdogEscalation(t *testing.T) {
+	var w negotiationWatchdog
+	require.Equal(t, NegotiationRecoveryRetry, w.fail())
+	require.Equal(t, NegotiationRecoveryResume, w.fail())
+	require.Equal(t, NegotiationRecoveryFullReconnect, w.fail())
+	require.Equal(t, NegotiationRecoveryFullReconnect, w.fail())
+
+	// a completed negotiation starts over
+	w.settled()
+	require.Equal(t, NegotiationRecoveryRetry, w.fail())
+
+	// a failure reported before the timeout stops it
+	fired := make(chan struct{}, 1)
+	w.arm(10*time.Millisecond, func() { fired <- struct{}{} })
+	w.fail()
+	select {
+	case <-fired:
+		t.Fatal("timeout fired after failure")
+	case <-time.After(30 * time.Millisecond):
+	}
+}
+
+// negotiationSignalTransport records the offers sent and fails to reconnect
+type negotiationSignalTransport struct {
+	signalling.SignalTransport
+
+	lock       sync.Mutex
+	offers     int
+	reconnects int
+}
+
+func (s *negotiationSignalTransport) Close() {}
+
+func (s *negotiationSignalTransport) SendMessage(msg proto.Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if msg.(*livekit.SignalRequest).GetOffer() != nil {
+		s.offers++
+	}
+	return nil
+}
+
+func (s *negotiationSignalTransport) Reconnect(string, string, signalling.ConnectParams, string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reconnects++
+	return errors.New("unavailable")
+}
+
+func (s *negotiationSignalTransport) counts() (int, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.offers, s.reconnects
+}
+
+// newNegotiationTestRoom returns a connected room whose negotiations are never answered
+func newNegotiationTestRoom(t *testing.T, timeout time.Duration) (*Room, *negotiationSignalTransport, func() []NegotiationRecovery) {
+	var (
+		lock       sync.Mutex
+		recoveries []NegotiationRecovery
+	)
+	cb := NewRoomCallback()
+	cb.OnNegotiationStalled = func(target livekit.SignalTarget, recovery NegotiationRecovery, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		recoveries = append(recoveries, recovery)
+	}
+	room := NewRoom(cb)
+	transport := &negotiationSignalTransport{}
+	room.engine.signalTransport = transport
+	room.engine.connParams = &signalling.ConnectParams{NegotiationTimeout: timeout}
+	require.NoError(t, room.engine.configure(nil, nil, nil))
+	room.engine.hasConnected.Store(true)
+	t.Cleanup(func() { _ = room.engine.Close(context.Background()) })
+
+	return room, transport, func() []NegotiationRecovery {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]NegotiationRecovery{}, recoveries...)
+	}
+}
+
+func TestNegotiationTimeout(t *testing.T) {
+	room, transport, recoveries := newNegotiationTestRoom(t, 100*time.Millisecond)
+
+	// the offer is sent again after the first timeout, the connection is resumed after the second
+	require.NoError(t, room.engine.publisher.createAndSendOffer(nil))
+	require.Eventually(t, func() bool {
+		_, reconnects := transport.counts()
+		return reconnects > 0
+	}, 2*time.Second, time.Millisecond)
+	offers, _ := transport.counts()
+	require.Equal(t, 2, offers)
+	require.Equal(t, []NegotiationRecovery{NegotiationRecoveryRetry, NegotiationRecoveryResume}, recoveries())
+}
+
+func TestNegotiationFailureReportedSynchronously(t *testing.T) {
+	room, transport, recoveries := newNegotiationTestRoom(t, time.Hour)
+
+	// an answer that cannot be applied is retried without waiting for the timeout
+	require.NoError(t, room.engine.publisher.createAndSendOffer(nil))
+	room.engine.OnAnswer(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: "invalid"}, 0, nil)
+	require.Equal(t, []NegotiationRecovery{NegotiationRecoveryRetry}, recoveries())
+	require.Eventually(t, func() bool {
+		offers, _ := transport.counts()
+		return offers == 2
+	}, time.Second, time.Millisecond)
+	room.engine.publisherWatchdog.settled()
+
+	// an offer that cannot be applied is applied again first, and the connection resumed when that fails too
+	room.engine.OnOffer(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: "invalid"}, 0, nil)
+	require.Eventually(t, func() bool {
+		_, reconnects := transport.counts()
+		return reconnects > 0
+	}, 2*time.Second, time.Millisecond)
+	require.Equal(t, []NegotiationRecovery{NegotiationRecoveryRetry, NegotiationRecoveryRetry, NegotiationRecoveryResume}, recoveries())
+}
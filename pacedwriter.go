@@ -0,0 +1,239 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"go.uber.org/atomic"
+)
+
+// OverflowPolicy decides what a PacedWriter does with a sample written while its buffer is full
+type OverflowPolicy int
+
+const (
+	// WriteSample blocks until there is room
+	OverflowBlock OverflowPolicy = iota
+	// the oldest buffered sample is dropped
+	OverflowDropOldest
+	// the written sample is dropped and ErrPacedWriterFull returned
+	OverflowDropNewest
+)
+
+const defaultPacedWriterDepth = 50
+
+var (
+	ErrPacedWriterFull   = errors.New("paced writer buffer is full")
+	ErrPacedWriterClosed = errors.New("paced writer is closed")
+)
+
+// SampleWriter is implemented by LocalTrack
+type SampleWriter interface {
+	WriteSample(sample media.Sample, opts *SampleWriteOptions) error
+}
+
+// PacedWriterConfig configures a PacedWriter, zero values use the defaults
+type PacedWriterConfig struct {
+	// number of samples buffered, 50 by default
+	Depth    int
+	Overflow OverflowPolicy
+}
+
+type pacedSample struct {
+	sample media.Sample
+	opts   *SampleWriteOptions
+}
+
+// PacedWriter buffers samples written in bursts, e.g. by a decoder running ahead of real time, and passes
+// them to the track at the cadence given by their Duration, so providers do not need to sleep themselves.
+type PacedWriter struct {
+	w        SampleWriter
+	overflow OverflowPolicy
+	queue    chan pacedSample
+
+	dropped atomic.Uint64
+
+	// samples accepted but not yet written, Flush waits for it to reach zero
+	lock    sync.Mutex
+	pending int
+	flushed []chan struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+	// last error returned by the track, reported by WriteSample
+	err atomic.Error
+}
+
+// NewPacedWriter creates a writer releasing samples to w, call Close when done
+func NewPacedWriter(w SampleWriter, config PacedWriterConfig) *PacedWriter {
+	if config.Depth <= 0 {
+		config.Depth = defaultPacedWriterDepth
+	}
+	p := &PacedWriter{
+		w:        w,
+		overflow: config.Overflow,
+		queue:    make(chan pacedSample, config.Depth),
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// WriteSample buffers a sample, it is written once the previous samples' durations passed.
+// A write error of the track is returned by the next call.
+func (p *PacedWriter) WriteSample(sample media.Sample, opts *SampleWriteOptions) error {
+	if err := p.err.Swap(nil); err != nil {
+		return err
+	}
+	item := pacedSample{sample: sample, opts: opts}
+
+	select {
+	case <-p.closed:
+		return ErrPacedWriterClosed
+	default:
+	}
+
+	p.addPending()
+	for {
+		select {
+		case p.queue <- item:
+			return nil
+		default:
+		}
+
+		switch p.overflow {
+		case OverflowDropNewest:
+			p.dropped.Inc()
+			p.donePending()
+			return ErrPacedWriterFull
+		case OverflowDropOldest:
+			select {
+			case <-p.queue:
+				p.dropped.Inc()
+				p.donePending()
+			default:
+			}
+		default:
+			select {
+			case p.queue <- item:
+				return nil
+			case <-p.closed:
+				p.donePending()
+				return ErrPacedWriterClosed
+			}
+		}
+	}
+}
+
+func (p *PacedWriter) run() {
+	defer close(p.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	var next time.Time
+	for {
+		var item pacedSample
+		select {
+		case <-p.closed:
+			return
+		case item = <-p.queue:
+		}
+
+		now := time.Now()
+		if next.Before(now) {
+			// buffer ran empty, restart the cadence
+			next = now
+		} else if wait := next.Sub(now); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-p.closed:
+				p.donePending()
+				return
+			case <-timer.C:
+			}
+		}
+
+		if err := p.w.WriteSample(item.sample, item.opts); err != nil {
+			p.err.Store(err)
+		}
+		next = next.Add(item.sample.Duration)
+		p.donePending()
+	}
+}
+
+func (p *PacedWriter) addPending() {
+	p.lock.Lock()
+	p.pending++
+	p.lock.Unlock()
+}
+
+func (p *PacedWriter) donePending() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pending--
+	if p.pending == 0 {
+		for _, ch := range p.flushed {
+			close(ch)
+		}
+		p.flushed = nil
+	}
+}
+
+// Buffered returns the number of samples waiting to be written
+func (p *PacedWriter) Buffered() int {
+	return len(p.queue)
+}
+
+// Dropped returns the number of samples dropped by the overflow policy
+func (p *PacedWriter) Dropped() uint64 {
+	return p.dropped.Load()
+}
+
+// Flush waits until all buffered samples were written or ctx is done
+func (p *PacedWriter) Flush(ctx context.Context) error {
+	p.lock.Lock()
+	if p.pending == 0 {
+		p.lock.Unlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	p.flushed = append(p.flushed, flushed)
+	p.lock.Unlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-p.done:
+		return ErrPacedWriterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops writing, buffered samples are discarded. Use Flush before to write them.
+func (p *PacedWriter) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+	<-p.done
+}
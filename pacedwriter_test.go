@@ -0,0 +1,109 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSampleWriter struct {
+	lock  sync.Mutex
+	times []time.Time
+	data  []byte
+	err   error
+}
+
+func (w *recordingSampleWriter) WriteSample(sample media.Sample, opts *SampleWriteOptions) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.times = append(w.times, time.Now())
+	w.data = append(w.data, sample.Data...)
+	return w.err
+}
+
+func (w *recordingSampleWriter) written() ([]time.Time, []byte) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return append([]time.Time(nil), w.times...), append([]byte(nil), w.data...)
+}
+
+func TestPacedWriterCadence(t *testing.T) {
+	w := &recordingSampleWriter{}
+	p := NewPacedWriter(w, PacedWriterConfig{})
+	defer p.Close()
+
+	// a burst of samples is released one per duration
+	for i := range 5 {
+		require.NoError(t, p.WriteSample(media.Sample{Data: []byte{byte(i)}, Duration: 20 * time.Millisecond}, nil))
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	require.NoError(t, p.Flush(ctx))
+
+	times, data := w.written()
+	require.Equal(t, []byte{0, 1, 2, 3, 4}, data)
+	require.GreaterOrEqual(t, times[4].Sub(times[0]), 75*time.Millisecond)
+	require.Zero(t, p.Buffered())
+
+	// write errors are reported by the next call
+	w.lock.Lock()
+	w.err = errors.New("write failed")
+	w.lock.Unlock()
+	require.NoError(t, p.WriteSample(media.Sample{Duration: time.Millisecond}, nil))
+	require.NoError(t, p.Flush(ctx))
+	require.Error(t, p.WriteSample(media.Sample{Duration: time.Millisecond}, nil))
+}
+
+func TestPacedWriterOverflow(t *testing.T) {
+	w := &recordingSampleWriter{}
+	p := NewPacedWriter(w, PacedWriterConfig{Depth: 2, Overflow: OverflowDropNewest})
+	// the first sample is written right away and holds the cadence for a second
+	require.NoError(t, p.WriteSample(media.Sample{Data: []byte{0}, Duration: time.Second}, nil))
+	require.Eventually(t, func() bool { return p.Buffered() == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, p.WriteSample(media.Sample{Data: []byte{1}}, nil))
+	require.Eventually(t, func() bool { return p.Buffered() == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, p.WriteSample(media.Sample{Data: []byte{2}}, nil))
+	require.NoError(t, p.WriteSample(media.Sample{Data: []byte{3}}, nil))
+	require.ErrorIs(t, p.WriteSample(media.Sample{Data: []byte{4}}, nil), ErrPacedWriterFull)
+	require.Equal(t, uint64(1), p.Dropped())
+	p.Close()
+	require.ErrorIs(t, p.WriteSample(media.Sample{}, nil), ErrPacedWriterClosed)
+
+	w = &recordingSampleWriter{}
+	p = NewPacedWriter(w, PacedWriterConfig{Depth: 2, Overflow: OverflowDropOldest})
+	defer p.Close()
+	require.NoError(t, p.WriteSample(media.Sample{Data: []byte{0}, Duration: 100 * time.Millisecond}, nil))
+	require.Eventually(t, func() bool { return p.Buffered() == 0 }, time.Second, time.Millisecond)
+	// sample 1 is taken by the writer and waits for its turn, 2 is dropped for 4
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, p.WriteSample(media.Sample{Data: []byte{byte(i)}}, nil))
+		if i == 1 {
+			require.Eventually(t, func() bool { return p.Buffered() == 0 }, time.Second, time.Millisecond)
+		}
+	}
+	require.Equal(t, uint64(1), p.Dropped())
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	require.NoError(t, p.Flush(ctx))
+	_, data := w.written()
+	require.Equal(t, []byte{0, 1, 3, 4}, data)
+}
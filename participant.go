@@ -16,6 +16,7 @@ package lksdk
 
 import (
 	"maps"
+	"slices"
 	"sync"
 
 	"go.uber.org/atomic"
@@ -107,6 +108,18 @@ func (p *baseParticipant) Kind() ParticipantKind {
 	return ParticipantKind(p.info.GetKind())
 }
 
+// KindDetails returns details of the kind, e.g. whether an agent runs in LiveKit Cloud or was forwarded
+func (p *baseParticipant) KindDetails() []livekit.ParticipantInfo_KindDetail {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return slices.Clone(p.info.GetKindDetails())
+}
+
+// IsAgent returns true when the participant is an agent worker
+func (p *baseParticipant) IsAgent() bool {
+	return p.Kind() == ParticipantAgent
+}
+
 func (p *baseParticipant) Metadata() string {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
 )
 
 func TestAttributeChanges(t *testing.T) {
@@ -20,3 +22,121 @@ func TestAttributeChanges(t *testing.T) {
 		"c": "3",
 	}, diff)
 }
+
+func TestSIPInfo(t *testing.T) {
+	var changes []SIPInfo
+	cb := NewRoomCallback()
+	cb.OnSIPInfoChanged = func(info SIPInfo, rp *RemoteParticipant) {
+		changes = append(changes, info)
+	}
+
+	pi := &livekit.ParticipantInfo{
+		Identity: "caller",
+		Kind:     livekit.ParticipantInfo_SIP,
+		Attributes: map[string]string{
+			livekit.AttrSIPCallID:             "SCL_1",
+			livekit.AttrSIPPhoneNumber:        "+15550100",
+			livekit.AttrSIPCallStatus:         "ringing",
+			livekit.AttrSIPHeaderPrefix + "X": "y",
+		},
+	}
+	rp := newRemoteParticipant(pi, cb, nil, nil, nil, logger)
+	info, ok := rp.SIPInfo()
+	require.True(t, ok)
+	require.Equal(t, "SCL_1", info.CallID)
+	require.Equal(t, "+15550100", info.PhoneNumber)
+	require.Equal(t, SIPCallStatusRinging, rp.SIPCallStatus())
+	require.Equal(t, map[string]string{"X": "y"}, rp.SIPHeaders())
+
+	pi = &livekit.ParticipantInfo{
+		Identity:   pi.Identity,
+		Kind:       pi.Kind,
+		Version:    1,
+		Attributes: map[string]string{livekit.AttrSIPCallID: "SCL_1", livekit.AttrSIPCallStatus: "active"},
+	}
+	rp.updateInfo(pi)
+	require.Len(t, changes, 2)
+	require.Equal(t, SIPCallStatusActive, changes[1].CallStatus)
+	require.Empty(t, changes[1].PhoneNumber)
+}
+
+func TestIngressInfo(t *testing.T) {
+	var changes []IngressInfo
+	cb := NewRoomCallback()
+	cb.OnIngressInfoChanged = func(info IngressInfo, rp *RemoteParticipant) {
+		changes = append(changes, info)
+	}
+
+	pi := &livekit.ParticipantInfo{
+		Identity:   "ingress",
+		Kind:       livekit.ParticipantInfo_INGRESS,
+		Attributes: map[string]string{livekit.AttrIngressID: "IN_1"},
+	}
+	rp := newRemoteParticipant(pi, cb, nil, nil, nil, logger)
+	info, ok := rp.IngressInfo()
+	require.True(t, ok)
+	require.Equal(t, "IN_1", info.IngressID)
+	require.Equal(t, IngressStreamStarting, info.Health)
+	require.False(t, info.IsOutOfNetwork())
+
+	rp.updateInfo(&livekit.ParticipantInfo{
+		Identity: pi.Identity,
+		Kind:     pi.Kind,
+		Version:  1,
+		Attributes: map[string]string{
+			livekit.AttrIngressID:                  "IN_1",
+			livekit.AttrIngressOutOfNetworkEventID: "ev",
+		},
+		Tracks: []*livekit.TrackInfo{{Sid: "TR_1", Type: livekit.TrackType_VIDEO}},
+	})
+	require.Len(t, changes, 2)
+	require.Equal(t, IngressStreamHealthy, changes[1].Health)
+	require.True(t, changes[1].IsOutOfNetwork())
+
+	rp.setConnectionQualityInfo(&livekit.ConnectionQualityInfo{Quality: livekit.ConnectionQuality_POOR})
+	require.Len(t, changes, 3)
+	require.Equal(t, IngressStreamDegraded, changes[2].Health)
+
+	_, ok = newRemoteParticipant(&livekit.ParticipantInfo{Identity: "user"}, cb, nil, nil, nil, logger).IngressInfo()
+	require.False(t, ok)
+}
+
+func TestParticipantKind(t *testing.T) {
+	pi := &livekit.ParticipantInfo{
+		Identity:    "agent",
+		Kind:        livekit.ParticipantInfo_AGENT,
+		KindDetails: []livekit.ParticipantInfo_KindDetail{livekit.ParticipantInfo_CLOUD_AGENT},
+	}
+	rp := newRemoteParticipant(pi, nil, nil, nil, nil, logger)
+	require.Equal(t, ParticipantAgent, rp.Kind())
+	require.True(t, rp.IsAgent())
+	require.Equal(t, []livekit.ParticipantInfo_KindDetail{livekit.ParticipantInfo_CLOUD_AGENT}, rp.KindDetails())
+
+	// the details are a copy
+	rp.KindDetails()[0] = livekit.ParticipantInfo_FORWARDED
+	require.Equal(t, livekit.ParticipantInfo_CLOUD_AGENT, rp.KindDetails()[0])
+
+	rp.updateInfo(&livekit.ParticipantInfo{
+		Identity:    "agent",
+		Kind:        livekit.ParticipantInfo_AGENT,
+		Version:     1,
+		KindDetails: []livekit.ParticipantInfo_KindDetail{livekit.ParticipantInfo_CLOUD_AGENT, livekit.ParticipantInfo_FORWARDED},
+	})
+	require.Len(t, rp.KindDetails(), 2)
+
+	caller := newRemoteParticipant(&livekit.ParticipantInfo{Identity: "caller", Kind: livekit.ParticipantInfo_SIP}, nil, nil, nil, nil, logger)
+	require.Equal(t, ParticipantSIP, caller.Kind())
+	require.False(t, caller.IsAgent())
+	require.Empty(t, caller.KindDetails())
+
+	room := NewRoom(nil)
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{
+		{Sid: "PA_agent", Identity: "agent", State: livekit.ParticipantInfo_ACTIVE, Kind: livekit.ParticipantInfo_AGENT},
+		{Sid: "PA_caller", Identity: "caller", State: livekit.ParticipantInfo_ACTIVE, Kind: livekit.ParticipantInfo_SIP},
+		{Sid: "PA_user", Identity: "user", State: livekit.ParticipantInfo_ACTIVE},
+	})
+	require.Len(t, room.GetAgents(), 1)
+	require.Equal(t, "agent", room.GetAgents()[0].Identity())
+	require.Len(t, room.GetSIPParticipants(), 1)
+	require.Len(t, room.GetParticipantsByKind(ParticipantAgent, ParticipantSIP), 2)
+}
@@ -0,0 +1,254 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pion/ice/v4"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+type ParticipantBundleOption func(*ParticipantBundle)
+
+// WithBundleSinglePeerConnection publishes and subscribes over one peer connection per participant
+// instead of two, halving the transports of the bundle. The server must support single peer connection mode.
+func WithBundleSinglePeerConnection() ParticipantBundleOption {
+	return func(b *ParticipantBundle) {
+		b.singlePeerConnection = true
+	}
+}
+
+// WithBundleUDPPort runs ICE of all participants on this local UDP port, on every interface.
+// By default a free port is picked when the first participant joins.
+func WithBundleUDPPort(port int) ParticipantBundleOption {
+	return func(b *ParticipantBundle) {
+		b.udpPort = port
+	}
+}
+
+// WithBundleConnectOptions sets connect options applied to every participant joining through the bundle.
+func WithBundleConnectOptions(opts ...ConnectOption) ParticipantBundleOption {
+	return func(b *ParticipantBundle) {
+		b.connectOptions = append(b.connectOptions, opts...)
+	}
+}
+
+// ParticipantBundle joins many participants to a project from one process, e.g. agent farms simulating users.
+// Experimental: the API may change.
+//
+// Participants share region discovery, which runs once for the bundle, and the local UDP sockets of their
+// transports: ICE of all peer connections is multiplexed over one port per interface, see WithBundleUDPPort, instead
+// of a port per transport. Every participant keeps its own signal session and DTLS session, and with
+// WithBundleSinglePeerConnection uses a single peer connection instead of two.
+type ParticipantBundle struct {
+	url                  string
+	connectOptions       []ConnectOption
+	singlePeerConnection bool
+	udpPort              int
+	regionURLProvider    *regionURLProvider
+
+	lock   sync.Mutex
+	rooms  map[string]*Room // identity -> room
+	udpMux *ice.MultiUDPMuxDefault
+}
+
+func NewParticipantBundle(url string, opts ...ParticipantBundleOption) *ParticipantBundle {
+	b := &ParticipantBundle{
+		url:               url,
+		regionURLProvider: newRegionURLProvider(),
+		rooms:             make(map[string]*Room),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Join connects a participant, opts are applied after the bundle's connect options.
+func (b *ParticipantBundle) Join(info ConnectInfo, callback *RoomCallback, opts ...ConnectOption) (*Room, error) {
+	if err := b.reserve(info.ParticipantIdentity); err != nil {
+		return nil, err
+	}
+	options, err := b.options(opts)
+	if err != nil {
+		b.release(info.ParticipantIdentity, nil)
+		return nil, err
+	}
+	room := b.newRoom(callback)
+	if err := room.Join(b.url, info, options...); err != nil {
+		b.release(info.ParticipantIdentity, nil)
+		return nil, err
+	}
+	b.release(info.ParticipantIdentity, room)
+	return room, nil
+}
+
+// JoinWithToken connects the participant of the token, opts are applied after the bundle's connect options.
+func (b *ParticipantBundle) JoinWithToken(token string, callback *RoomCallback, opts ...ConnectOption) (*Room, error) {
+	options, err := b.options(opts)
+	if err != nil {
+		return nil, err
+	}
+	room := b.newRoom(callback)
+	if err := room.JoinWithToken(b.url, token, options...); err != nil {
+		return nil, err
+	}
+
+	identity := room.LocalParticipant.Identity()
+	if err := b.reserve(identity); err != nil {
+		room.Disconnect()
+		return nil, err
+	}
+	b.release(identity, room)
+	return room, nil
+}
+
+func (b *ParticipantBundle) newRoom(callback *RoomCallback) *Room {
+	return newRoom(callback, b.singlePeerConnection, b.regionURLProvider)
+}
+
+// options returns the connect options of a participant, ICE runs on the sockets shared by the bundle
+func (b *ParticipantBundle) options(opts []ConnectOption) ([]ConnectOption, error) {
+	udpMux, err := b.sharedUDPMux()
+	if err != nil {
+		return nil, err
+	}
+	options := append([]ConnectOption{func(p *signalling.ConnectParams) {
+		p.ICEUDPMux = udpMux
+	}}, b.connectOptions...)
+	return append(options, opts...), nil
+}
+
+func (b *ParticipantBundle) sharedUDPMux() (*ice.MultiUDPMuxDefault, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.udpMux == nil {
+		udpMux, err := ice.NewMultiUDPMuxFromPort(b.udpPort)
+		if err != nil {
+			return nil, err
+		}
+		b.udpMux = udpMux
+	}
+	return b.udpMux, nil
+}
+
+// reserve claims an identity while joining, the room is nil until joined
+func (b *ParticipantBundle) reserve(identity string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.pruneLocked()
+	if _, ok := b.rooms[identity]; ok {
+		return fmt.Errorf("%w: participant %q is already in the bundle", ErrInvalidParameter, identity)
+	}
+	b.rooms[identity] = nil
+	return nil
+}
+
+// release stores the joined room, or frees the identity when joining failed
+func (b *ParticipantBundle) release(identity string, room *Room) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if room == nil {
+		delete(b.rooms, identity)
+	} else {
+		b.rooms[identity] = room
+	}
+}
+
+// pruneLocked removes participants that were disconnected
+func (b *ParticipantBundle) pruneLocked() {
+	for identity, room := range b.rooms {
+		if room != nil && room.ConnectionState() == ConnectionStateDisconnected {
+			delete(b.rooms, identity)
+		}
+	}
+}
+
+// Room returns the room of a participant in the bundle, nil when it is not connected.
+func (b *ParticipantBundle) Room(identity string) *Room {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.pruneLocked()
+	return b.rooms[identity]
+}
+
+// Rooms returns the rooms of all connected participants.
+func (b *ParticipantBundle) Rooms() []*Room {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.pruneLocked()
+	rooms := make([]*Room, 0, len(b.rooms))
+	for _, room := range b.rooms {
+		if room != nil {
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms
+}
+
+// Leave disconnects a participant of the bundle.
+func (b *ParticipantBundle) Leave(identity string) {
+	b.lock.Lock()
+	room := b.rooms[identity]
+	if room != nil {
+		delete(b.rooms, identity)
+	}
+	b.lock.Unlock()
+
+	if room != nil {
+		room.Disconnect()
+	}
+}
+
+// Close disconnects all participants of the bundle in parallel and waits for them, see Room.Close.
+// The shared sockets are closed once all participants left, a later Join opens new ones.
+func (b *ParticipantBundle) Close(ctx context.Context) error {
+	var rooms []*Room
+	b.lock.Lock()
+	for identity, room := range b.rooms {
+		if room != nil {
+			rooms = append(rooms, room)
+			delete(b.rooms, identity)
+		}
+	}
+	udpMux := b.udpMux
+	b.udpMux = nil
+	b.lock.Unlock()
+
+	errs := make([]error, len(rooms))
+	var wg sync.WaitGroup
+	for i, room := range rooms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = room.Close(ctx)
+		}()
+	}
+	wg.Wait()
+	if udpMux != nil {
+		errs = append(errs, udpMux.Close())
+	}
+	return errors.Join(errs...)
+}
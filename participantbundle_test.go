@@ -0,0 +1,96 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestParticipantBundle(t *testing.T) {
+	b := NewParticipantBundle("ws://localhost:7880", WithBundleSinglePeerConnection(), WithBundleConnectOptions(WithAutoSubscribe(false)))
+
+	r1, r2 := b.newRoom(nil), b.newRoom(nil)
+	require.True(t, r1.useSinglePeerConnection)
+	require.Same(t, r1.regionURLProvider, r2.regionURLProvider)
+	opts, err := b.options([]ConnectOption{WithAudioOnly()})
+	require.NoError(t, err)
+	require.Len(t, opts, 3)
+
+	require.NoError(t, b.reserve("alice"))
+	require.ErrorIs(t, b.reserve("alice"), ErrInvalidParameter)
+	require.Nil(t, b.Room("alice"))
+	require.Empty(t, b.Rooms())
+
+	// failed join frees the identity
+	b.release("alice", nil)
+	require.NoError(t, b.reserve("alice"))
+
+	// disconnected rooms are pruned
+	b.release("alice", r1)
+	require.Nil(t, b.Room("alice"))
+	require.NoError(t, b.reserve("alice"))
+
+	require.NoError(t, b.Close(context.Background()))
+}
+
+func TestParticipantBundleSharesUDPPort(t *testing.T) {
+	b := NewParticipantBundle("ws://localhost:7880")
+	defer b.Close(context.Background())
+
+	// ports of the host candidates of the publisher transport of a bundled participant, one per interface
+	gatherPorts := func() map[string]bool {
+		opts, err := b.options(nil)
+		require.NoError(t, err)
+		params := &signalling.ConnectParams{}
+		for _, opt := range opts {
+			opt(params)
+		}
+
+		room := b.newRoom(nil)
+		room.engine.connParams = params
+		require.NoError(t, room.engine.configure(nil, nil, nil))
+		defer room.engine.Close(context.Background())
+
+		pc := room.engine.publisher.PeerConnection()
+		_, err = pc.CreateDataChannel("probe", nil)
+		require.NoError(t, err)
+		offer, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		gathered := webrtc.GatheringCompletePromise(pc)
+		require.NoError(t, pc.SetLocalDescription(offer))
+		<-gathered
+
+		ports := make(map[string]bool)
+		for _, line := range strings.Split(pc.LocalDescription().SDP, "\r\n") {
+			// a=candidate:<foundation> <component> udp <priority> <address> <port> typ host
+			fields := strings.Fields(line)
+			if strings.HasPrefix(line, "a=candidate:") && len(fields) >= 8 && strings.EqualFold(fields[2], "udp") && fields[7] == "host" {
+				ports[fields[5]] = true
+			}
+		}
+		return ports
+	}
+
+	first, second := gatherPorts(), gatherPorts()
+	require.NotEmpty(t, first)
+	require.Equal(t, first, second)
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// participantCounts holds the counts of the last room update and the watchers of their thresholds
+type participantCounts struct {
+	lock         sync.Mutex
+	participants uint32
+	publishers   uint32
+	watchers     []*countWatcher
+	// runs the notifications of all updates, one at a time and in order
+	events *eventQueue
+}
+
+type countWatcher struct {
+	threshold  uint32
+	publishers bool
+	exceeded   bool
+	onCrossed  func(count uint32, exceeded bool)
+}
+
+func newParticipantCounts() *participantCounts {
+	return &participantCounts{
+		events: &eventQueue{},
+	}
+}
+
+func (c *participantCounts) get() (participants, publishers uint32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.participants, c.publishers
+}
+
+// update stores the counts and returns the notifications of watchers whose threshold was crossed
+func (c *participantCounts) update(participants, publishers uint32) []func() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.participants, c.publishers = participants, publishers
+	var notify []func()
+	for _, w := range c.watchers {
+		count := c.countLocked(w.publishers)
+		if exceeded := count > w.threshold; exceeded != w.exceeded {
+			w.exceeded = exceeded
+			onCrossed := w.onCrossed
+			notify = append(notify, func() { onCrossed(count, exceeded) })
+		}
+	}
+	return notify
+}
+
+func (c *participantCounts) countLocked(publishers bool) uint32 {
+	if publishers {
+		return c.publishers
+	}
+	return c.participants
+}
+
+func (c *participantCounts) watch(threshold uint32, publishers bool, onCrossed func(count uint32, exceeded bool)) func() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	w := &countWatcher{
+		threshold:  threshold,
+		publishers: publishers,
+		onCrossed:  onCrossed,
+	}
+	w.exceeded = c.countLocked(publishers) > threshold
+	c.watchers = append(c.watchers, w)
+
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		for i, o := range c.watchers {
+			if o == w {
+				c.watchers = append(c.watchers[:i], c.watchers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (c *participantCounts) clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.participants, c.publishers = 0, 0
+	c.watchers = nil
+}
+
+// NumParticipants returns the number of participants in the room, including the local participant. It is kept
+// current from room updates, which the server sends periodically rather than on every join or leave.
+func (r *Room) NumParticipants() uint32 {
+	participants, _ := r.participantCounts.get()
+	return participants
+}
+
+// NumPublishers returns the number of participants publishing tracks, updated like NumParticipants
+func (r *Room) NumPublishers() uint32 {
+	_, publishers := r.participantCounts.get()
+	return publishers
+}
+
+// WatchParticipantCount calls onCrossed when NumParticipants becomes greater than threshold, with exceeded set,
+// and when it drops to threshold or below again. It is not called for the count at the time of the call.
+// The returned function stops watching.
+func (r *Room) WatchParticipantCount(threshold uint32, onCrossed func(count uint32, exceeded bool)) func() {
+	return r.participantCounts.watch(threshold, false, onCrossed)
+}
+
+// WatchPublisherCount is WatchParticipantCount for NumPublishers
+func (r *Room) WatchPublisherCount(threshold uint32, onCrossed func(count uint32, exceeded bool)) func() {
+	return r.participantCounts.watch(threshold, true, onCrossed)
+}
+
+func (r *Room) updateParticipantCounts(room *livekit.Room) {
+	// in order, so that watchers see the crossings as they happened, also across updates
+	for _, n := range r.participantCounts.update(room.NumParticipants, room.NumPublishers) {
+		r.participantCounts.events.enqueue(n)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type countCrossing struct {
+	count    uint32
+	exceeded bool
+}
+
+func TestParticipantCounts(t *testing.T) {
+	c := newParticipantCounts()
+	run := func(notify []func()) {
+		for _, n := range notify {
+			n()
+		}
+	}
+
+	var participants, publishers []countCrossing
+	run(c.update(5, 1))
+	// not notified for the count when watching starts
+	c.watch(3, false, func(count uint32, exceeded bool) {
+		participants = append(participants, countCrossing{count, exceeded})
+	})
+	stop := c.watch(1, true, func(count uint32, exceeded bool) {
+		publishers = append(publishers, countCrossing{count, exceeded})
+	})
+
+	run(c.update(6, 2))
+	require.Empty(t, participants)
+	require.Equal(t, []countCrossing{{2, true}}, publishers)
+
+	run(c.update(3, 2))
+	run(c.update(2, 2))
+	run(c.update(4, 2))
+	require.Equal(t, []countCrossing{{3, false}, {4, true}}, participants)
+
+	stop()
+	run(c.update(4, 0))
+	require.Len(t, publishers, 1)
+
+	participantCount, publisherCount := c.get()
+	require.Equal(t, uint32(4), participantCount)
+	require.Zero(t, publisherCount)
+
+	c.clear()
+	require.Empty(t, c.update(0, 0))
+}
+
+func TestParticipantCountCrossingsInOrder(t *testing.T) {
+	room := NewRoom(nil)
+	crossings := make(chan countCrossing, 100)
+	room.WatchParticipantCount(1, func(count uint32, exceeded bool) {
+		crossings <- countCrossing{count, exceeded}
+	})
+
+	for i := 0; i < 50; i++ {
+		room.updateParticipantCounts(&livekit.Room{NumParticipants: uint32(2 - i%2)})
+	}
+	for i := 0; i < 50; i++ {
+		select {
+		case c := <-crossings:
+			require.Equal(t, countCrossing{uint32(2 - i%2), i%2 == 0}, c)
+		case <-time.After(time.Second):
+			t.Fatal("crossing not notified")
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"net"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+
+	sdkinterceptor "github.com/livekit/server-sdk-go/v2/pkg/interceptor"
+)
+
+const (
+	// smallest max payload size accepted, below it payloaders cannot produce useful packets
+	minRTPOutboundMTU = 256
+
+	ipv4HeaderSize = 20
+	ipv6HeaderSize = 40
+	udpHeaderSize  = 8
+	// SRTP authentication tag
+	srtpOverhead = 10
+	// header extensions added after packetization (audio level, mid, rid, transport-wide CC)
+	rtpExtensionAllowance = 20
+	// TURN ChannelData header
+	turnChannelOverhead = 4
+)
+
+// clampPayloadSize limits a max payload size to what the packetizer and the size limit interceptor accept,
+// 0 when not set
+func clampPayloadSize(size int) int {
+	if size <= 0 {
+		return 0
+	}
+	return max(minRTPOutboundMTU, min(size, sdkinterceptor.MaxPayloadSize))
+}
+
+// SetMaxPayloadSize sets the max size of RTP packets created when writing samples, 0 restores the default of 1200 bytes.
+// Sizes are limited to 256-1200 bytes. Can be changed while the track is published.
+func (s *LocalTrack) SetMaxPayloadSize(size int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.maxPayloadSize = clampPayloadSize(size)
+	s.resetPacketizerLocked()
+}
+
+// MaxPayloadSize returns the max size of RTP packets created when writing samples
+func (s *LocalTrack) MaxPayloadSize() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.maxPayloadSizeLocked()
+}
+
+func (s *LocalTrack) setInterfacePayloadLimit(limit int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	limit = clampPayloadSize(limit)
+	if limit == s.interfacePayloadLimit {
+		return
+	}
+	s.interfacePayloadLimit = limit
+	s.resetPacketizerLocked()
+}
+
+func (s *LocalTrack) maxPayloadSizeLocked() int {
+	size := rtpOutboundMTU
+	if s.maxPayloadSize > 0 {
+		size = s.maxPayloadSize
+	}
+	if s.interfacePayloadLimit > 0 {
+		size = min(size, s.interfacePayloadLimit)
+	}
+	return size
+}
+
+// resetPacketizerLocked creates the packetizer for the current max payload size,
+// continuing the RTP timestamps of the previous one
+func (s *LocalTrack) resetPacketizerLocked() {
+	if s.payloader == nil {
+		// not bound yet
+		return
+	}
+
+	var opts []rtp.PacketizerOption
+	if s.hasNextPacketTimestamp {
+		opts = append(opts, rtp.WithTimestamp(s.nextPacketTimestamp))
+	}
+	s.packetizer = rtp.NewPacketizerWithOptions(
+		uint16(s.maxPayloadSizeLocked()),
+		s.payloader,
+		s.sequencer,
+		uint32(s.clockRate),
+		opts...,
+	)
+}
+
+// configurePayloadSize applies the publish option and the interface MTU limit to tracks that are packetized by the SDK
+func (p *LocalParticipant) configurePayloadSize(pubOptions *LocalTrackPublishOptions, tracks ...webrtc.TrackLocal) {
+	limit := int(p.interfacePayloadLimit.Load())
+	for _, t := range tracks {
+		lt, ok := asLocalTrack(t)
+		if !ok {
+			continue
+		}
+		if pubOptions.maxPayloadSize > 0 {
+			lt.SetMaxPayloadSize(pubOptions.maxPayloadSize)
+		}
+		lt.setInterfacePayloadLimit(limit)
+	}
+}
+
+// updateInterfacePayloadLimit applies the max payload size estimated for the selected candidate of the
+// publisher connection to published tracks and to tracks published later
+func (p *LocalParticipant) updateInterfacePayloadLimit(local ICECandidateInfo) {
+	limit := interfacePayloadLimit(local, interfaceMTU)
+	if int(p.interfacePayloadLimit.Swap(int32(limit))) == limit {
+		return
+	}
+	if limit > 0 {
+		p.log.Infow("limiting payload size to interface MTU", "maxPayloadSize", limit, "localAddress", local.Address)
+	}
+
+	p.tracks.Range(func(_, value interface{}) bool {
+		pub := value.(*LocalTrackPublication)
+		if lt, ok := asLocalTrack(pub.TrackLocal()); ok {
+			lt.setInterfacePayloadLimit(limit)
+		}
+		for _, lt := range pub.TrackLocalForSimulcast() {
+			lt.setInterfacePayloadLimit(limit)
+		}
+		return true
+	})
+}
+
+// interfacePayloadLimit estimates the max RTP packet size that is not fragmented on the interface used by a
+// local candidate, 0 when the interface uses a regular MTU or cannot be found
+func interfacePayloadLimit(local ICECandidateInfo, mtuForIP func(net.IP) int) int {
+	addr := local.Address
+	if local.Type != webrtc.ICECandidateTypeHost && local.RelatedAddress != "" {
+		addr = local.RelatedAddress
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return 0
+	}
+	mtu := mtuForIP(ip)
+	if mtu <= 0 {
+		return 0
+	}
+
+	overhead := udpHeaderSize + srtpOverhead + rtpExtensionAllowance
+	if ip.To4() != nil {
+		overhead += ipv4HeaderSize
+	} else {
+		overhead += ipv6HeaderSize
+	}
+	if local.IsRelay() {
+		overhead += turnChannelOverhead
+	}
+	limit := mtu - overhead
+	if limit >= rtpOutboundMTU {
+		return 0
+	}
+	return clampPayloadSize(limit)
+}
+
+// interfaceMTU returns the MTU of the network interface with the given address, 0 if there is none
+func interfaceMTU(ip net.IP) int {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return 0
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.MTU
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"net"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalTrackMaxPayloadSize(t *testing.T) {
+	codec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}
+	track, err := NewLocalTrack(codec)
+	require.NoError(t, err)
+	require.Equal(t, rtpOutboundMTU, track.MaxPayloadSize())
+
+	track.SetMaxPayloadSize(5000)
+	require.Equal(t, rtpOutboundMTU, track.MaxPayloadSize())
+	track.SetMaxPayloadSize(10)
+	require.Equal(t, minRTPOutboundMTU, track.MaxPayloadSize())
+	track.SetMaxPayloadSize(1000)
+	track.setInterfacePayloadLimit(900)
+	require.Equal(t, 900, track.MaxPayloadSize())
+	track.setInterfacePayloadLimit(0)
+	require.Equal(t, 1000, track.MaxPayloadSize())
+
+	// simulate binding
+	payloader, err := payloaderForCodec(codec)
+	require.NoError(t, err)
+	track.lock.Lock()
+	track.payloader = payloader
+	track.sequencer = rtp.NewRandomSequencer()
+	track.clockRate = float64(codec.ClockRate)
+	track.resetPacketizerLocked()
+	track.lock.Unlock()
+
+	packetSizes := func(packets []*rtp.Packet) []int {
+		var sizes []int
+		for _, p := range packets {
+			sizes = append(sizes, p.MarshalSize())
+		}
+		return sizes
+	}
+	frame := make([]byte, 1800)
+	first := track.packetizer.Packetize(frame, 3000)
+	track.nextPacketTimestamp = first[0].Timestamp + 3000
+	track.hasNextPacketTimestamp = true
+	for _, size := range packetSizes(first) {
+		require.LessOrEqual(t, size, 1000)
+	}
+
+	track.SetMaxPayloadSize(500)
+	second := track.packetizer.Packetize(frame, 3000)
+	require.Len(t, second, 4)
+	for _, size := range packetSizes(second) {
+		require.LessOrEqual(t, size, 500)
+	}
+	require.Equal(t, first[0].Timestamp+3000, second[0].Timestamp)
+	require.Equal(t, first[len(first)-1].SequenceNumber+1, second[0].SequenceNumber)
+}
+
+func TestInterfacePayloadLimit(t *testing.T) {
+	mtu := func(m int) func(net.IP) int {
+		return func(net.IP) int { return m }
+	}
+
+	host := ICECandidateInfo{Address: "10.8.0.2", Type: webrtc.ICECandidateTypeHost}
+	require.Zero(t, interfacePayloadLimit(host, mtu(1500)))
+	require.Zero(t, interfacePayloadLimit(host, mtu(0)))
+	require.Equal(t, 1200-ipv4HeaderSize-udpHeaderSize-srtpOverhead-rtpExtensionAllowance, interfacePayloadLimit(host, mtu(1200)))
+	require.Equal(t, minRTPOutboundMTU, interfacePayloadLimit(host, mtu(200)))
+
+	relay := ICECandidateInfo{
+		Address:        "203.0.113.10",
+		Type:           webrtc.ICECandidateTypeRelay,
+		RelatedAddress: "fd00::2",
+	}
+	var looked net.IP
+	limit := interfacePayloadLimit(relay, func(ip net.IP) int {
+		looked = ip
+		return 1280
+	})
+	require.Equal(t, "fd00::2", looked.String())
+	require.Equal(t, 1280-ipv6HeaderSize-udpHeaderSize-srtpOverhead-rtpExtensionAllowance-turnChannelOverhead, limit)
+}
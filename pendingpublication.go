@@ -0,0 +1,134 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// PublicationState describes where a publication currently in flight is stuck
+type PublicationState int
+
+const (
+	// PublicationStateNegotiating means transceivers are being set up on the publisher connection
+	PublicationStateNegotiating PublicationState = iota
+	// PublicationStateAwaitingAck means the AddTrackRequest was sent and the server has not yet responded
+	PublicationStateAwaitingAck
+)
+
+func (s PublicationState) String() string {
+	switch s {
+	case PublicationStateNegotiating:
+		return "negotiating"
+	case PublicationStateAwaitingAck:
+		return "awaiting_ack"
+	default:
+		return "unknown"
+	}
+}
+
+// PendingPublication is a snapshot of a publication that has not been acknowledged by the server yet
+type PendingPublication struct {
+	// CID is the client-side track ID, used to match the server's TrackPublishedResponse
+	CID    string
+	Name   string
+	Kind   TrackKind
+	Source livekit.TrackSource
+	State  PublicationState
+	// StartedAt is when the publish call started
+	StartedAt time.Time
+	// StateChangedAt is when the publication entered its current state
+	StateChangedAt time.Time
+}
+
+// pendingPublications tracks publications in flight, keyed by client track ID
+type pendingPublications struct {
+	lock sync.Mutex
+	pubs map[string]*PendingPublication
+}
+
+func (p *pendingPublications) add(cid string, name string, kind TrackKind, source livekit.TrackSource) {
+	now := time.Now()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.pubs == nil {
+		p.pubs = make(map[string]*PendingPublication)
+	}
+	p.pubs[cid] = &PendingPublication{
+		CID:            cid,
+		Name:           name,
+		Kind:           kind,
+		Source:         source,
+		State:          PublicationStateNegotiating,
+		StartedAt:      now,
+		StateChangedAt: now,
+	}
+}
+
+func (p *pendingPublications) setState(cid string, state PublicationState) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if pp := p.pubs[cid]; pp != nil && pp.State != state {
+		pp.State = state
+		pp.StateChangedAt = time.Now()
+	}
+}
+
+func (p *pendingPublications) remove(cid string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.pubs, cid)
+}
+
+func (p *pendingPublications) list() []PendingPublication {
+	p.lock.Lock()
+	res := make([]PendingPublication, 0, len(p.pubs))
+	for _, pp := range p.pubs {
+		res = append(res, *pp)
+	}
+	p.lock.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].StartedAt.Before(res[j].StartedAt)
+	})
+	return res
+}
+
+// PendingPublications returns the publications that were started but not yet acknowledged by the server,
+// oldest first. Useful to find out where a stuck publish is waiting.
+func (p *LocalParticipant) PendingPublications() []PendingPublication {
+	return p.pending.list()
+}
+
+// TrackSIDForCID returns the server-assigned SID of a published track given its client track ID
+func (p *LocalParticipant) TrackSIDForCID(cid string) (string, bool) {
+	var sid string
+	p.tracks.Range(func(_, value interface{}) bool {
+		pub := value.(*LocalTrackPublication)
+		if pub.CID() == cid {
+			sid = pub.SID()
+			return false
+		}
+		return true
+	})
+	return sid, sid != ""
+}
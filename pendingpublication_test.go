@@ -0,0 +1,63 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestPendingPublications(t *testing.T) {
+	p := newLocalParticipant(nil, NewRoomCallback(), nil, logger)
+	require.Empty(t, p.PendingPublications())
+
+	p.pending.add("TR_mic", "mic", TrackKindAudio, livekit.TrackSource_MICROPHONE)
+	time.Sleep(time.Millisecond)
+	p.pending.add("TR_cam", "cam", TrackKindVideo, livekit.TrackSource_CAMERA)
+	p.pending.setState("TR_mic", PublicationStateAwaitingAck)
+
+	pending := p.PendingPublications()
+	require.Len(t, pending, 2)
+	require.Equal(t, "TR_mic", pending[0].CID)
+	require.Equal(t, PublicationStateAwaitingAck, pending[0].State)
+	require.Equal(t, livekit.TrackSource_MICROPHONE, pending[0].Source)
+	require.Equal(t, "TR_cam", pending[1].CID)
+	require.Equal(t, PublicationStateNegotiating, pending[1].State)
+	require.Equal(t, "negotiating", pending[1].State.String())
+
+	p.pending.remove("TR_mic")
+	p.pending.remove("TR_cam")
+	require.Empty(t, p.PendingPublications())
+}
+
+func TestTrackSIDForCID(t *testing.T) {
+	p := newLocalParticipant(nil, NewRoomCallback(), nil, logger)
+	pub := NewLocalTrackPublication(TrackKindAudio, nil, TrackPublicationOptions{}, nil, logger)
+	pub.cid = "TR_mic"
+	pub.updateInfo(&livekit.TrackInfo{Sid: "TR_server", Type: livekit.TrackType_AUDIO})
+	p.addPublication(pub)
+
+	sid, ok := p.TrackSIDForCID("TR_mic")
+	require.True(t, ok)
+	require.Equal(t, "TR_server", sid)
+	require.Equal(t, "TR_mic", pub.CID())
+
+	_, ok = p.TrackSIDForCID("TR_unknown")
+	require.False(t, ok)
+}
@@ -0,0 +1,238 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+
+	sdkinterceptor "github.com/livekit/server-sdk-go/v2/pkg/interceptor"
+)
+
+// PipelineStage names a step of the media pipeline measured by a PipelineTracer
+type PipelineStage string
+
+const (
+	// PipelineStageProvider is the time a SampleProvider took to return the next sample
+	PipelineStageProvider PipelineStage = "provider"
+	// PipelineStagePacketizer is the time taken to turn a sample into RTP packets
+	PipelineStagePacketizer PipelineStage = "packetizer"
+	// PipelineStagePacer is the time packets waited in the pacer queue, only measured with WithPacer
+	PipelineStagePacer PipelineStage = "pacer"
+	// PipelineStageSocket is the time taken to pass packets through the interceptors and SRTP to the socket
+	PipelineStageSocket PipelineStage = "socket"
+
+	// PipelineStageReceive is the time from reading a packet of a remote track until it reaches the depacketizer,
+	// measured by the application with PipelineTracer.Start
+	PipelineStageReceive PipelineStage = "receive"
+	// PipelineStageDepacketizer is the time packets were buffered until their sample was complete,
+	// see samplebuilder.WithSampleLatencyHandler
+	PipelineStageDepacketizer PipelineStage = "depacketizer"
+	// PipelineStageHandler is the time the application took to handle a sample, measured with PipelineTracer.Start
+	PipelineStageHandler PipelineStage = "handler"
+)
+
+const defaultPipelineTraceWindow = 1000
+
+// LatencySummary describes the latency of a pipeline stage.
+// Count includes all observations, the other values cover the most recent ones kept by the tracer.
+type LatencySummary struct {
+	Count uint64
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// PipelineTracer collects per-stage latencies of the media pipeline, to find where the end-to-end latency is spent.
+// Outbound stages of a LocalTrack are recorded once the track is created with WithPipelineTracer,
+// inbound stages are recorded by the application with Observe, Observer or Start.
+// A nil tracer ignores observations.
+type PipelineTracer struct {
+	lock   sync.Mutex
+	window int
+	stages map[PipelineStage]*stageLatencies
+}
+
+type stageLatencies struct {
+	count   uint64
+	samples []time.Duration // ring buffer of the most recent observations
+	next    int
+}
+
+// NewPipelineTracer creates a tracer summarizing the last window observations of each stage, 1000 when not positive.
+func NewPipelineTracer(window int) *PipelineTracer {
+	if window <= 0 {
+		window = defaultPipelineTraceWindow
+	}
+	return &PipelineTracer{
+		window: window,
+		stages: make(map[PipelineStage]*stageLatencies),
+	}
+}
+
+// WithPipelineTracer records the outbound stages of the track in the tracer
+func WithPipelineTracer(tracer *PipelineTracer) LocalTrackOptions {
+	return func(s *LocalTrack) {
+		s.tracer = tracer
+	}
+}
+
+// configurePipelineTracing lets traced tracks record the delays of the pacer of the publisher connection
+func (p *LocalParticipant) configurePipelineTracing(tracks ...webrtc.TrackLocal) {
+	monitor := p.engine.pacerDelayMonitor()
+	for _, t := range tracks {
+		if lt, ok := asLocalTrack(t); ok {
+			lt.setPacerDelays(monitor)
+		}
+	}
+}
+
+// setPacerDelays is called before publishing, monitor is nil when packets are not paced
+func (s *LocalTrack) setPacerDelays(monitor *sdkinterceptor.PacerDelayMonitor) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.tracer != nil {
+		s.pacerDelays = monitor
+	}
+}
+
+func (s *LocalTrack) registerPacerDelaysLocked() {
+	if s.pacerDelays == nil {
+		return
+	}
+	tracer := s.tracer
+	s.pacerDelays.Register(uint32(s.ssrc), func(queued, write time.Duration) {
+		tracer.Observe(PipelineStagePacer, queued)
+		tracer.Observe(PipelineStageSocket, write)
+	})
+}
+
+func (s *LocalTrack) unregisterPacerDelaysLocked() {
+	if s.pacerDelays != nil {
+		s.pacerDelays.Unregister(uint32(s.ssrc))
+	}
+}
+
+// Observe records the latency of a stage
+func (t *PipelineTracer) Observe(stage PipelineStage, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := t.stages[stage]
+	if s == nil {
+		s = &stageLatencies{samples: make([]time.Duration, 0, t.window)}
+		t.stages[stage] = s
+	}
+	s.count++
+	if len(s.samples) < t.window {
+		s.samples = append(s.samples, d)
+		return
+	}
+	s.samples[s.next] = d
+	s.next = (s.next + 1) % t.window
+}
+
+// Observer returns a function recording latencies of the given stage,
+// e.g. to pass to samplebuilder.WithSampleLatencyHandler
+func (t *PipelineTracer) Observer(stage PipelineStage) func(time.Duration) {
+	return func(d time.Duration) {
+		t.Observe(stage, d)
+	}
+}
+
+// Start begins measuring a stage, the returned function records the time elapsed since.
+func (t *PipelineTracer) Start(stage PipelineStage) func() {
+	start := time.Now()
+	return func() {
+		t.Observe(stage, time.Since(start))
+	}
+}
+
+// Summary returns the latency summary of a stage, false when the stage was not observed
+func (t *PipelineTracer) Summary(stage PipelineStage) (LatencySummary, bool) {
+	if t == nil {
+		return LatencySummary{}, false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := t.stages[stage]
+	if s == nil {
+		return LatencySummary{}, false
+	}
+	return s.summary(), true
+}
+
+// Summaries returns the latency summary of every observed stage
+func (t *PipelineTracer) Summaries() map[PipelineStage]LatencySummary {
+	summaries := make(map[PipelineStage]LatencySummary)
+	if t == nil {
+		return summaries
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for stage, s := range t.stages {
+		summaries[stage] = s.summary()
+	}
+	return summaries
+}
+
+// Reset drops all observations
+func (t *PipelineTracer) Reset() {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	clear(t.stages)
+}
+
+func (s *stageLatencies) summary() LatencySummary {
+	sorted := slices.Clone(s.samples)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return LatencySummary{
+		Count: s.count,
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(sorted, 0.5),
+		P90:   percentile(sorted, 0.9),
+		P99:   percentile(sorted, 0.99),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(float64(len(sorted))*p)) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/require"
+
+	sdkinterceptor "github.com/livekit/server-sdk-go/v2/pkg/interceptor"
+)
+
+func TestPipelineTracerSummary(t *testing.T) {
+	tracer := NewPipelineTracer(100)
+	_, ok := tracer.Summary(PipelineStageSocket)
+	require.False(t, ok)
+
+	// the first 100 observations are dropped from the window
+	for i := 1; i <= 200; i++ {
+		tracer.Observe(PipelineStageSocket, time.Duration(i)*time.Millisecond)
+	}
+	summary, ok := tracer.Summary(PipelineStageSocket)
+	require.True(t, ok)
+	require.Equal(t, LatencySummary{
+		Count: 200,
+		Min:   101 * time.Millisecond,
+		Max:   200 * time.Millisecond,
+		Mean:  150500 * time.Microsecond,
+		P50:   150 * time.Millisecond,
+		P90:   190 * time.Millisecond,
+		P99:   199 * time.Millisecond,
+	}, summary)
+
+	tracer.Observer(PipelineStageHandler)(time.Second)
+	require.Len(t, tracer.Summaries(), 2)
+	tracer.Reset()
+	require.Empty(t, tracer.Summaries())
+
+	// a nil tracer ignores observations
+	var nilTracer *PipelineTracer
+	nilTracer.Observe(PipelineStageSocket, time.Second)
+	nilTracer.Start(PipelineStageHandler)()
+	require.Empty(t, nilTracer.Summaries())
+}
+
+func TestLocalTrackPipelineTracing(t *testing.T) {
+	tracer := NewPipelineTracer(0)
+	codec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}
+	track, err := NewLocalTrack(codec, WithPipelineTracer(tracer))
+	require.NoError(t, err)
+
+	// simulate binding
+	payloader, err := payloaderForCodec(codec)
+	require.NoError(t, err)
+	track.lock.Lock()
+	track.payloader = payloader
+	track.sequencer = rtp.NewRandomSequencer()
+	track.clockRate = float64(codec.ClockRate)
+	track.resetPacketizerLocked()
+	track.lock.Unlock()
+
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 3000), Duration: 33 * time.Millisecond}, nil))
+	packetizer, ok := tracer.Summary(PipelineStagePacketizer)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), packetizer.Count)
+	socket, ok := tracer.Summary(PipelineStageSocket)
+	require.True(t, ok)
+	require.Equal(t, uint64(3), socket.Count)
+
+	// with a pacer, the socket stage is reported by the pacer
+	tracer.Reset()
+	monitor := sdkinterceptor.NewPacerDelayMonitor()
+	track.setPacerDelays(monitor)
+	track.lock.Lock()
+	track.ssrc = 1234
+	track.registerPacerDelaysLocked()
+	track.lock.Unlock()
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 100), Duration: 33 * time.Millisecond}, nil))
+	_, ok = tracer.Summary(PipelineStageSocket)
+	require.False(t, ok)
+}
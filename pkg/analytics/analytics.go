@@ -0,0 +1,299 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analytics aggregates per-participant speaking time, interruptions and presence over a session,
+// with periodic summaries and a final report when the collector is closed.
+package analytics
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+// ParticipantStats are the totals of one participant over the session
+type ParticipantStats struct {
+	Identity string
+	// SpeakingTime is the time the participant was an active speaker
+	SpeakingTime time.Duration
+	// SpeakingTurns counts how often the participant started speaking
+	SpeakingTurns int
+	// Interruptions counts turns started while another participant was speaking
+	Interruptions int
+	// JoinedAt is the first time the participant was seen, LeftAt is zero while present
+	JoinedAt time.Time
+	LeftAt   time.Time
+	// PresentTime sums the time in the room over all joins
+	PresentTime time.Duration
+}
+
+// Report is a snapshot of the session, participants are sorted by identity
+type Report struct {
+	StartedAt    time.Time
+	EndedAt      time.Time
+	Participants []ParticipantStats
+}
+
+type participantState struct {
+	stats         ParticipantStats
+	presentSince  time.Time
+	speakingSince time.Time
+}
+
+// Collector aggregates session analytics of a room. Room events must be passed to the Handle methods,
+// usually from the RoomCallback.
+type Collector struct {
+	summaryInterval time.Duration
+	onSummary       func(Report)
+	onReport        func(Report)
+
+	localIdentity func() string
+	remotes       func() []string
+	now           func() time.Time
+
+	lock         sync.Mutex
+	startedAt    time.Time
+	participants map[string]*participantState
+	speaking     map[string]struct{}
+	stop         chan struct{}
+}
+
+type Option func(*Collector)
+
+// WithSummaryInterval calls f with a report of the session so far every interval.
+func WithSummaryInterval(interval time.Duration, f func(Report)) Option {
+	return func(c *Collector) {
+		c.summaryInterval = interval
+		c.onSummary = f
+	}
+}
+
+// WithReportHandler calls f with the final report when the collector is closed.
+func WithReportHandler(f func(Report)) Option {
+	return func(c *Collector) {
+		c.onReport = f
+	}
+}
+
+func NewCollector(room *lksdk.Room, opts ...Option) *Collector {
+	c := &Collector{
+		now:          time.Now,
+		participants: make(map[string]*participantState),
+		speaking:     make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.localIdentity = func() string {
+		return room.LocalParticipant.Identity()
+	}
+	c.remotes = func() []string {
+		var identities []string
+		for _, rp := range room.GetRemoteParticipants() {
+			identities = append(identities, rp.Identity())
+		}
+		return identities
+	}
+	return c
+}
+
+// Start records the participants already in the room and starts sending summaries.
+// It should be called once connected to the room.
+func (c *Collector) Start() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stop != nil {
+		return
+	}
+	now := c.now()
+	if c.startedAt.IsZero() {
+		c.startedAt = now
+	}
+	c.joinedLocked(c.localIdentity(), now)
+	for _, identity := range c.remotes() {
+		c.joinedLocked(identity, now)
+	}
+
+	stop := make(chan struct{})
+	c.stop = stop
+	if c.summaryInterval > 0 && c.onSummary != nil {
+		go c.summaryWorker(stop)
+	}
+}
+
+// Close stops the summaries and returns the final report, also passed to WithReportHandler.
+// Usually called from RoomCallback.OnDisconnected.
+func (c *Collector) Close() Report {
+	c.lock.Lock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	report := c.reportLocked(c.now(), true)
+	c.lock.Unlock()
+
+	if c.onReport != nil {
+		c.onReport(report)
+	}
+	return report
+}
+
+// Report returns the totals of the session so far.
+func (c *Collector) Report() Report {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.reportLocked(c.now(), false)
+}
+
+// HandleActiveSpeakersChanged is to be called from RoomCallback.OnActiveSpeakersChanged.
+func (c *Collector) HandleActiveSpeakersChanged(speakers []lksdk.Participant) {
+	identities := make([]string, 0, len(speakers))
+	for _, p := range speakers {
+		identities = append(identities, p.Identity())
+	}
+	c.speakersChanged(identities)
+}
+
+// HandleParticipantConnected is to be called from RoomCallback.OnParticipantConnected.
+func (c *Collector) HandleParticipantConnected(rp *lksdk.RemoteParticipant) {
+	c.participantJoined(rp.Identity())
+}
+
+// HandleParticipantDisconnected is to be called from RoomCallback.OnParticipantDisconnected.
+func (c *Collector) HandleParticipantDisconnected(rp *lksdk.RemoteParticipant) {
+	c.participantLeft(rp.Identity())
+}
+
+func (c *Collector) speakersChanged(identities []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	current := make(map[string]struct{}, len(identities))
+	for _, identity := range identities {
+		current[identity] = struct{}{}
+	}
+	for identity := range c.speaking {
+		if _, ok := current[identity]; !ok {
+			c.stopSpeakingLocked(identity, now)
+		}
+	}
+	// speakers that were already speaking before this update are interrupted by new ones
+	wasSpeaking := len(c.speaking) > 0
+	for _, identity := range identities {
+		if _, ok := c.speaking[identity]; ok {
+			continue
+		}
+		p := c.joinedLocked(identity, now)
+		p.speakingSince = now
+		p.stats.SpeakingTurns++
+		if wasSpeaking {
+			p.stats.Interruptions++
+		}
+		c.speaking[identity] = struct{}{}
+	}
+}
+
+func (c *Collector) participantJoined(identity string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.joinedLocked(identity, c.now())
+}
+
+func (c *Collector) participantLeft(identity string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	p := c.participants[identity]
+	if p == nil || p.presentSince.IsZero() {
+		return
+	}
+	now := c.now()
+	c.stopSpeakingLocked(identity, now)
+	p.stats.PresentTime += now.Sub(p.presentSince)
+	p.presentSince = time.Time{}
+	p.stats.LeftAt = now
+}
+
+// joinedLocked marks identity as present, a participant can rejoin after leaving
+func (c *Collector) joinedLocked(identity string, now time.Time) *participantState {
+	p := c.participants[identity]
+	if p == nil {
+		p = &participantState{stats: ParticipantStats{Identity: identity, JoinedAt: now}}
+		c.participants[identity] = p
+	}
+	if p.presentSince.IsZero() {
+		p.presentSince = now
+		p.stats.LeftAt = time.Time{}
+	}
+	return p
+}
+
+func (c *Collector) stopSpeakingLocked(identity string, now time.Time) {
+	if _, ok := c.speaking[identity]; !ok {
+		return
+	}
+	delete(c.speaking, identity)
+	if p := c.participants[identity]; p != nil {
+		p.stats.SpeakingTime += now.Sub(p.speakingSince)
+		p.speakingSince = time.Time{}
+	}
+}
+
+// reportLocked adds the ongoing speaking and present time up to now, final ends the session
+func (c *Collector) reportLocked(now time.Time, final bool) Report {
+	report := Report{
+		StartedAt: c.startedAt,
+	}
+	if final {
+		report.EndedAt = now
+	}
+	for _, p := range c.participants {
+		stats := p.stats
+		if !p.speakingSince.IsZero() {
+			stats.SpeakingTime += now.Sub(p.speakingSince)
+		}
+		if !p.presentSince.IsZero() {
+			stats.PresentTime += now.Sub(p.presentSince)
+			if final {
+				stats.LeftAt = now
+			}
+		}
+		report.Participants = append(report.Participants, stats)
+	}
+	slices.SortFunc(report.Participants, func(a, b ParticipantStats) int {
+		return strings.Compare(a.Identity, b.Identity)
+	})
+	return report
+}
+
+func (c *Collector) summaryWorker(stop chan struct{}) {
+	ticker := time.NewTicker(c.summaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.onSummary(c.Report())
+		}
+	}
+}
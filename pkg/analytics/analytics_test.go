@@ -0,0 +1,82 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	start := time.Unix(1000, 0)
+	now := start
+	var final Report
+	c := &Collector{
+		onReport:      func(r Report) { final = r },
+		localIdentity: func() string { return "agent" },
+		remotes:       func() []string { return []string{"alice"} },
+		now:           func() time.Time { return now },
+		participants:  make(map[string]*participantState),
+		speaking:      make(map[string]struct{}),
+	}
+	c.Start()
+
+	advance := func(d time.Duration) { now = now.Add(d) }
+
+	c.speakersChanged([]string{"alice"})
+	advance(10 * time.Second)
+	c.participantJoined("bob")
+	// bob interrupts alice
+	c.speakersChanged([]string{"alice", "bob"})
+	advance(2 * time.Second)
+	c.speakersChanged([]string{"bob"})
+	advance(3 * time.Second)
+	c.speakersChanged(nil)
+	advance(5 * time.Second)
+	c.participantLeft("bob")
+	// agent speaks alone
+	c.speakersChanged([]string{"agent"})
+	advance(4 * time.Second)
+
+	report := c.Report()
+	require.True(t, report.EndedAt.IsZero())
+	require.Len(t, report.Participants, 3)
+	require.Equal(t, 4*time.Second, report.Participants[0].SpeakingTime)
+
+	final = c.Close()
+	require.Equal(t, final, c.Close())
+	require.Equal(t, start, final.StartedAt)
+	require.Equal(t, now, final.EndedAt)
+
+	agent, alice, bob := final.Participants[0], final.Participants[1], final.Participants[2]
+	require.Equal(t, "agent", agent.Identity)
+	require.Equal(t, 1, agent.SpeakingTurns)
+	require.Zero(t, agent.Interruptions)
+	require.Equal(t, 24*time.Second, agent.PresentTime)
+
+	require.Equal(t, "alice", alice.Identity)
+	require.Equal(t, 12*time.Second, alice.SpeakingTime)
+	require.Equal(t, 1, alice.SpeakingTurns)
+	require.Zero(t, alice.Interruptions)
+
+	require.Equal(t, "bob", bob.Identity)
+	require.Equal(t, 5*time.Second, bob.SpeakingTime)
+	require.Equal(t, 1, bob.Interruptions)
+	require.Equal(t, start.Add(10*time.Second), bob.JoinedAt)
+	require.Equal(t, start.Add(20*time.Second), bob.LeftAt)
+	require.Equal(t, 10*time.Second, bob.PresentTime)
+}
@@ -0,0 +1,243 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build portaudio
+
+package audiodevice
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gordonklaus/portaudio"
+	"github.com/livekit/media-sdk"
+	"github.com/livekit/media-sdk/opus"
+	protoLogger "github.com/livekit/protocol/logger"
+	"github.com/pion/webrtc/v4"
+	pionmedia "github.com/pion/webrtc/v4/pkg/media"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+const opusSampleRate = 48000
+
+// Capture reads audio from an input device and writes it as Opus to a LocalSampleTrack.
+// Publish Track, then call Start.
+type Capture struct {
+	cfg    Config
+	log    protoLogger.Logger
+	track  *lksdk.LocalSampleTrack
+	stream *portaudio.Stream
+	buf    []int16
+	writer media.PCM16Writer
+
+	lock    sync.Mutex
+	started bool
+	closed  bool
+	done    chan struct{}
+}
+
+// InputDevices returns the names of the devices that can be captured from
+func InputDevices() ([]string, error) {
+	if err := portaudio.Initialize(); err != nil {
+		return nil, err
+	}
+	defer portaudio.Terminate()
+
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, d := range devices {
+		if d.MaxInputChannels > 0 {
+			names = append(names, d.Name)
+		}
+	}
+	return names, nil
+}
+
+// NewCapture opens the input device of cfg and creates the track its audio is written to
+func NewCapture(cfg Config, logger protoLogger.Logger) (*Capture, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if err := portaudio.Initialize(); err != nil {
+		return nil, err
+	}
+
+	c, err := newCapture(cfg, logger)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, err
+	}
+	return c, nil
+}
+
+func newCapture(cfg Config, logger protoLogger.Logger) (*Capture, error) {
+	device, err := findInputDevice(cfg.DeviceName)
+	if err != nil {
+		return nil, err
+	}
+	if device.MaxInputChannels < cfg.Channels {
+		return nil, ErrInvalidConfig
+	}
+
+	track, err := lksdk.NewLocalSampleTrack(webrtc.RTPCodecCapability{
+		MimeType:  webrtc.MimeTypeOpus,
+		ClockRate: opusSampleRate,
+		Channels:  2,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// device frames -> resampler (if needed) -> opus encoder -> track, one sample per frame
+	opusWriter := media.FromSampleWriter[opus.Sample](trackWriter{track}, opusSampleRate, cfg.FrameDuration)
+	writer, err := opus.Encode(opusWriter, cfg.Channels, logger)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SampleRate != opusSampleRate {
+		writer = media.ResampleWriter(writer, cfg.SampleRate)
+	}
+
+	latency := cfg.Latency
+	if latency == 0 {
+		latency = device.DefaultLowInputLatency
+	}
+	buf := make([]int16, cfg.framesPerBuffer()*cfg.Channels)
+	stream, err := portaudio.OpenStream(portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   device,
+			Channels: cfg.Channels,
+			Latency:  latency,
+		},
+		SampleRate:      float64(cfg.SampleRate),
+		FramesPerBuffer: cfg.framesPerBuffer(),
+	}, buf)
+	if err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+
+	return &Capture{
+		cfg:    cfg,
+		log:    logger.WithValues("device", device.Name),
+		track:  track,
+		stream: stream,
+		buf:    buf,
+		writer: writer,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+func findInputDevice(name string) (*portaudio.DeviceInfo, error) {
+	if name == "" {
+		return portaudio.DefaultInputDevice()
+	}
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		if d.Name == name && d.MaxInputChannels > 0 {
+			return d, nil
+		}
+	}
+	return nil, ErrDeviceNotFound
+}
+
+// Track returns the track to publish
+func (c *Capture) Track() *lksdk.LocalSampleTrack {
+	return c.track
+}
+
+// Start starts capturing from the device
+func (c *Capture) Start() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return errors.New("capture is closed")
+	}
+	if c.started {
+		return nil
+	}
+	if err := c.stream.Start(); err != nil {
+		return err
+	}
+	c.started = true
+	go c.run()
+	return nil
+}
+
+func (c *Capture) run() {
+	defer close(c.done)
+
+	overflowLogged := false
+	for {
+		// blocks until the device filled a frame, so the device clock paces the track
+		err := c.stream.Read()
+		if errors.Is(err, portaudio.InputOverflowed) {
+			// samples were lost, the frame read is still valid
+			if !overflowLogged {
+				c.log.Infow("audio input overflowed, samples were dropped")
+				overflowLogged = true
+			}
+		} else if err != nil {
+			c.lock.Lock()
+			closed := c.closed
+			c.lock.Unlock()
+			if !closed {
+				c.log.Warnw("could not read audio input", err)
+			}
+			return
+		}
+
+		if err := c.writer.WriteSample(media.PCM16Sample(c.buf)); err != nil {
+			c.log.Warnw("could not write audio sample", err)
+		}
+	}
+}
+
+// Close stops capturing and releases the device, the track is not unpublished
+func (c *Capture) Close() error {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil
+	}
+	c.closed = true
+	started := c.started
+	c.lock.Unlock()
+
+	var err error
+	if started {
+		err = c.stream.Stop()
+		<-c.done
+	}
+	err = errors.Join(err, c.stream.Close(), c.writer.Close(), portaudio.Terminate())
+	return err
+}
+
+// trackWriter adapts LocalSampleTrack to media.MediaSampleWriter
+type trackWriter struct {
+	track *lksdk.LocalSampleTrack
+}
+
+func (w trackWriter) WriteSample(sample pionmedia.Sample) error {
+	return w.track.WriteSample(sample, nil)
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audiodevice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	cfg, err := Config{}.withDefaults()
+	require.NoError(t, err)
+	require.Equal(t, DefaultSampleRate, cfg.SampleRate)
+	require.Equal(t, DefaultChannels, cfg.Channels)
+	require.Equal(t, DefaultFrameDuration, cfg.FrameDuration)
+	require.Equal(t, 960, cfg.framesPerBuffer())
+
+	cfg, err = Config{SampleRate: 44100, Channels: 2, FrameDuration: 10 * time.Millisecond}.withDefaults()
+	require.NoError(t, err)
+	require.Equal(t, 441, cfg.framesPerBuffer())
+
+	for _, invalid := range []Config{
+		{Channels: 3},
+		{SampleRate: 4000},
+		{FrameDuration: 15 * time.Millisecond},
+		// 11025 Hz does not fit a whole number of samples into 10 ms
+		{SampleRate: 11025, FrameDuration: 10 * time.Millisecond},
+	} {
+		_, err := invalid.withDefaults()
+		require.ErrorIs(t, err, ErrInvalidConfig, "%+v", invalid)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audiodevice publishes audio from local capture devices (ALSA, PulseAudio, Core Audio, WASAPI, ...)
+// through PortAudio. It is only built with the portaudio build tag, which requires the PortAudio and
+// Opus development libraries:
+//
+//	go build -tags portaudio
+//
+// Capture is clocked by the device: every frame read from the device is encoded and written to the
+// track with its duration, so RTP timestamps follow the device clock instead of a timer.
+package audiodevice
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultSampleRate    = 48000
+	DefaultChannels      = 1
+	DefaultFrameDuration = 20 * time.Millisecond
+)
+
+var (
+	ErrDeviceNotFound = errors.New("audio device not found")
+	ErrInvalidConfig  = errors.New("invalid audio capture config")
+)
+
+// Config selects the capture device and format, zero values use the defaults
+type Config struct {
+	// name of the input device, the default input device when empty
+	DeviceName string
+	// capture sample rate, the device or host API resamples if it runs at a different rate
+	SampleRate int
+	// 1 or 2
+	Channels int
+	// duration of each frame read from the device and encoded, 10, 20, 40 or 60 ms
+	FrameDuration time.Duration
+	// suggested input latency, the device low latency when zero
+	Latency time.Duration
+}
+
+func (c Config) withDefaults() (Config, error) {
+	if c.SampleRate == 0 {
+		c.SampleRate = DefaultSampleRate
+	}
+	if c.Channels == 0 {
+		c.Channels = DefaultChannels
+	}
+	if c.FrameDuration == 0 {
+		c.FrameDuration = DefaultFrameDuration
+	}
+	if c.SampleRate < 8000 || c.Channels < 1 || c.Channels > 2 {
+		return c, ErrInvalidConfig
+	}
+	switch c.FrameDuration {
+	case 10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond:
+	default:
+		return c, ErrInvalidConfig
+	}
+	if c.SampleRate*int(c.FrameDuration/time.Millisecond)%1000 != 0 {
+		// frames must hold a whole number of samples
+		return c, ErrInvalidConfig
+	}
+	return c, nil
+}
+
+// framesPerBuffer is the number of samples per channel in a frame
+func (c Config) framesPerBuffer() int {
+	return c.SampleRate * int(c.FrameDuration/time.Millisecond) / 1000
+}
@@ -0,0 +1,376 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package identityverify lets participants prove their identity to each other with signed assertions
+// exchanged over data packets, for applications that should not rely on the SFU alone to authenticate
+// senders. A verifier sends a challenge, the participant answers with an assertion binding its identity,
+// the verifier's identity and the challenge nonce, signed by a key the verifier trusts.
+package identityverify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+const (
+	DefaultTopic         = "lk.identity"
+	DefaultMaxClockSkew  = 30 * time.Second
+	assertionContextName = "lk-identity-v1"
+)
+
+var (
+	ErrVerificationFailed = errors.New("identity verification failed")
+	ErrNoVerifier         = errors.New("no signature verifier configured")
+)
+
+// Signer signs assertions of the local participant, returning the ID of the key used
+type Signer interface {
+	Sign(payload []byte) (keyID string, signature []byte, err error)
+}
+
+// SignatureVerifier checks the signature of an assertion made by identity with the key keyID
+type SignatureVerifier interface {
+	Verify(keyID string, identity string, payload []byte, signature []byte) error
+}
+
+// HMACKey signs and verifies assertions with a shared secret. Using the API key and secret that the
+// access tokens are signed with lets any holder of the credentials verify participants, see NewAPIKey.
+type HMACKey struct {
+	keyID  string
+	secret []byte
+}
+
+func NewHMACKey(keyID string, secret []byte) *HMACKey {
+	return &HMACKey{keyID: keyID, secret: secret}
+}
+
+// NewAPIKey creates a key from the API key and secret used to create access tokens
+func NewAPIKey(apiKey, apiSecret string) *HMACKey {
+	return NewHMACKey(apiKey, []byte(apiSecret))
+}
+
+func (k *HMACKey) Sign(payload []byte) (string, []byte, error) {
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(payload)
+	return k.keyID, mac.Sum(nil), nil
+}
+
+func (k *HMACKey) Verify(keyID string, _ string, payload []byte, signature []byte) error {
+	if keyID != k.keyID {
+		return fmt.Errorf("unknown key %q", keyID)
+	}
+	_, expected, _ := k.Sign(payload)
+	if !hmac.Equal(expected, signature) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// Assertion is a signed statement of a participant about its identity
+type Assertion struct {
+	Identity string `json:"identity"`
+	// Audience is the identity of the verifier
+	Audience string `json:"audience"`
+	Nonce    string `json:"nonce"`
+	// IssuedAt is in milliseconds since epoch
+	IssuedAt  int64  `json:"issued_at"`
+	KeyID     string `json:"key_id"`
+	Signature []byte `json:"signature"`
+}
+
+func (a *Assertion) payload() []byte {
+	return []byte(strings.Join([]string{
+		assertionContextName,
+		a.Identity,
+		a.Audience,
+		a.Nonce,
+		strconv.FormatInt(a.IssuedAt, 10),
+	}, "\n"))
+}
+
+type messageType string
+
+const (
+	messageChallenge messageType = "challenge"
+	messageAssertion messageType = "assertion"
+)
+
+type message struct {
+	Type      messageType `json:"t"`
+	Nonce     string      `json:"n,omitempty"`
+	Assertion *Assertion  `json:"a,omitempty"`
+}
+
+type pendingChallenge struct {
+	identity string
+	result   chan error
+}
+
+type Option func(*Handshake)
+
+// WithSigner answers challenges of other participants with assertions signed by signer
+func WithSigner(signer Signer) Option {
+	return func(h *Handshake) {
+		h.signer = signer
+	}
+}
+
+// WithSignatureVerifier checks assertions of other participants with verifier, required for VerifyIdentity
+func WithSignatureVerifier(verifier SignatureVerifier) Option {
+	return func(h *Handshake) {
+		h.verifier = verifier
+	}
+}
+
+// WithTopic sets the data packet topic used for the handshake
+func WithTopic(topic string) Option {
+	return func(h *Handshake) {
+		h.topic = topic
+	}
+}
+
+// WithMaxClockSkew sets how far the issue time of an assertion may differ from the local clock
+func WithMaxClockSkew(skew time.Duration) Option {
+	return func(h *Handshake) {
+		h.maxClockSkew = skew
+	}
+}
+
+// WithLogger sets the logger for the Handshake.
+func WithLogger(logger logger.Logger) Option {
+	return func(h *Handshake) {
+		h.logger = logger
+	}
+}
+
+// Handshake exchanges identity assertions with other participants. Received packets must be passed to
+// HandleDataPacket, usually from RoomCallback.OnDataPacket.
+type Handshake struct {
+	topic        string
+	signer       Signer
+	verifier     SignatureVerifier
+	maxClockSkew time.Duration
+	logger       logger.Logger
+
+	localIdentity func() string
+	send          func(data []byte, destination string) error
+	now           func() time.Time
+
+	lock     sync.Mutex
+	pending  map[string]*pendingChallenge // nonce -> challenge
+	verified map[string]struct{}
+}
+
+func New(room *lksdk.Room, opts ...Option) *Handshake {
+	h := newHandshake(opts...)
+	h.localIdentity = func() string {
+		return room.LocalParticipant.Identity()
+	}
+	h.send = func(data []byte, destination string) error {
+		return room.LocalParticipant.PublishDataPacket(
+			lksdk.UserData(data),
+			lksdk.WithDataPublishTopic(h.topic),
+			lksdk.WithDataPublishReliable(true),
+			lksdk.WithDataPublishDestination([]string{destination}),
+		)
+	}
+	return h
+}
+
+func newHandshake(opts ...Option) *Handshake {
+	h := &Handshake{
+		topic:        DefaultTopic,
+		maxClockSkew: DefaultMaxClockSkew,
+		logger:       logger.GetLogger(),
+		now:          time.Now,
+		pending:      make(map[string]*pendingChallenge),
+		verified:     make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// VerifyIdentity challenges participant to prove its identity and waits for a valid assertion.
+// Successful verifications are remembered until Forget is called.
+func (h *Handshake) VerifyIdentity(ctx context.Context, participant *lksdk.RemoteParticipant) error {
+	return h.verifyIdentity(ctx, participant.Identity())
+}
+
+func (h *Handshake) verifyIdentity(ctx context.Context, identity string) error {
+	if h.verifier == nil {
+		return ErrNoVerifier
+	}
+	if h.IsVerified(identity) {
+		return nil
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	challenge := &pendingChallenge{identity: identity, result: make(chan error, 1)}
+	h.lock.Lock()
+	h.pending[nonce] = challenge
+	h.lock.Unlock()
+	defer func() {
+		h.lock.Lock()
+		delete(h.pending, nonce)
+		h.lock.Unlock()
+	}()
+
+	if err := h.sendMessage(&message{Type: messageChallenge, Nonce: nonce}, identity); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-challenge.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsVerified returns true when identity was verified
+func (h *Handshake) IsVerified(identity string) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	_, ok := h.verified[identity]
+	return ok
+}
+
+// Forget drops the verification result of identity, e.g. when the participant disconnects
+func (h *Handshake) Forget(identity string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.verified, identity)
+}
+
+// HandleDataPacket answers challenges and checks assertions received on the handshake topic.
+// It returns false for packets that are not for the handshake.
+func (h *Handshake) HandleDataPacket(packet lksdk.DataPacket, params lksdk.DataReceiveParams) bool {
+	user, ok := packet.(*lksdk.UserDataPacket)
+	if !ok || user.Topic != h.topic {
+		return false
+	}
+
+	var msg message
+	if err := json.Unmarshal(user.Payload, &msg); err != nil {
+		h.logger.Debugw("could not decode identity handshake message", "sender", params.SenderIdentity, "error", err)
+		return true
+	}
+
+	switch msg.Type {
+	case messageChallenge:
+		h.answerChallenge(msg.Nonce, params.SenderIdentity)
+	case messageAssertion:
+		if msg.Assertion != nil {
+			h.handleAssertion(msg.Assertion, params.SenderIdentity)
+		}
+	}
+	return true
+}
+
+func (h *Handshake) answerChallenge(nonce, verifier string) {
+	if h.signer == nil || nonce == "" {
+		return
+	}
+	a := &Assertion{
+		Identity: h.localIdentity(),
+		Audience: verifier,
+		Nonce:    nonce,
+		IssuedAt: h.now().UnixMilli(),
+	}
+	keyID, signature, err := h.signer.Sign(a.payload())
+	if err != nil {
+		h.logger.Warnw("could not sign identity assertion", err, "verifier", verifier)
+		return
+	}
+	a.KeyID, a.Signature = keyID, signature
+	if err := h.sendMessage(&message{Type: messageAssertion, Assertion: a}, verifier); err != nil {
+		h.logger.Warnw("could not send identity assertion", err, "verifier", verifier)
+	}
+}
+
+func (h *Handshake) handleAssertion(a *Assertion, sender string) {
+	h.lock.Lock()
+	challenge := h.pending[a.Nonce]
+	if challenge == nil || challenge.identity != sender {
+		h.lock.Unlock()
+		// not requested, or answered by someone else
+		return
+	}
+	delete(h.pending, a.Nonce)
+	h.lock.Unlock()
+
+	err := h.checkAssertion(a, sender)
+	if err == nil {
+		h.lock.Lock()
+		h.verified[sender] = struct{}{}
+		h.lock.Unlock()
+	} else {
+		h.logger.Infow("identity verification failed", "participant", sender, "error", err)
+	}
+	challenge.result <- err
+}
+
+func (h *Handshake) checkAssertion(a *Assertion, sender string) error {
+	switch {
+	case a.Identity != sender:
+		return fmt.Errorf("%w: assertion made for %q", ErrVerificationFailed, a.Identity)
+	case a.Audience != h.localIdentity():
+		return fmt.Errorf("%w: assertion made for verifier %q", ErrVerificationFailed, a.Audience)
+	}
+	skew := h.now().Sub(time.UnixMilli(a.IssuedAt))
+	if skew > h.maxClockSkew || skew < -h.maxClockSkew {
+		return fmt.Errorf("%w: assertion issued %s from now", ErrVerificationFailed, skew)
+	}
+	if err := h.verifier.Verify(a.KeyID, a.Identity, a.payload(), a.Signature); err != nil {
+		return fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+	return nil
+}
+
+func (h *Handshake) sendMessage(msg *message, destination string) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return h.send(data, destination)
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
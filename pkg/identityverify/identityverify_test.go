@@ -0,0 +1,119 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identityverify
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+// newTestHandshakes returns handshakes that deliver packets to each other synchronously,
+// the identity each one claims as sender can differ from its local identity
+func newTestHandshakes(identities []string, senders []string, opts ...[]Option) []*Handshake {
+	hs := make([]*Handshake, len(identities))
+	for i, identity := range identities {
+		h := newHandshake(opts[i]...)
+		h.localIdentity = func() string { return identity }
+		h.send = func(data []byte, destination string) error {
+			for j, other := range hs {
+				if identities[j] == destination {
+					other.HandleDataPacket(&lksdk.UserDataPacket{Topic: DefaultTopic, Payload: data}, lksdk.DataReceiveParams{SenderIdentity: senders[i]})
+				}
+			}
+			return nil
+		}
+		hs[i] = h
+	}
+	return hs
+}
+
+func TestVerifyIdentity(t *testing.T) {
+	key := NewAPIKey("APIkey", "secret")
+	hs := newTestHandshakes([]string{"bot", "alice"}, []string{"bot", "alice"},
+		[]Option{WithSignatureVerifier(key)},
+		[]Option{WithSigner(key)},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, hs[0].verifyIdentity(ctx, "alice"))
+	require.True(t, hs[0].IsVerified("alice"))
+	hs[0].Forget("alice")
+	require.False(t, hs[0].IsVerified("alice"))
+
+	// alice has no verifier
+	require.ErrorIs(t, hs[1].verifyIdentity(ctx, "bot"), ErrNoVerifier)
+}
+
+func TestVerifyIdentityWrongKey(t *testing.T) {
+	hs := newTestHandshakes([]string{"bot", "alice"}, []string{"bot", "alice"},
+		[]Option{WithSignatureVerifier(NewAPIKey("APIkey", "secret"))},
+		[]Option{WithSigner(NewAPIKey("APIkey", "other"))},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.ErrorIs(t, hs[0].verifyIdentity(ctx, "alice"), ErrVerificationFailed)
+	require.False(t, hs[0].IsVerified("alice"))
+}
+
+func TestVerifyIdentityStolenAssertion(t *testing.T) {
+	key := NewAPIKey("APIkey", "secret")
+	// mallory answers the challenge sent to alice, but the SFU reports mallory as sender
+	hs := newTestHandshakes([]string{"bot", "alice"}, []string{"bot", "mallory"},
+		[]Option{WithSignatureVerifier(key)},
+		[]Option{WithSigner(key)},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, hs[0].verifyIdentity(ctx, "alice"), context.DeadlineExceeded)
+}
+
+func TestCheckAssertion(t *testing.T) {
+	key := NewAPIKey("APIkey", "secret")
+	h := newHandshake(WithSignatureVerifier(key))
+	h.localIdentity = func() string { return "bot" }
+
+	sign := func(a *Assertion) *Assertion {
+		keyID, sig, err := key.Sign(a.payload())
+		require.NoError(t, err)
+		a.KeyID, a.Signature = keyID, sig
+		return a
+	}
+	now := time.Now().UnixMilli()
+	valid := sign(&Assertion{Identity: "alice", Audience: "bot", Nonce: "n", IssuedAt: now})
+	require.NoError(t, h.checkAssertion(valid, "alice"))
+	require.ErrorIs(t, h.checkAssertion(valid, "mallory"), ErrVerificationFailed)
+
+	otherAudience := sign(&Assertion{Identity: "alice", Audience: "other-bot", Nonce: "n", IssuedAt: now})
+	require.ErrorIs(t, h.checkAssertion(otherAudience, "alice"), ErrVerificationFailed)
+
+	old := sign(&Assertion{Identity: "alice", Audience: "bot", Nonce: "n", IssuedAt: now - time.Hour.Milliseconds()})
+	require.ErrorIs(t, h.checkAssertion(old, "alice"), ErrVerificationFailed)
+
+	// tampered after signing
+	var tampered Assertion
+	data, _ := json.Marshal(valid)
+	require.NoError(t, json.Unmarshal(data, &tampered))
+	tampered.Nonce = "other"
+	require.ErrorIs(t, h.checkAssertion(&tampered, "alice"), ErrVerificationFailed)
+}
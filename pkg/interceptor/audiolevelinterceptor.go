@@ -0,0 +1,94 @@
+package interceptor
+
+import (
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+)
+
+// AudioLevelMonitor passes the RFC 6464 audio levels of received packets to the handler registered for their SSRC.
+type AudioLevelMonitor struct {
+	handlers sync.Map // uint32 -> func(level uint8, voice bool)
+}
+
+func NewAudioLevelMonitor() *AudioLevelMonitor {
+	return &AudioLevelMonitor{}
+}
+
+// Register sets the handler of a stream, level is in -dBov, 0 being the loudest and 127 silence.
+func (m *AudioLevelMonitor) Register(ssrc uint32, handler func(level uint8, voice bool)) {
+	m.handlers.Store(ssrc, handler)
+}
+
+func (m *AudioLevelMonitor) Unregister(ssrc uint32) {
+	m.handlers.Delete(ssrc)
+}
+
+func (m *AudioLevelMonitor) handler(ssrc uint32) func(level uint8, voice bool) {
+	if h, ok := m.handlers.Load(ssrc); ok {
+		return h.(func(level uint8, voice bool))
+	}
+	return nil
+}
+
+type AudioLevelInterceptorFactory struct {
+	monitor *AudioLevelMonitor
+}
+
+func NewAudioLevelInterceptorFactory(monitor *AudioLevelMonitor) *AudioLevelInterceptorFactory {
+	return &AudioLevelInterceptorFactory{
+		monitor: monitor,
+	}
+}
+
+func (a *AudioLevelInterceptorFactory) NewInterceptor(_ string) (interceptor.Interceptor, error) {
+	return &AudioLevelInterceptor{monitor: a.monitor}, nil
+}
+
+type AudioLevelInterceptor struct {
+	interceptor.NoOp
+
+	monitor *AudioLevelMonitor
+}
+
+func (a *AudioLevelInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	var extID uint8
+	for _, ext := range info.RTPHeaderExtensions {
+		if ext.URI == sdp.AudioLevelURI {
+			extID = uint8(ext.ID)
+			break
+		}
+	}
+	if extID == 0 {
+		return reader
+	}
+
+	ssrc := info.SSRC
+	return interceptor.RTPReaderFunc(func(buf []byte, attributes interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, attr, err := reader.Read(buf, attributes)
+		if err != nil {
+			return n, attr, err
+		}
+		handler := a.monitor.handler(ssrc)
+		if handler == nil {
+			return n, attr, err
+		}
+
+		if attr == nil {
+			attr = make(interceptor.Attributes)
+		}
+		header, herr := attr.GetRTPHeader(buf[:n])
+		if herr != nil {
+			return n, attr, err
+		}
+		if payload := header.GetExtension(extID); payload != nil {
+			var ext rtp.AudioLevelExtension
+			if ext.Unmarshal(payload) == nil {
+				handler(ext.Level, ext.Voice)
+			}
+		}
+		return n, attr, err
+	})
+}
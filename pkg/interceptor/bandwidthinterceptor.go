@@ -0,0 +1,184 @@
+package interceptor
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/interceptor/pkg/gcc"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+const (
+	// rates are measured over at least this long
+	bandwidthSampleWindow = time.Second
+	// the send side estimate starts here instead of gcc's 10kbps, so that it does not report congestion
+	// until it ramped up
+	sendSideInitialBitrate = 1_000_000
+)
+
+// BandwidthMonitor counts media bytes sent and received on a peer connection,
+// and keeps the latest REMB estimate received from the remote peer, as well as
+// the send side estimate from TWCC feedback, see NewSendSideBWEInterceptorFactory.
+type BandwidthMonitor struct {
+	sentBytes     atomic.Uint64
+	receivedBytes atomic.Uint64
+	remoteBitrate atomic.Uint64
+	twccFeedback  atomic.Bool
+
+	estimatorLock sync.Mutex
+	estimator     cc.BandwidthEstimator
+
+	lock         sync.Mutex
+	sampledAt    time.Time
+	lastSent     uint64
+	lastReceived uint64
+	sentRate     uint64
+	receivedRate uint64
+}
+
+func NewBandwidthMonitor() *BandwidthMonitor {
+	return &BandwidthMonitor{
+		sampledAt: time.Now(),
+	}
+}
+
+// Bitrates returns the outgoing and incoming media bitrates in bits per second.
+func (m *BandwidthMonitor) Bitrates() (sent uint64, received uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(m.sampledAt)
+	if elapsed < bandwidthSampleWindow {
+		return m.sentRate, m.receivedRate
+	}
+
+	sentBytes, receivedBytes := m.sentBytes.Load(), m.receivedBytes.Load()
+	m.sentRate = uint64(float64(sentBytes-m.lastSent) * 8 / elapsed.Seconds())
+	m.receivedRate = uint64(float64(receivedBytes-m.lastReceived) * 8 / elapsed.Seconds())
+	m.lastSent, m.lastReceived = sentBytes, receivedBytes
+	m.sampledAt = now
+	return m.sentRate, m.receivedRate
+}
+
+// ReceivedBytes returns the number of media bytes received since the monitor was created.
+func (m *BandwidthMonitor) ReceivedBytes() uint64 {
+	return m.receivedBytes.Load()
+}
+
+// RemoteEstimate returns the latest available bitrate estimate (REMB) received from the remote peer,
+// in bits per second, 0 if none was received.
+func (m *BandwidthMonitor) RemoteEstimate() uint64 {
+	return m.remoteBitrate.Load()
+}
+
+// SendSideEstimate returns the available bitrate estimated from the TWCC feedback of the remote peer,
+// in bits per second, 0 if no feedback was received or no estimator is set.
+func (m *BandwidthMonitor) SendSideEstimate() uint64 {
+	if !m.twccFeedback.Load() {
+		return 0
+	}
+	m.estimatorLock.Lock()
+	estimator := m.estimator
+	m.estimatorLock.Unlock()
+	if estimator == nil {
+		return 0
+	}
+	return uint64(max(estimator.GetTargetBitrate(), 0))
+}
+
+func (m *BandwidthMonitor) setEstimator(estimator cc.BandwidthEstimator) {
+	m.estimatorLock.Lock()
+	m.estimator = estimator
+	m.estimatorLock.Unlock()
+}
+
+// NewSendSideBWEInterceptorFactory returns a congestion controller that estimates the available outgoing bitrate
+// from TWCC feedback, reported by monitor's SendSideEstimate. It does not pace, packets are written right away.
+// Outgoing packets need transport wide sequence numbers, i.e. twcc.NewHeaderExtensionInterceptor added after it.
+func NewSendSideBWEInterceptorFactory(monitor *BandwidthMonitor) (*cc.InterceptorFactory, error) {
+	factory, err := cc.NewInterceptor(func() (cc.BandwidthEstimator, error) {
+		return gcc.NewSendSideBWE(
+			gcc.SendSideBWEInitialBitrate(sendSideInitialBitrate),
+			gcc.SendSideBWEPacer(gcc.NewNoOpPacer()),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+	factory.OnNewPeerConnection(func(_ string, estimator cc.BandwidthEstimator) {
+		monitor.setEstimator(estimator)
+	})
+	return factory, nil
+}
+
+type BandwidthInterceptorFactory struct {
+	monitor *BandwidthMonitor
+}
+
+func NewBandwidthInterceptorFactory(monitor *BandwidthMonitor) *BandwidthInterceptorFactory {
+	return &BandwidthInterceptorFactory{
+		monitor: monitor,
+	}
+}
+
+func (b *BandwidthInterceptorFactory) NewInterceptor(_ string) (interceptor.Interceptor, error) {
+	return &BandwidthInterceptor{monitor: b.monitor}, nil
+}
+
+type BandwidthInterceptor struct {
+	interceptor.NoOp
+
+	monitor *BandwidthMonitor
+}
+
+func (b *BandwidthInterceptor) BindLocalStream(_ *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+		n, err := writer.Write(header, payload, attributes)
+		if err == nil {
+			b.monitor.sentBytes.Add(uint64(n))
+		}
+		return n, err
+	})
+}
+
+func (b *BandwidthInterceptor) BindRemoteStream(_ *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	return interceptor.RTPReaderFunc(func(buf []byte, attributes interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, attr, err := reader.Read(buf, attributes)
+		if err == nil {
+			b.monitor.receivedBytes.Add(uint64(n))
+		}
+		return n, attr, err
+	})
+}
+
+func (b *BandwidthInterceptor) BindRTCPReader(reader interceptor.RTCPReader) interceptor.RTCPReader {
+	return interceptor.RTCPReaderFunc(func(buf []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+		i, attr, err := reader.Read(buf, a)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if attr == nil {
+			attr = make(interceptor.Attributes)
+		}
+		pkts, err := attr.GetRTCPPackets(buf[:i])
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, packet := range pkts {
+			switch pkt := packet.(type) {
+			case *rtcp.ReceiverEstimatedMaximumBitrate:
+				b.monitor.remoteBitrate.Store(uint64(pkt.Bitrate))
+			case *rtcp.TransportLayerCC:
+				b.monitor.twccFeedback.Store(true)
+			}
+		}
+
+		return i, attr, err
+	})
+}
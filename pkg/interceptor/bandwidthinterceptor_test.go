@@ -0,0 +1,90 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/twcc"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSideBandwidthEstimate(t *testing.T) {
+	monitor := NewBandwidthMonitor()
+	bwe, err := NewSendSideBWEInterceptorFactory(monitor)
+	require.NoError(t, err)
+	headerExtension, err := twcc.NewHeaderExtensionInterceptor()
+	require.NoError(t, err)
+
+	var chain []interceptor.Interceptor
+	for _, f := range []interceptor.Factory{bwe, NewBandwidthInterceptorFactory(monitor), headerExtension} {
+		i, err := f.NewInterceptor("")
+		require.NoError(t, err)
+		chain = append(chain, i)
+	}
+	stream := NewMockStream(&interceptor.StreamInfo{
+		SSRC: 1,
+		RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+			{URI: "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01", ID: 1},
+		},
+	}, interceptor.NewChain(chain))
+	defer func() {
+		require.NoError(t, stream.Close())
+	}()
+
+	const packets = 10
+	var first uint16
+	for i := 0; i < packets; i++ {
+		require.NoError(t, stream.WriteRTP(&rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: uint16(i)}, Payload: make([]byte, 1000)}))
+		written := <-stream.WrittenRTP()
+		var ext rtp.TransportCCExtension
+		require.NoError(t, ext.Unmarshal(written.GetExtension(1)))
+		if i == 0 {
+			first = ext.TransportSequence
+		}
+	}
+	// no feedback received yet
+	require.Zero(t, monitor.SendSideEstimate())
+
+	deltas := make([]*rtcp.RecvDelta, packets)
+	for i := range deltas {
+		deltas[i] = &rtcp.RecvDelta{Type: rtcp.TypeTCCPacketReceivedSmallDelta, Delta: int64(time.Millisecond / time.Microsecond)}
+	}
+	feedback := &rtcp.TransportLayerCC{
+		MediaSSRC:          1,
+		BaseSequenceNumber: first,
+		PacketStatusCount:  packets,
+		PacketChunks: []rtcp.PacketStatusChunk{&rtcp.RunLengthChunk{
+			PacketStatusSymbol: rtcp.TypeTCCPacketReceivedSmallDelta,
+			RunLength:          packets,
+		}},
+		RecvDeltas: deltas,
+	}
+	feedback.Header = rtcp.Header{
+		Padding: feedback.Len()%4 != 0,
+		Count:   rtcp.FormatTCC,
+		Type:    rtcp.TypeTransportSpecificFeedback,
+		Length:  uint16((feedback.Len()+3)/4 - 1),
+	}
+	stream.ReceiveRTCP([]rtcp.Packet{feedback})
+	require.NoError(t, (<-stream.ReadRTCP()).Err)
+	require.NotZero(t, monitor.SendSideEstimate())
+	// REMB is not needed for an estimate
+	require.Zero(t, monitor.RemoteEstimate())
+}
@@ -25,6 +25,9 @@ import (
 )
 
 type NackGeneratorInterceptorFactory struct {
+	// MaxPairsPerPacket splits NACKs into multiple RTCP packets, unlimited when zero
+	MaxPairsPerPacket int
+
 	lock         sync.Mutex
 	interceptors []*NackGeneratorInterceptor
 }
@@ -35,6 +38,7 @@ func (g *NackGeneratorInterceptorFactory) NewInterceptor(id string) (interceptor
 	if err != nil {
 		return nil, err
 	}
+	i.maxPairsPerPacket = g.MaxPairsPerPacket
 
 	g.lock.Lock()
 	g.interceptors = append(g.interceptors, i)
@@ -42,6 +46,21 @@ func (g *NackGeneratorInterceptorFactory) NewInterceptor(id string) (interceptor
 	return i, err
 }
 
+// SetStreamParams replaces the NACK queue of a bound remote stream, nil disables NACKs for the stream.
+// Returns false when the stream is not bound.
+func (g *NackGeneratorInterceptorFactory) SetStreamParams(ssrc uint32, params *nack.NackQueueParams) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	found := false
+	for _, i := range g.interceptors {
+		if i.SetStreamParams(ssrc, params) {
+			found = true
+		}
+	}
+	return found
+}
+
 func (g *NackGeneratorInterceptorFactory) SetRTT(rtt uint32) {
 	g.lock.Lock()
 	defer g.lock.Unlock()
@@ -53,14 +72,19 @@ func (g *NackGeneratorInterceptorFactory) SetRTT(rtt uint32) {
 
 type NackGeneratorInterceptor struct {
 	interceptor.NoOp
-	lock       sync.Mutex
-	writer     atomic.Value
+	lock   sync.Mutex
+	writer atomic.Value
+	// queue of each remote stream, nil when NACKs are disabled for the stream
 	nackQueues map[uint32]*nack.NackQueue
+	rtt        uint32
+
+	maxPairsPerPacket int
 }
 
 func NewNackGeneratorInterceptor() (*NackGeneratorInterceptor, error) {
 	n := &NackGeneratorInterceptor{
 		nackQueues: make(map[uint32]*nack.NackQueue),
+		rtt:        70,
 	}
 
 	return n, nil
@@ -70,9 +94,36 @@ func (n *NackGeneratorInterceptor) SetRTT(rtt uint32) {
 	n.lock.Lock()
 	defer n.lock.Unlock()
 
+	n.rtt = rtt
 	for _, q := range n.nackQueues {
-		q.SetRTT(rtt)
+		if q != nil {
+			q.SetRTT(rtt)
+		}
+	}
+}
+
+// SetStreamParams replaces the NACK queue of a bound remote stream, pending NACKs are dropped.
+// nil disables NACKs for the stream. Returns false when the stream is not bound.
+func (n *NackGeneratorInterceptor) SetStreamParams(ssrc uint32, params *nack.NackQueueParams) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if _, ok := n.nackQueues[ssrc]; !ok {
+		return false
+	}
+	var q *nack.NackQueue
+	if params != nil {
+		q = nack.NewNACKQueue(*params)
+		q.SetRTT(n.rtt)
 	}
+	n.nackQueues[ssrc] = q
+	return true
+}
+
+func (n *NackGeneratorInterceptor) nackQueue(ssrc uint32) *nack.NackQueue {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.nackQueues[ssrc]
 }
 
 func (n *NackGeneratorInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
@@ -88,8 +139,8 @@ func (n *NackGeneratorInterceptor) BindRemoteStream(info *interceptor.StreamInfo
 	nackQueue := nack.NewNACKQueue(nack.NackQueueParamsDefault)
 	n.lock.Lock()
 	n.nackQueues[info.SSRC] = nackQueue
+	nackQueue.SetRTT(n.rtt)
 	n.lock.Unlock()
-	nackQueue.SetRTT(70)
 	var (
 		firstReceived bool
 		highestSeq    uint16
@@ -110,25 +161,28 @@ func (n *NackGeneratorInterceptor) BindRemoteStream(info *interceptor.StreamInfo
 			return 0, nil, err
 		}
 
+		// the queue is replaced by SetStreamParams
+		nackQueue := n.nackQueue(ssrc)
 		if !firstReceived {
 			firstReceived = true
 			highestSeq = header.SequenceNumber
 		} else {
-			nackQueue.Remove(header.SequenceNumber)
+			if nackQueue != nil {
+				nackQueue.Remove(header.SequenceNumber)
+			}
 			if diff := header.SequenceNumber - highestSeq; diff > 0 && diff < 0x8000 {
-				for seq := highestSeq + 1; seq < header.SequenceNumber; seq++ {
+				for seq := highestSeq + 1; nackQueue != nil && seq < header.SequenceNumber; seq++ {
 					nackQueue.Push(seq)
 				}
 				highestSeq = header.SequenceNumber
 			}
 		}
 
+		if nackQueue == nil {
+			return i, attr, nil
+		}
 		if nacks, _ := nackQueue.Pairs(); len(nacks) > 0 {
-			pkts := []rtcp.Packet{&rtcp.TransportLayerNack{
-				SenderSSRC: ssrc,
-				MediaSSRC:  ssrc,
-				Nacks:      nacks,
-			}}
+			pkts := nackPackets(ssrc, nacks, n.maxPairsPerPacket)
 			if w := n.writer.Load(); w != nil {
 				w.(interceptor.RTCPWriter).Write(pkts, nil)
 			}
@@ -144,6 +198,23 @@ func (n *NackGeneratorInterceptor) UnbindRemoteStream(info *interceptor.StreamIn
 	delete(n.nackQueues, info.SSRC)
 }
 
+func nackPackets(ssrc uint32, nacks []rtcp.NackPair, maxPairs int) []rtcp.Packet {
+	if maxPairs <= 0 {
+		maxPairs = len(nacks)
+	}
+	pkts := make([]rtcp.Packet, 0, (len(nacks)+maxPairs-1)/maxPairs)
+	for len(nacks) > 0 {
+		batch := nacks[:min(maxPairs, len(nacks))]
+		nacks = nacks[len(batch):]
+		pkts = append(pkts, &rtcp.TransportLayerNack{
+			SenderSSRC: ssrc,
+			MediaSSRC:  ssrc,
+			Nacks:      batch,
+		})
+	}
+	return pkts
+}
+
 func streamSupportNack(info *interceptor.StreamInfo) bool {
 	for _, fb := range info.RTCPFeedback {
 		if fb.Type == "nack" && fb.Parameter == "" {
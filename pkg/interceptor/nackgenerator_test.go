@@ -66,3 +66,43 @@ func TestGeneratorInterceptor(t *testing.T) {
 		t.Fatal("written rtcp packet not found")
 	}
 }
+
+func TestNackPackets(t *testing.T) {
+	nacks := []rtcp.NackPair{{PacketID: 1}, {PacketID: 20}, {PacketID: 40}}
+
+	require.Len(t, nackPackets(1, nacks, 0), 1)
+
+	pkts := nackPackets(1, nacks, 2)
+	require.Len(t, pkts, 2)
+	require.Len(t, pkts[0].(*rtcp.TransportLayerNack).Nacks, 2)
+	require.Equal(t, uint16(40), pkts[1].(*rtcp.TransportLayerNack).Nacks[0].PacketID)
+}
+
+func TestGeneratorStreamParams(t *testing.T) {
+	f := &NackGeneratorInterceptorFactory{}
+	require.False(t, f.SetStreamParams(1, nil))
+
+	i, err := f.NewInterceptor("")
+	require.NoError(t, err)
+
+	stream := NewMockStream(&interceptor.StreamInfo{
+		SSRC:         1,
+		RTCPFeedback: []interceptor.RTCPFeedback{{Type: "nack"}},
+	}, i)
+	defer func() {
+		require.NoError(t, stream.Close())
+	}()
+
+	// NACKs are disabled for the stream
+	require.True(t, f.SetStreamParams(1, nil))
+	for _, seqNum := range []uint16{10, 12, 14} {
+		stream.ReceiveRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: seqNum}})
+		<-stream.ReadRTP()
+	}
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case pkts := <-stream.WrittenRTCP():
+		t.Fatalf("unexpected rtcp packets %v", pkts)
+	default:
+	}
+}
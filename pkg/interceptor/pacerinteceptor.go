@@ -1,7 +1,9 @@
 package interceptor
 
 import (
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/pion/interceptor"
 	"github.com/pion/rtp"
@@ -9,26 +11,68 @@ import (
 	"github.com/livekit/mediatransportutil/pkg/pacer"
 )
 
+// PacerDelayMonitor passes the time paced packets spent queued and being written to the handler registered for their SSRC.
+type PacerDelayMonitor struct {
+	handlers sync.Map // uint32 -> func(queued, write time.Duration)
+}
+
+func NewPacerDelayMonitor() *PacerDelayMonitor {
+	return &PacerDelayMonitor{}
+}
+
+func (m *PacerDelayMonitor) Register(ssrc uint32, handler func(queued, write time.Duration)) {
+	m.handlers.Store(ssrc, handler)
+}
+
+func (m *PacerDelayMonitor) Unregister(ssrc uint32) {
+	m.handlers.Delete(ssrc)
+}
+
+func (m *PacerDelayMonitor) handler(ssrc uint32) func(queued, write time.Duration) {
+	if m == nil {
+		return nil
+	}
+	if h, ok := m.handlers.Load(ssrc); ok {
+		return h.(func(queued, write time.Duration))
+	}
+	return nil
+}
+
 type PacerInterceptorFactory struct {
-	pacer pacer.Factory
-	pool  *PacketPool
+	pacer       pacer.Factory
+	pool        *PacketPool
+	monitor     *PacerDelayMonitor
+	bypassAudio bool
 }
 
 func NewPacerInterceptorFactory(pacer pacer.Factory) *PacerInterceptorFactory {
+	return NewPacerInterceptorFactoryWithMonitor(pacer, nil)
+}
+
+// NewPacerInterceptorFactoryWithMonitor creates a pacer interceptor reporting packet delays to the monitor
+func NewPacerInterceptorFactoryWithMonitor(pacer pacer.Factory, monitor *PacerDelayMonitor) *PacerInterceptorFactory {
 	return &PacerInterceptorFactory{
-		pacer: pacer,
-		pool:  NewPacketPool(500, 1500),
+		pacer:   pacer,
+		pool:    NewPacketPool(500, 1500),
+		monitor: monitor,
 	}
 }
 
+// BypassAudio sends audio packets immediately instead of queueing them behind video packets
+func (p *PacerInterceptorFactory) BypassAudio() {
+	p.bypassAudio = true
+}
+
 func (p *PacerInterceptorFactory) NewInterceptor(id string) (interceptor.Interceptor, error) {
 	pacer, err := p.pacer.NewPacer()
 	if err != nil {
 		return nil, err
 	}
 	return &PacerInterceptor{
-		pacer: pacer,
-		pool:  p.pool,
+		pacer:       pacer,
+		pool:        p.pool,
+		monitor:     p.monitor,
+		bypassAudio: p.bypassAudio,
 	}, nil
 }
 
@@ -36,8 +80,10 @@ func (p *PacerInterceptorFactory) NewInterceptor(id string) (interceptor.Interce
 type PacerInterceptor struct {
 	interceptor.NoOp
 
-	pool  *PacketPool
-	pacer pacer.Pacer
+	pool        *PacketPool
+	pacer       pacer.Pacer
+	monitor     *PacerDelayMonitor
+	bypassAudio bool
 }
 
 // BindRTCPWriter lets you modify any outgoing RTCP packets. It is called once per PeerConnection. The returned method
@@ -48,6 +94,10 @@ func (pi *PacerInterceptor) BindRTCPWriter(writer interceptor.RTCPWriter) interc
 }
 
 func (pi *PacerInterceptor) BindLocalStream(stream *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	if pi.bypassAudio && strings.HasPrefix(strings.ToLower(stream.MimeType), "audio/") {
+		return writer
+	}
+
 	var (
 		lock         sync.Mutex
 		lastSeq      uint16
@@ -90,10 +140,20 @@ func (pi *PacerInterceptor) BindLocalStream(stream *interceptor.StreamInfo, writ
 		var headCopy rtp.Header
 		headCopy.Unmarshal(buf)
 
+		pktWriter := pacerWriter
+		if onDelay := pi.monitor.handler(stream.SSRC); onDelay != nil {
+			enqueued := time.Now()
+			pktWriter = func(header *rtp.Header, payload []byte) (int, error) {
+				start := time.Now()
+				n, err := pacerWriter(header, payload)
+				onDelay(start.Sub(enqueued), time.Since(start))
+				return n, err
+			}
+		}
 		pkt := &pacer.Packet{
 			Header:     &headCopy,
 			Payload:    buf[n:pktSize],
-			Writer:     pacerWriter,
+			Writer:     pktWriter,
 			Pool:       pool,
 			PoolEntity: poolEntity,
 		}
@@ -0,0 +1,87 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"sync"
+
+	"github.com/pion/interceptor"
+)
+
+// PayloadTypeMonitor reports payload type changes of received streams to the handler registered for their SSRC,
+// e.g. when the server switches the codec of a subscription.
+type PayloadTypeMonitor struct {
+	handlers sync.Map // uint32 -> func(payloadType uint8)
+}
+
+func NewPayloadTypeMonitor() *PayloadTypeMonitor {
+	return &PayloadTypeMonitor{}
+}
+
+// Register sets the handler of a stream. It is called from the reading goroutine,
+// before the first packet with the new payload type is returned.
+func (m *PayloadTypeMonitor) Register(ssrc uint32, handler func(payloadType uint8)) {
+	m.handlers.Store(ssrc, handler)
+}
+
+func (m *PayloadTypeMonitor) Unregister(ssrc uint32) {
+	m.handlers.Delete(ssrc)
+}
+
+func (m *PayloadTypeMonitor) handler(ssrc uint32) func(payloadType uint8) {
+	if h, ok := m.handlers.Load(ssrc); ok {
+		return h.(func(payloadType uint8))
+	}
+	return nil
+}
+
+type PayloadTypeInterceptorFactory struct {
+	monitor *PayloadTypeMonitor
+}
+
+func NewPayloadTypeInterceptorFactory(monitor *PayloadTypeMonitor) *PayloadTypeInterceptorFactory {
+	return &PayloadTypeInterceptorFactory{
+		monitor: monitor,
+	}
+}
+
+func (f *PayloadTypeInterceptorFactory) NewInterceptor(_ string) (interceptor.Interceptor, error) {
+	return &PayloadTypeInterceptor{monitor: f.monitor}, nil
+}
+
+type PayloadTypeInterceptor struct {
+	interceptor.NoOp
+
+	monitor *PayloadTypeMonitor
+}
+
+func (p *PayloadTypeInterceptor) BindRemoteStream(info *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+	ssrc := info.SSRC
+	payloadType := uint8(info.PayloadType)
+	return interceptor.RTPReaderFunc(func(buf []byte, attributes interceptor.Attributes) (int, interceptor.Attributes, error) {
+		n, attr, err := reader.Read(buf, attributes)
+		if err != nil || n < 2 {
+			return n, attr, err
+		}
+
+		if pt := buf[1] & 0x7f; pt != payloadType {
+			payloadType = pt
+			if handler := p.monitor.handler(ssrc); handler != nil {
+				handler(pt)
+			}
+		}
+		return n, attr, err
+	})
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadTypeInterceptor(t *testing.T) {
+	monitor := NewPayloadTypeMonitor()
+	var changes []uint8
+	monitor.Register(1, func(payloadType uint8) {
+		changes = append(changes, payloadType)
+	})
+
+	i, err := NewPayloadTypeInterceptorFactory(monitor).NewInterceptor("")
+	require.NoError(t, err)
+	stream := NewMockStream(&interceptor.StreamInfo{SSRC: 1, PayloadType: 96}, i)
+	defer func() {
+		require.NoError(t, stream.Close())
+	}()
+
+	for _, pt := range []uint8{96, 96, 102, 102, 96} {
+		stream.ReceiveRTP(&rtp.Packet{Header: rtp.Header{PayloadType: pt}})
+		res := <-stream.ReadRTP()
+		require.NoError(t, res.Err)
+	}
+	require.Equal(t, []uint8{102, 96}, changes)
+}
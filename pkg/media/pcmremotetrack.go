@@ -4,12 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/livekit/media-sdk"
 	"github.com/livekit/media-sdk/opus"
 	"github.com/livekit/media-sdk/rtp"
 	protoLogger "github.com/livekit/protocol/logger"
 	"github.com/pion/webrtc/v4"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+	"github.com/livekit/server-sdk-go/v2/pkg/sampletime"
 )
 
 type PCMRemoteTrackWriter interface {
@@ -20,6 +24,14 @@ type PCMRemoteTrackWriter interface {
 type internalPCMRemoteTrackWriter struct {
 	PCMRemoteTrackWriter
 	sampleRate int
+	meter      *lksdk.AudioLevelMeter
+}
+
+func (w *internalPCMRemoteTrackWriter) WriteSample(sample media.PCM16Sample) error {
+	if w.meter != nil {
+		w.meter.ObservePCM(sample)
+	}
+	return w.PCMRemoteTrackWriter.WriteSample(sample)
 }
 
 func (w *internalPCMRemoteTrackWriter) SampleRate() int {
@@ -35,6 +47,8 @@ type PCMRemoteTrackParams struct {
 	TargetSampleRate int
 	TargetChannels   int
 	Decryptor        Decryptor
+	AudioLevelMeter  *lksdk.AudioLevelMeter
+	Stamper          *sampletime.Stamper
 }
 
 type PCMRemoteTrackOption func(*PCMRemoteTrackParams)
@@ -63,6 +77,21 @@ func WithDecryptor(decryptor Decryptor) PCMRemoteTrackOption {
 	}
 }
 
+// WithAudioLevelMeter measures the decoded samples with meter, e.g. RemoteTrackPublication.AudioLevelMeter
+func WithAudioLevelMeter(meter *lksdk.AudioLevelMeter) PCMRemoteTrackOption {
+	return func(p *PCMRemoteTrackParams) {
+		p.AudioLevelMeter = meter
+	}
+}
+
+// WithSampleStamper stamps every received packet with its capture and receive time, e.g. to write a sidecar
+// file for compliance recordings. RTCP of the track has to be passed to the stamper, see sampletime.Stamper.
+func WithSampleStamper(stamper *sampletime.Stamper) PCMRemoteTrackOption {
+	return func(p *PCMRemoteTrackParams) {
+		p.Stamper = stamper
+	}
+}
+
 type PCMRemoteTrack struct {
 	trackRemote *webrtc.TrackRemote
 	channels    int
@@ -75,6 +104,7 @@ type PCMRemoteTrack struct {
 	logger             protoLogger.Logger
 
 	decryptor Decryptor
+	stamper   *sampletime.Stamper
 }
 
 // PCMRemoteTrack takes a remote track (currently only opus is supported)
@@ -107,6 +137,7 @@ func NewPCMRemoteTrack(track *webrtc.TrackRemote, writer PCMRemoteTrackWriter, o
 	internalWriter := &internalPCMRemoteTrackWriter{
 		PCMRemoteTrackWriter: writer,
 		sampleRate:           targetSampleRate,
+		meter:                options.AudioLevelMeter,
 	}
 
 	// resampledPCMWriter resamples the PCM16 samples from DefaultOpusSampleRate to targetSampleRate and
@@ -140,6 +171,7 @@ func NewPCMRemoteTrack(track *webrtc.TrackRemote, writer PCMRemoteTrackWriter, o
 		logger:             protoLogger.GetLogger(),
 		isResampled:        isResampled,
 		decryptor:          options.Decryptor,
+		stamper:            options.Stamper,
 	}
 
 	go t.processSamples(options.HandleJitter)
@@ -162,6 +194,10 @@ func (t *PCMRemoteTrack) processSamples(handleJitter bool) {
 	if handleJitter {
 		hc = rtp.HandleJitter(hc)
 	}
+	if t.stamper != nil {
+		// stamped on arrival, before the jitter buffer delays packets
+		hc = &stampingHandler{HandlerCloser: hc, stamper: t.stamper, logger: t.logger}
+	}
 
 	// HandleLoop takes RTP packets from the track and writes them to the handler
 	// TODO(anunaym14): handle concealment
@@ -198,3 +234,19 @@ func (hc handlerCloser) HandleRTP(hdr *rtp.Header, payload []byte) error {
 }
 
 func (handlerCloser) Close() {}
+
+type stampingHandler struct {
+	rtp.HandlerCloser
+	stamper *sampletime.Stamper
+	logger  protoLogger.Logger
+	failed  bool
+}
+
+func (s *stampingHandler) HandleRTP(hdr *rtp.Header, payload []byte) error {
+	if _, err := s.stamper.Stamp(hdr.Timestamp, time.Now()); err != nil && !s.failed {
+		// logged once, the sidecar is likely not writable anymore
+		s.failed = true
+		s.logger.Warnw("could not write sample timestamps", err)
+	}
+	return s.HandlerCloser.HandleRTP(hdr, payload)
+}
@@ -0,0 +1,28 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"errors"
+)
+
+var (
+	ErrTopicNotRegistered     = errors.New("topic is not registered")
+	ErrTopicAlreadyRegistered = errors.New("topic is already registered")
+	ErrTypeMismatch           = errors.New("message type does not match the registered type")
+	ErrUnsupportedVersion     = errors.New("message version is newer than the registered version")
+	ErrUnsupportedEncoding    = errors.New("message encoding does not match the registered codec")
+	ErrNotProtoMessage        = errors.New("message does not implement proto.Message")
+)
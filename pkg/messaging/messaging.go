@@ -0,0 +1,266 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package messaging provides typed messages on top of data packets. Go types are registered
+// per topic, values are validated and wrapped in a versioned JSON envelope before publishing,
+// and received packets are decoded and delivered to the registered handler.
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/livekit/protocol/logger"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+// Validator is implemented by message types that check their own content.
+// It is called before publishing and after decoding.
+type Validator interface {
+	Validate() error
+}
+
+type envelope struct {
+	Version  int             `json:"v"`
+	Encoding string          `json:"enc"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+type topicHandler struct {
+	msgType   reflect.Type
+	version   int
+	codec     Codec
+	validate  func(v any) error
+	handle    func(v any, params lksdk.DataReceiveParams)
+	newObject func() any
+}
+
+// Messenger publishes and dispatches typed messages for a room.
+// Received packets must be passed to HandleDataPacket, usually from RoomCallback.OnDataPacket.
+type Messenger struct {
+	room    *lksdk.Room
+	logger  logger.Logger
+	onError func(topic string, err error, params lksdk.DataReceiveParams)
+
+	lock   sync.RWMutex
+	topics map[string]*topicHandler
+}
+
+type MessengerOption func(*Messenger)
+
+// WithLogger sets the logger for the Messenger.
+func WithLogger(logger logger.Logger) MessengerOption {
+	return func(m *Messenger) {
+		m.logger = logger
+	}
+}
+
+// WithErrorHandler sets a function called when a received message cannot be decoded or fails validation.
+func WithErrorHandler(f func(topic string, err error, params lksdk.DataReceiveParams)) MessengerOption {
+	return func(m *Messenger) {
+		m.onError = f
+	}
+}
+
+func NewMessenger(room *lksdk.Room, opts ...MessengerOption) *Messenger {
+	m := &Messenger{
+		room:   room,
+		logger: logger.GetLogger(),
+		topics: make(map[string]*topicHandler),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+type topicOptions struct {
+	version   int
+	codec     Codec
+	validator func(v any) error
+}
+
+type TopicOption func(*topicOptions)
+
+// WithVersion sets the version of the message schema, messages with a newer version are rejected.
+// Default is 1.
+func WithVersion(version int) TopicOption {
+	return func(o *topicOptions) {
+		o.version = version
+	}
+}
+
+// WithCodec sets how messages are encoded, default is JSONCodec.
+func WithCodec(codec Codec) TopicOption {
+	return func(o *topicOptions) {
+		o.codec = codec
+	}
+}
+
+// WithValidator adds a validation function for messages of a topic, in addition to Validator.
+func WithValidator[T any](f func(msg *T) error) TopicOption {
+	return func(o *topicOptions) {
+		o.validator = func(v any) error {
+			return f(v.(*T))
+		}
+	}
+}
+
+// Register sets the message type and handler for a topic. Handler can be nil for topics that are only published.
+func Register[T any](m *Messenger, topic string, handler func(msg *T, params lksdk.DataReceiveParams), opts ...TopicOption) error {
+	o := &topicOptions{
+		version: 1,
+		codec:   JSONCodec,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	h := &topicHandler{
+		msgType: reflect.TypeFor[*T](),
+		version: o.version,
+		codec:   o.codec,
+		validate: func(v any) error {
+			if val, ok := v.(Validator); ok {
+				if err := val.Validate(); err != nil {
+					return err
+				}
+			}
+			if o.validator != nil {
+				return o.validator(v)
+			}
+			return nil
+		},
+		newObject: func() any {
+			return new(T)
+		},
+	}
+	if handler != nil {
+		h.handle = func(v any, params lksdk.DataReceiveParams) {
+			handler(v.(*T), params)
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.topics[topic]; ok {
+		return ErrTopicAlreadyRegistered
+	}
+	m.topics[topic] = h
+	return nil
+}
+
+// Unregister removes the type and handler of a topic.
+func (m *Messenger) Unregister(topic string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.topics, topic)
+}
+
+func (m *Messenger) getTopic(topic string) *topicHandler {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.topics[topic]
+}
+
+// Encode validates and encodes msg for a registered topic.
+func Encode[T any](m *Messenger, topic string, msg *T) ([]byte, error) {
+	h := m.getTopic(topic)
+	if h == nil {
+		return nil, ErrTopicNotRegistered
+	}
+	if reflect.TypeOf(msg) != h.msgType {
+		return nil, ErrTypeMismatch
+	}
+	if err := h.validate(msg); err != nil {
+		return nil, err
+	}
+
+	payload, err := h.codec.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&envelope{
+		Version:  h.version,
+		Encoding: h.codec.Name(),
+		Payload:  payload,
+	})
+}
+
+// Publish validates, encodes and sends msg on a registered topic. Messages are sent reliably
+// unless overridden with lksdk.WithDataPublishReliable.
+func Publish[T any](m *Messenger, topic string, msg *T, opts ...lksdk.DataPublishOption) error {
+	data, err := Encode(m, topic, msg)
+	if err != nil {
+		return err
+	}
+
+	opts = append([]lksdk.DataPublishOption{lksdk.WithDataPublishReliable(true)}, opts...)
+	opts = append(opts, lksdk.WithDataPublishTopic(topic))
+	return m.room.LocalParticipant.PublishDataPacket(lksdk.UserData(data), opts...)
+}
+
+// HandleDataPacket decodes user packets on registered topics and passes them to the handler.
+// It returns false for packets that are not handled by the Messenger.
+func (m *Messenger) HandleDataPacket(packet lksdk.DataPacket, params lksdk.DataReceiveParams) bool {
+	user, ok := packet.(*lksdk.UserDataPacket)
+	if !ok {
+		return false
+	}
+	h := m.getTopic(user.Topic)
+	if h == nil {
+		return false
+	}
+
+	msg, err := h.decode(user.Payload)
+	if err != nil {
+		m.logger.Debugw("could not decode message", "topic", user.Topic, "sender", params.SenderIdentity, "error", err)
+		if m.onError != nil {
+			m.onError(user.Topic, err, params)
+		}
+		return true
+	}
+	if h.handle != nil {
+		h.handle(msg, params)
+	}
+	return true
+}
+
+func (h *topicHandler) decode(data []byte) (any, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	if env.Version > h.version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, env.Version)
+	}
+	if env.Encoding != h.codec.Name() {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, env.Encoding)
+	}
+
+	msg := h.newObject()
+	if err := h.codec.Unmarshal(env.Payload, msg); err != nil {
+		return nil, err
+	}
+	if err := h.validate(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
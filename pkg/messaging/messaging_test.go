@@ -0,0 +1,89 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messaging
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+type cursor struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (c *cursor) Validate() error {
+	if c.X < 0 || c.Y < 0 {
+		return errors.New("negative position")
+	}
+	return nil
+}
+
+func TestMessenger(t *testing.T) {
+	var (
+		received []*cursor
+		errs     []error
+	)
+	m := NewMessenger(nil, WithErrorHandler(func(topic string, err error, params lksdk.DataReceiveParams) {
+		errs = append(errs, err)
+	}))
+	require.NoError(t, Register(m, "cursor", func(msg *cursor, params lksdk.DataReceiveParams) {
+		received = append(received, msg)
+	}))
+	require.ErrorIs(t, Register[cursor](m, "cursor", nil), ErrTopicAlreadyRegistered)
+
+	data, err := Encode(m, "cursor", &cursor{X: 1, Y: 2})
+	require.NoError(t, err)
+	require.True(t, m.HandleDataPacket(&lksdk.UserDataPacket{Topic: "cursor", Payload: data}, lksdk.DataReceiveParams{}))
+	require.Equal(t, []*cursor{{X: 1, Y: 2}}, received)
+
+	_, err = Encode(m, "cursor", &cursor{X: -1})
+	require.Error(t, err)
+	_, err = Encode(m, "other", &cursor{})
+	require.ErrorIs(t, err, ErrTopicNotRegistered)
+
+	require.True(t, m.HandleDataPacket(&lksdk.UserDataPacket{Topic: "cursor", Payload: []byte(`{"v":2,"enc":"json","payload":{}}`)}, lksdk.DataReceiveParams{}))
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], ErrUnsupportedVersion)
+
+	require.False(t, m.HandleDataPacket(&lksdk.UserDataPacket{Topic: "other"}, lksdk.DataReceiveParams{}))
+}
+
+func TestMessengerProto(t *testing.T) {
+	var received *livekit.ChatMessage
+	m := NewMessenger(nil)
+	require.NoError(t, Register(m, "chat", func(msg *livekit.ChatMessage, params lksdk.DataReceiveParams) {
+		received = msg
+	}, WithCodec(ProtoCodec), WithValidator(func(msg *livekit.ChatMessage) error {
+		if msg.Message == "" {
+			return errors.New("empty message")
+		}
+		return nil
+	})))
+
+	data, err := Encode(m, "chat", &livekit.ChatMessage{Id: "1", Message: "hello"})
+	require.NoError(t, err)
+	require.True(t, m.HandleDataPacket(&lksdk.UserDataPacket{Topic: "chat", Payload: data}, lksdk.DataReceiveParams{}))
+	require.Equal(t, "hello", received.Message)
+
+	_, err = Encode(m, "chat", &livekit.ChatMessage{Id: "2"})
+	require.Error(t, err)
+}
@@ -0,0 +1,387 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtspingest
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRTSPPort       = "554"
+	defaultSessionTimeout = 60 * time.Second
+	userAgent             = "livekit-server-sdk-go"
+	// largest response body accepted, SDP descriptions are a few KiB
+	maxContentLength = 1 << 20
+)
+
+type response struct {
+	status int
+	reason string
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// client is a minimal RTSP client receiving the streams interleaved on the RTSP connection (RTP over TCP),
+// which works through NAT and firewalls without opening UDP ports.
+type client struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	url     *url.URL
+	user    *url.Userinfo
+	timeout time.Duration
+
+	writeLock sync.Mutex
+	cseq      int
+	auth      *authChallenge
+	session   string
+	// the server closes the session when it hears nothing for this long
+	sessionTimeout time.Duration
+}
+
+func dial(rawURL string, timeout time.Duration) (*client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "rtsp" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedURL, u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), defaultRTSPPort)
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	user := u.User
+	u.User = nil
+	return &client{
+		conn:           conn,
+		reader:         bufio.NewReader(conn),
+		url:            u,
+		user:           user,
+		timeout:        timeout,
+		sessionTimeout: defaultSessionTimeout,
+	}, nil
+}
+
+func (c *client) close() error {
+	return c.conn.Close()
+}
+
+// describe returns the SDP of the stream and the base URL of its tracks
+func (c *client) describe() ([]byte, *url.URL, error) {
+	res, err := c.do("DESCRIBE", c.url.String(), map[string]string{"Accept": "application/sdp"})
+	if err != nil {
+		return nil, nil, err
+	}
+	base := c.url
+	for _, h := range []string{"Content-Base", "Content-Location"} {
+		if v := res.header.Get(h); v != "" {
+			if u, err := url.Parse(v); err == nil {
+				base = u
+				break
+			}
+		}
+	}
+	return res.body, base, nil
+}
+
+// setup requests the track to be sent interleaved on the given RTP channel, the server may pick another one
+func (c *client) setup(control string, channel uint8) (uint8, error) {
+	res, err := c.do("SETUP", control, map[string]string{
+		"Transport": fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d", channel, channel+1),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if session := res.header.Get("Session"); session != "" {
+		id, params, _ := strings.Cut(session, ";")
+		c.session = strings.TrimSpace(id)
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "timeout="); ok {
+				if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+					c.sessionTimeout = time.Duration(seconds) * time.Second
+				}
+			}
+		}
+	}
+	for _, param := range strings.Split(res.header.Get("Transport"), ";") {
+		if v, ok := strings.CutPrefix(param, "interleaved="); ok {
+			first, _, _ := strings.Cut(v, "-")
+			if ch, err := strconv.ParseUint(first, 10, 8); err == nil {
+				return uint8(ch), nil
+			}
+		}
+	}
+	return channel, nil
+}
+
+func (c *client) play() error {
+	_, err := c.do("PLAY", c.url.String(), map[string]string{"Range": "npt=0.000-"})
+	return err
+}
+
+// keepalive refreshes the session while streaming, the response is skipped by readFrame
+func (c *client) keepalive() error {
+	return c.send("OPTIONS", c.url.String(), nil)
+}
+
+func (c *client) teardown() error {
+	return c.send("TEARDOWN", c.url.String(), nil)
+}
+
+// do sends a request and waits for its response, authenticating once when challenged
+func (c *client) do(method, uri string, header map[string]string) (*response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.send(method, uri, header); err != nil {
+			return nil, err
+		}
+		res, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if res.status == 401 && attempt == 0 && c.user != nil {
+			if c.auth, err = parseAuthChallenge(res.header.Values("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if res.status != 200 {
+			return nil, &StatusError{Method: method, Status: res.status, Reason: res.reason}
+		}
+		return res, nil
+	}
+}
+
+func (c *client) send(method, uri string, header map[string]string) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.cseq++
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s RTSP/1.0\r\n", method, uri)
+	fmt.Fprintf(&b, "CSeq: %d\r\n", c.cseq)
+	fmt.Fprintf(&b, "User-Agent: %s\r\n", userAgent)
+	if c.session != "" {
+		fmt.Fprintf(&b, "Session: %s\r\n", c.session)
+	}
+	if c.auth != nil && c.user != nil {
+		fmt.Fprintf(&b, "Authorization: %s\r\n", c.auth.authorization(c.user, method, uri))
+	}
+	for k, v := range header {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	b.WriteString("\r\n")
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(c.conn, b.String())
+	return err
+}
+
+// readResponse skips interleaved frames, some servers start sending before the PLAY response
+func (c *client) readResponse() (*response, error) {
+	if err := c.conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+	for {
+		_, _, res, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			return res, nil
+		}
+	}
+}
+
+// readFrame returns the next interleaved frame, responses to keepalives are skipped
+func (c *client) readFrame() (uint8, []byte, error) {
+	for {
+		if err := c.conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+			return 0, nil, err
+		}
+		channel, payload, res, err := c.readMessage()
+		if err != nil {
+			return 0, nil, err
+		}
+		if res == nil {
+			return channel, payload, nil
+		}
+	}
+}
+
+// readMessage reads either an interleaved frame or a response
+func (c *client) readMessage() (uint8, []byte, *response, error) {
+	b, err := c.reader.Peek(1)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if b[0] == '$' {
+		var header [4]byte
+		if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+			return 0, nil, nil, err
+		}
+		payload := make([]byte, binary.BigEndian.Uint16(header[2:]))
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return 0, nil, nil, err
+		}
+		return header[1], payload, nil, nil
+	}
+
+	tp := textproto.NewReader(c.reader)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	proto, status, ok := strings.Cut(line, " ")
+	if !ok || !strings.HasPrefix(proto, "RTSP/") {
+		return 0, nil, nil, fmt.Errorf("%w: %q", ErrInvalidResponse, line)
+	}
+	code, reason, _ := strings.Cut(status, " ")
+	res := &response{reason: reason}
+	if res.status, err = strconv.Atoi(code); err != nil {
+		return 0, nil, nil, fmt.Errorf("%w: %q", ErrInvalidResponse, line)
+	}
+	if res.header, err = tp.ReadMIMEHeader(); err != nil {
+		return 0, nil, nil, err
+	}
+	if length := res.header.Get("Content-Length"); length != "" {
+		n, err := strconv.Atoi(length)
+		if err != nil || n < 0 || n > maxContentLength {
+			return 0, nil, nil, fmt.Errorf("%w: content length %q", ErrInvalidResponse, length)
+		}
+		res.body = make([]byte, n)
+		if _, err := io.ReadFull(c.reader, res.body); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+	return 0, nil, res, nil
+}
+
+// authChallenge is the Basic or Digest challenge of the server, Digest is preferred when both are offered
+type authChallenge struct {
+	digest bool
+	realm  string
+	nonce  string
+	opaque string
+	qop    bool
+	nc     int
+}
+
+func parseAuthChallenge(values []string) (*authChallenge, error) {
+	var basic *authChallenge
+	for _, v := range values {
+		scheme, params, _ := strings.Cut(strings.TrimSpace(v), " ")
+		switch strings.ToLower(scheme) {
+		case "digest":
+			a := &authChallenge{digest: true}
+			for key, value := range parseAuthParams(params) {
+				switch strings.ToLower(key) {
+				case "realm":
+					a.realm = value
+				case "nonce":
+					a.nonce = value
+				case "opaque":
+					a.opaque = value
+				case "qop":
+					for _, q := range strings.Split(value, ",") {
+						a.qop = a.qop || strings.TrimSpace(q) == "auth"
+					}
+				}
+			}
+			return a, nil
+		case "basic":
+			basic = &authChallenge{}
+		}
+	}
+	if basic == nil {
+		return nil, ErrUnsupportedAuth
+	}
+	return basic, nil
+}
+
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) > 0 {
+		var key, value string
+		key, s, _ = strings.Cut(s, "=")
+		key = strings.TrimSpace(strings.TrimLeft(key, ", "))
+		s = strings.TrimSpace(s)
+		if strings.HasPrefix(s, `"`) {
+			value, s, _ = strings.Cut(s[1:], `"`)
+		} else {
+			value, s, _ = strings.Cut(s, ",")
+		}
+		if key != "" {
+			params[key] = value
+		}
+	}
+	return params
+}
+
+func (a *authChallenge) authorization(user *url.Userinfo, method, uri string) string {
+	password, _ := user.Password()
+	if !a.digest {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password))
+	}
+
+	ha1 := md5Hex(user.Username() + ":" + a.realm + ":" + password)
+	ha2 := md5Hex(method + ":" + uri)
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username="%s", realm="%s", nonce="%s", uri="%s"`, user.Username(), a.realm, a.nonce, uri)
+	if a.qop {
+		a.nc++
+		nc := fmt.Sprintf("%08x", a.nc)
+		cnonce := newCnonce()
+		fmt.Fprintf(&b, `, response="%s", qop=auth, nc=%s, cnonce="%s"`,
+			md5Hex(ha1+":"+a.nonce+":"+nc+":"+cnonce+":auth:"+ha2), nc, cnonce)
+	} else {
+		fmt.Fprintf(&b, `, response="%s"`, md5Hex(ha1+":"+a.nonce+":"+ha2))
+	}
+	if a.opaque != "" {
+		fmt.Fprintf(&b, `, opaque="%s"`, a.opaque)
+	}
+	return b.String()
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func newCnonce() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtspingest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCamera answers RTSP requests with Digest authentication and sends one interleaved frame after PLAY
+type fakeCamera struct {
+	listener net.Listener
+	methods  chan string
+}
+
+func newFakeCamera(t *testing.T) *fakeCamera {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	c := &fakeCamera{listener: listener, methods: make(chan string, 10)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go c.serve()
+	return c
+}
+
+func (c *fakeCamera) serve() {
+	conn, err := c.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tp := textproto.NewReader(bufio.NewReader(conn))
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		header, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		method, uri, _ := strings.Cut(line, " ")
+		uri, _, _ = strings.Cut(uri, " ")
+		cseq := header.Get("CSeq")
+
+		authorization := header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Digest ") {
+			fmt.Fprintf(conn, "RTSP/1.0 401 Unauthorized\r\nCSeq: %s\r\nWWW-Authenticate: Basic realm=\"cam\"\r\nWWW-Authenticate: Digest realm=\"cam\", nonce=\"abc\"\r\n\r\n", cseq)
+			continue
+		}
+		expected := md5Hex(md5Hex("admin:cam:secret") + ":abc:" + md5Hex(method+":"+uri))
+		if !strings.Contains(authorization, `response="`+expected+`"`) {
+			fmt.Fprintf(conn, "RTSP/1.0 403 Forbidden\r\nCSeq: %s\r\n\r\n", cseq)
+			continue
+		}
+		c.methods <- method
+
+		switch method {
+		case "DESCRIBE":
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nContent-Base: rtsp://camera/live/\r\nContent-Length: %d\r\n\r\n%s", cseq, len(cameraSDP), cameraSDP)
+		case "SETUP":
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nSession: 1234;timeout=30\r\nTransport: RTP/AVP/TCP;unicast;interleaved=4-5\r\n\r\n", cseq)
+		case "PLAY":
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\nSession: 1234\r\n\r\n", cseq)
+			_, _ = conn.Write([]byte{'$', 4, 0, 3, 1, 2, 3})
+			// a keepalive response between frames
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: 100\r\n\r\n")
+			_, _ = conn.Write([]byte{'$', 5, 0, 1, 9})
+		default:
+			fmt.Fprintf(conn, "RTSP/1.0 200 OK\r\nCSeq: %s\r\n\r\n", cseq)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	camera := newFakeCamera(t)
+	c, err := dial("rtsp://admin:secret@"+camera.listener.Addr().String()+"/live", time.Second)
+	require.NoError(t, err)
+	defer c.close()
+
+	body, base, err := c.describe()
+	require.NoError(t, err)
+	require.Equal(t, cameraSDP, string(body))
+	require.Equal(t, "rtsp://camera/live/", base.String())
+
+	channel, err := c.setup(controlURL(base, "trackID=1"), 0)
+	require.NoError(t, err)
+	require.Equal(t, uint8(4), channel)
+	require.Equal(t, "1234", c.session)
+	require.Equal(t, 30*time.Second, c.sessionTimeout)
+
+	require.NoError(t, c.play())
+	channel, payload, err := c.readFrame()
+	require.NoError(t, err)
+	require.Equal(t, uint8(4), channel)
+	require.Equal(t, []byte{1, 2, 3}, payload)
+	channel, payload, err = c.readFrame()
+	require.NoError(t, err)
+	require.Equal(t, uint8(5), channel)
+	require.Equal(t, []byte{9}, payload)
+
+	require.Equal(t, "DESCRIBE", <-camera.methods)
+	require.Equal(t, "SETUP", <-camera.methods)
+	require.Equal(t, "PLAY", <-camera.methods)
+}
+
+func TestClientStatusError(t *testing.T) {
+	camera := newFakeCamera(t)
+	// wrong password
+	c, err := dial("rtsp://admin:wrong@"+camera.listener.Addr().String()+"/live", time.Second)
+	require.NoError(t, err)
+	defer c.close()
+
+	_, _, err = c.describe()
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, 403, statusErr.Status)
+
+	_, err = dial("http://camera/live", time.Second)
+	require.ErrorIs(t, err, ErrUnsupportedURL)
+}
+
+func TestClientContentLengthLimit(t *testing.T) {
+	server, conn := net.Pipe()
+	defer server.Close()
+	c := &client{conn: conn, reader: bufio.NewReader(conn)}
+	defer c.close()
+
+	// the body is never allocated
+	go fmt.Fprintf(server, "RTSP/1.0 200 OK\r\nCSeq: 1\r\nContent-Length: %d\r\n\r\n", maxContentLength+1)
+	_, _, _, err := c.readMessage()
+	require.ErrorIs(t, err, ErrInvalidResponse)
+}
@@ -0,0 +1,342 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtspingest
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pion/rtp"
+)
+
+// stream is a media section of the SDP of the camera
+type stream struct {
+	media       string // video or audio
+	payloadType uint8
+	codec       string // upper case encoding name, e.g. H264 or MPEG4-GENERIC
+	clockRate   uint32
+	channels    uint16
+	fmtp        map[string]string
+	control     string
+}
+
+// parseSDP reads the media sections of a session description. It is lenient on purpose,
+// cameras often produce SDP that stricter parsers reject.
+func parseSDP(body []byte) []*stream {
+	var (
+		streams []*stream
+		current *stream
+	)
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "m="):
+			// m=video 0 RTP/AVP 96
+			fields := strings.Fields(line[2:])
+			current = &stream{media: fields[0], fmtp: make(map[string]string)}
+			if len(fields) > 3 {
+				if pt, err := strconv.ParseUint(fields[3], 10, 7); err == nil {
+					current.payloadType = uint8(pt)
+				}
+			}
+			streams = append(streams, current)
+
+		case current == nil:
+
+		case strings.HasPrefix(line, "a=rtpmap:"):
+			// a=rtpmap:96 H264/90000, the first format of the section is used
+			pt, encoding, _ := strings.Cut(line[len("a=rtpmap:"):], " ")
+			if pt != strconv.Itoa(int(current.payloadType)) {
+				continue
+			}
+			parts := strings.Split(encoding, "/")
+			current.codec = strings.ToUpper(parts[0])
+			if len(parts) > 1 {
+				if rate, err := strconv.ParseUint(parts[1], 10, 32); err == nil {
+					current.clockRate = uint32(rate)
+				}
+			}
+			current.channels = 1
+			if len(parts) > 2 {
+				if channels, err := strconv.ParseUint(parts[2], 10, 16); err == nil {
+					current.channels = uint16(channels)
+				}
+			}
+
+		case strings.HasPrefix(line, "a=fmtp:"):
+			pt, params, _ := strings.Cut(line[len("a=fmtp:"):], " ")
+			if pt != strconv.Itoa(int(current.payloadType)) {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if key != "" {
+					current.fmtp[strings.ToLower(key)] = value
+				}
+			}
+
+		case strings.HasPrefix(line, "a=control:"):
+			current.control = line[len("a=control:"):]
+		}
+	}
+	return streams
+}
+
+// controlURL resolves the control attribute of a stream against the base URL of the session
+func controlURL(base *url.URL, control string) string {
+	if control == "" || control == "*" {
+		return base.String()
+	}
+	if u, err := url.Parse(control); err == nil && u.IsAbs() {
+		return control
+	}
+	return strings.TrimSuffix(base.String(), "/") + "/" + control
+}
+
+const (
+	naluTypeIDR   = 5
+	naluTypeSPS   = 7
+	naluTypePPS   = 8
+	naluTypeSTAPA = 24
+	naluTypeFUA   = 28
+)
+
+// parameterSets inserts the SPS and PPS announced in the SDP before key frames of cameras that do not
+// repeat them in-band, subscribers joining later could not decode the stream otherwise.
+type parameterSets struct {
+	sps, pps []byte
+	// parameter sets were received since the last key frame
+	inBand bool
+	// packets inserted so far, added to the sequence numbers of the camera
+	seqShift uint16
+}
+
+func newParameterSets(fmtp map[string]string) *parameterSets {
+	p := &parameterSets{}
+	for _, set := range strings.Split(fmtp["sprop-parameter-sets"], ",") {
+		nalu, err := base64.StdEncoding.DecodeString(set)
+		if err != nil || len(nalu) == 0 {
+			continue
+		}
+		switch nalu[0] & 0x1f {
+		case naluTypeSPS:
+			p.sps = nalu
+		case naluTypePPS:
+			p.pps = nalu
+		}
+	}
+	return p
+}
+
+// process returns the packets to send for a packet of the camera
+func (p *parameterSets) process(pkt *rtp.Packet) []*rtp.Packet {
+	var out []*rtp.Packet
+	types := naluTypes(pkt.Payload)
+	for _, t := range types {
+		switch t {
+		case naluTypeSPS, naluTypePPS:
+			p.inBand = true
+		case naluTypeIDR:
+			if !p.inBand && p.sps != nil && p.pps != nil {
+				stapA := &rtp.Packet{Header: pkt.Header.Clone(), Payload: p.stapA()}
+				stapA.Marker = false
+				stapA.SequenceNumber += p.seqShift
+				p.seqShift++
+				out = append(out, stapA)
+			}
+			p.inBand = false
+		}
+	}
+	pkt.SequenceNumber += p.seqShift
+	return append(out, pkt)
+}
+
+func (p *parameterSets) stapA() []byte {
+	payload := []byte{naluTypeSTAPA | p.sps[0]&0x60}
+	for _, nalu := range [][]byte{p.sps, p.pps} {
+		payload = append(payload, byte(len(nalu)>>8), byte(len(nalu)))
+		payload = append(payload, nalu...)
+	}
+	return payload
+}
+
+// naluTypes returns the types of the NAL units a packet starts
+func naluTypes(payload []byte) []uint8 {
+	if len(payload) == 0 {
+		return nil
+	}
+	switch t := payload[0] & 0x1f; t {
+	case naluTypeSTAPA:
+		var types []uint8
+		for i := 1; i+2 < len(payload); {
+			size := int(payload[i])<<8 | int(payload[i+1])
+			types = append(types, payload[i+2]&0x1f)
+			i += 2 + size
+		}
+		return types
+	case naluTypeFUA:
+		// start bit
+		if len(payload) > 1 && payload[1]&0x80 != 0 {
+			return []uint8{payload[1] & 0x1f}
+		}
+		return nil
+	default:
+		return []uint8{t}
+	}
+}
+
+// AACConfig describes the AAC stream of the camera, see AudioTranscoder
+type AACConfig struct {
+	ObjectType int
+	SampleRate int
+	Channels   int
+	// AudioSpecificConfig from the SDP
+	Config []byte
+}
+
+var aacSampleRates = []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+func parseAACConfig(fmtp map[string]string) (AACConfig, error) {
+	config, err := hex.DecodeString(fmtp["config"])
+	if err != nil || len(config) < 2 {
+		return AACConfig{}, fmt.Errorf("%w: invalid AAC config %q", ErrUnsupportedStream, fmtp["config"])
+	}
+	c := AACConfig{
+		ObjectType: int(config[0] >> 3),
+		Channels:   int(config[1]>>3) & 0x0f,
+		Config:     config,
+	}
+	index := int(config[0]&0x07)<<1 | int(config[1]>>7)
+	if index == 15 {
+		// explicit 24 bit sample rate
+		if len(config) < 5 {
+			return AACConfig{}, fmt.Errorf("%w: invalid AAC config %q", ErrUnsupportedStream, fmtp["config"])
+		}
+		c.SampleRate = int(config[1]&0x7f)<<17 | int(config[2])<<9 | int(config[3])<<1 | int(config[4]>>7)
+		c.Channels = int(config[4]>>3) & 0x0f
+	} else if index < len(aacSampleRates) {
+		c.SampleRate = aacSampleRates[index]
+	}
+	return c, nil
+}
+
+// aacDepacketizer extracts access units from RFC 3640 packets, e.g. mode=AAC-hbr
+type aacDepacketizer struct {
+	sizeLength       int
+	indexLength      int
+	indexDeltaLength int
+
+	fragment     []byte
+	fragmentSize int
+}
+
+func newAACDepacketizer(fmtp map[string]string) (*aacDepacketizer, error) {
+	d := &aacDepacketizer{}
+	for key, v := range map[string]*int{"sizelength": &d.sizeLength, "indexlength": &d.indexLength, "indexdeltalength": &d.indexDeltaLength} {
+		if s, ok := fmtp[key]; ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 || n > 16 {
+				return nil, fmt.Errorf("%w: invalid %s %q", ErrUnsupportedStream, key, s)
+			}
+			*v = n
+		}
+	}
+	if d.sizeLength == 0 {
+		return nil, fmt.Errorf("%w: AAC without AU headers", ErrUnsupportedStream)
+	}
+	return d, nil
+}
+
+func (d *aacDepacketizer) depacketize(pkt *rtp.Packet) ([][]byte, error) {
+	payload := pkt.Payload
+	if len(payload) < 2 {
+		return nil, errInvalidAACPacket
+	}
+	headersBits := int(payload[0])<<8 | int(payload[1])
+	headersLength := (headersBits + 7) / 8
+	if len(payload) < 2+headersLength {
+		return nil, errInvalidAACPacket
+	}
+	headers := bitReader{data: payload[2 : 2+headersLength]}
+	data := payload[2+headersLength:]
+
+	var sizes []int
+	for read := 0; read < headersBits; {
+		size, err := headers.read(d.sizeLength)
+		if err != nil {
+			return nil, err
+		}
+		indexLength := d.indexDeltaLength
+		if len(sizes) == 0 {
+			indexLength = d.indexLength
+		}
+		if _, err := headers.read(indexLength); err != nil {
+			return nil, err
+		}
+		read += d.sizeLength + indexLength
+		sizes = append(sizes, size)
+	}
+
+	// an access unit larger than a packet is fragmented, the last fragment has the marker set
+	if len(sizes) != 1 || sizes[0] != d.fragmentSize {
+		// a fragment was lost
+		d.fragment = nil
+	}
+	if len(sizes) == 1 && (sizes[0] > len(data) || d.fragment != nil) {
+		d.fragmentSize = sizes[0]
+		d.fragment = append(d.fragment, data...)
+		if !pkt.Marker {
+			return nil, nil
+		}
+		au := d.fragment
+		d.fragment = nil
+		if len(au) != d.fragmentSize {
+			return nil, errInvalidAACPacket
+		}
+		return [][]byte{au}, nil
+	}
+
+	units := make([][]byte, 0, len(sizes))
+	for _, size := range sizes {
+		if size > len(data) {
+			return nil, errInvalidAACPacket
+		}
+		units = append(units, data[:size])
+		data = data[size:]
+	}
+	return units, nil
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) read(n int) (int, error) {
+	v := 0
+	for i := 0; i < n; i++ {
+		if r.pos/8 >= len(r.data) {
+			return 0, errInvalidAACPacket
+		}
+		bit := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | int(bit)
+		r.pos++
+	}
+	return v, nil
+}
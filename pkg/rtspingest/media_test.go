@@ -0,0 +1,128 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtspingest
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+const cameraSDP = `v=0
+o=- 0 0 IN IP4 192.168.1.10
+s=Camera
+t=0 0
+a=control:*
+m=video 0 RTP/AVP 96
+a=rtpmap:96 H264/90000
+a=fmtp:96 packetization-mode=1;profile-level-id=42e01f;sprop-parameter-sets=Z0IAH5WoFAFuQA==,aM48gA==
+a=control:trackID=1
+m=audio 0 RTP/AVP 97
+a=rtpmap:97 MPEG4-GENERIC/16000/1
+a=fmtp:97 streamtype=5; profile-level-id=15; mode=AAC-hbr; config=1408; SizeLength=13; IndexLength=3; IndexDeltaLength=3
+a=control:trackID=2
+`
+
+func TestParseSDP(t *testing.T) {
+	streams := parseSDP([]byte(cameraSDP))
+	require.Len(t, streams, 2)
+
+	video := streams[0]
+	require.Equal(t, "video", video.media)
+	require.Equal(t, uint8(96), video.payloadType)
+	require.Equal(t, "H264", video.codec)
+	require.Equal(t, uint32(90000), video.clockRate)
+	require.Equal(t, "1", video.fmtp["packetization-mode"])
+	require.Equal(t, "trackID=1", video.control)
+
+	audio := streams[1]
+	require.Equal(t, "MPEG4-GENERIC", audio.codec)
+	require.Equal(t, uint32(16000), audio.clockRate)
+	require.Equal(t, uint16(1), audio.channels)
+	require.Equal(t, "13", audio.fmtp["sizelength"])
+
+	config, err := parseAACConfig(audio.fmtp)
+	require.NoError(t, err)
+	require.Equal(t, 2, config.ObjectType)
+	require.Equal(t, 16000, config.SampleRate)
+	require.Equal(t, 1, config.Channels)
+}
+
+func TestControlURL(t *testing.T) {
+	base, err := url.Parse("rtsp://camera/stream/")
+	require.NoError(t, err)
+	require.Equal(t, "rtsp://camera/stream/trackID=1", controlURL(base, "trackID=1"))
+	require.Equal(t, "rtsp://camera/stream/", controlURL(base, "*"))
+	require.Equal(t, "rtsp://other/track", controlURL(base, "rtsp://other/track"))
+}
+
+func TestParameterSets(t *testing.T) {
+	sets := newParameterSets(parseSDP([]byte(cameraSDP))[0].fmtp)
+	require.NotNil(t, sets.sps)
+	require.NotNil(t, sets.pps)
+
+	idr := func(seq uint16) *rtp.Packet {
+		// FU-A start of an IDR
+		return &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, Timestamp: 3000}, Payload: []byte{0x7c, 0x85, 1, 2}}
+	}
+
+	// parameter sets are inserted before the key frame
+	out := sets.process(idr(10))
+	require.Len(t, out, 2)
+	require.Equal(t, []uint8{naluTypeSPS, naluTypePPS}, naluTypes(out[0].Payload))
+	require.Equal(t, uint16(10), out[0].SequenceNumber)
+	require.Equal(t, uint32(3000), out[0].Timestamp)
+	require.Equal(t, uint16(11), out[1].SequenceNumber)
+
+	// following packets are shifted
+	out = sets.process(&rtp.Packet{Header: rtp.Header{SequenceNumber: 11}, Payload: []byte{0x41}})
+	require.Len(t, out, 1)
+	require.Equal(t, uint16(12), out[0].SequenceNumber)
+
+	// not when the camera sends them in-band
+	sets.process(&rtp.Packet{Header: rtp.Header{SequenceNumber: 12}, Payload: []byte{0x67}})
+	out = sets.process(idr(13))
+	require.Len(t, out, 1)
+	require.Equal(t, uint16(14), out[0].SequenceNumber)
+}
+
+func TestAACDepacketizer(t *testing.T) {
+	d, err := newAACDepacketizer(map[string]string{"sizelength": "13", "indexlength": "3", "indexdeltalength": "3"})
+	require.NoError(t, err)
+
+	// two access units of 2 and 3 bytes
+	units, err := d.depacketize(&rtp.Packet{
+		Header:  rtp.Header{Marker: true},
+		Payload: []byte{0x00, 0x20, 0x00, 0x10, 0x00, 0x18, 1, 2, 3, 4, 5},
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1, 2}, {3, 4, 5}}, units)
+
+	// an access unit of 4 bytes in two fragments
+	units, err = d.depacketize(&rtp.Packet{Payload: []byte{0x00, 0x10, 0x00, 0x20, 1, 2}})
+	require.NoError(t, err)
+	require.Empty(t, units)
+	units, err = d.depacketize(&rtp.Packet{Header: rtp.Header{Marker: true}, Payload: []byte{0x00, 0x10, 0x00, 0x20, 3, 4}})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{1, 2, 3, 4}}, units)
+
+	_, err = d.depacketize(&rtp.Packet{Payload: []byte{0x00, 0x10, 0x00}})
+	require.ErrorIs(t, err, errInvalidAACPacket)
+
+	_, err = newAACDepacketizer(map[string]string{})
+	require.ErrorIs(t, err, ErrUnsupportedStream)
+}
@@ -30,6 +30,8 @@ import (
 type packet struct {
 	start, end bool
 	packet     *rtp.Packet
+	// set when a sample latency handler is used
+	arrival time.Time
 }
 
 // SampleBuilder buffers packets and produces media frames
@@ -57,6 +59,7 @@ type SampleBuilder struct {
 	lastTimestamp uint32
 
 	onPacketDropped func()
+	onSampleLatency func(time.Duration)
 }
 
 // New constructs a new SampleBuilder.
@@ -102,6 +105,15 @@ func WithPacketDroppedHandler(h func()) Option {
 	}
 }
 
+// WithSampleLatencyHandler sets a callback that is called with the time
+// the packets of a sample were buffered, from the first packet pushed
+// until the sample is popped.
+func WithSampleLatencyHandler(h func(time.Duration)) Option {
+	return func(s *SampleBuilder) {
+		s.onSampleLatency = h
+	}
+}
+
 // check verifies the SampleBuilder's invariants.  It may be used in testing.
 func (s *SampleBuilder) check() error {
 	if s.head == s.tail {
@@ -258,6 +270,11 @@ func (s *SampleBuilder) drop() (bool, uint32) {
 // Push does not copy the input: the packet will be retained by s.  If you
 // plan to reuse the packet or its buffer, make sure to perform a copy.
 func (s *SampleBuilder) Push(p *rtp.Packet) {
+	var arrival time.Time
+	if s.onSampleLatency != nil {
+		arrival = time.Now()
+	}
+
 	if s.lastSeqnoValid {
 		if (s.lastSeqno-p.SequenceNumber)&0x8000 == 0 {
 			// late packet
@@ -283,9 +300,10 @@ func (s *SampleBuilder) Push(p *rtp.Packet) {
 	if s.head == s.tail {
 		// empty
 		s.packets[0] = packet{
-			start:  s.isStart(p),
-			end:    s.isEnd(p),
-			packet: p,
+			start:   s.isStart(p),
+			end:     s.isEnd(p),
+			packet:  p,
+			arrival: arrival,
 		}
 		s.tail = 0
 		s.head = 1
@@ -315,9 +333,10 @@ func (s *SampleBuilder) Push(p *rtp.Packet) {
 			start = s.isStart(p)
 		}
 		s.packets[s.head] = packet{
-			start:  start,
-			end:    s.isEnd(p),
-			packet: p,
+			start:   start,
+			end:     s.isEnd(p),
+			packet:  p,
+			arrival: arrival,
 		}
 		s.head = s.inc(s.head)
 		return
@@ -342,9 +361,10 @@ func (s *SampleBuilder) Push(p *rtp.Packet) {
 		index := (s.head + count) % uint16(len(s.packets))
 		start := s.isStart(p)
 		s.packets[index] = packet{
-			start:  start,
-			end:    s.isEnd(p),
-			packet: p,
+			start:   start,
+			end:     s.isEnd(p),
+			packet:  p,
+			arrival: arrival,
 		}
 		s.head = s.inc(index)
 		return
@@ -415,16 +435,17 @@ func (s *SampleBuilder) Push(p *rtp.Packet) {
 
 	// done!
 	s.packets[index] = packet{
-		start:  start,
-		end:    end,
-		packet: p,
+		start:   start,
+		end:     end,
+		packet:  p,
+		arrival: arrival,
 	}
 }
 
-func (s *SampleBuilder) popRtpPackets(force bool) ([]*rtp.Packet, uint32) {
+func (s *SampleBuilder) popRtpPackets(force bool) ([]*rtp.Packet, uint32, time.Time) {
 again:
 	if s.tail == s.head {
-		return nil, 0
+		return nil, 0, time.Time{}
 	}
 
 	if !s.packets[s.tail].start {
@@ -434,13 +455,13 @@ again:
 			s.drop()
 			goto again
 		}
-		return nil, 0
+		return nil, 0, time.Time{}
 	}
 
 	seqno := s.packets[s.tail].packet.SequenceNumber
 	if !force && s.lastSeqnoValid && s.lastSeqno+1 != seqno {
 		// packet loss before tail
-		return nil, 0
+		return nil, 0, time.Time{}
 	}
 
 	ts := s.packets[s.tail].packet.Timestamp
@@ -451,29 +472,33 @@ again:
 				s.drop()
 				goto again
 			}
-			return nil, 0
+			return nil, 0, time.Time{}
 		}
 		last = s.inc(last)
 	}
 
 	if last == s.head {
-		return nil, 0
+		return nil, 0, time.Time{}
 	}
 	count := last - s.tail + 1
 	if last < s.tail {
 		count = uint16(len(s.packets)) + last - s.tail + 1
 	}
 	packets := make([]*rtp.Packet, 0, count)
+	var arrival time.Time
 	for i := uint16(0); i < count; i++ {
+		if a := s.packets[s.tail].arrival; arrival.IsZero() || a.Before(arrival) {
+			arrival = a
+		}
 		packets = append(packets, s.packets[s.tail].packet)
 		s.release(false)
 	}
 
-	return packets, ts
+	return packets, ts, arrival
 }
 
 func (s *SampleBuilder) popSample(force bool) (*media.Sample, uint32) {
-	packets, ts := s.popRtpPackets(force)
+	packets, ts, arrival := s.popRtpPackets(force)
 	if packets == nil {
 		return nil, 0
 	}
@@ -504,6 +529,9 @@ func (s *SampleBuilder) popSample(force bool) (*media.Sample, uint32) {
 	s.lastTimestampValid = true
 	s.lastTimestamp = ts
 	duration := time.Duration(float64(samples) / float64(s.sampleRate) * float64(time.Second))
+	if s.onSampleLatency != nil && !arrival.IsZero() {
+		s.onSampleLatency(time.Since(arrival))
+	}
 
 	return &media.Sample{
 		Data:     data,
@@ -540,7 +568,7 @@ func (s *SampleBuilder) ForcePopWithTimestamp() (*media.Sample, uint32) {
 // rtp packets returned is not called release handle by SampleBuilder, so caller
 // is responsible for release these packets if required.
 func (s *SampleBuilder) PopPackets() []*rtp.Packet {
-	pkts, _ := s.popRtpPackets(false)
+	pkts, _, _ := s.popRtpPackets(false)
 	return pkts
 }
 
@@ -548,6 +576,6 @@ func (s *SampleBuilder) PopPackets() []*rtp.Packet {
 // (frames of audio/video). Any incomplete packets are dropped. After
 // ForcePopPackets returns, the SampleBuilder is guaranteed to be empty.
 func (s *SampleBuilder) ForcePopPackets() []*rtp.Packet {
-	pkts, _ := s.popRtpPackets(true)
+	pkts, _, _ := s.popRtpPackets(true)
 	return pkts
 }
@@ -937,3 +937,18 @@ func BenchmarkSampleBuilderFragmentedLoss(b *testing.B) {
 		b.Errorf("Got %v (N=%v)", j, b.N)
 	}
 }
+
+func TestSampleBuilderLatencyHandler(t *testing.T) {
+	var latencies []time.Duration
+	s := New(10, &testDepacketizer{headBytes: []byte{1}}, 1, WithSampleLatencyHandler(func(d time.Duration) {
+		latencies = append(latencies, d)
+	}))
+	// a sample of two packets, the latency counts from the first one pushed
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 1, Timestamp: 1, Marker: true}, Payload: []byte{2}})
+	time.Sleep(20 * time.Millisecond)
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 0, Timestamp: 1}, Payload: []byte{1}})
+	require.NotNil(t, s.Pop())
+	require.Nil(t, s.Pop())
+	require.Len(t, latencies, 1)
+	require.GreaterOrEqual(t, latencies[0], 20*time.Millisecond)
+}
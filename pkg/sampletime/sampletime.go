@@ -0,0 +1,153 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sampletime stamps received samples with the capture time derived from RTCP sender reports
+// and with the local receive time, and records both in a sidecar JSON lines file next to the recording.
+// With LiveKit, sender reports of subscribed tracks carry the NTP clock of the server.
+package sampletime
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+)
+
+// Timestamps of a sample
+type Timestamps struct {
+	// Index counts the samples stamped, starting at 0
+	Index        uint64
+	RTPTimestamp uint32
+	// CaptureTime is derived from the last sender report, zero until the first one is received
+	CaptureTime time.Time
+	ReceiveTime time.Time
+}
+
+// HasCaptureTime returns true when a sender report was available to compute the capture time
+func (t Timestamps) HasCaptureTime() bool {
+	return !t.CaptureTime.IsZero()
+}
+
+// sidecar record, one JSON object per line
+type record struct {
+	Index        uint64 `json:"index"`
+	RTPTimestamp uint32 `json:"rtp_timestamp"`
+	CaptureTime  string `json:"capture_time,omitempty"`
+	ReceiveTime  string `json:"receive_time"`
+}
+
+type Option func(*Stamper)
+
+// WithSidecar writes the timestamps of every sample to w as JSON lines
+func WithSidecar(w io.Writer) Option {
+	return func(s *Stamper) {
+		s.sidecar = json.NewEncoder(w)
+	}
+}
+
+// WithSSRC ignores sender reports of other streams, useful when RTCP of several tracks is handled together
+func WithSSRC(ssrc uint32) Option {
+	return func(s *Stamper) {
+		s.ssrc = ssrc
+	}
+}
+
+// Stamper maps RTP timestamps of a track to capture times. Pass RTCP packets of the track to OnRTCP,
+// e.g. from RemoteTrackPublication.OnRTCP, and call Stamp for every sample written.
+type Stamper struct {
+	clockRate uint32
+	ssrc      uint32
+	sidecar   *json.Encoder
+
+	lock      sync.Mutex
+	hasReport bool
+	srNTP     time.Time
+	srRTP     uint32
+	index     uint64
+}
+
+// NewStamper creates a stamper for a stream with the given RTP clock rate
+func NewStamper(clockRate uint32, opts ...Option) *Stamper {
+	s := &Stamper{clockRate: clockRate}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewTrackStamper creates a stamper for a subscribed track
+func NewTrackStamper(track *webrtc.TrackRemote, opts ...Option) *Stamper {
+	return NewStamper(track.Codec().ClockRate, append([]Option{WithSSRC(uint32(track.SSRC()))}, opts...)...)
+}
+
+// OnRTCP updates the RTP to NTP mapping from sender reports, other packets are ignored
+func (s *Stamper) OnRTCP(pkt rtcp.Packet) {
+	sr, ok := pkt.(*rtcp.SenderReport)
+	if !ok || (s.ssrc != 0 && sr.SSRC != s.ssrc) {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.hasReport = true
+	s.srNTP = ntpToTime(sr.NTPTime)
+	s.srRTP = sr.RTPTime
+}
+
+// Stamp returns the timestamps of a sample with the given RTP timestamp that was received at receivedAt,
+// and writes them to the sidecar when configured
+func (s *Stamper) Stamp(rtpTimestamp uint32, receivedAt time.Time) (Timestamps, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ts := Timestamps{
+		Index:        s.index,
+		RTPTimestamp: rtpTimestamp,
+		ReceiveTime:  receivedAt,
+	}
+	s.index++
+	if s.hasReport && s.clockRate > 0 {
+		// signed difference handles timestamp wrap around and samples older than the report
+		delta := int64(int32(rtpTimestamp - s.srRTP))
+		ts.CaptureTime = s.srNTP.Add(time.Duration(delta * int64(time.Second) / int64(s.clockRate)))
+	}
+	if s.sidecar == nil {
+		return ts, nil
+	}
+
+	rec := record{
+		Index:        ts.Index,
+		RTPTimestamp: ts.RTPTimestamp,
+		ReceiveTime:  ts.ReceiveTime.UTC().Format(time.RFC3339Nano),
+	}
+	if ts.HasCaptureTime() {
+		rec.CaptureTime = ts.CaptureTime.UTC().Format(time.RFC3339Nano)
+	}
+	// written under the lock to keep lines in order
+	return ts, s.sidecar.Encode(rec)
+}
+
+// ntpEpochOffset is the number of seconds between 1900 and 1970
+const ntpEpochOffset = 2208988800
+
+func ntpToTime(ntp uint64) time.Time {
+	secs := int64(ntp>>32) - ntpEpochOffset
+	frac := ntp & 0xFFFFFFFF
+	nanos := int64((frac * uint64(time.Second)) >> 32)
+	return time.Unix(secs, nanos)
+}
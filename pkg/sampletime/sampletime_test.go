@@ -0,0 +1,71 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sampletime
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func timeToNTP(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+func TestStamper(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStamper(48000, WithSSRC(1234), WithSidecar(&buf))
+	received := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	ts, err := s.Stamp(1000, received)
+	require.NoError(t, err)
+	require.False(t, ts.HasCaptureTime())
+
+	captured := time.Date(2024, 5, 6, 7, 8, 8, 500_000_000, time.UTC)
+	// other streams are ignored
+	s.OnRTCP(&rtcp.SenderReport{SSRC: 42, NTPTime: timeToNTP(captured.Add(time.Hour)), RTPTime: 0})
+	s.OnRTCP(&rtcp.SenderReport{SSRC: 1234, NTPTime: timeToNTP(captured), RTPTime: 4294967296 - 48000})
+
+	// one second after the report, across the wrap around
+	ts, err = s.Stamp(0, received.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), ts.Index)
+	require.WithinDuration(t, captured.Add(time.Second), ts.CaptureTime, time.Microsecond)
+
+	// a sample from before the report
+	ts, err = s.Stamp(4294967296-72000, received.Add(2*time.Second))
+	require.NoError(t, err)
+	require.WithinDuration(t, captured.Add(-500*time.Millisecond), ts.CaptureTime, time.Microsecond)
+
+	var records []record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	require.Len(t, records, 3)
+	require.Empty(t, records[0].CaptureTime)
+	require.Equal(t, "2024-05-06T07:08:09Z", records[0].ReceiveTime)
+	require.Equal(t, "2024-05-06T07:08:09.5Z", records[1].CaptureTime)
+	require.Equal(t, uint64(2), records[2].Index)
+}
@@ -0,0 +1,215 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package screencapture
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"time"
+
+	protoLogger "github.com/livekit/protocol/logger"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"go.uber.org/atomic"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+const DefaultFrameRate = 15
+
+type Option func(*Publisher)
+
+// WithFrameRate sets how many frames per second are captured, DefaultFrameRate by default
+func WithFrameRate(fps int) Option {
+	return func(p *Publisher) {
+		if fps > 0 {
+			p.fps = fps
+		}
+	}
+}
+
+// WithRegion captures only a part of the display
+func WithRegion(region image.Rectangle) Option {
+	return func(p *Publisher) {
+		p.region = region
+	}
+}
+
+// WithLogger sets the logger, logger.GetLogger if not set
+func WithLogger(logger protoLogger.Logger) Option {
+	return func(p *Publisher) {
+		p.log = logger
+	}
+}
+
+// Publisher captures frames of a Source at a fixed rate, encodes them and writes them to a LocalSampleTrack.
+// Publish Track, then call Start.
+type Publisher struct {
+	source  Source
+	encoder Encoder
+	fps     int
+	region  image.Rectangle
+	log     protoLogger.Logger
+
+	track *lksdk.LocalSampleTrack
+	// set when a subscriber asked for a key frame
+	keyFrame atomic.Bool
+
+	lock    sync.Mutex
+	started bool
+	closed  bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewPublisher creates the track frames of source are written to. The publisher owns source and encoder,
+// they are closed by Close.
+func NewPublisher(source Source, encoder Encoder, opts ...Option) (*Publisher, error) {
+	if source == nil || encoder == nil {
+		return nil, errors.New("source and encoder are required")
+	}
+	p := &Publisher{
+		source:  source,
+		encoder: encoder,
+		fps:     DefaultFrameRate,
+		log:     protoLogger.GetLogger(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	// the first frame is always a key frame
+	p.keyFrame.Store(true)
+
+	var err error
+	p.track, err = lksdk.NewLocalSampleTrack(
+		webrtc.RTPCodecCapability{MimeType: encoder.MimeType(), ClockRate: 90000},
+		lksdk.WithRTCPHandler(p.handleRTCP),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Publisher) handleRTCP(pkt rtcp.Packet) {
+	switch pkt.(type) {
+	case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+		p.keyFrame.Store(true)
+	}
+}
+
+// Track returns the track to publish
+func (p *Publisher) Track() *lksdk.LocalSampleTrack {
+	return p.track
+}
+
+// Start starts capturing
+func (p *Publisher) Start() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+	if p.started {
+		return nil
+	}
+	p.started = true
+	go p.run()
+	return nil
+}
+
+func (p *Publisher) run() {
+	defer close(p.done)
+
+	interval := time.Second / time.Duration(p.fps)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastWrite time.Time
+	failing := false
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		wrote, err := p.captureFrame(lastWrite, interval)
+		if err != nil {
+			if !failing {
+				p.log.Warnw("could not capture screen", err)
+				failing = true
+			}
+			continue
+		}
+		failing = false
+		if !wrote.IsZero() {
+			lastWrite = wrote
+		}
+	}
+}
+
+// captureFrame captures, encodes and writes one frame, returns the time it was written
+func (p *Publisher) captureFrame(lastWrite time.Time, interval time.Duration) (time.Time, error) {
+	frame, err := p.source.Frame()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !p.region.Empty() {
+		if frame, err = frame.Crop(p.region); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	keyFrame := p.keyFrame.Swap(false)
+	sample, err := p.encoder.Encode(frame, keyFrame)
+	if err != nil || sample == nil {
+		if keyFrame {
+			p.keyFrame.Store(true)
+		}
+		return time.Time{}, err
+	}
+
+	// durations follow the capture times, so a slow encoder does not make the video drift
+	now := time.Now()
+	duration := interval
+	if !lastWrite.IsZero() {
+		duration = now.Sub(lastWrite)
+	}
+	return now, p.track.WriteSample(media.Sample{Data: sample, Duration: duration}, nil)
+}
+
+// Close stops capturing and closes the source and encoder, the track is not unpublished
+func (p *Publisher) Close() error {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil
+	}
+	p.closed = true
+	started := p.started
+	close(p.stop)
+	p.lock.Unlock()
+
+	if started {
+		<-p.done
+	}
+	return errors.Join(p.encoder.Close(), p.source.Close())
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package screencapture
+
+import (
+	"encoding/binary"
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+// xwdImage builds an XWD image as written by Xvfb for a 32 bit BGRX screen
+func xwdImage(width, height int) []byte {
+	const name = "Xvfb main window\x00"
+	headerSize := xwdHeaderSize + len(name)
+	header := make([]uint32, 25)
+	header[0] = uint32(headerSize)
+	header[1] = xwdFileVersion
+	header[2] = xwdZPixmap
+	header[3] = 24
+	header[4] = uint32(width)
+	header[5] = uint32(height)
+	header[7] = xwdLSBFirst
+	header[11] = 32
+	header[12] = uint32(width * 4)
+	header[14] = 0xff0000
+	header[15] = 0xff00
+	header[16] = 0xff
+	header[19] = 2
+
+	b := make([]byte, 0, headerSize+2*xwdColorSize+width*height*4)
+	for _, v := range header {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	b = append(b, name...)
+	b = append(b, make([]byte, 2*xwdColorSize)...)
+	for i := range width * height {
+		b = append(b, byte(i), 0, 0, 0)
+	}
+	return b
+}
+
+func TestParseXWDHeader(t *testing.T) {
+	b := xwdImage(4, 2)
+	layout, err := parseXWDHeader(b)
+	require.NoError(t, err)
+	require.Equal(t, xwdLayout{format: PixelFormatBGRA, width: 4, height: 2, stride: 16, offset: 117 + 24}, layout)
+
+	binary.BigEndian.PutUint32(b[11*4:], 16)
+	_, err = parseXWDHeader(b)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = parseXWDHeader(b[:10])
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+type testEncoder struct {
+	lock      sync.Mutex
+	frames    int
+	keyFrames int
+	closed    bool
+}
+
+func (e *testEncoder) MimeType() string { return "video/VP8" }
+
+func (e *testEncoder) Encode(frame Frame, keyFrame bool) ([]byte, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.frames++
+	if keyFrame {
+		e.keyFrames++
+	}
+	return []byte{1}, nil
+}
+
+func (e *testEncoder) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.closed = true
+	return nil
+}
+
+func (e *testEncoder) counts() (int, int) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.frames, e.keyFrames
+}
+
+func TestPublisher(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	encoder := &testEncoder{}
+	p, err := NewPublisher(ImageSource(func() (*image.RGBA, error) { return img, nil }), encoder,
+		WithFrameRate(100),
+		WithRegion(image.Rect(0, 0, 4, 4)),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "video/VP8", p.Track().Codec().MimeType)
+
+	require.NoError(t, p.Start())
+	require.Eventually(t, func() bool {
+		frames, _ := encoder.counts()
+		return frames >= 3
+	}, time.Second, 10*time.Millisecond)
+	_, keyFrames := encoder.counts()
+	require.Equal(t, 1, keyFrames)
+
+	p.handleRTCP(&rtcp.PictureLossIndication{})
+	require.Eventually(t, func() bool {
+		_, keyFrames := encoder.counts()
+		return keyFrames == 2
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, p.Close())
+	require.True(t, encoder.closed)
+	require.ErrorIs(t, p.Start(), ErrClosed)
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package screencapture
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// FramebufferConfig describes a raw framebuffer in a file or shared memory
+type FramebufferConfig struct {
+	Width  int
+	Height int
+	// bytes per row, 4 * Width when zero
+	Stride int
+	Format PixelFormat
+	// offset of the first pixel in the file
+	Offset int
+}
+
+type mappedSource struct {
+	data   []byte
+	layout xwdLayout
+}
+
+// NewFramebufferSource maps a raw framebuffer, e.g. a file in /dev/shm a compositor renders into
+func NewFramebufferSource(path string, config FramebufferConfig) (Source, error) {
+	if config.Stride == 0 {
+		config.Stride = config.Width * 4
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Stride < config.Width*4 {
+		return nil, fmt.Errorf("%w: %dx%d, stride %d", ErrUnsupportedFormat, config.Width, config.Height, config.Stride)
+	}
+	return mapSource(path, func([]byte) (xwdLayout, error) {
+		return xwdLayout{
+			format: config.Format,
+			width:  config.Width,
+			height: config.Height,
+			stride: config.Stride,
+			offset: config.Offset,
+		}, nil
+	})
+}
+
+// NewXvfbSource maps the screen of an Xvfb server started with -fbdir, e.g.
+// Xvfb :99 -screen 0 1280x720x24 -fbdir /dev/shm maps /dev/shm/Xvfb_screen0
+func NewXvfbSource(path string) (Source, error) {
+	return mapSource(path, parseXWDHeader)
+}
+
+func mapSource(path string, layout func([]byte) (xwdLayout, error)) (Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, err := unix.Mmap(int(f.Fd()), 0, int(info.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := layout(data)
+	if err == nil && l.offset+(l.height-1)*l.stride+l.width*4 > len(data) {
+		err = fmt.Errorf("%w: %dx%d does not fit into %d bytes", ErrUnsupportedFormat, l.width, l.height, len(data))
+	}
+	if err != nil {
+		_ = unix.Munmap(data)
+		return nil, err
+	}
+	return &mappedSource{data: data, layout: l}, nil
+}
+
+func (s *mappedSource) Frame() (Frame, error) {
+	if s.data == nil {
+		return Frame{}, ErrClosed
+	}
+	return Frame{
+		Format:    s.layout.format,
+		Width:     s.layout.width,
+		Height:    s.layout.height,
+		Stride:    s.layout.stride,
+		Data:      s.data[s.layout.offset:],
+		Timestamp: time.Now(),
+	}, nil
+}
+
+func (s *mappedSource) Close() error {
+	if s.data == nil {
+		return nil
+	}
+	err := unix.Munmap(s.data)
+	s.data = nil
+	return err
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package screencapture
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXvfbSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Xvfb_screen0")
+	require.NoError(t, os.WriteFile(path, xwdImage(4, 2), 0o600))
+
+	source, err := NewXvfbSource(path)
+	require.NoError(t, err)
+	frame, err := source.Frame()
+	require.NoError(t, err)
+	require.Equal(t, 4, frame.Width)
+	require.Equal(t, byte(5), frame.Data[frame.Stride+4])
+
+	cropped, err := frame.Crop(image.Rect(1, 1, 3, 2))
+	require.NoError(t, err)
+	require.Equal(t, 2, cropped.Width)
+	require.Equal(t, 1, cropped.Height)
+	require.Equal(t, byte(5), cropped.Data[0])
+	require.Len(t, cropped.Data, 8)
+	_, err = frame.Crop(image.Rect(0, 0, 5, 1))
+	require.ErrorIs(t, err, ErrInvalidRegion)
+
+	require.NoError(t, source.Close())
+	_, err = source.Frame()
+	require.ErrorIs(t, err, ErrClosed)
+
+	_, err = NewFramebufferSource(path, FramebufferConfig{Width: 100, Height: 100})
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
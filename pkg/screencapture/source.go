@@ -0,0 +1,123 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package screencapture publishes a virtual display or any other frame source as a video track, e.g. the
+// screen of a headless browser rendering into Xvfb. Frames are polled at a fixed rate and passed to a
+// pluggable Encoder, so no egress stack is needed.
+//
+// Sources are provided for Xvfb screens exported with -fbdir and for raw framebuffers in shared memory.
+// Wayland compositors can be captured by rendering into a shared memory framebuffer, or with a custom Source.
+package screencapture
+
+import (
+	"errors"
+	"image"
+	"time"
+)
+
+// PixelFormat is the memory layout of a 32 bit pixel
+type PixelFormat int
+
+const (
+	PixelFormatBGRA PixelFormat = iota
+	PixelFormatRGBA
+)
+
+func (f PixelFormat) String() string {
+	switch f {
+	case PixelFormatBGRA:
+		return "BGRA"
+	case PixelFormatRGBA:
+		return "RGBA"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported framebuffer format")
+	ErrInvalidRegion     = errors.New("capture region is outside of the frame")
+	ErrClosed            = errors.New("publisher is closed")
+)
+
+// Frame is a captured frame of 32 bit pixels. Data is only valid until the callee returns.
+type Frame struct {
+	Format PixelFormat
+	Width  int
+	Height int
+	// bytes per row, at least 4 * Width
+	Stride int
+	Data   []byte
+	// time the frame was captured
+	Timestamp time.Time
+}
+
+// Crop returns the part of the frame inside region, without copying
+func (f Frame) Crop(region image.Rectangle) (Frame, error) {
+	if region.Empty() || !region.In(image.Rect(0, 0, f.Width, f.Height)) {
+		return Frame{}, ErrInvalidRegion
+	}
+	start := region.Min.Y*f.Stride + region.Min.X*4
+	end := (region.Max.Y-1)*f.Stride + region.Max.X*4
+	f.Data = f.Data[start:end]
+	f.Width = region.Dx()
+	f.Height = region.Dy()
+	return f, nil
+}
+
+// Source provides frames to capture
+type Source interface {
+	// Frame returns the current content of the display
+	Frame() (Frame, error)
+	Close() error
+}
+
+// Encoder encodes frames into samples of a video codec, e.g. with a VP8 or H.264 library
+type Encoder interface {
+	// MimeType is the codec of the encoded samples, e.g. webrtc.MimeTypeVP8
+	MimeType() string
+	// Encode returns the encoded sample, nil to skip the frame. keyFrame is set when a key frame is requested.
+	Encode(frame Frame, keyFrame bool) ([]byte, error)
+	Close() error
+}
+
+// SourceFunc turns a function into a Source
+type SourceFunc func() (Frame, error)
+
+func (f SourceFunc) Frame() (Frame, error) {
+	return f()
+}
+
+func (f SourceFunc) Close() error {
+	return nil
+}
+
+// ImageSource captures images returned by fn, e.g. screenshots taken through a browser automation protocol
+func ImageSource(fn func() (*image.RGBA, error)) Source {
+	return SourceFunc(func() (Frame, error) {
+		img, err := fn()
+		if err != nil {
+			return Frame{}, err
+		}
+		b := img.Bounds()
+		return Frame{
+			Format:    PixelFormatRGBA,
+			Width:     b.Dx(),
+			Height:    b.Dy(),
+			Stride:    img.Stride,
+			Data:      img.Pix[img.PixOffset(b.Min.X, b.Min.Y):],
+			Timestamp: time.Now(),
+		}, nil
+	})
+}
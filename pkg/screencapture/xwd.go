@@ -0,0 +1,84 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package screencapture
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	xwdHeaderSize  = 100
+	xwdFileVersion = 7
+	xwdZPixmap     = 2
+	xwdColorSize   = 12
+	xwdLSBFirst    = 0
+)
+
+// xwdLayout is the location and format of the pixels of an XWD image, as written by Xvfb -fbdir
+type xwdLayout struct {
+	format PixelFormat
+	width  int
+	height int
+	stride int
+	offset int
+}
+
+// parseXWDHeader reads the header of an XWD image, the header is big endian
+func parseXWDHeader(b []byte) (xwdLayout, error) {
+	if len(b) < xwdHeaderSize {
+		return xwdLayout{}, fmt.Errorf("%w: short xwd header", ErrUnsupportedFormat)
+	}
+	field := func(i int) uint32 {
+		return binary.BigEndian.Uint32(b[i*4:])
+	}
+	var (
+		headerSize   = int(field(0))
+		version      = field(1)
+		pixmapFormat = field(2)
+		width        = int(field(4))
+		height       = int(field(5))
+		byteOrder    = field(7)
+		bitsPerPixel = field(11)
+		bytesPerLine = int(field(12))
+		redMask      = field(14)
+		blueMask     = field(16)
+		colors       = int(field(19))
+	)
+	if version != xwdFileVersion || pixmapFormat != xwdZPixmap || headerSize < xwdHeaderSize {
+		return xwdLayout{}, fmt.Errorf("%w: xwd version %d, pixmap format %d", ErrUnsupportedFormat, version, pixmapFormat)
+	}
+	if bitsPerPixel != 32 || byteOrder != xwdLSBFirst || bytesPerLine < width*4 {
+		return xwdLayout{}, fmt.Errorf("%w: %d bits per pixel, byte order %d", ErrUnsupportedFormat, bitsPerPixel, byteOrder)
+	}
+
+	var format PixelFormat
+	switch {
+	case redMask == 0xff0000 && blueMask == 0xff:
+		format = PixelFormatBGRA
+	case redMask == 0xff && blueMask == 0xff0000:
+		format = PixelFormatRGBA
+	default:
+		return xwdLayout{}, fmt.Errorf("%w: red mask %#x, blue mask %#x", ErrUnsupportedFormat, redMask, blueMask)
+	}
+
+	return xwdLayout{
+		format: format,
+		width:  width,
+		height: height,
+		stride: bytesPerLine,
+		offset: headerSize + colors*xwdColorSize,
+	}, nil
+}
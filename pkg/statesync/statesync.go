@@ -0,0 +1,313 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statesync replicates a key-value document among participants of a room over reliable
+// data packets. Conflicting writes are resolved as last-writer-wins using a Lamport clock, or with
+// a custom Resolver. Peers send periodic snapshots, and answer sync requests of late joiners.
+package statesync
+
+import (
+	"encoding/json"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/logger"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+const (
+	DefaultTopic            = "lk.statesync"
+	DefaultSnapshotInterval = 30 * time.Second
+)
+
+type messageType string
+
+const (
+	messageUpdate      messageType = "update"
+	messageSnapshot    messageType = "snapshot"
+	messageSyncRequest messageType = "sync_request"
+)
+
+// Entry is the replicated state of a key, deleted keys are kept as tombstones
+type Entry struct {
+	Value   json.RawMessage `json:"v,omitempty"`
+	Deleted bool            `json:"d,omitempty"`
+	Clock   uint64          `json:"c"`
+	Writer  string          `json:"w"`
+}
+
+// Resolver returns true if incoming should replace current
+type Resolver func(key string, current, incoming Entry) bool
+
+// LastWriterWins keeps the entry with the higher clock, ties are broken by writer identity
+func LastWriterWins(_ string, current, incoming Entry) bool {
+	if incoming.Clock != current.Clock {
+		return incoming.Clock > current.Clock
+	}
+	return incoming.Writer > current.Writer
+}
+
+type message struct {
+	Type    messageType      `json:"t"`
+	Entries map[string]Entry `json:"e,omitempty"`
+}
+
+// Document is a replicated key-value document. Received packets must be passed to HandleDataPacket,
+// usually from RoomCallback.OnDataPacket.
+type Document struct {
+	topic            string
+	snapshotInterval time.Duration
+	resolver         Resolver
+	onChange         func(key string, entry Entry, from string)
+	logger           logger.Logger
+
+	localIdentity func() string
+	send          func(data []byte, destinations []string) error
+
+	lock    sync.RWMutex
+	entries map[string]Entry
+	clock   uint64
+	stop    chan struct{}
+}
+
+type Option func(*Document)
+
+// WithTopic sets the data packet topic used by the document, for multiple documents in a room.
+func WithTopic(topic string) Option {
+	return func(d *Document) {
+		d.topic = topic
+	}
+}
+
+// WithSnapshotInterval sets how often the full document is broadcast, zero disables snapshots.
+func WithSnapshotInterval(interval time.Duration) Option {
+	return func(d *Document) {
+		d.snapshotInterval = interval
+	}
+}
+
+// WithResolver replaces last-writer-wins conflict resolution.
+func WithResolver(resolver Resolver) Option {
+	return func(d *Document) {
+		d.resolver = resolver
+	}
+}
+
+// WithOnChange sets a function called for every key changed by a remote participant.
+func WithOnChange(f func(key string, entry Entry, from string)) Option {
+	return func(d *Document) {
+		d.onChange = f
+	}
+}
+
+// WithLogger sets the logger for the Document.
+func WithLogger(logger logger.Logger) Option {
+	return func(d *Document) {
+		d.logger = logger
+	}
+}
+
+func NewDocument(room *lksdk.Room, opts ...Option) *Document {
+	d := &Document{
+		topic:            DefaultTopic,
+		snapshotInterval: DefaultSnapshotInterval,
+		resolver:         LastWriterWins,
+		logger:           logger.GetLogger(),
+		entries:          make(map[string]Entry),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.localIdentity = func() string {
+		return room.LocalParticipant.Identity()
+	}
+	d.send = func(data []byte, destinations []string) error {
+		return room.LocalParticipant.PublishDataPacket(
+			lksdk.UserData(data),
+			lksdk.WithDataPublishTopic(d.topic),
+			lksdk.WithDataPublishReliable(true),
+			lksdk.WithDataPublishDestination(destinations),
+		)
+	}
+	return d
+}
+
+// Start requests the current document from other participants and starts sending snapshots.
+// It should be called once connected to the room.
+func (d *Document) Start() error {
+	d.lock.Lock()
+	if d.stop != nil {
+		d.lock.Unlock()
+		return nil
+	}
+	stop := make(chan struct{})
+	d.stop = stop
+	d.lock.Unlock()
+
+	if d.snapshotInterval > 0 {
+		go d.snapshotWorker(stop)
+	}
+	return d.sendMessage(&message{Type: messageSyncRequest}, nil)
+}
+
+// Close stops sending snapshots, the local copy of the document remains readable.
+func (d *Document) Close() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+}
+
+// Get returns the value of a key, false if it is not set or deleted
+func (d *Document) Get(key string) (json.RawMessage, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	e, ok := d.entries[key]
+	if !ok || e.Deleted {
+		return nil, false
+	}
+	return e.Value, true
+}
+
+// Values returns all keys that are set
+func (d *Document) Values() map[string]json.RawMessage {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	values := make(map[string]json.RawMessage, len(d.entries))
+	for k, e := range d.entries {
+		if !e.Deleted {
+			values[k] = e.Value
+		}
+	}
+	return values
+}
+
+// Set encodes value as JSON, stores it and replicates it to other participants
+func (d *Document) Set(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return d.write(key, Entry{Value: data})
+}
+
+// Delete removes a key, replicated as a tombstone
+func (d *Document) Delete(key string) error {
+	return d.write(key, Entry{Deleted: true})
+}
+
+func (d *Document) write(key string, e Entry) error {
+	d.lock.Lock()
+	d.clock++
+	e.Clock = d.clock
+	e.Writer = d.localIdentity()
+	d.entries[key] = e
+	d.lock.Unlock()
+
+	return d.sendMessage(&message{
+		Type:    messageUpdate,
+		Entries: map[string]Entry{key: e},
+	}, nil)
+}
+
+// HandleDataPacket merges updates received on the document topic.
+// It returns false for packets that are not for the document.
+func (d *Document) HandleDataPacket(packet lksdk.DataPacket, params lksdk.DataReceiveParams) bool {
+	user, ok := packet.(*lksdk.UserDataPacket)
+	if !ok || user.Topic != d.topic {
+		return false
+	}
+
+	var msg message
+	if err := json.Unmarshal(user.Payload, &msg); err != nil {
+		d.logger.Debugw("could not decode state sync message", "sender", params.SenderIdentity, "error", err)
+		return true
+	}
+
+	switch msg.Type {
+	case messageSyncRequest:
+		// answer late joiner with the full document
+		if err := d.sendMessage(d.snapshot(), []string{params.SenderIdentity}); err != nil {
+			d.logger.Warnw("could not send state snapshot", err, "destination", params.SenderIdentity)
+		}
+	case messageUpdate, messageSnapshot:
+		d.merge(msg.Entries, params.SenderIdentity)
+	}
+	return true
+}
+
+func (d *Document) merge(entries map[string]Entry, from string) {
+	changed := make(map[string]Entry)
+
+	d.lock.Lock()
+	for key, incoming := range entries {
+		// keep the clock ahead of everything seen, so that local writes win over older ones
+		d.clock = max(d.clock, incoming.Clock)
+		if current, ok := d.entries[key]; ok && !d.resolver(key, current, incoming) {
+			continue
+		}
+		d.entries[key] = incoming
+		changed[key] = incoming
+	}
+	d.lock.Unlock()
+
+	if d.onChange != nil {
+		for key, e := range changed {
+			d.onChange(key, e, from)
+		}
+	}
+}
+
+func (d *Document) snapshot() *message {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	return &message{
+		Type:    messageSnapshot,
+		Entries: maps.Clone(d.entries),
+	}
+}
+
+func (d *Document) snapshotWorker(stop chan struct{}) {
+	ticker := time.NewTicker(d.snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if msg := d.snapshot(); len(msg.Entries) > 0 {
+				if err := d.sendMessage(msg, nil); err != nil {
+					d.logger.Debugw("could not send state snapshot", "error", err)
+				}
+			}
+		}
+	}
+}
+
+func (d *Document) sendMessage(msg *message, destinations []string) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return d.send(data, destinations)
+}
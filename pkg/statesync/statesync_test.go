@@ -0,0 +1,95 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statesync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+// newTestDocuments returns documents that deliver packets to each other synchronously
+func newTestDocuments(identities ...string) []*Document {
+	docs := make([]*Document, len(identities))
+	for i, identity := range identities {
+		d := &Document{
+			topic:         DefaultTopic,
+			resolver:      LastWriterWins,
+			logger:        logger.GetLogger(),
+			entries:       make(map[string]Entry),
+			localIdentity: func() string { return identity },
+		}
+		d.send = func(data []byte, destinations []string) error {
+			for j, other := range docs {
+				if j == i || (len(destinations) > 0 && destinations[0] != identities[j]) {
+					continue
+				}
+				other.HandleDataPacket(&lksdk.UserDataPacket{Topic: DefaultTopic, Payload: data}, lksdk.DataReceiveParams{SenderIdentity: identity})
+			}
+			return nil
+		}
+		docs[i] = d
+	}
+	return docs
+}
+
+func TestDocumentReplication(t *testing.T) {
+	docs := newTestDocuments("a", "b", "c")
+	a, b, c := docs[0], docs[1], docs[2]
+
+	require.NoError(t, a.Set("title", "hello"))
+	v, ok := b.Get("title")
+	require.True(t, ok)
+	require.JSONEq(t, `"hello"`, string(v))
+
+	// b saw a's write, so its write is newer
+	require.NoError(t, b.Set("title", "world"))
+	v, _ = a.Get("title")
+	require.JSONEq(t, `"world"`, string(v))
+
+	require.NoError(t, a.Delete("title"))
+	_, ok = c.Get("title")
+	require.False(t, ok)
+}
+
+func TestDocumentConcurrentWrites(t *testing.T) {
+	docs := newTestDocuments("a", "b")
+	a, b := docs[0], docs[1]
+
+	// same clock on both sides, higher writer identity wins everywhere
+	a.merge(map[string]Entry{"k": {Value: []byte(`1`), Clock: 5, Writer: "a"}}, "a")
+	a.merge(map[string]Entry{"k": {Value: []byte(`2`), Clock: 5, Writer: "b"}}, "b")
+	b.merge(map[string]Entry{"k": {Value: []byte(`2`), Clock: 5, Writer: "b"}}, "b")
+	b.merge(map[string]Entry{"k": {Value: []byte(`1`), Clock: 5, Writer: "a"}}, "a")
+	require.Equal(t, a.Values(), b.Values())
+}
+
+func TestDocumentLateJoiner(t *testing.T) {
+	docs := newTestDocuments("a", "b")
+	a, b := docs[0], docs[1]
+
+	// b not listening yet
+	a.entries["k"] = Entry{Value: []byte(`"v"`), Clock: 1, Writer: "a"}
+	require.NoError(t, b.Start())
+	defer b.Close()
+
+	v, ok := b.Get("k")
+	require.True(t, ok)
+	require.JSONEq(t, `"v"`, string(v))
+}
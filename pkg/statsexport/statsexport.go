@@ -0,0 +1,338 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statsexport records the WebRTC stats of a room session over time and dumps them in the
+// format of chrome://webrtc-internals, so that tools built for browser dumps can be used to analyze
+// sessions of the Go SDK.
+package statsexport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+
+	"github.com/livekit/protocol/logger"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+const (
+	DefaultInterval   = time.Second
+	DefaultMaxSamples = 1000
+
+	// time format used by webrtc-internals
+	timeLayout = "2006-01-02T15:04:05.000Z"
+)
+
+// Dump is a webrtc-internals dump
+type Dump struct {
+	GetUserMedia    []any                         `json:"getUserMedia"`
+	PeerConnections map[string]PeerConnectionDump `json:"PeerConnections"`
+	UserAgent       string                        `json:"UserAgent"`
+}
+
+// PeerConnectionDump holds the recorded stats of one peer connection
+type PeerConnectionDump struct {
+	PID              int                   `json:"pid"`
+	RTCConfiguration string                `json:"rtcConfiguration"`
+	Constraints      string                `json:"constraints"`
+	URL              string                `json:"url"`
+	UpdateLog        []UpdateLogEntry      `json:"updateLog"`
+	Stats            map[string]StatSeries `json:"stats"`
+}
+
+type UpdateLogEntry struct {
+	Time  string `json:"time"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// StatSeries holds the values of one attribute of a stats object, keyed by "<stats id>-<attribute>".
+// As in webrtc-internals, Values is a JSON encoded array.
+type StatSeries struct {
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	StatsType string `json:"statsType"`
+	Values    string `json:"values"`
+}
+
+type Option func(*Exporter)
+
+// WithInterval sets how often stats are sampled, defaults to DefaultInterval
+func WithInterval(interval time.Duration) Option {
+	return func(e *Exporter) {
+		e.interval = interval
+	}
+}
+
+// WithMaxSamples limits the number of values kept per attribute, older values are dropped.
+// Defaults to DefaultMaxSamples, as webrtc-internals does.
+func WithMaxSamples(n int) Option {
+	return func(e *Exporter) {
+		e.maxSamples = n
+	}
+}
+
+// WithOutputFile writes the dump to path every interval and when the exporter is closed
+func WithOutputFile(path string, interval time.Duration) Option {
+	return func(e *Exporter) {
+		e.outputPath = path
+		e.outputInterval = interval
+	}
+}
+
+// WithUserAgent sets the UserAgent of the dump, used by some tools to label the session
+func WithUserAgent(userAgent string) Option {
+	return func(e *Exporter) {
+		e.userAgent = userAgent
+	}
+}
+
+type series struct {
+	statsType string
+	start     time.Time
+	end       time.Time
+	values    []any
+}
+
+type peerConnection struct {
+	series map[string]*series
+}
+
+// Exporter samples the stats of a room and keeps them as time series
+type Exporter struct {
+	interval       time.Duration
+	maxSamples     int
+	outputPath     string
+	outputInterval time.Duration
+	userAgent      string
+
+	stats func() lksdk.SessionStats
+	now   func() time.Time
+
+	lock    sync.Mutex
+	pcs     map[string]*peerConnection
+	started bool
+	close   chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewExporter creates an exporter for the session of room, call Start to begin sampling
+func NewExporter(room *lksdk.Room, opts ...Option) *Exporter {
+	return newExporter(room.GetSessionStats, opts...)
+}
+
+func newExporter(stats func() lksdk.SessionStats, opts ...Option) *Exporter {
+	e := &Exporter{
+		interval:   DefaultInterval,
+		maxSamples: DefaultMaxSamples,
+		userAgent:  "livekit-server-sdk-go",
+		stats:      stats,
+		now:        time.Now,
+		pcs:        make(map[string]*peerConnection),
+		close:      make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Start samples stats periodically until Close is called
+func (e *Exporter) Start() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.started {
+		return
+	}
+	e.started = true
+	go e.run()
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	var lastOutput time.Time
+	for {
+		select {
+		case <-e.close:
+			return
+		case <-ticker.C:
+			e.Sample()
+			if e.outputPath != "" && time.Since(lastOutput) >= e.outputInterval {
+				lastOutput = time.Now()
+				if err := e.WriteFile(e.outputPath); err != nil {
+					logger.Warnw("could not write stats dump", err, "path", e.outputPath)
+				}
+			}
+		}
+	}
+}
+
+// Close stops sampling and writes the output file when configured
+func (e *Exporter) Close() error {
+	e.once.Do(func() {
+		close(e.close)
+	})
+	e.lock.Lock()
+	started := e.started
+	e.lock.Unlock()
+	if started {
+		<-e.done
+	}
+
+	if e.outputPath != "" {
+		return e.WriteFile(e.outputPath)
+	}
+	return nil
+}
+
+// Sample records the current stats
+func (e *Exporter) Sample() {
+	stats := e.stats()
+	now := e.now()
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.addReportLocked("publisher", stats.Publisher, now)
+	e.addReportLocked("subscriber", stats.Subscriber, now)
+}
+
+func (e *Exporter) addReportLocked(name string, report webrtc.StatsReport, now time.Time) {
+	if report == nil {
+		return
+	}
+	pc := e.pcs[name]
+	if pc == nil {
+		pc = &peerConnection{series: make(map[string]*series)}
+		e.pcs[name] = pc
+	}
+
+	for id, s := range report {
+		data, err := json.Marshal(s)
+		if err != nil {
+			continue
+		}
+		var attrs map[string]any
+		if err := json.Unmarshal(data, &attrs); err != nil {
+			continue
+		}
+		statsType, _ := attrs["type"].(string)
+		for attr, value := range attrs {
+			switch attr {
+			case "id", "type", "timestamp":
+				continue
+			}
+			switch value.(type) {
+			case float64, string, bool:
+			default:
+				// nested values are not plotted by webrtc-internals
+				continue
+			}
+
+			key := id + "-" + attr
+			ser := pc.series[key]
+			if ser == nil {
+				ser = &series{statsType: statsType, start: now}
+				pc.series[key] = ser
+			}
+			ser.end = now
+			ser.values = append(ser.values, value)
+			if e.maxSamples > 0 && len(ser.values) > e.maxSamples {
+				ser.values = ser.values[len(ser.values)-e.maxSamples:]
+			}
+		}
+	}
+}
+
+// Dump returns the recorded stats in webrtc-internals format
+func (e *Exporter) Dump() Dump {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	dump := Dump{
+		GetUserMedia:    []any{},
+		PeerConnections: make(map[string]PeerConnectionDump, len(e.pcs)),
+		UserAgent:       e.userAgent,
+	}
+	names := make([]string, 0, len(e.pcs))
+	for name := range e.pcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		pc := e.pcs[name]
+		pcDump := PeerConnectionDump{
+			PID:       i + 1,
+			URL:       name,
+			UpdateLog: []UpdateLogEntry{},
+			Stats:     make(map[string]StatSeries, len(pc.series)),
+		}
+		for key, ser := range pc.series {
+			values, err := json.Marshal(ser.values)
+			if err != nil {
+				continue
+			}
+			pcDump.Stats[key] = StatSeries{
+				StartTime: ser.start.UTC().Format(timeLayout),
+				EndTime:   ser.end.UTC().Format(timeLayout),
+				StatsType: ser.statsType,
+				Values:    string(values),
+			}
+		}
+		dump.PeerConnections[name] = pcDump
+	}
+	return dump
+}
+
+// WriteTo writes the dump as JSON
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(e.Dump())
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteFile writes the dump to path, replacing the previous file atomically
+func (e *Exporter) WriteFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = e.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("could not write stats dump: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
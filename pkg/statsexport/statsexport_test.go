@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+func TestExporterDump(t *testing.T) {
+	packets := uint32(0)
+	e := newExporter(func() lksdk.SessionStats {
+		packets += 10
+		return lksdk.SessionStats{
+			Subscriber: webrtc.StatsReport{
+				"IT01": webrtc.InboundRTPStreamStats{
+					ID:              "IT01",
+					Type:            webrtc.StatsTypeInboundRTP,
+					Kind:            "audio",
+					PacketsReceived: packets,
+				},
+			},
+		}
+	}, WithMaxSamples(2), WithUserAgent("test"))
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	now := start
+	e.now = func() time.Time { return now }
+
+	for range 3 {
+		e.Sample()
+		now = now.Add(time.Second)
+	}
+
+	var buf bytes.Buffer
+	_, err := e.WriteTo(&buf)
+	require.NoError(t, err)
+
+	var dump Dump
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &dump))
+	require.Equal(t, "test", dump.UserAgent)
+	require.Len(t, dump.PeerConnections, 1)
+
+	pc := dump.PeerConnections["subscriber"]
+	received, ok := pc.Stats["IT01-packetsReceived"]
+	require.True(t, ok)
+	require.Equal(t, "inbound-rtp", received.StatsType)
+	require.Equal(t, "[20,30]", received.Values)
+	require.Equal(t, "2024-01-02T03:04:05.000Z", received.StartTime)
+	require.Equal(t, "2024-01-02T03:04:07.000Z", received.EndTime)
+	require.Equal(t, `["audio","audio"]`, pc.Stats["IT01-kind"].Values)
+	_, ok = pc.Stats["IT01-timestamp"]
+	require.False(t, ok)
+}
+
+func TestExporterOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+	e := newExporter(func() lksdk.SessionStats {
+		return lksdk.SessionStats{
+			Publisher: webrtc.StatsReport{
+				"T01": webrtc.TransportStats{ID: "T01", Type: webrtc.StatsTypeTransport, BytesSent: 100},
+			},
+		}
+	}, WithInterval(10*time.Millisecond), WithOutputFile(path, time.Hour))
+	e.Start()
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, e.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var dump Dump
+	require.NoError(t, json.Unmarshal(data, &dump))
+	require.Contains(t, dump.PeerConnections["publisher"].Stats, "T01-bytesSent")
+}
@@ -0,0 +1,314 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v4l2capture
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	protoLogger "github.com/livekit/protocol/logger"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+const pollTimeout = 500 * time.Millisecond
+
+type Option func(*Capture)
+
+// WithEncoder allows capturing MJPEG or raw frames, which are passed to the encoder before being published
+func WithEncoder(encoder Encoder) Option {
+	return func(c *Capture) {
+		c.encoder = encoder
+	}
+}
+
+// WithLogger sets the logger, logger.GetLogger if not set
+func WithLogger(logger protoLogger.Logger) Option {
+	return func(c *Capture) {
+		c.log = logger
+	}
+}
+
+// WithOnError is called for every capture error before the device is restarted, and with the last error
+// when capture is given up after Config.MaxRestarts
+func WithOnError(onError func(err error, restarting bool)) Option {
+	return func(c *Capture) {
+		c.onError = onError
+	}
+}
+
+// Capture reads frames of a V4L2 camera and writes them to a LocalSampleTrack.
+// Publish Track, then call Start.
+type Capture struct {
+	cfg     Config
+	encoder Encoder
+	log     protoLogger.Logger
+	onError func(err error, restarting bool)
+
+	track  *lksdk.LocalSampleTrack
+	format PixelFormat
+	// passthrough when the camera delivers H.264
+	passthrough bool
+	width       int
+	height      int
+
+	lock    sync.Mutex
+	dev     *device
+	started bool
+	closed  bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewCapture opens the camera, negotiates the format and creates the track the frames are written to
+func NewCapture(cfg Config, opts ...Option) (*Capture, error) {
+	c := &Capture{
+		cfg:  cfg.withDefaults(),
+		log:  protoLogger.GetLogger(),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.log = c.log.WithValues("device", c.cfg.Device)
+
+	dev, err := openDevice(c.cfg.Device)
+	if err != nil {
+		return nil, err
+	}
+	offered, err := dev.formats()
+	if err != nil {
+		dev.close()
+		return nil, err
+	}
+	c.format, c.passthrough, err = negotiateFormat(c.cfg.Formats, offered, c.encoder)
+	if err != nil {
+		dev.close()
+		return nil, err
+	}
+	if err := c.configure(dev); err != nil {
+		dev.close()
+		return nil, err
+	}
+	c.dev = dev
+
+	mimeType := webrtc.MimeTypeH264
+	if !c.passthrough {
+		mimeType = c.encoder.MimeType()
+	}
+	c.track, err = lksdk.NewLocalSampleTrack(webrtc.RTPCodecCapability{MimeType: mimeType, ClockRate: 90000})
+	if err != nil {
+		dev.close()
+		return nil, err
+	}
+	c.log.Infow("camera opened", "format", c.format.String(), "width", c.width, "height", c.height, "mime", mimeType)
+	return c, nil
+}
+
+// configure applies format and frame rate, and allocates the buffers
+func (c *Capture) configure(dev *device) error {
+	width, height, err := dev.setFormat(c.format, c.cfg.Width, c.cfg.Height)
+	if err != nil {
+		return fmt.Errorf("could not set format %s: %w", c.format, err)
+	}
+	if c.width != 0 && (width != c.width || height != c.height) {
+		// the track was created for the first size, restarts keep it
+		return fmt.Errorf("camera size changed from %dx%d to %dx%d", c.width, c.height, width, height)
+	}
+	c.width, c.height = width, height
+	if err := dev.setFrameRate(c.cfg.FPS); err != nil {
+		// not all drivers allow setting the frame rate
+		c.log.Debugw("could not set frame rate", "error", err)
+	}
+	return dev.start(c.cfg.Buffers)
+}
+
+// Track returns the track to publish
+func (c *Capture) Track() *lksdk.LocalSampleTrack {
+	return c.track
+}
+
+// Format returns the negotiated pixel format and frame size
+func (c *Capture) Format() (PixelFormat, int, int) {
+	return c.format, c.width, c.height
+}
+
+// Start starts writing frames to the track
+func (c *Capture) Start() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if c.started {
+		return nil
+	}
+	c.started = true
+	go c.run()
+	return nil
+}
+
+func (c *Capture) run() {
+	defer close(c.done)
+
+	restarts := 0
+	for {
+		err := c.capture(func() { restarts = 0 })
+		if err == nil {
+			return
+		}
+		restarts++
+		giveUp := c.cfg.MaxRestarts > 0 && restarts > c.cfg.MaxRestarts
+		c.log.Warnw("camera capture failed", err, "restarting", !giveUp)
+		if c.onError != nil {
+			c.onError(err, !giveUp)
+		}
+		if giveUp {
+			return
+		}
+
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(c.cfg.RestartDelay):
+		}
+		if err := c.reopen(); err != nil {
+			c.log.Debugw("could not reopen camera", "error", err)
+		}
+	}
+}
+
+// capture writes frames until stopped (nil) or an error occurs
+func (c *Capture) capture(onFrame func()) error {
+	c.lock.Lock()
+	dev := c.dev
+	c.lock.Unlock()
+	if dev == nil {
+		return ErrNotCaptureDevice
+	}
+
+	var prevTimestamp time.Duration
+	for {
+		select {
+		case <-c.stop:
+			return nil
+		default:
+		}
+
+		buf, data, err := dev.dequeue(pollTimeout)
+		if err != nil {
+			return err
+		}
+		if buf == nil {
+			continue
+		}
+
+		timestamp := bufferTimestamp(buf)
+		frame := Frame{
+			Format:    c.format,
+			Width:     c.width,
+			Height:    c.height,
+			Data:      data,
+			Sequence:  buf.sequence,
+			Timestamp: timestamp,
+		}
+		duration := frameDuration(prevTimestamp, timestamp, c.cfg.FPS)
+		prevTimestamp = timestamp
+		err = c.writeFrame(frame, duration)
+		if qerr := dev.queue(buf.index); qerr != nil {
+			return qerr
+		}
+		if err != nil {
+			c.log.Debugw("could not write frame", "error", err)
+			continue
+		}
+		onFrame()
+	}
+}
+
+func (c *Capture) writeFrame(frame Frame, duration time.Duration) error {
+	if len(frame.Data) == 0 {
+		return nil
+	}
+	var sample []byte
+	if c.passthrough {
+		// the buffer is reused by the driver, the track may hold on to the sample
+		sample = append([]byte(nil), frame.Data...)
+	} else {
+		var err error
+		if sample, err = c.encoder.Encode(frame); err != nil || sample == nil {
+			return err
+		}
+	}
+	return c.track.WriteSample(media.Sample{Data: sample, Duration: duration}, nil)
+}
+
+// reopen closes and reopens the device with the negotiated format
+func (c *Capture) reopen() error {
+	c.lock.Lock()
+	if c.dev != nil {
+		c.dev.close()
+		c.dev = nil
+	}
+	c.lock.Unlock()
+
+	dev, err := openDevice(c.cfg.Device)
+	if err != nil {
+		return err
+	}
+	if err := c.configure(dev); err != nil {
+		dev.close()
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		dev.close()
+		return ErrClosed
+	}
+	c.dev = dev
+	return nil
+}
+
+// Close stops capturing and releases the camera, the track is not unpublished
+func (c *Capture) Close() error {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil
+	}
+	c.closed = true
+	started := c.started
+	close(c.stop)
+	c.lock.Unlock()
+
+	if started {
+		<-c.done
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.dev != nil {
+		c.dev.close()
+		c.dev = nil
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v4l2capture
+
+import (
+	"errors"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// subset of linux/videodev2.h
+const (
+	bufTypeVideoCapture = 1
+	memoryMMAP          = 1
+	fieldAny            = 0
+
+	capVideoCapture = 0x00000001
+	capStreaming    = 0x04000000
+	capDeviceCaps   = 0x80000000
+)
+
+type v4l2Capability struct {
+	driver       [16]byte
+	card         [32]byte
+	busInfo      [32]byte
+	version      uint32
+	capabilities uint32
+	deviceCaps   uint32
+	reserved     [3]uint32
+}
+
+type v4l2FmtDesc struct {
+	index       uint32
+	typ         uint32
+	flags       uint32
+	description [32]byte
+	pixelFormat uint32
+	mbusCode    uint32
+	reserved    [3]uint32
+}
+
+type v4l2PixFormat struct {
+	width        uint32
+	height       uint32
+	pixelFormat  uint32
+	field        uint32
+	bytesPerLine uint32
+	sizeImage    uint32
+	colorspace   uint32
+	priv         uint32
+	flags        uint32
+	ycbcrEnc     uint32
+	quantization uint32
+	xferFunc     uint32
+}
+
+type v4l2Format struct {
+	typ uint32
+	// union of 200 bytes, pointer aligned in C, uint64 gives the same offset on 32 and 64 bit
+	fmt [25]uint64
+}
+
+func (f *v4l2Format) pix() *v4l2PixFormat {
+	return (*v4l2PixFormat)(unsafe.Pointer(&f.fmt[0]))
+}
+
+type v4l2Fract struct {
+	numerator   uint32
+	denominator uint32
+}
+
+type v4l2StreamParm struct {
+	typ          uint32
+	capability   uint32
+	captureMode  uint32
+	timePerFrame v4l2Fract
+	extendedMode uint32
+	readBuffers  uint32
+	reserved     [4]uint32
+	// rest of the 200 byte union
+	pad [160]byte
+}
+
+type v4l2RequestBuffers struct {
+	count        uint32
+	typ          uint32
+	memory       uint32
+	capabilities uint32
+	flags        uint8
+	reserved     [3]uint8
+}
+
+type v4l2Timecode struct {
+	typ      uint32
+	flags    uint32
+	frames   uint8
+	seconds  uint8
+	minutes  uint8
+	hours    uint8
+	userBits [4]uint8
+}
+
+type v4l2Buffer struct {
+	index     uint32
+	typ       uint32
+	bytesUsed uint32
+	flags     uint32
+	field     uint32
+	timestamp unix.Timeval
+	timecode  v4l2Timecode
+	sequence  uint32
+	memory    uint32
+	// union of offset, userptr, planes and fd
+	m         uintptr
+	length    uint32
+	reserved2 uint32
+	requestFD int32
+}
+
+const (
+	iocWrite = 1
+	iocRead  = 2
+)
+
+func ioc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'V'<<8 | nr
+}
+
+var (
+	vidiocQueryCap  = ioc(iocRead, 0, unsafe.Sizeof(v4l2Capability{}))
+	vidiocEnumFmt   = ioc(iocRead|iocWrite, 2, unsafe.Sizeof(v4l2FmtDesc{}))
+	vidiocSFmt      = ioc(iocRead|iocWrite, 5, unsafe.Sizeof(v4l2Format{}))
+	vidiocReqBufs   = ioc(iocRead|iocWrite, 8, unsafe.Sizeof(v4l2RequestBuffers{}))
+	vidiocQueryBuf  = ioc(iocRead|iocWrite, 9, unsafe.Sizeof(v4l2Buffer{}))
+	vidiocQBuf      = ioc(iocRead|iocWrite, 15, unsafe.Sizeof(v4l2Buffer{}))
+	vidiocDQBuf     = ioc(iocRead|iocWrite, 17, unsafe.Sizeof(v4l2Buffer{}))
+	vidiocStreamOn  = ioc(iocWrite, 18, unsafe.Sizeof(int32(0)))
+	vidiocStreamOff = ioc(iocWrite, 19, unsafe.Sizeof(int32(0)))
+	vidiocSParm     = ioc(iocRead|iocWrite, 22, unsafe.Sizeof(v4l2StreamParm{}))
+)
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	for {
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+		switch errno {
+		case 0:
+			return nil
+		case unix.EINTR:
+			continue
+		default:
+			return errno
+		}
+	}
+}
+
+// device is an opened V4L2 capture device using memory mapped buffers
+type device struct {
+	fd      int
+	buffers [][]byte
+	started bool
+}
+
+func openDevice(path string) (*device, error) {
+	fd, err := unix.Open(path, unix.O_RDWR|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	d := &device{fd: fd}
+
+	var cp v4l2Capability
+	if err := ioctl(fd, vidiocQueryCap, unsafe.Pointer(&cp)); err != nil {
+		d.close()
+		return nil, err
+	}
+	caps := cp.capabilities
+	if caps&capDeviceCaps != 0 {
+		caps = cp.deviceCaps
+	}
+	if caps&capVideoCapture == 0 || caps&capStreaming == 0 {
+		d.close()
+		return nil, ErrNotCaptureDevice
+	}
+	return d, nil
+}
+
+func (d *device) formats() ([]PixelFormat, error) {
+	var formats []PixelFormat
+	for i := uint32(0); ; i++ {
+		desc := v4l2FmtDesc{index: i, typ: bufTypeVideoCapture}
+		if err := ioctl(d.fd, vidiocEnumFmt, unsafe.Pointer(&desc)); err != nil {
+			if errors.Is(err, unix.EINVAL) {
+				return formats, nil
+			}
+			return nil, err
+		}
+		formats = append(formats, PixelFormat(desc.pixelFormat))
+	}
+}
+
+// setFormat requests a format, the driver may adjust the size which is returned
+func (d *device) setFormat(format PixelFormat, width, height int) (int, int, error) {
+	f := v4l2Format{typ: bufTypeVideoCapture}
+	pix := f.pix()
+	pix.width = uint32(width)
+	pix.height = uint32(height)
+	pix.pixelFormat = uint32(format)
+	pix.field = fieldAny
+	if err := ioctl(d.fd, vidiocSFmt, unsafe.Pointer(&f)); err != nil {
+		return 0, 0, err
+	}
+	if PixelFormat(pix.pixelFormat) != format {
+		return 0, 0, ErrNoSupportedFormat
+	}
+	return int(pix.width), int(pix.height), nil
+}
+
+func (d *device) setFrameRate(fps int) error {
+	parm := v4l2StreamParm{
+		typ:          bufTypeVideoCapture,
+		timePerFrame: v4l2Fract{numerator: 1, denominator: uint32(fps)},
+	}
+	return ioctl(d.fd, vidiocSParm, unsafe.Pointer(&parm))
+}
+
+func (d *device) start(count int) error {
+	req := v4l2RequestBuffers{count: uint32(count), typ: bufTypeVideoCapture, memory: memoryMMAP}
+	if err := ioctl(d.fd, vidiocReqBufs, unsafe.Pointer(&req)); err != nil {
+		return err
+	}
+	for i := uint32(0); i < req.count; i++ {
+		buf := v4l2Buffer{index: i, typ: bufTypeVideoCapture, memory: memoryMMAP}
+		if err := ioctl(d.fd, vidiocQueryBuf, unsafe.Pointer(&buf)); err != nil {
+			return err
+		}
+		// m holds the 32 bit offset of the buffer for memory mapped I/O
+		data, err := unix.Mmap(d.fd, int64(uint32(buf.m)), int(buf.length), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+		if err != nil {
+			return err
+		}
+		d.buffers = append(d.buffers, data)
+		if err := d.queue(i); err != nil {
+			return err
+		}
+	}
+
+	typ := int32(bufTypeVideoCapture)
+	if err := ioctl(d.fd, vidiocStreamOn, unsafe.Pointer(&typ)); err != nil {
+		return err
+	}
+	d.started = true
+	return nil
+}
+
+func (d *device) queue(index uint32) error {
+	buf := v4l2Buffer{index: index, typ: bufTypeVideoCapture, memory: memoryMMAP}
+	return ioctl(d.fd, vidiocQBuf, unsafe.Pointer(&buf))
+}
+
+// dequeue waits up to timeout for a filled buffer, the buffer has to be queued again after use.
+// Returns a nil buffer on timeout.
+func (d *device) dequeue(timeout time.Duration) (*v4l2Buffer, []byte, error) {
+	fds := []unix.PollFd{{Fd: int32(d.fd), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout/time.Millisecond))
+	if err != nil {
+		if errors.Is(err, unix.EINTR) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	if n == 0 {
+		return nil, nil, nil
+	}
+	if fds[0].Revents&(unix.POLLERR|unix.POLLHUP|unix.POLLNVAL) != 0 {
+		return nil, nil, unix.EIO
+	}
+
+	buf := &v4l2Buffer{typ: bufTypeVideoCapture, memory: memoryMMAP}
+	if err := ioctl(d.fd, vidiocDQBuf, unsafe.Pointer(buf)); err != nil {
+		if errors.Is(err, unix.EAGAIN) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	if int(buf.index) >= len(d.buffers) || int(buf.bytesUsed) > len(d.buffers[buf.index]) {
+		return nil, nil, unix.EIO
+	}
+	return buf, d.buffers[buf.index][:buf.bytesUsed], nil
+}
+
+func (d *device) close() {
+	if d.started {
+		typ := int32(bufTypeVideoCapture)
+		_ = ioctl(d.fd, vidiocStreamOff, unsafe.Pointer(&typ))
+		d.started = false
+	}
+	for _, b := range d.buffers {
+		_ = unix.Munmap(b)
+	}
+	d.buffers = nil
+	_ = unix.Close(d.fd)
+}
+
+func bufferTimestamp(buf *v4l2Buffer) time.Duration {
+	return time.Duration(buf.timestamp.Nano())
+}
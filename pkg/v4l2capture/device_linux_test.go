@@ -0,0 +1,39 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v4l2capture
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIoctlNumbers(t *testing.T) {
+	if runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64" {
+		t.Skip("values are for 64 bit platforms")
+	}
+	// from linux/videodev2.h
+	require.Equal(t, uintptr(0x80685600), vidiocQueryCap)
+	require.Equal(t, uintptr(0xc0405602), vidiocEnumFmt)
+	require.Equal(t, uintptr(0xc0d05605), vidiocSFmt)
+	require.Equal(t, uintptr(0xc0145608), vidiocReqBufs)
+	require.Equal(t, uintptr(0xc0585609), vidiocQueryBuf)
+	require.Equal(t, uintptr(0xc058560f), vidiocQBuf)
+	require.Equal(t, uintptr(0xc0585611), vidiocDQBuf)
+	require.Equal(t, uintptr(0x40045612), vidiocStreamOn)
+	require.Equal(t, uintptr(0x40045613), vidiocStreamOff)
+	require.Equal(t, uintptr(0xc0cc5616), vidiocSParm)
+}
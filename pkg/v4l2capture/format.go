@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v4l2capture publishes frames of V4L2 cameras on Linux, e.g. USB webcams or the Raspberry Pi camera,
+// as a video track. H.264 capable cameras are published as-is, MJPEG and raw formats require an Encoder.
+//
+// The device is accessed with plain ioctls, no cgo is needed.
+package v4l2capture
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PixelFormat is a V4L2 pixel format fourcc
+type PixelFormat uint32
+
+func fourcc(a, b, c, d byte) PixelFormat {
+	return PixelFormat(uint32(a) | uint32(b)<<8 | uint32(c)<<16 | uint32(d)<<24)
+}
+
+var (
+	PixelFormatH264  = fourcc('H', '2', '6', '4')
+	PixelFormatMJPEG = fourcc('M', 'J', 'P', 'G')
+	PixelFormatYUYV  = fourcc('Y', 'U', 'Y', 'V')
+	PixelFormatNV12  = fourcc('N', 'V', '1', '2')
+	PixelFormatI420  = fourcc('Y', 'U', '1', '2')
+)
+
+func (f PixelFormat) String() string {
+	return string([]byte{byte(f), byte(f >> 8), byte(f >> 16), byte(f >> 24)})
+}
+
+var (
+	ErrNoSupportedFormat = errors.New("camera does not offer a format that can be published")
+	ErrNotCaptureDevice  = errors.New("device does not support video capture streaming")
+	ErrClosed            = errors.New("capture is closed")
+)
+
+const (
+	DefaultDevice       = "/dev/video0"
+	DefaultWidth        = 1280
+	DefaultHeight       = 720
+	DefaultFPS          = 30
+	DefaultBuffers      = 4
+	DefaultRestartDelay = time.Second
+)
+
+// Config selects the camera and the format to capture, zero values use the defaults
+type Config struct {
+	Device string
+	Width  int
+	Height int
+	FPS    int
+	// formats in order of preference, H.264 then MJPEG then raw formats by default
+	Formats []PixelFormat
+	// number of memory mapped capture buffers
+	Buffers int
+	// delay before the device is reopened after a capture error
+	RestartDelay time.Duration
+	// give up after this many restarts in a row without a frame, unlimited when zero
+	MaxRestarts int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Device == "" {
+		c.Device = DefaultDevice
+	}
+	if c.Width == 0 {
+		c.Width = DefaultWidth
+	}
+	if c.Height == 0 {
+		c.Height = DefaultHeight
+	}
+	if c.FPS == 0 {
+		c.FPS = DefaultFPS
+	}
+	if len(c.Formats) == 0 {
+		c.Formats = []PixelFormat{PixelFormatH264, PixelFormatMJPEG, PixelFormatYUYV, PixelFormatNV12, PixelFormatI420}
+	}
+	if c.Buffers == 0 {
+		c.Buffers = DefaultBuffers
+	}
+	if c.RestartDelay == 0 {
+		c.RestartDelay = DefaultRestartDelay
+	}
+	return c
+}
+
+// Frame is a captured frame. Data is only valid until the callee returns.
+type Frame struct {
+	Format   PixelFormat
+	Width    int
+	Height   int
+	Data     []byte
+	Sequence uint32
+	// driver capture time on the monotonic clock
+	Timestamp time.Duration
+}
+
+// Encoder converts frames of formats the camera can not publish directly into samples of a codec,
+// e.g. MJPEG to VP8 or YUYV to H.264
+type Encoder interface {
+	// MimeType is the codec of the encoded samples, e.g. webrtc.MimeTypeVP8
+	MimeType() string
+	// InputFormats are the pixel formats accepted by Encode
+	InputFormats() []PixelFormat
+	// Encode returns the encoded sample, nil to skip the frame
+	Encode(frame Frame) ([]byte, error)
+}
+
+// negotiateFormat picks the first preferred format the camera offers and that can be published,
+// either directly (H.264) or through the encoder
+func negotiateFormat(preferred, offered []PixelFormat, encoder Encoder) (PixelFormat, bool, error) {
+	for _, f := range preferred {
+		if !slices.Contains(offered, f) {
+			continue
+		}
+		if f == PixelFormatH264 {
+			return f, true, nil
+		}
+		if encoder != nil && slices.Contains(encoder.InputFormats(), f) {
+			return f, false, nil
+		}
+	}
+	return 0, false, fmt.Errorf("%w, offered %v", ErrNoSupportedFormat, offered)
+}
+
+// frameDuration returns the time between two frames from the driver timestamps,
+// falling back to the nominal frame interval for the first frame or unusable timestamps
+func frameDuration(prev, cur time.Duration, fps int) time.Duration {
+	nominal := time.Second / time.Duration(fps)
+	d := cur - prev
+	if prev == 0 || d <= 0 || d > time.Second {
+		return nominal
+	}
+	return d
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v4l2capture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEncoder struct{}
+
+func (testEncoder) MimeType() string                   { return "video/VP8" }
+func (testEncoder) InputFormats() []PixelFormat        { return []PixelFormat{PixelFormatYUYV} }
+func (testEncoder) Encode(frame Frame) ([]byte, error) { return frame.Data, nil }
+
+func TestNegotiateFormat(t *testing.T) {
+	preferred := Config{}.withDefaults().Formats
+
+	format, passthrough, err := negotiateFormat(preferred, []PixelFormat{PixelFormatYUYV, PixelFormatH264}, nil)
+	require.NoError(t, err)
+	require.Equal(t, PixelFormatH264, format)
+	require.True(t, passthrough)
+
+	// MJPEG is preferred, but the encoder only takes YUYV
+	format, passthrough, err = negotiateFormat(preferred, []PixelFormat{PixelFormatMJPEG, PixelFormatYUYV}, testEncoder{})
+	require.NoError(t, err)
+	require.Equal(t, PixelFormatYUYV, format)
+	require.False(t, passthrough)
+
+	_, _, err = negotiateFormat(preferred, []PixelFormat{PixelFormatMJPEG, PixelFormatYUYV}, nil)
+	require.ErrorIs(t, err, ErrNoSupportedFormat)
+
+	require.Equal(t, "MJPG", PixelFormatMJPEG.String())
+}
+
+func TestFrameDuration(t *testing.T) {
+	nominal := time.Second / 30
+	require.Equal(t, nominal, frameDuration(0, time.Second, 30))
+	require.Equal(t, 40*time.Millisecond, frameDuration(time.Second, time.Second+40*time.Millisecond, 30))
+	// timestamps going backwards or jumping after a restart
+	require.Equal(t, nominal, frameDuration(time.Second, time.Second-time.Millisecond, 30))
+	require.Equal(t, nominal, frameDuration(time.Second, 3*time.Second, 30))
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhookexport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+// Sink publishes encoded events. Kafka or NATS producers are adapted with SinkFunc, e.g.
+//
+//	webhookexport.SinkFunc(func(ctx context.Context, msg *webhookexport.Message) error {
+//		return nc.Publish("livekit."+msg.Type, msg.Body)
+//	})
+type Sink interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+type SinkFunc func(ctx context.Context, msg *Message) error
+
+func (f SinkFunc) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// HTTPSink posts each message to a URL and fails on non-2xx responses.
+type HTTPSink struct {
+	URL string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+	// Header is added to every request, e.g. for authorization
+	Header http.Header
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, msg *Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(msg.Body))
+	if err != nil {
+		return err
+	}
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", msg.ContentType)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s: unexpected status %s", s.URL, res.Status)
+	}
+	return nil
+}
+
+type ExporterOption func(*Exporter)
+
+// WithFormat sets the encoding of exported events, FormatJSON by default.
+func WithFormat(format Format) ExporterOption {
+	return func(e *Exporter) {
+		e.format = format
+	}
+}
+
+// WithSource sets the CloudEvents source, e.g. the project URL.
+func WithSource(source string) ExporterOption {
+	return func(e *Exporter) {
+		e.source = source
+	}
+}
+
+// WithLogger sets the logger for the Exporter.
+func WithLogger(logger logger.Logger) ExporterOption {
+	return func(e *Exporter) {
+		e.logger = logger
+	}
+}
+
+// Exporter encodes webhook events and publishes them to all sinks.
+type Exporter struct {
+	sinks  []Sink
+	format Format
+	source string
+	logger logger.Logger
+}
+
+func NewExporter(sinks []Sink, opts ...ExporterOption) *Exporter {
+	e := &Exporter{
+		sinks:  sinks,
+		source: "livekit",
+		logger: logger.GetLogger(),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Export publishes the event to every sink and returns the joined sink errors.
+func (e *Exporter) Export(ctx context.Context, event *livekit.WebhookEvent) error {
+	msg, err := NewMessage(event, e.format, e.source)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, sink := range e.sinks {
+		if err := sink.Publish(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run exports events from the channel until it is closed or ctx is done. Failed exports are logged.
+// Events are usually received in an HTTP handler with webhook.ReceiveWebhookEvent.
+func (e *Exporter) Run(ctx context.Context, events <-chan *livekit.WebhookEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := e.Export(ctx, event); err != nil {
+				e.logger.Warnw("could not export webhook event", err, "event", event.Event, "id", event.Id)
+			}
+		}
+	}
+}
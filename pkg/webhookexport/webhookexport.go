@@ -0,0 +1,199 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhookexport converts received webhook events into CloudEvents or plain JSON
+// and publishes them to sinks such as HTTP endpoints, Kafka or NATS.
+package webhookexport
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// SchemaVersion is the version of the Event schema, it changes only on incompatible changes
+	SchemaVersion = 1
+
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventTypePrefix is followed by the webhook event name, e.g. io.livekit.webhook.room_started
+	CloudEventTypePrefix = "io.livekit.webhook."
+
+	ContentTypeJSON        = "application/json"
+	ContentTypeCloudEvents = "application/cloudevents+json"
+)
+
+var payloadMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// Event is the plain JSON form of a webhook event. The common IDs are flattened so consumers
+// can route and filter without parsing the payload.
+type Event struct {
+	SchemaVersion       int       `json:"schema_version"`
+	ID                  string    `json:"id"`
+	Type                string    `json:"type"`
+	CreatedAt           time.Time `json:"created_at"`
+	RoomName            string    `json:"room_name,omitempty"`
+	RoomSID             string    `json:"room_sid,omitempty"`
+	ParticipantIdentity string    `json:"participant_identity,omitempty"`
+	ParticipantSID      string    `json:"participant_sid,omitempty"`
+	TrackSID            string    `json:"track_sid,omitempty"`
+	EgressID            string    `json:"egress_id,omitempty"`
+	IngressID           string    `json:"ingress_id,omitempty"`
+	// Payload is the complete webhook event in protobuf JSON with the field names of the .proto definition
+	Payload json.RawMessage `json:"payload"`
+}
+
+// CloudEvent is a CloudEvents 1.0 event in structured JSON mode, the data is the webhook event payload.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// ToEvent converts a webhook event to its plain JSON form.
+func ToEvent(event *livekit.WebhookEvent) (*Event, error) {
+	payload, err := payloadMarshaler.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	e := &Event{
+		SchemaVersion:       SchemaVersion,
+		ID:                  event.Id,
+		Type:                event.Event,
+		CreatedAt:           time.Unix(event.CreatedAt, 0).UTC(),
+		RoomName:            eventRoomName(event),
+		RoomSID:             event.Room.GetSid(),
+		ParticipantIdentity: event.Participant.GetIdentity(),
+		ParticipantSID:      event.Participant.GetSid(),
+		TrackSID:            event.Track.GetSid(),
+		EgressID:            event.EgressInfo.GetEgressId(),
+		IngressID:           event.IngressInfo.GetIngressId(),
+		Payload:             payload,
+	}
+	return e, nil
+}
+
+// ToCloudEvent converts a webhook event to a CloudEvent. The source identifies the LiveKit project,
+// e.g. its URL. The subject is the room name.
+func ToCloudEvent(event *livekit.WebhookEvent, source string) (*CloudEvent, error) {
+	payload, err := payloadMarshaler.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              event.Id,
+		Source:          source,
+		Type:            CloudEventTypePrefix + event.Event,
+		Subject:         eventRoomName(event),
+		Time:            time.Unix(event.CreatedAt, 0).UTC(),
+		DataContentType: ContentTypeJSON,
+		Data:            payload,
+	}, nil
+}
+
+// EventName returns the webhook event name of a CloudEvent type, empty for other types.
+func EventName(cloudEventType string) string {
+	name, ok := strings.CutPrefix(cloudEventType, CloudEventTypePrefix)
+	if !ok {
+		return ""
+	}
+	return name
+}
+
+// eventKey matches the key the server orders webhooks by
+func eventKey(event *livekit.WebhookEvent) string {
+	switch {
+	case event.EgressInfo != nil:
+		return event.EgressInfo.EgressId
+	case event.IngressInfo != nil:
+		return event.IngressInfo.IngressId
+	case event.Room != nil:
+		return event.Room.Name
+	case event.Participant != nil:
+		return event.Participant.Identity
+	case event.Track != nil:
+		return event.Track.Sid
+	}
+	return ""
+}
+
+func eventRoomName(event *livekit.WebhookEvent) string {
+	if name := event.Room.GetName(); name != "" {
+		return name
+	}
+	if name := event.EgressInfo.GetRoomName(); name != "" {
+		return name
+	}
+	return event.IngressInfo.GetRoomName()
+}
+
+// Format selects how events are encoded for sinks.
+type Format int
+
+const (
+	FormatJSON Format = iota
+	FormatCloudEvents
+)
+
+// Message is an encoded event handed to a Sink.
+type Message struct {
+	// Key keeps events of the same resource in order, e.g. as Kafka message key. It matches the
+	// key the server uses to order webhooks: egress or ingress ID, otherwise the room name.
+	Key string
+	// Type is the webhook event name, e.g. to build a NATS subject
+	Type        string
+	ContentType string
+	Body        []byte
+	// Event is the original webhook event
+	Event *livekit.WebhookEvent
+}
+
+// NewMessage encodes a webhook event in the given format.
+func NewMessage(event *livekit.WebhookEvent, format Format, source string) (*Message, error) {
+	msg := &Message{
+		Key:   eventKey(event),
+		Type:  event.Event,
+		Event: event,
+	}
+
+	var (
+		v   any
+		err error
+	)
+	switch format {
+	case FormatCloudEvents:
+		msg.ContentType = ContentTypeCloudEvents
+		v, err = ToCloudEvent(event, source)
+	default:
+		msg.ContentType = ContentTypeJSON
+		v, err = ToEvent(event)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if msg.Body, err = json.Marshal(v); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
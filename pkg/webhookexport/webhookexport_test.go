@@ -0,0 +1,115 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhookexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func testEvent() *livekit.WebhookEvent {
+	return &livekit.WebhookEvent{
+		Event:       "participant_joined",
+		Id:          "EV_1",
+		CreatedAt:   1700000000,
+		Room:        &livekit.Room{Sid: "RM_1", Name: "room"},
+		Participant: &livekit.ParticipantInfo{Sid: "PA_1", Identity: "alice"},
+	}
+}
+
+func TestToEvent(t *testing.T) {
+	e, err := ToEvent(testEvent())
+	require.NoError(t, err)
+	require.Equal(t, SchemaVersion, e.SchemaVersion)
+	require.Equal(t, "EV_1", e.ID)
+	require.Equal(t, "participant_joined", e.Type)
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), e.CreatedAt)
+	require.Equal(t, "room", e.RoomName)
+	require.Equal(t, "RM_1", e.RoomSID)
+	require.Equal(t, "alice", e.ParticipantIdentity)
+	require.Equal(t, "PA_1", e.ParticipantSID)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(e.Payload, &payload))
+	require.Equal(t, "alice", payload["participant"].(map[string]any)["identity"])
+	require.Contains(t, payload, "created_at")
+}
+
+func TestToCloudEvent(t *testing.T) {
+	ce, err := ToCloudEvent(testEvent(), "https://project.livekit.cloud")
+	require.NoError(t, err)
+	require.Equal(t, "1.0", ce.SpecVersion)
+	require.Equal(t, "EV_1", ce.ID)
+	require.Equal(t, "https://project.livekit.cloud", ce.Source)
+	require.Equal(t, "io.livekit.webhook.participant_joined", ce.Type)
+	require.Equal(t, "participant_joined", EventName(ce.Type))
+	require.Equal(t, "room", ce.Subject)
+
+	b, err := json.Marshal(ce)
+	require.NoError(t, err)
+	var attrs map[string]any
+	require.NoError(t, json.Unmarshal(b, &attrs))
+	for _, attr := range []string{"specversion", "id", "source", "type", "subject", "time", "datacontenttype", "data"} {
+		require.Contains(t, attrs, attr)
+	}
+}
+
+func TestExporter(t *testing.T) {
+	var received []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, b)
+	}))
+	defer server.Close()
+
+	var messages []*Message
+	e := NewExporter([]Sink{
+		&HTTPSink{URL: server.URL, Header: http.Header{"Authorization": {"Bearer token"}}},
+		SinkFunc(func(ctx context.Context, msg *Message) error {
+			messages = append(messages, msg)
+			return nil
+		}),
+	}, WithFormat(FormatCloudEvents))
+
+	require.NoError(t, e.Export(context.Background(), testEvent()))
+	require.Len(t, received, 1)
+	require.Equal(t, ContentTypeCloudEvents, received[0].Header.Get("Content-Type"))
+	require.Equal(t, "Bearer token", received[0].Header.Get("Authorization"))
+	require.Len(t, messages, 1)
+	require.Equal(t, "room", messages[0].Key)
+	require.Equal(t, "participant_joined", messages[0].Type)
+	require.Equal(t, messages[0].Body, bodies[0])
+
+	failed := errors.New("unavailable")
+	e = NewExporter([]Sink{
+		SinkFunc(func(ctx context.Context, msg *Message) error { return failed }),
+		&HTTPSink{URL: server.URL},
+	})
+	require.ErrorIs(t, e.Export(context.Background(), testEvent()), failed)
+	require.Len(t, received, 2)
+	require.Equal(t, ContentTypeJSON, received[1].Header.Get("Content-Type"))
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooktest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// EventOptions sets the names used in generated events.
+type EventOptions struct {
+	RoomName            string
+	ParticipantIdentity string
+	Now                 time.Time
+}
+
+func (o *EventOptions) defaults() {
+	if o.RoomName == "" {
+		o.RoomName = "test-room"
+	}
+	if o.ParticipantIdentity == "" {
+		o.ParticipantIdentity = "test-participant"
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+}
+
+// NewEvent returns an event of the given type populated with the fields the server sets for it.
+func NewEvent(eventName string, opts EventOptions) (*livekit.WebhookEvent, error) {
+	opts.defaults()
+	now := opts.Now.Unix()
+
+	event := &livekit.WebhookEvent{
+		Event:     eventName,
+		Id:        "EV_" + shortID(),
+		CreatedAt: now,
+	}
+	room := &livekit.Room{
+		Sid:          "RM_" + shortID(),
+		Name:         opts.RoomName,
+		CreationTime: now,
+	}
+	participant := &livekit.ParticipantInfo{
+		Sid:      "PA_" + shortID(),
+		Identity: opts.ParticipantIdentity,
+		State:    livekit.ParticipantInfo_ACTIVE,
+		JoinedAt: now,
+	}
+	track := &livekit.TrackInfo{
+		Sid:    "TR_" + shortID(),
+		Type:   livekit.TrackType_AUDIO,
+		Source: livekit.TrackSource_MICROPHONE,
+	}
+	egress := &livekit.EgressInfo{
+		EgressId:  "EG_" + shortID(),
+		RoomId:    room.Sid,
+		RoomName:  room.Name,
+		StartedAt: opts.Now.UnixNano(),
+		UpdatedAt: opts.Now.UnixNano(),
+	}
+	ingress := &livekit.IngressInfo{
+		IngressId:           "IN_" + shortID(),
+		InputType:           livekit.IngressInput_RTMP_INPUT,
+		RoomName:            room.Name,
+		ParticipantIdentity: opts.ParticipantIdentity,
+		State: &livekit.IngressState{
+			RoomId:    room.Sid,
+			StartedAt: opts.Now.UnixNano(),
+			UpdatedAt: opts.Now.UnixNano(),
+		},
+	}
+
+	switch eventName {
+	case EventRoomStarted, EventRoomFinished:
+		event.Room = room
+	case EventParticipantJoined, EventParticipantConnectionAborted:
+		event.Room = room
+		event.Participant = participant
+	case EventParticipantLeft:
+		participant.State = livekit.ParticipantInfo_DISCONNECTED
+		event.Room = room
+		event.Participant = participant
+	case EventTrackPublished, EventTrackUnpublished:
+		participant.Tracks = []*livekit.TrackInfo{track}
+		event.Room = room
+		event.Participant = participant
+		event.Track = track
+	case EventEgressStarted:
+		egress.Status = livekit.EgressStatus_EGRESS_STARTING
+		event.EgressInfo = egress
+	case EventEgressUpdated:
+		egress.Status = livekit.EgressStatus_EGRESS_ACTIVE
+		event.EgressInfo = egress
+	case EventEgressEnded:
+		egress.Status = livekit.EgressStatus_EGRESS_COMPLETE
+		egress.EndedAt = opts.Now.UnixNano()
+		event.EgressInfo = egress
+	case EventIngressStarted:
+		ingress.State.Status = livekit.IngressState_ENDPOINT_PUBLISHING
+		event.IngressInfo = ingress
+	case EventIngressEnded:
+		ingress.State.Status = livekit.IngressState_ENDPOINT_INACTIVE
+		ingress.State.EndedAt = opts.Now.UnixNano()
+		event.IngressInfo = ingress
+	default:
+		return nil, fmt.Errorf("unknown webhook event %q", eventName)
+	}
+	return event, nil
+}
+
+func shortID() string {
+	return uuid.New().String()[:12]
+}
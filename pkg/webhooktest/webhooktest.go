@@ -0,0 +1,224 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhooktest synthesizes signed webhook requests for testing webhook handlers,
+// including invalid variants that handlers are expected to reject.
+package webhooktest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	EventRoomStarted                  = "room_started"
+	EventRoomFinished                 = "room_finished"
+	EventParticipantJoined            = "participant_joined"
+	EventParticipantLeft              = "participant_left"
+	EventParticipantConnectionAborted = "participant_connection_aborted"
+	EventTrackPublished               = "track_published"
+	EventTrackUnpublished             = "track_unpublished"
+	EventEgressStarted                = "egress_started"
+	EventEgressUpdated                = "egress_updated"
+	EventEgressEnded                  = "egress_ended"
+	EventIngressStarted               = "ingress_started"
+	EventIngressEnded                 = "ingress_ended"
+
+	// ContentType is the content type the server sends webhooks with
+	ContentType = "application/webhook+json"
+
+	validFor = 5 * time.Minute
+	// tokens are verified with a leeway for clock skew
+	outsideLeeway = 5 * time.Minute
+)
+
+// EventTypes lists every webhook event name.
+var EventTypes = []string{
+	EventRoomStarted,
+	EventRoomFinished,
+	EventParticipantJoined,
+	EventParticipantLeft,
+	EventParticipantConnectionAborted,
+	EventTrackPublished,
+	EventTrackUnpublished,
+	EventEgressStarted,
+	EventEgressUpdated,
+	EventEgressEnded,
+	EventIngressStarted,
+	EventIngressEnded,
+}
+
+// Variant selects how a payload is signed. Every variant except Valid must be rejected by a handler.
+type Variant int
+
+const (
+	Valid Variant = iota
+	// Expired is signed with a token that expired beyond the allowed clock skew
+	Expired
+	// NotYetValid is signed with a token that becomes valid beyond the allowed clock skew
+	NotYetValid
+	// WrongSecret is signed with a different secret for the same API key
+	WrongSecret
+	// UnknownAPIKey is signed with an API key the handler does not know
+	UnknownAPIKey
+	// InvalidChecksum is signed for a different body
+	InvalidChecksum
+	// MissingAuthorization has no Authorization header
+	MissingAuthorization
+	// MalformedBody is correctly signed but the body is not a webhook event
+	MalformedBody
+)
+
+// InvalidVariants lists every variant a handler must reject.
+var InvalidVariants = []Variant{Expired, NotYetValid, WrongSecret, UnknownAPIKey, InvalidChecksum, MissingAuthorization, MalformedBody}
+
+func (v Variant) String() string {
+	switch v {
+	case Valid:
+		return "valid"
+	case Expired:
+		return "expired"
+	case NotYetValid:
+		return "not_yet_valid"
+	case WrongSecret:
+		return "wrong_secret"
+	case UnknownAPIKey:
+		return "unknown_api_key"
+	case InvalidChecksum:
+		return "invalid_checksum"
+	case MissingAuthorization:
+		return "missing_authorization"
+	case MalformedBody:
+		return "malformed_body"
+	default:
+		return fmt.Sprintf("%d", int(v))
+	}
+}
+
+// Payload is a webhook request body with its headers.
+type Payload struct {
+	Body   []byte
+	Header http.Header
+}
+
+// NewRequest returns an incoming server request for the payload, to be passed to a handler directly.
+func (p *Payload) NewRequest(target string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(p.Body))
+	for k, v := range p.Header {
+		r.Header[k] = v
+	}
+	return r
+}
+
+// Generator signs webhook payloads like the server does with an API key and secret.
+type Generator struct {
+	apiKey    string
+	apiSecret string
+	// Now is the signing time, time.Now by default
+	Now func() time.Time
+}
+
+func NewGenerator(apiKey, apiSecret string) *Generator {
+	return &Generator{
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		Now:       time.Now,
+	}
+}
+
+// Payload encodes and signs the event for the variant.
+func (g *Generator) Payload(event *livekit.WebhookEvent, variant Variant) (*Payload, error) {
+	body, err := protojson.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	if variant == MalformedBody {
+		body = []byte(`{"event": "` + event.Event + `", "room": `)
+	}
+
+	signedBody := body
+	if variant == InvalidChecksum {
+		signedBody = append([]byte(" "), body...)
+	}
+
+	p := &Payload{
+		Body:   body,
+		Header: http.Header{},
+	}
+	p.Header.Set("Content-Type", ContentType)
+	if variant == MissingAuthorization {
+		return p, nil
+	}
+
+	token, err := g.sign(signedBody, variant)
+	if err != nil {
+		return nil, err
+	}
+	p.Header.Set("Authorization", token)
+	return p, nil
+}
+
+// NewRequest is Payload followed by Payload.NewRequest.
+func (g *Generator) NewRequest(target string, event *livekit.WebhookEvent, variant Variant) (*http.Request, error) {
+	p, err := g.Payload(event, variant)
+	if err != nil {
+		return nil, err
+	}
+	return p.NewRequest(target), nil
+}
+
+func (g *Generator) sign(body []byte, variant Variant) (string, error) {
+	apiKey, apiSecret := g.apiKey, g.apiSecret
+	switch variant {
+	case WrongSecret:
+		apiSecret += "-wrong"
+	case UnknownAPIKey:
+		apiKey += "-unknown"
+	}
+
+	now := g.Now()
+	notBefore, expiry := now, now.Add(validFor)
+	switch variant {
+	case Expired:
+		notBefore, expiry = now.Add(-validFor-outsideLeeway), now.Add(-outsideLeeway)
+	case NotYetValid:
+		notBefore, expiry = now.Add(outsideLeeway), now.Add(validFor+outsideLeeway)
+	}
+
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(apiSecret)},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	cl := jwt.Claims{
+		Issuer:    apiKey,
+		NotBefore: jwt.NewNumericDate(notBefore),
+		Expiry:    jwt.NewNumericDate(expiry),
+	}
+	grants := &auth.ClaimGrants{Sha256: base64.StdEncoding.EncodeToString(sum[:])}
+	return jwt.Signed(sig).Claims(cl).Claims(grants).CompactSerialize()
+}
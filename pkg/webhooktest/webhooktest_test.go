@@ -0,0 +1,110 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooktest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	testAPIKey    = "APIkey"
+	testAPISecret = "secret-secret-secret-secret-secret"
+)
+
+// receive verifies a request the same way webhook.ReceiveWebhookEvent does
+func receive(r *http.Request) (*livekit.WebhookEvent, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		return nil, errors.New("missing authorization")
+	}
+	v, err := auth.ParseAPIToken(token)
+	if err != nil {
+		return nil, err
+	}
+	secret := auth.NewFileBasedKeyProviderFromMap(map[string]string{testAPIKey: testAPISecret}).GetSecret(v.APIKey())
+	if secret == "" {
+		return nil, errors.New("unknown api key")
+	}
+	claims, err := v.Verify(secret)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if claims.Sha256 != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, errors.New("invalid checksum")
+	}
+	event := &livekit.WebhookEvent{}
+	if err := protojson.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator(testAPIKey, testAPISecret)
+
+	for _, eventName := range EventTypes {
+		t.Run(eventName, func(t *testing.T) {
+			event, err := NewEvent(eventName, EventOptions{RoomName: "room"})
+			require.NoError(t, err)
+
+			r, err := g.NewRequest("/webhook", event, Valid)
+			require.NoError(t, err)
+			require.Equal(t, ContentType, r.Header.Get("Content-Type"))
+			received, err := receive(r)
+			require.NoError(t, err)
+			require.Equal(t, eventName, received.Event)
+			require.Equal(t, event.Id, received.Id)
+
+			for _, variant := range InvalidVariants {
+				r, err := g.NewRequest("/webhook", event, variant)
+				require.NoError(t, err)
+				_, err = receive(r)
+				require.Error(t, err, variant.String())
+			}
+		})
+	}
+
+	_, err := NewEvent("unknown", EventOptions{})
+	require.Error(t, err)
+}
+
+func TestNewEvent(t *testing.T) {
+	event, err := NewEvent(EventTrackPublished, EventOptions{RoomName: "room", ParticipantIdentity: "alice"})
+	require.NoError(t, err)
+	require.Equal(t, "room", event.Room.Name)
+	require.Equal(t, "alice", event.Participant.Identity)
+	require.Equal(t, event.Track.Sid, event.Participant.Tracks[0].Sid)
+
+	event, err = NewEvent(EventEgressEnded, EventOptions{RoomName: "room"})
+	require.NoError(t, err)
+	require.Equal(t, "room", event.EgressInfo.RoomName)
+	require.Equal(t, livekit.EgressStatus_EGRESS_COMPLETE, event.EgressInfo.Status)
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// heartbeats are sent as user packets on this topic and are not passed to data callbacks
+	presenceTopic = "lk.presence"
+	// participant is considered gone after missing this many heartbeats
+	presenceMissedHeartbeats = 3
+)
+
+// presenceTracker records when remote participants were last heard from on the data channel.
+// Only participants that sent at least one heartbeat are checked for liveness.
+type presenceTracker struct {
+	lock     sync.Mutex
+	lastSeen map[string]time.Time
+	stale    map[string]bool
+	stop     chan struct{}
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{
+		lastSeen: make(map[string]time.Time),
+		stale:    make(map[string]bool),
+	}
+}
+
+// seen records activity of identity, returns true if it was stale before
+func (t *presenceTracker) seen(identity string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stop == nil {
+		return false
+	}
+	t.lastSeen[identity] = time.Now()
+	wasStale := t.stale[identity]
+	delete(t.stale, identity)
+	return wasStale
+}
+
+func (t *presenceTracker) getLastSeen(identity string) (time.Time, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	at, ok := t.lastSeen[identity]
+	return at, ok
+}
+
+func (t *presenceTracker) remove(identity string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.lastSeen, identity)
+	delete(t.stale, identity)
+}
+
+// expire marks participants not seen within timeout as stale, returns the newly stale ones
+func (t *presenceTracker) expire(timeout time.Duration) []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var expired []string
+	for identity, at := range t.lastSeen {
+		if !t.stale[identity] && time.Since(at) > timeout {
+			t.stale[identity] = true
+			expired = append(expired, identity)
+		}
+	}
+	return expired
+}
+
+func (t *presenceTracker) start() (chan struct{}, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stop != nil {
+		return nil, false
+	}
+	t.stop = make(chan struct{})
+	return t.stop, true
+}
+
+func (t *presenceTracker) close() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+	clear(t.lastSeen)
+	clear(t.stale)
+}
+
+func (r *Room) startPresence() {
+	interval := r.engine.connParams.PresenceInterval
+	if interval <= 0 {
+		return
+	}
+	stop, ok := r.presence.start()
+	if !ok {
+		return
+	}
+
+	r.engine.goroutines.Go("presence", func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			for _, identity := range r.presence.expire(presenceMissedHeartbeats * interval) {
+				if rp := r.GetParticipantByIdentity(identity); rp != nil {
+					r.log.Infow("participant stopped sending heartbeats", "participant", identity)
+					go r.callback.OnParticipantLivenessChanged(rp, false)
+				} else {
+					r.presence.remove(identity)
+				}
+			}
+
+			// publishing waits for the connection, which would hold up the liveness checks while reconnecting
+			if r.ConnectionState() != ConnectionStateConnected {
+				continue
+			}
+			if err := r.LocalParticipant.PublishDataPacket(
+				UserData(nil),
+				WithDataPublishTopic(presenceTopic),
+				WithDataPublishReliable(false),
+			); err != nil {
+				r.log.Debugw("could not send presence heartbeat", "error", err)
+			}
+		}
+	})
+}
+
+// handlePresence records data channel activity of a participant, returns true if the packet was a heartbeat
+func (r *Room) handlePresence(rp *RemoteParticipant, dataPacket DataPacket) bool {
+	if rp != nil && r.presence.seen(rp.Identity()) {
+		go r.callback.OnParticipantLivenessChanged(rp, true)
+	}
+	user, ok := dataPacket.(*UserDataPacket)
+	return ok && user.Topic == presenceTopic
+}
+
+// LastSeen returns when a participant was last heard from on the data channel.
+// Only available when presence heartbeats are enabled with WithPresenceHeartbeat.
+func (r *Room) LastSeen(identity string) (time.Time, bool) {
+	return r.presence.getLastSeen(identity)
+}
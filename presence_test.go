@@ -0,0 +1,99 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestPresenceTracker(t *testing.T) {
+	tracker := newPresenceTracker()
+
+	// not tracked until heartbeats are enabled
+	require.False(t, tracker.seen("alice"))
+	_, ok := tracker.getLastSeen("alice")
+	require.False(t, ok)
+
+	_, ok = tracker.start()
+	require.True(t, ok)
+	_, ok = tracker.start()
+	require.False(t, ok)
+
+	require.False(t, tracker.seen("alice"))
+	require.False(t, tracker.seen("bob"))
+	at, ok := tracker.getLastSeen("alice")
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now(), at, time.Second)
+
+	time.Sleep(20 * time.Millisecond)
+	require.False(t, tracker.seen("bob"))
+	require.Equal(t, []string{"alice"}, tracker.expire(10*time.Millisecond))
+	// reported once
+	require.Empty(t, tracker.expire(10*time.Millisecond))
+	// and back once seen again
+	require.True(t, tracker.seen("alice"))
+	require.False(t, tracker.seen("alice"))
+
+	tracker.remove("bob")
+	_, ok = tracker.getLastSeen("bob")
+	require.False(t, ok)
+
+	tracker.close()
+	_, ok = tracker.getLastSeen("alice")
+	require.False(t, ok)
+	require.False(t, tracker.seen("alice"))
+}
+
+func TestHandlePresence(t *testing.T) {
+	liveness := make(chan bool, 1)
+	cb := NewRoomCallback()
+	cb.OnParticipantLivenessChanged = func(rp *RemoteParticipant, alive bool) {
+		liveness <- alive
+	}
+	room := NewRoom(cb)
+	room.engine.connParams = &signalling.ConnectParams{PresenceInterval: 10 * time.Millisecond}
+	room.startPresence()
+	t.Cleanup(room.presence.close)
+	rp := room.addRemoteParticipant(&livekit.ParticipantInfo{Sid: "PA_alice", Identity: "alice"}, false)
+
+	// heartbeats are consumed, other packets still count as activity
+	require.True(t, room.handlePresence(rp, &UserDataPacket{Topic: presenceTopic}))
+	require.False(t, room.handlePresence(rp, &UserDataPacket{Topic: "chat"}))
+	seen, ok := room.LastSeen("alice")
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now(), seen, time.Second)
+
+	// reported gone after missing heartbeats, and back with the next packet
+	select {
+	case alive := <-liveness:
+		require.False(t, alive)
+	case <-time.After(time.Second):
+		t.Fatal("stale participant not reported")
+	}
+	require.False(t, room.handlePresence(rp, &UserDataPacket{}))
+	select {
+	case alive := <-liveness:
+		require.True(t, alive)
+	case <-time.After(time.Second):
+		t.Fatal("liveness not reported")
+	}
+}
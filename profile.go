@@ -0,0 +1,126 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// RoomProfile bundles connect options and encoder recommendations for a kind of workload.
+type RoomProfile struct {
+	Name string
+	// ConnectOptions are applied before the options passed to ConnectToRoomWithProfile, which take precedence
+	ConnectOptions []ConnectOption
+
+	// Encoder settings recommended for tracks published with this profile. The SDK publishes encoded media,
+	// these are meant for configuring the encoder producing it. Zero values mean no recommendation.
+	AudioBitrate      int // bits per second
+	VideoCodec        string
+	VideoBitrate      int // bits per second
+	VideoMaxFramerate float64
+}
+
+// Options returns the profile's connect options followed by opts.
+func (p RoomProfile) Options(opts ...ConnectOption) []ConnectOption {
+	return append(append([]ConnectOption{}, p.ConnectOptions...), opts...)
+}
+
+var (
+	// ProfileAudioConference is for voice rooms: audio only, DTX for silence suppression and reliable data for chat.
+	ProfileAudioConference = RoomProfile{
+		Name: "audio_conference",
+		ConnectOptions: []ConnectOption{
+			WithAudioOnly(),
+			WithReliableDataByDefault(),
+		},
+		AudioBitrate: 32_000,
+	}
+
+	// ProfileBroadcast is for long running publishers: no auto subscribe, stereo audio without DTX for music,
+	// simulcast of backup codecs and patient reconnects.
+	ProfileBroadcast = RoomProfile{
+		Name: "broadcast",
+		ConnectOptions: []ConnectOption{
+			WithAutoSubscribe(false),
+			WithPublishDefaults(PublishDefaults{
+				DisableAudioDTX:   true,
+				StereoAudio:       true,
+				BackupCodecPolicy: livekit.BackupCodecPolicy_SIMULCAST,
+				PublishTimeout:    30 * time.Second,
+			}),
+			WithReliableDataByDefault(),
+			WithMaxReconnectAttempts(30),
+		},
+		AudioBitrate:      128_000,
+		VideoCodec:        webrtc.MimeTypeH264,
+		VideoBitrate:      3_000_000,
+		VideoMaxFramerate: 30,
+	}
+
+	// ProfileGamingLowLatency detects dead connections fast and fails quickly instead of retrying for minutes.
+	// Data is lossy by default, state updates should be superseded rather than retransmitted.
+	ProfileGamingLowLatency = RoomProfile{
+		Name: "gaming_low_latency",
+		ConnectOptions: []ConnectOption{
+			WithSignalPing(2*time.Second, 6*time.Second),
+			WithNegotiationTimeout(5 * time.Second),
+			WithRTCPConfig(RTCPConfig{TWCCFeedbackInterval: 50 * time.Millisecond}),
+			WithMaxReconnectAttempts(5),
+		},
+		AudioBitrate:      24_000,
+		VideoCodec:        webrtc.MimeTypeVP8,
+		VideoBitrate:      2_500_000,
+		VideoMaxFramerate: 60,
+	}
+)
+
+var (
+	profilesLock sync.RWMutex
+	profiles     = map[string]RoomProfile{
+		ProfileAudioConference.Name:  ProfileAudioConference,
+		ProfileBroadcast.Name:        ProfileBroadcast,
+		ProfileGamingLowLatency.Name: ProfileGamingLowLatency,
+	}
+)
+
+// RegisterRoomProfile adds or replaces a profile for lookups by name with GetRoomProfile.
+func RegisterRoomProfile(profile RoomProfile) {
+	profilesLock.Lock()
+	defer profilesLock.Unlock()
+	profiles[profile.Name] = profile
+}
+
+// GetRoomProfile returns a built-in or registered profile.
+func GetRoomProfile(name string) (RoomProfile, bool) {
+	profilesLock.RLock()
+	defer profilesLock.RUnlock()
+	profile, ok := profiles[name]
+	return profile, ok
+}
+
+// ConnectToRoomWithProfile creates and joins the room with the profile's options, opts override them.
+func ConnectToRoomWithProfile(url string, info ConnectInfo, callback *RoomCallback, profile RoomProfile, opts ...ConnectOption) (*Room, error) {
+	return ConnectToRoom(url, info, callback, profile.Options(opts...)...)
+}
+
+// ConnectToRoomWithTokenAndProfile creates and joins the room with the profile's options, opts override them.
+func ConnectToRoomWithTokenAndProfile(url, token string, callback *RoomCallback, profile RoomProfile, opts ...ConnectOption) (*Room, error) {
+	return ConnectToRoomWithToken(url, token, callback, profile.Options(opts...)...)
+}
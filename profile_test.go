@@ -0,0 +1,63 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestRoomProfiles(t *testing.T) {
+	apply := func(opts []ConnectOption) *signalling.ConnectParams {
+		params := &signalling.ConnectParams{AutoSubscribe: true}
+		for _, opt := range opts {
+			opt(params)
+		}
+		return params
+	}
+
+	for _, name := range []string{"audio_conference", "broadcast", "gaming_low_latency"} {
+		profile, ok := GetRoomProfile(name)
+		require.True(t, ok, name)
+		require.Equal(t, name, profile.Name)
+	}
+
+	params := apply(ProfileAudioConference.Options())
+	require.True(t, params.AudioOnly)
+	require.True(t, params.ReliableDataByDefault)
+
+	params = apply(ProfileBroadcast.Options(WithAutoSubscribe(true)))
+	require.True(t, params.AutoSubscribe, "options override the profile")
+	require.True(t, params.PublishDefaults.StereoAudio)
+	require.Equal(t, 30, params.MaxReconnectAttempts)
+
+	params = apply(ProfileGamingLowLatency.Options())
+	require.False(t, params.ReliableDataByDefault)
+	require.Equal(t, 2*time.Second, params.PingInterval)
+	require.Equal(t, 5, params.MaxReconnectAttempts)
+
+	custom := RoomProfile{Name: "custom", ConnectOptions: []ConnectOption{WithAutoSubscribe(false)}}
+	RegisterRoomProfile(custom)
+	profile, ok := GetRoomProfile("custom")
+	require.True(t, ok)
+	require.False(t, apply(profile.Options()).AutoSubscribe)
+
+	_, ok = GetRoomProfile("unknown")
+	require.False(t, ok)
+}
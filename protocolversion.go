@@ -14,4 +14,73 @@
 
 package lksdk
 
+import (
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"golang.org/x/mod/semver"
+)
+
 const PROTOCOL = 16
+
+// protocol versions introducing features, see Capabilities
+const (
+	protocolRegionsInLeave  = 12
+	protocolSignalResponses = 15
+	protocolRoomMove        = 16
+)
+
+// Capabilities describes what the connected server supports, derived from the join response.
+// Use it to gate features instead of failing at runtime on older servers.
+type Capabilities struct {
+	// ProtocolVersion is the negotiated signal protocol version, the lower of client and server versions
+	ProtocolVersion int32
+	// ServerProtocolVersion is the protocol version announced by the server, zero when not announced
+	ServerProtocolVersion int32
+	ServerVersion         string
+	Edition               livekit.ServerInfo_Edition
+	AgentProtocol         int32
+}
+
+func newCapabilities(serverInfo *livekit.ServerInfo) Capabilities {
+	c := Capabilities{
+		ProtocolVersion:       PROTOCOL,
+		ServerProtocolVersion: serverInfo.GetProtocol(),
+		ServerVersion:         serverInfo.GetVersion(),
+		Edition:               serverInfo.GetEdition(),
+		AgentProtocol:         serverInfo.GetAgentProtocol(),
+	}
+	// older servers do not announce their protocol version
+	if c.ServerProtocolVersion != 0 {
+		c.ProtocolVersion = min(c.ProtocolVersion, c.ServerProtocolVersion)
+	}
+	return c
+}
+
+// SupportsRoomMove returns true if participants can be moved to another room, see RoomCallback.OnRoomMoved
+func (c Capabilities) SupportsRoomMove() bool {
+	return c.ProtocolVersion >= protocolRoomMove
+}
+
+// SupportsSignalResponses returns true if the server acknowledges signal requests such as metadata updates
+func (c Capabilities) SupportsSignalResponses() bool {
+	return c.ProtocolVersion >= protocolSignalResponses
+}
+
+// SupportsRegionsInLeave returns true if the server suggests regions to reconnect to when it leaves
+func (c Capabilities) SupportsRegionsInLeave() bool {
+	return c.ProtocolVersion >= protocolRegionsInLeave
+}
+
+// IsCloud returns true when connected to LiveKit Cloud
+func (c Capabilities) IsCloud() bool {
+	return c.Edition == livekit.ServerInfo_Cloud
+}
+
+// ServerVersionAtLeast returns true if the server version is known and not lower than version, e.g. "1.8.0"
+func (c Capabilities) ServerVersionAtLeast(version string) bool {
+	if c.ServerVersion == "" {
+		return false
+	}
+	return semver.Compare("v"+strings.TrimPrefix(c.ServerVersion, "v"), "v"+strings.TrimPrefix(version, "v")) >= 0
+}
@@ -0,0 +1,41 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	c := newCapabilities(&livekit.ServerInfo{Protocol: 15, Version: "1.8.2", Edition: livekit.ServerInfo_Cloud})
+	require.EqualValues(t, 15, c.ProtocolVersion)
+	require.True(t, c.SupportsSignalResponses())
+	require.False(t, c.SupportsRoomMove())
+	require.True(t, c.IsCloud())
+	require.True(t, c.ServerVersionAtLeast("1.8.0"))
+	require.False(t, c.ServerVersionAtLeast("v1.9.0"))
+
+	c = newCapabilities(&livekit.ServerInfo{Protocol: PROTOCOL + 1})
+	require.EqualValues(t, PROTOCOL, c.ProtocolVersion)
+	require.True(t, c.SupportsRoomMove())
+	require.False(t, c.ServerVersionAtLeast("1.0.0"))
+
+	c = newCapabilities(nil)
+	require.EqualValues(t, PROTOCOL, c.ProtocolVersion)
+	require.Zero(t, c.ServerProtocolVersion)
+}
@@ -42,6 +42,8 @@ type TrackPublication interface {
 	TrackInfo() *livekit.TrackInfo
 	// Track is either a webrtc.TrackLocal or webrtc.TrackRemote
 	Track() Track
+	// EncryptionStatus returns the end-to-end encryption type and key index, see EncryptionStatus
+	EncryptionStatus() EncryptionStatus
 	updateInfo(info *livekit.TrackInfo)
 }
 
@@ -55,6 +57,9 @@ type trackPublicationBase struct {
 	lock   sync.RWMutex
 	info   atomic.Value
 	engine *RTCEngine
+
+	// last seen key index + 1, zero when unknown
+	keyIndex atomic.Uint32
 }
 
 func (p *trackPublicationBase) Name() string {
@@ -144,6 +149,7 @@ type RemoteTrackPublication struct {
 	participantID string
 	receiver      *webrtc.RTPReceiver
 	onRTCP        func(rtcp.Packet)
+	settingsStore *subscriptionStateStore
 
 	disabled bool
 
@@ -152,6 +158,27 @@ type RemoteTrackPublication struct {
 	videoHeight *uint32
 	// preferred video quality to subscribe
 	videoQuality *livekit.VideoQuality
+
+	subscriptionRetries    int
+	subscriptionRetryTimer *time.Timer
+
+	audioLevelMeter *AudioLevelMeter
+	audioLevelSSRC  uint32
+
+	// nil when the connect default is used
+	bufferConfig *SubscriptionBufferConfig
+
+	// codec currently received, see OnCodecChanged
+	codec               webrtc.RTPCodecParameters
+	codecSSRC           uint32
+	codecChangeHandler  func(codec webrtc.RTPCodecParameters)
+	onTrackCodecChanged func(*RemoteTrackPublication, webrtc.RTPCodecParameters)
+
+	onEncryptionChanged func(*RemoteTrackPublication, EncryptionStatus)
+
+	// see SetSubscriptionPriority, the action is applied on top of the settings above
+	priority     SubscriptionPriority
+	budgetAction budgetAction
 }
 
 // TrackRemote returns the underlying webrtc.TrackRemote if available.
@@ -175,6 +202,14 @@ func (p *RemoteTrackPublication) Receiver() *webrtc.RTPReceiver {
 // SetSubscribed subscribes or unsubscribes from this track.
 // When subscribed, track data will be received from the server.
 func (p *RemoteTrackPublication) SetSubscribed(subscribed bool) error {
+	if p.settingsStore != nil {
+		p.settingsStore.setSubscribed(p.SID(), subscribed)
+	}
+	p.stopSubscriptionRetry()
+	return p.sendUpdateSubscription(subscribed)
+}
+
+func (p *RemoteTrackPublication) sendUpdateSubscription(subscribed bool) error {
 	return p.engine.SendUpdateSubscription(
 		&livekit.UpdateSubscription{
 			Subscribe: subscribed,
@@ -238,6 +273,40 @@ func (p *RemoteTrackPublication) OnRTCP(cb func(rtcp.Packet)) {
 }
 
 func (p *RemoteTrackPublication) updateSettings() {
+	settings := p.trackSettings()
+	if p.settingsStore != nil {
+		p.lock.RLock()
+		p.settingsStore.setTrackSettings(p.SID(), remoteTrackSettings{
+			disabled:     p.disabled,
+			videoWidth:   p.videoWidth,
+			videoHeight:  p.videoHeight,
+			videoQuality: p.videoQuality,
+		})
+		p.lock.RUnlock()
+	}
+
+	if err := p.engine.SendUpdateTrackSettings(settings); err != nil {
+		p.engine.log.Errorw("could not send track settings", err, "trackID", p.SID())
+	}
+}
+
+// restoreSettings re-applies settings stored before a full reconnect, returns
+// the track settings to send to the server, nil if defaults are in use
+func (p *RemoteTrackPublication) restoreSettings(ts remoteTrackSettings) *livekit.UpdateTrackSettings {
+	p.lock.Lock()
+	p.disabled = ts.disabled
+	p.videoWidth = ts.videoWidth
+	p.videoHeight = ts.videoHeight
+	p.videoQuality = ts.videoQuality
+	p.lock.Unlock()
+
+	if !ts.disabled && ts.videoWidth == nil && ts.videoQuality == nil {
+		return nil
+	}
+	return p.trackSettings()
+}
+
+func (p *RemoteTrackPublication) trackSettings() *livekit.UpdateTrackSettings {
 	p.lock.RLock()
 	settings := &livekit.UpdateTrackSettings{
 		TrackSids: []string{p.SID()},
@@ -252,11 +321,9 @@ func (p *RemoteTrackPublication) updateSettings() {
 	if p.videoQuality != nil {
 		settings.Quality = *p.videoQuality
 	}
+	p.budgetAction.apply(settings)
 	p.lock.RUnlock()
-
-	if err := p.engine.SendUpdateTrackSettings(settings); err != nil {
-		p.engine.log.Errorw("could not send track settings", err, "trackID", p.SID())
-	}
+	return settings
 }
 
 func (p *RemoteTrackPublication) setReceiverAndTrack(r *webrtc.RTPReceiver, t *webrtc.TrackRemote) {
@@ -264,8 +331,85 @@ func (p *RemoteTrackPublication) setReceiverAndTrack(r *webrtc.RTPReceiver, t *w
 	p.receiver = r
 	p.track = t
 	p.lock.Unlock()
+	p.stopSubscriptionRetry()
+	if t != nil && t.Kind() == webrtc.RTPCodecTypeAudio {
+		p.startAudioLevel(uint32(t.SSRC()))
+	}
+	if t != nil {
+		p.engine.configureReceiveStream(uint32(t.SSRC()), p.BufferConfig())
+		p.startCodecMonitor(r, t)
+	}
 	if r != nil {
-		go p.rtcpWorker()
+		p.engine.goroutineRegistry().Go("rtcp-worker", func() { p.rtcpWorker() })
+	}
+}
+
+// SetBufferConfig overrides the receive buffer size, NACK window and target latency of this subscription,
+// zero values fall back to WithSubscriptionBufferConfig. Applied immediately when subscribed, and again when the
+// track is received anew after a reconnect.
+func (p *RemoteTrackPublication) SetBufferConfig(config SubscriptionBufferConfig) {
+	if p.settingsStore != nil {
+		p.settingsStore.setBufferConfig(p.SID(), config)
+	}
+
+	p.lock.Lock()
+	p.bufferConfig = &config
+	track, _ := p.track.(*webrtc.TrackRemote)
+	p.lock.Unlock()
+
+	if track != nil {
+		p.engine.configureReceiveStream(uint32(track.SSRC()), p.BufferConfig())
+	}
+}
+
+// BufferConfig returns the buffer config in effect for this subscription
+func (p *RemoteTrackPublication) BufferConfig() SubscriptionBufferConfig {
+	p.lock.Lock()
+	config := p.bufferConfig
+	p.lock.Unlock()
+
+	defaults := p.engine.subscriptionBufferDefaults()
+	if config == nil {
+		return defaults
+	}
+	return mergeBufferConfig(*config, defaults)
+}
+
+// AudioLevelMeter returns the level meter of an audio track, fed from the audio levels of received packets
+// while subscribed. Decoded PCM can be added as well, see media.WithAudioLevelMeter.
+func (p *RemoteTrackPublication) AudioLevelMeter() *AudioLevelMeter {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.audioLevelMeter == nil {
+		p.audioLevelMeter = NewAudioLevelMeter(0)
+	}
+	return p.audioLevelMeter
+}
+
+// AudioLevel returns the current level of an audio track, see AudioLevelMeter
+func (p *RemoteTrackPublication) AudioLevel() AudioLevel {
+	return p.AudioLevelMeter().Level()
+}
+
+func (p *RemoteTrackPublication) startAudioLevel(ssrc uint32) {
+	monitor := p.engine.audioLevelMonitor()
+	if monitor == nil {
+		return
+	}
+	meter := p.AudioLevelMeter()
+	p.lock.Lock()
+	p.audioLevelSSRC = ssrc
+	p.lock.Unlock()
+	monitor.Register(ssrc, meter.ObserveLevel)
+}
+
+func (p *RemoteTrackPublication) stopAudioLevel() {
+	p.lock.Lock()
+	ssrc := p.audioLevelSSRC
+	p.audioLevelSSRC = 0
+	p.lock.Unlock()
+	if monitor := p.engine.audioLevelMonitor(); monitor != nil && ssrc != 0 {
+		monitor.Unregister(ssrc)
 	}
 }
 
@@ -302,10 +446,16 @@ type LocalTrackPublication struct {
 	backupCodecTrack              TrackLocalWithCodec
 	backupCodecTracksForSimulcast map[livekit.VideoQuality]*LocalTrack
 	backupCodecPublished          atomic.Bool
+	subscribedQualities           []*livekit.SubscribedQuality
+	publishedCodec                atomic.String
+	// client track ID sent in AddTrackRequest
+	cid string
 
 	opts          TrackPublicationOptions
 	onMuteChanged func(*LocalTrackPublication, bool)
 
+	onEncryptionChanged func(*LocalTrackPublication, EncryptionStatus)
+
 	log protoLogger.Logger
 }
 
@@ -327,6 +477,17 @@ func (p *LocalTrackPublication) PublicationOptions() TrackPublicationOptions {
 	return p.opts
 }
 
+// PublishedCodec returns the mime type of the published track's codec, empty when the track does not
+// report its codec. With WithCodecFallback, this is the codec that was negotiated.
+func (p *LocalTrackPublication) PublishedCodec() string {
+	return p.publishedCodec.Load()
+}
+
+// CID returns the client-side track ID the publication was requested with
+func (p *LocalTrackPublication) CID() string {
+	return p.cid
+}
+
 func (p *LocalTrackPublication) TrackLocal() webrtc.TrackLocal {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -362,15 +523,12 @@ func (p *LocalTrackPublication) SetMuted(muted bool) {
 // SimulateDisconnection simulates a network disconnection for testing purposes.
 // If duration is 0, the disconnection persists until manually reconnected.
 func (p *LocalTrackPublication) SimulateDisconnection(duration time.Duration) {
-	if track := p.track; track != nil {
-		switch t := track.(type) {
-		case *LocalTrack:
-			t.setDisconnected(true)
-			if duration != 0 {
-				time.AfterFunc(duration, func() {
-					t.setDisconnected(false)
-				})
-			}
+	if t, ok := asLocalTrack(p.track); ok {
+		t.setDisconnected(true)
+		if duration != 0 {
+			time.AfterFunc(duration, func() {
+				t.setDisconnected(false)
+			})
 		}
 	}
 }
@@ -383,13 +541,10 @@ func (p *LocalTrackPublication) setMuted(muted bool, byRemote bool) {
 	if !byRemote {
 		_ = p.engine.SendMuteTrack(p.sid.Load(), muted)
 	}
-	if track := p.track; track != nil {
-		switch t := track.(type) {
-		case *LocalTrack:
-			t.setMuted(muted)
-		case interface{ GetMuteFunc() Private[MuteFunc] }:
-			t.GetMuteFunc().v(muted)
-		}
+	if t, ok := asLocalTrack(p.track); ok {
+		t.setMuted(muted)
+	} else if t, ok := p.track.(interface{ GetMuteFunc() Private[MuteFunc] }); ok {
+		t.GetMuteFunc().v(muted)
 	}
 
 	if p.onMuteChanged != nil {
@@ -440,7 +595,7 @@ func (p *LocalTrackPublication) setBackupCodecPublished() {
 
 func (p *LocalTrackPublication) readRTCP(sender *webrtc.RTPSender) {
 	// consume RTCP packets so interceptors can handle them (rtt, nacks...)
-	go func() {
+	p.engine.goroutineRegistry().Go("rtcp-reader", func() {
 		for {
 			_, _, err := sender.ReadRTCP()
 			if err != nil {
@@ -448,7 +603,7 @@ func (p *LocalTrackPublication) readRTCP(sender *webrtc.RTPSender) {
 				return
 			}
 		}
-	}()
+	})
 }
 
 // CloseTrack closes the underlying track and all simulcast tracks.
@@ -479,17 +634,45 @@ func (p *LocalTrackPublication) CloseTrack() {
 	}
 }
 
+// SubscribedQualities returns the qualities of the primary codec the server last requested, nil before any request.
+func (p *LocalTrackPublication) SubscribedQualities() []*livekit.SubscribedQuality {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.subscribedQualities
+}
+
 // SetPublishingCodecsQuality sets the publishing codecs based on the subscribed codecs quality, return
-// the track(s) to be published for backup codec that is required by subscriber but not published yet.
-func (p *LocalTrackPublication) setPublishingCodecsQuality(subscribedCodecs []*livekit.SubscribedCodec) []string {
-	var codecNeedPub []string
+// the track(s) to be published for backup codec that is required by subscriber but not published yet,
+// and whether the qualities of the primary codec were updated.
+func (p *LocalTrackPublication) setPublishingCodecsQuality(subscribedCodecs []*livekit.SubscribedCodec) ([]string, bool) {
+	var (
+		codecNeedPub   []string
+		primaryUpdated bool
+	)
 	for _, subscribedCodec := range subscribedCodecs {
 		if strings.HasSuffix(strings.ToLower(p.MimeType()), subscribedCodec.Codec) {
 			// primary codec
+			p.lock.Lock()
+			p.subscribedQualities = subscribedCodec.Qualities
+			p.lock.Unlock()
+			primaryUpdated = true
+
+			if track, ok := asLocalTrack(p.track); ok && p.Kind() == TrackKindVideo {
+				// single layer, pause when no quality is needed
+				enabled := false
+				for _, subscribedQuality := range subscribedCodec.Qualities {
+					enabled = enabled || subscribedQuality.Enabled
+				}
+				if track.setPaused(!enabled) {
+					p.log.Infow("updating track enable", "trackID", p.SID(), "enabled", enabled, "codec", subscribedCodec.Codec)
+				}
+				continue
+			}
+
 			for _, subscribedQuality := range subscribedCodec.Qualities {
 				track := p.GetSimulcastTrack(subscribedQuality.Quality)
 				if track != nil {
-					track.setMuted(!subscribedQuality.Enabled)
+					track.setPaused(!subscribedQuality.Enabled)
 					p.log.Infow(
 						"updating layer enable",
 						"trackID", p.SID(),
@@ -539,7 +722,7 @@ func (p *LocalTrackPublication) setPublishingCodecsQuality(subscribedCodecs []*l
 		for _, subscribedQuality := range subscribedCodec.Qualities {
 			track := backupCodecTracksForSimulcast[subscribedQuality.Quality]
 			if track != nil {
-				track.setMuted(!subscribedQuality.Enabled)
+				track.setPaused(!subscribedQuality.Enabled)
 				p.log.Infow(
 					"updating layer enable",
 					"trackID", p.SID(),
@@ -551,7 +734,7 @@ func (p *LocalTrackPublication) setPublishingCodecsQuality(subscribedCodecs []*l
 		}
 	}
 
-	return codecNeedPub
+	return codecNeedPub, primaryUpdated
 }
 
 func (p *LocalTrackPublication) setAudioCodecSubscribed(subscribedCodecs []*livekit.SubscribedAudioCodec) []string {
@@ -594,19 +777,29 @@ func (p *LocalTrackPublication) unpublish(transport *PCTransport) error {
 		tracks = append(tracks, st)
 	}
 
-	tracks = append(tracks, p.backupCodecTrack)
+	if p.backupCodecTrack != nil {
+		tracks = append(tracks, p.backupCodecTrack)
+	}
 
 	for _, st := range p.backupCodecTracksForSimulcast {
 		tracks = append(tracks, st)
 	}
 	p.lock.RUnlock()
 
-	for _, sender := range transport.pc.GetSenders() {
+	for _, tr := range transport.pc.GetTransceivers() {
+		sender := tr.Sender()
+		if sender == nil {
+			continue
+		}
 		for _, track := range tracks {
 			if sender.Track() == track {
 				if err := transport.pc.RemoveTrack(sender); err != nil {
 					return err
 				}
+				// transceiver can be re-used for another track, which may prefer a different codec
+				if err := tr.SetCodecPreferences(nil); err != nil {
+					p.log.Warnw("could not reset codec preferences", err)
+				}
 			}
 		}
 	}
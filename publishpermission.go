@@ -0,0 +1,73 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// PublishPermissionError is returned before publishing when the participant's permissions do not allow
+// the track, it matches ErrPublishNotPermitted. Permissions come from the token grants or later updates by the server.
+type PublishPermissionError struct {
+	Source livekit.TrackSource
+	// MissingGrant is the video grant that is missing, canPublish or canPublishSources
+	MissingGrant string
+	// AllowedSources is set when the source is not in canPublishSources
+	AllowedSources []livekit.TrackSource
+}
+
+func (e *PublishPermissionError) Error() string {
+	if len(e.AllowedSources) > 0 {
+		allowed := make([]string, 0, len(e.AllowedSources))
+		for _, s := range e.AllowedSources {
+			allowed = append(allowed, trackSourceGrantName(s))
+		}
+		return fmt.Sprintf("%s: missing grant %s for %s, allowed sources are %s",
+			ErrPublishNotPermitted, e.MissingGrant, trackSourceGrantName(e.Source), strings.Join(allowed, ", "))
+	}
+	return fmt.Sprintf("%s: missing grant %s", ErrPublishNotPermitted, e.MissingGrant)
+}
+
+func (e *PublishPermissionError) Unwrap() error {
+	return ErrPublishNotPermitted
+}
+
+// trackSourceGrantName returns the source as written in canPublishSources grants
+func trackSourceGrantName(source livekit.TrackSource) string {
+	return strings.ToLower(source.String())
+}
+
+// checkPublishPermission returns a PublishPermissionError when the permissions deny publishing the source.
+// Unknown permissions, before joining, are not checked.
+func checkPublishPermission(perm *livekit.ParticipantPermission, source livekit.TrackSource) error {
+	if perm == nil {
+		return nil
+	}
+	if !perm.CanPublish {
+		return &PublishPermissionError{Source: source, MissingGrant: "canPublish"}
+	}
+	if len(perm.CanPublishSources) > 0 && !slices.Contains(perm.CanPublishSources, source) {
+		return &PublishPermissionError{
+			Source:         source,
+			MissingGrant:   "canPublishSources",
+			AllowedSources: slices.Clone(perm.CanPublishSources),
+		}
+	}
+	return nil
+}
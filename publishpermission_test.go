@@ -0,0 +1,46 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestCheckPublishPermission(t *testing.T) {
+	require.NoError(t, checkPublishPermission(nil, livekit.TrackSource_CAMERA))
+	require.NoError(t, checkPublishPermission(&livekit.ParticipantPermission{CanPublish: true}, livekit.TrackSource_CAMERA))
+
+	err := checkPublishPermission(&livekit.ParticipantPermission{}, livekit.TrackSource_MICROPHONE)
+	require.ErrorIs(t, err, ErrPublishNotPermitted)
+	require.Contains(t, err.Error(), "canPublish")
+
+	perm := &livekit.ParticipantPermission{
+		CanPublish:        true,
+		CanPublishSources: []livekit.TrackSource{livekit.TrackSource_MICROPHONE, livekit.TrackSource_SCREEN_SHARE_AUDIO},
+	}
+	require.NoError(t, checkPublishPermission(perm, livekit.TrackSource_MICROPHONE))
+
+	err = checkPublishPermission(perm, livekit.TrackSource_CAMERA)
+	require.ErrorIs(t, err, ErrPublishNotPermitted)
+	var permErr *PublishPermissionError
+	require.ErrorAs(t, err, &permErr)
+	require.Equal(t, "canPublishSources", permErr.MissingGrant)
+	require.Equal(t, perm.CanPublishSources, permErr.AllowedSources)
+	require.Equal(t, "participant is not permitted to publish: missing grant canPublishSources for camera, allowed sources are microphone, screen_share_audio", err.Error())
+}
@@ -0,0 +1,252 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"go.uber.org/atomic"
+)
+
+const defaultPublishSchedulerSlice = 10 * time.Millisecond
+
+// PublishSchedulerConfig configures a PublishScheduler, zero values use the defaults
+type PublishSchedulerConfig struct {
+	// uplink bitrate shared by all tracks of the scheduler, required
+	BitrateBps uint64
+	// length of a scheduling round, 10ms by default
+	Slice time.Duration
+	// samples buffered per track, 50 by default
+	Depth    int
+	Overflow OverflowPolicy
+}
+
+// PublishScheduler shares an uplink budget between tracks published from one process. Each round, the budget
+// is split between the tracks with buffered samples by weight (deficit round robin), so that a track sending
+// large samples, e.g. a 4K screen share, cannot delay samples of others, e.g. audio, beyond its share.
+type PublishScheduler struct {
+	config PublishSchedulerConfig
+
+	lock   sync.Mutex
+	space  *sync.Cond
+	tracks []*ScheduledTrack
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ScheduledTrack is a SampleWriter whose samples are written to its track by a PublishScheduler
+type ScheduledTrack struct {
+	s      *PublishScheduler
+	w      SampleWriter
+	weight uint64
+
+	// guarded by PublishScheduler.lock
+	queue   []pacedSample
+	deficit uint64
+	removed bool
+
+	dropped atomic.Uint64
+	// last error returned by the track, reported by WriteSample
+	err atomic.Error
+}
+
+type scheduledWrite struct {
+	t    *ScheduledTrack
+	item pacedSample
+}
+
+// NewPublishScheduler starts a scheduler, call Close when done
+func NewPublishScheduler(config PublishSchedulerConfig) *PublishScheduler {
+	if config.Slice <= 0 {
+		config.Slice = defaultPublishSchedulerSlice
+	}
+	if config.Depth <= 0 {
+		config.Depth = defaultPacedWriterDepth
+	}
+	s := &PublishScheduler{
+		config: config,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	s.space = sync.NewCond(&s.lock)
+	go s.run()
+	return s
+}
+
+// AddTrack schedules the samples written to the returned writer for w. A track with twice the weight of another
+// gets twice its share of the bitrate while both have samples buffered, a weight of 0 is treated as 1.
+func (s *PublishScheduler) AddTrack(w SampleWriter, weight uint64) *ScheduledTrack {
+	t := &ScheduledTrack{s: s, w: w, weight: max(weight, 1)}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		t.removed = true
+	} else {
+		s.tracks = append(s.tracks, t)
+	}
+	return t
+}
+
+// Close stops scheduling, buffered samples are discarded
+func (s *PublishScheduler) Close() {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		<-s.done
+		return
+	}
+	s.closed = true
+	for _, t := range s.tracks {
+		t.removed = true
+		t.queue = nil
+	}
+	s.tracks = nil
+	s.space.Broadcast()
+	s.lock.Unlock()
+
+	close(s.stop)
+	<-s.done
+}
+
+func (s *PublishScheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.Slice)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, w := range s.nextRound() {
+			if err := w.t.w.WriteSample(w.item.sample, w.item.opts); err != nil {
+				w.t.err.Store(err)
+			}
+		}
+	}
+}
+
+// nextRound adds the share of one slice to the deficit of each track with buffered samples
+// and takes the samples that fit
+func (s *PublishScheduler) nextRound() []scheduledWrite {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var weights uint64
+	for _, t := range s.tracks {
+		if len(t.queue) != 0 {
+			weights += t.weight
+		}
+	}
+	if weights == 0 {
+		return nil
+	}
+
+	budget := s.config.BitrateBps / 8 * uint64(s.config.Slice) / uint64(time.Second)
+	var writes []scheduledWrite
+	for _, t := range s.tracks {
+		if len(t.queue) == 0 {
+			continue
+		}
+		t.deficit += budget * t.weight / weights
+		for len(t.queue) != 0 && uint64(len(t.queue[0].sample.Data)) <= t.deficit {
+			t.deficit -= uint64(len(t.queue[0].sample.Data))
+			writes = append(writes, scheduledWrite{t: t, item: t.queue[0]})
+			t.queue[0] = pacedSample{}
+			t.queue = t.queue[1:]
+		}
+		if len(t.queue) == 0 {
+			// idle tracks do not save up budget
+			t.deficit = 0
+		}
+	}
+	if len(writes) != 0 {
+		s.space.Broadcast()
+	}
+	return writes
+}
+
+// WriteSample buffers a sample until the scheduler assigns enough budget to the track.
+// A write error of the track is returned by the next call.
+func (t *ScheduledTrack) WriteSample(sample media.Sample, opts *SampleWriteOptions) error {
+	if err := t.err.Swap(nil); err != nil {
+		return err
+	}
+
+	s := t.s
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for !t.removed && len(t.queue) >= s.config.Depth {
+		switch s.config.Overflow {
+		case OverflowDropNewest:
+			t.dropped.Inc()
+			return ErrPacedWriterFull
+		case OverflowDropOldest:
+			t.queue[0] = pacedSample{}
+			t.queue = t.queue[1:]
+			t.dropped.Inc()
+		default:
+			s.space.Wait()
+		}
+	}
+	if t.removed {
+		return ErrPacedWriterClosed
+	}
+	t.queue = append(t.queue, pacedSample{sample: sample, opts: opts})
+	return nil
+}
+
+// Buffered returns the number of samples waiting to be written
+func (t *ScheduledTrack) Buffered() int {
+	t.s.lock.Lock()
+	defer t.s.lock.Unlock()
+
+	return len(t.queue)
+}
+
+// Dropped returns the number of samples dropped by the overflow policy
+func (t *ScheduledTrack) Dropped() uint64 {
+	return t.dropped.Load()
+}
+
+// Remove stops scheduling the track, buffered samples are discarded
+func (t *ScheduledTrack) Remove() {
+	s := t.s
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if t.removed {
+		return
+	}
+	t.removed = true
+	t.queue = nil
+	for i, other := range s.tracks {
+		if other == t {
+			s.tracks = append(s.tracks[:i], s.tracks[i+1:]...)
+			break
+		}
+	}
+	s.space.Broadcast()
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPublishScheduler(config PublishSchedulerConfig) *PublishScheduler {
+	s := &PublishScheduler{config: config}
+	s.space = sync.NewCond(&s.lock)
+	return s
+}
+
+func TestPublishSchedulerWeightedShare(t *testing.T) {
+	// 8000 bytes per round
+	s := newTestPublishScheduler(PublishSchedulerConfig{BitrateBps: 6_400_000, Slice: 10 * time.Millisecond, Depth: 100})
+
+	audio := s.AddTrack(&recordingSampleWriter{}, 1)
+	screen := s.AddTrack(&recordingSampleWriter{}, 3)
+	for range 10 {
+		require.NoError(t, audio.WriteSample(media.Sample{Data: make([]byte, 100)}, nil))
+		require.NoError(t, screen.WriteSample(media.Sample{Data: make([]byte, 1000)}, nil))
+	}
+
+	written := map[*ScheduledTrack]int{}
+	for _, w := range s.nextRound() {
+		written[w.t] += len(w.item.sample.Data)
+	}
+	// 2000 bytes for audio, which only has 1000 buffered, and 6000 for the screen share
+	require.Equal(t, 1000, written[audio])
+	require.Equal(t, 6000, written[screen])
+	require.Zero(t, audio.Buffered())
+	require.Equal(t, 4, screen.Buffered())
+
+	// the screen share gets all of the next round
+	written = map[*ScheduledTrack]int{}
+	for _, w := range s.nextRound() {
+		written[w.t] += len(w.item.sample.Data)
+	}
+	require.Equal(t, 4000, written[screen])
+}
+
+func TestPublishSchedulerLargeSample(t *testing.T) {
+	// 1000 bytes per round
+	s := newTestPublishScheduler(PublishSchedulerConfig{BitrateBps: 800_000, Slice: 10 * time.Millisecond, Depth: 10})
+	video := s.AddTrack(&recordingSampleWriter{}, 1)
+	require.NoError(t, video.WriteSample(media.Sample{Data: make([]byte, 2500)}, nil))
+
+	// a sample larger than a round is written once enough budget accumulated
+	require.Empty(t, s.nextRound())
+	require.Empty(t, s.nextRound())
+	require.Len(t, s.nextRound(), 1)
+}
+
+func TestPublishSchedulerOverflow(t *testing.T) {
+	s := newTestPublishScheduler(PublishSchedulerConfig{BitrateBps: 800_000, Slice: 10 * time.Millisecond, Depth: 1, Overflow: OverflowDropNewest})
+	track := s.AddTrack(&recordingSampleWriter{}, 1)
+
+	require.NoError(t, track.WriteSample(media.Sample{Data: []byte{1}}, nil))
+	require.ErrorIs(t, track.WriteSample(media.Sample{Data: []byte{2}}, nil), ErrPacedWriterFull)
+	require.Equal(t, uint64(1), track.Dropped())
+
+	track.Remove()
+	require.ErrorIs(t, track.WriteSample(media.Sample{Data: []byte{3}}, nil), ErrPacedWriterClosed)
+	require.Empty(t, s.tracks)
+}
+
+func TestPublishSchedulerWrites(t *testing.T) {
+	s := NewPublishScheduler(PublishSchedulerConfig{BitrateBps: 8_000_000})
+	defer s.Close()
+
+	w := &recordingSampleWriter{}
+	track := s.AddTrack(w, 1)
+	require.NoError(t, track.WriteSample(media.Sample{Data: []byte{1, 2, 3}}, nil))
+	require.Eventually(t, func() bool {
+		_, data := w.written()
+		return len(data) == 3
+	}, time.Second, 5*time.Millisecond)
+}
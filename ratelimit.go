@@ -0,0 +1,159 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+type RateLimitKind string
+
+const (
+	RateLimitData RateLimitKind = "data"
+	RateLimitRPC  RateLimitKind = "rpc"
+)
+
+const (
+	// allows a single packet of the maximum size through byte limits
+	minBytesBurst = 64 * 1024
+	// OnRateLimited is called at most once per interval for a participant and kind
+	rateLimitedReportInterval = time.Second
+)
+
+type participantLimiters struct {
+	dataMessages *rate.Limiter
+	dataBytes    *rate.Limiter
+	rpcRequests  *rate.Limiter
+	rpcBytes     *rate.Limiter
+
+	lastReported map[RateLimitKind]time.Time
+}
+
+// inboundRateLimiter tracks inbound rates per remote participant identity
+type inboundRateLimiter struct {
+	lock         sync.Mutex
+	participants map[string]*participantLimiters
+}
+
+func newInboundRateLimiter() *inboundRateLimiter {
+	return &inboundRateLimiter{
+		participants: make(map[string]*participantLimiters),
+	}
+}
+
+func newLimiter(perSecond float64, minBurst int) *rate.Limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), max(int(math.Ceil(perSecond)), minBurst))
+}
+
+// allow returns whether a packet of size bytes is within the limits, and whether it should be reported
+func (l *inboundRateLimiter) allow(limits signalling.InboundRateLimits, identity string, kind RateLimitKind, size int) (allowed bool, report bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	p := l.participants[identity]
+	if p == nil {
+		p = &participantLimiters{
+			dataMessages: newLimiter(limits.DataMessagesPerSecond, 1),
+			dataBytes:    newLimiter(limits.DataBytesPerSecond, minBytesBurst),
+			rpcRequests:  newLimiter(limits.RPCRequestsPerSecond, 1),
+			rpcBytes:     newLimiter(limits.RPCBytesPerSecond, minBytesBurst),
+			lastReported: make(map[RateLimitKind]time.Time),
+		}
+		l.participants[identity] = p
+	}
+
+	messages, bytes := p.dataMessages, p.dataBytes
+	if kind == RateLimitRPC {
+		messages, bytes = p.rpcRequests, p.rpcBytes
+	}
+	now := time.Now()
+	if messages != nil && !messages.AllowN(now, 1) {
+		return false, p.shouldReport(kind, now)
+	}
+	if bytes != nil && !bytes.AllowN(now, size) {
+		return false, p.shouldReport(kind, now)
+	}
+	return true, false
+}
+
+func (p *participantLimiters) shouldReport(kind RateLimitKind, now time.Time) bool {
+	if now.Sub(p.lastReported[kind]) < rateLimitedReportInterval {
+		return false
+	}
+	p.lastReported[kind] = now
+	return true
+}
+
+func (l *inboundRateLimiter) remove(identity string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.participants, identity)
+}
+
+func (l *inboundRateLimiter) clear() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	clear(l.participants)
+}
+
+func hasInboundRateLimits(limits signalling.InboundRateLimits) bool {
+	return limits.DataMessagesPerSecond > 0 || limits.DataBytesPerSecond > 0 ||
+		limits.RPCRequestsPerSecond > 0 || limits.RPCBytesPerSecond > 0
+}
+
+// allowInboundPacket applies rate limits by packet type, responses to own requests are not limited
+func (e *RTCEngine) allowInboundPacket(packet *livekit.DataPacket, size int) bool {
+	identity := packet.ParticipantIdentity
+	switch msg := packet.Value.(type) {
+	case *livekit.DataPacket_RpcAck, *livekit.DataPacket_RpcResponse:
+		return true
+	case *livekit.DataPacket_RpcRequest:
+		if e.allowInbound(identity, RateLimitRPC, size) {
+			return true
+		}
+		if e.connParams.InboundRateLimits.RPCPolicy == RateLimitDeny {
+			_ = e.publishRpcAck(identity, msg.RpcRequest.Id)
+			_ = e.publishRpcResponse(identity, msg.RpcRequest.Id, nil, rpcErrorFromBuiltInCodes(RpcRateLimited, nil))
+		}
+		return false
+	default:
+		return e.allowInbound(identity, RateLimitData, size)
+	}
+}
+
+// allowInbound applies inbound rate limits to a received data packet
+func (e *RTCEngine) allowInbound(identity string, kind RateLimitKind, size int) bool {
+	if e.connParams == nil || identity == "" || !hasInboundRateLimits(e.connParams.InboundRateLimits) {
+		return true
+	}
+	allowed, report := e.inboundLimiter.allow(e.connParams.InboundRateLimits, identity, kind, size)
+	if report {
+		e.log.Infow("inbound rate limit exceeded", "participant", identity, "kind", kind)
+		e.engineHandler.OnRateLimited(identity, kind)
+	}
+	return allowed
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestInboundRateLimiter(t *testing.T) {
+	limits := signalling.InboundRateLimits{
+		DataMessagesPerSecond: 2,
+		RPCBytesPerSecond:     100,
+	}
+	l := newInboundRateLimiter()
+
+	allowed, _ := l.allow(limits, "a", RateLimitData, 10)
+	require.True(t, allowed)
+	allowed, _ = l.allow(limits, "a", RateLimitData, 10)
+	require.True(t, allowed)
+	allowed, report := l.allow(limits, "a", RateLimitData, 10)
+	require.False(t, allowed)
+	require.True(t, report)
+	_, report = l.allow(limits, "a", RateLimitData, 10)
+	require.False(t, report, "reported once per interval")
+
+	// limits are per participant
+	allowed, _ = l.allow(limits, "b", RateLimitData, 10)
+	require.True(t, allowed)
+
+	// single packet up to burst passes, then bytes are exhausted
+	allowed, _ = l.allow(limits, "a", RateLimitRPC, minBytesBurst)
+	require.True(t, allowed)
+	allowed, _ = l.allow(limits, "a", RateLimitRPC, 10)
+	require.False(t, allowed)
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"io"
+	"sync"
+
+	"github.com/livekit/mediatransportutil/pkg/nack"
+	"github.com/pion/transport/v3/packetio"
+)
+
+const (
+	// same as the defaults of pion/srtp
+	defaultRTPReceiveBufferSize  = 1000 * 1000
+	defaultRTCPReceiveBufferSize = 100 * 1000
+)
+
+// receiveBufferRegistry creates the receive buffers of a peer connection and keeps the RTP ones by SSRC,
+// so that their size can be changed per subscription
+type receiveBufferRegistry struct {
+	lock    sync.Mutex
+	buffers map[uint32]*packetio.Buffer
+	limits  map[uint32]int // limits set before the buffer was created
+}
+
+func newReceiveBufferRegistry() *receiveBufferRegistry {
+	return &receiveBufferRegistry{
+		buffers: make(map[uint32]*packetio.Buffer),
+		limits:  make(map[uint32]int),
+	}
+}
+
+// newBuffer is used as webrtc.SettingEngine.BufferFactory
+func (r *receiveBufferRegistry) newBuffer(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	buffer := packetio.NewBuffer()
+	if packetType != packetio.RTPBufferPacket {
+		buffer.SetLimitSize(defaultRTCPReceiveBufferSize)
+		return buffer
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	limit, ok := r.limits[ssrc]
+	if !ok {
+		limit = defaultRTPReceiveBufferSize
+	}
+	buffer.SetLimitSize(limit)
+	r.buffers[ssrc] = buffer
+	return &registeredBuffer{Buffer: buffer, onClose: func() { r.remove(ssrc, buffer) }}
+}
+
+// setLimit changes the size of the RTP buffer of an SSRC, a size of 0 restores the default
+func (r *receiveBufferRegistry) setLimit(ssrc uint32, size int) {
+	if size <= 0 {
+		size = defaultRTPReceiveBufferSize
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.limits[ssrc] = size
+	if buffer := r.buffers[ssrc]; buffer != nil {
+		buffer.SetLimitSize(size)
+	}
+}
+
+func (r *receiveBufferRegistry) remove(ssrc uint32, buffer *packetio.Buffer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.buffers[ssrc] == buffer {
+		delete(r.buffers, ssrc)
+		delete(r.limits, ssrc)
+	}
+}
+
+type registeredBuffer struct {
+	*packetio.Buffer
+	onClose func()
+}
+
+func (b *registeredBuffer) Close() error {
+	err := b.Buffer.Close()
+	b.onClose()
+	return err
+}
+
+// mergeBufferConfig fills the zero values of config with the ones of defaults
+func mergeBufferConfig(config, defaults SubscriptionBufferConfig) SubscriptionBufferConfig {
+	if config.ReceiveBufferSize == 0 {
+		config.ReceiveBufferSize = defaults.ReceiveBufferSize
+	}
+	if config.NACKWindow == 0 {
+		config.NACKWindow = defaults.NACKWindow
+	}
+	if config.TargetLatency == 0 {
+		config.TargetLatency = defaults.TargetLatency
+	}
+	return config
+}
+
+// nackQueueParams returns nil when NACKs are disabled
+func nackQueueParams(config SubscriptionBufferConfig) *nack.NackQueueParams {
+	if config.NACKWindow < 0 {
+		return nil
+	}
+	params := nack.NackQueueParamsDefault
+	if config.NACKWindow > 0 {
+		params.MaxLifetime = config.NACKWindow
+	}
+	// a packet arriving after the target latency is useless, so is requesting it
+	if config.TargetLatency > 0 && config.TargetLatency < params.MaxLifetime {
+		params.MaxLifetime = config.TargetLatency
+	}
+	return &params
+}
+
+func (e *RTCEngine) subscriptionBufferDefaults() SubscriptionBufferConfig {
+	if e == nil || e.connParams == nil {
+		return SubscriptionBufferConfig{}
+	}
+	return e.connParams.SubscriptionBuffer
+}
+
+// configureReceiveStream applies the buffer config to a received SSRC
+func (e *RTCEngine) configureReceiveStream(ssrc uint32, config SubscriptionBufferConfig) {
+	if e == nil {
+		return
+	}
+	e.receiveBuffers.setLimit(ssrc, config.ReceiveBufferSize)
+
+	params := nackQueueParams(config)
+	for _, t := range []func() (*PCTransport, bool){e.Subscriber, e.Publisher} {
+		if transport, ok := t(); ok && transport.nackGenerator != nil {
+			transport.nackGenerator.SetStreamParams(ssrc, params)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/packetio"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/mediatransportutil/pkg/nack"
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestReceiveBufferRegistry(t *testing.T) {
+	r := newReceiveBufferRegistry()
+
+	rtcp := r.newBuffer(packetio.RTCPBufferPacket, 1)
+	require.Empty(t, r.buffers)
+	require.NoError(t, rtcp.Close())
+
+	// limit set before the buffer is created
+	r.setLimit(2, 10)
+	buffer := r.newBuffer(packetio.RTPBufferPacket, 2)
+	require.Contains(t, r.buffers, uint32(2))
+	_, err := buffer.Write(make([]byte, 8))
+	require.NoError(t, err)
+	_, err = buffer.Write(make([]byte, 8))
+	require.ErrorIs(t, err, packetio.ErrFull)
+
+	// limit changed on a live buffer
+	r.setLimit(2, 100)
+	_, err = buffer.Write(make([]byte, 8))
+	require.NoError(t, err)
+
+	require.NoError(t, buffer.Close())
+	require.Empty(t, r.buffers)
+	require.Empty(t, r.limits)
+}
+
+func TestNackQueueParams(t *testing.T) {
+	params := nackQueueParams(SubscriptionBufferConfig{})
+	require.Equal(t, nack.NackQueueParamsDefault, *params)
+
+	params = nackQueueParams(SubscriptionBufferConfig{NACKWindow: time.Second})
+	require.Equal(t, time.Second, params.MaxLifetime)
+
+	params = nackQueueParams(SubscriptionBufferConfig{NACKWindow: time.Second, TargetLatency: 200 * time.Millisecond})
+	require.Equal(t, 200*time.Millisecond, params.MaxLifetime)
+
+	require.Nil(t, nackQueueParams(SubscriptionBufferConfig{NACKWindow: -1}))
+}
+
+func TestRemoteTrackPublicationBufferConfig(t *testing.T) {
+	defaults := SubscriptionBufferConfig{ReceiveBufferSize: 1000, NACKWindow: time.Second}
+	pub := &RemoteTrackPublication{}
+	pub.engine = &RTCEngine{connParams: &signalling.ConnectParams{SubscriptionBuffer: defaults}}
+	require.Equal(t, defaults, pub.BufferConfig())
+
+	pub.SetBufferConfig(SubscriptionBufferConfig{NACKWindow: -1, TargetLatency: 50 * time.Millisecond})
+	require.Equal(t, SubscriptionBufferConfig{
+		ReceiveBufferSize: 1000,
+		NACKWindow:        -1,
+		TargetLatency:     50 * time.Millisecond,
+	}, pub.BufferConfig())
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"math/rand"
+	"time"
+)
+
+// DefaultReconnectPolicy retries right away, then with a quadratic backoff capped at a minute
+type DefaultReconnectPolicy struct {
+	// 10 when zero
+	MaxAttempts int
+}
+
+func (p DefaultReconnectPolicy) NextRetryDelay(attempt int, _ time.Duration) (time.Duration, bool) {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = maxReconnectCount
+	}
+	if attempt >= maxAttempts {
+		return 0, false
+	}
+	if attempt == 0 {
+		return 0, true
+	}
+	return min(time.Duration((attempt-1)*(attempt-1))*initialReconnectInterval, maxReconnectInterval), true
+}
+
+// ExponentialReconnectPolicy retries right away, then doubles the delay up to MaxDelay. Jitter randomizes
+// delays so that many clients losing their connection at once do not retry at the same time.
+type ExponentialReconnectPolicy struct {
+	// delay before the second attempt, 300ms when zero
+	InitialDelay time.Duration
+	// 60s when zero
+	MaxDelay time.Duration
+	// fraction of the delay added or removed at random, between 0 and 1
+	Jitter float64
+	// give up after this many attempts or this long after the connection was lost, unlimited when zero
+	MaxAttempts int
+	MaxElapsed  time.Duration
+}
+
+func (p ExponentialReconnectPolicy) NextRetryDelay(attempt int, elapsed time.Duration) (time.Duration, bool) {
+	if (p.MaxAttempts > 0 && attempt >= p.MaxAttempts) || (p.MaxElapsed > 0 && elapsed >= p.MaxElapsed) {
+		return 0, false
+	}
+	if attempt == 0 {
+		return 0, true
+	}
+	delay, maxDelay := p.InitialDelay, p.MaxDelay
+	if delay <= 0 {
+		delay = initialReconnectInterval
+	}
+	if maxDelay <= 0 {
+		maxDelay = maxReconnectInterval
+	}
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * jitter * float64(delay))
+	}
+	if p.MaxElapsed > 0 {
+		delay = min(delay, p.MaxElapsed-elapsed)
+	}
+	return delay, true
+}
+
+func (e *RTCEngine) reconnectPolicy() ReconnectPolicy {
+	if e.connParams == nil {
+		return DefaultReconnectPolicy{}
+	}
+	if e.connParams.ReconnectPolicy != nil {
+		return e.connParams.ReconnectPolicy
+	}
+	return DefaultReconnectPolicy{MaxAttempts: e.connParams.MaxReconnectAttempts}
+}
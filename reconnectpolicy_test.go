@@ -0,0 +1,86 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestDefaultReconnectPolicy(t *testing.T) {
+	p := DefaultReconnectPolicy{}
+	var delays []time.Duration
+	for attempt := 0; ; attempt++ {
+		delay, ok := p.NextRetryDelay(attempt, 0)
+		if !ok {
+			break
+		}
+		delays = append(delays, delay)
+	}
+	require.Len(t, delays, maxReconnectCount)
+	require.Equal(t, time.Duration(0), delays[0])
+	require.Equal(t, time.Duration(0), delays[1])
+	require.Equal(t, initialReconnectInterval, delays[2])
+	require.Equal(t, 4*initialReconnectInterval, delays[3])
+
+	_, ok := DefaultReconnectPolicy{MaxAttempts: 2}.NextRetryDelay(2, 0)
+	require.False(t, ok)
+}
+
+func TestExponentialReconnectPolicy(t *testing.T) {
+	p := ExponentialReconnectPolicy{InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, expected := range []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		delay, ok := p.NextRetryDelay(attempt, time.Hour)
+		require.True(t, ok)
+		require.Equal(t, expected, delay)
+	}
+	// unlimited
+	delay, ok := p.NextRetryDelay(1000, time.Hour)
+	require.True(t, ok)
+	require.Equal(t, 5*time.Second, delay)
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay, _ := p.NextRetryDelay(2, 0)
+		require.GreaterOrEqual(t, delay, time.Second)
+		require.LessOrEqual(t, delay, 3*time.Second)
+	}
+
+	p = ExponentialReconnectPolicy{MaxAttempts: 3, MaxElapsed: time.Minute}
+	_, ok = p.NextRetryDelay(3, 0)
+	require.False(t, ok)
+	_, ok = p.NextRetryDelay(1, time.Minute)
+	require.False(t, ok)
+	// not beyond the deadline
+	delay, ok = p.NextRetryDelay(2, time.Minute-100*time.Millisecond)
+	require.True(t, ok)
+	require.Equal(t, 100*time.Millisecond, delay)
+}
+
+func TestEngineReconnectPolicy(t *testing.T) {
+	e := &RTCEngine{}
+	require.Equal(t, DefaultReconnectPolicy{}, e.reconnectPolicy())
+
+	e.connParams = &signalling.ConnectParams{MaxReconnectAttempts: 3}
+	require.Equal(t, DefaultReconnectPolicy{MaxAttempts: 3}, e.reconnectPolicy())
+
+	policy := ExponentialReconnectPolicy{}
+	WithReconnectPolicy(policy)(e.connParams)
+	require.Equal(t, policy, e.reconnectPolicy())
+}
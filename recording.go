@@ -0,0 +1,214 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	// RecordingStatusTopic is the data packet topic recording indicators are announced on.
+	// Packets on this topic are consumed by the Room and not passed to data callbacks.
+	RecordingStatusTopic = "lk.recording-status"
+	// RecordingMetadataKey is the key of the recording indicator in the room metadata JSON object.
+	RecordingMetadataKey = "lk.recording"
+)
+
+// RecordingIndicator is a standardized "recording in progress" notice, published in room metadata
+// and announced over the data channel, for UIs that need to show recording consent or a watermark.
+type RecordingIndicator struct {
+	Active bool `json:"active"`
+	// Label is an optional human readable notice, e.g. "This meeting is being recorded"
+	Label string `json:"label,omitempty"`
+	// StartedAt is the unix time in milliseconds the recording started
+	StartedAt int64  `json:"startedAt,omitempty"`
+	EgressID  string `json:"egressId,omitempty"`
+}
+
+// NewRecordingIndicator returns an active indicator started now.
+func NewRecordingIndicator(label string, egressID string) *RecordingIndicator {
+	return &RecordingIndicator{
+		Active:    true,
+		Label:     label,
+		StartedAt: time.Now().UnixMilli(),
+		EgressID:  egressID,
+	}
+}
+
+// RecordingIndicatorFromMetadata parses the recording indicator out of room metadata.
+// Returns false if the metadata is not a JSON object or does not contain an indicator.
+func RecordingIndicatorFromMetadata(metadata string) (*RecordingIndicator, bool) {
+	if metadata == "" {
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(metadata), &fields); err != nil {
+		return nil, false
+	}
+	raw, ok := fields[RecordingMetadataKey]
+	if !ok {
+		return nil, false
+	}
+	indicator := &RecordingIndicator{}
+	if err := json.Unmarshal(raw, indicator); err != nil {
+		return nil, false
+	}
+	return indicator, true
+}
+
+// SetRecordingIndicatorMetadata returns metadata with the recording indicator set, other keys are preserved.
+// A nil indicator removes it. Fails if metadata is neither empty nor a JSON object.
+func SetRecordingIndicatorMetadata(metadata string, indicator *RecordingIndicator) (string, error) {
+	fields := make(map[string]json.RawMessage)
+	if metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &fields); err != nil {
+			return "", fmt.Errorf("room metadata is not a JSON object: %w", err)
+		}
+		if fields == nil {
+			fields = make(map[string]json.RawMessage)
+		}
+	}
+	if indicator == nil {
+		delete(fields, RecordingMetadataKey)
+	} else {
+		raw, err := json.Marshal(indicator)
+		if err != nil {
+			return "", err
+		}
+		fields[RecordingMetadataKey] = raw
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// SetRecordingIndicator stores the indicator in the room metadata and announces it to all participants.
+// Pass an indicator with Active false, or nil, to clear it.
+func (c *RoomServiceClient) SetRecordingIndicator(ctx context.Context, roomName string, indicator *RecordingIndicator) error {
+	res, err := c.ListRooms(ctx, &livekit.ListRoomsRequest{Names: []string{roomName}})
+	if err != nil {
+		return err
+	}
+	if len(res.Rooms) == 0 {
+		return ErrRoomNotFound
+	}
+	metadata, err := SetRecordingIndicatorMetadata(res.Rooms[0].Metadata, indicator)
+	if err != nil {
+		return err
+	}
+	if _, err = c.UpdateRoomMetadata(ctx, &livekit.UpdateRoomMetadataRequest{
+		Room:     roomName,
+		Metadata: metadata,
+	}); err != nil {
+		return err
+	}
+
+	if indicator == nil {
+		indicator = &RecordingIndicator{}
+	}
+	payload, err := json.Marshal(indicator)
+	if err != nil {
+		return err
+	}
+	topic := RecordingStatusTopic
+	_, err = c.SendData(ctx, &livekit.SendDataRequest{
+		Room:  roomName,
+		Data:  payload,
+		Kind:  livekit.DataPacket_RELIABLE,
+		Topic: &topic,
+	})
+	return err
+}
+
+// AnnounceRecording announces a recording indicator to the other participants over the data channel.
+// Use RoomServiceClient.SetRecordingIndicator to also persist it in room metadata.
+func (p *LocalParticipant) AnnounceRecording(indicator RecordingIndicator) error {
+	payload, err := json.Marshal(indicator)
+	if err != nil {
+		return err
+	}
+	return p.PublishDataPacket(UserData(payload),
+		WithDataPublishTopic(RecordingStatusTopic),
+		WithDataPublishReliable(true),
+	)
+}
+
+// RecordingIndicator returns the last recording indicator received from room metadata or an announcement.
+func (r *Room) RecordingIndicator() (RecordingIndicator, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.recordingIndicator == nil {
+		return RecordingIndicator{}, false
+	}
+	return *r.recordingIndicator, true
+}
+
+// isRecordingLocked is the effective recording status: the server flag or an active indicator
+func (r *Room) isRecordingLocked() bool {
+	return r.activeRecording || r.recordingIndicator != nil && r.recordingIndicator.Active
+}
+
+// updateRecordingIndicatorFromMetadataLocked picks up the indicator from room metadata.
+// An indicator that was only announced is kept when metadata does not carry one.
+func (r *Room) updateRecordingIndicatorFromMetadataLocked(metadata string) {
+	if indicator, ok := RecordingIndicatorFromMetadata(metadata); ok {
+		r.recordingIndicator = indicator
+		r.recordingFromMetadata = true
+	} else if r.recordingFromMetadata {
+		r.recordingIndicator = nil
+		r.recordingFromMetadata = false
+	}
+}
+
+// handleRecordingAnnouncement applies recording indicators received on RecordingStatusTopic,
+// returns true if the packet was an announcement
+func (r *Room) handleRecordingAnnouncement(dataPacket DataPacket) bool {
+	user, ok := dataPacket.(*UserDataPacket)
+	if !ok || user.Topic != RecordingStatusTopic {
+		return false
+	}
+	indicator := &RecordingIndicator{}
+	if err := json.Unmarshal(user.Payload, indicator); err != nil {
+		r.log.Warnw("could not parse recording indicator", err)
+		return true
+	}
+
+	r.lock.Lock()
+	wasRecording := r.isRecordingLocked()
+	r.recordingIndicator = indicator
+	r.recordingFromMetadata = false
+	isRecording := r.isRecordingLocked()
+	r.lock.Unlock()
+
+	if wasRecording != isRecording {
+		r.notifyRecordingChanged(isRecording)
+	}
+	return true
+}
+
+func (r *Room) notifyRecordingChanged(isRecording bool) {
+	go r.callback.OnRecordingStatusChanged(isRecording)
+	go r.callback.OnIsRecordingChanged(isRecording)
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingIndicatorMetadata(t *testing.T) {
+	metadata, err := SetRecordingIndicatorMetadata(`{"theme":"dark"}`, &RecordingIndicator{Active: true, Label: "recording"})
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal([]byte(metadata), &fields))
+	require.Equal(t, "dark", fields["theme"])
+
+	indicator, ok := RecordingIndicatorFromMetadata(metadata)
+	require.True(t, ok)
+	require.True(t, indicator.Active)
+	require.Equal(t, "recording", indicator.Label)
+
+	metadata, err = SetRecordingIndicatorMetadata(metadata, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"theme":"dark"}`, metadata)
+	_, ok = RecordingIndicatorFromMetadata(metadata)
+	require.False(t, ok)
+
+	_, err = SetRecordingIndicatorMetadata("plain text", nil)
+	require.Error(t, err)
+	_, ok = RecordingIndicatorFromMetadata("plain text")
+	require.False(t, ok)
+}
+
+func TestRoomRecordingStatus(t *testing.T) {
+	changes := make(chan bool, 10)
+	cb := NewRoomCallback()
+	cb.OnRecordingStatusChanged = func(isRecording bool) {
+		changes <- isRecording
+	}
+	received := make(chan struct{}, 1)
+	cb.OnDataPacket = func(DataPacket, DataReceiveParams) {
+		received <- struct{}{}
+	}
+	room := NewRoom(cb)
+
+	expect := func(isRecording bool) {
+		select {
+		case v := <-changes:
+			require.Equal(t, isRecording, v)
+		case <-time.After(time.Second):
+			t.Fatal("recording status not changed")
+		}
+		require.Equal(t, isRecording, room.IsRecording())
+	}
+
+	// indicator in metadata
+	metadata, err := SetRecordingIndicatorMetadata("", &RecordingIndicator{Active: true})
+	require.NoError(t, err)
+	room.OnRoomUpdate(&livekit.Room{Metadata: metadata})
+	expect(true)
+
+	// server flag alone keeps the room recording
+	room.OnRoomUpdate(&livekit.Room{Metadata: metadata, ActiveRecording: true})
+	room.OnRoomUpdate(&livekit.Room{ActiveRecording: true})
+	require.True(t, room.IsRecording())
+	room.OnRoomUpdate(&livekit.Room{})
+	expect(false)
+
+	// announcement over the data channel
+	payload, err := json.Marshal(RecordingIndicator{Active: true, Label: "consent"})
+	require.NoError(t, err)
+	room.OnDataPacket("recorder", &UserDataPacket{Topic: RecordingStatusTopic, Payload: payload})
+	expect(true)
+	indicator, ok := room.RecordingIndicator()
+	require.True(t, ok)
+	require.Equal(t, "consent", indicator.Label)
+
+	// unrelated metadata does not drop an announced indicator
+	room.OnRoomUpdate(&livekit.Room{Metadata: "{}"})
+	require.True(t, room.IsRecording())
+
+	room.OnDataPacket("recorder", &UserDataPacket{Topic: RecordingStatusTopic, Payload: []byte(`{"active":false}`)})
+	expect(false)
+
+	select {
+	case <-received:
+		t.Fatal("announcement passed to data callbacks")
+	default:
+	}
+}
+
+func TestRoomIsRecordingChanged(t *testing.T) {
+	changes := make(chan bool, 10)
+	cb := NewRoomCallback()
+	cb.OnIsRecordingChanged = func(isRecording bool) {
+		changes <- isRecording
+	}
+	room := NewRoom(cb)
+	require.False(t, room.IsRecording())
+
+	for _, active := range []bool{true, false} {
+		room.OnRoomUpdate(&livekit.Room{ActiveRecording: active})
+		select {
+		case v := <-changes:
+			require.Equal(t, active, v)
+		case <-time.After(time.Second):
+			t.Fatal("recording status not changed")
+		}
+		require.Equal(t, active, room.IsRecording())
+	}
+
+	// no callback without a change
+	room.OnRoomUpdate(&livekit.Room{})
+	select {
+	case <-changes:
+		t.Fatal("unexpected recording change")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -1,6 +1,7 @@
 package lksdk
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -50,33 +51,10 @@ func (r *regionURLProvider) RefreshRegionSettings(cloudHostname, token string) e
 		return nil
 	}
 
-	settingsURL := "https://" + cloudHostname + "/settings/regions"
-	req, err := http.NewRequest("GET", settingsURL, nil)
-	if err != nil {
-		return errors.New("refreshRegionSettings failed to create request: " + err.Error())
-	}
-	req.Header = http.Header{
-		"Authorization": []string{"Bearer " + token},
-	}
-
-	resp, err := r.httpClient.Do(req)
+	regions, err := fetchRegionSettings(context.Background(), r.httpClient, cloudHostname, token)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return errors.New("refreshRegionSettings failed to fetch region settings. http status: " + resp.Status)
-	}
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return errors.New("refreshRegionSettings failed to read response body: " + err.Error())
-	}
-	regions := &livekit.RegionSettings{}
-	if err := protojson.Unmarshal(respBody, regions); err != nil {
-		return errors.New("refreshRegionSettings failed to decode region settings: " + err.Error())
-	}
 
 	item := &hostnameSettingsCacheItem{
 		regionSettings:    regions,
@@ -112,6 +90,38 @@ func (r *regionURLProvider) PopBestURL(cloudHostname, token string) (string, err
 	return bestRegionURL, nil
 }
 
+// fetchRegionSettings returns the regions of a cloud project, ordered from nearest to farthest
+func fetchRegionSettings(ctx context.Context, httpClient *http.Client, cloudHostname, token string) (*livekit.RegionSettings, error) {
+	settingsURL := "https://" + cloudHostname + "/settings/regions"
+	req, err := http.NewRequestWithContext(ctx, "GET", settingsURL, nil)
+	if err != nil {
+		return nil, errors.New("refreshRegionSettings failed to create request: " + err.Error())
+	}
+	req.Header = http.Header{
+		"Authorization": []string{"Bearer " + token},
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("refreshRegionSettings failed to fetch region settings. http status: " + resp.Status)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.New("refreshRegionSettings failed to read response body: " + err.Error())
+	}
+	regions := &livekit.RegionSettings{}
+	if err := protojson.Unmarshal(respBody, regions); err != nil {
+		return nil, errors.New("refreshRegionSettings failed to decode region settings: " + err.Error())
+	}
+	return regions, nil
+}
+
 func parseCloudURL(serverURL string) (string, error) {
 	parsedURL, err := url.Parse(serverURL)
 	if err != nil {
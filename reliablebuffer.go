@@ -0,0 +1,154 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"github.com/pion/webrtc/v4"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type bufferedDataPacket struct {
+	sequence uint32
+	msg      webrtc.DataChannelMessage
+}
+
+// reliableSendBuffer holds reliable packets that may not have reached the server yet, so that they can be sent
+// again after the connection was resumed. Not safe for concurrent use, guarded by RTCEngine.reliableMsgLock
+type reliableSendBuffer struct {
+	packets []bufferedDataPacket
+	size    uint64
+}
+
+func (b *reliableSendBuffer) push(sequence uint32, msg webrtc.DataChannelMessage) {
+	b.packets = append(b.packets, bufferedDataPacket{sequence: sequence, msg: msg})
+	b.size += uint64(len(msg.Data))
+}
+
+// align drops packets that left the data channel, i.e. all but the last bufferedAmount bytes,
+// and returns their sequence numbers
+func (b *reliableSendBuffer) align(bufferedAmount uint64) []uint32 {
+	var sent []uint32
+	for _, pck := range b.leftChannel(bufferedAmount) {
+		sent = append(sent, pck.sequence)
+	}
+	return sent
+}
+
+// leftChannel drops and returns the packets that left the data channel, all but the last bufferedAmount bytes
+func (b *reliableSendBuffer) leftChannel(bufferedAmount uint64) []bufferedDataPacket {
+	var left []bufferedDataPacket
+	for len(b.packets) != 0 && b.size-uint64(len(b.packets[0].msg.Data)) >= bufferedAmount {
+		left = append(left, b.packets[0])
+		b.drop()
+	}
+	return left
+}
+
+// ack drops packets up to and including sequence, which the server reported as received
+func (b *reliableSendBuffer) ack(sequence uint32) []uint32 {
+	var acked []uint32
+	for len(b.packets) != 0 && b.packets[0].sequence <= sequence {
+		acked = append(acked, b.drop())
+	}
+	return acked
+}
+
+// reset drops all packets and returns their sequence numbers
+func (b *reliableSendBuffer) reset() []uint32 {
+	return b.ack(^uint32(0))
+}
+
+func (b *reliableSendBuffer) drop() uint32 {
+	pck := b.packets[0]
+	b.packets[0] = bufferedDataPacket{}
+	b.packets = b.packets[1:]
+	b.size -= uint64(len(pck.msg.Data))
+	return pck.sequence
+}
+
+// sendReliableLocked sends a sequenced reliable packet and keeps it until it left the data channel,
+// returns the packets that left it since the last call
+func (e *RTCEngine) sendReliableLocked(dc *webrtc.DataChannel, sequence uint32, msg webrtc.DataChannelMessage) []uint32 {
+	e.reliableSendBuffer.push(sequence, msg)
+	if err := sendDataChannelMessage(dc, msg); err != nil {
+		// not on the data channel, sent again on resume
+		e.log.Debugw("could not send reliable data packet", "error", err, "sequence", sequence)
+		return nil
+	}
+	return e.reliableSendBuffer.align(dc.BufferedAmount())
+}
+
+// alignReliableSendBuffer drops packets that left the reliable data channel and reports them as sent
+func (e *RTCEngine) alignReliableSendBuffer(dc *webrtc.DataChannel) {
+	e.reliableMsgLock.Lock()
+	sent := e.reliableSendBuffer.align(dc.BufferedAmount())
+	e.reliableMsgLock.Unlock()
+
+	e.reportDataSent(sent, nil)
+}
+
+// resendReliableMessages sends the buffered reliable packets the server did not receive before the connection
+// was resumed, lastSequence is the last sequence it received. Packets still queued on the data channel are not
+// sent again, and nothing is resent when the server did not report a sequence, i.e. lastSequence is 0
+func (e *RTCEngine) resendReliableMessages(lastSequence uint32) {
+	dc := e.GetDataChannel(livekit.DataPacket_RELIABLE)
+
+	e.reliableMsgLock.Lock()
+	var acked, failed []uint32
+	switch {
+	case len(e.reliableSendBuffer.packets) == 0:
+	case dc == nil:
+		failed = e.reliableSendBuffer.reset()
+	case lastSequence == 0:
+		// older servers don't report the last sequence, what left the channel can't be told apart from what arrived
+		acked = e.reliableSendBuffer.align(dc.BufferedAmount())
+	default:
+		acked = e.reliableSendBuffer.ack(lastSequence)
+		if packets := e.reliableSendBuffer.leftChannel(dc.BufferedAmount()); len(packets) != 0 {
+			e.log.Infow("resending reliable data packets", "count", len(packets))
+			for _, pck := range packets {
+				acked = append(acked, e.sendReliableLocked(dc, pck.sequence, pck.msg)...)
+			}
+		}
+	}
+	e.reliableMsgLock.Unlock()
+
+	e.reportDataSent(acked, nil)
+	e.reportDataSent(failed, ErrDataNotSent)
+}
+
+// failReliableMessages reports all buffered reliable packets as not sent,
+// used when the connection is not resumed but restarted or closed
+func (e *RTCEngine) failReliableMessages() {
+	e.reliableMsgLock.Lock()
+	failed := e.reliableSendBuffer.reset()
+	e.reliableMsgLock.Unlock()
+
+	e.reportDataSent(failed, ErrDataNotSent)
+}
+
+func (e *RTCEngine) reportDataSent(sequences []uint32, err error) {
+	if e.engineHandler == nil {
+		return
+	}
+	for _, sequence := range sequences {
+		e.engineHandler.OnDataSent(sequence, err)
+	}
+}
+
+func (r *Room) OnDataSent(sequence uint32, err error) {
+	r.callback.OnDataSent(sequence, err)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReliableSendBuffer(t *testing.T) {
+	var b reliableSendBuffer
+	for seq := uint32(1); seq <= 4; seq++ {
+		b.push(seq, webrtc.DataChannelMessage{Data: make([]byte, 10)})
+	}
+
+	// the last 15 bytes are still on the data channel, packet 3 partially
+	require.Equal(t, []uint32{1, 2}, b.align(15))
+	require.Equal(t, uint64(20), b.size)
+	require.Nil(t, b.align(20))
+
+	// server received packet 3 before the connection was resumed
+	require.Equal(t, []uint32{3}, b.ack(3))
+	require.Len(t, b.packets, 1)
+	require.Equal(t, uint32(4), b.packets[0].sequence)
+
+	require.Equal(t, []uint32{4}, b.reset())
+	require.Empty(t, b.packets)
+	require.Zero(t, b.size)
+}
+
+func TestResendReliableMessages(t *testing.T) {
+	// not open, sending fails and keeps the packet buffered
+	e := &RTCEngine{log: logger, reliableDC: &webrtc.DataChannel{}}
+	for seq := uint32(1); seq <= 3; seq++ {
+		e.reliableSendBuffer.push(seq, webrtc.DataChannelMessage{Data: make([]byte, 10)})
+	}
+
+	// packet 1 reached the server, 2 and 3 left the channel and are sent again
+	e.resendReliableMessages(1)
+	require.Len(t, e.reliableSendBuffer.packets, 2)
+	require.Equal(t, uint32(2), e.reliableSendBuffer.packets[0].sequence)
+	require.Equal(t, uint32(3), e.reliableSendBuffer.packets[1].sequence)
+}
+
+func TestResendReliableMessagesWithoutSequence(t *testing.T) {
+	e := &RTCEngine{log: logger, reliableDC: &webrtc.DataChannel{}}
+	for seq := uint32(1); seq <= 3; seq++ {
+		e.reliableSendBuffer.push(seq, webrtc.DataChannelMessage{Data: make([]byte, 10)})
+	}
+
+	// the server did not report a sequence, nothing is sent again
+	e.resendReliableMessages(0)
+	require.Empty(t, e.reliableSendBuffer.packets)
+	require.Zero(t, e.reliableSendBuffer.size)
+}
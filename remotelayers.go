@@ -0,0 +1,97 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"slices"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// AvailableLayer is a layer of a remote video track, as reported by the server
+type AvailableLayer struct {
+	// Codec is the mime type of the layer, a track with backup codecs has layers of each codec
+	Codec   string
+	Quality livekit.VideoQuality
+	Width   uint32
+	Height  uint32
+	// Bitrate is the target bitrate of the publisher in bits per second
+	Bitrate      uint32
+	SpatialLayer int32
+	RID          string
+	// SVC is set for layers sent as spatial layers of a single stream, e.g. VP9 or AV1
+	SVC bool
+}
+
+// availableLayers returns the layers of each codec from low to high quality
+func availableLayers(info *livekit.TrackInfo) []AvailableLayer {
+	if info == nil || info.Type != livekit.TrackType_VIDEO {
+		return nil
+	}
+
+	var layers []AvailableLayer
+	add := func(codec string, mode livekit.VideoLayer_Mode, videoLayers []*livekit.VideoLayer) {
+		start := len(layers)
+		for _, l := range videoLayers {
+			layers = append(layers, AvailableLayer{
+				Codec:        codec,
+				Quality:      l.Quality,
+				Width:        l.Width,
+				Height:       l.Height,
+				Bitrate:      l.Bitrate,
+				SpatialLayer: l.SpatialLayer,
+				RID:          l.Rid,
+				SVC:          mode == livekit.VideoLayer_MULTIPLE_SPATIAL_LAYERS_PER_STREAM,
+			})
+		}
+		slices.SortStableFunc(layers[start:], func(a, b AvailableLayer) int {
+			return int(a.Quality) - int(b.Quality)
+		})
+	}
+
+	for _, codec := range info.Codecs {
+		codecLayers := codec.Layers
+		if len(codecLayers) == 0 && len(info.Codecs) == 1 {
+			codecLayers = info.Layers
+		}
+		add(codec.MimeType, codec.VideoLayerMode, codecLayers)
+	}
+	if len(info.Codecs) == 0 {
+		add(info.MimeType, livekit.VideoLayer_MODE_UNUSED, info.Layers)
+	}
+	return layers
+}
+
+// AvailableLayers returns the layers the server reports for a video track, from low to high quality per codec.
+// Use SetVideoQuality or SetVideoDimensions to pick one, see OnTrackLayersChanged for updates.
+func (p *RemoteTrackPublication) AvailableLayers() []AvailableLayer {
+	return availableLayers(p.TrackInfo())
+}
+
+// HighestAvailableQuality returns the highest quality the server reports for the track,
+// false when no layers are known.
+func (p *RemoteTrackPublication) HighestAvailableQuality() (livekit.VideoQuality, bool) {
+	layers := p.AvailableLayers()
+	if len(layers) == 0 {
+		return livekit.VideoQuality_LOW, false
+	}
+	highest := layers[0].Quality
+	for _, l := range layers[1:] {
+		if l.Quality != livekit.VideoQuality_OFF {
+			highest = max(highest, l.Quality)
+		}
+	}
+	return highest, true
+}
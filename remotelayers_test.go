@@ -0,0 +1,80 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestRemoteAvailableLayers(t *testing.T) {
+	var changes [][]AvailableLayer
+	cb := NewRoomCallback()
+	cb.OnTrackLayersChanged = func(pub *RemoteTrackPublication, layers []AvailableLayer, rp *RemoteParticipant) {
+		changes = append(changes, layers)
+	}
+
+	track := &livekit.TrackInfo{
+		Sid:      "TR_video",
+		Type:     livekit.TrackType_VIDEO,
+		MimeType: "video/VP8",
+		Layers: []*livekit.VideoLayer{
+			{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720, Rid: "f"},
+			{Quality: livekit.VideoQuality_LOW, Width: 320, Height: 180, Rid: "q"},
+		},
+	}
+	pi := &livekit.ParticipantInfo{Identity: "publisher", Tracks: []*livekit.TrackInfo{track}}
+	rp := newRemoteParticipant(pi, cb, nil, nil, nil, logger)
+	pub := rp.getPublication("TR_video")
+	require.NotNil(t, pub)
+
+	layers := pub.AvailableLayers()
+	require.Len(t, layers, 2)
+	require.Equal(t, AvailableLayer{Codec: "video/VP8", Quality: livekit.VideoQuality_LOW, Width: 320, Height: 180, RID: "q"}, layers[0])
+	quality, ok := pub.HighestAvailableQuality()
+	require.True(t, ok)
+	require.Equal(t, livekit.VideoQuality_HIGH, quality)
+
+	// unchanged layers do not notify
+	pi.Version = 1
+	rp.updateInfo(pi)
+	require.Empty(t, changes)
+
+	// a backup codec sent with SVC
+	pi = &livekit.ParticipantInfo{Identity: "publisher", Version: 2, Tracks: []*livekit.TrackInfo{{
+		Sid:    "TR_video",
+		Type:   livekit.TrackType_VIDEO,
+		Layers: track.Layers,
+		Codecs: []*livekit.SimulcastCodecInfo{
+			{MimeType: "video/VP8", Layers: track.Layers},
+			{
+				MimeType:       "video/VP9",
+				VideoLayerMode: livekit.VideoLayer_MULTIPLE_SPATIAL_LAYERS_PER_STREAM,
+				Layers:         []*livekit.VideoLayer{{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720, SpatialLayer: 2}},
+			},
+		},
+	}}}
+	rp.updateInfo(pi)
+	require.Len(t, changes, 1)
+	require.Len(t, changes[0], 3)
+	require.Equal(t, "video/VP9", changes[0][2].Codec)
+	require.True(t, changes[0][2].SVC)
+	require.Equal(t, int32(2), changes[0][2].SpatialLayer)
+
+	require.Nil(t, availableLayers(&livekit.TrackInfo{Type: livekit.TrackType_AUDIO}))
+}
@@ -15,6 +15,7 @@
 package lksdk
 
 import (
+	"slices"
 	"time"
 
 	"github.com/pion/webrtc/v4"
@@ -25,25 +26,37 @@ import (
 
 type RemoteParticipant struct {
 	baseParticipant
-	pliWriter PLIWriter
-	engine    *RTCEngine
+	pliWriter     PLIWriter
+	engine        *RTCEngine
+	settingsStore *subscriptionStateStore
+	// delivers track and data events in order, see eventQueue
+	events *eventQueue
 }
 
-func newRemoteParticipant(pi *livekit.ParticipantInfo, roomCallback *RoomCallback, engine *RTCEngine, pliWriter PLIWriter, log protoLogger.Logger) *RemoteParticipant {
+func newRemoteParticipant(pi *livekit.ParticipantInfo, roomCallback *RoomCallback, engine *RTCEngine, pliWriter PLIWriter, settingsStore *subscriptionStateStore, log protoLogger.Logger) *RemoteParticipant {
 	p := &RemoteParticipant{
 		baseParticipant: *newBaseParticipant(roomCallback, log.WithValues("isLocal", false)),
 		engine:          engine,
 		pliWriter:       pliWriter,
+		settingsStore:   settingsStore,
+		events:          newEventQueue(),
 	}
 	p.updateInfo(pi)
 	return p
 }
 
 func (p *RemoteParticipant) updateInfo(pi *livekit.ParticipantInfo) {
+	oldSIPInfo, _ := p.SIPInfo()
+	oldIngressInfo, _ := p.IngressInfo()
+	defer p.notifyIngressInfoChanged(oldIngressInfo)
 	if !p.baseParticipant.updateInfo(pi, p) {
 		// not a valid update, could be due to older version
 		return
 	}
+	if sipInfo, ok := p.SIPInfo(); ok && sipInfo != oldSIPInfo {
+		p.Callback.OnSIPInfoChanged(sipInfo, p)
+		p.roomCallback.OnSIPInfoChanged(sipInfo, p)
+	}
 	// update tracks
 	validPubs := make(map[string]TrackPublication)
 	newPubs := make(map[string]TrackPublication)
@@ -56,29 +69,65 @@ func (p *RemoteParticipant) updateInfo(pi *livekit.ParticipantInfo) {
 			remotePub.updateInfo(ti)
 			remotePub.engine = p.engine
 			remotePub.participantID = p.sid
+			remotePub.settingsStore = p.settingsStore
+			if p.settingsStore != nil {
+				// set before a full reconnect, the receiver gets it once subscribed
+				if ts, ok := p.settingsStore.get(ti.Sid); ok && ts.bufferConfig != nil {
+					remotePub.bufferConfig = ts.bufferConfig
+				}
+			}
+			remotePub.onEncryptionChanged = p.onEncryptionStatusChanged
+			remotePub.onTrackCodecChanged = p.onTrackCodecChanged
 			p.addPublication(remotePub)
 			newPubs[ti.Sid] = remotePub
 			pub = remotePub
 		} else {
 			wasMuted := pub.IsMuted()
+			oldLayers := pub.AvailableLayers()
+			oldEncryption := pub.EncryptionType()
 			pub.updateInfo(ti)
+			if ti.Encryption != oldEncryption {
+				p.onEncryptionStatusChanged(pub, pub.EncryptionStatus())
+			}
+			if layers := pub.AvailableLayers(); !slices.Equal(oldLayers, layers) {
+				p.Callback.OnTrackLayersChanged(pub, layers, p)
+				p.roomCallback.OnTrackLayersChanged(pub, layers, p)
+			}
 			if ti.Muted != wasMuted {
 				if ti.Muted {
-					p.Callback.OnTrackMuted(pub, p)
-					p.roomCallback.OnTrackMuted(pub, p)
+					p.events.enqueue(func() {
+						p.Callback.OnTrackMuted(pub, p)
+						p.roomCallback.OnTrackMuted(pub, p)
+					})
 				} else {
-					p.Callback.OnTrackUnmuted(pub, p)
-					p.roomCallback.OnTrackUnmuted(pub, p)
+					p.events.enqueue(func() {
+						p.Callback.OnTrackUnmuted(pub, p)
+						p.roomCallback.OnTrackUnmuted(pub, p)
+					})
 				}
 			}
 		}
 		validPubs[ti.Sid] = pub
 	}
 
+	// server does not auto subscribe on audio only connections or with a filter
+	if p.engine != nil {
+		for _, pub := range newPubs {
+			if p.engine.clientAutoSubscribes(p.Identity(), pub) {
+				if err := pub.(*RemoteTrackPublication).SetSubscribed(true); err != nil {
+					p.engine.log.Warnw("could not subscribe to track", err, "trackID", pub.SID())
+				}
+			}
+		}
+	}
+
 	// send events for new publications
 	for _, pub := range newPubs {
-		p.Callback.OnTrackPublished(pub.(*RemoteTrackPublication), p)
-		p.roomCallback.OnTrackPublished(pub.(*RemoteTrackPublication), p)
+		remotePub := pub.(*RemoteTrackPublication)
+		p.events.enqueue(func() {
+			p.Callback.OnTrackPublished(remotePub, p)
+			p.roomCallback.OnTrackPublished(remotePub, p)
+		})
 	}
 
 	var toUnpublish []string
@@ -102,7 +151,7 @@ func (p *RemoteParticipant) addSubscribedMediaTrack(
 	pub := p.getPublication(trackSID)
 	if pub == nil {
 		// wait for metadata to arrive
-		go func() {
+		p.engine.goroutineRegistry().Go("track-info-wait", func() {
 			start := time.Now()
 			for time.Since(start) < 5*time.Second {
 				pub := p.getPublication(trackSID)
@@ -112,9 +161,8 @@ func (p *RemoteParticipant) addSubscribedMediaTrack(
 				}
 				time.Sleep(50 * time.Millisecond)
 			}
-			p.Callback.OnTrackSubscriptionFailed(trackSID, p)
-			p.roomCallback.OnTrackSubscriptionFailed(trackSID, p)
-		}()
+			p.notifySubscriptionFailed(trackSID, ErrCannotFindTrack)
+		})
 		return
 	}
 	pub.setReceiverAndTrack(receiver, track)
@@ -125,8 +173,17 @@ func (p *RemoteParticipant) addSubscribedMediaTrack(
 		"trackID", pub.sid.Load(),
 		"kind", pub.kind.Load(),
 	)
-	p.Callback.OnTrackSubscribed(track, pub, p)
-	p.roomCallback.OnTrackSubscribed(track, pub, p)
+	p.events.enqueueDetached(trackSID, func() {
+		p.Callback.OnTrackSubscribed(track, pub, p)
+		p.roomCallback.OnTrackSubscribed(track, pub, p)
+	})
+}
+
+func (p *RemoteParticipant) onEncryptionStatusChanged(pub *RemoteTrackPublication, status EncryptionStatus) {
+	p.events.enqueue(func() {
+		p.Callback.OnEncryptionStatusChanged(pub, status, p)
+		p.roomCallback.OnEncryptionStatusChanged(pub, status, p)
+	})
 }
 
 func (p *RemoteParticipant) getPublication(trackSID string) *RemoteTrackPublication {
@@ -150,15 +207,25 @@ func (p *RemoteParticipant) unpublishTrack(sid string, sendUnpublish bool) {
 		p.videoTracks.Delete(sid)
 	}
 	p.tracks.Delete(sid)
+	pub.stopSubscriptionRetry()
+	pub.stopAudioLevel()
+	pub.stopCodecMonitor()
 
 	track := pub.TrackRemote()
 	if track != nil {
-		p.Callback.OnTrackUnsubscribed(track, pub, p)
-		p.roomCallback.OnTrackUnsubscribed(track, pub, p)
+		p.events.enqueueAfterDetached(sid, func() {
+			p.Callback.OnTrackUnsubscribed(track, pub, p)
+			p.roomCallback.OnTrackUnsubscribed(track, pub, p)
+		})
 	}
 	if sendUnpublish {
-		p.Callback.OnTrackUnpublished(pub, p)
-		p.roomCallback.OnTrackUnpublished(pub, p)
+		if p.settingsStore != nil {
+			p.settingsStore.remove(sid)
+		}
+		p.events.enqueueAfterDetached(sid, func() {
+			p.Callback.OnTrackUnpublished(pub, p)
+			p.roomCallback.OnTrackUnpublished(pub, p)
+		})
 	}
 }
 
@@ -167,13 +234,16 @@ func (p *RemoteParticipant) WritePLI(ssrc webrtc.SSRC) {
 }
 
 func (p *RemoteParticipant) unpublishAllTracks() {
-	p.tracks.Range(func(_, value interface{}) bool {
-		pub := value.(TrackPublication)
-		if remoteTrack, ok := pub.Track().(*webrtc.TrackRemote); ok {
-			if pub.Track() != nil {
-				p.Callback.OnTrackUnsubscribed(remoteTrack, pub.(*RemoteTrackPublication), p)
-				p.roomCallback.OnTrackUnsubscribed(remoteTrack, pub.(*RemoteTrackPublication), p)
-			}
+	p.tracks.Range(func(key, value interface{}) bool {
+		pub := value.(*RemoteTrackPublication)
+		pub.stopSubscriptionRetry()
+		pub.stopAudioLevel()
+		pub.stopCodecMonitor()
+		if remoteTrack, ok := pub.Track().(*webrtc.TrackRemote); ok && remoteTrack != nil {
+			p.events.enqueueAfterDetached(key.(string), func() {
+				p.Callback.OnTrackUnsubscribed(remoteTrack, pub, p)
+				p.roomCallback.OnTrackUnsubscribed(remoteTrack, pub, p)
+			})
 		}
 		return true
 	})
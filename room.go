@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -60,9 +61,17 @@ const (
 type ConnectionState string
 
 const (
-	ConnectionStateConnected    ConnectionState = "connected"
-	ConnectionStateReconnecting ConnectionState = "reconnecting"
+	ConnectionStateConnecting ConnectionState = "connecting"
+	ConnectionStateConnected  ConnectionState = "connected"
+	// the connection was lost and is being resumed with ICE restarts, media and participants are kept
+	ConnectionStateResuming ConnectionState = "resuming"
+	// the room is joined again after the connection could not be resumed, remote participants are re-added
+	ConnectionStateRestarting   ConnectionState = "restarting"
 	ConnectionStateDisconnected ConnectionState = "disconnected"
+
+	// Deprecated: rooms no longer report this state, see the breaking changes in CHANGELOG.md. While reconnecting,
+	// ConnectionState returns ConnectionStateResuming or ConnectionStateRestarting, use ConnectionState.IsReconnecting
+	ConnectionStateReconnecting ConnectionState = "reconnecting"
 )
 
 // -----------------------------------------------
@@ -87,6 +96,10 @@ const (
 	ParticipantConnector = ParticipantKind(livekit.ParticipantInfo_CONNECTOR)
 )
 
+func (k ParticipantKind) String() string {
+	return livekit.ParticipantInfo_Kind(k).String()
+}
+
 type ConnectInfo struct {
 	APIKey                string
 	APISecret             string
@@ -168,6 +181,362 @@ func WithExtraAttributes(attrs map[string]string) ConnectOption {
 	}
 }
 
+// WithAutoRepublish sets whether local tracks should be re-published automatically after a full reconnect.
+// Publication options and sample providers of the previous publications are re-used.
+// Results are reported through OnLocalTrackRepublished and OnLocalTrackRepublishFailed.
+// Default is true.
+func WithAutoRepublish(val bool) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.DisableAutoRepublish = !val
+	}
+}
+
+// WithSignalPing overrides the signal ping interval and timeout provided by the server.
+// When no pong is received within timeout, the connection is considered dead and is resumed,
+// rather than waiting for the underlying TCP connection to time out. Zero keeps the server value.
+func WithSignalPing(interval, timeout time.Duration) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.PingInterval = interval
+		p.PingTimeout = timeout
+	}
+}
+
+type SDPDirection = signalling.SDPDirection
+
+const (
+	SDPDirectionOutgoing = signalling.SDPDirectionOutgoing
+	SDPDirectionIncoming = signalling.SDPDirectionIncoming
+)
+
+// WithSDPTransformer sets a function to modify session descriptions, outgoing offers and answers are
+// passed in before being sent, incoming ones before being applied. Outgoing ones are applied locally as created,
+// the transformed description is only what the server sees.
+// This allows tweaking fmtp lines, bandwidth or codec ordering. The returned description must remain valid.
+func WithSDPTransformer(transformer func(direction SDPDirection, sd webrtc.SessionDescription) webrtc.SessionDescription) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.SDPTransformer = transformer
+	}
+}
+
+type RTCPConfig = signalling.RTCPConfig
+
+// WithRTCPConfig tunes RTCP report intervals, NACK batching and NACK usage per track kind.
+// Ignored when custom interceptors are set with WithInterceptors, apart from NACK usage.
+func WithRTCPConfig(config RTCPConfig) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.RTCP = config
+	}
+}
+
+type ICEKeepaliveConfig = signalling.ICEKeepaliveConfig
+
+// WithICEKeepalive tunes how often ICE keepalives are sent and how long the connection survives without
+// traffic from the server. Lower the interval when middleboxes expire NAT bindings during long silent periods,
+// e.g. a muted microphone with DTX. The interval is capped below the disconnected timeout.
+func WithICEKeepalive(config ICEKeepaliveConfig) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.ICEKeepalive = config
+	}
+}
+
+// WithAudioOnly restricts the connection to audio, for telephony and voice agent workloads.
+// Only audio codecs are negotiated, video feedback and NACK buffers are not set up,
+// publishing video fails with ErrAudioOnly and auto subscribe only applies to audio tracks.
+func WithAudioOnly() ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.AudioOnly = true
+	}
+}
+
+// WithNegotiationTimeout sets how long to wait for an answer before recovering a stuck negotiation.
+// Recovery retries the negotiation first, then resumes and finally fully reconnects, see OnNegotiationStalled.
+// Default is 10s, a negative value disables the watchdog.
+func WithNegotiationTimeout(timeout time.Duration) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.NegotiationTimeout = timeout
+	}
+}
+
+// WithTranscriptionBridge delivers transcriptions through one API regardless of the server protocol version.
+// Transcriptions received as text streams on TopicTranscription are passed to OnTranscriptionReceived
+// when no handler is registered for the topic. Legacy transcription packets are also passed to a handler
+// registered for TopicTranscription, as one closed text stream per segment.
+func WithTranscriptionBridge() ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.BridgeTranscriptions = true
+	}
+}
+
+// WithPresenceHeartbeat enables presence heartbeats on the lossy data channel.
+// Participants that send heartbeats and then miss three of them are reported with OnParticipantLivenessChanged,
+// detecting zombie participants faster than the server timeout. See Room.LastSeen.
+func WithPresenceHeartbeat(interval time.Duration) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.PresenceInterval = interval
+	}
+}
+
+type (
+	InboundRateLimits = signalling.InboundRateLimits
+	RateLimitPolicy   = signalling.RateLimitPolicy
+)
+
+const (
+	RateLimitDeny = signalling.RateLimitDeny
+	RateLimitDrop = signalling.RateLimitDrop
+)
+
+// WithInboundRateLimits limits data packets and RPC requests accepted from each remote participant.
+// Packets above the limits are dropped and reported with OnRateLimited.
+func WithInboundRateLimits(limits InboundRateLimits) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.InboundRateLimits = limits
+	}
+}
+
+type StreamGuard = signalling.StreamGuard
+
+// WithStreamGuard validates incoming data streams on receive, before any chunk is buffered.
+// Rejected streams are not passed to handlers and are reported with OnDataStreamRejected.
+func WithStreamGuard(guard StreamGuard) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.StreamGuard = guard
+	}
+}
+
+type StreamSpoolConfig = signalling.StreamSpoolConfig
+
+// WithStreamSpooling spools incoming byte streams to temporary files once they exceed the memory threshold.
+// Streams exceeding a quota are aborted with ErrStreamQuotaExceeded, their files are removed.
+func WithStreamSpooling(config StreamSpoolConfig) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.StreamSpool = config
+	}
+}
+
+// WithBandwidthEstimatesInterval enables periodic OnBandwidthEstimatesUpdated callbacks.
+func WithBandwidthEstimatesInterval(interval time.Duration) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.BandwidthEstimatesInterval = interval
+	}
+}
+
+// WithStatsReportInterval enables periodic OnStatsReport callbacks.
+func WithStatsReportInterval(interval time.Duration) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.StatsReportInterval = interval
+	}
+}
+
+// WithMaxReconnectAttempts sets how often resuming or restarting the connection is attempted before
+// the room is disconnected, 10 by default. Ignored when WithReconnectPolicy is used.
+func WithMaxReconnectAttempts(attempts int) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.MaxReconnectAttempts = attempts
+	}
+}
+
+type ReconnectPolicy = signalling.ReconnectPolicy
+
+// WithReconnectPolicy decides when reconnecting is attempted and when to give up, e.g. an
+// ExponentialReconnectPolicy retrying without limit for long running bots. See DefaultReconnectPolicy.
+func WithReconnectPolicy(policy ReconnectPolicy) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.ReconnectPolicy = policy
+	}
+}
+
+type PublishDefaults = signalling.PublishDefaults
+
+// WithPublishDefaults sets options for tracks published without them. DTX and stereo apply to audio tracks,
+// the backup codec policy to tracks published with PublishTrack.
+func WithPublishDefaults(defaults PublishDefaults) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.PublishDefaults = defaults
+	}
+}
+
+// WithReliableDataByDefault sends data packets reliably unless WithDataPublishReliable(false) is given.
+func WithReliableDataByDefault() ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.ReliableDataByDefault = true
+	}
+}
+
+// AttributeDataPacketEncoding is set to "json" by WithJSONDataPackets, so that other participants can tell
+// that data packets of this participant are text messages.
+const AttributeDataPacketEncoding = "lk.data_packet_encoding"
+
+// WithJSONDataPackets sends data packets as protojson text messages instead of binary protobuf, for interop
+// with clients that cannot decode protobuf. Received packets are parsed in either encoding.
+// The encoding is advertised with the AttributeDataPacketEncoding participant attribute.
+func WithJSONDataPackets() ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.JSONDataPackets = true
+		if p.Attributes == nil {
+			p.Attributes = make(map[string]string, 1)
+		}
+		p.Attributes[AttributeDataPacketEncoding] = "json"
+	}
+}
+
+// WithGoroutineLeakTimeout sets how long SDK goroutines may take to exit after disconnecting before
+// the remaining ones are logged, see Room.Goroutines. Default is 5s, a negative value disables the check.
+func WithGoroutineLeakTimeout(timeout time.Duration) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.GoroutineLeakTimeout = timeout
+	}
+}
+
+// WithParticipantConnectedOnJoin calls OnParticipantConnected for participants that were in the room
+// before joining as well, so that all participants are handled in one place. See also OnParticipantSnapshot.
+func WithParticipantConnectedOnJoin() ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.ParticipantConnectedOnJoin = true
+	}
+}
+
+type DTMFSequenceConfig = signalling.DTMFSequenceConfig
+
+// WithDTMFSequence configures how SIP DTMF digits are assembled into the sequences passed to OnDTMFSequence.
+// By default a sequence completes after 3s without a digit or when "#" is entered.
+func WithDTMFSequence(config DTMFSequenceConfig) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.DTMFSequence = &config
+	}
+}
+
+// WithInterfaceMTULimit lowers the max payload size of published tracks when the local network interface of the
+// publisher connection's selected ICE candidate has a small MTU, e.g. a VPN or tunnel, so that RTP packets are not
+// fragmented. Only the MTU of that interface is looked up, the path is not probed and smaller MTUs further
+// along it are not detected.
+func WithInterfaceMTULimit() ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.InterfaceMTULimit = true
+	}
+}
+
+// WithICENetworkTypes limits the local ICE candidates to the given network types, e.g. only UDP or only TCP.
+// All network types supported by pion are gathered by default.
+func WithICENetworkTypes(types ...webrtc.NetworkType) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.ICENetworkTypes = types
+	}
+}
+
+type (
+	ICEServersPolicy       = signalling.ICEServersPolicy
+	ICECredentialRefresher = signalling.ICECredentialRefresher
+)
+
+const (
+	ICEServersMerge   = signalling.ICEServersMerge
+	ICEServersReplace = signalling.ICEServersReplace
+)
+
+// WithICEServers sets ICE servers for the connection, e.g. own TURN servers. They are used in addition to the
+// servers sent by the server with ICEServersMerge, or instead of them with ICEServersReplace.
+// Servers set later with Room.UpdateICEServers take precedence.
+func WithICEServers(servers []webrtc.ICEServer, policy ICEServersPolicy) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.ICEServers = servers
+		p.ICEServersPolicy = policy
+	}
+}
+
+// WithICECredentialRefresh calls refresh before joining and before each reconnect with the servers of
+// WithICEServers, or those returned by the previous call, to renew time-limited TURN credentials.
+// When refresh fails, the previous servers are used.
+func WithICECredentialRefresh(refresh ICECredentialRefresher) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.ICECredentialRefresh = refresh
+	}
+}
+
+type DuplicateIdentityPolicy = signalling.DuplicateIdentityPolicy
+
+const (
+	DuplicateIdentityReplace = signalling.DuplicateIdentityReplace
+	DuplicateIdentityFail    = signalling.DuplicateIdentityFail
+)
+
+type AutoSubscribeFilter = signalling.AutoSubscribeFilter
+
+// WithAutoSubscribeFilter enables auto subscribe for the tracks matching the filter only, e.g. to keep an audio
+// transcription agent from receiving video. The server has no filter support, so the SDK subscribes to matching
+// tracks itself once they are published.
+func WithAutoSubscribeFilter(filter AutoSubscribeFilter) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.AutoSubscribe = true
+		p.AutoSubscribeFilter = &filter
+	}
+}
+
+type SubscriptionBufferConfig = signalling.SubscriptionBufferConfig
+
+// WithSubscriptionBufferConfig sets the receive buffer size, NACK window and target latency of subscribed tracks,
+// see RemoteTrackPublication.SetBufferConfig to tune a single subscription.
+func WithSubscriptionBufferConfig(config SubscriptionBufferConfig) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.SubscriptionBuffer = config
+	}
+}
+
+type SubscriptionBudget = signalling.SubscriptionBudget
+
+// WithSubscriptionBudget caps the media bytes received by the room. Approaching the cap, subscribed video is
+// downgraded and low priority subscriptions are paused, once it is reached only high priority subscriptions
+// keep receiving media. See RemoteTrackPublication.SetSubscriptionPriority and OnSubscriptionBudgetChanged.
+func WithSubscriptionBudget(budget SubscriptionBudget) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.SubscriptionBudget = budget
+	}
+}
+
+type AudioPriorityConfig = signalling.AudioPriorityConfig
+
+// WithAudioPriority protects published audio under congestion: audio packets are not queued in the pacer
+// set with WithPacer, and while more is sent than is estimated to be available from the TWCC feedback of the
+// server, video simulcast layers are paused one at a time starting with the highest. See OnAudioPriorityChanged.
+func WithAudioPriority(config AudioPriorityConfig) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.AudioPriority = &config
+	}
+}
+
+type (
+	SessionStore           = signalling.SessionStore
+	SessionState           = signalling.SessionState
+	TrackSubscriptionState = signalling.TrackSubscriptionState
+)
+
+// WithSessionStore persists the token, participant and subscription settings of the session, so that a process
+// restarting within maxAge (5 minutes when 0) can continue it with JoinWithSession. Media sessions do not survive
+// the process, the room is joined again: the server replaces the previous participant right away instead of
+// waiting for it to time out, and subscription settings are restored. See FileSessionStore.
+func WithSessionStore(store SessionStore, maxAge time.Duration) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.SessionStore = store
+		p.SessionMaxAge = maxAge
+	}
+}
+
+// WithDuplicateIdentityPolicy controls joining with an identity that is already connected, e.g. when an agent restarts.
+// The server replaces the existing participant by default. DuplicateIdentityFail looks the identity up with the
+// join token before joining, which requires the roomAdmin grant, and fails with ErrDuplicateIdentity when found.
+func WithDuplicateIdentityPolicy(policy DuplicateIdentityPolicy) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.DuplicateIdentity = policy
+	}
+}
+
+// for internal use to restrict the ICE servers sent by the server
+func withICEServerFilter(filter func(url string) bool) ConnectOption {
+	return func(p *signalling.ConnectParams) {
+		p.ICEServerFilter = filter
+	}
+}
+
 // for internal use to test codecs
 func withCodecs(codecs []webrtc.RTPCodecParameters) ConnectOption {
 	return func(p *signalling.ConnectParams) {
@@ -185,17 +554,48 @@ type Room struct {
 	name                    string
 	LocalParticipant        *LocalParticipant
 	callback                *RoomCallback
-	connectionState         ConnectionState
 	sidReady                chan struct{}
 
 	remoteParticipants map[livekit.ParticipantIdentity]*RemoteParticipant
 	sidToIdentity      map[livekit.ParticipantID]livekit.ParticipantIdentity
 	sidDefers          map[livekit.ParticipantID]map[livekit.TrackID]func(p *RemoteParticipant)
+	pendingEvents      map[string]*pendingEvents
+	releasedEvents     *eventQueue // held events of senders that never connected, see releaseEvents
 	metadata           string
 	activeRecording    bool
 	activeSpeakers     []Participant
 	serverInfo         *livekit.ServerInfo
 	regionURLProvider  *regionURLProvider
+	subscriptionStore  *subscriptionStateStore
+	presence           *presenceTracker
+	streamSpooler      *streamSpooler
+	dtmf               *dtmfAggregator
+	subscriptionBudget *subscriptionBudgetWatcher
+	audioPriority      *audioPriorityController
+	session            *sessionPersister
+
+	// recording indicator from room metadata or an announcement, see RecordingIndicator
+	recordingIndicator    *RecordingIndicator
+	recordingFromMetadata bool
+
+	// see SetTopicPolicy
+	topicPolicy *TopicPolicy
+
+	// outstanding pings, see MeasureDataRTT
+	dataPings *dataPingTracker
+
+	// see SendMailboxRequest
+	mailbox *mailbox
+	// see OpenVirtualStream
+	virtualStreams *virtualStreamMux
+	// see NumParticipants
+	participantCounts *participantCounts
+	// periodic reports, a slow callback skips the reports it missed
+	bandwidthEstimates *latestEvent[BandwidthEstimates]
+	statsReports       *latestEvent[StatsReport]
+
+	// see DisconnectionError
+	disconnectErr *DisconnectionError
 
 	sifTrailer []byte
 
@@ -203,33 +603,56 @@ type Room struct {
 	byteStreamReaders  *sync.Map
 	textStreamHandlers *sync.Map
 	textStreamReaders  *sync.Map
-	rpcHandlers        *sync.Map
 
 	lock sync.RWMutex
 }
 
 // NewRoom can be used to update callbacks before calling Join
 func NewRoom(callback *RoomCallback) *Room {
+	return newRoom(callback, semver.Compare("v"+Version, "v3.0.0") >= 0, newRegionURLProvider())
+}
+
+func newRoom(callback *RoomCallback, useSinglePeerConnection bool, regionURLProvider *regionURLProvider) *Room {
 	r := &Room{
 		log:                     logger,
-		useSinglePeerConnection: semver.Compare("v"+Version, "v3.0.0") >= 0,
+		useSinglePeerConnection: useSinglePeerConnection,
 		remoteParticipants:      make(map[livekit.ParticipantIdentity]*RemoteParticipant),
 		sidToIdentity:           make(map[livekit.ParticipantID]livekit.ParticipantIdentity),
 		sidDefers:               make(map[livekit.ParticipantID]map[livekit.TrackID]func(*RemoteParticipant)),
+		pendingEvents:           make(map[string]*pendingEvents),
+		releasedEvents:          &eventQueue{},
 		callback:                NewRoomCallback(),
 		sidReady:                make(chan struct{}),
-		connectionState:         ConnectionStateDisconnected,
-		regionURLProvider:       newRegionURLProvider(),
+		regionURLProvider:       regionURLProvider,
+		subscriptionStore:       newSubscriptionStateStore(),
+		presence:                newPresenceTracker(),
+		subscriptionBudget:      newSubscriptionBudgetWatcher(),
+		audioPriority:           newAudioPriorityController(),
+		session:                 &sessionPersister{},
+		dataPings:               newDataPingTracker(),
+		mailbox:                 newMailbox(),
+		participantCounts:       newParticipantCounts(),
+		streamSpooler:           &streamSpooler{},
 		byteStreamHandlers:      &sync.Map{},
 		byteStreamReaders:       &sync.Map{},
 		textStreamHandlers:      &sync.Map{},
 		textStreamReaders:       &sync.Map{},
-		rpcHandlers:             &sync.Map{},
 	}
 	r.callback.Merge(callback)
+	r.virtualStreams = newVirtualStreamMux(r.sendVirtualStreamFrame)
+	r.subscriptionStore.onChange = r.saveSession
 
 	r.engine = NewRTCEngine(r.useSinglePeerConnection, r, r.getLocalParticipantSID)
 	r.LocalParticipant = newLocalParticipant(r.engine, r.callback, r.serverInfo, r.log)
+	r.bandwidthEstimates = newLatestEvent(func(estimates BandwidthEstimates) {
+		r.callback.OnBandwidthEstimatesUpdated(estimates)
+	})
+	r.statsReports = newLatestEvent(func(report StatsReport) {
+		r.callback.OnStatsReport(report)
+	})
+	r.dtmf = newDTMFAggregator(r.engine.dtmfSequenceConfig, func(identity, digits string) {
+		r.callback.OnDTMFSequence(identity, digits)
+	})
 	return r
 }
 
@@ -320,14 +743,27 @@ func (r *Room) Join(url string, info ConnectInfo, opts ...ConnectOption) error {
 
 // JoinWithToken - customize participant options by generating your own token
 func (r *Room) JoinWithToken(url, token string, opts ...ConnectOption) error {
-	ctx := context.TODO()
+	return r.joinWithToken(context.TODO(), url, token, opts...)
+}
 
+func (r *Room) joinWithToken(ctx context.Context, url, token string, opts ...ConnectOption) error {
 	params := &signalling.ConnectParams{
 		AutoSubscribe: true,
 	}
 	for _, opt := range opts {
 		opt(params)
 	}
+	r.clearDisconnectionError()
+	r.restoreSession(url, token, params)
+	r.setConnectionState(ConnectionStateConnecting)
+
+	if params.DuplicateIdentity == DuplicateIdentityFail {
+		if err := checkIdentityAvailable(ctx, newTokenRoomService(url), token); err != nil {
+			r.setDisconnectionError(newFailureDisconnectionError(err, token, false))
+			r.setConnectionState(ConnectionStateDisconnected)
+			return err
+		}
+	}
 
 	isSuccess := false
 	cloudHostname, _ := parseCloudURL(url)
@@ -369,6 +805,8 @@ func (r *Room) JoinWithToken(url, token string, opts ...ConnectOption) error {
 
 	if !isSuccess {
 		if _, err := r.engine.JoinContext(ctx, url, token, params); err != nil {
+			r.setDisconnectionError(newFailureDisconnectionError(err, token, false))
+			r.setConnectionState(ConnectionStateDisconnected)
 			return err
 		}
 	}
@@ -383,21 +821,27 @@ func (r *Room) Disconnect() {
 
 // DisconnectWithReason leaves the room with a specific disconnect reason.
 func (r *Room) DisconnectWithReason(reason livekit.DisconnectReason) {
+	r.setDisconnectionError(newLeaveDisconnectionError(reason))
 	_ = r.engine.SendLeaveWithReason(reason)
 	r.cleanup()
 }
 
-// ConnectionState returns the current connection state of the room.
-func (r *Room) ConnectionState() ConnectionState {
-	r.lock.RLock()
-	defer r.lock.RUnlock()
-	return r.connectionState
+// Close leaves the room like Disconnect and blocks until the connection is torn down and the SDK goroutines
+// of the room exited, or ctx is done. Callbacks that are still running are not waited for.
+func (r *Room) Close(ctx context.Context) error {
+	r.Disconnect()
+	if err := r.engine.Close(ctx); err != nil {
+		return err
+	}
+	if leaked := r.engine.goroutines.wait(ctx); leaked != nil {
+		return fmt.Errorf("%w: goroutines still running %v", ctx.Err(), leaked)
+	}
+	return nil
 }
 
-func (r *Room) setConnectionState(cs ConnectionState) {
-	r.lock.Lock()
-	r.connectionState = cs
-	r.lock.Unlock()
+// ConnectionState returns the current connection state of the room, see OnConnectionStateChanged.
+func (r *Room) ConnectionState() ConnectionState {
+	return r.engine.ConnectionState()
 }
 
 func (r *Room) deferParticipantUpdate(sid livekit.ParticipantID, trackID livekit.TrackID, fnc func(p *RemoteParticipant)) {
@@ -493,6 +937,30 @@ func (r *Room) GetRemoteParticipants() []*RemoteParticipant {
 	return participants
 }
 
+// GetParticipantsByKind returns remote participants of the given kinds
+func (r *Room) GetParticipantsByKind(kinds ...ParticipantKind) []*RemoteParticipant {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var participants []*RemoteParticipant
+	for _, rp := range r.remoteParticipants {
+		if slices.Contains(kinds, rp.Kind()) {
+			participants = append(participants, rp)
+		}
+	}
+	return participants
+}
+
+// GetAgents returns remote participants that are agents
+func (r *Room) GetAgents() []*RemoteParticipant {
+	return r.GetParticipantsByKind(ParticipantAgent)
+}
+
+// GetSIPParticipants returns remote participants that joined through SIP
+func (r *Room) GetSIPParticipants() []*RemoteParticipant {
+	return r.GetParticipantsByKind(ParticipantSIP)
+}
+
 // ActiveSpeakers returns a list of currently active speakers.
 // Speakers are ordered by audio level (loudest first).
 func (r *Room) ActiveSpeakers() []Participant {
@@ -507,11 +975,12 @@ func (r *Room) Metadata() string {
 	return r.metadata
 }
 
-// IsRecording returns true if the room is currently being recorded.
+// IsRecording returns true if the room is currently being recorded,
+// either by egress or as announced by an active RecordingIndicator.
 func (r *Room) IsRecording() bool {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
-	return r.activeRecording
+	return r.isRecordingLocked()
 }
 
 // ServerInfo returns information about the LiveKit server.
@@ -521,6 +990,37 @@ func (r *Room) ServerInfo() *livekit.ServerInfo {
 	return proto.Clone(r.serverInfo).(*livekit.ServerInfo)
 }
 
+// Capabilities returns the negotiated protocol version and the features supported by the server.
+func (r *Room) Capabilities() Capabilities {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return newCapabilities(r.serverInfo)
+}
+
+// ConnectionDetails returns the ICE candidate pairs currently used by the transports,
+// e.g. to find out whether media is relayed through TURN.
+func (r *Room) ConnectionDetails() ConnectionDetails {
+	return r.engine.ConnectionDetails()
+}
+
+// GetSessionStats returns the WebRTC stats reports of the peer connections, see pkg/statsexport
+// to record them over time.
+func (r *Room) GetSessionStats() SessionStats {
+	return r.engine.SessionStats()
+}
+
+// BandwidthEstimates returns the available outgoing bitrate estimated by the server, along with
+// measured outgoing and incoming media bitrates.
+func (r *Room) BandwidthEstimates() BandwidthEstimates {
+	return r.engine.BandwidthEstimates()
+}
+
+// SignalRTT returns the round trip time of the signal connection, measured with pings.
+// Returns 0 until a pong has been received.
+func (r *Room) SignalRTT() time.Duration {
+	return r.engine.SignalRTT()
+}
+
 // SifTrailer returns the SIF (Server Injected Frames) trailer data used by E2EE
 func (r *Room) SifTrailer() []byte {
 	r.lock.RLock()
@@ -550,7 +1050,7 @@ func (r *Room) addRemoteParticipant(pi *livekit.ParticipantInfo, updateExisting
 		if subscriber, ok := r.engine.Subscriber(); ok {
 			_ = subscriber.pc.WriteRTCP(pli)
 		}
-	}, r.log.WithValues("participant", pi.Identity))
+	}, r.subscriptionStore, r.log.WithValues("participant", pi.Identity))
 	r.remoteParticipants[livekit.ParticipantIdentity(pi.Identity)] = rp
 	r.sidToIdentity[livekit.ParticipantID(pi.Sid)] = livekit.ParticipantIdentity(pi.Identity)
 	return rp
@@ -578,7 +1078,7 @@ func (r *Room) sendSyncState() {
 
 	var trackSids []string
 	var trackSidsDisabled []string
-	sendUnsub := r.engine.connParams.AutoSubscribe
+	sendUnsub := r.engine.connParams.AutoSubscribe && !r.engine.connParams.AudioOnly
 	for _, rp := range r.GetRemoteParticipants() {
 		for _, t := range rp.TrackPublications() {
 			if t.IsSubscribed() != sendUnsub {
@@ -635,15 +1135,29 @@ func (r *Room) sendSyncState() {
 
 func (r *Room) cleanup() {
 	r.setConnectionState(ConnectionStateDisconnected)
-	r.engine.Close()
+	r.engine.CloseAsync()
 	r.LocalParticipant.closeTracks()
 	r.setSid("", true)
 	r.byteStreamHandlers.Clear()
+	r.byteStreamReaders.Range(func(_, reader any) bool {
+		reader.(*ByteStreamReader).closeWithError(ErrStreamAborted)
+		return true
+	})
 	r.byteStreamReaders.Clear()
 	r.textStreamHandlers.Clear()
 	r.textStreamReaders.Clear()
-	r.rpcHandlers.Clear()
+	r.session.clear(r.log)
+	r.subscriptionStore.clear()
+	r.presence.close()
+	r.dtmf.close()
+	r.subscriptionBudget.close()
+	r.audioPriority.close()
+	r.virtualStreams.closeParticipant("")
+	r.participantCounts.clear()
+	r.clearPendingEvents()
+	r.engine.inboundLimiter.clear()
 	r.LocalParticipant.cleanup()
+	r.checkGoroutineLeaks()
 }
 
 func (r *Room) setSid(sid string, allowEmpty bool) {
@@ -697,16 +1211,17 @@ func (r *Room) getLocalParticipantSID() string {
 // You may throw errors of type `RpcError` with a string `message` in the handler,
 // and they will be received on the caller's side with the message intact.
 // Other errors thrown in your handler will not be transmitted as-is, and will instead arrive to the caller as `1500` ("Application Error").
+//
+// See LocalParticipant.RegisterRpcMethod for timeouts, concurrency limits and middleware.
 func (r *Room) RegisterRpcMethod(method string, handler RpcHandlerFunc) error {
-	if _, loaded := r.rpcHandlers.LoadOrStore(method, handler); loaded {
-		return fmt.Errorf("rpc handler already registered for method: %s, unregisterRpcMethod before trying to register again", method)
-	}
-	return nil
+	return r.LocalParticipant.RegisterRpcMethod(method, func(_ context.Context, data RpcInvocationData) (string, error) {
+		return handler(data)
+	})
 }
 
 // UnregisterRpcMethod unregisters a previously registered RPC method.
 func (r *Room) UnregisterRpcMethod(method string) {
-	r.rpcHandlers.Delete(method)
+	r.LocalParticipant.UnregisterRpcMethod(method)
 }
 
 // RegisterTextStreamHandler registers a handler for incoming text streams on a specific topic.
@@ -719,6 +1234,16 @@ func (r *Room) RegisterTextStreamHandler(topic string, handler TextStreamHandler
 	return nil
 }
 
+// RegisterIncrementalTextStreamHandler registers a handler for incoming text streams on a specific topic,
+// that reads text as it arrives. A topic has either a TextStreamHandler or an IncrementalTextStreamHandler.
+// Use TopicTranscription or TopicChat to receive transcriptions and chat messages of other participants.
+func (r *Room) RegisterIncrementalTextStreamHandler(topic string, handler IncrementalTextStreamHandler) error {
+	if _, loaded := r.textStreamHandlers.LoadOrStore(topic, handler); loaded {
+		return fmt.Errorf("text stream handler already registered for topic: %s", topic)
+	}
+	return nil
+}
+
 // UnregisterTextStreamHandler removes a previously registered text stream handler.
 func (r *Room) UnregisterTextStreamHandler(topic string) {
 	r.textStreamHandlers.Delete(topic)
@@ -779,33 +1304,65 @@ func (r *Room) OnRoomJoined(
 	r.name = room.Name
 	r.metadata = room.Metadata
 	r.activeRecording = room.ActiveRecording
+	r.updateRecordingIndicatorFromMetadataLocked(room.Metadata)
+	isRecording := r.isRecordingLocked()
 	r.serverInfo = serverInfo
-	r.connectionState = ConnectionStateConnected
 	r.sifTrailer = make([]byte, len(sifTrailer))
 	copy(r.sifTrailer, sifTrailer)
 	r.lock.Unlock()
+	r.setConnectionState(ConnectionStateConnected)
+
+	if isRecording {
+		// let bots pause sensitive behavior when joining a room that is already recorded
+		go r.callback.OnIsRecordingChanged(true)
+	}
 
 	r.setSid(room.Sid, false)
+	r.updateParticipantCounts(room)
 
 	r.LocalParticipant.updateInfo(participant)
 	r.LocalParticipant.updateSubscriptionPermission()
 
+	var present []*RemoteParticipant
 	for _, pi := range otherParticipants {
-		r.addRemoteParticipant(pi, true)
+		rp := r.addRemoteParticipant(pi, true)
+		if r.engine.connParams != nil && r.engine.connParams.ParticipantConnectedOnJoin {
+			rp.events.release(r.participantConnectedEvent(rp))
+		} else {
+			rp.events.release(nil)
+		}
 		r.clearParticipantDefers(livekit.ParticipantID(pi.Sid), pi)
 		// no need to run participant defers here, since we are connected for the first time
+		present = append(present, rp)
+	}
+	go r.callback.OnParticipantSnapshot(present)
+
+	r.startPresence()
+	r.startSubscriptionBudget()
+	r.startAudioPriority()
+
+	if r.session.takeRestored() != nil {
+		r.restoreSubscriptions()
 	}
+	r.saveSession()
 }
 
 func (r *Room) OnDisconnected(reason DisconnectionReason) {
+	r.OnDisconnectedWithError(&DisconnectionError{Reason: reason, WasConnected: true})
+}
+
+func (r *Room) OnDisconnectedWithError(err *DisconnectionError) {
+	r.setDisconnectionError(err)
+	r.setConnectionState(ConnectionStateDisconnected)
 	r.callback.OnDisconnected()
-	r.callback.OnDisconnectedWithReason(reason)
+	r.callback.OnDisconnectedWithReason(err.Reason)
+	r.callback.OnDisconnectedWithError(err)
 
 	r.cleanup()
 }
 
 func (r *Room) OnRestarting() {
-	r.setConnectionState(ConnectionStateReconnecting)
+	r.setConnectionState(ConnectionStateRestarting)
 	r.callback.OnReconnecting()
 
 	for _, rp := range r.GetRemoteParticipants() {
@@ -824,15 +1381,75 @@ func (r *Room) OnRestarted(
 	r.LocalParticipant.updateSubscriptionPermission()
 
 	r.OnParticipantUpdate(otherParticipants)
+	r.restoreSubscriptions()
+	r.saveSession()
 
-	r.LocalParticipant.republishTracks()
+	if r.engine.connParams.DisableAutoRepublish {
+		r.LocalParticipant.unpublishAllTracks()
+	} else {
+		r.LocalParticipant.republishTracks()
+	}
 
 	r.setConnectionState(ConnectionStateConnected)
 	r.callback.OnReconnected()
 }
 
+// restoreSubscriptions re-applies subscription state and track settings that
+// were set on remote tracks before a full reconnect
+func (r *Room) restoreSubscriptions() {
+	var subscribe, unsubscribe []*livekit.ParticipantTracks
+	present := make(map[string]struct{})
+	for _, rp := range r.GetRemoteParticipants() {
+		var subscribeSids, unsubscribeSids []string
+		for _, pub := range rp.TrackPublications() {
+			remotePub, ok := pub.(*RemoteTrackPublication)
+			if !ok {
+				continue
+			}
+			present[remotePub.SID()] = struct{}{}
+
+			ts, ok := r.subscriptionStore.get(remotePub.SID())
+			if !ok {
+				continue
+			}
+			if ts.subscribed != nil {
+				if *ts.subscribed {
+					subscribeSids = append(subscribeSids, remotePub.SID())
+				} else {
+					unsubscribeSids = append(unsubscribeSids, remotePub.SID())
+				}
+			}
+			if settings := remotePub.restoreSettings(ts); settings != nil {
+				if err := r.engine.SendUpdateTrackSettings(settings); err != nil {
+					r.engine.reportError(ErrorCategoryMedia, "could not restore track settings", err, "trackID", remotePub.SID())
+				}
+			}
+		}
+		if len(subscribeSids) != 0 {
+			subscribe = append(subscribe, &livekit.ParticipantTracks{ParticipantSid: rp.SID(), TrackSids: subscribeSids})
+		}
+		if len(unsubscribeSids) != 0 {
+			unsubscribe = append(unsubscribe, &livekit.ParticipantTracks{ParticipantSid: rp.SID(), TrackSids: unsubscribeSids})
+		}
+	}
+	// tracks that are gone after reconnect will not come back with the same SID
+	r.subscriptionStore.retain(present)
+
+	for _, update := range []*livekit.UpdateSubscription{
+		{Subscribe: true, ParticipantTracks: subscribe},
+		{Subscribe: false, ParticipantTracks: unsubscribe},
+	} {
+		if len(update.ParticipantTracks) == 0 {
+			continue
+		}
+		if err := r.engine.SendUpdateSubscription(update); err != nil {
+			r.engine.reportError(ErrorCategoryMedia, "could not restore subscriptions", err, "subscribe", update.Subscribe)
+		}
+	}
+}
+
 func (r *Room) OnResuming() {
-	r.setConnectionState(ConnectionStateReconnecting)
+	r.setConnectionState(ConnectionStateResuming)
 	r.callback.OnReconnecting()
 }
 
@@ -849,22 +1466,43 @@ func (r *Room) OnDataPacket(identity string, dataPacket DataPacket) {
 		return
 	}
 	p := r.GetParticipantByIdentity(identity)
-	params := DataReceiveParams{
-		SenderIdentity: identity,
-		Sender:         p,
+	if r.handlePresence(p, dataPacket) || r.handleRecordingAnnouncement(dataPacket) || r.handleDataPing(identity, dataPacket) ||
+		r.handleMailbox(identity, dataPacket) || r.handleVirtualStream(identity, dataPacket) {
+		return
+	}
+	if msg, ok := dataPacket.(*livekit.SipDTMF); ok {
+		r.dtmf.add(identity, msg)
 	}
-	switch msg := dataPacket.(type) {
-	case *UserDataPacket: // compatibility
-		params.Topic = msg.Topic
+
+	deliver := func(p *RemoteParticipant) {
+		params := DataReceiveParams{
+			SenderIdentity: identity,
+			Sender:         p,
+		}
+		if msg, ok := dataPacket.(*UserDataPacket); ok { // compatibility
+			params.Topic = msg.Topic
+			if !r.allowTopic(msg.Topic, identity, p) {
+				return
+			}
+			if p != nil {
+				p.Callback.OnDataReceived(msg.Payload, params)
+			}
+			r.callback.OnDataReceived(msg.Payload, params)
+		}
 		if p != nil {
-			p.Callback.OnDataReceived(msg.Payload, params)
+			p.Callback.OnDataPacket(dataPacket, params)
 		}
-		r.callback.OnDataReceived(msg.Payload, params)
+		r.callback.OnDataPacket(dataPacket, params)
 	}
-	if p != nil {
-		p.Callback.OnDataPacket(dataPacket, params)
+	switch {
+	case p != nil:
+		p.events.enqueue(func() { deliver(p) })
+	case identity != "":
+		// data can arrive before the participant update of its sender
+		r.holdEvent(identity, deliver)
+	default:
+		deliver(nil)
 	}
-	r.callback.OnDataPacket(dataPacket, params)
 }
 
 func (r *Room) OnParticipantUpdate(participants []*livekit.ParticipantInfo) {
@@ -883,7 +1521,9 @@ func (r *Room) OnParticipantUpdate(participants []*livekit.ParticipantInfo) {
 			rp = r.addRemoteParticipant(pi, true)
 			r.clearParticipantDefers(livekit.ParticipantID(pi.Sid), pi)
 			r.runParticipantDefers(livekit.ParticipantID(pi.Sid), rp)
-			go r.callback.OnParticipantConnected(rp)
+			// connected is delivered ahead of the track events queued while adding the participant
+			rp.events.release(r.participantConnectedEvent(rp))
+			r.releaseEvents(rp.Identity(), rp)
 		} else {
 			oldSid := livekit.ParticipantID(rp.SID())
 			rp.updateInfo(pi)
@@ -907,6 +1547,15 @@ func (r *Room) OnParticipantUpdate(participants []*livekit.ParticipantInfo) {
 	}
 }
 
+func (r *Room) participantConnectedEvent(rp *RemoteParticipant) func() {
+	return func() {
+		r.callback.OnParticipantConnected(rp)
+		if rp.IsAgent() {
+			r.callback.OnAgentConnected(rp)
+		}
+	}
+}
+
 func (r *Room) OnParticipantDisconnect(rp *RemoteParticipant, reason livekit.DisconnectReason) {
 	if rp == nil {
 		return
@@ -919,10 +1568,20 @@ func (r *Room) OnParticipantDisconnect(rp *RemoteParticipant, reason livekit.Dis
 	r.lock.Unlock()
 
 	rp.unpublishAllTracks()
+	r.presence.remove(rp.Identity())
+	r.engine.inboundLimiter.remove(rp.Identity())
 	r.LocalParticipant.handleParticipantDisconnected(rp.Identity())
+	r.virtualStreams.closeParticipant(rp.Identity())
 
 	rp.info.DisconnectReason = reason
-	go r.callback.OnParticipantDisconnected(rp)
+	// a participant that was never announced is released here, so that its disconnect is still delivered
+	rp.events.release(nil)
+	rp.events.enqueue(func() {
+		r.callback.OnParticipantDisconnected(rp)
+		if rp.IsAgent() {
+			r.callback.OnAgentDisconnected(rp)
+		}
+	})
 }
 
 func (r *Room) OnSpeakersChanged(speakerUpdates []*livekit.SpeakerInfo) {
@@ -979,23 +1638,23 @@ func (r *Room) OnConnectionQuality(updates []*livekit.ConnectionQualityInfo) {
 
 func (r *Room) OnRoomUpdate(room *livekit.Room) {
 	metadataChanged := false
-	recordingChanged := false
 	r.lock.Lock()
+	wasRecording := r.isRecordingLocked()
 	if r.metadata != room.Metadata {
 		metadataChanged = true
 		r.metadata = room.Metadata
+		r.updateRecordingIndicatorFromMetadataLocked(room.Metadata)
 	}
-	if r.activeRecording != room.ActiveRecording {
-		recordingChanged = true
-		r.activeRecording = room.ActiveRecording
-	}
+	r.activeRecording = room.ActiveRecording
+	isRecording := r.isRecordingLocked()
 	r.lock.Unlock()
 	r.setSid(room.Sid, false)
+	r.updateParticipantCounts(room)
 	if metadataChanged {
 		go r.callback.OnRoomMetadataChanged(room.Metadata)
 	}
-	if recordingChanged {
-		go r.callback.OnRecordingStatusChanged(room.ActiveRecording)
+	if wasRecording != isRecording {
+		r.notifyRecordingChanged(isRecording)
 	}
 }
 
@@ -1053,6 +1712,12 @@ func (r *Room) OnTranscription(transcription *livekit.Transcription) {
 	transcriptionSegments := ExtractTranscriptionSegments(transcription)
 
 	r.callback.OnTranscriptionReceived(transcriptionSegments, p, publication)
+
+	if r.engine.connParams.BridgeTranscriptions {
+		if handler, ok := r.textStreamHandlers.Load(TopicTranscription); ok {
+			bridgeTranscriptionToStreams(transcription, handler)
+		}
+	}
 }
 
 func (r *Room) OnLocalTrackSubscribed(trackSubscribed *livekit.TrackSubscribed) {
@@ -1072,6 +1737,49 @@ func (r *Room) OnSubscribedAudioCodecUpdate(subscribedAudioCodecUpdate *livekit.
 	r.LocalParticipant.handleSubscribedAudioCodecUpdate(subscribedAudioCodecUpdate)
 }
 
+func (r *Room) OnActiveCandidatePairChanged(target livekit.SignalTarget, pair *ICECandidatePairInfo) {
+	r.log.Infow(
+		"selected candidate pair changed",
+		"transport", target,
+		"localType", pair.Local.Type,
+		"localProtocol", pair.Local.Protocol,
+		"relayProtocol", pair.Local.RelayProtocol,
+		"remoteType", pair.Remote.Type,
+		"remoteProtocol", pair.Remote.Protocol,
+	)
+	if target == livekit.SignalTarget_PUBLISHER && r.engine.interfaceMTULimit() {
+		r.LocalParticipant.updateInterfacePayloadLimit(pair.Local)
+	}
+	r.callback.OnActiveCandidatePairChanged(target, pair.Local, pair.Remote)
+}
+
+func (r *Room) OnBandwidthEstimates(estimates BandwidthEstimates) {
+	// the estimates worker is tracked, the callback must not hold it back
+	r.bandwidthEstimates.post(estimates)
+}
+
+func (r *Room) OnStatsReport(report StatsReport) {
+	r.statsReports.post(report)
+}
+
+func (r *Room) OnNegotiationStalled(target livekit.SignalTarget, recovery NegotiationRecovery, err error) {
+	r.callback.OnNegotiationStalled(target, recovery, err)
+}
+
+func (r *Room) OnRateLimited(identity string, kind RateLimitKind) {
+	r.callback.OnRateLimited(identity, kind)
+}
+
+func (r *Room) OnSubscriptionResponse(response *livekit.SubscriptionResponse) {
+	for _, rp := range r.GetRemoteParticipants() {
+		if pub := rp.getPublication(response.TrackSid); pub != nil {
+			rp.handleSubscriptionError(pub, &SubscriptionError{Code: response.Err})
+			return
+		}
+	}
+	r.log.Debugw("could not find track for subscription response", "trackID", response.TrackSid)
+}
+
 func (r *Room) OnMediaSectionsRequirement(mediaSectionsRequirement *livekit.MediaSectionsRequirement) {
 	addTransceivers := func(transport *PCTransport, kind webrtc.RTPCodecType, count uint32) {
 		for i := uint32(0); i < count; i++ {
@@ -1114,10 +1822,25 @@ func (r *Room) OnMediaSectionsRequirement(mediaSectionsRequirement *livekit.Medi
 }
 
 func (r *Room) OnStreamHeader(streamHeader *livekit.DataStream_Header, participantIdentity string) {
+	if !r.allowTopic(streamHeader.Topic, participantIdentity, r.GetParticipantByIdentity(participantIdentity)) {
+		r.rejectDataStream(&StreamRejectedError{
+			StreamID: streamHeader.StreamId,
+			Topic:    streamHeader.Topic,
+			MimeType: streamHeader.MimeType,
+			Reason:   StreamRejectTopicNotAllowed,
+		}, participantIdentity)
+		return
+	}
+	if err := checkStreamHeader(r.engine.connParams.StreamGuard, streamHeader, participantIdentity); err != nil {
+		r.rejectDataStream(err, participantIdentity)
+		return
+	}
+
 	switch header := streamHeader.ContentHeader.(type) {
 	case *livekit.DataStream_Header_TextHeader:
 		streamHandlerCallback, ok := r.textStreamHandlers.Load(streamHeader.Topic)
-		if !ok {
+		bridge := streamHeader.Topic == TopicTranscription && r.engine.connParams.BridgeTranscriptions
+		if !ok && !bridge {
 			r.log.Debugw("ignoring incoming text stream due to no handler for topic", "topic", streamHeader.Topic)
 			return
 		}
@@ -1131,9 +1854,24 @@ func (r *Room) OnStreamHeader(streamHeader *livekit.DataStream_Header, participa
 				Timestamp:  streamHeader.Timestamp,
 				Attributes: streamHeader.Attributes,
 			},
+			Generated: header.TextHeader.Generated,
+		}
+
+		if !ok {
+			reader := newIncrementalTextReader(info, participantIdentity)
+			r.textStreamReaders.Store(streamHeader.StreamId, reader)
+			go r.bridgeStreamToTranscription(reader, participantIdentity)
+			return
+		}
+		if handler, ok := streamHandlerCallback.(IncrementalTextStreamHandler); ok {
+			reader := newIncrementalTextReader(info, participantIdentity)
+			r.textStreamReaders.Store(streamHeader.StreamId, reader)
+			go handler(reader, participantIdentity)
+			return
 		}
 
 		textStreamReader := NewTextStreamReader(info, streamHeader.TotalLength)
+		textStreamReader.senderIdentity = participantIdentity
 		r.textStreamReaders.Store(streamHeader.StreamId, textStreamReader)
 		go streamHandlerCallback.(TextStreamHandler)(textStreamReader, participantIdentity)
 	case *livekit.DataStream_Header_ByteHeader:
@@ -1156,6 +1894,10 @@ func (r *Room) OnStreamHeader(streamHeader *livekit.DataStream_Header, participa
 		}
 
 		byteStreamReader := NewByteStreamReader(info, streamHeader.TotalLength)
+		byteStreamReader.senderIdentity = participantIdentity
+		if spool := r.engine.connParams.StreamSpool; spool.MemoryThreshold > 0 {
+			byteStreamReader.spool = newStreamSpool(r.streamSpooler, spool)
+		}
 		r.byteStreamReaders.Store(streamHeader.StreamId, byteStreamReader)
 		go streamHandlerCallback.(ByteStreamHandler)(byteStreamReader, participantIdentity)
 	}
@@ -1167,18 +1909,50 @@ func (r *Room) OnStreamChunk(streamChunk *livekit.DataStream_Chunk) {
 	byteStreamReader, ok := r.byteStreamReaders.Load(streamId)
 	if ok {
 		if len(streamChunk.Content) > 0 {
-			byteStreamReader.(*ByteStreamReader).enqueue(streamChunk)
+			reader := byteStreamReader.(*ByteStreamReader)
+			received := reader.enqueue(streamChunk)
+			if err := checkStreamSize(r.engine.connParams.StreamGuard, reader.Info.baseStreamInfo, received); err != nil {
+				reader.closeWithError(err)
+				r.byteStreamReaders.Delete(streamId)
+				r.rejectDataStream(err, reader.senderIdentity)
+			}
 		}
 	}
 
 	textStreamReader, ok := r.textStreamReaders.Load(streamId)
 	if ok {
-		if len(streamChunk.Content) > 0 {
-			textStreamReader.(*TextStreamReader).enqueue(streamChunk)
+		switch reader := textStreamReader.(type) {
+		case *TextStreamReader:
+			if len(streamChunk.Content) > 0 {
+				received := reader.enqueue(streamChunk)
+				if err := checkStreamSize(r.engine.connParams.StreamGuard, reader.Info.baseStreamInfo, received); err != nil {
+					reader.closeWithError(err)
+					r.textStreamReaders.Delete(streamId)
+					r.rejectDataStream(err, reader.senderIdentity)
+				}
+			}
+		case *IncrementalTextReader:
+			// empty chunks remove text replaced by the sender
+			received := reader.enqueue(streamChunk)
+			if err := checkStreamSize(r.engine.connParams.StreamGuard, reader.Info.baseStreamInfo, received); err != nil {
+				reader.closeWithError(err)
+				r.textStreamReaders.Delete(streamId)
+				r.rejectDataStream(err, reader.senderIdentity)
+			}
 		}
 	}
 }
 
+func (r *Room) rejectDataStream(err *StreamRejectedError, participantIdentity string) {
+	r.log.Infow("rejecting incoming data stream",
+		"streamID", err.StreamID,
+		"topic", err.Topic,
+		"reason", err.Reason,
+		"participant", participantIdentity,
+	)
+	r.callback.OnDataStreamRejected(err, participantIdentity)
+}
+
 func (r *Room) OnStreamTrailer(streamTrailer *livekit.DataStream_Trailer) {
 	streamId := streamTrailer.StreamId
 
@@ -1194,11 +1968,16 @@ func (r *Room) OnStreamTrailer(streamTrailer *livekit.DataStream_Trailer) {
 
 	textStreamReader, ok := r.textStreamReaders.Load(streamId)
 	if ok {
-		reader := textStreamReader.(*TextStreamReader)
-		for k, v := range streamTrailer.Attributes {
-			reader.Info.Attributes[k] = v
+		switch reader := textStreamReader.(type) {
+		case *TextStreamReader:
+			for k, v := range streamTrailer.Attributes {
+				reader.Info.Attributes[k] = v
+			}
+			reader.close()
+		case *IncrementalTextReader:
+			// Info is read by the handler, trailer attributes are not merged to avoid a race
+			reader.close()
 		}
-		reader.close()
 		r.textStreamReaders.Delete(streamId)
 	}
 }
@@ -1211,19 +1990,17 @@ func (r *Room) OnRpcRequest(callerIdentity, requestId, method, payload string, r
 		return
 	}
 
-	handler, ok := r.rpcHandlers.Load(method)
-	if !ok {
-		r.engine.publishRpcResponse(callerIdentity, requestId, nil, rpcErrorFromBuiltInCodes(RpcUnsupportedMethod, nil))
-		return
-	}
-
-	response, err := handler.(RpcHandlerFunc)(RpcInvocationData{
+	r.LocalParticipant.rpc.invoke(r.engine, method, RpcInvocationData{
 		RequestID:       requestId,
 		CallerIdentity:  callerIdentity,
 		Payload:         payload,
 		ResponseTimeout: responseTimeout,
+	}, func(response string, err error) {
+		r.respondRpc(callerIdentity, requestId, method, response, err)
 	})
+}
 
+func (r *Room) respondRpc(callerIdentity, requestId, method, response string, err error) {
 	if err != nil {
 		if _, ok := err.(*RpcError); ok {
 			r.engine.publishRpcResponse(callerIdentity, requestId, nil, err.(*RpcError))
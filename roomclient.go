@@ -51,7 +51,9 @@ func (c *RoomServiceClient) CreateRoom(ctx context.Context, req *livekit.CreateR
 		return nil, err
 	}
 
-	return c.roomService.CreateRoom(ctx, req)
+	return idempotent(ctx, c.authBase, "CreateRoom", req, func(ctx context.Context) (*livekit.Room, error) {
+		return c.roomService.CreateRoom(ctx, req)
+	})
 }
 
 func (c *RoomServiceClient) ListRooms(ctx context.Context, req *livekit.ListRoomsRequest) (*livekit.ListRoomsResponse, error) {
@@ -0,0 +1,214 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	defaultWatchRoomInterval = 5 * time.Second
+)
+
+// RoomStateDiff describes the changes of a watched room since the previous diff.
+// The first diff is a snapshot with all participants in Joined.
+type RoomStateDiff struct {
+	Snapshot bool
+	// Room is set when the room was created or its info changed, nil otherwise
+	Room *livekit.Room
+	// Closed is true when the room no longer exists, remaining participants are in Left
+	Closed  bool
+	Joined  []*livekit.ParticipantInfo
+	Updated []*livekit.ParticipantInfo
+	Left    []*livekit.ParticipantInfo
+}
+
+func (d RoomStateDiff) empty() bool {
+	return !d.Snapshot && d.Room == nil && !d.Closed && len(d.Joined) == 0 && len(d.Updated) == 0 && len(d.Left) == 0
+}
+
+type WatchRoomOption func(*roomWatcher)
+
+// WithWatchInterval sets how often the room is polled, 5s by default.
+func WithWatchInterval(interval time.Duration) WatchRoomOption {
+	return func(w *roomWatcher) {
+		w.interval = interval
+	}
+}
+
+// WithWatchWebhookEvents refreshes the room state as soon as a webhook event for the room is received,
+// e.g. from webhook.ReceiveWebhookEvent. Polling continues to catch missed events.
+func WithWatchWebhookEvents(events <-chan *livekit.WebhookEvent) WatchRoomOption {
+	return func(w *roomWatcher) {
+		w.events = events
+	}
+}
+
+// WithWatchErrorHandler is called when the room state could not be fetched.
+func WithWatchErrorHandler(onError func(err error)) WatchRoomOption {
+	return func(w *roomWatcher) {
+		w.onError = onError
+	}
+}
+
+type roomWatcher struct {
+	client   *RoomServiceClient
+	roomName string
+	interval time.Duration
+	events   <-chan *livekit.WebhookEvent
+	onError  func(err error)
+
+	synced       bool
+	room         *livekit.Room
+	participants map[string]*livekit.ParticipantInfo // identity -> info
+}
+
+// WatchRoom returns a channel of room state diffs, starting with a snapshot of the participants.
+// It gives backends a view of a room without joining it as a participant. The channel is closed when ctx is done.
+func (c *RoomServiceClient) WatchRoom(ctx context.Context, roomName string, opts ...WatchRoomOption) <-chan RoomStateDiff {
+	w := &roomWatcher{
+		client:       c,
+		roomName:     roomName,
+		interval:     defaultWatchRoomInterval,
+		participants: make(map[string]*livekit.ParticipantInfo),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	diffs := make(chan RoomStateDiff, 16)
+	go w.run(ctx, diffs)
+	return diffs
+}
+
+func (w *roomWatcher) run(ctx context.Context, diffs chan<- RoomStateDiff) {
+	defer close(diffs)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		diff, err := w.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if w.onError != nil {
+				w.onError(err)
+			} else {
+				logger.Warnw("could not fetch room state", err, "room", w.roomName)
+			}
+		} else if !diff.empty() {
+			select {
+			case diffs <- diff:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if !w.wait(ctx, ticker) {
+			return
+		}
+	}
+}
+
+// wait returns when the room should be polled again, false when ctx is done
+func (w *roomWatcher) wait(ctx context.Context, ticker *time.Ticker) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		case event, ok := <-w.events:
+			if !ok {
+				w.events = nil
+			} else if event.GetRoom().GetName() == w.roomName {
+				return true
+			}
+		}
+	}
+}
+
+func (w *roomWatcher) poll(ctx context.Context) (RoomStateDiff, error) {
+	rooms, err := w.client.ListRooms(ctx, &livekit.ListRoomsRequest{Names: []string{w.roomName}})
+	if err != nil {
+		return RoomStateDiff{}, err
+	}
+	var room *livekit.Room
+	var participants []*livekit.ParticipantInfo
+	if len(rooms.Rooms) > 0 {
+		room = rooms.Rooms[0]
+		res, err := w.client.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: w.roomName})
+		var twErr twirp.Error
+		if errors.As(err, &twErr) && twErr.Code() == twirp.NotFound {
+			room = nil
+		} else if err != nil {
+			return RoomStateDiff{}, err
+		} else {
+			participants = res.Participants
+		}
+	}
+	return w.update(room, participants), nil
+}
+
+// update applies the current state and returns the changes
+func (w *roomWatcher) update(room *livekit.Room, participants []*livekit.ParticipantInfo) RoomStateDiff {
+	diff := RoomStateDiff{Snapshot: !w.synced}
+	w.synced = true
+
+	switch {
+	case room == nil && w.room != nil:
+		diff.Closed = true
+	case room != nil && (w.room == nil || !roomInfoEqual(room, w.room)):
+		diff.Room = room
+	}
+	w.room = room
+
+	current := make(map[string]*livekit.ParticipantInfo, len(participants))
+	for _, pi := range participants {
+		current[pi.Identity] = pi
+		prev, ok := w.participants[pi.Identity]
+		switch {
+		case !ok:
+			diff.Joined = append(diff.Joined, pi)
+		case !proto.Equal(prev, pi):
+			diff.Updated = append(diff.Updated, pi)
+		}
+	}
+	for identity, pi := range w.participants {
+		if _, ok := current[identity]; !ok {
+			diff.Left = append(diff.Left, pi)
+		}
+	}
+	w.participants = current
+	return diff
+}
+
+// roomInfoEqual ignores counters that change with every participant update
+func roomInfoEqual(a, b *livekit.Room) bool {
+	a = proto.Clone(a).(*livekit.Room)
+	b = proto.Clone(b).(*livekit.Room)
+	a.NumParticipants, b.NumParticipants = 0, 0
+	a.NumPublishers, b.NumPublishers = 0, 0
+	return proto.Equal(a, b)
+}
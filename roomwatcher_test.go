@@ -0,0 +1,49 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomWatcherUpdate(t *testing.T) {
+	w := &roomWatcher{participants: make(map[string]*livekit.ParticipantInfo)}
+	room := &livekit.Room{Name: "room", NumParticipants: 1}
+
+	diff := w.update(room, []*livekit.ParticipantInfo{{Identity: "a"}})
+	require.True(t, diff.Snapshot)
+	require.Equal(t, room, diff.Room)
+	require.Len(t, diff.Joined, 1)
+
+	// counters are not reported as room changes
+	diff = w.update(&livekit.Room{Name: "room", NumParticipants: 2}, []*livekit.ParticipantInfo{{Identity: "a", Metadata: "m"}, {Identity: "b"}})
+	require.False(t, diff.Snapshot)
+	require.Nil(t, diff.Room)
+	require.Equal(t, "b", diff.Joined[0].Identity)
+	require.Equal(t, "a", diff.Updated[0].Identity)
+
+	diff = w.update(room, []*livekit.ParticipantInfo{{Identity: "b"}})
+	require.Equal(t, "a", diff.Left[0].Identity)
+	require.Empty(t, diff.Joined)
+	require.Empty(t, diff.Updated)
+
+	diff = w.update(nil, nil)
+	require.True(t, diff.Closed)
+	require.Equal(t, "b", diff.Left[0].Identity)
+	require.True(t, w.update(nil, nil).empty())
+}
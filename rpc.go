@@ -24,6 +24,7 @@ const (
 	RpcRequestPayloadTooLarge
 	RpcUnsupportedServer
 	RpcUnsupportedVersion
+	RpcRateLimited
 )
 
 const (
@@ -35,6 +36,9 @@ const (
 	MaxPayloadBytes = 15360 // 15KiB
 )
 
+// response timeout of calls that don't set one
+const defaultRpcResponseTimeout = 15 * time.Second
+
 var rpcErrorMessages = map[RpcErrorCode]string{
 	RpcApplicationError:        "Application error in method handler",
 	RpcConnectionTimeout:       "Connection timeout",
@@ -48,6 +52,7 @@ var rpcErrorMessages = map[RpcErrorCode]string{
 	RpcRequestPayloadTooLarge: "Request payload too large",
 	RpcUnsupportedServer:      "RPC not supported by server",
 	RpcUnsupportedVersion:     "Unsupported RPC version",
+	RpcRateLimited:            "Rate limit exceeded",
 }
 
 // Parameters for initiating an RPC call
@@ -0,0 +1,100 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pendingRpcRequestID(p *LocalParticipant) string {
+	var id string
+	p.rpcPendingResponses.Range(func(key, value any) bool {
+		id = key.(string)
+		return false
+	})
+	return id
+}
+
+func requireNoPendingRpc(t *testing.T, p *LocalParticipant) {
+	require.Empty(t, pendingRpcRequestID(p))
+	p.rpcPendingAcks.Range(func(key, value any) bool {
+		t.Fatalf("pending ack for %v", key)
+		return false
+	})
+}
+
+func TestPerformRpcContext(t *testing.T) {
+	newParticipant := func(joinTimeout time.Duration) *LocalParticipant {
+		return newLocalParticipant(&RTCEngine{log: logger, joinTimeout: joinTimeout}, NewRoomCallback(), nil, logger)
+	}
+	params := PerformRpcParams{DestinationIdentity: "remote", Method: "echo", Payload: "hello"}
+
+	t.Run("send failure", func(t *testing.T) {
+		p := newParticipant(0)
+		_, err := p.PerformRpcContext(context.Background(), params)
+		requireRpcError(t, err, RpcSendFailed)
+		requireNoPendingRpc(t, p)
+	})
+
+	t.Run("response", func(t *testing.T) {
+		p := newParticipant(time.Second)
+		go func() {
+			require.Eventually(t, func() bool { return pendingRpcRequestID(p) != "" }, time.Second, time.Millisecond)
+			id := pendingRpcRequestID(p)
+			p.HandleIncomingRpcAck(id)
+			payload := "world"
+			p.HandleIncomingRpcResponse(id, &payload, nil)
+		}()
+		res, err := p.PerformRpcContext(context.Background(), params)
+		require.NoError(t, err)
+		require.Equal(t, "world", *res)
+		requireNoPendingRpc(t, p)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		p := newParticipant(time.Second)
+		go func() {
+			require.Eventually(t, func() bool { return pendingRpcRequestID(p) != "" }, time.Second, time.Millisecond)
+			p.HandleIncomingRpcResponse(pendingRpcRequestID(p), nil, NewRpcError(RpcApplicationError, "failed", nil))
+		}()
+		_, err := p.PerformRpcContext(context.Background(), params)
+		requireRpcError(t, err, RpcApplicationError)
+		requireNoPendingRpc(t, p)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		p := newParticipant(time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			require.Eventually(t, func() bool { return pendingRpcRequestID(p) != "" }, time.Second, time.Millisecond)
+			cancel()
+		}()
+		_, err := p.PerformRpcContext(ctx, params)
+		require.ErrorIs(t, err, context.Canceled)
+		requireNoPendingRpc(t, p)
+	})
+
+	t.Run("payload too large", func(t *testing.T) {
+		p := newParticipant(0)
+		large := params
+		large.Payload = string(make([]byte, MaxPayloadBytes+1))
+		_, err := p.PerformRpcContext(context.Background(), large)
+		requireRpcError(t, err, RpcRequestPayloadTooLarge)
+	})
+}
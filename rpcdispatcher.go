@@ -0,0 +1,175 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+const defaultRpcMaxInFlight = 4
+
+// RpcDispatcherConfig configures an RpcDispatcher, zero values use the defaults
+type RpcDispatcherConfig struct {
+	// calls in flight per destination participant, 4 by default
+	MaxInFlight int
+	// calls waiting per destination participant, unlimited by default. Calls beyond fail with ErrRpcQueueFull.
+	MaxQueued int
+}
+
+// RpcQueueStats is the state of the calls to a destination participant
+type RpcQueueStats struct {
+	InFlight int
+	Queued   int
+}
+
+// RpcDispatcher limits the RPC calls in flight per destination participant, e.g. when an agent fans out many
+// calls at once, so that they don't congest the reliable data channel. Calls beyond the limit wait in order.
+// Stats and Queued can be used for backpressure.
+type RpcDispatcher struct {
+	config  RpcDispatcherConfig
+	perform func(ctx context.Context, params PerformRpcParams) (*string, error)
+
+	lock         sync.Mutex
+	destinations map[string]*rpcDestination
+}
+
+type rpcDestination struct {
+	inFlight int
+	waiting  []*rpcWaiter
+}
+
+type rpcWaiter struct {
+	ready chan struct{}
+	// set with the lock held when a slot was handed over
+	granted bool
+}
+
+// NewRpcDispatcher returns a dispatcher performing calls with p
+func NewRpcDispatcher(p *LocalParticipant, config RpcDispatcherConfig) *RpcDispatcher {
+	return newRpcDispatcher(config, p.PerformRpcContext)
+}
+
+func newRpcDispatcher(config RpcDispatcherConfig, perform func(ctx context.Context, params PerformRpcParams) (*string, error)) *RpcDispatcher {
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = defaultRpcMaxInFlight
+	}
+	return &RpcDispatcher{
+		config:       config,
+		perform:      perform,
+		destinations: make(map[string]*rpcDestination),
+	}
+}
+
+// PerformRpc is LocalParticipant.PerformRpcContext once fewer than MaxInFlight calls to the destination are in flight.
+// Returns ctx.Err() when ctx is done while waiting, and ErrRpcQueueFull when too many calls are waiting.
+func (d *RpcDispatcher) PerformRpc(ctx context.Context, params PerformRpcParams) (*string, error) {
+	if err := d.acquire(ctx, params.DestinationIdentity); err != nil {
+		return nil, err
+	}
+	defer d.release(params.DestinationIdentity)
+
+	return d.perform(ctx, params)
+}
+
+// Stats returns the calls in flight and waiting for a destination participant
+func (d *RpcDispatcher) Stats(destinationIdentity string) RpcQueueStats {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	dest := d.destinations[destinationIdentity]
+	if dest == nil {
+		return RpcQueueStats{}
+	}
+	return RpcQueueStats{InFlight: dest.inFlight, Queued: len(dest.waiting)}
+}
+
+// Queued returns the calls waiting for all destinations
+func (d *RpcDispatcher) Queued() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var queued int
+	for _, dest := range d.destinations {
+		queued += len(dest.waiting)
+	}
+	return queued
+}
+
+func (d *RpcDispatcher) acquire(ctx context.Context, identity string) error {
+	d.lock.Lock()
+	dest := d.destinations[identity]
+	if dest == nil {
+		dest = &rpcDestination{}
+		d.destinations[identity] = dest
+	}
+	if dest.inFlight < d.config.MaxInFlight && len(dest.waiting) == 0 {
+		dest.inFlight++
+		d.lock.Unlock()
+		return nil
+	}
+	if d.config.MaxQueued > 0 && len(dest.waiting) >= d.config.MaxQueued {
+		d.removeIdleLocked(identity, dest)
+		d.lock.Unlock()
+		return ErrRpcQueueFull
+	}
+	w := &rpcWaiter{ready: make(chan struct{})}
+	dest.waiting = append(dest.waiting, w)
+	d.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.lock.Lock()
+	granted := w.granted
+	if !granted {
+		dest.waiting = slices.DeleteFunc(dest.waiting, func(o *rpcWaiter) bool { return o == w })
+		d.removeIdleLocked(identity, dest)
+	}
+	d.lock.Unlock()
+
+	if granted {
+		// handed a slot while giving up, pass it on
+		d.release(identity)
+	}
+	return ctx.Err()
+}
+
+func (d *RpcDispatcher) release(identity string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	dest := d.destinations[identity]
+	if len(dest.waiting) != 0 {
+		// the slot is handed over, calls in flight stay the same
+		w := dest.waiting[0]
+		dest.waiting = dest.waiting[1:]
+		w.granted = true
+		close(w.ready)
+		return
+	}
+	dest.inFlight--
+	d.removeIdleLocked(identity, dest)
+}
+
+func (d *RpcDispatcher) removeIdleLocked(identity string, dest *rpcDestination) {
+	if dest.inFlight == 0 && len(dest.waiting) == 0 {
+		delete(d.destinations, identity)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRpcDispatcher(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 10)
+	d := newRpcDispatcher(RpcDispatcherConfig{MaxInFlight: 2, MaxQueued: 2}, func(ctx context.Context, params PerformRpcParams) (*string, error) {
+		started <- params.Payload
+		<-release
+		return &params.Payload, nil
+	})
+
+	results := make(chan error, 10)
+	call := func(ctx context.Context, destination, payload string) {
+		go func() {
+			_, err := d.PerformRpc(ctx, PerformRpcParams{DestinationIdentity: destination, Payload: payload})
+			results <- err
+		}()
+	}
+	requireStats := func(destination string, stats RpcQueueStats) {
+		require.Eventually(t, func() bool { return d.Stats(destination) == stats }, time.Second, time.Millisecond)
+	}
+
+	call(context.Background(), "a", "1")
+	call(context.Background(), "a", "2")
+	requireStats("a", RpcQueueStats{InFlight: 2})
+	call(context.Background(), "a", "3")
+	requireStats("a", RpcQueueStats{InFlight: 2, Queued: 1})
+
+	// other destinations are not limited by a
+	call(context.Background(), "b", "b")
+	requireStats("b", RpcQueueStats{InFlight: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	call(ctx, "a", "cancelled")
+	requireStats("a", RpcQueueStats{InFlight: 2, Queued: 2})
+	call(context.Background(), "a", "full")
+	require.ErrorIs(t, <-results, ErrRpcQueueFull)
+	require.Equal(t, 2, d.Queued())
+
+	cancel()
+	require.ErrorIs(t, <-results, context.Canceled)
+	requireStats("a", RpcQueueStats{InFlight: 2, Queued: 1})
+
+	started1, started2, startedB := <-started, <-started, <-started
+	require.ElementsMatch(t, []string{"1", "2", "b"}, []string{started1, started2, startedB})
+
+	close(release)
+	for range 4 {
+		require.NoError(t, <-results)
+	}
+	require.Equal(t, "3", <-started)
+	require.Equal(t, RpcQueueStats{}, d.Stats("a"))
+	require.Empty(t, d.destinations)
+}
@@ -0,0 +1,251 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	protoLogger "github.com/livekit/protocol/logger"
+)
+
+// RpcMethodHandler handles an RPC invocation. ctx is done when the caller stops waiting for the response,
+// or after the timeout set with WithRpcMethodTimeout.
+type RpcMethodHandler func(ctx context.Context, data RpcInvocationData) (string, error)
+
+// RpcMiddleware wraps the handler of method, e.g. for logging, authorization or recovery
+type RpcMiddleware func(method string, next RpcMethodHandler) RpcMethodHandler
+
+type rpcMethodOptions struct {
+	timeout       time.Duration
+	maxConcurrent int
+	middleware    []RpcMiddleware
+}
+
+type RpcMethodOption func(*rpcMethodOptions)
+
+// WithRpcMethodTimeout responds with a RpcResponseTimeout error when the handler takes longer than timeout,
+// instead of letting the caller wait for its own response timeout
+func WithRpcMethodTimeout(timeout time.Duration) RpcMethodOption {
+	return func(o *rpcMethodOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithRpcMaxConcurrency rejects requests with RpcRateLimited while max requests of the method are handled
+func WithRpcMaxConcurrency(max int) RpcMethodOption {
+	return func(o *rpcMethodOptions) {
+		o.maxConcurrent = max
+	}
+}
+
+// WithRpcMethodMiddleware wraps the handler of this method, inside the middleware added with UseRpcMiddleware
+func WithRpcMethodMiddleware(middleware ...RpcMiddleware) RpcMethodOption {
+	return func(o *rpcMethodOptions) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+type rpcMethod struct {
+	handler  RpcMethodHandler
+	opts     rpcMethodOptions
+	inFlight atomic.Int32
+}
+
+// rpcServer holds the RPC methods of the local participant
+type rpcServer struct {
+	methods sync.Map // method -> *rpcMethod
+
+	lock       sync.RWMutex
+	middleware []RpcMiddleware
+}
+
+func (s *rpcServer) register(method string, handler RpcMethodHandler, opts []RpcMethodOption) error {
+	m := &rpcMethod{handler: handler}
+	for _, opt := range opts {
+		opt(&m.opts)
+	}
+	if _, loaded := s.methods.LoadOrStore(method, m); loaded {
+		return fmt.Errorf("rpc handler already registered for method: %s, unregisterRpcMethod before trying to register again", method)
+	}
+	return nil
+}
+
+func (s *rpcServer) use(middleware []RpcMiddleware) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.middleware = append(s.middleware, middleware...)
+}
+
+func (s *rpcServer) clear() {
+	s.methods.Clear()
+
+	s.lock.Lock()
+	s.middleware = nil
+	s.lock.Unlock()
+}
+
+// chain wraps the handler of m with its own and the participant middleware, the first one is the outermost
+func (s *rpcServer) chain(method string, m *rpcMethod) RpcMethodHandler {
+	s.lock.RLock()
+	middleware := append(append([]RpcMiddleware{}, s.middleware...), m.opts.middleware...)
+	s.lock.RUnlock()
+
+	handler := m.handler
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](method, handler)
+	}
+	return handler
+}
+
+type rpcResult struct {
+	payload string
+	err     error
+}
+
+// invoke runs the handler of method, the result is passed to respond exactly once
+func (s *rpcServer) invoke(e *RTCEngine, method string, data RpcInvocationData, respond func(payload string, err error)) {
+	value, ok := s.methods.Load(method)
+	if !ok {
+		respond("", rpcErrorFromBuiltInCodes(RpcUnsupportedMethod, nil))
+		return
+	}
+	m := value.(*rpcMethod)
+	if inFlight := m.inFlight.Add(1); m.opts.maxConcurrent > 0 && int(inFlight) > m.opts.maxConcurrent {
+		m.inFlight.Add(-1)
+		respond("", rpcErrorFromBuiltInCodes(RpcRateLimited, nil))
+		return
+	}
+
+	handler := s.chain(method, m)
+	// the wait is tracked and must end, when the request has no timeout the default of the caller applies
+	timeout := data.ResponseTimeout
+	if timeout <= 0 {
+		timeout = defaultRpcResponseTimeout
+	}
+	if m.opts.timeout > 0 && m.opts.timeout < timeout {
+		timeout = m.opts.timeout
+	}
+
+	e.goroutineRegistry().Go("rpc-handler", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		result := make(chan rpcResult, 1)
+		go func() {
+			defer m.inFlight.Add(-1)
+			payload, err := handler(ctx, data)
+			result <- rpcResult{payload: payload, err: err}
+		}()
+
+		select {
+		case res := <-result:
+			respond(res.payload, res.err)
+		case <-ctx.Done():
+			respond("", rpcErrorFromBuiltInCodes(RpcResponseTimeout, nil))
+		}
+	})
+}
+
+// RegisterRpcMethod registers a handler for an RPC method, see Room.RegisterRpcMethod.
+// Handlers run concurrently, each in its own goroutine, and the ack and response are published automatically.
+func (p *LocalParticipant) RegisterRpcMethod(method string, handler RpcMethodHandler, opts ...RpcMethodOption) error {
+	return p.rpc.register(method, handler, opts)
+}
+
+// UnregisterRpcMethod unregisters a previously registered RPC method.
+func (p *LocalParticipant) UnregisterRpcMethod(method string) {
+	p.rpc.methods.Delete(method)
+}
+
+// UseRpcMiddleware wraps the handlers of all RPC methods, including methods registered earlier.
+func (p *LocalParticipant) UseRpcMiddleware(middleware ...RpcMiddleware) {
+	p.rpc.use(middleware)
+}
+
+// RegisterTypedRpcMethod registers an RPC method with JSON encoded request and response payloads.
+// Payloads that cannot be decoded are rejected with an application error.
+func RegisterTypedRpcMethod[Req, Res any](
+	p *LocalParticipant,
+	method string,
+	handler func(ctx context.Context, data RpcInvocationData, req Req) (Res, error),
+	opts ...RpcMethodOption,
+) error {
+	return p.RegisterRpcMethod(method, func(ctx context.Context, data RpcInvocationData) (string, error) {
+		var req Req
+		if err := json.Unmarshal([]byte(data.Payload), &req); err != nil {
+			return "", NewRpcError(RpcApplicationError, "invalid request payload", nil)
+		}
+		res, err := handler(ctx, data, req)
+		if err != nil {
+			return "", err
+		}
+		payload, err := json.Marshal(res)
+		if err != nil {
+			return "", err
+		}
+		return string(payload), nil
+	}, opts...)
+}
+
+// RpcLoggingMiddleware logs every invocation with its duration, and failed ones with their error
+func RpcLoggingMiddleware(log protoLogger.Logger) RpcMiddleware {
+	return func(method string, next RpcMethodHandler) RpcMethodHandler {
+		return func(ctx context.Context, data RpcInvocationData) (string, error) {
+			start := time.Now()
+			payload, err := next(ctx, data)
+			if err != nil {
+				log.Infow("rpc request failed", "method", method, "caller", data.CallerIdentity,
+					"requestID", data.RequestID, "duration", time.Since(start), "error", err)
+			} else {
+				log.Debugw("rpc request handled", "method", method, "caller", data.CallerIdentity,
+					"requestID", data.RequestID, "duration", time.Since(start))
+			}
+			return payload, err
+		}
+	}
+}
+
+// RpcAuthMiddleware rejects invocations authorize returns an error for, an RpcError is passed to the caller as is
+func RpcAuthMiddleware(authorize func(method string, data RpcInvocationData) error) RpcMiddleware {
+	return func(method string, next RpcMethodHandler) RpcMethodHandler {
+		return func(ctx context.Context, data RpcInvocationData) (string, error) {
+			if err := authorize(method, data); err != nil {
+				return "", err
+			}
+			return next(ctx, data)
+		}
+	}
+}
+
+// RpcRecoveryMiddleware turns a panicking handler into an application error
+func RpcRecoveryMiddleware(log protoLogger.Logger) RpcMiddleware {
+	return func(method string, next RpcMethodHandler) RpcMethodHandler {
+		return func(ctx context.Context, data RpcInvocationData) (payload string, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorw("rpc handler panicked", nil, "method", method, "requestID", data.RequestID, "panic", r)
+					payload, err = "", rpcErrorFromBuiltInCodes(RpcApplicationError, nil)
+				}
+			}()
+			return next(ctx, data)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type rpcTestResult struct {
+	payload string
+	err     error
+}
+
+func invokeRpc(s *rpcServer, method, payload string) <-chan rpcTestResult {
+	res := make(chan rpcTestResult, 1)
+	s.invoke(nil, method, RpcInvocationData{RequestID: "req", CallerIdentity: "caller", Payload: payload, ResponseTimeout: time.Second},
+		func(payload string, err error) {
+			res <- rpcTestResult{payload: payload, err: err}
+		})
+	return res
+}
+
+func requireRpcError(t *testing.T, err error, code RpcErrorCode) {
+	var rpcErr *RpcError
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, code, rpcErr.Code)
+}
+
+func TestRpcServerInvoke(t *testing.T) {
+	var s rpcServer
+
+	res := <-invokeRpc(&s, "missing", "")
+	requireRpcError(t, res.err, RpcUnsupportedMethod)
+
+	var order []string
+	trace := func(name string) RpcMiddleware {
+		return func(method string, next RpcMethodHandler) RpcMethodHandler {
+			return func(ctx context.Context, data RpcInvocationData) (string, error) {
+				order = append(order, name)
+				return next(ctx, data)
+			}
+		}
+	}
+	require.NoError(t, s.register("echo", func(ctx context.Context, data RpcInvocationData) (string, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			return "", errors.New("no deadline")
+		}
+		return data.Payload, nil
+	}, []RpcMethodOption{WithRpcMethodMiddleware(trace("method"))}))
+	require.Error(t, s.register("echo", nil, nil))
+	// participant middleware applies to methods registered before
+	s.use([]RpcMiddleware{trace("participant")})
+
+	res = <-invokeRpc(&s, "echo", "hello")
+	require.NoError(t, res.err)
+	require.Equal(t, "hello", res.payload)
+	require.Equal(t, []string{"participant", "method"}, order)
+}
+
+func TestRpcServerLimits(t *testing.T) {
+	var s rpcServer
+	release := make(chan struct{})
+	require.NoError(t, s.register("slow", func(ctx context.Context, data RpcInvocationData) (string, error) {
+		<-release
+		return "done", nil
+	}, []RpcMethodOption{WithRpcMaxConcurrency(1), WithRpcMethodTimeout(50 * time.Millisecond)}))
+
+	first := invokeRpc(&s, "slow", "")
+	requireRpcError(t, (<-invokeRpc(&s, "slow", "")).err, RpcRateLimited)
+
+	// the caller gets a timeout error, the handler is still counted until it returns
+	requireRpcError(t, (<-first).err, RpcResponseTimeout)
+	requireRpcError(t, (<-invokeRpc(&s, "slow", "")).err, RpcRateLimited)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		value, _ := s.methods.Load("slow")
+		return value.(*rpcMethod).inFlight.Load() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRpcMiddleware(t *testing.T) {
+	var s rpcServer
+	s.use([]RpcMiddleware{
+		RpcRecoveryMiddleware(logger),
+		RpcAuthMiddleware(func(method string, data RpcInvocationData) error {
+			if data.CallerIdentity != "caller" {
+				return NewRpcError(RpcApplicationError, "forbidden", nil)
+			}
+			return nil
+		}),
+	})
+	require.NoError(t, s.register("panic", func(ctx context.Context, data RpcInvocationData) (string, error) {
+		panic(errors.New("boom"))
+	}, nil))
+
+	requireRpcError(t, (<-invokeRpc(&s, "panic", "")).err, RpcApplicationError)
+
+	res := make(chan error, 1)
+	s.invoke(nil, "panic", RpcInvocationData{CallerIdentity: "other"}, func(_ string, err error) { res <- err })
+	var rpcErr *RpcError
+	require.ErrorAs(t, <-res, &rpcErr)
+	require.Equal(t, "forbidden", rpcErr.Message)
+}
+
+func TestRegisterTypedRpcMethod(t *testing.T) {
+	type request struct {
+		Name string `json:"name"`
+	}
+	type response struct {
+		Greeting string `json:"greeting"`
+	}
+
+	p := newLocalParticipant(nil, NewRoomCallback(), nil, logger)
+	require.NoError(t, RegisterTypedRpcMethod(p, "greet", func(ctx context.Context, data RpcInvocationData, req request) (response, error) {
+		return response{Greeting: "Hello, " + req.Name}, nil
+	}))
+
+	res := <-invokeRpc(&p.rpc, "greet", `{"name":"Ada"}`)
+	require.NoError(t, res.err)
+	require.JSONEq(t, `{"greeting":"Hello, Ada"}`, res.payload)
+
+	requireRpcError(t, (<-invokeRpc(&p.rpc, "greet", "{")).err, RpcApplicationError)
+}
+
+func TestRpcDefaultResponseTimeout(t *testing.T) {
+	var s rpcServer
+	deadline := make(chan time.Time, 1)
+	require.NoError(t, s.register("deadline", func(ctx context.Context, data RpcInvocationData) (string, error) {
+		d, _ := ctx.Deadline()
+		deadline <- d
+		return "", nil
+	}, nil))
+
+	// a request without a timeout is not waited for forever
+	start := time.Now()
+	res := make(chan error, 1)
+	s.invoke(nil, "deadline", RpcInvocationData{CallerIdentity: "caller"}, func(_ string, err error) { res <- err })
+	require.NoError(t, <-res)
+	require.WithinDuration(t, start.Add(defaultRpcResponseTimeout), <-deadline, time.Second)
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// packets further apart are treated as a restart of the source
+const rtpPassthroughMaxSeqJump = 1000
+
+// LocalRTPTrack publishes RTP that is already packetized, e.g. by an external encoder or an RTSP demuxer,
+// without decoding and encoding it again. Sequence numbers and timestamps are rewritten, so that the published
+// stream stays continuous when the source restarts or changes its SSRC. Header extensions of the source are
+// dropped, the ones negotiated with the server are added by the track.
+type LocalRTPTrack struct {
+	*LocalTrack
+
+	rewriteLock sync.Mutex
+	rewriter    rtpRewriter
+
+	keyFrameLock      sync.Mutex
+	onKeyFrameRequest func()
+}
+
+// NewLocalRTPTrack creates a track publishing RTP packets of the given codec, pass it to
+// LocalParticipant.PublishTrack or see LocalRTPSimulcastTrack for simulcast sources.
+func NewLocalRTPTrack(c webrtc.RTPCodecCapability, opts ...LocalTrackOptions) (*LocalRTPTrack, error) {
+	track, err := NewLocalTrack(c, opts...)
+	if err != nil {
+		return nil, err
+	}
+	t := &LocalRTPTrack{
+		LocalTrack: track,
+		rewriter:   rtpRewriter{clockRate: c.ClockRate},
+	}
+	onRTCP := track.onRTCP
+	track.onRTCP = func(packet rtcp.Packet) {
+		switch packet.(type) {
+		case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+			t.keyFrameRequested()
+		}
+		if onRTCP != nil {
+			onRTCP(packet)
+		}
+	}
+	return t, nil
+}
+
+// OnKeyFrameRequest sets a handler called when subscribers request a key frame with a PLI or FIR.
+// Forward it to the source, e.g. as an RTCP PLI upstream or by asking the camera for an IDR frame.
+func (t *LocalRTPTrack) OnKeyFrameRequest(f func()) {
+	t.keyFrameLock.Lock()
+	t.onKeyFrameRequest = f
+	t.keyFrameLock.Unlock()
+}
+
+func (t *LocalRTPTrack) keyFrameRequested() {
+	t.keyFrameLock.Lock()
+	onKeyFrameRequest := t.onKeyFrameRequest
+	t.keyFrameLock.Unlock()
+	if onKeyFrameRequest != nil {
+		onKeyFrameRequest()
+	}
+}
+
+// WriteRTP publishes a packet of the source, the packet is not modified.
+func (t *LocalRTPTrack) WriteRTP(p *rtp.Packet, opts *SampleWriteOptions) error {
+	packet := &rtp.Packet{
+		Header:      p.Header.Clone(),
+		Payload:     p.Payload,
+		PaddingSize: p.PaddingSize,
+	}
+	packet.Extension = false
+	packet.ExtensionProfile = 0
+	packet.Extensions = nil
+
+	t.rewriteLock.Lock()
+	t.rewriter.rewrite(&packet.Header, time.Now())
+	t.rewriteLock.Unlock()
+
+	return t.LocalTrack.WriteRTP(packet, opts)
+}
+
+// rtpRewriter maps sequence numbers and timestamps of the source to a continuous output
+type rtpRewriter struct {
+	clockRate uint32
+
+	sourceSSRC uint32
+	lastInSeq  uint16
+	seqOffset  uint16
+	tsOffset   uint32
+	lastOutSeq uint16
+	lastOutTS  uint32
+	// zero before the first packet
+	lastWrite time.Time
+}
+
+func (r *rtpRewriter) rewrite(h *rtp.Header, now time.Time) {
+	if !r.lastWrite.IsZero() && (h.SSRC != r.sourceSSRC || !seqNear(h.SequenceNumber, r.lastInSeq)) {
+		// the source restarted, continue after the last packet written
+		elapsed := uint32(now.Sub(r.lastWrite).Seconds() * float64(r.clockRate))
+		r.seqOffset = r.lastOutSeq + 1 - h.SequenceNumber
+		r.tsOffset = r.lastOutTS + max(elapsed, 1) - h.Timestamp
+		r.lastWrite = time.Time{}
+	}
+	r.sourceSSRC = h.SSRC
+
+	seq := h.SequenceNumber + r.seqOffset
+	ts := h.Timestamp + r.tsOffset
+	// reordered packets are rewritten as well, but do not move the output forward
+	if diff := seq - r.lastOutSeq; r.lastWrite.IsZero() || (diff != 0 && diff < 0x8000) {
+		r.lastInSeq = h.SequenceNumber
+		r.lastOutSeq = seq
+		r.lastOutTS = ts
+		r.lastWrite = now
+	}
+	h.SequenceNumber = seq
+	h.Timestamp = ts
+}
+
+func seqNear(seq, last uint16) bool {
+	diff := seq - last
+	return diff < rtpPassthroughMaxSeqJump || diff > 0xffff-rtpPassthroughMaxSeqJump
+}
+
+// LocalRTPSimulcastTrack publishes the layers of a simulcast RTP source, mapping the RIDs of the source
+// to the layers of the publication.
+type LocalRTPSimulcastTrack struct {
+	layers map[string]*LocalRTPTrack
+	tracks []*LocalTrack
+}
+
+// NewLocalRTPSimulcastTrack creates a layer track for each RID of the source, pass Tracks to
+// LocalParticipant.PublishSimulcastTrack.
+func NewLocalRTPSimulcastTrack(
+	c webrtc.RTPCodecCapability,
+	simulcastID string,
+	layers map[string]*livekit.VideoLayer,
+	opts ...LocalTrackOptions,
+) (*LocalRTPSimulcastTrack, error) {
+	if len(layers) == 0 {
+		return nil, ErrInvalidSimulcastTrack
+	}
+	t := &LocalRTPSimulcastTrack{
+		layers: make(map[string]*LocalRTPTrack, len(layers)),
+	}
+	for rid, layer := range layers {
+		track, err := NewLocalRTPTrack(c, append([]LocalTrackOptions{WithSimulcast(simulcastID, layer)}, opts...)...)
+		if err != nil {
+			return nil, err
+		}
+		t.layers[rid] = track
+		t.tracks = append(t.tracks, track.LocalTrack)
+	}
+	return t, nil
+}
+
+// Layer returns the track of a RID of the source, nil when unknown
+func (t *LocalRTPSimulcastTrack) Layer(rid string) *LocalRTPTrack {
+	return t.layers[rid]
+}
+
+// Tracks returns the layer tracks to publish
+func (t *LocalRTPSimulcastTrack) Tracks() []*LocalTrack {
+	return t.tracks
+}
+
+// WriteRTP publishes a packet of the source layer with the given RID
+func (t *LocalRTPSimulcastTrack) WriteRTP(rid string, p *rtp.Packet, opts *SampleWriteOptions) error {
+	layer := t.layers[rid]
+	if layer == nil {
+		return ErrUnknownRID
+	}
+	return layer.WriteRTP(p, opts)
+}
+
+// OnKeyFrameRequest sets a handler called with the RID of the source layer subscribers need a key frame of
+func (t *LocalRTPSimulcastTrack) OnKeyFrameRequest(f func(rid string)) {
+	for rid, layer := range t.layers {
+		layer.OnKeyFrameRequest(func() { f(rid) })
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestRTPRewriter(t *testing.T) {
+	r := &rtpRewriter{clockRate: 90000}
+	now := time.Now()
+	write := func(ssrc uint32, seq uint16, ts uint32) (uint16, uint32) {
+		h := &rtp.Header{SSRC: ssrc, SequenceNumber: seq, Timestamp: ts}
+		r.rewrite(h, now)
+		return h.SequenceNumber, h.Timestamp
+	}
+
+	seq, ts := write(1, 100, 1000)
+	require.Equal(t, uint16(100), seq)
+	require.Equal(t, uint32(1000), ts)
+
+	// reordered packets keep their position
+	seq, _ = write(1, 102, 1000)
+	require.Equal(t, uint16(102), seq)
+	seq, _ = write(1, 101, 1000)
+	require.Equal(t, uint16(101), seq)
+
+	// a new source continues where the last one stopped
+	now = now.Add(time.Second)
+	seq, ts = write(2, 5000, 7)
+	require.Equal(t, uint16(103), seq)
+	require.Equal(t, uint32(1000+90000), ts)
+	seq, ts = write(2, 5001, 3007)
+	require.Equal(t, uint16(104), seq)
+	require.Equal(t, uint32(1000+90000+3000), ts)
+
+	// so does a restart with the same SSRC
+	now = now.Add(10 * time.Millisecond)
+	seq, ts = write(2, 40000, 0)
+	require.Equal(t, uint16(105), seq)
+	require.Equal(t, uint32(1000+90000+3000+900), ts)
+
+	// wrap around is not a restart
+	seq, _ = write(3, 65535, 0)
+	require.Equal(t, uint16(106), seq)
+	seq, _ = write(3, 0, 0)
+	require.Equal(t, uint16(107), seq)
+}
+
+func TestLocalRTPTrackKeyFrameRequest(t *testing.T) {
+	var forwarded []rtcp.Packet
+	track, err := NewLocalRTPTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000},
+		WithRTCPHandler(func(p rtcp.Packet) { forwarded = append(forwarded, p) }))
+	require.NoError(t, err)
+
+	requests := 0
+	track.OnKeyFrameRequest(func() { requests++ })
+	track.onRTCP(&rtcp.PictureLossIndication{})
+	track.onRTCP(&rtcp.FullIntraRequest{})
+	track.onRTCP(&rtcp.ReceiverReport{})
+	require.Equal(t, 2, requests)
+	require.Len(t, forwarded, 3)
+
+	lt, ok := asLocalTrack(track)
+	require.True(t, ok)
+	require.Same(t, track.LocalTrack, lt)
+}
+
+func TestLocalRTPSimulcastTrack(t *testing.T) {
+	track, err := NewLocalRTPSimulcastTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}, "camera",
+		map[string]*livekit.VideoLayer{
+			"0": {Quality: livekit.VideoQuality_LOW},
+			"2": {Quality: livekit.VideoQuality_HIGH},
+		})
+	require.NoError(t, err)
+	require.Len(t, track.Tracks(), 2)
+	require.Equal(t, "q", track.Layer("0").RID())
+	require.Equal(t, "f", track.Layer("2").RID())
+	require.Nil(t, track.Layer("1"))
+	require.ErrorIs(t, track.WriteRTP("1", &rtp.Packet{}, nil), ErrUnknownRID)
+
+	var rids []string
+	track.OnKeyFrameRequest(func(rid string) { rids = append(rids, rid) })
+	track.Layer("2").onRTCP(&rtcp.PictureLossIndication{})
+	require.Equal(t, []string{"2"}, rids)
+
+	_, err = NewLocalRTPSimulcastTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, "camera", nil)
+	require.ErrorIs(t, err, ErrInvalidSimulcastTrack)
+}
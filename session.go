@@ -0,0 +1,320 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	protoLogger "github.com/livekit/protocol/logger"
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+const defaultSessionMaxAge = 5 * time.Minute
+
+// sessionPersister saves the session state of a room to the SessionStore of the connect params
+type sessionPersister struct {
+	lock     sync.Mutex
+	store    SessionStore
+	closed   bool
+	restored *SessionState
+}
+
+// start loads the stored session when it was saved within the max age for the same server and participant,
+// returns nil when there is none
+func (s *sessionPersister) start(url, token string, params *signalling.ConnectParams, log protoLogger.Logger) *SessionState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.store = params.SessionStore
+	s.closed = false
+	s.restored = nil
+	if s.store == nil {
+		return nil
+	}
+
+	state, err := s.store.LoadSession()
+	if err != nil {
+		log.Warnw("could not load session", err)
+		return nil
+	}
+	if state == nil {
+		return nil
+	}
+	maxAge := params.SessionMaxAge
+	if maxAge <= 0 {
+		maxAge = defaultSessionMaxAge
+	}
+	room, identity, _ := tokenParticipant(token)
+	switch {
+	case time.Since(state.SavedAt) > maxAge:
+		log.Debugw("ignoring expired session", "savedAt", state.SavedAt)
+		return nil
+	case state.URL != url,
+		state.ParticipantIdentity != identity,
+		room != "" && state.RoomName != room:
+		log.Debugw("ignoring session of another participant", "room", state.RoomName, "participant", state.ParticipantIdentity)
+		return nil
+	}
+	s.restored = state
+	return state
+}
+
+// takeRestored returns the session loaded by start once
+func (s *sessionPersister) takeRestored() *SessionState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := s.restored
+	s.restored = nil
+	return state
+}
+
+func (s *sessionPersister) save(getState func() *SessionState, log protoLogger.Logger) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.store == nil || s.closed {
+		return
+	}
+	state := getState()
+	if state == nil {
+		return
+	}
+	if err := s.store.SaveSession(state); err != nil {
+		log.Warnw("could not save session", err)
+	}
+}
+
+// clear removes the stored session when the room was left, later saves are ignored
+func (s *sessionPersister) clear(log protoLogger.Logger) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.store == nil || s.closed {
+		return
+	}
+	s.closed = true
+	if err := s.store.ClearSession(); err != nil {
+		log.Warnw("could not clear session", err)
+	}
+}
+
+// restoreSession applies the subscription settings of a stored session before joining
+func (r *Room) restoreSession(url, token string, params *signalling.ConnectParams) {
+	state := r.session.start(url, token, params, r.log)
+	if state == nil {
+		return
+	}
+	r.log.Infow("continuing stored session",
+		"room", state.RoomName,
+		"previousParticipantID", state.ParticipantSID,
+		"savedAt", state.SavedAt,
+	)
+	r.subscriptionStore.restore(state.Subscriptions)
+}
+
+func (r *Room) saveSession() {
+	r.session.save(func() *SessionState {
+		if state := r.ConnectionState(); state != ConnectionStateConnected && !state.IsReconnecting() {
+			return nil
+		}
+		lp := r.LocalParticipant
+		if lp.SID() == "" {
+			return nil
+		}
+		return &SessionState{
+			URL:                 r.engine.url,
+			Token:               r.engine.token.Load(),
+			RoomName:            r.Name(),
+			ParticipantSID:      lp.SID(),
+			ParticipantIdentity: lp.Identity(),
+			Subscriptions:       r.subscriptionStore.snapshot(),
+			SavedAt:             time.Now(),
+		}
+	}, r.log)
+}
+
+func (r *Room) OnTokenRefreshed(_ string) {
+	r.saveSession()
+}
+
+// JoinWithSession continues the session stored by WithSessionStore, e.g. after the process restarted. The session
+// is resumed with new transports when the server still keeps the participant, otherwise the room is joined again
+// with the URL and latest token of the session. Fails with ErrNoSession when no session is stored or it expired,
+// options should match the ones used to start the session.
+//
+// A resumed session keeps the participant SID, remote participants are announced with the updates sent by the server.
+func (r *Room) JoinWithSession(ctx context.Context, store SessionStore, opts ...ConnectOption) error {
+	params := &signalling.ConnectParams{}
+	for _, opt := range opts {
+		opt(params)
+	}
+	maxAge := params.SessionMaxAge
+	if maxAge <= 0 {
+		maxAge = defaultSessionMaxAge
+	}
+
+	state, err := store.LoadSession()
+	if err != nil {
+		return err
+	}
+	if state == nil || time.Since(state.SavedAt) > maxAge {
+		return ErrNoSession
+	}
+	opts = append(opts, WithSessionStore(store, maxAge))
+	if err := r.resumeSession(ctx, state, opts...); err != nil {
+		r.log.Infow("could not resume session, joining again", "error", err, "previousParticipantID", state.ParticipantSID)
+		return r.joinWithToken(ctx, state.URL, state.Token, opts...)
+	}
+	return nil
+}
+
+// resumeSession reconnects as the participant of state, see RTCEngine.resumeSession
+func (r *Room) resumeSession(ctx context.Context, state *SessionState, opts ...ConnectOption) error {
+	if state.ParticipantSID == "" {
+		return ErrSessionNotResumed
+	}
+	params := &signalling.ConnectParams{
+		AutoSubscribe: true,
+	}
+	for _, opt := range opts {
+		opt(params)
+	}
+	r.clearDisconnectionError()
+	r.restoreSession(state.URL, state.Token, params)
+	r.setConnectionState(ConnectionStateConnecting)
+
+	participant := &livekit.ParticipantInfo{
+		Sid:      state.ParticipantSID,
+		Identity: state.ParticipantIdentity,
+	}
+	err := r.engine.resumeSession(ctx, state.URL, state.Token, state.ParticipantSID, params, func() {
+		// updates of the local participant are told apart from remote ones once the server sends them
+		r.LocalParticipant.updateInfo(participant)
+	})
+	if err != nil {
+		r.setConnectionState(ConnectionStateDisconnected)
+		return err
+	}
+	r.OnRoomJoined(&livekit.Room{Name: state.RoomName}, participant, nil, nil, nil)
+	return nil
+}
+
+// resumeSession continues the session of participantSID kept by the server with new transports, e.g. after the
+// process restarted. Fails when the server does not resume it, e.g. the participant left or the server is too old
+// to answer with a ReconnectResponse, or the new transports do not connect. onResumed runs once the server
+// accepted, before messages of the server are handled.
+func (e *RTCEngine) resumeSession(
+	ctx context.Context,
+	url, token, participantSID string,
+	params *signalling.ConnectParams,
+	onResumed func(),
+) error {
+	e.url = url
+	e.token.Store(token)
+	e.connParams = params
+	e.refreshICEServers(ctx)
+
+	e.sessionResumed.Store(false)
+	e.resumingSession.Store(true)
+	err := e.signalTransport.Reconnect(url, token, *params, participantSID)
+	e.resumingSession.Store(false)
+	if err == nil && !e.sessionResumed.Load() {
+		err = ErrSessionNotResumed
+	}
+	if err == nil {
+		onResumed()
+		e.signalTransport.Start()
+		if publisher, ok := e.Publisher(); ok {
+			err = publisher.createAndSendOffer(nil)
+		}
+	}
+	if err == nil {
+		e.startPingWorker(e.pingSettings())
+		err = e.waitUntilConnected()
+	}
+	if err != nil {
+		e.stopPingWorker()
+		e.signalTransport.Close()
+		e.closePeerConnections()
+		return err
+	}
+
+	e.hasConnected.Store(true)
+	e.startBandwidthEstimatesWorker()
+	e.startStatsReportWorker()
+	return nil
+}
+
+// FileSessionStore is a SessionStore keeping the session in a JSON file
+type FileSessionStore struct {
+	path string
+}
+
+func NewFileSessionStore(path string) *FileSessionStore {
+	return &FileSessionStore{path: path}
+}
+
+func (s *FileSessionStore) SaveSession(state *SessionState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	// created with mode 0600, the token grants access to the room
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	// replaced atomically, a crash while saving keeps the previous session
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileSessionStore) LoadSession() (*SessionState, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &SessionState{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *FileSessionStore) ClearSession() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	protoLogger "github.com/livekit/protocol/logger"
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestFileSessionStore(t *testing.T) {
+	store := NewFileSessionStore(filepath.Join(t.TempDir(), "session.json"))
+	state, err := store.LoadSession()
+	require.NoError(t, err)
+	require.Nil(t, state)
+
+	subscribed, quality := false, livekit.VideoQuality_LOW
+	saved := &SessionState{
+		URL:                 "wss://example.livekit.cloud",
+		Token:               "token",
+		RoomName:            "room",
+		ParticipantSID:      "PA_agent",
+		ParticipantIdentity: "agent",
+		Subscriptions: []TrackSubscriptionState{
+			{TrackSID: "TR_audio", Subscribed: &subscribed},
+			{TrackSID: "TR_video", VideoQuality: &quality},
+		},
+		SavedAt: time.Now(),
+	}
+	require.NoError(t, store.SaveSession(saved))
+	state, err = store.LoadSession()
+	require.NoError(t, err)
+	require.True(t, saved.SavedAt.Equal(state.SavedAt))
+	state.SavedAt = saved.SavedAt
+	require.Equal(t, saved, state)
+
+	require.NoError(t, store.ClearSession())
+	require.NoError(t, store.ClearSession())
+	state, err = store.LoadSession()
+	require.NoError(t, err)
+	require.Nil(t, state)
+}
+
+func TestSessionPersisterStart(t *testing.T) {
+	token, err := auth.NewAccessToken("key", "secret").
+		SetIdentity("agent").
+		SetVideoGrant(&auth.VideoGrant{RoomJoin: true, Room: "room"}).
+		ToJWT()
+	require.NoError(t, err)
+
+	url := "wss://example.livekit.cloud"
+	store := NewFileSessionStore(filepath.Join(t.TempDir(), "session.json"))
+	params := &signalling.ConnectParams{SessionStore: store}
+	stored := SessionState{URL: url, RoomName: "room", ParticipantIdentity: "agent", SavedAt: time.Now()}
+
+	s := &sessionPersister{}
+	require.Nil(t, s.start(url, token, params, logger))
+
+	for _, c := range []struct {
+		name    string
+		modify  func(state *SessionState)
+		matches bool
+	}{
+		{"matching", func(state *SessionState) {}, true},
+		{"expired", func(state *SessionState) { state.SavedAt = time.Now().Add(-time.Hour) }, false},
+		{"other server", func(state *SessionState) { state.URL = "wss://other.livekit.cloud" }, false},
+		{"other identity", func(state *SessionState) { state.ParticipantIdentity = "other" }, false},
+		{"other room", func(state *SessionState) { state.RoomName = "other" }, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			state := stored
+			c.modify(&state)
+			require.NoError(t, store.SaveSession(&state))
+
+			restored := s.start(url, token, params, logger)
+			require.Equal(t, c.matches, restored != nil)
+			require.Equal(t, restored, s.takeRestored())
+			require.Nil(t, s.takeRestored())
+		})
+	}
+
+	// saves are ignored once the room was left
+	s.clear(logger)
+	s.save(func() *SessionState { return &stored }, logger)
+	state, err := store.LoadSession()
+	require.NoError(t, err)
+	require.Nil(t, state)
+}
+
+func TestSubscriptionStateSnapshot(t *testing.T) {
+	s := newSubscriptionStateStore()
+	changes := 0
+	s.onChange = func() { changes++ }
+
+	width, height := uint32(640), uint32(360)
+	s.setTrackSettings("TR_video", remoteTrackSettings{videoWidth: &width, videoHeight: &height})
+	s.setSubscribed("TR_audio", false)
+	s.remove("TR_missing")
+	require.Equal(t, 2, changes)
+
+	snapshot := s.snapshot()
+	require.Len(t, snapshot, 2)
+	require.Equal(t, "TR_audio", snapshot[0].TrackSID)
+	require.False(t, *snapshot[0].Subscribed)
+	require.Equal(t, width, *snapshot[1].VideoWidth)
+
+	restored := newSubscriptionStateStore()
+	restored.restore(snapshot)
+	ts, ok := restored.get("TR_video")
+	require.True(t, ok)
+	require.Nil(t, ts.subscribed)
+	require.Equal(t, height, *ts.videoHeight)
+	require.Equal(t, snapshot, restored.snapshot())
+}
+
+func TestBufferConfigSurvivesReconnect(t *testing.T) {
+	store := newSubscriptionStateStore()
+	pi := &livekit.ParticipantInfo{Sid: "PA_publisher", Identity: "publisher", Tracks: []*livekit.TrackInfo{{
+		Sid:  "TR_audio",
+		Type: livekit.TrackType_AUDIO,
+	}}}
+	rp := newRemoteParticipant(pi, NewRoomCallback(), nil, nil, store, logger)
+	rp.events.release(nil)
+	pub := rp.getPublication("TR_audio")
+	pub.SetBufferConfig(SubscriptionBufferConfig{TargetLatency: 250 * time.Millisecond})
+
+	// a full reconnect recreates participants and publications, the receiver is configured from the new one
+	rejoined := newRemoteParticipant(pi, NewRoomCallback(), nil, nil, store, logger)
+	rejoined.events.release(nil)
+	pub = rejoined.getPublication("TR_audio")
+	require.Equal(t, 250*time.Millisecond, pub.BufferConfig().TargetLatency)
+
+	// and so does a restart restoring the persisted session
+	restored := newSubscriptionStateStore()
+	restored.restore(store.snapshot())
+	rp = newRemoteParticipant(pi, NewRoomCallback(), nil, nil, restored, logger)
+	rp.events.release(nil)
+	pub = rp.getPublication("TR_audio")
+	require.Equal(t, 250*time.Millisecond, pub.BufferConfig().TargetLatency)
+}
+
+// resumeSignalTransport stands in for a server that resumes the session when accept is set,
+// and answers publisher offers from a local peer connection
+type resumeSignalTransport struct {
+	t      *testing.T
+	engine *RTCEngine
+	accept bool
+
+	lock          sync.Mutex
+	reconnectedAs string
+	joined        bool
+	remote        *webrtc.PeerConnection
+}
+
+func (s *resumeSignalTransport) SetLogger(protoLogger.Logger) {}
+func (s *resumeSignalTransport) Start()                       {}
+func (s *resumeSignalTransport) IsStarted() bool              { return true }
+func (s *resumeSignalTransport) Close()                       {}
+
+func (s *resumeSignalTransport) Join(context.Context, string, string, signalling.ConnectParams, []*livekit.AddTrackRequest, webrtc.SessionDescription) error {
+	s.lock.Lock()
+	s.joined = true
+	s.lock.Unlock()
+	return errors.New("join refused")
+}
+
+func (s *resumeSignalTransport) Reconnect(_, _ string, _ signalling.ConnectParams, participantSID string) error {
+	s.lock.Lock()
+	s.reconnectedAs = participantSID
+	s.lock.Unlock()
+	if !s.accept {
+		return errors.New("participant not found")
+	}
+	return s.engine.OnReconnectResponse(&livekit.ReconnectResponse{})
+}
+
+func (s *resumeSignalTransport) SendMessage(msg proto.Message) error {
+	offer := msg.(*livekit.SignalRequest).GetOffer()
+	if offer == nil {
+		return nil
+	}
+	remote, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(s.t, err)
+	s.lock.Lock()
+	s.remote = remote
+	s.lock.Unlock()
+
+	require.NoError(s.t, remote.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offer.Sdp}))
+	answer, err := remote.CreateAnswer(nil)
+	require.NoError(s.t, err)
+	gathered := webrtc.GatheringCompletePromise(remote)
+	require.NoError(s.t, remote.SetLocalDescription(answer))
+	go func() {
+		<-gathered
+		s.engine.OnAnswer(*remote.LocalDescription(), 0, nil)
+	}()
+	return nil
+}
+
+func (s *resumeSignalTransport) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.remote != nil {
+		_ = s.remote.Close()
+	}
+}
+
+func TestJoinWithSession(t *testing.T) {
+	token, err := auth.NewAccessToken("key", "secret").
+		SetIdentity("agent").
+		SetVideoGrant(&auth.VideoGrant{RoomJoin: true, Room: "room"}).
+		ToJWT()
+	require.NoError(t, err)
+
+	for _, accept := range []bool{true, false} {
+		store := NewFileSessionStore(filepath.Join(t.TempDir(), "session.json"))
+		require.NoError(t, store.SaveSession(&SessionState{
+			URL:                 "ws://127.0.0.1:1",
+			Token:               token,
+			RoomName:            "room",
+			ParticipantSID:      "PA_agent",
+			ParticipantIdentity: "agent",
+			SavedAt:             time.Now(),
+		}))
+
+		room := NewRoom(nil)
+		transport := &resumeSignalTransport{t: t, engine: room.engine, accept: accept}
+		room.engine.signalTransport = transport
+
+		err := room.JoinWithSession(context.Background(), store, WithDisableRegionDiscovery())
+		require.Equal(t, "PA_agent", transport.reconnectedAs)
+		if accept {
+			// resumed as the stored participant, without joining again
+			require.NoError(t, err)
+			require.False(t, transport.joined)
+			require.Equal(t, "PA_agent", room.LocalParticipant.SID())
+			require.Equal(t, "room", room.Name())
+			require.Equal(t, ConnectionStateConnected, room.ConnectionState())
+		} else {
+			// rejected, falls back to a full join
+			require.Error(t, err)
+			require.True(t, transport.joined)
+		}
+		room.Disconnect()
+		transport.close()
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"github.com/pion/webrtc/v4"
+)
+
+// SessionStats holds the WebRTC stats of the peer connections of a session.
+// A report is nil when the transport does not exist, e.g. Subscriber when a single peer connection is used.
+type SessionStats struct {
+	Publisher  webrtc.StatsReport
+	Subscriber webrtc.StatsReport
+}
+
+// SessionStats returns the current stats of each transport
+func (e *RTCEngine) SessionStats() SessionStats {
+	var stats SessionStats
+	if publisher, ok := e.Publisher(); ok {
+		stats.Publisher = publisher.pc.GetStats()
+	}
+	if subscriber, ok := e.Subscriber(); ok {
+		stats.Subscriber = subscriber.pc.GetStats()
+	}
+	return stats
+}
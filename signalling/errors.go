@@ -22,4 +22,5 @@ var (
 	ErrInvalidMessageType = errors.New("invalid message type")
 	ErrInvalidParameter   = errors.New("invalid parameter")
 	ErrCannotDialSignal   = errors.New("could not dial signal connection")
+	ErrSignalQueueFull    = errors.New("signal queue is full")
 )
@@ -16,11 +16,14 @@ package signalling
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/livekit/mediatransportutil/pkg/pacer"
 	"github.com/livekit/protocol/livekit"
 	protoLogger "github.com/livekit/protocol/logger"
+	"github.com/pion/ice/v4"
 	"github.com/pion/interceptor"
 	"github.com/pion/webrtc/v4"
 	"google.golang.org/protobuf/proto"
@@ -67,6 +70,228 @@ type Signalling interface {
 	SignalUpdateParticipantMetadata(metadata *livekit.UpdateParticipantMetadata) proto.Message
 }
 
+// SignalPinger is implemented by Signalling that can ping the server to measure RTT and detect dead connections
+type SignalPinger interface {
+	// SignalPing returns the legacy ping, answered with the timestamp only
+	SignalPing(timestamp int64) proto.Message
+	SignalPingReq(ping *livekit.Ping) proto.Message
+}
+
+type SDPDirection int
+
+const (
+	SDPDirectionOutgoing SDPDirection = iota
+	SDPDirectionIncoming
+)
+
+func (d SDPDirection) String() string {
+	switch d {
+	case SDPDirectionOutgoing:
+		return "OUTGOING"
+	case SDPDirectionIncoming:
+		return "INCOMING"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(d))
+	}
+}
+
+// SDPTransformer can modify a session description before it is sent, or applied to a peer connection
+type SDPTransformer func(direction SDPDirection, sd webrtc.SessionDescription) webrtc.SessionDescription
+
+// RTCPConfig tunes RTCP feedback of the peer connections, zero values keep the defaults
+type RTCPConfig struct {
+	SenderReportInterval   time.Duration // default 1s
+	ReceiverReportInterval time.Duration // default 1s
+	TWCCFeedbackInterval   time.Duration // default 100ms
+
+	// MaxNACKPairsPerPacket caps the NACK pairs batched into one RTCP packet, unlimited when zero
+	MaxNACKPairsPerPacket int
+
+	// audio streams do not use NACK by default, video does
+	EnableAudioNACK  bool
+	DisableVideoNACK bool
+}
+
+// ICEKeepaliveConfig tunes ICE keepalives and consent freshness of the peer connections, zero values keep the defaults
+type ICEKeepaliveConfig struct {
+	// Interval of STUN binding requests sent when no other traffic is sent, keeps NAT bindings open. Default 2s
+	Interval time.Duration
+	// DisconnectedTimeout is how long without traffic from the remote before the connection is disconnected. Default 10s
+	DisconnectedTimeout time.Duration
+	// FailedTimeout is how long the connection may stay disconnected before it fails. Default 5s
+	FailedTimeout time.Duration
+}
+
+// RateLimitPolicy decides how rate limited RPC requests are handled, data packets are always dropped
+type RateLimitPolicy int
+
+const (
+	// RateLimitDeny responds to limited RPC requests with an error
+	RateLimitDeny RateLimitPolicy = iota
+	// RateLimitDrop ignores limited RPC requests, callers time out
+	RateLimitDrop
+)
+
+// AutoSubscribeFilter limits the tracks subscribed to automatically, empty fields match all tracks
+type AutoSubscribeFilter struct {
+	// Kinds subscribes to tracks of these kinds only, e.g. audio for transcription agents
+	Kinds []livekit.TrackType
+	// Sources subscribes to tracks of these sources only
+	Sources []livekit.TrackSource
+	// ExcludeSources skips tracks of these sources, e.g. screen shares
+	ExcludeSources []livekit.TrackSource
+	// Identities subscribes to tracks of these participants only
+	Identities []string
+}
+
+// SubscriptionBufferConfig tunes the receive path of subscribed tracks, zero values keep the defaults
+type SubscriptionBufferConfig struct {
+	// ReceiveBufferSize is the number of bytes buffered for a track before packets are dropped, 1MB by default.
+	// Lower it for low latency bots that rather skip than fall behind, raise it for recorders reading in bursts.
+	ReceiveBufferSize int
+	// NACKWindow is how long a missing packet is requested again, 2 minutes by default, negative disables NACKs
+	NACKWindow time.Duration
+	// TargetLatency is the latency the application aims for, packets missing for longer are not requested again.
+	// It is also the delay jitter buffers of the application should use, see RemoteTrackPublication.BufferConfig.
+	TargetLatency time.Duration
+}
+
+// SubscriptionBudget caps the media bytes received by a room, see WithSubscriptionBudget
+type SubscriptionBudget struct {
+	// MaxBytes is the number of received bytes the room may use, 0 disables the budget
+	MaxBytes uint64
+	// DowngradeAt is the fraction of MaxBytes at which subscribed video is downgraded to low quality, 0.8 by default
+	DowngradeAt float64
+	// PauseAt is the fraction of MaxBytes at which low priority subscriptions are paused, 0.95 by default
+	PauseAt float64
+	// Interval is how often received bytes are checked, 1 second by default
+	Interval time.Duration
+}
+
+// AudioPriorityConfig configures how published audio is protected under congestion, see WithAudioPriority
+type AudioPriorityConfig struct {
+	// Interval is how often bandwidth estimates are checked, 1 second by default
+	Interval time.Duration
+	// AllowVideoPause also pauses video without simulcast and the lowest simulcast layers when reducing
+	// higher layers is not enough
+	AllowVideoPause bool
+}
+
+// ICEServersPolicy decides how ICE servers given with WithICEServers are combined with the servers sent by the server
+type ICEServersPolicy int
+
+const (
+	// ICEServersMerge uses the given servers in addition to those of the server
+	ICEServersMerge ICEServersPolicy = iota
+	// ICEServersReplace uses the given servers only
+	ICEServersReplace
+)
+
+// ICECredentialRefresher returns servers with current credentials, given the servers used so far
+type ICECredentialRefresher func(ctx context.Context, servers []webrtc.ICEServer) ([]webrtc.ICEServer, error)
+
+// DuplicateIdentityPolicy decides what happens when joining with an identity that is already connected
+type DuplicateIdentityPolicy int
+
+const (
+	// DuplicateIdentityReplace lets the server disconnect the existing participant, which is notified with DuplicateIdentity
+	DuplicateIdentityReplace DuplicateIdentityPolicy = iota
+	// DuplicateIdentityFail fails the join with ErrDuplicateIdentity and leaves the existing participant connected
+	DuplicateIdentityFail
+)
+
+// InboundRateLimits are applied per remote participant, zero values disable a limit
+type InboundRateLimits struct {
+	DataMessagesPerSecond float64
+	DataBytesPerSecond    float64
+	RPCRequestsPerSecond  float64
+	RPCBytesPerSecond     float64
+	RPCPolicy             RateLimitPolicy
+}
+
+// ReconnectPolicy decides whether and when a lost connection is attempted to be resumed or restarted
+type ReconnectPolicy interface {
+	// NextRetryDelay is called before every attempt with the number of failed attempts and the time since
+	// the connection was lost. Returns the delay before the attempt, or false to give up and disconnect.
+	NextRetryDelay(attempt int, elapsed time.Duration) (time.Duration, bool)
+}
+
+// SessionState is the minimal state of a connection persisted with a SessionStore
+type SessionState struct {
+	URL string `json:"url"`
+	// latest token, refreshed by the server while connected
+	Token               string                   `json:"token"`
+	RoomName            string                   `json:"roomName"`
+	ParticipantSID      string                   `json:"participantSid"`
+	ParticipantIdentity string                   `json:"participantIdentity"`
+	Subscriptions       []TrackSubscriptionState `json:"subscriptions,omitempty"`
+	SavedAt             time.Time                `json:"savedAt"`
+}
+
+// TrackSubscriptionState holds the settings applied to a remote track by the application
+type TrackSubscriptionState struct {
+	TrackSID string `json:"trackSid"`
+	// nil when subscription was never changed explicitly
+	Subscribed   *bool                 `json:"subscribed,omitempty"`
+	Disabled     bool                  `json:"disabled,omitempty"`
+	VideoWidth   *uint32               `json:"videoWidth,omitempty"`
+	VideoHeight  *uint32               `json:"videoHeight,omitempty"`
+	VideoQuality *livekit.VideoQuality `json:"videoQuality,omitempty"`
+	// receive buffer and target latency set for the track, see SubscriptionBufferConfig
+	BufferConfig *SubscriptionBufferConfig `json:"bufferConfig,omitempty"`
+}
+
+// SessionStore persists the session state of a room across process restarts, see WithSessionStore
+type SessionStore interface {
+	SaveSession(state *SessionState) error
+	// LoadSession returns nil when no session is stored
+	LoadSession() (*SessionState, error)
+	ClearSession() error
+}
+
+// PublishDefaults are applied to tracks published without the corresponding options
+type PublishDefaults struct {
+	DisableAudioDTX   bool
+	StereoAudio       bool
+	BackupCodecPolicy livekit.BackupCodecPolicy
+	PublishTimeout    time.Duration
+}
+
+// StreamGuard validates incoming data streams before they are buffered, zero values disable a check
+type StreamGuard struct {
+	// MaxStreamBytes rejects streams that announce or deliver more bytes
+	MaxStreamBytes uint64
+	// AllowedTopics rejects streams on other topics when not empty
+	AllowedTopics []string
+	// AllowedMimeTypes rejects streams of other mime types when not empty, "image/*" matches any image type
+	AllowedMimeTypes []string
+	// Validate is called with the stream header after the built-in checks, a non-nil error rejects the stream
+	Validate func(header *livekit.DataStream_Header, participantIdentity string) error
+}
+
+// StreamSpoolConfig spools incoming byte streams to temporary files instead of memory, see WithStreamSpooling
+type StreamSpoolConfig struct {
+	// MemoryThreshold is the number of bytes buffered in memory per stream before spooling to disk,
+	// spooling is disabled when zero
+	MemoryThreshold uint64
+	// Dir holds the temporary files, os.TempDir when empty
+	Dir string
+	// MaxStreamBytes aborts a stream receiving more bytes, unlimited when zero
+	MaxStreamBytes uint64
+	// MaxTotalBytes caps the bytes spooled to disk by all streams of a room, unlimited when zero
+	MaxTotalBytes uint64
+}
+
+// DTMFSequenceConfig controls how incoming DTMF digits are assembled into sequences
+type DTMFSequenceConfig struct {
+	// InterDigitTimeout completes a sequence when no digit follows in time, default 3s, negative disables sequences
+	InterDigitTimeout time.Duration
+	// Terminators are digits that complete a sequence immediately, e.g. "#", they are not part of the sequence
+	Terminators string
+	// MaxDigits completes a sequence once it has this many digits, unlimited when zero
+	MaxDigits int
+}
+
 type ConnectParams struct {
 	AutoSubscribe          bool
 	Reconnect              bool
@@ -74,6 +299,11 @@ type ConnectParams struct {
 
 	RetransmitBufferSize uint16
 
+	RTCP RTCPConfig // See WithRTCPConfig
+
+	// AudioOnly negotiates audio codecs only and subscribes to audio tracks only, see WithAudioOnly
+	AudioOnly bool
+
 	Metadata string // See WithMetadata
 
 	Attributes map[string]string // See WithExtraAttributes
@@ -84,8 +314,83 @@ type ConnectParams struct {
 
 	ICETransportPolicy webrtc.ICETransportPolicy
 
+	// overrides ping interval and timeout sent by the server, see WithSignalPing
+	PingInterval time.Duration
+	PingTimeout  time.Duration
+
+	SDPTransformer SDPTransformer // See WithSDPTransformer
+
+	BandwidthEstimatesInterval time.Duration // See WithBandwidthEstimatesInterval
+	StatsReportInterval        time.Duration // See WithStatsReportInterval
+
+	NegotiationTimeout time.Duration // See WithNegotiationTimeout
+
+	PresenceInterval time.Duration // See WithPresenceHeartbeat
+
+	InboundRateLimits InboundRateLimits // See WithInboundRateLimits
+
+	StreamGuard StreamGuard // See WithStreamGuard
+
+	StreamSpool StreamSpoolConfig // See WithStreamSpooling
+
+	BridgeTranscriptions bool // See WithTranscriptionBridge
+
+	// DisableAutoRepublish skips re-publishing local tracks after a full reconnect, see WithAutoRepublish
+	DisableAutoRepublish bool
+
+	MaxReconnectAttempts int // See WithMaxReconnectAttempts
+
+	ReconnectPolicy ReconnectPolicy // See WithReconnectPolicy
+
+	PublishDefaults PublishDefaults // See WithPublishDefaults
+
+	ReliableDataByDefault bool // See WithReliableDataByDefault
+
+	JSONDataPackets bool // See WithJSONDataPackets
+
+	GoroutineLeakTimeout time.Duration // See WithGoroutineLeakTimeout
+
+	DTMFSequence *DTMFSequenceConfig // See WithDTMFSequence
+
+	ParticipantConnectedOnJoin bool // See WithParticipantConnectedOnJoin
+
+	InterfaceMTULimit bool // See WithInterfaceMTULimit
+
+	ICEKeepalive ICEKeepaliveConfig // See WithICEKeepalive
+
+	ICENetworkTypes []webrtc.NetworkType // See WithICENetworkTypes
+
+	// see WithICEServers
+	ICEServers           []webrtc.ICEServer
+	ICEServersPolicy     ICEServersPolicy
+	ICECredentialRefresh ICECredentialRefresher // See WithICECredentialRefresh
+
+	DuplicateIdentity DuplicateIdentityPolicy // See WithDuplicateIdentityPolicy
+
+	AutoSubscribeFilter *AutoSubscribeFilter // See WithAutoSubscribeFilter
+
+	SubscriptionBuffer SubscriptionBufferConfig // See WithSubscriptionBufferConfig
+
+	SubscriptionBudget SubscriptionBudget // See WithSubscriptionBudget
+
+	AudioPriority *AudioPriorityConfig // See WithAudioPriority
+
+	// see WithSessionStore
+	SessionStore  SessionStore
+	SessionMaxAge time.Duration
+
 	// internal use
 	Codecs []webrtc.RTPCodecParameters
+	// drops URLs of server provided ICE servers, used by connectivity checks
+	ICEServerFilter func(url string) bool
+	// shared by the transports of participants in a bundle, see ParticipantBundle
+	ICEUDPMux ice.UDPMux
+}
+
+// ServerAutoSubscribe returns whether the server subscribes to tracks, the SDK subscribes itself when
+// auto subscribe is limited by audio only or a filter
+func (p *ConnectParams) ServerAutoSubscribe() bool {
+	return p.AutoSubscribe && !p.AudioOnly && p.AutoSubscribeFilter == nil
 }
 
 type SignalTransport interface {
@@ -115,6 +420,12 @@ type SignalTransportHandler interface {
 	OnTransportClose()
 }
 
+// PongProcessor is implemented by SignalProcessor that handles the answers to pings, see SignalPinger
+type PongProcessor interface {
+	OnPong(pong *livekit.Pong)
+	OnLegacyPong(lastPingTimestamp int64)
+}
+
 type SignalHandler interface {
 	SetLogger(l protoLogger.Logger)
 
@@ -142,3 +453,8 @@ type SignalProcessor interface {
 	OnSubscribedAudioCodecUpdate(subscribedAudioCodecUpdate *livekit.SubscribedAudioCodecUpdate)
 	OnMediaSectionsRequirement(mediaSectionsRequirement *livekit.MediaSectionsRequirement)
 }
+
+// SubscriptionResponseProcessor is implemented by SignalProcessor that handles failed track subscriptions
+type SubscriptionResponseProcessor interface {
+	OnSubscriptionResponse(response *livekit.SubscriptionResponse)
+}
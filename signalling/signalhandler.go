@@ -121,6 +121,21 @@ func (s *signalhandler) HandleMessage(msg proto.Message) error {
 
 	case *livekit.SignalResponse_MediaSectionsRequirement:
 		s.params.Processor.OnMediaSectionsRequirement(payload.MediaSectionsRequirement)
+
+	case *livekit.SignalResponse_SubscriptionResponse:
+		if p, ok := s.params.Processor.(SubscriptionResponseProcessor); ok {
+			p.OnSubscriptionResponse(payload.SubscriptionResponse)
+		}
+
+	case *livekit.SignalResponse_PongResp:
+		if p, ok := s.params.Processor.(PongProcessor); ok {
+			p.OnPong(payload.PongResp)
+		}
+
+	case *livekit.SignalResponse_Pong:
+		if p, ok := s.params.Processor.(PongProcessor); ok {
+			p.OnLegacyPong(payload.Pong)
+		}
 	}
 
 	return nil
@@ -57,7 +57,8 @@ func (s *signalling) ConnectQueryParams(
 ) (string, error) {
 	queryParams := fmt.Sprintf("version=%s&protocol=%d&", version, protocol)
 
-	if connectParams.AutoSubscribe {
+	// with audio only or a filter, the SDK subscribes to tracks itself
+	if connectParams.ServerAutoSubscribe() {
 		queryParams += "&auto_subscribe=1"
 	} else {
 		queryParams += "&auto_subscribe=0"
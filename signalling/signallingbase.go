@@ -176,3 +176,19 @@ func (s *signallingBase) SignalUpdateParticipantMetadata(metadata *livekit.Updat
 		},
 	}
 }
+
+func (s *signallingBase) SignalPing(timestamp int64) proto.Message {
+	return &livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Ping{
+			Ping: timestamp,
+		},
+	}
+}
+
+func (s *signallingBase) SignalPingReq(ping *livekit.Ping) proto.Message {
+	return &livekit.SignalRequest{
+		Message: &livekit.SignalRequest_PingReq{
+			PingReq: ping,
+		},
+	}
+}
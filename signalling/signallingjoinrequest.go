@@ -66,7 +66,8 @@ func (s *signallingJoinRequest) ConnectQueryParams(
 	}
 
 	connectionSettings := &livekit.ConnectionSettings{
-		AutoSubscribe: connectParams.AutoSubscribe,
+		// with audio only or a filter, the SDK subscribes to tracks itself
+		AutoSubscribe: connectParams.ServerAutoSubscribe(),
 	}
 
 	joinRequest := &livekit.JoinRequest{
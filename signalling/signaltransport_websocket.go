@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -35,6 +36,11 @@ import (
 
 var _ SignalTransport = (*signalTransportWebSocket)(nil)
 
+const (
+	// maximum number of requests buffered while the connection is being resumed
+	maxPendingMessages = 256
+)
+
 type SignalTransportWebSocketParams struct {
 	Logger                 logger.Logger
 	Version                string
@@ -54,6 +60,12 @@ type signalTransportWebSocket struct {
 	isStarted       atomic.Bool
 	pendingResponse proto.Message // only for old servers which do not send ReconnectResponse
 	readerClosedCh  chan struct{}
+	closing         atomic.Bool
+
+	// requests sent from losing the connection until the replay after reconnect finished
+	pendingLock     sync.Mutex
+	queueing        bool
+	pendingMessages signalQueue
 }
 
 func NewSignalTransportWebSocket(params SignalTransportWebSocketParams) SignalTransport {
@@ -70,6 +82,8 @@ func (s *signalTransportWebSocket) Start() {
 	if s.isStarted.Swap(true) {
 		return
 	}
+	s.replayPendingMessages()
+
 	s.readerClosedCh = make(chan struct{})
 	go s.readWorker(s.readerClosedCh)
 }
@@ -79,6 +93,19 @@ func (s *signalTransportWebSocket) IsStarted() bool {
 }
 
 func (s *signalTransportWebSocket) Close() {
+	s.closeConn()
+	s.discardPendingMessages()
+
+	// a response of a reconnect that was not completed does not apply to the next connection
+	s.lock.Lock()
+	s.pendingResponse = nil
+	s.lock.Unlock()
+}
+
+func (s *signalTransportWebSocket) closeConn() {
+	s.closing.Store(true)
+	defer s.closing.Store(false)
+
 	isStarted := s.IsStarted()
 	readerClosedCh := s.readerClosedCh
 	conn := s.websocketConn()
@@ -98,6 +125,9 @@ func (s *signalTransportWebSocket) Join(
 	addTrackRequests []*livekit.AddTrackRequest,
 	publisherOffer webrtc.SessionDescription,
 ) error {
+	// requests of a previous session do not apply to a new one
+	s.discardPendingMessages()
+
 	msg, err := s.connect(ctx, url, token, connectParams, addTrackRequests, publisherOffer, "")
 	if err != nil {
 		return err
@@ -116,6 +146,8 @@ func (s *signalTransportWebSocket) Reconnect(
 	participantSID string,
 ) error {
 	connectParams.Reconnect = true
+	// requests sent until the replay are queued, also when the previous connection is still being closed
+	s.startQueueing()
 	msg, err := s.connect(
 		context.TODO(),
 		url,
@@ -162,10 +194,16 @@ func (s *signalTransportWebSocket) Reconnect(
 
 func (s *signalTransportWebSocket) SendMessage(msg proto.Message) error {
 	conn := s.websocketConn()
+	if queued, err := s.queueMessage(msg, conn != nil); queued || err != nil {
+		return err
+	}
 	if conn == nil {
 		return errors.New("signal transport is not connected")
 	}
+	return s.writeMessage(conn, msg)
+}
 
+func (s *signalTransportWebSocket) writeMessage(conn *websocket.Conn, msg proto.Message) error {
 	payload, err := proto.Marshal(msg)
 	if err != nil {
 		return err
@@ -230,7 +268,7 @@ func (s *signalTransportWebSocket) connect(
 		return nil, err
 	}
 
-	s.Close() // close previous conn, if any
+	s.closeConn() // close previous conn, if any
 	s.conn.Store(conn)
 
 	// server should send join as soon as connected
@@ -249,6 +287,10 @@ func (s *signalTransportWebSocket) websocketConn() *websocket.Conn {
 
 func (s *signalTransportWebSocket) readWorker(readerClosedCh chan struct{}) {
 	defer func() {
+		if !s.closing.Load() {
+			// connection lost, hold requests until reconnected
+			s.startQueueing()
+		}
 		s.isStarted.Store(false)
 		s.conn.Store(nil)
 		close(readerClosedCh)
@@ -306,8 +348,165 @@ func (s *signalTransportWebSocket) readResponse() (proto.Message, error) {
 	}
 }
 
+func (s *signalTransportWebSocket) startQueueing() {
+	s.pendingLock.Lock()
+	s.queueing = true
+	s.pendingLock.Unlock()
+}
+
+// queueMessage queues msg until the replay finished, returns whether it was queued or dropped.
+// Requests tied to a connection are dropped while disconnected, and sent right away on a new connection.
+func (s *signalTransportWebSocket) queueMessage(msg proto.Message, connected bool) (bool, error) {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+
+	if !s.queueing {
+		return false, nil
+	}
+	if !isReplayableRequest(msg) {
+		if connected {
+			return false, nil
+		}
+		s.params.Logger.Debugw("dropping stale signal request while disconnected", "messageType", fmt.Sprintf("%T", msg))
+		return true, nil
+	}
+	if !s.pendingMessages.push(msg) {
+		return true, ErrSignalQueueFull
+	}
+	return true, nil
+}
+
+// replayPendingMessages sends the queued requests, including those queued while replaying, and stops queueing
+func (s *signalTransportWebSocket) replayPendingMessages() {
+	for {
+		s.pendingLock.Lock()
+		pendingMessages := s.pendingMessages.drain()
+		if len(pendingMessages) == 0 {
+			s.queueing = false
+			s.pendingLock.Unlock()
+			return
+		}
+		s.pendingLock.Unlock()
+
+		s.params.Logger.Debugw("replaying signal requests", "count", len(pendingMessages))
+		conn := s.websocketConn()
+		for _, msg := range pendingMessages {
+			err := errors.New("signal transport is not connected")
+			if conn != nil {
+				err = s.writeMessage(conn, msg)
+			}
+			if err != nil {
+				s.params.Logger.Warnw("could not replay signal request", err, "messageType", fmt.Sprintf("%T", msg))
+			}
+		}
+	}
+}
+
+func (s *signalTransportWebSocket) discardPendingMessages() {
+	s.pendingLock.Lock()
+	s.pendingMessages.drain()
+	s.queueing = false
+	s.pendingLock.Unlock()
+}
+
+// signalQueue holds the requests to replay after reconnecting. A request replaces a queued one
+// it supersedes, e.g. settings of the same tracks, and subscription and track settings are replayed
+// ahead of other requests, so that media flows as requested as soon as possible.
+type signalQueue struct {
+	messages []proto.Message
+}
+
+// push returns false when the queue is full
+func (q *signalQueue) push(msg proto.Message) bool {
+	if key, _ := replayKey(msg); key != "" {
+		q.messages = slices.DeleteFunc(q.messages, func(queued proto.Message) bool {
+			queuedKey, _ := replayKey(queued)
+			return queuedKey == key
+		})
+	}
+	if len(q.messages) >= maxPendingMessages {
+		return false
+	}
+	q.messages = append(q.messages, msg)
+	return true
+}
+
+// drain empties the queue and returns its requests in the order to replay them
+func (q *signalQueue) drain() []proto.Message {
+	messages := q.messages
+	q.messages = nil
+	slices.SortStableFunc(messages, func(a, b proto.Message) int {
+		_, aFirst := replayKey(a)
+		_, bFirst := replayKey(b)
+		switch {
+		case aFirst == bFirst:
+			return 0
+		case aFirst:
+			return -1
+		default:
+			return 1
+		}
+	})
+	return messages
+}
+
+// replayKey returns the key of requests that supersede each other, empty when requests are all replayed,
+// and whether the request is replayed ahead of others
+func replayKey(msg proto.Message) (string, bool) {
+	req, ok := msg.(*livekit.SignalRequest)
+	if !ok {
+		return "", false
+	}
+	switch m := req.Message.(type) {
+	case *livekit.SignalRequest_Subscription:
+		sids := slices.Clone(m.Subscription.TrackSids)
+		for _, pt := range m.Subscription.ParticipantTracks {
+			sids = append(sids, pt.TrackSids...)
+		}
+		return "subscription/" + sortedKey(sids), true
+	case *livekit.SignalRequest_TrackSetting:
+		return "track-setting/" + sortedKey(slices.Clone(m.TrackSetting.TrackSids)), true
+	case *livekit.SignalRequest_Mute:
+		return "mute/" + m.Mute.Sid, false
+	case *livekit.SignalRequest_UpdateMetadata:
+		return "metadata", false
+	case *livekit.SignalRequest_SubscriptionPermission:
+		return "subscription-permission", false
+	default:
+		return "", false
+	}
+}
+
+func sortedKey(values []string) string {
+	slices.Sort(values)
+	return strings.Join(values, ",")
+}
+
 // ----------------------------------
 
+// isReplayableRequest returns false for requests that are tied to the
+// connection they were created for, candidates and session descriptions are
+// regenerated as part of reconnecting
+func isReplayableRequest(msg proto.Message) bool {
+	req, ok := msg.(*livekit.SignalRequest)
+	if !ok {
+		return true
+	}
+	switch req.Message.(type) {
+	case *livekit.SignalRequest_Trickle,
+		*livekit.SignalRequest_Offer,
+		*livekit.SignalRequest_Answer,
+		*livekit.SignalRequest_Leave,
+		*livekit.SignalRequest_SyncState,
+		*livekit.SignalRequest_Ping,
+		*livekit.SignalRequest_PingReq,
+		*livekit.SignalRequest_Simulate:
+		return false
+	default:
+		return true
+	}
+}
+
 func isIgnoredWebsocketError(err error) bool {
 	if err == nil ||
 		err == io.EOF ||
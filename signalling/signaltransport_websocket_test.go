@@ -0,0 +1,141 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signalling
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func trackSettingRequest(quality livekit.VideoQuality, sids ...string) *livekit.SignalRequest {
+	return &livekit.SignalRequest{Message: &livekit.SignalRequest_TrackSetting{
+		TrackSetting: &livekit.UpdateTrackSettings{TrackSids: sids, Quality: quality},
+	}}
+}
+
+func muteRequest(sid string, muted bool) *livekit.SignalRequest {
+	return &livekit.SignalRequest{Message: &livekit.SignalRequest_Mute{
+		Mute: &livekit.MuteTrackRequest{Sid: sid, Muted: muted},
+	}}
+}
+
+func subscriptionRequest(subscribe bool, sids ...string) *livekit.SignalRequest {
+	return &livekit.SignalRequest{Message: &livekit.SignalRequest_Subscription{
+		Subscription: &livekit.UpdateSubscription{TrackSids: sids, Subscribe: subscribe},
+	}}
+}
+
+func TestSignalQueue(t *testing.T) {
+	var q signalQueue
+	metadata := &livekit.SignalRequest{Message: &livekit.SignalRequest_UpdateMetadata{
+		UpdateMetadata: &livekit.UpdateParticipantMetadata{Metadata: "m"},
+	}}
+	require.True(t, q.push(muteRequest("TR_a", true)))
+	require.True(t, q.push(trackSettingRequest(livekit.VideoQuality_LOW, "TR_a", "TR_b")))
+	require.True(t, q.push(subscriptionRequest(true, "TR_c")))
+	require.True(t, q.push(metadata))
+	// supersede the queued requests for the same tracks
+	require.True(t, q.push(trackSettingRequest(livekit.VideoQuality_HIGH, "TR_b", "TR_a")))
+	require.True(t, q.push(subscriptionRequest(false, "TR_c")))
+	require.True(t, q.push(muteRequest("TR_a", false)))
+	// other tracks are kept
+	require.True(t, q.push(subscriptionRequest(true, "TR_d")))
+
+	// subscription and track settings first, in the order they were queued
+	expected := []proto.Message{
+		trackSettingRequest(livekit.VideoQuality_HIGH, "TR_b", "TR_a"),
+		subscriptionRequest(false, "TR_c"),
+		subscriptionRequest(true, "TR_d"),
+		metadata,
+		muteRequest("TR_a", false),
+	}
+	drained := q.drain()
+	require.Len(t, drained, len(expected))
+	for i := range expected {
+		require.True(t, proto.Equal(expected[i], drained[i]), "request %d: %v", i, drained[i])
+	}
+	require.Empty(t, q.drain())
+
+	for i := 0; i < maxPendingMessages; i++ {
+		require.True(t, q.push(muteRequest("TR_"+strings.Repeat("x", i), true)))
+	}
+	require.False(t, q.push(muteRequest("TR_full", true)))
+	// replacing a queued request does not need room
+	require.True(t, q.push(muteRequest("TR_", false)))
+}
+
+func TestSignalTransportReplaysBeforeNewRequests(t *testing.T) {
+	received := make(chan *livekit.SignalRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, payload, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			req := &livekit.SignalRequest{}
+			if err := proto.Unmarshal(payload, req); err == nil {
+				received <- req
+			}
+		}
+	}))
+	defer server.Close()
+
+	s := &signalTransportWebSocket{params: SignalTransportWebSocketParams{Logger: logger.GetLogger()}}
+	// connection lost
+	s.startQueueing()
+	require.NoError(t, s.SendMessage(trackSettingRequest(livekit.VideoQuality_LOW, "TR_a")))
+	require.NoError(t, s.SendMessage(muteRequest("TR_b", true)))
+	trickle := &livekit.SignalRequest{Message: &livekit.SignalRequest_Trickle{Trickle: &livekit.TrickleRequest{}}}
+	require.NoError(t, s.SendMessage(trickle))
+
+	// reconnected, but not replayed yet
+	conn, _, err := websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http", "ws", 1), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	s.conn.Store(conn)
+	require.NoError(t, s.SendMessage(trackSettingRequest(livekit.VideoQuality_HIGH, "TR_a")))
+	// requests of the new connection are sent right away
+	offer := &livekit.SignalRequest{Message: &livekit.SignalRequest_Offer{Offer: &livekit.SessionDescription{Type: "offer"}}}
+	require.NoError(t, s.SendMessage(offer))
+
+	s.replayPendingMessages()
+	require.NoError(t, s.SendMessage(muteRequest("TR_b", false)))
+
+	for _, expected := range []*livekit.SignalRequest{
+		offer,
+		trackSettingRequest(livekit.VideoQuality_HIGH, "TR_a"),
+		muteRequest("TR_b", true),
+		muteRequest("TR_b", false),
+	} {
+		require.True(t, proto.Equal(expected, <-received))
+	}
+
+	// not queueing without a connection
+	s.conn.Store(nil)
+	require.Error(t, s.SendMessage(muteRequest("TR_b", true)))
+}
@@ -201,6 +201,9 @@ func (s *SIPClient) CreateSIPDispatchRule(ctx context.Context, in *livekit.Creat
 	if in == nil {
 		return nil, ErrInvalidParameter
 	}
+	if err := ValidateRequest(in); err != nil {
+		return nil, err
+	}
 
 	ctx, err := s.withAuth(ctx, withSIPGrant{Admin: true})
 	if err != nil {
@@ -214,6 +217,9 @@ func (s *SIPClient) UpdateSIPDispatchRule(ctx context.Context, in *livekit.Updat
 	if in == nil || in.Action == nil || in.SipDispatchRuleId == "" {
 		return nil, ErrInvalidParameter
 	}
+	if err := ValidateRequest(in); err != nil {
+		return nil, err
+	}
 
 	ctx, err := s.withAuth(ctx, withSIPGrant{Admin: true})
 	if err != nil {
@@ -289,7 +295,9 @@ func (s *SIPClient) CreateSIPParticipant(ctx context.Context, in *livekit.Create
 		defer cancel()
 	}
 
-	return s.sipClient.CreateSIPParticipant(ctx, in)
+	return idempotent(ctx, s.authBase, "CreateSIPParticipant", in, func(ctx context.Context) (*livekit.SIPParticipantInfo, error) {
+		return s.sipClient.CreateSIPParticipant(ctx, in)
+	})
 }
 
 // TransferSIPParticipant transfer an existing SIP participant to an outside SIP endpoint.
@@ -0,0 +1,85 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// SIPCallStatus is the value of the sip.callStatus attribute
+type SIPCallStatus string
+
+const (
+	SIPCallStatusDialing    SIPCallStatus = "dialing"
+	SIPCallStatusRinging    SIPCallStatus = "ringing"
+	SIPCallStatusAutomation SIPCallStatus = "automation"
+	SIPCallStatusActive     SIPCallStatus = "active"
+	SIPCallStatusHangup     SIPCallStatus = "hangup"
+)
+
+// SIPInfo holds the well-known sip.* attributes of a SIP participant, fields are empty when not set
+type SIPInfo struct {
+	CallID           string
+	TrunkID          string
+	DispatchRuleID   string
+	TrunkPhoneNumber string
+	PhoneNumber      string // omitted by the server if the trunk hides phone numbers
+	HostName         string
+	CallStatus       SIPCallStatus
+}
+
+func sipInfoFromAttributes(attrs map[string]string) SIPInfo {
+	return SIPInfo{
+		CallID:           attrs[livekit.AttrSIPCallID],
+		TrunkID:          attrs[livekit.AttrSIPTrunkID],
+		DispatchRuleID:   attrs[livekit.AttrSIPDispatchRuleID],
+		TrunkPhoneNumber: attrs[livekit.AttrSIPTrunkNumber],
+		PhoneNumber:      attrs[livekit.AttrSIPPhoneNumber],
+		HostName:         attrs[livekit.AttrSIPHostName],
+		CallStatus:       SIPCallStatus(attrs[livekit.AttrSIPCallStatus]),
+	}
+}
+
+// SIPInfo returns the SIP attributes of the participant, ok is false if it did not join through SIP
+func (p *RemoteParticipant) SIPInfo() (info SIPInfo, ok bool) {
+	if p.Kind() != ParticipantSIP {
+		return SIPInfo{}, false
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return sipInfoFromAttributes(p.attributes), true
+}
+
+// SIPCallStatus returns the current call status, empty if the participant did not join through SIP
+func (p *RemoteParticipant) SIPCallStatus() SIPCallStatus {
+	info, _ := p.SIPInfo()
+	return info.CallStatus
+}
+
+// SIPHeaders returns SIP headers mapped to attributes, keyed by attribute name without the sip.h. prefix
+func (p *RemoteParticipant) SIPHeaders() map[string]string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	headers := make(map[string]string)
+	for k, v := range p.attributes {
+		if name, ok := strings.CutPrefix(k, livekit.AttrSIPHeaderPrefix); ok {
+			headers[name] = v
+		}
+	}
+	return headers
+}
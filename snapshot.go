@@ -0,0 +1,279 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// RoomSnapshot is the state of a room at a point in time, serializable to JSON to attach it to bug reports
+// and audits. It does not contain tokens or other credentials. See Room.Snapshot and NewRoomFromSnapshot.
+type RoomSnapshot struct {
+	TakenAt         time.Time       `json:"takenAt"`
+	SID             string          `json:"sid"`
+	Name            string          `json:"name"`
+	Metadata        string          `json:"metadata,omitempty"`
+	IsRecording     bool            `json:"isRecording,omitempty"`
+	ConnectionState ConnectionState `json:"connectionState"`
+	// nil before joining
+	Server     *ServerSnapshot   `json:"server,omitempty"`
+	Connection ConnectionDetails `json:"connection"`
+	SignalRTT  time.Duration     `json:"signalRtt,omitempty"`
+
+	LocalParticipant ParticipantSnapshot `json:"localParticipant"`
+	// ordered by identity
+	RemoteParticipants []ParticipantSnapshot `json:"remoteParticipants,omitempty"`
+
+	// nil when WithSubscriptionBudget is not used
+	SubscriptionBudget *SubscriptionBudgetStatus `json:"subscriptionBudget,omitempty"`
+}
+
+// ServerSnapshot describes the server a room is connected to
+type ServerSnapshot struct {
+	Version  string `json:"version,omitempty"`
+	Region   string `json:"region,omitempty"`
+	NodeID   string `json:"nodeId,omitempty"`
+	Protocol int32  `json:"protocol,omitempty"`
+}
+
+// ParticipantSnapshot is the state of a participant in a RoomSnapshot
+type ParticipantSnapshot struct {
+	SID        string            `json:"sid"`
+	Identity   string            `json:"identity"`
+	Name       string            `json:"name,omitempty"`
+	Kind       string            `json:"kind"`
+	Metadata   string            `json:"metadata,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	IsSpeaking bool              `json:"isSpeaking,omitempty"`
+	AudioLevel float32           `json:"audioLevel,omitempty"`
+	// ordered by SID
+	Tracks []TrackSnapshot `json:"tracks,omitempty"`
+}
+
+// TrackSnapshot is the state of a track publication in a RoomSnapshot.
+// Subscription settings are only set for tracks of remote participants.
+type TrackSnapshot struct {
+	SID        string    `json:"sid"`
+	Name       string    `json:"name,omitempty"`
+	Kind       TrackKind `json:"kind"`
+	Source     string    `json:"source"`
+	MimeType   string    `json:"mimeType,omitempty"`
+	Muted      bool      `json:"muted,omitempty"`
+	Width      uint32    `json:"width,omitempty"`
+	Height     uint32    `json:"height,omitempty"`
+	Simulcast  bool      `json:"simulcast,omitempty"`
+	Subscribed bool      `json:"subscribed,omitempty"`
+
+	Disabled     bool   `json:"disabled,omitempty"`
+	VideoQuality string `json:"videoQuality,omitempty"`
+	VideoWidth   uint32 `json:"videoWidth,omitempty"`
+	VideoHeight  uint32 `json:"videoHeight,omitempty"`
+	Priority     string `json:"priority,omitempty"`
+}
+
+// Snapshot returns the current state of the room, e.g. to log it when an application detects
+// an unexpected state.
+func (r *Room) Snapshot() *RoomSnapshot {
+	r.lock.RLock()
+	s := &RoomSnapshot{
+		TakenAt:     time.Now(),
+		SID:         r.sid,
+		Name:        r.name,
+		Metadata:    r.metadata,
+		IsRecording: r.isRecordingLocked(),
+	}
+	if r.serverInfo != nil {
+		s.Server = &ServerSnapshot{
+			Version:  r.serverInfo.Version,
+			Region:   r.serverInfo.Region,
+			NodeID:   r.serverInfo.NodeId,
+			Protocol: r.serverInfo.Protocol,
+		}
+	}
+	r.lock.RUnlock()
+
+	s.ConnectionState = r.ConnectionState()
+	s.Connection = r.ConnectionDetails()
+	s.SignalRTT = r.SignalRTT()
+	s.LocalParticipant = newParticipantSnapshot(&r.LocalParticipant.baseParticipant)
+	for _, rp := range r.GetRemoteParticipants() {
+		s.RemoteParticipants = append(s.RemoteParticipants, newParticipantSnapshot(&rp.baseParticipant))
+	}
+	slices.SortFunc(s.RemoteParticipants, func(a, b ParticipantSnapshot) int {
+		return strings.Compare(a.Identity, b.Identity)
+	})
+	if budget := r.SubscriptionBudgetStatus(); budget.MaxBytes != 0 {
+		s.SubscriptionBudget = &budget
+	}
+	return s
+}
+
+func newParticipantSnapshot(p *baseParticipant) ParticipantSnapshot {
+	s := ParticipantSnapshot{
+		SID:        p.SID(),
+		Identity:   p.Identity(),
+		Name:       p.Name(),
+		Kind:       livekit.ParticipantInfo_Kind(p.Kind()).String(),
+		Metadata:   p.Metadata(),
+		Attributes: p.Attributes(),
+		IsSpeaking: p.IsSpeaking(),
+		AudioLevel: p.AudioLevel(),
+	}
+	for _, pub := range p.TrackPublications() {
+		s.Tracks = append(s.Tracks, newTrackSnapshot(pub))
+	}
+	slices.SortFunc(s.Tracks, func(a, b TrackSnapshot) int {
+		return strings.Compare(a.SID, b.SID)
+	})
+	return s
+}
+
+func newTrackSnapshot(pub TrackPublication) TrackSnapshot {
+	s := TrackSnapshot{
+		SID:        pub.SID(),
+		Name:       pub.Name(),
+		Kind:       pub.Kind(),
+		Source:     pub.Source().String(),
+		MimeType:   pub.MimeType(),
+		Muted:      pub.IsMuted(),
+		Subscribed: pub.IsSubscribed(),
+	}
+	if info := pub.TrackInfo(); info != nil {
+		s.Width, s.Height = info.Width, info.Height
+		s.Simulcast = info.Simulcast
+	}
+
+	remotePub, ok := pub.(*RemoteTrackPublication)
+	if !ok {
+		return s
+	}
+	remotePub.lock.RLock()
+	s.Disabled = remotePub.disabled
+	if remotePub.videoQuality != nil {
+		s.VideoQuality = remotePub.videoQuality.String()
+	}
+	if remotePub.videoWidth != nil && remotePub.videoHeight != nil {
+		s.VideoWidth, s.VideoHeight = *remotePub.videoWidth, *remotePub.videoHeight
+	}
+	if remotePub.priority != SubscriptionPriorityNormal {
+		s.Priority = remotePub.priority.String()
+	}
+	remotePub.lock.RUnlock()
+	return s
+}
+
+// LoadRoomSnapshot parses a snapshot serialized to JSON.
+func LoadRoomSnapshot(data []byte) (*RoomSnapshot, error) {
+	s := &RoomSnapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewRoomFromSnapshot creates a room that is not connected, holding the participants, remote track publications
+// and subscription settings of the snapshot, to reproduce a reported state in tests. Callbacks are invoked as if
+// the participants were already in the room when joining. Tracks of the local participant are not restored.
+func NewRoomFromSnapshot(s *RoomSnapshot, callback *RoomCallback) *Room {
+	r := NewRoom(callback)
+	r.lock.Lock()
+	r.name = s.Name
+	r.metadata = s.Metadata
+	r.activeRecording = s.IsRecording
+	if s.Server != nil {
+		r.serverInfo = &livekit.ServerInfo{
+			Version:  s.Server.Version,
+			Region:   s.Server.Region,
+			NodeId:   s.Server.NodeID,
+			Protocol: s.Server.Protocol,
+		}
+	}
+	r.lock.Unlock()
+	r.setSid(s.SID, false)
+
+	local := s.LocalParticipant.participantInfo()
+	local.Tracks = nil
+	r.LocalParticipant.updateInfo(local)
+
+	for _, ps := range s.RemoteParticipants {
+		rp := r.addRemoteParticipant(ps.participantInfo(), true)
+		for _, ts := range ps.Tracks {
+			if pub := rp.getPublication(ts.SID); pub != nil {
+				pub.restoreSnapshot(ts)
+			}
+		}
+		rp.events.release(nil)
+	}
+	return r
+}
+
+func (s *ParticipantSnapshot) participantInfo() *livekit.ParticipantInfo {
+	pi := &livekit.ParticipantInfo{
+		Sid:        s.SID,
+		Identity:   s.Identity,
+		Name:       s.Name,
+		Kind:       livekit.ParticipantInfo_Kind(livekit.ParticipantInfo_Kind_value[s.Kind]),
+		Metadata:   s.Metadata,
+		Attributes: maps.Clone(s.Attributes),
+		State:      livekit.ParticipantInfo_ACTIVE,
+	}
+	for _, ts := range s.Tracks {
+		ti := &livekit.TrackInfo{
+			Sid:       ts.SID,
+			Name:      ts.Name,
+			Type:      livekit.TrackType_AUDIO,
+			Source:    livekit.TrackSource(livekit.TrackSource_value[ts.Source]),
+			MimeType:  ts.MimeType,
+			Muted:     ts.Muted,
+			Width:     ts.Width,
+			Height:    ts.Height,
+			Simulcast: ts.Simulcast,
+		}
+		if ts.Kind == TrackKindVideo {
+			ti.Type = livekit.TrackType_VIDEO
+		}
+		pi.Tracks = append(pi.Tracks, ti)
+	}
+	return pi
+}
+
+// restoreSnapshot applies the subscription settings of a snapshot without sending them,
+// the track is not subscribed
+func (p *RemoteTrackPublication) restoreSnapshot(s TrackSnapshot) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.disabled = s.Disabled
+	if quality, ok := livekit.VideoQuality_value[s.VideoQuality]; ok {
+		q := livekit.VideoQuality(quality)
+		p.videoQuality = &q
+	}
+	if s.VideoWidth != 0 && s.VideoHeight != 0 {
+		width, height := s.VideoWidth, s.VideoHeight
+		p.videoWidth, p.videoHeight = &width, &height
+	}
+	switch s.Priority {
+	case SubscriptionPriorityLow.String():
+		p.priority = SubscriptionPriorityLow
+	case SubscriptionPriorityHigh.String():
+		p.priority = SubscriptionPriorityHigh
+	}
+}
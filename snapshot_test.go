@@ -0,0 +1,90 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomSnapshotRoundTrip(t *testing.T) {
+	reported := &RoomSnapshot{
+		TakenAt:         time.Now(),
+		SID:             "RM_room",
+		Name:            "room",
+		Metadata:        `{"topic":"support"}`,
+		IsRecording:     true,
+		ConnectionState: ConnectionStateDisconnected,
+		Server:          &ServerSnapshot{Version: "1.9.0", Region: "eu", NodeID: "ND_node", Protocol: 16},
+		LocalParticipant: ParticipantSnapshot{
+			SID:      "PA_agent",
+			Identity: "agent",
+			Kind:     "AGENT",
+		},
+		RemoteParticipants: []ParticipantSnapshot{
+			{
+				SID:        "PA_alice",
+				Identity:   "alice",
+				Name:       "Alice",
+				Kind:       "STANDARD",
+				Attributes: map[string]string{"role": "customer"},
+				Tracks: []TrackSnapshot{
+					{SID: "TR_audio", Name: "mic", Kind: TrackKindAudio, Source: "MICROPHONE", MimeType: "audio/opus", Priority: "high"},
+					{
+						SID: "TR_video", Name: "camera", Kind: TrackKindVideo, Source: "CAMERA", MimeType: "video/VP8",
+						Width: 1280, Height: 720, Simulcast: true, VideoQuality: "MEDIUM", VideoWidth: 640, VideoHeight: 360,
+					},
+				},
+			},
+			{SID: "PA_bob", Identity: "bob", Kind: "SIP", Tracks: []TrackSnapshot{
+				{SID: "TR_phone", Kind: TrackKindAudio, Source: "MICROPHONE", Muted: true, Disabled: true, Priority: "low"},
+			}},
+		},
+	}
+	data, err := json.Marshal(reported)
+	require.NoError(t, err)
+	loaded, err := LoadRoomSnapshot(data)
+	require.NoError(t, err)
+
+	var connected []string
+	room := NewRoomFromSnapshot(loaded, &RoomCallback{
+		OnParticipantConnected: func(rp *RemoteParticipant) {
+			connected = append(connected, rp.Identity())
+		},
+	})
+	require.Empty(t, connected, "participants were present when joining")
+	require.Equal(t, "room", room.Name())
+	require.True(t, room.IsRecording())
+	require.Equal(t, "eu", room.ServerInfo().Region)
+	require.Equal(t, ParticipantAgent, room.LocalParticipant.Kind())
+
+	alice := room.GetParticipantByIdentity("alice")
+	require.NotNil(t, alice)
+	require.Equal(t, "customer", alice.Attributes()["role"])
+	video := alice.getPublication("TR_video")
+	require.NotNil(t, video)
+	require.Equal(t, TrackKindVideo, video.Kind())
+	require.Equal(t, SubscriptionPriorityHigh, alice.getPublication("TR_audio").SubscriptionPriority())
+	require.False(t, room.GetParticipantByIdentity("bob").getPublication("TR_phone").IsEnabled())
+
+	snapshot := room.Snapshot()
+	require.Equal(t, ConnectionStateDisconnected, snapshot.ConnectionState)
+	require.Nil(t, snapshot.SubscriptionBudget)
+	snapshot.TakenAt = reported.TakenAt
+	require.Equal(t, reported, snapshot)
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+type StreamRejectReason string
+
+const (
+	StreamRejectTooLarge           StreamRejectReason = "too_large"
+	StreamRejectTopicNotAllowed    StreamRejectReason = "topic_not_allowed"
+	StreamRejectMimeTypeNotAllowed StreamRejectReason = "mime_type_not_allowed"
+	StreamRejectValidationFailed   StreamRejectReason = "validation_failed"
+)
+
+// StreamRejectedError is reported when an incoming data stream fails the checks of WithStreamGuard.
+// When a stream is rejected after its handler was called, reads from the stream return this error.
+type StreamRejectedError struct {
+	StreamID string
+	Topic    string
+	MimeType string
+	Reason   StreamRejectReason
+	// Err is the error returned by StreamGuard.Validate
+	Err error
+}
+
+func (e *StreamRejectedError) Error() string {
+	msg := fmt.Sprintf("data stream %s on topic %q rejected: %s", e.StreamID, e.Topic, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *StreamRejectedError) Unwrap() error {
+	return e.Err
+}
+
+func mimeTypeAllowed(allowed []string, mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok {
+			if strings.HasPrefix(mimeType, prefix+"/") {
+				return true
+			}
+		} else if a == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStreamHeader returns an error when the stream described by header must not be accepted
+func checkStreamHeader(guard signalling.StreamGuard, header *livekit.DataStream_Header, participantIdentity string) *StreamRejectedError {
+	reject := func(reason StreamRejectReason, err error) *StreamRejectedError {
+		return &StreamRejectedError{
+			StreamID: header.StreamId,
+			Topic:    header.Topic,
+			MimeType: header.MimeType,
+			Reason:   reason,
+			Err:      err,
+		}
+	}
+
+	if len(guard.AllowedTopics) > 0 && !slices.Contains(guard.AllowedTopics, header.Topic) {
+		return reject(StreamRejectTopicNotAllowed, nil)
+	}
+	if len(guard.AllowedMimeTypes) > 0 && !mimeTypeAllowed(guard.AllowedMimeTypes, header.MimeType) {
+		return reject(StreamRejectMimeTypeNotAllowed, nil)
+	}
+	if guard.MaxStreamBytes > 0 && header.TotalLength != nil && *header.TotalLength > guard.MaxStreamBytes {
+		return reject(StreamRejectTooLarge, nil)
+	}
+	if guard.Validate != nil {
+		if err := guard.Validate(header, participantIdentity); err != nil {
+			return reject(StreamRejectValidationFailed, err)
+		}
+	}
+	return nil
+}
+
+// checkStreamSize returns an error when a stream delivered more bytes than allowed
+func checkStreamSize(guard signalling.StreamGuard, info *baseStreamInfo, received uint64) *StreamRejectedError {
+	if guard.MaxStreamBytes == 0 || received <= guard.MaxStreamBytes {
+		return nil
+	}
+	return &StreamRejectedError{
+		StreamID: info.Id,
+		Topic:    info.Topic,
+		MimeType: info.MimeType,
+		Reason:   StreamRejectTooLarge,
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestCheckStreamHeader(t *testing.T) {
+	size := uint64(2048)
+	header := &livekit.DataStream_Header{
+		StreamId:    "stream",
+		Topic:       "files",
+		MimeType:    "image/png",
+		TotalLength: &size,
+	}
+
+	require.Nil(t, checkStreamHeader(signalling.StreamGuard{}, header, "p"))
+	require.Nil(t, checkStreamHeader(signalling.StreamGuard{
+		MaxStreamBytes:   4096,
+		AllowedTopics:    []string{"files"},
+		AllowedMimeTypes: []string{"image/*"},
+	}, header, "p"))
+
+	err := checkStreamHeader(signalling.StreamGuard{AllowedTopics: []string{"chat"}}, header, "p")
+	require.Equal(t, StreamRejectTopicNotAllowed, err.Reason)
+
+	err = checkStreamHeader(signalling.StreamGuard{AllowedMimeTypes: []string{"text/plain"}}, header, "p")
+	require.Equal(t, StreamRejectMimeTypeNotAllowed, err.Reason)
+
+	err = checkStreamHeader(signalling.StreamGuard{MaxStreamBytes: 1024}, header, "p")
+	require.Equal(t, StreamRejectTooLarge, err.Reason)
+
+	invalid := errors.New("invalid")
+	err = checkStreamHeader(signalling.StreamGuard{
+		Validate: func(header *livekit.DataStream_Header, participantIdentity string) error {
+			return invalid
+		},
+	}, header, "p")
+	require.Equal(t, StreamRejectValidationFailed, err.Reason)
+	require.ErrorIs(t, err, invalid)
+}
+
+func TestStreamReaderCloseWithError(t *testing.T) {
+	reader := NewByteStreamReader(ByteStreamInfo{baseStreamInfo: &baseStreamInfo{Id: "stream"}}, nil)
+	require.EqualValues(t, 3, reader.enqueue(&livekit.DataStream_Chunk{Content: []byte("abc")}))
+
+	rejected := &StreamRejectedError{StreamID: "stream", Reason: StreamRejectTooLarge}
+	reader.closeWithError(rejected)
+
+	_, err := reader.Read(make([]byte, 8))
+	require.ErrorIs(t, err, rejected)
+	require.Empty(t, reader.ReadAll())
+	require.Equal(t, rejected, reader.Err())
+}
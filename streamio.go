@@ -0,0 +1,111 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+// writeSync queues chunks and waits until they were sent
+func (w *baseStreamWriter[T]) writeSync(chunks [][]byte) error {
+	if w.closed.Load() {
+		return io.ErrClosedPipe
+	}
+
+	done := make(chan struct{})
+	onDone := func() { close(done) }
+	w.writeQueue <- writeTask{chunks: chunks, onDone: &onDone}
+	<-done
+	return nil
+}
+
+// WriteCloser returns the stream as io.WriteCloser, e.g. for io.Copy. Write blocks until the data was sent
+// and Close finalizes the stream.
+func (w *ByteStreamWriter) WriteCloser() io.WriteCloser {
+	return &byteStreamWriteCloser{w: w}
+}
+
+type byteStreamWriteCloser struct {
+	w *ByteStreamWriter
+}
+
+func (c *byteStreamWriteCloser) Write(p []byte) (int, error) {
+	// chunks reference p, which is why Write waits until they were sent
+	if err := c.w.writeSync(chunkBytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *byteStreamWriteCloser) Close() error {
+	c.w.Close()
+	return nil
+}
+
+// WriteCloser returns the stream as io.WriteCloser that appends the UTF-8 text written to it.
+// A character split across writes is sent with the next write or on Close.
+func (w *TextStreamWriter) WriteCloser() io.WriteCloser {
+	return &textStreamWriteCloser{w: w}
+}
+
+type textStreamWriteCloser struct {
+	w *TextStreamWriter
+
+	lock sync.Mutex
+	// bytes of an incomplete character at the end of the last write
+	pending []byte
+}
+
+func (c *textStreamWriteCloser) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	text, rest := splitIncompleteRune(append(c.pending, p...))
+	if len(text) != 0 {
+		if err := c.w.writeSync(chunkUtf8String(string(text))); err != nil {
+			return 0, err
+		}
+	}
+	c.pending = rest
+	return len(p), nil
+}
+
+func (c *textStreamWriteCloser) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.pending) != 0 {
+		// invalid UTF-8, sent as is
+		_ = c.w.writeSync(chunkUtf8String(string(c.pending)))
+		c.pending = nil
+	}
+	c.w.Close()
+	return nil
+}
+
+// splitIncompleteRune splits b before a UTF-8 encoded character that is cut off at its end
+func splitIncompleteRune(b []byte) ([]byte, []byte) {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i], b[i:]
+			}
+			break
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitIncompleteRune(t *testing.T) {
+	euro := []byte("€") // 3 bytes
+
+	text, rest := splitIncompleteRune([]byte("abc"))
+	require.Equal(t, "abc", string(text))
+	require.Empty(t, rest)
+
+	text, rest = splitIncompleteRune(append([]byte("a"), euro...))
+	require.Equal(t, "a€", string(text))
+	require.Empty(t, rest)
+
+	for cut := 1; cut < len(euro); cut++ {
+		text, rest = splitIncompleteRune(append([]byte("a"), euro[:cut]...))
+		require.Equal(t, "a", string(text))
+		require.Equal(t, euro[:cut], rest)
+	}
+}
+
+func TestStreamWriteCloserClosed(t *testing.T) {
+	w := &ByteStreamWriter{baseStreamWriter: &baseStreamWriter[[]byte]{}}
+	w.closed.Store(true)
+
+	n, err := w.WriteCloser().Write([]byte("data"))
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+	require.Zero(t, n)
+}
@@ -38,8 +38,10 @@ type ByteStreamInfo struct {
 //   - Timestamp is the timestamp of sending the stream
 //   - Size is the total size of the stream, if provided
 //   - Attributes are any additional attributes of the stream
+//   - Generated is true if the text was generated by an agent
 type TextStreamInfo struct {
 	*baseStreamInfo
+	Generated bool
 }
 
 const (
@@ -57,6 +59,7 @@ const (
 //   - OnProgress is a callback function that will be called when the stream is being written
 //   - Attachments is the list of file paths to attach to the stream, optional
 //   - AttachedStreamIds is the list of stream ids that are attached to this stream, mapped by index to attachments, optional, generated if not provided
+//   - Generated marks text generated by an agent, e.g. transcriptions or LLM responses
 type StreamTextOptions struct {
 	Topic                 string
 	DestinationIdentities []string
@@ -67,6 +70,7 @@ type StreamTextOptions struct {
 	OnProgress            func(progress float64)
 	Attachments           []string
 	AttachedStreamIds     []string
+	Generated             bool
 }
 
 // Options for publishing a byte stream
@@ -92,8 +96,9 @@ type StreamBytesOptions struct {
 // writeTask contains a list of chunks to be written to the stream
 // and a callback function that will be called when the data provided is written to the stream
 type writeTask struct {
-	chunks [][]byte
-	onDone *func()
+	chunks  [][]byte
+	onDone  *func()
+	replace bool
 }
 
 type baseStreamWriter[T any] struct {
@@ -107,6 +112,11 @@ type baseStreamWriter[T any] struct {
 	closed     atomic.Bool
 	lock       sync.Mutex
 
+	// chunks sent so far and their version, kept by text writers to support Replace
+	keepHistory bool
+	history     [][]byte
+	version     int32
+
 	writeQueue chan writeTask
 }
 
@@ -129,7 +139,11 @@ func newBaseStreamWriter[T any](engine *RTCEngine, header *protocol.DataStream_H
 // processes write queue asynchronously
 func (w *baseStreamWriter[T]) processWriteQueue() {
 	for task := range w.writeQueue {
-		w.writeStreamBytes(task.chunks, task.onDone)
+		if task.replace {
+			w.replaceStreamBytes(task.chunks, task.onDone)
+		} else {
+			w.writeStreamBytes(task.chunks, task.onDone)
+		}
 	}
 }
 
@@ -157,11 +171,21 @@ func (w *baseStreamWriter[T]) Write(data T, onDone *func()) {
 
 // Close the stream, this will send a stream trailer to notify the receiver that the stream is closed
 func (w *baseStreamWriter[T]) Close() {
+	w.closeWithTrailer("", nil)
+}
+
+// closeWithTrailer closes the stream with a trailer carrying reason and attributes,
+// which receivers merge into the stream info
+func (w *baseStreamWriter[T]) closeWithTrailer(reason string, attributes map[string]string) {
 	if !w.closed.Load() {
 		w.closed.Store(true)
 
 		w.lock.Lock()
-		w.engine.publishStreamTrailer(w.streamId, w.destinationIdentities)
+		w.engine.publishStreamTrailer(&protocol.DataStream_Trailer{
+			StreamId:   w.streamId,
+			Reason:     reason,
+			Attributes: attributes,
+		}, w.destinationIdentities)
 		w.lock.Unlock()
 	}
 }
@@ -180,8 +204,13 @@ func (w *baseStreamWriter[T]) writeStreamBytes(chunks [][]byte, onDone *func())
 			StreamId:   w.streamId,
 			Content:    chunk,
 			ChunkIndex: chunkIndex,
+			Version:    w.version,
 		}, w.destinationIdentities)
 
+		if w.keepHistory {
+			w.history = append(w.history, chunk)
+		}
+
 		if w.onProgress != nil && w.totalSize != nil {
 			progress := float64(len(chunk)) / float64(*w.totalSize)
 			w.onProgress(progress)
@@ -198,6 +227,40 @@ func (w *baseStreamWriter[T]) writeStreamBytes(chunks [][]byte, onDone *func())
 	}
 }
 
+// rewrites the content of the stream, chunks that changed are sent again with a new version
+// and chunks beyond the new content are sent empty
+func (w *baseStreamWriter[T]) replaceStreamBytes(chunks [][]byte, onDone *func()) {
+	w.lock.Lock()
+	w.version++
+
+	for i := 0; i < max(len(chunks), len(w.history)) && !w.closed.Load(); i++ {
+		var chunk []byte
+		if i < len(chunks) {
+			chunk = chunks[i]
+		}
+		if i < len(w.history) && bytes.Equal(w.history[i], chunk) {
+			continue
+		}
+
+		w.engine.waitForBufferStatusLow(protocol.DataPacket_RELIABLE)
+
+		w.engine.publishStreamChunk(&protocol.DataStream_Chunk{
+			StreamId:   w.streamId,
+			Content:    chunk,
+			ChunkIndex: uint64(i),
+			Version:    w.version,
+		}, w.destinationIdentities)
+	}
+
+	w.history = chunks
+	w.chunkIndex = uint64(len(chunks))
+	w.lock.Unlock()
+
+	if onDone != nil {
+		(*onDone)()
+	}
+}
+
 // TextStreamWriter is a writer type for text streams
 type TextStreamWriter struct {
 	*baseStreamWriter[string]
@@ -206,10 +269,30 @@ type TextStreamWriter struct {
 
 // create a new text stream writer
 func newTextStreamWriter(info TextStreamInfo, header *protocol.DataStream_Header, e *RTCEngine, destinationIdentities []string, onProgress func(progress float64)) *TextStreamWriter {
-	return &TextStreamWriter{
+	w := &TextStreamWriter{
 		baseStreamWriter: newBaseStreamWriter[string](e, header, info.Id, destinationIdentities, info.Size, onProgress),
 		Info:             info,
 	}
+	w.keepHistory = true
+	return w
+}
+
+// Append adds text to the end of the stream, e.g. the next tokens of an LLM response
+func (w *TextStreamWriter) Append(text string) {
+	w.Write(text, nil)
+}
+
+// Replace replaces all text written so far, only the chunks that changed are sent again.
+// Receivers see the new text through IncrementalTextReader, TextStreamReader only supports appended text.
+func (w *TextStreamWriter) Replace(text string) {
+	if w.closed.Load() {
+		return
+	}
+
+	w.writeQueue <- writeTask{
+		chunks:  chunkUtf8String(text),
+		replace: true,
+	}
 }
 
 // ByteStreamWriter is a writer type for byte streams
@@ -230,8 +313,15 @@ type baseStreamReader struct {
 	readBuffer    bytes.Buffer
 	totalByteSize *uint64
 	bytesReceived int
+	bytesEnqueued uint64
+	// identity of the participant sending the stream
+	senderIdentity string
+	// set for byte streams spooled to disk, see WithStreamSpooling
+	spool             *streamSpool
+	onReceiveProgress func(bytesReceived uint64)
 
 	closed atomic.Bool
+	err    error
 	lock   sync.Mutex
 	cond   *sync.Cond
 
@@ -249,16 +339,39 @@ func newBaseStreamReader(totalByteSize *uint64) *baseStreamReader {
 	return baseReader
 }
 
-// writes a chunk to the read buffer
-func (r *baseStreamReader) enqueue(chunk *protocol.DataStream_Chunk) {
+// writes a chunk to the read buffer, returns the number of bytes enqueued so far
+func (r *baseStreamReader) enqueue(chunk *protocol.DataStream_Chunk) uint64 {
 	if r.closed.Load() {
-		return
+		return 0
 	}
+	var err error
 	// write seems to handle growing the buffer if needed
 	r.lock.Lock()
-	r.readBuffer.Write(chunk.Content)
+	r.bytesEnqueued += uint64(len(chunk.Content))
+	enqueued := r.bytesEnqueued
+	switch {
+	case r.spool == nil:
+		r.readBuffer.Write(chunk.Content)
+	case r.spool.config.MaxStreamBytes > 0 && enqueued > r.spool.config.MaxStreamBytes:
+		err = ErrStreamQuotaExceeded
+	case r.spool.shouldSpool(r.readBuffer.Len(), len(chunk.Content)):
+		err = r.spool.write(chunk.Content)
+	default:
+		r.readBuffer.Write(chunk.Content)
+	}
+	onReceiveProgress := r.onReceiveProgress
 	r.cond.Broadcast()
 	r.lock.Unlock()
+
+	if err != nil {
+		logger.Warnw("aborting spooled data stream", err, "participant", r.senderIdentity)
+		r.closeWithError(err)
+		return enqueued
+	}
+	if onReceiveProgress != nil {
+		onReceiveProgress(enqueued)
+	}
+	return enqueued
 }
 
 // OnProgress sets the callback function that will be called when the stream is being read
@@ -277,18 +390,37 @@ func (r *baseStreamReader) maybeCallOnProgress(n int) {
 	}
 }
 
+// returns true when spooled data has not been read yet
+func (r *baseStreamReader) hasSpooledData() bool {
+	return r.spool != nil && r.spool.unread() > 0
+}
+
 // waits for a write to the stream
 func (r *baseStreamReader) waitForData() {
 	// if stream is closed, the methods will return io.EOF automatically
-	for r.readBuffer.Len() == 0 && !r.closed.Load() {
+	for r.readBuffer.Len() == 0 && !r.hasSpooledData() && !r.closed.Load() {
 		r.cond.Wait()
 	}
+	if r.readBuffer.Len() == 0 && r.hasSpooledData() {
+		if err := r.spool.fill(&r.readBuffer); err != nil {
+			r.abortLocked(err)
+		}
+	}
 }
 
 // handles the EOF error before the stream is closed
 func (r *baseStreamReader) handleEOFBeforeStreamClosed(err error) error {
 	if err == io.EOF {
+		if r.hasSpooledData() {
+			return nil
+		}
 		if r.closed.Load() {
+			if r.err != nil {
+				return r.err
+			}
+			if r.spool != nil {
+				r.spool.remove()
+			}
 			return io.EOF
 		} else {
 			return nil
@@ -338,6 +470,14 @@ func (r *baseStreamReader) ReadBytes(delim byte) ([]byte, error) {
 	return n, r.handleEOFBeforeStreamClosed(err)
 }
 
+// Err returns the error the stream was aborted with, e.g. a *StreamRejectedError, or nil
+func (r *baseStreamReader) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.err
+}
+
 func (r *baseStreamReader) close() {
 	if !r.closed.Load() {
 		r.closed.Store(true)
@@ -348,6 +488,25 @@ func (r *baseStreamReader) close() {
 	}
 }
 
+// aborts the stream, buffered data is discarded and reads return err
+func (r *baseStreamReader) closeWithError(err error) {
+	r.lock.Lock()
+	if !r.closed.Load() || r.err == nil {
+		r.abortLocked(err)
+	}
+	r.lock.Unlock()
+}
+
+func (r *baseStreamReader) abortLocked(err error) {
+	r.err = err
+	r.readBuffer.Reset()
+	if r.spool != nil {
+		r.spool.remove()
+	}
+	r.closed.Store(true)
+	r.cond.Broadcast()
+}
+
 type TextStreamReader struct {
 	*baseStreamReader
 	Info TextStreamInfo
@@ -417,7 +576,7 @@ func NewByteStreamReader(info ByteStreamInfo, totalChunkCount *uint64) *ByteStre
 }
 
 // ReadAll reads all the data from the stream and returns it as a byte slice.
-// This will block until the stream is closed.
+// This will block until the stream is closed. Spooled data is loaded into memory, use Read to process large streams.
 func (r *ByteStreamReader) ReadAll() []byte {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -428,11 +587,33 @@ func (r *ByteStreamReader) ReadAll() []byte {
 	}
 
 	// Now that the stream is closed, read all data
+	if r.hasSpooledData() {
+		if err := r.spool.drain(&r.readBuffer); err != nil {
+			r.abortLocked(err)
+		}
+	}
+	if r.spool != nil {
+		r.spool.remove()
+	}
 	n := r.readBuffer.Bytes()
 	r.maybeCallOnProgress(len(n))
 	return n
 }
 
+// OnReceiveProgress sets a callback called with the number of bytes received whenever a chunk arrives
+func (r *ByteStreamReader) OnReceiveProgress(onReceiveProgress func(bytesReceived uint64)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.onReceiveProgress = onReceiveProgress
+}
+
+// Discard stops receiving the stream and drops buffered data, temporary files are removed.
+// Reads return ErrStreamAborted afterwards.
+func (r *ByteStreamReader) Discard() {
+	r.closeWithError(ErrStreamAborted)
+}
+
 // TextStreamHandler is a function that will be called when a text stream is received.
 // It will be called with the stream reader and the participant identity that sent the stream.
 type TextStreamHandler func(reader *TextStreamReader, participantIdentity string)
@@ -0,0 +1,130 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+// size of reads from a spool file into the memory buffer
+const spoolReadSize = 64 * 1024
+
+// streamSpooler accounts the bytes spooled to disk by all streams of a room
+type streamSpooler struct {
+	lock  sync.Mutex
+	total uint64
+}
+
+func (s *streamSpooler) reserve(n, max uint64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if max > 0 && s.total+n > max {
+		return false
+	}
+	s.total += n
+	return true
+}
+
+func (s *streamSpooler) release(n uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.total -= min(n, s.total)
+}
+
+// streamSpool is the temporary file of a single byte stream, it is guarded by the lock of the reader
+type streamSpool struct {
+	spooler *streamSpooler
+	config  signalling.StreamSpoolConfig
+
+	file    *os.File
+	written int64
+	readOff int64
+}
+
+func newStreamSpool(spooler *streamSpooler, config signalling.StreamSpoolConfig) *streamSpool {
+	return &streamSpool{
+		spooler: spooler,
+		config:  config,
+	}
+}
+
+// shouldSpool returns true when data must be written to disk, once spooling started
+// all data goes to the file to preserve ordering
+func (s *streamSpool) shouldSpool(buffered, n int) bool {
+	return s.file != nil || uint64(buffered+n) > s.config.MemoryThreshold
+}
+
+func (s *streamSpool) write(data []byte) error {
+	if !s.spooler.reserve(uint64(len(data)), s.config.MaxTotalBytes) {
+		return ErrStreamQuotaExceeded
+	}
+	if s.file == nil {
+		f, err := os.CreateTemp(s.config.Dir, "lk-stream-*")
+		if err != nil {
+			s.spooler.release(uint64(len(data)))
+			return err
+		}
+		s.file = f
+	}
+	n, err := s.file.WriteAt(data, s.written)
+	s.written += int64(n)
+	if n < len(data) {
+		s.spooler.release(uint64(len(data) - n))
+	}
+	return err
+}
+
+func (s *streamSpool) unread() int64 {
+	return s.written - s.readOff
+}
+
+// fill moves up to spoolReadSize bytes from the file to buf
+func (s *streamSpool) fill(buf *bytes.Buffer) error {
+	return s.copyTo(buf, min(s.unread(), spoolReadSize))
+}
+
+// drain moves all unread bytes from the file to buf
+func (s *streamSpool) drain(buf *bytes.Buffer) error {
+	return s.copyTo(buf, s.unread())
+}
+
+func (s *streamSpool) copyTo(buf *bytes.Buffer, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	copied, err := io.Copy(buf, io.NewSectionReader(s.file, s.readOff, n))
+	s.readOff += copied
+	return err
+}
+
+// remove deletes the file and releases its quota
+func (s *streamSpool) remove() {
+	if s.file == nil {
+		return
+	}
+	_ = s.file.Close()
+	_ = os.Remove(s.file.Name())
+	s.spooler.release(uint64(s.written))
+	s.file = nil
+	s.written = 0
+	s.readOff = 0
+}
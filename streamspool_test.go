@@ -0,0 +1,90 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func newSpooledReader(spooler *streamSpooler, config signalling.StreamSpoolConfig) *ByteStreamReader {
+	reader := NewByteStreamReader(ByteStreamInfo{baseStreamInfo: &baseStreamInfo{Id: "stream"}}, nil)
+	reader.spool = newStreamSpool(spooler, config)
+	return reader
+}
+
+func spoolFiles(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	return len(entries)
+}
+
+func TestStreamSpool(t *testing.T) {
+	t.Run("spools beyond threshold", func(t *testing.T) {
+		dir := t.TempDir()
+		reader := newSpooledReader(&streamSpooler{}, signalling.StreamSpoolConfig{MemoryThreshold: 10, Dir: dir})
+
+		var expected []byte
+		for i := 0; i < 100; i++ {
+			chunk := bytes.Repeat([]byte{byte(i)}, 1000)
+			expected = append(expected, chunk...)
+			reader.enqueue(&livekit.DataStream_Chunk{Content: chunk})
+		}
+		reader.close()
+		require.Equal(t, 1, spoolFiles(t, dir))
+		require.LessOrEqual(t, reader.readBuffer.Len(), 10)
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, expected, data)
+		require.Equal(t, 0, spoolFiles(t, dir))
+	})
+
+	t.Run("stream quota", func(t *testing.T) {
+		dir := t.TempDir()
+		reader := newSpooledReader(&streamSpooler{}, signalling.StreamSpoolConfig{MemoryThreshold: 10, Dir: dir, MaxStreamBytes: 100})
+
+		reader.enqueue(&livekit.DataStream_Chunk{Content: make([]byte, 60)})
+		reader.enqueue(&livekit.DataStream_Chunk{Content: make([]byte, 60)})
+		_, err := reader.Read(make([]byte, 10))
+		require.ErrorIs(t, err, ErrStreamQuotaExceeded)
+		require.Equal(t, 0, spoolFiles(t, dir))
+	})
+
+	t.Run("global quota", func(t *testing.T) {
+		dir := t.TempDir()
+		spooler := &streamSpooler{}
+		config := signalling.StreamSpoolConfig{MemoryThreshold: 10, Dir: dir, MaxTotalBytes: 100}
+		first := newSpooledReader(spooler, config)
+		second := newSpooledReader(spooler, config)
+
+		first.enqueue(&livekit.DataStream_Chunk{Content: make([]byte, 80)})
+		second.enqueue(&livekit.DataStream_Chunk{Content: make([]byte, 80)})
+		require.ErrorIs(t, second.Err(), ErrStreamQuotaExceeded)
+		require.NoError(t, first.Err())
+
+		first.Discard()
+		require.ErrorIs(t, first.Err(), ErrStreamAborted)
+		require.Zero(t, spooler.total)
+		require.Equal(t, 0, spoolFiles(t, dir))
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"fmt"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// SubscriptionChange is a subscription change of a single remote track, see Room.UpdateSubscriptions
+type SubscriptionChange struct {
+	TrackSID  string
+	Subscribe bool
+}
+
+// UpdateSubscriptions applies many subscription changes at once. Changes are coalesced into
+// one UpdateSubscription message for the subscribed tracks and one for the unsubscribed tracks,
+// instead of a message per track as with RemoteTrackPublication.SetSubscribed.
+// When a track appears more than once, the last change wins.
+//
+// Nothing is sent if any of the tracks is unknown.
+func (r *Room) UpdateSubscriptions(changes []SubscriptionChange) error {
+	updates, pubs, err := buildSubscriptionUpdates(changes, r.findRemoteTrackPublication)
+	if err != nil {
+		return err
+	}
+
+	for pub, subscribed := range pubs {
+		if pub.settingsStore != nil {
+			pub.settingsStore.setSubscribed(pub.SID(), subscribed)
+		}
+		pub.stopSubscriptionRetry()
+	}
+	for _, update := range updates {
+		if err := r.engine.SendUpdateSubscription(update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Room) findRemoteTrackPublication(trackSID string) *RemoteTrackPublication {
+	for _, rp := range r.GetRemoteParticipants() {
+		if pub := rp.getPublication(trackSID); pub != nil {
+			return pub
+		}
+	}
+	return nil
+}
+
+// buildSubscriptionUpdates groups changes by direction and participant,
+// keeping the order in which tracks were first seen
+func buildSubscriptionUpdates(
+	changes []SubscriptionChange,
+	lookup func(trackSID string) *RemoteTrackPublication,
+) ([]*livekit.UpdateSubscription, map[*RemoteTrackPublication]bool, error) {
+	pubs := make(map[*RemoteTrackPublication]bool, len(changes))
+	var order []*RemoteTrackPublication
+	for _, change := range changes {
+		pub := lookup(change.TrackSID)
+		if pub == nil {
+			return nil, nil, fmt.Errorf("%w: %s", ErrCannotFindTrack, change.TrackSID)
+		}
+		if _, ok := pubs[pub]; !ok {
+			order = append(order, pub)
+		}
+		pubs[pub] = change.Subscribe
+	}
+
+	var updates []*livekit.UpdateSubscription
+	for _, subscribe := range []bool{true, false} {
+		update := &livekit.UpdateSubscription{Subscribe: subscribe}
+		byParticipant := make(map[string]*livekit.ParticipantTracks)
+		for _, pub := range order {
+			if pubs[pub] != subscribe {
+				continue
+			}
+			pt := byParticipant[pub.participantID]
+			if pt == nil {
+				pt = &livekit.ParticipantTracks{ParticipantSid: pub.participantID}
+				byParticipant[pub.participantID] = pt
+				update.ParticipantTracks = append(update.ParticipantTracks, pt)
+			}
+			pt.TrackSids = append(pt.TrackSids, pub.SID())
+		}
+		if len(update.ParticipantTracks) != 0 {
+			updates = append(updates, update)
+		}
+	}
+	return updates, pubs, nil
+}
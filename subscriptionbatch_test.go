@@ -0,0 +1,66 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestBuildSubscriptionUpdates(t *testing.T) {
+	pubs := make(map[string]*RemoteTrackPublication)
+	for _, track := range []struct{ participant, sid string }{
+		{"PA_a", "TR_a1"}, {"PA_a", "TR_a2"}, {"PA_b", "TR_b1"}, {"PA_b", "TR_b2"},
+	} {
+		pub := &RemoteTrackPublication{participantID: track.participant}
+		pub.updateInfo(&livekit.TrackInfo{Sid: track.sid})
+		pubs[track.sid] = pub
+	}
+	lookup := func(sid string) *RemoteTrackPublication { return pubs[sid] }
+
+	updates, changed, err := buildSubscriptionUpdates([]SubscriptionChange{
+		{TrackSID: "TR_b1", Subscribe: true},
+		{TrackSID: "TR_a1", Subscribe: true},
+		{TrackSID: "TR_a2", Subscribe: false},
+		{TrackSID: "TR_b2", Subscribe: true},
+		// last change wins
+		{TrackSID: "TR_a2", Subscribe: true},
+		{TrackSID: "TR_b2", Subscribe: false},
+	}, lookup)
+	require.NoError(t, err)
+	require.Len(t, changed, 4)
+	require.Len(t, updates, 2)
+
+	require.True(t, updates[0].Subscribe)
+	require.Len(t, updates[0].ParticipantTracks, 2)
+	require.Equal(t, "PA_b", updates[0].ParticipantTracks[0].ParticipantSid)
+	require.Equal(t, []string{"TR_b1"}, updates[0].ParticipantTracks[0].TrackSids)
+	require.Equal(t, "PA_a", updates[0].ParticipantTracks[1].ParticipantSid)
+	require.Equal(t, []string{"TR_a1", "TR_a2"}, updates[0].ParticipantTracks[1].TrackSids)
+
+	require.False(t, updates[1].Subscribe)
+	require.Len(t, updates[1].ParticipantTracks, 1)
+	require.Equal(t, []string{"TR_b2"}, updates[1].ParticipantTracks[0].TrackSids)
+
+	_, _, err = buildSubscriptionUpdates([]SubscriptionChange{{TrackSID: "TR_missing"}}, lookup)
+	require.ErrorIs(t, err, ErrCannotFindTrack)
+
+	updates, _, err = buildSubscriptionUpdates(nil, lookup)
+	require.NoError(t, err)
+	require.Empty(t, updates)
+}
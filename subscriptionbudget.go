@@ -0,0 +1,347 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	defaultBudgetDowngradeAt = 0.8
+	defaultBudgetPauseAt     = 0.95
+	defaultBudgetInterval    = time.Second
+)
+
+// SubscriptionPriority decides which subscriptions keep receiving media when the room
+// approaches its subscription budget, see WithSubscriptionBudget
+type SubscriptionPriority int
+
+const (
+	SubscriptionPriorityNormal SubscriptionPriority = iota
+	// paused first when approaching the budget
+	SubscriptionPriorityLow
+	// never downgraded or paused by the budget
+	SubscriptionPriorityHigh
+)
+
+func (p SubscriptionPriority) String() string {
+	switch p {
+	case SubscriptionPriorityLow:
+		return "low"
+	case SubscriptionPriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// SubscriptionBudgetState is the stage of a room's subscription budget
+type SubscriptionBudgetState int
+
+const (
+	// below DowngradeAt, subscriptions are left as configured
+	SubscriptionBudgetNormal SubscriptionBudgetState = iota
+	// video below high priority is received in low quality
+	SubscriptionBudgetDowngraded
+	// in addition low priority subscriptions are paused
+	SubscriptionBudgetPaused
+	// the budget is used up, all but high priority subscriptions are paused
+	SubscriptionBudgetExhausted
+)
+
+func (s SubscriptionBudgetState) String() string {
+	switch s {
+	case SubscriptionBudgetDowngraded:
+		return "downgraded"
+	case SubscriptionBudgetPaused:
+		return "paused"
+	case SubscriptionBudgetExhausted:
+		return "exhausted"
+	default:
+		return "normal"
+	}
+}
+
+// SubscriptionBudgetStatus reports the usage of the subscription budget
+type SubscriptionBudgetStatus struct {
+	State SubscriptionBudgetState
+	// media bytes received since joining or the last ResetSubscriptionBudget
+	ReceivedBytes uint64
+	MaxBytes      uint64
+}
+
+// budgetAction is applied by the budget on top of the settings of a subscription
+type budgetAction int
+
+const (
+	budgetActionNone budgetAction = iota
+	budgetActionDowngrade
+	budgetActionPause
+)
+
+func (a budgetAction) apply(settings *livekit.UpdateTrackSettings) {
+	switch a {
+	case budgetActionDowngrade:
+		// dimensions take precedence over quality on the server
+		settings.Width, settings.Height = 0, 0
+		settings.Quality = livekit.VideoQuality_LOW
+	case budgetActionPause:
+		settings.Disabled = true
+	}
+}
+
+func (s SubscriptionBudgetState) action(priority SubscriptionPriority, kind TrackKind) budgetAction {
+	if priority == SubscriptionPriorityHigh {
+		return budgetActionNone
+	}
+	switch {
+	case s == SubscriptionBudgetExhausted,
+		s == SubscriptionBudgetPaused && priority == SubscriptionPriorityLow:
+		return budgetActionPause
+	case s >= SubscriptionBudgetDowngraded && kind == TrackKindVideo:
+		return budgetActionDowngrade
+	default:
+		return budgetActionNone
+	}
+}
+
+// SetSubscriptionPriority sets the priority of this subscription for WithSubscriptionBudget, normal by default.
+func (p *RemoteTrackPublication) SetSubscriptionPriority(priority SubscriptionPriority) {
+	p.lock.Lock()
+	p.priority = priority
+	p.lock.Unlock()
+}
+
+// SubscriptionPriority returns the priority set with SetSubscriptionPriority.
+func (p *RemoteTrackPublication) SubscriptionPriority() SubscriptionPriority {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.priority
+}
+
+// setBudgetAction updates the settings sent to the server when the action of the budget changed
+func (p *RemoteTrackPublication) setBudgetAction(state SubscriptionBudgetState) {
+	p.lock.Lock()
+	action := state.action(p.priority, p.Kind())
+	changed := action != p.budgetAction
+	p.budgetAction = action
+	p.lock.Unlock()
+
+	if changed {
+		p.updateSettings()
+	}
+}
+
+// byteCounter is implemented by sdkinterceptor.BandwidthMonitor
+type byteCounter interface {
+	ReceivedBytes() uint64
+}
+
+// subscriptionBudgetWatcher accumulates the bytes received by the transports of a room.
+// Counters of transports replaced by a reconnect are kept.
+type subscriptionBudgetWatcher struct {
+	lock     sync.Mutex
+	budget   SubscriptionBudget
+	received uint64
+	counted  map[byteCounter]uint64
+	state    SubscriptionBudgetState
+	stop     chan struct{}
+}
+
+func newSubscriptionBudgetWatcher() *subscriptionBudgetWatcher {
+	return &subscriptionBudgetWatcher{
+		counted: make(map[byteCounter]uint64),
+	}
+}
+
+func budgetWithDefaults(budget SubscriptionBudget) SubscriptionBudget {
+	if budget.DowngradeAt <= 0 {
+		budget.DowngradeAt = defaultBudgetDowngradeAt
+	}
+	if budget.PauseAt <= 0 {
+		budget.PauseAt = defaultBudgetPauseAt
+	}
+	if budget.Interval <= 0 {
+		budget.Interval = defaultBudgetInterval
+	}
+	return budget
+}
+
+func (w *subscriptionBudgetWatcher) start(budget SubscriptionBudget) (chan struct{}, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.stop != nil {
+		return nil, false
+	}
+	w.budget = budget
+	w.stop = make(chan struct{})
+	return w.stop, true
+}
+
+func (w *subscriptionBudgetWatcher) close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+	w.budget = SubscriptionBudget{}
+	w.received = 0
+	w.state = SubscriptionBudgetNormal
+	clear(w.counted)
+}
+
+// update adds the bytes received by counters since the last update, returns the status and whether the state changed
+func (w *subscriptionBudgetWatcher) update(counters ...byteCounter) (SubscriptionBudgetStatus, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	counted := make(map[byteCounter]uint64, len(counters))
+	for _, m := range counters {
+		if _, ok := counted[m]; ok {
+			continue
+		}
+		received := m.ReceivedBytes()
+		w.received += received - w.counted[m]
+		counted[m] = received
+	}
+	w.counted = counted
+
+	state := w.stateLocked()
+	changed := state != w.state
+	w.state = state
+	return w.statusLocked(), changed
+}
+
+func (w *subscriptionBudgetWatcher) stateLocked() SubscriptionBudgetState {
+	if w.budget.MaxBytes == 0 {
+		return SubscriptionBudgetNormal
+	}
+	used := float64(w.received) / float64(w.budget.MaxBytes)
+	switch {
+	case w.received >= w.budget.MaxBytes:
+		return SubscriptionBudgetExhausted
+	case used >= w.budget.PauseAt:
+		return SubscriptionBudgetPaused
+	case used >= w.budget.DowngradeAt:
+		return SubscriptionBudgetDowngraded
+	default:
+		return SubscriptionBudgetNormal
+	}
+}
+
+func (w *subscriptionBudgetWatcher) statusLocked() SubscriptionBudgetStatus {
+	return SubscriptionBudgetStatus{
+		State:         w.state,
+		ReceivedBytes: w.received,
+		MaxBytes:      w.budget.MaxBytes,
+	}
+}
+
+func (w *subscriptionBudgetWatcher) status() SubscriptionBudgetStatus {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.statusLocked()
+}
+
+// reset starts counting from zero, returns the status and whether the state changed
+func (w *subscriptionBudgetWatcher) reset() (SubscriptionBudgetStatus, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.received = 0
+	changed := w.state != SubscriptionBudgetNormal
+	w.state = SubscriptionBudgetNormal
+	return w.statusLocked(), changed
+}
+
+// receiveCounters returns the received byte counters of the transports, the same transport
+// is returned twice with a single peer connection
+func (e *RTCEngine) receiveCounters() []byteCounter {
+	var monitors []byteCounter
+	if publisher, ok := e.Publisher(); ok {
+		monitors = append(monitors, publisher.BandwidthMonitor())
+	}
+	if subscriber, ok := e.Subscriber(); ok {
+		monitors = append(monitors, subscriber.BandwidthMonitor())
+	}
+	return monitors
+}
+
+func (r *Room) startSubscriptionBudget() {
+	budget := budgetWithDefaults(r.engine.connParams.SubscriptionBudget)
+	if budget.MaxBytes == 0 {
+		return
+	}
+	stop, ok := r.subscriptionBudget.start(budget)
+	if !ok {
+		return
+	}
+
+	r.engine.goroutines.Go("subscription-budget", func() {
+		ticker := time.NewTicker(budget.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			status, changed := r.subscriptionBudget.update(r.engine.receiveCounters()...)
+			if changed {
+				r.log.Infow("subscription budget changed",
+					"state", status.State,
+					"receivedBytes", status.ReceivedBytes,
+					"maxBytes", status.MaxBytes,
+				)
+				go r.callback.OnSubscriptionBudgetChanged(status)
+			}
+			// also applies the state to tracks subscribed since the last tick
+			r.applySubscriptionBudget(status.State)
+		}
+	})
+}
+
+func (r *Room) applySubscriptionBudget(state SubscriptionBudgetState) {
+	for _, rp := range r.GetRemoteParticipants() {
+		for _, pub := range rp.TrackPublications() {
+			if rpub, ok := pub.(*RemoteTrackPublication); ok {
+				rpub.setBudgetAction(state)
+			}
+		}
+	}
+}
+
+// SubscriptionBudgetStatus returns the usage of the budget set with WithSubscriptionBudget.
+func (r *Room) SubscriptionBudgetStatus() SubscriptionBudgetStatus {
+	return r.subscriptionBudget.status()
+}
+
+// ResetSubscriptionBudget starts counting received bytes from zero, e.g. at the start of a billing period,
+// and restores subscriptions that were downgraded or paused by the budget.
+func (r *Room) ResetSubscriptionBudget() {
+	status, changed := r.subscriptionBudget.reset()
+	if changed {
+		go r.callback.OnSubscriptionBudgetChanged(status)
+	}
+	r.applySubscriptionBudget(status.State)
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type fakeByteCounter struct {
+	received uint64
+}
+
+func (c *fakeByteCounter) ReceivedBytes() uint64 {
+	return c.received
+}
+
+func TestSubscriptionBudgetWatcher(t *testing.T) {
+	w := newSubscriptionBudgetWatcher()
+	_, ok := w.start(budgetWithDefaults(SubscriptionBudget{MaxBytes: 1000}))
+	require.True(t, ok)
+	_, ok = w.start(budgetWithDefaults(SubscriptionBudget{MaxBytes: 1000}))
+	require.False(t, ok)
+
+	publisher, subscriber := &fakeByteCounter{}, &fakeByteCounter{}
+	subscriber.received = 500
+	status, changed := w.update(publisher, subscriber)
+	require.False(t, changed)
+	require.Equal(t, SubscriptionBudgetStatus{State: SubscriptionBudgetNormal, ReceivedBytes: 500, MaxBytes: 1000}, status)
+
+	// a single peer connection is counted once
+	subscriber.received = 800
+	status, changed = w.update(subscriber, subscriber)
+	require.True(t, changed)
+	require.Equal(t, SubscriptionBudgetDowngraded, status.State)
+	require.EqualValues(t, 800, status.ReceivedBytes)
+
+	// bytes of a replaced transport are kept
+	reconnected := &fakeByteCounter{received: 160}
+	status, changed = w.update(reconnected)
+	require.True(t, changed)
+	require.Equal(t, SubscriptionBudgetPaused, status.State)
+	require.EqualValues(t, 960, status.ReceivedBytes)
+
+	reconnected.received = 240
+	status, changed = w.update(reconnected)
+	require.True(t, changed)
+	require.Equal(t, SubscriptionBudgetExhausted, status.State)
+
+	status, changed = w.update(reconnected)
+	require.False(t, changed)
+	require.Equal(t, SubscriptionBudgetExhausted, status.State)
+
+	status, changed = w.reset()
+	require.True(t, changed)
+	require.Equal(t, SubscriptionBudgetNormal, status.State)
+	require.Zero(t, status.ReceivedBytes)
+
+	reconnected.received = 300
+	status, _ = w.update(reconnected)
+	require.EqualValues(t, 60, status.ReceivedBytes)
+	require.Equal(t, status, w.status())
+
+	w.close()
+	require.Equal(t, SubscriptionBudgetStatus{}, w.status())
+}
+
+func TestSubscriptionBudgetAction(t *testing.T) {
+	cases := []struct {
+		state    SubscriptionBudgetState
+		priority SubscriptionPriority
+		kind     TrackKind
+		expected budgetAction
+	}{
+		{SubscriptionBudgetNormal, SubscriptionPriorityLow, TrackKindVideo, budgetActionNone},
+		{SubscriptionBudgetDowngraded, SubscriptionPriorityNormal, TrackKindVideo, budgetActionDowngrade},
+		{SubscriptionBudgetDowngraded, SubscriptionPriorityNormal, TrackKindAudio, budgetActionNone},
+		{SubscriptionBudgetDowngraded, SubscriptionPriorityHigh, TrackKindVideo, budgetActionNone},
+		{SubscriptionBudgetPaused, SubscriptionPriorityLow, TrackKindAudio, budgetActionPause},
+		{SubscriptionBudgetPaused, SubscriptionPriorityNormal, TrackKindVideo, budgetActionDowngrade},
+		{SubscriptionBudgetExhausted, SubscriptionPriorityNormal, TrackKindAudio, budgetActionPause},
+		{SubscriptionBudgetExhausted, SubscriptionPriorityHigh, TrackKindVideo, budgetActionNone},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.expected, c.state.action(c.priority, c.kind), "%s %s %s", c.state, c.priority, c.kind)
+	}
+}
+
+func TestSubscriptionBudgetTrackSettings(t *testing.T) {
+	pub := &RemoteTrackPublication{}
+	pub.updateInfo(&livekit.TrackInfo{Sid: "TR_video", Type: livekit.TrackType_VIDEO})
+	width, height := uint32(1280), uint32(720)
+	pub.videoWidth, pub.videoHeight = &width, &height
+
+	pub.budgetAction = budgetActionDowngrade
+	settings := pub.trackSettings()
+	require.Equal(t, livekit.VideoQuality_LOW, settings.Quality)
+	require.Zero(t, settings.Width)
+	require.False(t, settings.Disabled)
+
+	pub.budgetAction = budgetActionPause
+	require.True(t, pub.trackSettings().Disabled)
+
+	// settings of the application are restored without the budget action
+	pub.budgetAction = budgetActionNone
+	settings = pub.trackSettings()
+	require.False(t, settings.Disabled)
+	require.Equal(t, width, settings.Width)
+	require.Equal(t, livekit.VideoQuality_HIGH, settings.Quality)
+}
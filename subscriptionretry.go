@@ -0,0 +1,126 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"errors"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	subscriptionRetryInitialBackoff = 500 * time.Millisecond
+	subscriptionRetryMaxBackoff     = 8 * time.Second
+	maxSubscriptionRetries          = 5
+)
+
+// SubscriptionError is reported by the server when it could not subscribe to a track
+type SubscriptionError struct {
+	Code livekit.SubscriptionError
+}
+
+func (e *SubscriptionError) Error() string {
+	return "track subscription failed: " + e.Code.String()
+}
+
+// Temporary returns true for errors that may succeed on retry
+func (e *SubscriptionError) Temporary() bool {
+	return e.Code == livekit.SubscriptionError_SE_UNKNOWN
+}
+
+// scheduleSubscriptionRetry re-sends the subscription after a backoff,
+// returns false when the error is permanent or retries are exhausted
+func (p *RemoteTrackPublication) scheduleSubscriptionRetry(err error) (time.Duration, bool) {
+	var subErr *SubscriptionError
+	if errors.As(err, &subErr) && !subErr.Temporary() {
+		return 0, false
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.subscriptionRetries >= maxSubscriptionRetries {
+		p.subscriptionRetries = 0
+		return 0, false
+	}
+	backoff := min(subscriptionRetryInitialBackoff<<p.subscriptionRetries, subscriptionRetryMaxBackoff)
+	p.subscriptionRetries++
+
+	if p.subscriptionRetryTimer != nil {
+		p.subscriptionRetryTimer.Stop()
+	}
+	p.subscriptionRetryTimer = time.AfterFunc(backoff, func() {
+		if !p.wantsSubscription() {
+			return
+		}
+		if err := p.sendUpdateSubscription(true); err != nil {
+			p.engine.log.Warnw("could not retry track subscription", err, "trackID", p.SID())
+		}
+	})
+	return backoff, true
+}
+
+// stopSubscriptionRetry cancels a pending retry and resets the attempts
+func (p *RemoteTrackPublication) stopSubscriptionRetry() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.subscriptionRetryTimer != nil {
+		p.subscriptionRetryTimer.Stop()
+		p.subscriptionRetryTimer = nil
+	}
+	p.subscriptionRetries = 0
+}
+
+// wantsSubscription returns true if the track is not subscribed yet and was not unsubscribed explicitly
+func (p *RemoteTrackPublication) wantsSubscription() bool {
+	if p.TrackRemote() != nil {
+		return false
+	}
+	if p.settingsStore != nil {
+		if ts, ok := p.settingsStore.get(p.SID()); ok && ts.subscribed != nil {
+			return *ts.subscribed
+		}
+	}
+	return true
+}
+
+func (p *RemoteParticipant) handleSubscriptionError(pub *RemoteTrackPublication, err error) {
+	if backoff, ok := pub.scheduleSubscriptionRetry(err); ok {
+		p.engine.log.Infow(
+			"retrying track subscription",
+			"participant", p.Identity(),
+			"trackID", pub.SID(),
+			"backoff", backoff,
+			"error", err,
+		)
+		return
+	}
+
+	p.engine.log.Warnw(
+		"track subscription failed", err,
+		"participant", p.Identity(),
+		"trackID", pub.SID(),
+	)
+	p.notifySubscriptionFailed(pub.SID(), err)
+}
+
+func (p *RemoteParticipant) notifySubscriptionFailed(trackSID string, err error) {
+	p.Callback.OnTrackSubscriptionFailed(trackSID, p)
+	p.roomCallback.OnTrackSubscriptionFailed(trackSID, p)
+	p.Callback.OnTrackSubscriptionFailedWithError(trackSID, err, p)
+	p.roomCallback.OnTrackSubscriptionFailedWithError(trackSID, err, p)
+}
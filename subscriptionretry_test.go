@@ -0,0 +1,133 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+// subscriptionSignalTransport records the subscription requests sent to the server
+type subscriptionSignalTransport struct {
+	signalling.SignalTransport
+
+	lock          sync.Mutex
+	subscriptions []*livekit.UpdateSubscription
+}
+
+func (s *subscriptionSignalTransport) SendMessage(msg proto.Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if sub := msg.(*livekit.SignalRequest).GetSubscription(); sub != nil {
+		s.subscriptions = append(s.subscriptions, sub)
+	}
+	return nil
+}
+
+func (s *subscriptionSignalTransport) sent() []*livekit.UpdateSubscription {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*livekit.UpdateSubscription{}, s.subscriptions...)
+}
+
+func TestSubscriptionRetryBackoff(t *testing.T) {
+	pub := &RemoteTrackPublication{}
+	pub.engine = &RTCEngine{log: logger}
+	defer pub.stopSubscriptionRetry()
+
+	temporary := &SubscriptionError{Code: livekit.SubscriptionError_SE_UNKNOWN}
+	for _, expected := range []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+	} {
+		backoff, ok := pub.scheduleSubscriptionRetry(temporary)
+		require.True(t, ok)
+		require.Equal(t, expected, backoff)
+	}
+	// exhausted after 5 retries, the next failure starts over
+	_, ok := pub.scheduleSubscriptionRetry(temporary)
+	require.False(t, ok)
+	backoff, ok := pub.scheduleSubscriptionRetry(temporary)
+	require.True(t, ok)
+	require.Equal(t, 500*time.Millisecond, backoff)
+}
+
+func TestSubscriptionErrorTemporary(t *testing.T) {
+	for code, name := range livekit.SubscriptionError_name {
+		err := &SubscriptionError{Code: livekit.SubscriptionError(code)}
+		require.Equal(t, err.Code == livekit.SubscriptionError_SE_UNKNOWN, err.Temporary(), name)
+
+		pub := &RemoteTrackPublication{}
+		pub.engine = &RTCEngine{log: logger}
+		_, ok := pub.scheduleSubscriptionRetry(err)
+		require.Equal(t, err.Temporary(), ok, name)
+		pub.stopSubscriptionRetry()
+	}
+}
+
+func TestSubscriptionResponseRetries(t *testing.T) {
+	var (
+		lock   sync.Mutex
+		failed []error
+	)
+	cb := NewRoomCallback()
+	cb.OnTrackSubscriptionFailedWithError = func(sid string, err error, rp *RemoteParticipant) {
+		lock.Lock()
+		defer lock.Unlock()
+		failed = append(failed, err)
+	}
+	room := NewRoom(cb)
+	transport := &subscriptionSignalTransport{}
+	room.engine.signalTransport = transport
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{{
+		Sid:      "PA_alice",
+		Identity: "alice",
+		State:    livekit.ParticipantInfo_ACTIVE,
+		Tracks:   []*livekit.TrackInfo{{Sid: "TR_video", Type: livekit.TrackType_VIDEO}},
+	}})
+	defer room.GetParticipantByIdentity("alice").unpublishAllTracks()
+
+	handle := func(code livekit.SubscriptionError) {
+		require.NoError(t, room.engine.signalHandler.HandleMessage(&livekit.SignalResponse{
+			Message: &livekit.SignalResponse_SubscriptionResponse{
+				SubscriptionResponse: &livekit.SubscriptionResponse{TrackSid: "TR_video", Err: code},
+			},
+		}))
+	}
+
+	// a temporary error subscribes again after the backoff
+	handle(livekit.SubscriptionError_SE_UNKNOWN)
+	require.Eventually(t, func() bool {
+		return len(transport.sent()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	sub := transport.sent()[0]
+	require.True(t, sub.Subscribe)
+	require.Equal(t, []string{"TR_video"}, sub.ParticipantTracks[0].TrackSids)
+
+	// a permanent error is reported without retrying
+	handle(livekit.SubscriptionError_SE_TRACK_NOTFOUND)
+	lock.Lock()
+	require.Len(t, failed, 1)
+	require.Equal(t, livekit.SubscriptionError_SE_TRACK_NOTFOUND, failed[0].(*SubscriptionError).Code)
+	lock.Unlock()
+	require.Len(t, transport.sent(), 1)
+}
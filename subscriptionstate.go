@@ -0,0 +1,177 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// remoteTrackSettings holds the settings applied to a remote track publication by the application
+type remoteTrackSettings struct {
+	// nil when subscription was never changed explicitly
+	subscribed   *bool
+	disabled     bool
+	videoWidth   *uint32
+	videoHeight  *uint32
+	videoQuality *livekit.VideoQuality
+	// see RemoteTrackPublication.SetBufferConfig, applied to the receiver whenever it is recreated
+	bufferConfig *SubscriptionBufferConfig
+}
+
+// subscriptionStateStore remembers subscription settings per remote track,
+// so that they can be re-applied after a full reconnect
+type subscriptionStateStore struct {
+	lock     sync.Mutex
+	settings map[string]*remoteTrackSettings // track SID -> settings
+
+	// called after settings were changed by the application, see WithSessionStore
+	onChange func()
+}
+
+func newSubscriptionStateStore() *subscriptionStateStore {
+	return &subscriptionStateStore{
+		settings: make(map[string]*remoteTrackSettings),
+	}
+}
+
+func (s *subscriptionStateStore) getOrCreateLocked(trackSID string) *remoteTrackSettings {
+	ts := s.settings[trackSID]
+	if ts == nil {
+		ts = &remoteTrackSettings{}
+		s.settings[trackSID] = ts
+	}
+	return ts
+}
+
+func (s *subscriptionStateStore) setSubscribed(trackSID string, subscribed bool) {
+	s.lock.Lock()
+
+	s.getOrCreateLocked(trackSID).subscribed = &subscribed
+	s.lock.Unlock()
+
+	s.changed()
+}
+
+func (s *subscriptionStateStore) setTrackSettings(trackSID string, settings remoteTrackSettings) {
+	s.lock.Lock()
+	ts := s.getOrCreateLocked(trackSID)
+	ts.disabled = settings.disabled
+	ts.videoWidth = settings.videoWidth
+	ts.videoHeight = settings.videoHeight
+	ts.videoQuality = settings.videoQuality
+	s.lock.Unlock()
+
+	s.changed()
+}
+
+func (s *subscriptionStateStore) setBufferConfig(trackSID string, config SubscriptionBufferConfig) {
+	s.lock.Lock()
+	s.getOrCreateLocked(trackSID).bufferConfig = &config
+	s.lock.Unlock()
+
+	s.changed()
+}
+
+func (s *subscriptionStateStore) get(trackSID string) (remoteTrackSettings, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ts, ok := s.settings[trackSID]
+	if !ok {
+		return remoteTrackSettings{}, false
+	}
+	return *ts, true
+}
+
+func (s *subscriptionStateStore) remove(trackSID string) {
+	s.lock.Lock()
+	_, ok := s.settings[trackSID]
+	delete(s.settings, trackSID)
+	s.lock.Unlock()
+
+	if ok {
+		s.changed()
+	}
+}
+
+// retain drops settings of tracks that are not in the given set
+func (s *subscriptionStateStore) retain(trackSIDs map[string]struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for sid := range s.settings {
+		if _, ok := trackSIDs[sid]; !ok {
+			delete(s.settings, sid)
+		}
+	}
+}
+
+func (s *subscriptionStateStore) clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	clear(s.settings)
+}
+
+func (s *subscriptionStateStore) changed() {
+	if s.onChange != nil {
+		s.onChange()
+	}
+}
+
+// snapshot returns the stored settings to persist, ordered by track SID
+func (s *subscriptionStateStore) snapshot() []TrackSubscriptionState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	states := make([]TrackSubscriptionState, 0, len(s.settings))
+	for sid, ts := range s.settings {
+		states = append(states, TrackSubscriptionState{
+			TrackSID:     sid,
+			Subscribed:   ts.subscribed,
+			Disabled:     ts.disabled,
+			VideoWidth:   ts.videoWidth,
+			VideoHeight:  ts.videoHeight,
+			VideoQuality: ts.videoQuality,
+			BufferConfig: ts.bufferConfig,
+		})
+	}
+	slices.SortFunc(states, func(a, b TrackSubscriptionState) int {
+		return strings.Compare(a.TrackSID, b.TrackSID)
+	})
+	return states
+}
+
+// restore replaces the stored settings with persisted ones
+func (s *subscriptionStateStore) restore(states []TrackSubscriptionState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	clear(s.settings)
+	for _, state := range states {
+		s.settings[state.TrackSID] = &remoteTrackSettings{
+			subscribed:   state.Subscribed,
+			disabled:     state.Disabled,
+			videoWidth:   state.VideoWidth,
+			videoHeight:  state.VideoHeight,
+			videoQuality: state.VideoQuality,
+			bufferConfig: state.BufferConfig,
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"strings"
+	"sync"
+
+	protocol "github.com/livekit/protocol/livekit"
+)
+
+// Topics and attributes of text streams used by LiveKit agents and client SDKs
+const (
+	TopicTranscription = "lk.transcription"
+	TopicChat          = "lk.chat"
+
+	AttributeTranscribedTrackID = "lk.transcribed_track_id"
+	AttributeSegmentID          = "lk.segment_id"
+	AttributeTranscriptionFinal = "lk.transcription_final"
+)
+
+// IncrementalTextStreamHandler is called when a text stream is received on a topic registered
+// with RegisterIncrementalTextStreamHandler.
+type IncrementalTextStreamHandler func(reader *IncrementalTextReader, participantIdentity string)
+
+type versionedChunk struct {
+	content []byte
+	version int32
+}
+
+// IncrementalTextReader delivers a text stream as it arrives, e.g. the tokens of an LLM response.
+// Unlike TextStreamReader it applies chunks that were replaced by the sender with TextStreamWriter.Replace.
+type IncrementalTextReader struct {
+	Info TextStreamInfo
+
+	senderIdentity string
+
+	lock          sync.Mutex
+	cond          *sync.Cond
+	chunks        []versionedChunk
+	bytesReceived uint64
+	delivered     string
+	replaced      bool
+	closed        bool
+	err           error
+}
+
+func newIncrementalTextReader(info TextStreamInfo, senderIdentity string) *IncrementalTextReader {
+	r := &IncrementalTextReader{
+		Info:           info,
+		senderIdentity: senderIdentity,
+	}
+	r.cond = sync.NewCond(&r.lock)
+	return r
+}
+
+// applies a chunk, returns the number of bytes received so far
+func (r *IncrementalTextReader) enqueue(chunk *protocol.DataStream_Chunk) uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return r.bytesReceived
+	}
+	r.bytesReceived += uint64(len(chunk.Content))
+
+	if chunk.ChunkIndex >= uint64(len(r.chunks)) {
+		r.chunks = append(r.chunks, make([]versionedChunk, chunk.ChunkIndex+1-uint64(len(r.chunks)))...)
+	}
+	if existing := r.chunks[chunk.ChunkIndex]; existing.content != nil && existing.version > chunk.Version {
+		// stale chunk
+		return r.bytesReceived
+	}
+	content := chunk.Content
+	if content == nil {
+		content = []byte{}
+	}
+	r.chunks[chunk.ChunkIndex] = versionedChunk{content: content, version: chunk.Version}
+	r.cond.Broadcast()
+	return r.bytesReceived
+}
+
+func (r *IncrementalTextReader) textLocked() string {
+	var sb strings.Builder
+	for _, c := range r.chunks {
+		sb.Write(c.content)
+	}
+	return sb.String()
+}
+
+// Text returns the text received so far
+func (r *IncrementalTextReader) Text() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.textLocked()
+}
+
+// Next blocks until more text is received or the stream is closed and returns the text appended since the previous call.
+// When text that was already returned is replaced by the sender, delta holds the complete text and Replaced returns true.
+// done is true once the stream is closed and all text has been returned.
+func (r *IncrementalTextReader) Next() (delta string, done bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	text := r.textLocked()
+	for text == r.delivered && !r.closed {
+		r.cond.Wait()
+		text = r.textLocked()
+	}
+
+	if rest, ok := strings.CutPrefix(text, r.delivered); ok {
+		delta = rest
+		r.replaced = false
+	} else {
+		delta = text
+		r.replaced = true
+	}
+	r.delivered = text
+	return delta, r.closed
+}
+
+// Replaced reports whether the text returned by the last call to Next replaced the text returned before
+func (r *IncrementalTextReader) Replaced() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.replaced
+}
+
+// Err returns the error the stream was aborted with, e.g. a *StreamRejectedError, or nil
+func (r *IncrementalTextReader) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.err
+}
+
+func (r *IncrementalTextReader) close() {
+	r.closeWithError(nil)
+}
+
+func (r *IncrementalTextReader) closeWithError(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.closed {
+		r.closed = true
+		r.err = err
+		r.cond.Broadcast()
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrementalTextReader(t *testing.T) {
+	reader := newIncrementalTextReader(TextStreamInfo{baseStreamInfo: &baseStreamInfo{Id: "stream"}}, "p")
+	chunk := func(index uint64, content string, version int32) {
+		reader.enqueue(&livekit.DataStream_Chunk{ChunkIndex: index, Content: []byte(content), Version: version})
+	}
+
+	chunk(0, "Hello", 0)
+	delta, done := reader.Next()
+	require.Equal(t, "Hello", delta)
+	require.False(t, done)
+
+	chunk(1, " wrld", 0)
+	delta, _ = reader.Next()
+	require.Equal(t, " wrld", delta)
+	require.False(t, reader.Replaced())
+
+	// replace the second chunk, then a stale version arrives
+	chunk(1, " world", 1)
+	chunk(1, " wrld", 0)
+	delta, _ = reader.Next()
+	require.Equal(t, "Hello world", delta)
+	require.True(t, reader.Replaced())
+
+	// an empty chunk removes text
+	chunk(2, "!", 1)
+	chunk(2, "", 2)
+	reader.close()
+	delta, done = reader.Next()
+	require.Empty(t, delta)
+	require.True(t, done)
+	require.Equal(t, "Hello world", reader.Text())
+}
+
+func TestBridgeTranscriptionToStreams(t *testing.T) {
+	transcription := &livekit.Transcription{
+		TranscribedParticipantIdentity: "speaker",
+		TrackId:                        "TR_audio",
+		Segments: []*livekit.TranscriptionSegment{
+			{Id: "seg", Text: "hello", Final: true},
+		},
+	}
+
+	received := make(chan *IncrementalTextReader, 1)
+	bridgeTranscriptionToStreams(transcription, IncrementalTextStreamHandler(func(reader *IncrementalTextReader, participantIdentity string) {
+		require.Equal(t, "speaker", participantIdentity)
+		received <- reader
+	}))
+
+	select {
+	case reader := <-received:
+		delta, done := reader.Next()
+		require.Equal(t, "hello", delta)
+		require.True(t, done)
+		require.Equal(t, TopicTranscription, reader.Info.Topic)
+		require.Equal(t, "TR_audio", reader.Info.Attributes[AttributeTranscribedTrackID])
+		require.Equal(t, "true", reader.Info.Attributes[AttributeTranscriptionFinal])
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sync"
+)
+
+// TopicRule accepts packets on matching topics from matching senders
+type TopicRule struct {
+	// Topic is a pattern as accepted by path.Match, e.g. "chat.*". An empty pattern matches packets without topic
+	Topic string
+	// Identities are patterns of accepted sender identities, any sender when empty
+	Identities []string
+	// Kinds of accepted senders, any kind when empty.
+	// Packets of senders whose participant info has not been received match only rules without kinds.
+	Kinds []ParticipantKind
+}
+
+// TopicPolicyStats counts packets checked against a TopicPolicy
+type TopicPolicyStats struct {
+	Accepted uint64
+	Dropped  uint64
+	// DroppedByTopic counts dropped packets per topic
+	DroppedByTopic map[string]uint64
+}
+
+// TopicPolicy declares the topics of user data packets and data streams accepted from remote participants.
+// Packets not matching any rule are dropped before they reach handlers, see Room.SetTopicPolicy.
+type TopicPolicy struct {
+	rules []TopicRule
+
+	lock  sync.Mutex
+	stats TopicPolicyStats
+}
+
+// NewTopicPolicy creates a policy accepting packets that match one of the rules
+func NewTopicPolicy(rules ...TopicRule) (*TopicPolicy, error) {
+	for _, rule := range rules {
+		if _, err := path.Match(rule.Topic, ""); err != nil {
+			return nil, fmt.Errorf("invalid topic pattern %q: %w", rule.Topic, err)
+		}
+		for _, identity := range rule.Identities {
+			if _, err := path.Match(identity, ""); err != nil {
+				return nil, fmt.Errorf("invalid identity pattern %q: %w", identity, err)
+			}
+		}
+	}
+	return &TopicPolicy{
+		rules: slices.Clone(rules),
+		stats: TopicPolicyStats{DroppedByTopic: make(map[string]uint64)},
+	}, nil
+}
+
+// Allow returns whether a packet on topic from sender is accepted, sender is nil when its participant info
+// has not been received. The result is counted in Stats.
+func (p *TopicPolicy) Allow(topic, identity string, sender *RemoteParticipant) bool {
+	allowed := p.matches(topic, identity, sender)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if allowed {
+		p.stats.Accepted++
+	} else {
+		p.stats.Dropped++
+		p.stats.DroppedByTopic[topic]++
+	}
+	return allowed
+}
+
+func (p *TopicPolicy) matches(topic, identity string, sender *RemoteParticipant) bool {
+	for _, rule := range p.rules {
+		if ok, _ := path.Match(rule.Topic, topic); !ok {
+			continue
+		}
+		if len(rule.Identities) > 0 && !slices.ContainsFunc(rule.Identities, func(pattern string) bool {
+			ok, _ := path.Match(pattern, identity)
+			return ok
+		}) {
+			continue
+		}
+		if len(rule.Kinds) > 0 && (sender == nil || !slices.Contains(rule.Kinds, sender.Kind())) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// Stats returns the number of accepted and dropped packets
+func (p *TopicPolicy) Stats() TopicPolicyStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	stats := p.stats
+	stats.DroppedByTopic = maps.Clone(p.stats.DroppedByTopic)
+	return stats
+}
+
+// SetTopicPolicy restricts the topics of user data packets and data streams accepted from remote
+// participants, nil accepts all. Dropped streams are reported with OnDataStreamRejected.
+// Other packets, like RPC or chat messages, are not affected.
+func (r *Room) SetTopicPolicy(policy *TopicPolicy) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.topicPolicy = policy
+}
+
+// allowTopic applies the topic policy to a packet that was received
+func (r *Room) allowTopic(topic, identity string, sender *RemoteParticipant) bool {
+	r.lock.RLock()
+	policy := r.topicPolicy
+	r.lock.RUnlock()
+
+	if policy == nil || policy.Allow(topic, identity, sender) {
+		return true
+	}
+	r.log.Debugw("dropping packet not allowed by topic policy", "topic", topic, "participant", identity)
+	return false
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestTopicPolicy(t *testing.T) {
+	_, err := NewTopicPolicy(TopicRule{Topic: "chat["})
+	require.Error(t, err)
+
+	policy, err := NewTopicPolicy(
+		TopicRule{Topic: "chat.*"},
+		TopicRule{Topic: "control", Identities: []string{"admin-*"}},
+		TopicRule{Topic: "tool", Kinds: []ParticipantKind{ParticipantAgent}},
+	)
+	require.NoError(t, err)
+
+	agent := newRemoteParticipant(&livekit.ParticipantInfo{Identity: "bot", Kind: livekit.ParticipantInfo_AGENT}, NewRoomCallback(), nil, nil, nil, logger)
+
+	require.True(t, policy.Allow("chat.general", "anyone", nil))
+	require.True(t, policy.Allow("control", "admin-1", nil))
+	require.False(t, policy.Allow("control", "user-1", nil))
+	require.True(t, policy.Allow("tool", "bot", agent))
+	// kind is unknown without participant info
+	require.False(t, policy.Allow("tool", "bot", nil))
+	require.False(t, policy.Allow("", "anyone", nil))
+
+	stats := policy.Stats()
+	require.Equal(t, uint64(3), stats.Accepted)
+	require.Equal(t, uint64(3), stats.Dropped)
+	require.Equal(t, map[string]uint64{"control": 1, "tool": 1, "": 1}, stats.DroppedByTopic)
+}
+
+func TestRoomTopicPolicy(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		received []string
+		rejected []string
+	)
+	cb := NewRoomCallback()
+	cb.OnDataPacket = func(data DataPacket, params DataReceiveParams) {
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, params.Topic)
+	}
+	cb.OnDataStreamRejected = func(err *StreamRejectedError, participantIdentity string) {
+		lock.Lock()
+		defer lock.Unlock()
+		rejected = append(rejected, err.Topic)
+	}
+	room := NewRoom(cb)
+	policy, err := NewTopicPolicy(TopicRule{Topic: "allowed"})
+	require.NoError(t, err)
+	room.SetTopicPolicy(policy)
+
+	room.OnParticipantUpdate([]*livekit.ParticipantInfo{{
+		Sid:      "PA_alice",
+		Identity: "alice",
+		State:    livekit.ParticipantInfo_ACTIVE,
+	}})
+	room.OnDataPacket("alice", &UserDataPacket{Topic: "blocked"})
+	room.OnDataPacket("alice", &UserDataPacket{Topic: "allowed"})
+	room.OnStreamHeader(&livekit.DataStream_Header{StreamId: "stream", Topic: "blocked"}, "alice")
+
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) == 1
+	}, time.Second, 10*time.Millisecond)
+	lock.Lock()
+	require.Equal(t, []string{"allowed"}, received)
+	require.Equal(t, []string{"blocked"}, rejected)
+	lock.Unlock()
+	require.Equal(t, uint64(2), policy.Stats().Dropped)
+}
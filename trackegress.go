@@ -0,0 +1,164 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	defaultTrackEgressFollowInterval = 5 * time.Second
+)
+
+// TrackSelector selects a published track by participant identity and source instead of its SID,
+// the SID changes when the participant publishes the track again.
+type TrackSelector struct {
+	Identity string
+	Source   livekit.TrackSource
+}
+
+// TrackResolver finds the SID of a selected track, it is implemented by RoomServiceClient and Room.
+type TrackResolver interface {
+	ResolveTrack(ctx context.Context, roomName string, selector TrackSelector) (string, error)
+}
+
+func findTrackBySource(tracks []*livekit.TrackInfo, source livekit.TrackSource) (string, error) {
+	for _, t := range tracks {
+		if t.Source == source {
+			return t.Sid, nil
+		}
+	}
+	return "", ErrCannotFindTrack
+}
+
+// ResolveTrack returns the SID of the track published by the participant with the given source.
+func (c *RoomServiceClient) ResolveTrack(ctx context.Context, roomName string, selector TrackSelector) (string, error) {
+	p, err := c.GetParticipant(ctx, &livekit.RoomParticipantIdentity{Room: roomName, Identity: selector.Identity})
+	if err != nil {
+		return "", err
+	}
+	return findTrackBySource(p.Tracks, selector.Source)
+}
+
+// ResolveTrack returns the SID of the track published by the participant with the given source,
+// using the state of the connected room. roomName must be empty or match the room.
+func (r *Room) ResolveTrack(_ context.Context, roomName string, selector TrackSelector) (string, error) {
+	if roomName != "" && roomName != r.Name() {
+		return "", ErrCannotFindTrack
+	}
+
+	var p Participant
+	if selector.Identity == r.LocalParticipant.Identity() {
+		p = r.LocalParticipant
+	} else if rp := r.GetParticipantByIdentity(selector.Identity); rp != nil {
+		p = rp
+	} else {
+		return "", ErrCannotFindTrack
+	}
+	for _, pub := range p.TrackPublications() {
+		if pub.Source() == selector.Source {
+			return pub.SID(), nil
+		}
+	}
+	return "", ErrCannotFindTrack
+}
+
+// StartTrackEgressBySelector starts a track egress for the selected track, with TrackId set to the resolved SID.
+// req is not modified.
+func (c *EgressClient) StartTrackEgressBySelector(ctx context.Context, resolver TrackResolver, selector TrackSelector, req *livekit.TrackEgressRequest) (*livekit.EgressInfo, error) {
+	if req == nil {
+		return nil, ErrInvalidParameter
+	}
+	sid, err := resolver.ResolveTrack(ctx, req.RoomName, selector)
+	if err != nil {
+		return nil, err
+	}
+	req = proto.Clone(req).(*livekit.TrackEgressRequest)
+	req.TrackId = sid
+	return c.StartTrackEgress(ctx, req)
+}
+
+// StartTrackCompositeEgressBySelector starts a track composite egress for the selected tracks,
+// audio or video may be nil. AudioTrackId and VideoTrackId are set to the resolved SIDs, req is not modified.
+func (c *EgressClient) StartTrackCompositeEgressBySelector(ctx context.Context, resolver TrackResolver, audio, video *TrackSelector, req *livekit.TrackCompositeEgressRequest) (*livekit.EgressInfo, error) {
+	if req == nil || (audio == nil && video == nil) {
+		return nil, ErrInvalidParameter
+	}
+	req = proto.Clone(req).(*livekit.TrackCompositeEgressRequest)
+	if audio != nil {
+		sid, err := resolver.ResolveTrack(ctx, req.RoomName, *audio)
+		if err != nil {
+			return nil, err
+		}
+		req.AudioTrackId = sid
+	}
+	if video != nil {
+		sid, err := resolver.ResolveTrack(ctx, req.RoomName, *video)
+		if err != nil {
+			return nil, err
+		}
+		req.VideoTrackId = sid
+	}
+	return c.StartTrackCompositeEgress(ctx, req)
+}
+
+// FollowTrackEgress keeps a track egress running for the selected track. The track is resolved every interval,
+// 5s when zero, and a new egress is started when the participant publishes the track again.
+// Started egresses are sent on the returned channel, which is closed when ctx is done.
+func (c *EgressClient) FollowTrackEgress(ctx context.Context, resolver TrackResolver, selector TrackSelector, req *livekit.TrackEgressRequest, interval time.Duration) <-chan *livekit.EgressInfo {
+	if interval <= 0 {
+		interval = defaultTrackEgressFollowInterval
+	}
+	started := make(chan *livekit.EgressInfo, 1)
+
+	go func() {
+		defer close(started)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var current string
+		for {
+			sid, err := resolver.ResolveTrack(ctx, req.RoomName, selector)
+			if err == nil && sid != current {
+				r := proto.Clone(req).(*livekit.TrackEgressRequest)
+				r.TrackId = sid
+				info, err := c.StartTrackEgress(ctx, r)
+				if err != nil {
+					logger.Warnw("could not start track egress", err, "room", req.RoomName, "trackID", sid)
+				} else {
+					current = sid
+					select {
+					case started <- info:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return started
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+type fakeTrackResolver struct {
+	lock sync.Mutex
+	sid  string
+}
+
+func (r *fakeTrackResolver) ResolveTrack(context.Context, string, TrackSelector) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.sid == "" {
+		return "", ErrCannotFindTrack
+	}
+	return r.sid, nil
+}
+
+func (r *fakeTrackResolver) set(sid string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sid = sid
+}
+
+type fakeTrackEgress struct {
+	livekit.Egress
+
+	lock     sync.Mutex
+	trackIDs []string
+}
+
+func (f *fakeTrackEgress) StartTrackEgress(ctx context.Context, req *livekit.TrackEgressRequest) (*livekit.EgressInfo, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.trackIDs = append(f.trackIDs, req.TrackId)
+	return &livekit.EgressInfo{EgressId: "EG_" + req.TrackId, RoomName: req.RoomName}, nil
+}
+
+func newTrackEgressRequest() *livekit.TrackEgressRequest {
+	return &livekit.TrackEgressRequest{
+		RoomName: "room",
+		Output:   &livekit.TrackEgressRequest_WebsocketUrl{WebsocketUrl: "wss://example.com"},
+	}
+}
+
+func TestFindTrackBySource(t *testing.T) {
+	tracks := []*livekit.TrackInfo{
+		{Sid: "TR_mic", Source: livekit.TrackSource_MICROPHONE},
+		{Sid: "TR_camera", Source: livekit.TrackSource_CAMERA},
+	}
+	sid, err := findTrackBySource(tracks, livekit.TrackSource_CAMERA)
+	require.NoError(t, err)
+	require.Equal(t, "TR_camera", sid)
+
+	_, err = findTrackBySource(tracks, livekit.TrackSource_SCREEN_SHARE)
+	require.ErrorIs(t, err, ErrCannotFindTrack)
+	_, err = findTrackBySource(nil, livekit.TrackSource_CAMERA)
+	require.ErrorIs(t, err, ErrCannotFindTrack)
+}
+
+func TestStartTrackEgressBySelector(t *testing.T) {
+	egress := &fakeTrackEgress{}
+	c := &EgressClient{egressClient: egress, authBase: authBase{apiKey: "key", apiSecret: "secret"}}
+
+	req := newTrackEgressRequest()
+	info, err := c.StartTrackEgressBySelector(context.Background(), &fakeTrackResolver{sid: "TR_camera"}, TrackSelector{Identity: "alice"}, req)
+	require.NoError(t, err)
+	require.Equal(t, "EG_TR_camera", info.EgressId)
+	// the request of the caller is left as is
+	require.Empty(t, req.TrackId)
+}
+
+func TestFollowTrackEgress(t *testing.T) {
+	egress := &fakeTrackEgress{}
+	c := &EgressClient{egressClient: egress, authBase: authBase{apiKey: "key", apiSecret: "secret"}}
+	resolver := &fakeTrackResolver{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := c.FollowTrackEgress(ctx, resolver, TrackSelector{Identity: "alice", Source: livekit.TrackSource_CAMERA}, newTrackEgressRequest(), 5*time.Millisecond)
+
+	receive := func() *livekit.EgressInfo {
+		select {
+		case info := <-started:
+			return info
+		case <-time.After(time.Second):
+			t.Fatal("no egress started")
+			return nil
+		}
+	}
+
+	// started once the track is published, and again when it is published with a new SID
+	resolver.set("TR_1")
+	require.Equal(t, "EG_TR_1", receive().EgressId)
+	time.Sleep(20 * time.Millisecond)
+	resolver.set("TR_2")
+	require.Equal(t, "EG_TR_2", receive().EgressId)
+
+	egress.lock.Lock()
+	require.Equal(t, []string{"TR_1", "TR_2"}, egress.trackIDs)
+	egress.lock.Unlock()
+
+	// closed when ctx is done
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-started:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+}
@@ -15,6 +15,9 @@
 package lksdk
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/livekit/protocol/livekit"
 )
 
@@ -45,3 +48,94 @@ func ExtractTranscriptionSegments(transcription *livekit.Transcription) []*Trans
 	}
 	return segments
 }
+
+// transcriptionStreamInfo describes a transcription segment as a text stream
+func transcriptionStreamInfo(transcription *livekit.Transcription, segment *livekit.TranscriptionSegment) TextStreamInfo {
+	return TextStreamInfo{
+		baseStreamInfo: &baseStreamInfo{
+			Id:        segment.Id,
+			MimeType:  "text/plain",
+			Topic:     TopicTranscription,
+			Timestamp: time.Now().UnixMilli(),
+			Attributes: map[string]string{
+				AttributeSegmentID:          segment.Id,
+				AttributeTranscribedTrackID: transcription.TrackId,
+				AttributeTranscriptionFinal: strconv.FormatBool(segment.Final),
+			},
+		},
+		Generated: true,
+	}
+}
+
+// bridgeTranscriptionToStreams passes the segments of a legacy transcription packet to a text stream handler
+func bridgeTranscriptionToStreams(transcription *livekit.Transcription, handler any) {
+	for _, segment := range transcription.Segments {
+		info := transcriptionStreamInfo(transcription, segment)
+		chunk := &livekit.DataStream_Chunk{StreamId: segment.Id, Content: []byte(segment.Text)}
+
+		switch h := handler.(type) {
+		case TextStreamHandler:
+			reader := NewTextStreamReader(info, nil)
+			reader.enqueue(chunk)
+			reader.close()
+			go h(reader, transcription.TranscribedParticipantIdentity)
+		case IncrementalTextStreamHandler:
+			reader := newIncrementalTextReader(info, transcription.TranscribedParticipantIdentity)
+			reader.enqueue(chunk)
+			reader.close()
+			go h(reader, transcription.TranscribedParticipantIdentity)
+		}
+	}
+}
+
+// transcriptionTarget finds the participant and publication of a transcribed track,
+// it falls back to the participant sending the transcription
+func (r *Room) transcriptionTarget(trackID string, senderIdentity string) (Participant, TrackPublication) {
+	if trackID != "" {
+		if pub := r.LocalParticipant.getPublication(trackID); pub != nil {
+			return r.LocalParticipant, pub
+		}
+		for _, rp := range r.GetRemoteParticipants() {
+			if pub := rp.getPublication(trackID); pub != nil {
+				return rp, pub
+			}
+		}
+	}
+	if rp := r.GetParticipantByIdentity(senderIdentity); rp != nil {
+		return rp, nil
+	}
+	return nil, nil
+}
+
+// bridgeStreamToTranscription reads a transcription text stream and passes it to OnTranscriptionReceived,
+// interim results are reported as the text arrives and the final result once the stream is closed
+func (r *Room) bridgeStreamToTranscription(reader *IncrementalTextReader, senderIdentity string) {
+	attrs := reader.Info.Attributes
+	p, publication := r.transcriptionTarget(attrs[AttributeTranscribedTrackID], senderIdentity)
+	if p == nil {
+		r.log.Debugw("received transcription stream from unknown participant", "participant", senderIdentity)
+		return
+	}
+
+	segmentID := attrs[AttributeSegmentID]
+	if segmentID == "" {
+		segmentID = reader.Info.Id
+	}
+	final := attrs[AttributeTranscriptionFinal] != "false"
+
+	for {
+		_, done := reader.Next()
+		if done && reader.Err() != nil {
+			return
+		}
+		segment := &TranscriptionSegment{
+			ID:    segmentID,
+			Text:  reader.Text(),
+			Final: done && final,
+		}
+		r.callback.OnTranscriptionReceived([]*TranscriptionSegment{segment}, p, publication)
+		if done {
+			return
+		}
+	}
+}
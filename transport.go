@@ -24,8 +24,10 @@ import (
 
 	"github.com/bep/debounce"
 	"github.com/pion/dtls/v3"
+	"github.com/pion/ice/v4"
 	"github.com/pion/interceptor"
 	"github.com/pion/interceptor/pkg/nack"
+	"github.com/pion/interceptor/pkg/report"
 	"github.com/pion/interceptor/pkg/twcc"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v4"
@@ -36,11 +38,15 @@ import (
 	"github.com/livekit/protocol/logger/pionlogger"
 	lksdp "github.com/livekit/protocol/sdp"
 	sdkinterceptor "github.com/livekit/server-sdk-go/v2/pkg/interceptor"
+	"github.com/livekit/server-sdk-go/v2/signalling"
 )
 
 const (
 	negotiationFrequency = 20 * time.Millisecond
 
+	// how long publishing waits for removed transceivers to be renegotiated, so they can be re-used
+	transceiverRecycleTimeout = 2 * time.Second
+
 	dtlsRetransmissionInterval = 100 * time.Millisecond
 	iceDisconnectedTimeout     = 10 * time.Second
 	iceFailedTimeout           = 5 * time.Second
@@ -52,6 +58,9 @@ type PCTransport struct {
 	log protoLogger.Logger
 	pc  *webrtc.PeerConnection
 
+	// held for writing while creating an offer, publishers hold it for reading while setting up transceivers
+	transceiverLock sync.RWMutex
+
 	lock                      sync.Mutex
 	pendingCandidates         []webrtc.ICECandidateInit
 	debouncedNegotiate        func(func())
@@ -65,6 +74,8 @@ type PCTransport struct {
 
 	onRemoteDescriptionSettled func() error
 	onRTTUpdate                func(rtt uint32)
+	sdpTransformer             signalling.SDPTransformer
+	bandwidth                  *sdkinterceptor.BandwidthMonitor
 
 	OnOffer func(description webrtc.SessionDescription)
 }
@@ -74,19 +85,62 @@ type PCTransportParams struct {
 	Codecs        []webrtc.RTPCodecParameters
 
 	RetransmitBufferSize uint16
+	RTCP                 signalling.RTCPConfig
+	AudioOnly            bool
 	Pacer                pacer.Factory
 	Interceptors         []interceptor.Factory
 	OnRTTUpdate          func(rtt uint32)
 	IsSender             bool
+	SDPTransformer       signalling.SDPTransformer
+	// receives audio levels of remote audio packets when set
+	AudioLevels *sdkinterceptor.AudioLevelMonitor
+	// receives delays of paced packets when set
+	PacerDelays *sdkinterceptor.PacerDelayMonitor
+	// sends audio packets without pacing, see WithAudioPriority
+	PacerBypassAudio bool
+	// receives payload type changes of remote streams when set
+	PayloadTypes *sdkinterceptor.PayloadTypeMonitor
+	// creates the receive buffers when set
+	ReceiveBuffers *receiveBufferRegistry
+
+	ICEKeepalive signalling.ICEKeepaliveConfig
+	NetworkTypes []webrtc.NetworkType
+	// runs ICE on the sockets of the mux instead of ports of its own when set
+	ICEUDPMux ice.UDPMux
+}
+
+// iceTimeouts returns the disconnected and failed timeouts and keepalive interval to use
+func iceTimeouts(config signalling.ICEKeepaliveConfig) (time.Duration, time.Duration, time.Duration) {
+	disconnected, failed, keepalive := iceDisconnectedTimeout, iceFailedTimeout, iceKeepaliveInterval
+	if config.DisconnectedTimeout > 0 {
+		disconnected = config.DisconnectedTimeout
+	}
+	if config.FailedTimeout > 0 {
+		failed = config.FailedTimeout
+	}
+	if config.Interval > 0 {
+		keepalive = config.Interval
+	}
+	// a keepalive has to be sent before the remote considers the connection disconnected
+	if keepalive >= disconnected {
+		keepalive = disconnected / 2
+	}
+	return disconnected, failed, keepalive
 }
 
 func (t *PCTransport) registerDefaultInterceptors(params PCTransportParams, i *interceptor.Registry) error {
 	if params.Pacer != nil {
-		i.Add(sdkinterceptor.NewPacerInterceptorFactory(params.Pacer))
+		factory := sdkinterceptor.NewPacerInterceptorFactoryWithMonitor(params.Pacer, params.PacerDelays)
+		if params.PacerBypassAudio {
+			factory.BypassAudio()
+		}
+		i.Add(factory)
 	}
 
 	// nack interceptor
-	generator := &sdkinterceptor.NackGeneratorInterceptorFactory{}
+	generator := &sdkinterceptor.NackGeneratorInterceptorFactory{
+		MaxPairsPerPacket: params.RTCP.MaxNACKPairsPerPacket,
+	}
 	var generatorOption []nack.ResponderOption
 	if params.RetransmitBufferSize > 0 {
 		generatorOption = append(generatorOption, nack.ResponderSize(params.RetransmitBufferSize))
@@ -101,12 +155,32 @@ func (t *PCTransport) registerDefaultInterceptors(params PCTransportParams, i *i
 	i.Add(responder)
 
 	// rtcp report interceptor
-	if err := webrtc.ConfigureRTCPReports(i); err != nil {
+	var receiverOptions []report.ReceiverOption
+	if params.RTCP.ReceiverReportInterval > 0 {
+		receiverOptions = append(receiverOptions, report.ReceiverInterval(params.RTCP.ReceiverReportInterval))
+	}
+	receiver, err := report.NewReceiverInterceptor(receiverOptions...)
+	if err != nil {
 		return err
 	}
+	i.Add(receiver)
+
+	var senderOptions []report.SenderOption
+	if params.RTCP.SenderReportInterval > 0 {
+		senderOptions = append(senderOptions, report.SenderInterval(params.RTCP.SenderReportInterval))
+	}
+	sender, err := report.NewSenderInterceptor(senderOptions...)
+	if err != nil {
+		return err
+	}
+	i.Add(sender)
 
 	// twcc interceptor
-	twccGenerator, err := twcc.NewSenderInterceptor()
+	var twccOptions []twcc.Option
+	if params.RTCP.TWCCFeedbackInterval > 0 {
+		twccOptions = append(twccOptions, twcc.SendInterval(params.RTCP.TWCCFeedbackInterval))
+	}
+	twccGenerator, err := twcc.NewSenderInterceptor(twccOptions...)
 	if err != nil {
 		return err
 	}
@@ -130,13 +204,66 @@ func (t *PCTransport) registerDefaultInterceptors(params PCTransportParams, i *i
 	}
 	i.Add(lkinterceptor.NewRTTFromXRFactory(onXRRtt))
 
+	if params.IsSender {
+		// estimates the available outgoing bitrate from the TWCC feedback of the server,
+		// the header extension interceptor numbers packets before they reach the estimator
+		bwe, err := sdkinterceptor.NewSendSideBWEInterceptorFactory(t.bandwidth)
+		if err != nil {
+			return err
+		}
+		i.Add(bwe)
+		twccHeaderExtension, err := twcc.NewHeaderExtensionInterceptor()
+		if err != nil {
+			return err
+		}
+		i.Add(twccHeaderExtension)
+	}
+
 	return nil
 }
 
+// default audio codecs registered by pion
+var defaultAudioCodecs = []webrtc.RTPCodecParameters{
+	{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2, SDPFmtpLine: "minptime=10;useinbandfec=1"},
+		PayloadType:        111,
+	},
+	{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeG722, ClockRate: 8000},
+		PayloadType:        9,
+	},
+	{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMU, ClockRate: 8000},
+		PayloadType:        0,
+	},
+	{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMA, ClockRate: 8000},
+		PayloadType:        8,
+	},
+}
+
+// audioCodecs filters out video codecs, falling back to pion's default audio codecs
+func audioCodecs(codecs []webrtc.RTPCodecParameters) []webrtc.RTPCodecParameters {
+	var filtered []webrtc.RTPCodecParameters
+	for _, codec := range codecs {
+		if strings.HasPrefix(codec.MimeType, "audio/") {
+			filtered = append(filtered, codec)
+		}
+	}
+	if len(filtered) == 0 {
+		return defaultAudioCodecs
+	}
+	return filtered
+}
+
 func NewPCTransport(params PCTransportParams) (*PCTransport, error) {
 	m := &webrtc.MediaEngine{}
-	if len(params.Codecs) > 0 {
-		for _, codec := range params.Codecs {
+	codecs := params.Codecs
+	if params.AudioOnly {
+		codecs = audioCodecs(codecs)
+	}
+	if len(codecs) > 0 {
+		for _, codec := range codecs {
 			codecType := webrtc.RTPCodecTypeAudio
 			if strings.HasPrefix(codec.MimeType, "video/") {
 				codecType = webrtc.RTPCodecTypeVideo
@@ -152,13 +279,15 @@ func NewPCTransport(params PCTransportParams) (*PCTransport, error) {
 	if err := m.RegisterHeaderExtension(audioLevelExtension, webrtc.RTPCodecTypeAudio); err != nil {
 		return nil, err
 	}
-	sdesMidExtension := webrtc.RTPHeaderExtensionCapability{URI: sdp.SDESMidURI}
-	if err := m.RegisterHeaderExtension(sdesMidExtension, webrtc.RTPCodecTypeVideo); err != nil {
-		return nil, err
-	}
-	sdesRtpStreamIdExtension := webrtc.RTPHeaderExtensionCapability{URI: sdp.SDESRTPStreamIDURI}
-	if err := m.RegisterHeaderExtension(sdesRtpStreamIdExtension, webrtc.RTPCodecTypeVideo); err != nil {
-		return nil, err
+	if !params.AudioOnly {
+		sdesMidExtension := webrtc.RTPHeaderExtensionCapability{URI: sdp.SDESMidURI}
+		if err := m.RegisterHeaderExtension(sdesMidExtension, webrtc.RTPCodecTypeVideo); err != nil {
+			return nil, err
+		}
+		sdesRtpStreamIdExtension := webrtc.RTPHeaderExtensionCapability{URI: sdp.SDESRTPStreamIDURI}
+		if err := m.RegisterHeaderExtension(sdesRtpStreamIdExtension, webrtc.RTPCodecTypeVideo); err != nil {
+			return nil, err
+		}
 	}
 
 	i := &interceptor.Registry{}
@@ -166,6 +295,8 @@ func NewPCTransport(params PCTransportParams) (*PCTransport, error) {
 	t := &PCTransport{
 		debouncedNegotiate: debounce.New(negotiationFrequency),
 		onRTTUpdate:        params.OnRTTUpdate,
+		sdpTransformer:     params.SDPTransformer,
+		bandwidth:          sdkinterceptor.NewBandwidthMonitor(),
 	}
 
 	if params.Interceptors != nil {
@@ -178,13 +309,29 @@ func NewPCTransport(params PCTransportParams) (*PCTransport, error) {
 			return nil, err
 		}
 	}
+	// only observes traffic, added with custom interceptors as well
+	i.Add(sdkinterceptor.NewBandwidthInterceptorFactory(t.bandwidth))
+	if params.AudioLevels != nil {
+		i.Add(sdkinterceptor.NewAudioLevelInterceptorFactory(params.AudioLevels))
+	}
+	if params.PayloadTypes != nil {
+		i.Add(sdkinterceptor.NewPayloadTypeInterceptorFactory(params.PayloadTypes))
+	}
 
-	m.RegisterFeedback(webrtc.RTCPFeedback{Type: "nack"}, webrtc.RTPCodecTypeVideo)
-	m.RegisterFeedback(webrtc.RTCPFeedback{Type: "nack", Parameter: "pli"}, webrtc.RTPCodecTypeVideo)
+	// nack generator and responder only act on streams that negotiated nack feedback
+	if params.RTCP.EnableAudioNACK {
+		m.RegisterFeedback(webrtc.RTCPFeedback{Type: "nack"}, webrtc.RTPCodecTypeAudio)
+	}
+	if !params.AudioOnly {
+		if !params.RTCP.DisableVideoNACK {
+			m.RegisterFeedback(webrtc.RTCPFeedback{Type: "nack"}, webrtc.RTPCodecTypeVideo)
+		}
+		m.RegisterFeedback(webrtc.RTCPFeedback{Type: "nack", Parameter: "pli"}, webrtc.RTPCodecTypeVideo)
 
-	m.RegisterFeedback(webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBTransportCC}, webrtc.RTPCodecTypeVideo)
-	if err := m.RegisterHeaderExtension(webrtc.RTPHeaderExtensionCapability{URI: sdp.TransportCCURI}, webrtc.RTPCodecTypeVideo); err != nil {
-		return nil, err
+		m.RegisterFeedback(webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBTransportCC}, webrtc.RTPCodecTypeVideo)
+		if err := m.RegisterHeaderExtension(webrtc.RTPHeaderExtensionCapability{URI: sdp.TransportCCURI}, webrtc.RTPCodecTypeVideo); err != nil {
+			return nil, err
+		}
 	}
 
 	m.RegisterFeedback(webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBTransportCC}, webrtc.RTPCodecTypeAudio)
@@ -195,7 +342,16 @@ func NewPCTransport(params PCTransportParams) (*PCTransport, error) {
 	se := webrtc.SettingEngine{}
 	se.SetSRTPProtectionProfiles(dtls.SRTP_AEAD_AES_128_GCM, dtls.SRTP_AES128_CM_HMAC_SHA1_80)
 	se.SetDTLSRetransmissionInterval(dtlsRetransmissionInterval)
-	se.SetICETimeouts(iceDisconnectedTimeout, iceFailedTimeout, iceKeepaliveInterval)
+	se.SetICETimeouts(iceTimeouts(params.ICEKeepalive))
+	if params.ReceiveBuffers != nil {
+		se.BufferFactory = params.ReceiveBuffers.newBuffer
+	}
+	if len(params.NetworkTypes) != 0 {
+		se.SetNetworkTypes(params.NetworkTypes)
+	}
+	if params.ICEUDPMux != nil {
+		se.SetICEUDPMux(params.ICEUDPMux)
+	}
 	lf := pionlogger.NewLoggerFactory(logger)
 	if lf != nil {
 		se.LoggerFactory = lf
@@ -295,6 +451,8 @@ func (t *PCTransport) setRTT(rtt uint32) {
 }
 
 func (t *PCTransport) SetRemoteDescription(sd webrtc.SessionDescription) error {
+	sd = t.transformSDP(signalling.SDPDirectionIncoming, sd)
+
 	t.lock.Lock()
 
 	var (
@@ -374,6 +532,33 @@ func (t *PCTransport) GetSelectedCandidatePair() (*webrtc.ICECandidatePair, erro
 	return iceTransport.GetSelectedCandidatePair()
 }
 
+// BandwidthMonitor returns the bitrate measurements of this transport
+func (t *PCTransport) BandwidthMonitor() *sdkinterceptor.BandwidthMonitor {
+	return t.bandwidth
+}
+
+// SelectedCandidatePair returns details of the selected candidate pair, nil if none is selected
+func (t *PCTransport) SelectedCandidatePair() *ICECandidatePairInfo {
+	pair, err := t.GetSelectedCandidatePair()
+	if err != nil {
+		return nil
+	}
+	return newICECandidatePairInfo(pair, t.pc)
+}
+
+// OnSelectedCandidatePairChange sets a callback for changes of the selected candidate pair
+func (t *PCTransport) OnSelectedCandidatePairChange(f func(pair *ICECandidatePairInfo)) {
+	sctp := t.pc.SCTP()
+	if sctp == nil || sctp.Transport() == nil || sctp.Transport().ICETransport() == nil {
+		return
+	}
+	sctp.Transport().ICETransport().OnSelectedCandidatePairChange(func(pair *webrtc.ICECandidatePair) {
+		if info := newICECandidatePairInfo(pair, t.pc); info != nil {
+			f(info)
+		}
+	})
+}
+
 func (t *PCTransport) isRemoteOfferRestartICE(sd webrtc.SessionDescription) (string, bool, error) {
 	parsed, err := sd.Unmarshal()
 	if err != nil {
@@ -396,6 +581,76 @@ func (t *PCTransport) Negotiate() {
 	})
 }
 
+// setupTransceivers runs f while no offer is being created, so that an offer never
+// contains a partially configured transceiver. Multiple calls can run concurrently,
+// offers for all of them are batched by Negotiate.
+func (t *PCTransport) setupTransceivers(f func() error) error {
+	t.transceiverLock.RLock()
+	defer t.transceiverLock.RUnlock()
+
+	return f()
+}
+
+// waitForRecyclableTransceiver waits until transceivers of removed tracks are renegotiated.
+// An unused transceiver is only re-used by AddTrack after negotiation, publishing earlier would
+// add another m-line and grow the session description with every unpublish/publish cycle.
+func (t *PCTransport) waitForRecyclableTransceiver(kind webrtc.RTPCodecType) {
+	if !t.hasPendingRecyclableTransceiver(kind) {
+		return
+	}
+
+	t.Negotiate()
+	deadline := time.Now().Add(transceiverRecycleTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		if !t.hasPendingRecyclableTransceiver(kind) {
+			return
+		}
+	}
+	t.log.Debugw("timed out waiting for transceiver to be recycled", "kind", kind)
+}
+
+// hasPendingRecyclableTransceiver returns true when there is no transceiver of the kind
+// free to be re-used, but there are ones waiting for their removal to be negotiated
+func (t *PCTransport) hasPendingRecyclableTransceiver(kind webrtc.RTPCodecType) bool {
+	remote := t.pc.CurrentRemoteDescription()
+	if remote == nil || remote.Type != webrtc.SDPTypeAnswer {
+		return false
+	}
+	// parsed from the SDP, Unmarshal would cache the result in the description pion holds on to
+	parsed := &sdp.SessionDescription{}
+	if err := parsed.UnmarshalString(remote.SDP); err != nil {
+		return false
+	}
+	// m-lines the remote still receives on
+	receiving := make(map[string]bool)
+	for _, md := range parsed.MediaDescriptions {
+		mid, ok := md.Attribute(sdp.AttrKeyMID)
+		if !ok {
+			continue
+		}
+		_, sendRecv := md.Attribute(webrtc.RTPTransceiverDirectionSendrecv.String())
+		_, recvOnly := md.Attribute(webrtc.RTPTransceiverDirectionRecvonly.String())
+		receiving[mid] = sendRecv || recvOnly
+	}
+
+	pending := false
+	for _, tr := range t.pc.GetTransceivers() {
+		if tr.Kind() != kind || tr.Sender() != nil || tr.Mid() == "" {
+			continue
+		}
+		if tr.Direction() != webrtc.RTPTransceiverDirectionRecvonly && tr.Direction() != webrtc.RTPTransceiverDirectionInactive {
+			continue
+		}
+		if !receiving[tr.Mid()] {
+			// free to be re-used
+			return false
+		}
+		pending = true
+	}
+	return pending
+}
+
 func (t *PCTransport) GetLocalOffer() (webrtc.SessionDescription, error) {
 	offer, err := t.pc.CreateOffer(nil)
 	t.log.Debugw("get offer", "offer", offer.SDP)
@@ -417,6 +672,9 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 	if t.OnOffer == nil {
 		return nil
 	}
+	t.transceiverLock.Lock()
+	defer t.transceiverLock.Unlock()
+
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
@@ -440,6 +698,11 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 				if err := t.pc.SetRemoteDescription(*currentSD); err != nil {
 					return err
 				}
+			} else if pending := t.pc.PendingLocalDescription(); pending != nil {
+				// the first offer was never answered and can't be rolled back, it is sent again
+				t.restartAfterGathering = false
+				t.OnOffer(t.transformSDP(signalling.SDPDirectionOutgoing, *pending))
+				return nil
 			}
 		} else {
 			t.renegotiate = true
@@ -459,10 +722,18 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 		return err
 	}
 	t.restartAfterGathering = false
-	t.OnOffer(offer)
+	// pion only applies descriptions as created, the transformed one is only sent
+	t.OnOffer(t.transformSDP(signalling.SDPDirectionOutgoing, offer))
 	return nil
 }
 
+func (t *PCTransport) transformSDP(direction signalling.SDPDirection, sd webrtc.SessionDescription) webrtc.SessionDescription {
+	if t.sdpTransformer == nil {
+		return sd
+	}
+	return t.sdpTransformer(direction, sd)
+}
+
 func (t *PCTransport) SetConfiguration(config webrtc.Configuration) error {
 	return t.pc.SetConfiguration(config)
 }
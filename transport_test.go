@@ -0,0 +1,211 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/server-sdk-go/v2/signalling"
+)
+
+func TestICETimeouts(t *testing.T) {
+	disconnected, failed, keepalive := iceTimeouts(signalling.ICEKeepaliveConfig{})
+	require.Equal(t, iceDisconnectedTimeout, disconnected)
+	require.Equal(t, iceFailedTimeout, failed)
+	require.Equal(t, iceKeepaliveInterval, keepalive)
+
+	disconnected, failed, keepalive = iceTimeouts(signalling.ICEKeepaliveConfig{
+		Interval:            500 * time.Millisecond,
+		DisconnectedTimeout: 30 * time.Second,
+		FailedTimeout:       20 * time.Second,
+	})
+	require.Equal(t, 30*time.Second, disconnected)
+	require.Equal(t, 20*time.Second, failed)
+	require.Equal(t, 500*time.Millisecond, keepalive)
+
+	_, _, keepalive = iceTimeouts(signalling.ICEKeepaliveConfig{
+		Interval:            15 * time.Second,
+		DisconnectedTimeout: 4 * time.Second,
+	})
+	require.Equal(t, 2*time.Second, keepalive)
+}
+
+func TestSetupTransceiversBlocksOffer(t *testing.T) {
+	room := NewRoom(nil)
+	room.engine.connParams = &signalling.ConnectParams{}
+	require.NoError(t, room.engine.configure(nil, nil, nil))
+	t.Cleanup(func() { _ = room.engine.Close(context.Background()) })
+
+	publisher := room.engine.publisher
+	offers := make(chan webrtc.SessionDescription, 1)
+	publisher.OnOffer = func(offer webrtc.SessionDescription) {
+		offers <- offer
+	}
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = publisher.setupTransceivers(func() error {
+			_, err := publisher.PeerConnection().AddTransceiverFromKind(webrtc.RTPCodecTypeAudio)
+			close(entered)
+			<-release
+			return err
+		})
+	}()
+	<-entered
+
+	// other publications set up their transceivers meanwhile
+	require.NoError(t, publisher.setupTransceivers(func() error {
+		_, err := publisher.PeerConnection().AddTransceiverFromKind(webrtc.RTPCodecTypeVideo)
+		return err
+	}))
+
+	// while the offer waits for all of them to complete
+	go func() { _ = publisher.createAndSendOffer(nil) }()
+	select {
+	case <-offers:
+		t.Fatal("offer created during transceiver setup")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case offer := <-offers:
+		require.Contains(t, offer.SDP, "m=audio")
+		require.Contains(t, offer.SDP, "m=video")
+	case <-time.After(time.Second):
+		t.Fatal("offer not created")
+	}
+}
+
+func TestSDPTransformer(t *testing.T) {
+	var directions []SDPDirection
+	room := NewRoom(nil)
+	room.engine.connParams = &signalling.ConnectParams{
+		SDPTransformer: func(direction SDPDirection, sd webrtc.SessionDescription) webrtc.SessionDescription {
+			directions = append(directions, direction)
+			sd.SDP = strings.Replace(sd.SDP, "\r\ns=-\r\n", "\r\ns=transformed\r\n", 1)
+			return sd
+		},
+	}
+	require.NoError(t, room.engine.configure(nil, nil, nil))
+	t.Cleanup(func() { _ = room.engine.Close(context.Background()) })
+
+	publisher := room.engine.publisher
+	offers := make(chan webrtc.SessionDescription, 1)
+	publisher.OnOffer = func(offer webrtc.SessionDescription) {
+		offers <- offer
+	}
+	_, err := publisher.PeerConnection().AddTransceiverFromKind(webrtc.RTPCodecTypeAudio)
+	require.NoError(t, err)
+
+	// outgoing offers are transformed once applied, before they are sent
+	require.NoError(t, publisher.createAndSendOffer(nil))
+	offer := <-offers
+	require.Contains(t, offer.SDP, "s=transformed")
+	require.NotContains(t, publisher.PeerConnection().LocalDescription().SDP, "s=transformed")
+
+	// incoming answers before they are applied
+	remote, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = remote.Close() })
+	require.NoError(t, remote.SetRemoteDescription(offer))
+	answer, err := remote.CreateAnswer(nil)
+	require.NoError(t, err)
+	require.NoError(t, remote.SetLocalDescription(answer))
+	require.NoError(t, publisher.SetRemoteDescription(*remote.LocalDescription()))
+	require.Contains(t, publisher.PeerConnection().RemoteDescription().SDP, "s=transformed")
+
+	require.Equal(t, []SDPDirection{SDPDirectionOutgoing, SDPDirectionIncoming}, directions)
+	require.Equal(t, "INCOMING", SDPDirectionIncoming.String())
+}
+
+func TestRecycleTransceiver(t *testing.T) {
+	room := NewRoom(nil)
+	room.engine.connParams = &signalling.ConnectParams{}
+	require.NoError(t, room.engine.configure(nil, nil, nil))
+	t.Cleanup(func() { _ = room.engine.Close(context.Background()) })
+
+	remote, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = remote.Close() })
+	publisher := room.engine.publisher
+	publisher.OnOffer = func(offer webrtc.SessionDescription) {
+		go func() {
+			require.NoError(t, remote.SetRemoteDescription(offer))
+			answer, err := remote.CreateAnswer(nil)
+			require.NoError(t, err)
+			require.NoError(t, remote.SetLocalDescription(answer))
+			require.NoError(t, publisher.SetRemoteDescription(answer))
+		}()
+	}
+	negotiated := func() bool {
+		return publisher.PeerConnection().SignalingState() == webrtc.SignalingStateStable &&
+			publisher.PeerConnection().CurrentRemoteDescription() != nil
+	}
+
+	newTrack := func(id string) webrtc.TrackLocal {
+		track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, id, "local")
+		require.NoError(t, err)
+		return track
+	}
+	sender, err := publisher.PeerConnection().AddTrack(newTrack("first"))
+	require.NoError(t, err)
+	require.NoError(t, publisher.createAndSendOffer(nil))
+	require.Eventually(t, negotiated, time.Second, 10*time.Millisecond)
+	require.False(t, publisher.hasPendingRecyclableTransceiver(webrtc.RTPCodecTypeAudio))
+
+	// the removed track's transceiver can only be re-used once the removal is negotiated
+	require.NoError(t, publisher.PeerConnection().RemoveTrack(sender))
+	require.True(t, publisher.hasPendingRecyclableTransceiver(webrtc.RTPCodecTypeAudio))
+	require.False(t, publisher.hasPendingRecyclableTransceiver(webrtc.RTPCodecTypeVideo))
+
+	start := time.Now()
+	publisher.waitForRecyclableTransceiver(webrtc.RTPCodecTypeAudio)
+	require.Less(t, time.Since(start), transceiverRecycleTimeout)
+	require.False(t, publisher.hasPendingRecyclableTransceiver(webrtc.RTPCodecTypeAudio))
+
+	// so that publishing again does not add an m-line
+	_, err = publisher.PeerConnection().AddTrack(newTrack("second"))
+	require.NoError(t, err)
+	require.Len(t, publisher.PeerConnection().GetTransceivers(), 1)
+}
+
+func TestAudioOnlyTransport(t *testing.T) {
+	vp8 := webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}, PayloadType: 96}
+	pcmu := webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypePCMU, ClockRate: 8000}, PayloadType: 0}
+	require.Equal(t, []webrtc.RTPCodecParameters{pcmu}, audioCodecs([]webrtc.RTPCodecParameters{vp8, pcmu}))
+	require.Equal(t, defaultAudioCodecs, audioCodecs([]webrtc.RTPCodecParameters{vp8}))
+	require.Equal(t, defaultAudioCodecs, audioCodecs(nil))
+
+	transport, err := NewPCTransport(PCTransportParams{AudioOnly: true, IsSender: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = transport.Close() })
+	_, err = transport.PeerConnection().AddTransceiverFromKind(webrtc.RTPCodecTypeAudio)
+	require.NoError(t, err)
+
+	// no video codecs, feedback or header extensions are negotiated
+	offer, err := transport.PeerConnection().CreateOffer(nil)
+	require.NoError(t, err)
+	require.Contains(t, offer.SDP, "opus/48000")
+	require.NotContains(t, offer.SDP, "VP8")
+	require.NotContains(t, offer.SDP, sdp.SDESRTPStreamIDURI)
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// TransportStats summarizes the WebRTC stats of a PCTransport, see PCTransport.GetStats
+type TransportStats struct {
+	// nil before a candidate pair was selected
+	CandidatePair *CandidatePairStats
+	BytesSent     uint64
+	BytesReceived uint64
+	// RTP streams sent and received on the transport, ordered by SSRC
+	Outbound []OutboundRTPStreamStats
+	Inbound  []InboundRTPStreamStats
+}
+
+// CandidatePairStats are the stats of the selected ICE candidate pair
+type CandidatePairStats struct {
+	ICECandidatePairInfo
+	// round trip time of the last STUN request
+	RTT                      time.Duration
+	AvailableOutgoingBitrate uint64
+	BytesSent                uint64
+	BytesReceived            uint64
+}
+
+// OutboundRTPStreamStats are the stats of an RTP stream sent on a transport
+type OutboundRTPStreamStats struct {
+	SSRC webrtc.SSRC
+	Kind string
+	Mid  string
+	RID  string
+
+	PacketsSent uint32
+	BytesSent   uint64
+	NACKCount   uint32
+	PLICount    uint32
+	FIRCount    uint32
+
+	// as reported by the receiver in RTCP receiver reports, zero until the first report
+	PacketsLost int32
+	Jitter      time.Duration
+	RTT         time.Duration
+}
+
+// InboundRTPStreamStats are the stats of an RTP stream received on a transport
+type InboundRTPStreamStats struct {
+	SSRC    webrtc.SSRC
+	Kind    string
+	Mid     string
+	TrackID string
+
+	PacketsReceived uint32
+	BytesReceived   uint64
+	PacketsLost     int32
+	Jitter          time.Duration
+	// feedback sent for the stream
+	NACKCount uint32
+	PLICount  uint32
+	FIRCount  uint32
+}
+
+// StatsReport holds the stats of each transport, a transport that does not exist is nil.
+// It is delivered periodically when enabled with WithStatsReportInterval.
+type StatsReport struct {
+	Publisher  *TransportStats
+	Subscriber *TransportStats
+}
+
+// GetStats returns a summary of the stats of the peer connection.
+// Use PeerConnection().GetStats() for the full report.
+func (t *PCTransport) GetStats() TransportStats {
+	return newTransportStats(t.pc.GetStats())
+}
+
+// StatsReport returns the stats of each transport
+func (e *RTCEngine) StatsReport() StatsReport {
+	var report StatsReport
+	publisher, hasPublisher := e.Publisher()
+	if hasPublisher {
+		stats := publisher.GetStats()
+		report.Publisher = &stats
+	}
+	if subscriber, ok := e.Subscriber(); ok && (!hasPublisher || subscriber != publisher) {
+		stats := subscriber.GetStats()
+		report.Subscriber = &stats
+	}
+	return report
+}
+
+func (e *RTCEngine) startStatsReportWorker() {
+	interval := e.connParams.StatsReportInterval
+	if interval <= 0 || !e.statsWorkerStarted.CompareAndSwap(false, true) {
+		return
+	}
+
+	e.goroutines.Go("stats-report", func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if e.closed.Load() {
+				return
+			}
+			if e.reconnecting.Load() {
+				continue
+			}
+			e.engineHandler.OnStatsReport(e.StatsReport())
+		}
+	})
+}
+
+func newTransportStats(report webrtc.StatsReport) TransportStats {
+	var (
+		stats          TransportStats
+		selectedPairID string
+		remoteInbound  = make(map[string]webrtc.RemoteInboundRTPStreamStats)
+	)
+	for _, s := range report {
+		switch s := s.(type) {
+		case webrtc.TransportStats:
+			stats.BytesSent += s.BytesSent
+			stats.BytesReceived += s.BytesReceived
+			if s.SelectedCandidatePairID != "" {
+				selectedPairID = s.SelectedCandidatePairID
+			}
+		case webrtc.RemoteInboundRTPStreamStats:
+			remoteInbound[s.LocalID] = s
+		case webrtc.InboundRTPStreamStats:
+			stats.Inbound = append(stats.Inbound, InboundRTPStreamStats{
+				SSRC:            s.SSRC,
+				Kind:            s.Kind,
+				Mid:             s.Mid,
+				TrackID:         s.TrackID,
+				PacketsReceived: s.PacketsReceived,
+				BytesReceived:   s.BytesReceived,
+				PacketsLost:     s.PacketsLost,
+				Jitter:          secondsToDuration(s.Jitter),
+				NACKCount:       s.NACKCount,
+				PLICount:        s.PLICount,
+				FIRCount:        s.FIRCount,
+			})
+		}
+	}
+
+	for _, s := range report {
+		if s, ok := s.(webrtc.OutboundRTPStreamStats); ok {
+			out := OutboundRTPStreamStats{
+				SSRC:        s.SSRC,
+				Kind:        s.Kind,
+				Mid:         s.Mid,
+				RID:         s.Rid,
+				PacketsSent: s.PacketsSent,
+				BytesSent:   s.BytesSent,
+				NACKCount:   s.NACKCount,
+				PLICount:    s.PLICount,
+				FIRCount:    s.FIRCount,
+			}
+			if remote, ok := remoteInbound[s.ID]; ok {
+				out.PacketsLost = remote.PacketsLost
+				out.Jitter = secondsToDuration(remote.Jitter)
+				out.RTT = secondsToDuration(remote.RoundTripTime)
+			}
+			stats.Outbound = append(stats.Outbound, out)
+		}
+	}
+
+	if pair, ok := selectedCandidatePairStats(report, selectedPairID); ok {
+		stats.CandidatePair = &CandidatePairStats{
+			ICECandidatePairInfo: ICECandidatePairInfo{
+				Local:  candidateInfoFromStats(report, pair.LocalCandidateID),
+				Remote: candidateInfoFromStats(report, pair.RemoteCandidateID),
+			},
+			RTT:                      secondsToDuration(pair.CurrentRoundTripTime),
+			AvailableOutgoingBitrate: uint64(pair.AvailableOutgoingBitrate),
+			BytesSent:                pair.BytesSent,
+			BytesReceived:            pair.BytesReceived,
+		}
+	}
+
+	slices.SortFunc(stats.Outbound, func(a, b OutboundRTPStreamStats) int { return cmp.Compare(a.SSRC, b.SSRC) })
+	slices.SortFunc(stats.Inbound, func(a, b InboundRTPStreamStats) int { return cmp.Compare(a.SSRC, b.SSRC) })
+	return stats
+}
+
+// selectedCandidatePairStats returns the pair selected by the transport, or the nominated pair when the
+// transport stats don't name one
+func selectedCandidatePairStats(report webrtc.StatsReport, selectedID string) (webrtc.ICECandidatePairStats, bool) {
+	if selectedID != "" {
+		pair, ok := report[selectedID].(webrtc.ICECandidatePairStats)
+		return pair, ok
+	}
+	for _, s := range report {
+		if pair, ok := s.(webrtc.ICECandidatePairStats); ok && pair.Nominated && pair.State == webrtc.StatsICECandidatePairStateSucceeded {
+			return pair, true
+		}
+	}
+	return webrtc.ICECandidatePairStats{}, false
+}
+
+func candidateInfoFromStats(report webrtc.StatsReport, id string) ICECandidateInfo {
+	c, ok := report[id].(webrtc.ICECandidateStats)
+	if !ok {
+		return ICECandidateInfo{}
+	}
+	protocol, _ := webrtc.NewICEProtocol(c.Protocol)
+	return ICECandidateInfo{
+		Address:       c.IP,
+		Port:          uint16(c.Port),
+		Protocol:      protocol,
+		Type:          c.CandidateType,
+		RelayProtocol: c.RelayProtocol,
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
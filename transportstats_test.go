@@ -0,0 +1,114 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransportStats(t *testing.T) {
+	report := webrtc.StatsReport{
+		"transport": webrtc.TransportStats{
+			ID:                      "transport",
+			BytesSent:               1000,
+			BytesReceived:           2000,
+			SelectedCandidatePairID: "pair-1",
+		},
+		"pair-1": webrtc.ICECandidatePairStats{
+			ID:                       "pair-1",
+			LocalCandidateID:         "local-1",
+			RemoteCandidateID:        "remote-1",
+			CurrentRoundTripTime:     0.05,
+			AvailableOutgoingBitrate: 1_500_000,
+		},
+		// nominated, but not selected by the transport
+		"pair-2": webrtc.ICECandidatePairStats{
+			ID:        "pair-2",
+			State:     webrtc.StatsICECandidatePairStateSucceeded,
+			Nominated: true,
+		},
+		"local-1": webrtc.ICECandidateStats{
+			IP:            "10.0.0.1",
+			Port:          50000,
+			Protocol:      "udp",
+			CandidateType: webrtc.ICECandidateTypeRelay,
+			RelayProtocol: "tls",
+		},
+		"remote-1": webrtc.ICECandidateStats{IP: "192.0.2.1", Port: 7882, Protocol: "udp", CandidateType: webrtc.ICECandidateTypeHost},
+		"out-2":    webrtc.OutboundRTPStreamStats{ID: "out-2", SSRC: 2, Kind: "video", Rid: "f", PacketsSent: 100, NACKCount: 3, PLICount: 1},
+		"out-1":    webrtc.OutboundRTPStreamStats{ID: "out-1", SSRC: 1, Kind: "audio", PacketsSent: 50},
+		"remote-inbound-2": webrtc.RemoteInboundRTPStreamStats{
+			LocalID:       "out-2",
+			PacketsLost:   4,
+			Jitter:        0.002,
+			RoundTripTime: 0.04,
+		},
+		"in-3": webrtc.InboundRTPStreamStats{SSRC: 3, Kind: "video", TrackID: "TR_1", PacketsReceived: 10, PacketsLost: 1, Jitter: 0.001, NACKCount: 2},
+	}
+
+	stats := newTransportStats(report)
+	require.Equal(t, uint64(1000), stats.BytesSent)
+	require.Equal(t, uint64(2000), stats.BytesReceived)
+
+	require.NotNil(t, stats.CandidatePair)
+	require.Equal(t, 50*time.Millisecond, stats.CandidatePair.RTT)
+	require.Equal(t, uint64(1_500_000), stats.CandidatePair.AvailableOutgoingBitrate)
+	require.Equal(t, ICECandidateInfo{
+		Address:       "10.0.0.1",
+		Port:          50000,
+		Protocol:      webrtc.ICEProtocolUDP,
+		Type:          webrtc.ICECandidateTypeRelay,
+		RelayProtocol: "tls",
+	}, stats.CandidatePair.Local)
+	require.True(t, stats.CandidatePair.Local.IsRelay())
+	require.Equal(t, "192.0.2.1", stats.CandidatePair.Remote.Address)
+
+	require.Len(t, stats.Outbound, 2)
+	require.Equal(t, webrtc.SSRC(1), stats.Outbound[0].SSRC)
+	require.Zero(t, stats.Outbound[0].RTT)
+	require.Equal(t, OutboundRTPStreamStats{
+		SSRC:        2,
+		Kind:        "video",
+		RID:         "f",
+		PacketsSent: 100,
+		NACKCount:   3,
+		PLICount:    1,
+		PacketsLost: 4,
+		Jitter:      2 * time.Millisecond,
+		RTT:         40 * time.Millisecond,
+	}, stats.Outbound[1])
+
+	require.Equal(t, []InboundRTPStreamStats{{
+		SSRC:            3,
+		Kind:            "video",
+		TrackID:         "TR_1",
+		PacketsReceived: 10,
+		PacketsLost:     1,
+		Jitter:          time.Millisecond,
+		NACKCount:       2,
+	}}, stats.Inbound)
+
+	// the nominated pair is used without a selected pair
+	delete(report, "transport")
+	stats = newTransportStats(report)
+	require.NotNil(t, stats.CandidatePair)
+	require.Zero(t, stats.CandidatePair.RTT)
+
+	require.Nil(t, newTransportStats(webrtc.StatsReport{}).CandidatePair)
+}
@@ -0,0 +1,239 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+// FieldError describes an invalid field of a request
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when a request fails local validation, it matches ErrInvalidParameter
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidParameter
+}
+
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(field, value string) {
+	if value == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *validator) exclusive(a string, aSet bool, b string, bSet bool) {
+	if aSet && bSet {
+		v.add(a, "cannot be combined with %s", b)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// ValidateRequest checks required fields, mutually exclusive options and value ranges of egress, ingress
+// and SIP dispatch rule requests locally, returning a *ValidationError with all invalid fields.
+// The service clients validate these requests before sending them, other requests are not checked.
+func ValidateRequest(req proto.Message) error {
+	if req == nil || !req.ProtoReflect().IsValid() {
+		return ErrInvalidParameter
+	}
+
+	v := &validator{}
+	switch r := req.(type) {
+	case *livekit.RoomCompositeEgressRequest:
+		v.required("room_name", r.RoomName)
+		v.exclusive("audio_only", r.AudioOnly, "video_only", r.VideoOnly)
+		v.egressOutputs(r.Output != nil, r.FileOutputs, r.StreamOutputs, r.SegmentOutputs, r.ImageOutputs)
+		v.encodingOptions(r.GetAdvanced())
+	case *livekit.WebEgressRequest:
+		v.url("url", r.Url, "http", "https")
+		v.exclusive("audio_only", r.AudioOnly, "video_only", r.VideoOnly)
+		v.egressOutputs(r.Output != nil, r.FileOutputs, r.StreamOutputs, r.SegmentOutputs, r.ImageOutputs)
+		v.encodingOptions(r.GetAdvanced())
+	case *livekit.ParticipantEgressRequest:
+		v.required("room_name", r.RoomName)
+		v.required("identity", r.Identity)
+		v.egressOutputs(false, r.FileOutputs, r.StreamOutputs, r.SegmentOutputs, r.ImageOutputs)
+		v.encodingOptions(r.GetAdvanced())
+	case *livekit.TrackCompositeEgressRequest:
+		v.required("room_name", r.RoomName)
+		if r.AudioTrackId == "" && r.VideoTrackId == "" {
+			v.add("audio_track_id", "audio_track_id or video_track_id is required")
+		}
+		v.egressOutputs(r.Output != nil, r.FileOutputs, r.StreamOutputs, r.SegmentOutputs, r.ImageOutputs)
+		v.encodingOptions(r.GetAdvanced())
+	case *livekit.TrackEgressRequest:
+		v.required("room_name", r.RoomName)
+		v.required("track_id", r.TrackId)
+		if r.Output == nil {
+			v.add("output", "is required")
+		}
+	case *livekit.CreateIngressRequest:
+		v.ingress(r)
+	case *livekit.CreateSIPDispatchRuleRequest:
+		if err := r.Validate(); err != nil {
+			v.add("dispatch_rule", "%s", err.Error())
+		} else {
+			v.dispatchRule(r.DispatchRuleInfo())
+		}
+	case *livekit.UpdateSIPDispatchRuleRequest:
+		if err := r.Validate(); err != nil {
+			v.add("dispatch_rule", "%s", err.Error())
+		} else if replace := r.GetReplace(); replace != nil {
+			v.dispatchRule(replace)
+		}
+	}
+	return v.err()
+}
+
+// url checks that value parses and has a scheme, if schemes are given it has to be one of them
+func (v *validator) url(field, value string, schemes ...string) {
+	if value == "" {
+		v.add(field, "is required")
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.add(field, "is not a valid url")
+		return
+	}
+	if u.Scheme == "" {
+		v.add(field, "must have a scheme")
+		return
+	}
+	if len(schemes) == 0 {
+		return
+	}
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			return
+		}
+	}
+	v.add(field, "must use one of the schemes %s", strings.Join(schemes, ", "))
+}
+
+func (v *validator) egressOutputs(legacyOutput bool, files []*livekit.EncodedFileOutput, streams []*livekit.StreamOutput, segments []*livekit.SegmentedFileOutput, images []*livekit.ImageOutput) {
+	if !legacyOutput && len(files)+len(streams)+len(segments)+len(images) == 0 {
+		v.add("outputs", "at least one output is required")
+	}
+	for i, s := range streams {
+		field := fmt.Sprintf("stream_outputs[%d].urls", i)
+		if len(s.Urls) == 0 {
+			v.add(field, "at least one url is required")
+		}
+		for j, u := range s.Urls {
+			if u == "" {
+				v.add(fmt.Sprintf("%s[%d]", field, j), "must not be empty")
+			}
+		}
+	}
+}
+
+func (v *validator) encodingOptions(opts *livekit.EncodingOptions) {
+	if opts == nil {
+		return
+	}
+	if (opts.Width == 0) != (opts.Height == 0) {
+		v.add("advanced.width", "width and height must be set together")
+	}
+	for _, f := range []struct {
+		field string
+		value int32
+	}{
+		{"advanced.width", opts.Width},
+		{"advanced.height", opts.Height},
+		{"advanced.depth", opts.Depth},
+		{"advanced.framerate", opts.Framerate},
+		{"advanced.audio_bitrate", opts.AudioBitrate},
+		{"advanced.video_bitrate", opts.VideoBitrate},
+	} {
+		if f.value < 0 {
+			v.add(f.field, "must not be negative")
+		}
+	}
+	if opts.KeyFrameInterval < 0 {
+		v.add("advanced.key_frame_interval", "must not be negative")
+	}
+}
+
+func (v *validator) ingress(r *livekit.CreateIngressRequest) {
+	if r.InputType == livekit.IngressInput_URL_INPUT {
+		// supported input schemes are up to the server
+		v.url("url", r.Url)
+	}
+	transcodingDisabled := r.BypassTranscoding || (r.EnableTranscoding != nil && !*r.EnableTranscoding)
+	v.exclusive("bypass_transcoding", r.BypassTranscoding, "enable_transcoding", r.EnableTranscoding != nil && *r.EnableTranscoding)
+	if transcodingDisabled {
+		if r.Audio.GetEncodingOptions() != nil {
+			v.add("audio", "encoding options require transcoding")
+		}
+		if r.Video.GetEncodingOptions() != nil {
+			v.add("video", "encoding options require transcoding")
+		}
+	}
+	if opts := r.Video.GetOptions(); opts != nil {
+		if len(opts.Layers) > 3 {
+			v.add("video.options.layers", "at most 3 layers are supported")
+		}
+		if opts.FrameRate < 0 {
+			v.add("video.options.frame_rate", "must not be negative")
+		}
+	}
+}
+
+func (v *validator) dispatchRule(info *livekit.SIPDispatchRuleInfo) {
+	switch rule := info.GetRule().GetRule().(type) {
+	case nil:
+		v.add("rule", "is required")
+	case *livekit.SIPDispatchRule_DispatchRuleDirect:
+		v.required("rule.dispatch_rule_direct.room_name", rule.DispatchRuleDirect.GetRoomName())
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRequest(t *testing.T) {
+	err := ValidateRequest(&livekit.RoomCompositeEgressRequest{
+		AudioOnly: true,
+		VideoOnly: true,
+		StreamOutputs: []*livekit.StreamOutput{
+			{Protocol: livekit.StreamProtocol_RTMP},
+		},
+		Options: &livekit.RoomCompositeEgressRequest_Advanced{
+			Advanced: &livekit.EncodingOptions{Width: 1280},
+		},
+	})
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.ErrorIs(t, err, ErrInvalidParameter)
+	require.Equal(t, []FieldError{
+		{Field: "room_name", Message: "is required"},
+		{Field: "audio_only", Message: "cannot be combined with video_only"},
+		{Field: "stream_outputs[0].urls", Message: "at least one url is required"},
+		{Field: "advanced.width", Message: "width and height must be set together"},
+	}, verr.Fields)
+
+	require.NoError(t, ValidateRequest(&livekit.RoomCompositeEgressRequest{
+		RoomName:    "room",
+		FileOutputs: []*livekit.EncodedFileOutput{{Filepath: "out.mp4"}},
+	}))
+
+	enabled := false
+	err = ValidateRequest(&livekit.CreateIngressRequest{
+		InputType:         livekit.IngressInput_WHIP_INPUT,
+		EnableTranscoding: &enabled,
+		Video: &livekit.IngressVideoOptions{
+			EncodingOptions: &livekit.IngressVideoOptions_Preset{},
+		},
+	})
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, "video", verr.Fields[0].Field)
+
+	// any scheme is left to the server, but there has to be one
+	require.NoError(t, ValidateRequest(&livekit.CreateIngressRequest{
+		InputType: livekit.IngressInput_URL_INPUT,
+		Url:       "rtsp://camera.local/stream",
+	}))
+	err = ValidateRequest(&livekit.CreateIngressRequest{
+		InputType: livekit.IngressInput_URL_INPUT,
+		Url:       "camera.local/stream",
+	})
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []FieldError{{Field: "url", Message: "must have a scheme"}}, verr.Fields)
+
+	err = ValidateRequest(&livekit.CreateSIPDispatchRuleRequest{
+		DispatchRule: &livekit.SIPDispatchRuleInfo{
+			Rule: &livekit.SIPDispatchRule{
+				Rule: &livekit.SIPDispatchRule_DispatchRuleDirect{DispatchRuleDirect: &livekit.SIPDispatchRuleDirect{}},
+			},
+		},
+	})
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, "rule.dispatch_rule_direct.room_name", verr.Fields[0].Field)
+
+	require.ErrorIs(t, ValidateRequest((*livekit.TrackEgressRequest)(nil)), ErrInvalidParameter)
+}
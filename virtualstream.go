@@ -0,0 +1,436 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// VirtualStreamTopic carries the frames of virtual streams, packets on it are not passed to data callbacks.
+const VirtualStreamTopic = "lk.vstream"
+
+const (
+	// bytes a stream may send before the receiver read them
+	virtualStreamWindow = 64 * 1024
+	// credit is returned to the sender after reading this much
+	virtualStreamCreditThreshold = virtualStreamWindow / 4
+)
+
+// frame types, the first byte of a frame also has virtualStreamFromOpener set when sent by the side that
+// opened the stream. It is followed by the stream ID and the payload.
+const (
+	virtualStreamOpen   byte = 1 // payload is the name
+	virtualStreamData   byte = 2
+	virtualStreamCredit byte = 3 // payload is the number of bytes read, uint32
+	virtualStreamClose  byte = 4 // payload is virtualStreamRejected when there was no handler
+
+	virtualStreamFromOpener byte = 0x80
+	virtualStreamTypeMask   byte = 0x7f
+	virtualStreamRejected   byte = 1
+
+	virtualStreamHeaderSize = 5
+)
+
+// VirtualStreamHandler is called with streams opened by remote participants
+type VirtualStreamHandler func(stream *VirtualStream)
+
+type virtualStreamKey struct {
+	identity string
+	id       uint32
+	// set for streams opened by the local participant, IDs of both sides are independent
+	local bool
+}
+
+// virtualStreamMux multiplexes virtual streams over reliable user packets
+type virtualStreamMux struct {
+	send func(identity string, frame []byte) error
+
+	lock     sync.Mutex
+	nextID   uint32
+	streams  map[virtualStreamKey]*VirtualStream
+	handlers map[string]VirtualStreamHandler
+}
+
+// VirtualStream is a bidirectional byte stream with a remote participant, multiplexed with other streams over
+// the reliable data channel. Each direction is flow controlled: a writer blocks once 64KiB were sent that the
+// receiver did not read yet, so that a stream that is not read cannot hold back the others.
+// Close must be called when done.
+type VirtualStream struct {
+	mux  *virtualStreamMux
+	key  virtualStreamKey
+	name string
+
+	lock sync.Mutex
+	// closed and replaced when the state changes
+	changed  chan struct{}
+	buf      []byte
+	credit   int
+	consumed int
+	// the remote side closed the stream, reads return io.EOF once buf is drained
+	remoteClosed bool
+	closed       bool
+	err          error
+}
+
+func newVirtualStreamMux(send func(identity string, frame []byte) error) *virtualStreamMux {
+	return &virtualStreamMux{
+		send:     send,
+		streams:  make(map[virtualStreamKey]*VirtualStream),
+		handlers: make(map[string]VirtualStreamHandler),
+	}
+}
+
+func (m *virtualStreamMux) register(name string, handler VirtualStreamHandler) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.handlers[name]; ok {
+		return fmt.Errorf("virtual stream handler already registered for name: %s", name)
+	}
+	m.handlers[name] = handler
+	return nil
+}
+
+func (m *virtualStreamMux) unregister(name string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.handlers, name)
+}
+
+func (m *virtualStreamMux) newStreamLocked(key virtualStreamKey, name string) *VirtualStream {
+	s := &VirtualStream{
+		mux:     m,
+		key:     key,
+		name:    name,
+		changed: make(chan struct{}),
+		credit:  virtualStreamWindow,
+	}
+	m.streams[key] = s
+	return s
+}
+
+func (m *virtualStreamMux) open(identity, name string) (*VirtualStream, error) {
+	m.lock.Lock()
+	m.nextID++
+	s := m.newStreamLocked(virtualStreamKey{identity: identity, id: m.nextID, local: true}, name)
+	m.lock.Unlock()
+
+	if err := s.sendFrame(virtualStreamOpen, []byte(name)); err != nil {
+		s.abort(err)
+		return nil, err
+	}
+	return s, nil
+}
+
+func (m *virtualStreamMux) remove(key virtualStreamKey) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.streams, key)
+}
+
+// handleFrame processes a frame received from identity
+func (m *virtualStreamMux) handleFrame(identity string, frame []byte) {
+	if len(frame) < virtualStreamHeaderSize {
+		return
+	}
+	key := virtualStreamKey{
+		identity: identity,
+		id:       binary.BigEndian.Uint32(frame[1:virtualStreamHeaderSize]),
+		local:    frame[0]&virtualStreamFromOpener == 0,
+	}
+	typ, payload := frame[0]&virtualStreamTypeMask, frame[virtualStreamHeaderSize:]
+
+	m.lock.Lock()
+	s := m.streams[key]
+	if typ == virtualStreamOpen && s == nil && !key.local {
+		handler := m.handlers[string(payload)]
+		if handler == nil {
+			m.lock.Unlock()
+			reject := &VirtualStream{mux: m, key: key}
+			_ = reject.sendFrame(virtualStreamClose, []byte{virtualStreamRejected})
+			return
+		}
+		s = m.newStreamLocked(key, string(payload))
+		m.lock.Unlock()
+		go handler(s)
+		return
+	}
+	m.lock.Unlock()
+	if s == nil {
+		return
+	}
+
+	switch typ {
+	case virtualStreamData:
+		s.receive(payload)
+	case virtualStreamCredit:
+		if len(payload) == 4 {
+			s.addCredit(int(binary.BigEndian.Uint32(payload)))
+		}
+	case virtualStreamClose:
+		var err error
+		if len(payload) > 0 && payload[0] == virtualStreamRejected {
+			err = ErrVirtualStreamRejected
+		}
+		s.remoteClose(err)
+	}
+}
+
+// closeParticipant aborts the streams with identity, or all streams when identity is empty
+func (m *virtualStreamMux) closeParticipant(identity string) {
+	m.lock.Lock()
+	var streams []*VirtualStream
+	for key, s := range m.streams {
+		if identity == "" || key.identity == identity {
+			streams = append(streams, s)
+		}
+	}
+	m.lock.Unlock()
+
+	for _, s := range streams {
+		s.abort(ErrStreamAborted)
+	}
+}
+
+// Name returns the name the stream was opened with
+func (s *VirtualStream) Name() string {
+	return s.name
+}
+
+// RemoteIdentity returns the identity of the participant at the other end of the stream
+func (s *VirtualStream) RemoteIdentity() string {
+	return s.key.identity
+}
+
+// Read reads data written by the remote participant, it returns io.EOF once the remote side closed the stream
+func (s *VirtualStream) Read(p []byte) (int, error) {
+	return s.ReadContext(context.Background(), p)
+}
+
+// ReadContext is Read that returns ctx.Err() when ctx is done before data arrived
+func (s *VirtualStream) ReadContext(ctx context.Context, p []byte) (int, error) {
+	for {
+		s.lock.Lock()
+		if s.closed {
+			err := s.errLocked()
+			s.lock.Unlock()
+			return 0, err
+		}
+		if len(s.buf) > 0 {
+			n := copy(p, s.buf)
+			s.buf = s.buf[n:]
+			s.consumed += n
+			var grant int
+			if s.consumed >= virtualStreamCreditThreshold && !s.remoteClosed {
+				grant, s.consumed = s.consumed, 0
+			}
+			s.lock.Unlock()
+
+			if grant > 0 {
+				credit := binary.BigEndian.AppendUint32(nil, uint32(grant))
+				if err := s.sendFrame(virtualStreamCredit, credit); err != nil {
+					return n, err
+				}
+			}
+			return n, nil
+		}
+		if s.remoteClosed {
+			err := s.err
+			s.lock.Unlock()
+			if err == nil {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		changed := s.changed
+		s.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// Write sends p to the remote participant, blocking while the remote participant did not read enough of the
+// data sent before
+func (s *VirtualStream) Write(p []byte) (int, error) {
+	return s.WriteContext(context.Background(), p)
+}
+
+// WriteContext is Write that returns ctx.Err() when ctx is done before all of p was sent
+func (s *VirtualStream) WriteContext(ctx context.Context, p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		s.lock.Lock()
+		if s.closed || s.remoteClosed {
+			err := s.errLocked()
+			s.lock.Unlock()
+			return written, err
+		}
+		if s.credit == 0 {
+			changed := s.changed
+			s.lock.Unlock()
+
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				return written, ctx.Err()
+			}
+		}
+		n := min(len(p), s.credit, STREAM_CHUNK_SIZE)
+		s.credit -= n
+		s.lock.Unlock()
+
+		if err := s.sendFrame(virtualStreamData, p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close closes both directions of the stream, the remote participant reads io.EOF after the data written before
+func (s *VirtualStream) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	aborted := s.err != nil
+	s.notifyLocked()
+	s.lock.Unlock()
+
+	s.mux.remove(s.key)
+	if aborted {
+		return nil
+	}
+	return s.sendFrame(virtualStreamClose, nil)
+}
+
+func (s *VirtualStream) errLocked() error {
+	if s.err != nil {
+		return s.err
+	}
+	return io.ErrClosedPipe
+}
+
+func (s *VirtualStream) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *VirtualStream) sendFrame(typ byte, payload []byte) error {
+	if s.key.local {
+		typ |= virtualStreamFromOpener
+	}
+	frame := make([]byte, virtualStreamHeaderSize, virtualStreamHeaderSize+len(payload))
+	frame[0] = typ
+	binary.BigEndian.PutUint32(frame[1:], s.key.id)
+	return s.mux.send(s.key.identity, append(frame, payload...))
+}
+
+func (s *VirtualStream) receive(data []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed || s.remoteClosed {
+		return
+	}
+	s.buf = append(s.buf, data...)
+	s.notifyLocked()
+}
+
+func (s *VirtualStream) addCredit(n int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.credit += n
+	s.notifyLocked()
+}
+
+func (s *VirtualStream) remoteClose(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.remoteClosed {
+		return
+	}
+	s.remoteClosed = true
+	s.err = err
+	s.notifyLocked()
+}
+
+// abort fails pending and later reads and writes with err, the remote side is not notified
+func (s *VirtualStream) abort(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.remoteClosed = true
+	if s.err == nil {
+		s.err = err
+	}
+	s.buf = nil
+	s.notifyLocked()
+	s.mux.remove(s.key)
+}
+
+// RegisterVirtualStreamHandler registers a handler for virtual streams opened with name by remote participants.
+// It returns an error if a handler is already registered for this name. Streams without a handler are rejected.
+func (r *Room) RegisterVirtualStreamHandler(name string, handler VirtualStreamHandler) error {
+	return r.virtualStreams.register(name, handler)
+}
+
+// UnregisterVirtualStreamHandler removes a previously registered virtual stream handler.
+func (r *Room) UnregisterVirtualStreamHandler(name string) {
+	r.virtualStreams.unregister(name)
+}
+
+// OpenVirtualStream opens a stream with a participant that registered a handler for name, see VirtualStream.
+// Writes fail with ErrVirtualStreamRejected if it did not. Streams can be written right away.
+func (r *Room) OpenVirtualStream(identity string, name string) (*VirtualStream, error) {
+	return r.virtualStreams.open(identity, name)
+}
+
+func (r *Room) sendVirtualStreamFrame(identity string, frame []byte) error {
+	return r.LocalParticipant.PublishDataPacket(
+		UserData(frame),
+		WithDataPublishTopic(VirtualStreamTopic),
+		WithDataPublishReliable(true),
+		WithDataPublishDestination([]string{identity}),
+	)
+}
+
+// handleVirtualStream passes frames to their streams, returns true if the packet was on VirtualStreamTopic
+func (r *Room) handleVirtualStream(identity string, dataPacket DataPacket) bool {
+	user, ok := dataPacket.(*UserDataPacket)
+	if !ok || user.Topic != VirtualStreamTopic {
+		return false
+	}
+	if identity != "" {
+		r.virtualStreams.handleFrame(identity, user.Payload)
+	}
+	return true
+}
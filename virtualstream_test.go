@@ -0,0 +1,151 @@
+// Copyright 2023 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lksdk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// connectedVirtualStreamMuxes returns muxes of alice and bob that deliver frames to each other
+func connectedVirtualStreamMuxes() (alice, bob *virtualStreamMux) {
+	alice = newVirtualStreamMux(func(identity string, frame []byte) error {
+		bob.handleFrame("alice", frame)
+		return nil
+	})
+	bob = newVirtualStreamMux(func(identity string, frame []byte) error {
+		alice.handleFrame("bob", frame)
+		return nil
+	})
+	return alice, bob
+}
+
+func TestVirtualStream(t *testing.T) {
+	alice, bob := connectedVirtualStreamMuxes()
+	accepted := make(chan *VirtualStream, 4)
+	require.NoError(t, bob.register("echo", func(stream *VirtualStream) { accepted <- stream }))
+	require.Error(t, bob.register("echo", nil))
+
+	s, err := alice.open("bob", "echo")
+	require.NoError(t, err)
+	_, err = s.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	remote := <-accepted
+	require.Equal(t, "echo", remote.Name())
+	require.Equal(t, "alice", remote.RemoteIdentity())
+	buf := make([]byte, 16)
+	n, err := remote.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(buf[:n]))
+
+	// both directions
+	_, err = remote.Write([]byte("world"))
+	require.NoError(t, err)
+	n, err = s.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "world", string(buf[:n]))
+
+	require.NoError(t, s.Close())
+	_, err = remote.Read(buf)
+	require.ErrorIs(t, err, io.EOF)
+	_, err = remote.Write([]byte("late"))
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+	require.NoError(t, remote.Close())
+	require.Empty(t, alice.streams)
+	require.Empty(t, bob.streams)
+}
+
+func TestVirtualStreamFlowControl(t *testing.T) {
+	alice, bob := connectedVirtualStreamMuxes()
+	accepted := make(chan *VirtualStream, 4)
+	require.NoError(t, bob.register("bulk", func(stream *VirtualStream) { accepted <- stream }))
+
+	slow, err := alice.open("bob", "bulk")
+	require.NoError(t, err)
+	data := bytes.Repeat([]byte{1}, 2*virtualStreamWindow)
+	written := make(chan error, 1)
+	go func() {
+		_, err := slow.Write(data)
+		written <- err
+	}()
+	slowRemote := <-accepted
+
+	// the writer blocks once the window is used up
+	require.Eventually(t, func() bool {
+		slowRemote.lock.Lock()
+		defer slowRemote.lock.Unlock()
+		return len(slowRemote.buf) == virtualStreamWindow
+	}, time.Second, time.Millisecond)
+	select {
+	case <-written:
+		t.Fatal("write did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	n, err := slow.WriteContext(ctx, []byte{2})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Zero(t, n)
+
+	// other streams are not held back
+	fast, err := alice.open("bob", "bulk")
+	require.NoError(t, err)
+	_, err = fast.Write([]byte("fast"))
+	require.NoError(t, err)
+	fastRemote := <-accepted
+	buf := make([]byte, 16)
+	n, err = fastRemote.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "fast", string(buf[:n]))
+
+	// reading returns credit to the writer
+	received, err := io.ReadAll(io.LimitReader(slowRemote, int64(len(data))))
+	require.NoError(t, err)
+	require.Equal(t, data, received)
+	require.NoError(t, <-written)
+}
+
+func TestVirtualStreamRejectAndAbort(t *testing.T) {
+	alice, bob := connectedVirtualStreamMuxes()
+
+	s, err := alice.open("bob", "missing")
+	require.NoError(t, err)
+	_, err = s.Write([]byte("hello"))
+	require.ErrorIs(t, err, ErrVirtualStreamRejected)
+	_, err = s.Read(make([]byte, 1))
+	require.ErrorIs(t, err, ErrVirtualStreamRejected)
+	require.NoError(t, s.Close())
+
+	accepted := make(chan *VirtualStream, 1)
+	require.NoError(t, bob.register("echo", func(stream *VirtualStream) { accepted <- stream }))
+	s, err = alice.open("bob", "echo")
+	require.NoError(t, err)
+	<-accepted
+
+	read := make(chan error, 1)
+	go func() {
+		_, err := s.Read(make([]byte, 1))
+		read <- err
+	}()
+	alice.closeParticipant("bob")
+	require.ErrorIs(t, <-read, ErrStreamAborted)
+	require.Empty(t, alice.streams)
+}